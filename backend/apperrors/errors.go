@@ -0,0 +1,141 @@
+// Package apperrors defines the application's typed errors and renders them as the JSON body
+// every endpoint responds to a failed request with:
+//
+//	{"code": "not_found", "message": "Session not found", "details": ..., "request_id": "..."}
+//
+// code is stable for clients to branch on; message is for people and may change. details is
+// present when the error carries structured data, such as the fields that failed validation.
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// Code identifies a kind of error in a response body
+type Code string
+
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodeConflict         Code = "conflict"
+	CodeGone             Code = "gone"
+	CodePayloadTooLarge  Code = "payload_too_large"
+	CodeUnprocessable    Code = "unprocessable"
+	CodeValidation       Code = "validation_failed"
+	CodeTermsRequired    Code = "terms_acceptance_required"
+	CodeRateLimited      Code = "rate_limited"
+	CodeInternal         Code = "internal_error"
+)
+
+// statusCodes is the code of an error created for a status
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusGone:                  CodeGone,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnprocessableEntity:   CodeUnprocessable,
+	http.StatusPreconditionRequired:  CodeTermsRequired,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternal,
+}
+
+// Error is an error with the HTTP status and body it is rendered as. Services return them for
+// failures the client caused, so handlers can pass them straight to Write.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithDetails returns a copy of the error carrying structured details
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// WithCode returns a copy of the error with a more specific code than its status's
+func (e *Error) WithCode(code Code) *Error {
+	copied := *e
+	copied.Code = code
+	return &copied
+}
+
+// New returns an error rendered with the status, its code and the message
+func New(status int, message string) *Error {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeInternal
+		if status < http.StatusInternalServerError {
+			code = CodeBadRequest
+		}
+	}
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error      { return New(http.StatusBadRequest, message) }
+func Unauthorized(message string) *Error    { return New(http.StatusUnauthorized, message) }
+func Forbidden(message string) *Error       { return New(http.StatusForbidden, message) }
+func NotFound(message string) *Error        { return New(http.StatusNotFound, message) }
+func Conflict(message string) *Error        { return New(http.StatusConflict, message) }
+func Gone(message string) *Error            { return New(http.StatusGone, message) }
+func TooLarge(message string) *Error        { return New(http.StatusRequestEntityTooLarge, message) }
+func Unprocessable(message string) *Error   { return New(http.StatusUnprocessableEntity, message) }
+func TooManyRequests(message string) *Error { return New(http.StatusTooManyRequests, message) }
+func Internal(message string) *Error        { return New(http.StatusInternalServerError, message) }
+
+// Body is the JSON body of an error response
+type Body struct {
+	Code      Code        `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Write renders err as the response. An error that isn't an *Error is logged and rendered as an
+// internal error, so its text never reaches the client.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		logging.FromContext(r.Context()).Error("Unhandled error", "error", err)
+		appErr = Internal("Internal server error")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Del("Content-Disposition")
+	w.WriteHeader(appErr.Status)
+	json.NewEncoder(w).Encode(Body{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Details:   appErr.Details,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+}
+
+// NotFoundHandler renders unknown routes as not found errors
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, NotFound("Not found"))
+}
+
+// MethodNotAllowedHandler renders requests with a method a route doesn't serve
+func MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, New(http.StatusMethodNotAllowed, "Method not allowed"))
+}
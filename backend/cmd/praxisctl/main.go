@@ -0,0 +1,253 @@
+// Command praxisctl is the operators' tool for a Praxis deployment. It reads the same
+// configuration as the server and works through the same repository, so it needs no direct
+// database access: create users, promote admins, reseed agents, migrate, purge old sessions
+// and print the configuration.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	"github.com/krshsl/praxis/backend/services"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func main() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	root := &cobra.Command{
+		Use:           "praxisctl",
+		Short:         "Manage a Praxis deployment",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.AddCommand(userCommand(), agentsCommand(), migrateCommand(), purgeCommand(), configCommand())
+
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// connect loads the configuration and its secrets and opens the repository
+func connect(ctx context.Context) (*services.Config, *repository.GORMRepository, error) {
+	config := services.LoadConfig()
+	secrets, err := services.LoadSecrets(ctx, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load secrets: %w", err)
+	}
+	if config.Database.URL == "" {
+		return nil, nil, fmt.Errorf("database is required: set DATABASE_URL")
+	}
+	db, _, err := services.ConnectDatabase(config.Database, secrets)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return config, repository.NewGORMRepository(db), nil
+}
+
+func userCommand() *cobra.Command {
+	user := &cobra.Command{
+		Use:   "user",
+		Short: "Create users and change their roles",
+	}
+
+	var email, name string
+	var admin bool
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user; the password is read from stdin",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			password, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if err != nil && password == "" {
+				return fmt.Errorf("failed to read the password from stdin: %w", err)
+			}
+			password = strings.TrimRight(password, "\r\n")
+			errs, err := services.ValidateStruct(services.SignupRequest{Email: email, Password: password, FullName: name})
+			if err != nil {
+				return err
+			}
+			if errs != nil {
+				return errs
+			}
+
+			_, repo, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			existing, err := repo.GetUserByEmail(cmd.Context(), email)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return fmt.Errorf("a user with email %s already exists", email)
+			}
+
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return fmt.Errorf("failed to hash password: %w", err)
+			}
+			created := &models.User{Email: email, Password: string(hash), FullName: name, Role: "user"}
+			if admin {
+				created.Role = "admin"
+			}
+			if err := repo.CreateUser(cmd.Context(), created); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Created %s %s (%s)\n", created.Role, created.Email, created.ID)
+			return nil
+		},
+	}
+	create.Flags().StringVar(&email, "email", "", "email address to sign in with")
+	create.Flags().StringVar(&name, "name", "", "full name")
+	create.Flags().BoolVar(&admin, "admin", false, "create the user as an admin")
+	create.MarkFlagRequired("email")
+
+	user.AddCommand(create, roleCommand("promote", "admin"), roleCommand("demote", "user"))
+	return user
+}
+
+// roleCommand sets the role of the user with an email
+func roleCommand(use, role string) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " EMAIL",
+		Short: fmt.Sprintf("Make a user %s", map[string]string{"admin": "an admin", "user": "a regular user"}[role]),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, repo, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			user, err := repo.GetUserByEmail(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if user == nil {
+				return fmt.Errorf("no user with email %s", args[0])
+			}
+			if user.Role == role {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s is already %s\n", user.Email, role)
+				return nil
+			}
+			if err := repo.SetUserRole(cmd.Context(), user.ID, role); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is now %s\n", user.Email, role)
+			return nil
+		},
+	}
+}
+
+func agentsCommand() *cobra.Command {
+	agents := &cobra.Command{
+		Use:   "agents",
+		Short: "Manage the default agents",
+	}
+	agents.AddCommand(&cobra.Command{
+		Use:   "reseed",
+		Short: "Recreate any of the default public agents that are missing",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, repo, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if failed := services.NewDatabaseSeeder(repo).SeedAgents(cmd.Context()); failed > 0 {
+				return fmt.Errorf("failed to seed %d agents", failed)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Default agents are in place")
+			return nil
+		},
+	})
+	return agents
+}
+
+func migrateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Create and update the database tables",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, repo, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err := repo.AutoMigrate(); err != nil {
+				return fmt.Errorf("failed to migrate: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Database tables migrated")
+			return nil
+		},
+	}
+}
+
+func purgeCommand() *cobra.Command {
+	purge := &cobra.Command{
+		Use:   "purge",
+		Short: "Permanently remove old data",
+	}
+
+	var days int
+	var dryRun bool
+	sessions := &cobra.Command{
+		Use:   "sessions",
+		Short: "Permanently remove ended sessions started more than --older-than days ago, with their recordings",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if days < 1 {
+				return fmt.Errorf("--older-than must be at least 1 day")
+			}
+			config, repo, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			cutoff := time.Now().AddDate(0, 0, -days)
+
+			if dryRun {
+				count, err := repo.CountSessionsStartedBefore(cmd.Context(), cutoff)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%d sessions started before %s would be removed\n", count, cutoff.Format(time.RFC3339))
+				return nil
+			}
+
+			store, err := services.NewBlobStore(config.Storage)
+			if err != nil {
+				return fmt.Errorf("recording storage: %w", err)
+			}
+			purged, err := services.NewDataPurgeService(repo, store, config.Storage, config.Purge).PurgeSessionsBefore(cmd.Context(), cutoff)
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d sessions started before %s\n", purged, cutoff.Format(time.RFC3339))
+			return err
+		},
+	}
+	sessions.Flags().IntVar(&days, "older-than", 0, "age in days of the sessions to remove")
+	sessions.Flags().BoolVar(&dryRun, "dry-run", false, "only count the sessions that would be removed")
+	sessions.MarkFlagRequired("older-than")
+
+	purge.AddCommand(sessions)
+	return purge
+}
+
+func configCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Print the configuration in effect, with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(services.RedactConfig(services.LoadConfig()))
+		},
+	}
+}
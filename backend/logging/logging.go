@@ -0,0 +1,46 @@
+// Package logging carries a request's logger in its context, so every line logged while
+// serving one request shares its request_id, and its user_id once the request is
+// authenticated:
+//
+//	logging.FromContext(ctx).Error("Failed to get session", "session_id", id, "error", err)
+//
+// Work done outside a request, such as background jobs, logs through the default logger.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx that carries logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger ctx carries, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// With returns a copy of ctx whose logger adds args to every line, e.g. the user a request
+// is authenticated as
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+// Middleware gives each request a logger tagged with its request ID, method and path. It
+// must run after middleware.RequestID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := With(r.Context(), "request_id", middleware.GetReqID(r.Context()), "method", r.Method, "path", r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
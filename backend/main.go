@@ -1,14 +1,13 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 
 	"github.com/krshsl/praxis/backend/repository"
 	"github.com/krshsl/praxis/backend/services"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	gormLogger "gorm.io/gorm/logger"
 )
 
 var (
@@ -24,35 +23,25 @@ func main() {
 	// Load configuration
 	config := services.LoadConfig()
 
+	// Override configured secrets with values from the secrets provider
+	secrets, err := services.LoadSecrets(context.Background(), config)
+	if err != nil {
+		slog.Error("Failed to load secrets", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize database connection
-	var err error
+	startup := services.StartupStatus{DatabaseConfigured: config.Database.URL != ""}
 	if config.Database.URL != "" {
-		// Configure GORM logger based on config
-		var gormLogLevel gormLogger.LogLevel
-		switch config.Database.LogLevel {
-		case "silent":
-			gormLogLevel = gormLogger.Silent
-		case "error":
-			gormLogLevel = gormLogger.Error
-		case "warn":
-			gormLogLevel = gormLogger.Warn
-		case "info":
-			gormLogLevel = gormLogger.Info
-		default:
-			gormLogLevel = gormLogger.Silent
-		}
-
-		// Initialize GORM for ORM operations with PostgreSQL
-		gormDB, err = gorm.Open(postgres.Open(config.Database.URL), &gorm.Config{
-			// Disable foreign key constraint checks during migration for better performance
-			DisableForeignKeyConstraintWhenMigrating: true,
-			// Skip default transaction for better performance
-			SkipDefaultTransaction: true,
-			// Configure logging level
-			Logger: gormLogger.Default.LogMode(gormLogLevel),
-		})
+		// Connect with PostgreSQL, retrying while the database comes up
+		gormDB, startup.DatabaseAttempts, err = services.ConnectDatabase(config.Database, secrets)
 		if err != nil {
-			slog.Error("Failed to connect to database with GORM", "error", err)
+			startup.DatabaseError = err.Error()
+			if config.Database.FailFast {
+				slog.Error("Database unavailable, exiting", "error", err, "attempts", startup.DatabaseAttempts)
+				os.Exit(1)
+			}
+			slog.Error("Database unavailable, starting degraded without a repository", "error", err, "attempts", startup.DatabaseAttempts)
 		} else {
 			slog.Info("Connected to database with GORM")
 
@@ -96,6 +85,8 @@ func main() {
 	// Initialize server
 	server := services.NewServer(config)
 	server.SetDatabase(gormRepo, gormDB)
+	server.SetSecrets(secrets)
+	server.SetStartupStatus(startup)
 
 	// Initialize all services
 	if err := server.InitializeServices(); err != nil {
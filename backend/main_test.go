@@ -1,11 +1,50 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
 	"github.com/spf13/viper"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
 	svc "github.com/krshsl/praxis/backend/services"
+	ws "github.com/krshsl/praxis/backend/websocket"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 func TestCheckOrigin(t *testing.T) {
@@ -80,3 +119,2902 @@ func TestCheckOrigin(t *testing.T) {
 		})
 	}
 }
+
+func TestSummaryQualityEvaluate(t *testing.T) {
+	quality := svc.NewSummaryQualityService(nil, svc.QualityConfig{})
+	agent := &models.Agent{Name: "Sarah Chen - Tech Recruiter", Industry: "Technology"}
+	transcripts := []models.InterviewTranscript{
+		{Speaker: "agent", Content: "Hello! I'm Sarah Chen. Tell me about yourself."},
+		{Speaker: "user", Content: "I'm Priya, I build distributed systems in Go."},
+	}
+
+	tests := []struct {
+		name              string
+		raw               string
+		summary           models.InterviewSummary
+		expectJSONValid   bool
+		expectMissing     string
+		expectHallucinate string
+	}{
+		{
+			name: "Complete summary with known names",
+			raw:  `{"summary":"ok"}`,
+			summary: models.InterviewSummary{
+				Summary:         strings.Repeat("Priya discussed distributed systems with Sarah Chen. ", 5),
+				Strengths:       "Clear communication",
+				Weaknesses:      "Limited testing depth",
+				Recommendations: "Practice system design",
+			},
+			expectJSONValid: true,
+		},
+		{
+			name: "Unparseable response with placeholders",
+			raw:  "SUMMARY: not json",
+			summary: models.InterviewSummary{
+				Summary:         "SUMMARY: not json",
+				Strengths:       "Unable to parse structured response",
+				Weaknesses:      "Unable to parse structured response",
+				Recommendations: "Unable to parse structured response",
+			},
+			expectJSONValid: false,
+			expectMissing:   "strengths,weaknesses,recommendations",
+		},
+		{
+			name: "Invented candidate name",
+			raw:  `{"summary":"ok"}`,
+			summary: models.InterviewSummary{
+				Summary:         "Michael Jordan showed strong Go experience.",
+				Strengths:       "Go",
+				Weaknesses:      "Testing",
+				Recommendations: "Practice",
+			},
+			expectJSONValid:   true,
+			expectHallucinate: "Michael Jordan",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := quality.Evaluate(tt.raw, &tt.summary, agent, transcripts)
+
+			if result.JSONValid != tt.expectJSONValid {
+				t.Errorf("JSONValid = %v, expected %v", result.JSONValid, tt.expectJSONValid)
+			}
+			if result.MissingSections != tt.expectMissing {
+				t.Errorf("MissingSections = %q, expected %q", result.MissingSections, tt.expectMissing)
+			}
+			if result.HallucinatedNames != tt.expectHallucinate {
+				t.Errorf("HallucinatedNames = %q, expected %q", result.HallucinatedNames, tt.expectHallucinate)
+			}
+			if result.QualityScore < 0 || result.QualityScore > 100 {
+				t.Errorf("QualityScore = %v, expected 0-100", result.QualityScore)
+			}
+		})
+	}
+}
+
+func TestSummaryQualityAlertsArePersisted(t *testing.T) {
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `INSERT INTO "summary_qualities"`, rows: []map[string]driver.Value{{"id": "quality-1"}}},
+		fakeStub{pattern: `AS recent`, rows: []map[string]driver.Value{{"sample_count": int64(10), "json_valid_rate": 0.0}}},
+		fakeStub{pattern: `INSERT INTO "summary_quality_alerts"`, rows: []map[string]driver.Value{{"id": "alert-1"}}},
+	)
+	quality := svc.NewSummaryQualityService(repo, svc.QualityConfig{Window: 10, MinJSONValidRate: 0.9})
+	ctx := context.Background()
+	summary := &models.InterviewSummary{ID: "summary-1", SessionID: "session-1", Summary: "SUMMARY: not json"}
+
+	if err := quality.Record(ctx, "SUMMARY: not json", summary, &models.Agent{}, nil); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if !fake.ran(`INSERT INTO "summary_quality_alerts"`) || fake.ran(`UPDATE "summary_quality_alerts"`) {
+		t.Errorf("statements = %v, want the new alert saved", fake.statements)
+	}
+
+	// The same regression again refreshes the open alert instead of adding another
+	fake.answerWith(fakeStub{pattern: `INSERT INTO "summary_quality_alerts"`})
+	if err := quality.Record(ctx, "SUMMARY: not json", summary, &models.Agent{}, nil); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if !fake.ran(`UPDATE "summary_quality_alerts" SET "current"`) {
+		t.Errorf("statements = %v, want the open alert refreshed", fake.statements)
+	}
+
+	if err := quality.ClearAlerts(ctx); err != nil || !fake.ran(`DELETE FROM "summary_quality_alerts" WHERE raised_at <=`) {
+		t.Errorf("ClearAlerts = %v, statements = %v, want the alerts deleted", err, fake.statements)
+	}
+}
+
+func TestFilesystemBlobStore(t *testing.T) {
+	store, err := svc.NewFilesystemBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystemBlobStore failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "session/turn-1", "hot", []byte("audio")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := store.Get(ctx, "session/turn-1", "cold"); err == nil {
+		t.Error("expected object to be missing from the cold class")
+	}
+	data, err := store.Get(ctx, "session/turn-1", "hot")
+	if err != nil || string(data) != "audio" {
+		t.Errorf("Get = %q, %v; expected \"audio\"", data, err)
+	}
+	if err := store.Put(ctx, "../../escape", "hot", []byte("x")); err == nil {
+		t.Error("expected key escaping the class directory to be rejected")
+	}
+}
+
+func TestRegionForCountry(t *testing.T) {
+	tests := []struct {
+		country         string
+		jurisdiction    string
+		language        string
+		consentRequired bool
+	}{
+		{"de", svc.JurisdictionEU, "de", true},
+		{"GB", svc.JurisdictionUK, "en", true},
+		{"US", svc.JurisdictionUS, "en", false},
+		{"BR", svc.JurisdictionBR, "pt", true},
+		{"", svc.JurisdictionUnknown, "en", true},
+		{"XX", svc.JurisdictionUnknown, "en", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.country, func(t *testing.T) {
+			region := svc.RegionForCountry(tt.country)
+			if region.Jurisdiction != tt.jurisdiction || region.Language != tt.language || region.ConsentRequired != tt.consentRequired {
+				t.Errorf("RegionForCountry(%q) = %+v, expected %s/%s/%v", tt.country, region, tt.jurisdiction, tt.language, tt.consentRequired)
+			}
+		})
+	}
+}
+
+func TestApplyScoringPolicy(t *testing.T) {
+	scoreCap := 35.0
+	policy := &models.ScoringPolicy{
+		Name:         "Communication first",
+		Weights:      `{"communication": 3, "Technical Knowledge": 1}`,
+		Gates:        `[{"metric": "Communication", "min": 40}]`,
+		PassScore:    60,
+		GateScoreCap: &scoreCap,
+	}
+	scores := func(communication, technical float64) []models.PerformanceScore {
+		return []models.PerformanceScore{
+			{Metric: "Communication", Score: communication, MaxScore: 100},
+			{Metric: "Technical Knowledge", Score: technical, MaxScore: 100},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		scores  []models.PerformanceScore
+		overall float64
+		passed  bool
+	}{
+		{"weighted", scores(80, 40), 70, true},
+		{"below pass score", scores(50, 50), 50, false},
+		{"gate fails and caps", scores(30, 100), 35, false},
+		{"missing gate metric", []models.PerformanceScore{{Metric: "Technical Knowledge", Score: 90, MaxScore: 100}}, 35, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.ApplyScoringPolicy(policy, 75, tt.scores)
+			if err != nil {
+				t.Fatalf("ApplyScoringPolicy returned error: %v", err)
+			}
+			if result.OverallScore != tt.overall || result.Passed != tt.passed {
+				t.Errorf("got score %.2f passed %v, expected %.2f passed %v", result.OverallScore, result.Passed, tt.overall, tt.passed)
+			}
+		})
+	}
+}
+
+func TestAnonymizeText(t *testing.T) {
+	text := "Hi, I'm Jane Doe (jane.doe@example.com), call me on +1 (555) 010-2030 or see https://janedoe.dev. Jane loves Go."
+	got := svc.AnonymizeText(text, []string{"Jane", "Doe", "J"})
+
+	for _, leaked := range []string{"Jane", "Doe", "example.com", "555", "janedoe.dev"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("AnonymizeText left %q in %q", leaked, got)
+		}
+	}
+	if !strings.Contains(got, "loves Go") {
+		t.Errorf("AnonymizeText removed ordinary text: %q", got)
+	}
+}
+
+func TestValidateFlow(t *testing.T) {
+	stage := func(id, next string, turns int) models.FlowStage {
+		return models.FlowStage{ID: id, Directive: "Ask about " + id, MaxTurns: turns, Next: next}
+	}
+
+	tests := []struct {
+		name   string
+		stages []models.FlowStage
+		valid  bool
+	}{
+		{"linear", []models.FlowStage{stage("intro", "behavioral", 1), stage("behavioral", "wrap-up", 2), stage("wrap-up", "", 0)}, true},
+		{"missing next", []models.FlowStage{stage("intro", "coding", 1)}, false},
+		{"loop", []models.FlowStage{stage("intro", "behavioral", 1), stage("behavioral", "intro", 1)}, false},
+		{"unreachable", []models.FlowStage{stage("intro", "", 0), stage("coding", "", 0)}, false},
+		{"no budget", []models.FlowStage{stage("intro", "wrap-up", 0), stage("wrap-up", "", 0)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.ValidateFlow(&models.InterviewFlow{Stages: tt.stages})
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateFlow() error = %v, expected valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := svc.UnifiedDiff("a\nb\n", "a\nb\n", "old", "new"); diff != "" {
+		t.Errorf("identical texts should have no diff, got %q", diff)
+	}
+
+	from := "func add(a, b int) int {\n\treturn a - b\n}\n"
+	to := "func add(a, b int) int {\n\treturn a + b\n}\n"
+	want := "--- old\n+++ new\n@@ -1,3 +1,3 @@\n func add(a, b int) int {\n-\treturn a - b\n+\treturn a + b\n }\n"
+	if diff := svc.UnifiedDiff(from, to, "old", "new"); diff != want {
+		t.Errorf("UnifiedDiff() = %q, want %q", diff, want)
+	}
+
+	want = "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+x\n"
+	if diff := svc.UnifiedDiff("", "x", "old", "new"); diff != want {
+		t.Errorf("UnifiedDiff() from empty = %q, want %q", diff, want)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := svc.NewRateLimiter("test", 60, 2)
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("user-1"); !ok {
+			t.Fatalf("request %d within the burst was limited", i+1)
+		}
+	}
+	ok, wait := limiter.Allow("user-1")
+	if ok || wait <= 0 || wait > time.Second {
+		t.Errorf("request over the burst: ok=%v wait=%v, want limited for up to 1s", ok, wait)
+	}
+	if ok, _ := limiter.Allow("user-2"); !ok {
+		t.Error("other keys should have their own bucket")
+	}
+
+	if ok, _ := svc.NewRateLimiter("disabled", 0, 0).Allow("user-1"); !ok {
+		t.Error("a disabled limiter should allow everything")
+	}
+}
+
+func TestRealIP(t *testing.T) {
+	var seen string
+	handler := svc.RealIP(svc.ServerConfig{TrustedProxies: "10.0.0.0/8, 172.28.0.10"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.RemoteAddr
+	}))
+
+	tests := []struct {
+		name, remote, forwardedFor, realIP, want string
+	}{
+		{"direct client spoofing the headers", "203.0.113.7:5000", "198.51.100.1", "198.51.100.2", "203.0.113.7:5000"},
+		{"through the proxy", "172.28.0.10:4000", "203.0.113.7", "203.0.113.7", "203.0.113.7"},
+		{"client spoofing through the proxy", "172.28.0.10:4000", "198.51.100.1, 203.0.113.7", "203.0.113.7", "203.0.113.7"},
+		{"through a chain of proxies", "10.1.2.3:4000", "203.0.113.7, 172.28.0.10", "", "203.0.113.7"},
+		{"proxy setting only X-Real-IP", "172.28.0.10:4000", "", "203.0.113.7", "203.0.113.7"},
+		{"garbage forwarded", "172.28.0.10:4000", "not-an-ip", "", "172.28.0.10:4000"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/login", nil)
+		req.RemoteAddr = tt.remote
+		if tt.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-IP", tt.realIP)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if seen != tt.want {
+			t.Errorf("%s: RemoteAddr = %q, want %q", tt.name, seen, tt.want)
+		}
+	}
+
+	// Without trusted proxies the headers are never believed
+	svc.RealIP(svc.ServerConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.RemoteAddr
+	})).ServeHTTP(httptest.NewRecorder(), &http.Request{RemoteAddr: "203.0.113.7:5000", Header: http.Header{"X-Forwarded-For": {"198.51.100.1"}}})
+	if seen != "203.0.113.7:5000" {
+		t.Errorf("RemoteAddr without trusted proxies = %q, want the socket address", seen)
+	}
+
+	if _, err := svc.ParseTrustedProxies("10.0.0.0/8,proxy.internal"); err == nil {
+		t.Error("ParseTrustedProxies(hostname) succeeded, want an error")
+	}
+}
+
+func TestCodeSimilarity(t *testing.T) {
+	original := `def two_sum(nums, target):
+    seen = {}
+    for i, n in enumerate(nums):
+        if target - n in seen:
+            return [seen[target - n], i]
+        seen[n] = i
+    return []`
+	renamed := `def find_pair(values, goal):
+    # look up complements as we go
+    index = {}
+    for j, v in enumerate(values):
+        if goal - v in index:
+            return [index[goal - v], j]
+        index[v] = j
+    return []`
+	different := `def two_sum(nums, target):
+    nums = sorted(nums)
+    lo, hi = 0, len(nums) - 1
+    while lo < hi:
+        total = nums[lo] + nums[hi]
+        if total == target:
+            return [lo, hi]
+        elif total < target:
+            lo += 1
+        else:
+            hi -= 1
+    return None`
+
+	if got := svc.CodeSimilarity(renamed, original); got < 0.99 {
+		t.Errorf("renamed copy similarity = %.2f, want ~1", got)
+	}
+	if got := svc.CodeSimilarity(different, original); got > 0.5 {
+		t.Errorf("different solution similarity = %.2f, want low", got)
+	}
+	if got := svc.CodeSimilarity("return x", "return x"); got != 0 {
+		t.Errorf("tiny snippet similarity = %.2f, want 0", got)
+	}
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name       string
+		timezone   string
+		start, end string
+		now        string
+		quiet      bool
+		wantEnd    string
+	}{
+		{"overnight before midnight", "UTC", "22:00", "07:00", "2025-03-10T23:30:00Z", true, "2025-03-11T07:00:00Z"},
+		{"overnight after midnight", "UTC", "22:00", "07:00", "2025-03-11T03:00:00Z", true, "2025-03-11T07:00:00Z"},
+		{"outside overnight window", "UTC", "22:00", "07:00", "2025-03-11T12:00:00Z", false, ""},
+		{"daytime window", "UTC", "12:00", "14:00", "2025-03-11T13:15:00Z", true, "2025-03-11T14:00:00Z"},
+		{"end is exclusive", "UTC", "12:00", "14:00", "2025-03-11T14:00:00Z", false, ""},
+		{"user timezone", "Asia/Kolkata", "22:00", "07:00", "2025-03-11T17:00:00Z", true, "2025-03-12T01:30:00Z"},
+		{"no quiet hours", "UTC", "", "", "2025-03-11T03:00:00Z", false, ""},
+	}
+
+	for _, tt := range tests {
+		end, quiet := svc.QuietHoursEnd(tt.timezone, tt.start, tt.end, at(tt.now))
+		if quiet != tt.quiet {
+			t.Errorf("%s: quiet = %v, want %v", tt.name, quiet, tt.quiet)
+			continue
+		}
+		if quiet && !end.Equal(at(tt.wantEnd)) {
+			t.Errorf("%s: end = %v, want %v", tt.name, end.UTC(), tt.wantEnd)
+		}
+	}
+}
+
+func TestLocaleFormat(t *testing.T) {
+	at := time.Date(2025, 3, 11, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		locale, timezone string
+		wantDate         string
+		wantNumber       string
+	}{
+		{"en-US", "America/New_York", "March 11, 2025", "1,234.50"},
+		{"de-DE", "Europe/Berlin", "12.3.2025", "1.234,50"},
+		{"ja", "Asia/Tokyo", "2025/03/12", "1,234.50"},
+		{"xx-invalid", "Nowhere/Town", "March 11, 2025", "1,234.50"},
+	}
+
+	for _, tt := range tests {
+		format := svc.NewLocaleFormat(tt.locale, tt.timezone)
+		if got := format.Date(at); got != tt.wantDate {
+			t.Errorf("%s: Date = %q, want %q", tt.locale, got, tt.wantDate)
+		}
+		if got := format.Number(1234.5, 2); got != tt.wantNumber {
+			t.Errorf("%s: Number = %q, want %q", tt.locale, got, tt.wantNumber)
+		}
+	}
+}
+
+func TestAgentHealthScore(t *testing.T) {
+	unscored := svc.AgentHealthScore(models.AgentHealth{})
+	if unscored != 50 {
+		t.Errorf("agent without sessions scored %v, want 50", unscored)
+	}
+
+	great := models.AgentHealth{Sessions: 200, CompletionRate: 0.95, Ratings: 80, AverageRating: 4.8, Summaries: 180, SummaryQuality: 90}
+	lucky := models.AgentHealth{Sessions: 2, CompletionRate: 1, Ratings: 1, AverageRating: 5, Summaries: 2, SummaryQuality: 100}
+	poor := models.AgentHealth{Sessions: 200, CompletionRate: 0.3, Ratings: 80, AverageRating: 2, Summaries: 60, SummaryQuality: 55}
+
+	if !(svc.AgentHealthScore(great) > svc.AgentHealthScore(lucky)) {
+		t.Errorf("well-established agent (%v) should outrank one with two perfect sessions (%v)", svc.AgentHealthScore(great), svc.AgentHealthScore(lucky))
+	}
+	if !(svc.AgentHealthScore(lucky) > unscored && unscored > svc.AgentHealthScore(poor)) {
+		t.Errorf("scores out of order: lucky %v, unscored %v, poor %v", svc.AgentHealthScore(lucky), unscored, svc.AgentHealthScore(poor))
+	}
+}
+
+func TestSummaryRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := svc.SummaryRetryBackoff(30*time.Second, tt.attempts); got != tt.want {
+			t.Errorf("SummaryRetryBackoff after %d attempts = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebSocketEnvelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		code    string // "" when the message is valid
+	}{
+		{"text", `{"v":1,"type":"text","payload":{"content":"hello"}}`, ""},
+		{"text with typing metrics", `{"v":1,"type":"text","payload":{"content":"hello","metrics":{"response_latency_ms":1200,"compose_ms":5000,"keystrokes":7,"edits":2}}}`, ""},
+		{"negative typing metrics", `{"v":1,"type":"text","payload":{"content":"hello","metrics":{"edits":-1}}}`, ws.ErrCodeInvalidPayload},
+		{"end session without payload", `{"v":1,"type":"end_session"}`, ""},
+		{"audio chunk", `{"v":1,"type":"audio_chunk","payload":{"audio_data":"AAEC","chunk_index":1,"total_chunks":2,"is_last_chunk":true}}`, ""},
+		{"not json", `hello`, ws.ErrCodeInvalidMessage},
+		{"legacy message", `{"type":"text","content":"hello"}`, ws.ErrCodeUnsupportedVersion},
+		{"unknown envelope field", `{"v":1,"type":"text","content":"hello"}`, ws.ErrCodeInvalidMessage},
+		{"missing version", `{"type":"text","payload":{"content":"hello"}}`, ws.ErrCodeUnsupportedVersion},
+		{"future version", `{"v":2,"type":"text","payload":{"content":"hello"}}`, ws.ErrCodeUnsupportedVersion},
+		{"server-only type", `{"v":1,"type":"note_saved","payload":{}}`, ws.ErrCodeUnknownType},
+		{"empty text", `{"v":1,"type":"text","payload":{"content":"  "}}`, ws.ErrCodeInvalidPayload},
+		{"unknown field", `{"v":1,"type":"text","payload":{"content":"hi","session_id":"x"}}`, ws.ErrCodeInvalidPayload},
+		{"bad base64", `{"v":1,"type":"audio","payload":{"audio_data":"not base64!"}}`, ws.ErrCodeInvalidPayload},
+		{"chunk out of range", `{"v":1,"type":"audio_chunk","payload":{"audio_data":"AAEC","chunk_index":2,"total_chunks":2,"is_last_chunk":true}}`, ws.ErrCodeInvalidPayload},
+		{"last chunk not flagged", `{"v":1,"type":"audio_chunk","payload":{"audio_data":"AAEC","chunk_index":1,"total_chunks":2}}`, ws.ErrCodeInvalidPayload},
+		{"audio with timing", `{"v":1,"type":"audio","payload":{"audio_data":"AAEC","timing":{"duration_ms":30000,"pauses_ms":[1200,2500]}}}`, ""},
+		{"pauses longer than audio", `{"v":1,"type":"audio","payload":{"audio_data":"AAEC","timing":{"duration_ms":1000,"pauses_ms":[1200]}}}`, ws.ErrCodeInvalidPayload},
+		{"timing on an early chunk", `{"v":1,"type":"audio_chunk","payload":{"audio_data":"AAEC","chunk_index":0,"total_chunks":2,"is_last_chunk":false,"timing":{"duration_ms":1000}}}`, ws.ErrCodeInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, perr := ws.ParseEnvelope([]byte(tt.message))
+			if perr == nil {
+				_, perr = ws.DecodePayload(env)
+			}
+			code := ""
+			if perr != nil {
+				code = perr.Code
+			}
+			if code != tt.code {
+				t.Errorf("got error code %q, want %q (%v)", code, tt.code, perr)
+			}
+		})
+	}
+}
+
+func TestPickDeterministicVoice(t *testing.T) {
+	first := svc.PickDeterministicVoice("Sarah Chen", "female")
+	if again := svc.PickDeterministicVoice("sarah chen", "Female"); again != first {
+		t.Errorf("voice changed between calls: %q then %q", first, again)
+	}
+
+	// Adam is only in the male pool, so a female agent never gets the default voice
+	for _, name := range []string{"Sarah", "Emily", "Lisa", "Priya", "Ana"} {
+		if svc.PickDeterministicVoice(name, "female") == svc.DefaultVoiceID {
+			t.Errorf("female agent %q got the male default voice", name)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"role":"candidate","content":"I would shard by tenant."},`, 50)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantGzip       bool
+	}{
+		{"large json", "gzip, deflate, br", "application/json", large, true},
+		{"small json", "gzip", "application/json", `{"ok":true}`, false},
+		{"client without gzip", "br;q=1, gzip;q=0", "application/json", large, false},
+		{"audio", "gzip", "audio/mpeg", large, false},
+		{"sniffed text", "gzip", "", large, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := svc.Compress(svc.ServerConfig{CompressionMinBytes: 1024, CompressionLevel: 5})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.Write([]byte(tt.body[:len(tt.body)/2]))
+				w.Write([]byte(tt.body[len(tt.body)/2:]))
+			}))
+
+			req := httptest.NewRequest("GET", "/api/v1/sessions/1", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			gzipped := rec.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("gzipped = %v, want %v", gzipped, tt.wantGzip)
+			}
+			body := rec.Body.Bytes()
+			if gzipped {
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("invalid gzip body: %v", err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("invalid gzip body: %v", err)
+				}
+			}
+			if string(body) != tt.body {
+				t.Errorf("body was altered: got %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestClientErrorReportValidate(t *testing.T) {
+	report := svc.ClientErrorReport{Kind: " Playback ", Message: " audio failed ", Context: []byte(`{"media_error_code": 4}`)}
+	if err := report.Validate(); err != nil {
+		t.Fatalf("valid report rejected: %v", err)
+	}
+	if report.Kind != "playback" || report.Message != "audio failed" {
+		t.Errorf("report not normalized: kind %q, message %q", report.Kind, report.Message)
+	}
+
+	invalid := []svc.ClientErrorReport{
+		{Kind: "crash", Message: "boom"},
+		{Kind: "decode", Message: "  "},
+		{Kind: "decode", Message: "bad frame", Context: []byte(`[1, 2]`)},
+		{Kind: "decode", Message: "bad frame", Context: []byte(`{"a":`)},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("report %+v should be rejected", r)
+		}
+	}
+}
+
+func TestBuildProctoringReport(t *testing.T) {
+	if report := svc.BuildProctoringReport("s1", nil); !report.Clean || report.Events == nil {
+		t.Errorf("session without events should be clean with an empty event list, got %+v", report)
+	}
+
+	events := []models.ProctorEvent{
+		{Kind: models.ProctorEventFocusLost},
+		{Kind: models.ProctorEventFocusRegained, DurationMs: 4500},
+		{Kind: models.ProctorEventTabHidden},
+		{Kind: models.ProctorEventFocusRegained, DurationMs: 2500},
+		{Kind: models.ProctorEventFullscreenExit},
+		{Kind: models.ProctorEventHeartbeatMissed, DurationMs: 25000},
+		{Kind: models.ProctorEventHeartbeatMissed, DurationMs: 40000},
+	}
+	report := svc.BuildProctoringReport("s1", events)
+	if report.Clean {
+		t.Error("report with events should not be clean")
+	}
+	if report.FocusLosses != 2 || report.TimeAwaySeconds != 6 || report.FullscreenExits != 1 {
+		t.Errorf("focus counts: losses %d, away %ds, fullscreen exits %d", report.FocusLosses, report.TimeAwaySeconds, report.FullscreenExits)
+	}
+	if report.HeartbeatGaps != 2 || report.LongestGapSeconds != 40 {
+		t.Errorf("heartbeat gaps: %d, longest %ds", report.HeartbeatGaps, report.LongestGapSeconds)
+	}
+}
+
+func TestNewTranscriptionProvider(t *testing.T) {
+	provider, err := svc.NewTranscriptionProvider(svc.AIConfig{}, nil)
+	if err != nil || provider.Name() != svc.TranscriptionGemini {
+		t.Errorf("default provider: got %v, %v", provider, err)
+	}
+
+	cfg := svc.AIConfig{Transcription: "Deepgram, whisper,gemini", OpenAIKey: "sk", DeepgramKey: "dg"}
+	provider, err = svc.NewTranscriptionProvider(cfg, nil)
+	if err != nil {
+		t.Fatalf("fallback chain rejected: %v", err)
+	}
+	if name := provider.Name(); name != "deepgram,whisper,gemini" {
+		t.Errorf("fallback chain order: got %q", name)
+	}
+
+	for _, bad := range []svc.AIConfig{
+		{Transcription: "whisper"},
+		{Transcription: "gemini,deepgram"},
+		{Transcription: "azure"},
+	} {
+		if _, err := svc.NewTranscriptionProvider(bad, nil); err == nil {
+			t.Errorf("config %+v should be rejected", bad)
+		}
+	}
+}
+
+func TestTestResultSummary(t *testing.T) {
+	result := svc.TestResult{
+		Passed: 1,
+		Total:  3,
+		Tests: []svc.TestCaseResult{
+			{Name: "TestEmpty", Passed: true},
+			{Name: "TestSingle", Passed: false},
+			{Name: "TestMany", Passed: false},
+		},
+		Output:   "--- FAIL: TestSingle",
+		TimedOut: true,
+	}
+	summary := result.Summary()
+	for _, want := range []string{"Passed 1 of 3 tests (the run timed out)", "Failed: TestSingle, TestMany", "Output:\n--- FAIL: TestSingle"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary %q is missing %q", summary, want)
+		}
+	}
+
+	if summary := (svc.TestResult{Passed: 2, Total: 2}).Summary(); summary != "Passed 2 of 2 tests" {
+		t.Errorf("passing run summary: got %q", summary)
+	}
+}
+
+func TestRenderReportMarkdown(t *testing.T) {
+	if err := svc.ValidateReportHeader("Acme\n{{.Level}} {{.Industry}} screening of {{.Candidate}}"); err != nil {
+		t.Errorf("valid header rejected: %v", err)
+	}
+	for _, bad := range []string{"{{.Company}}", "{{.AgentName"} {
+		if err := svc.ValidateReportHeader(bad); err == nil {
+			t.Errorf("header %q should be rejected", bad)
+		}
+	}
+
+	started := time.Date(2025, 3, 4, 9, 0, 0, 0, time.UTC)
+	session := &models.InterviewSession{
+		ID:        "s1",
+		StartedAt: started,
+		Duration:  600,
+		Agent:     models.Agent{Name: "Ada", Industry: "Technology", Level: "senior", ReportHeader: "Acme\nScreening with {{.AgentName}} on {{.Date}}"},
+		Transcripts: []models.InterviewTranscript{
+			{Speaker: "user", Kind: models.TranscriptKindCode, Language: "go", Content: "x := \"```\"", TestsPassed: 2, TestsTotal: 3, TurnOrder: 2, Timestamp: started.Add(time.Minute)},
+			{Speaker: "agent", Kind: models.TranscriptKindText, Content: "Write a function.", TurnOrder: 1, Timestamp: started},
+		},
+		Summary:           &models.InterviewSummary{Summary: "Solid.", Strengths: "Clear code", OverallScore: 72},
+		PerformanceScores: []models.PerformanceScore{{Metric: "Communication", Score: 80, MaxScore: 100}},
+	}
+	format := svc.NewLocaleFormat("en-US", "UTC")
+	report, err := svc.BuildSessionReport(session, "Grace", format)
+	if err != nil {
+		t.Fatalf("BuildSessionReport: %v", err)
+	}
+	if report.Header != "Acme\nScreening with Ada on March 4, 2025" {
+		t.Errorf("header: got %q", report.Header)
+	}
+	if report.Transcript[0].Speaker != "interviewer" {
+		t.Errorf("transcript should be in turn order, got %+v", report.Transcript)
+	}
+
+	md := svc.RenderReportMarkdown(report, format)
+	for _, want := range []string{
+		"# Acme\n\nScreening with Ada on March 4, 2025\n",
+		"- Candidate: Grace\n",
+		"Overall score: 72 / 100",
+		"### Strengths\n\nClear code\n",
+		"| Communication | 80 / 100 |",
+		"submitted code, passing 2 of 3 tests:\n\n````go\nx := \"```\"\n````\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestBrandingUpdateValidate(t *testing.T) {
+	tests := []struct {
+		update svc.BrandingUpdate
+		valid  bool
+	}{
+		{svc.BrandingUpdate{}, true},
+		{svc.BrandingUpdate{PrimaryColor: "#1A2b3C", AccentColor: "#ff8800", FooterText: "Acme Hiring"}, true},
+		{svc.BrandingUpdate{PrimaryColor: "red"}, false},
+		{svc.BrandingUpdate{AccentColor: "#fff"}, false},
+		{svc.BrandingUpdate{FooterText: strings.Repeat("x", 501)}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.update.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", tt.update, err, tt.valid)
+		}
+	}
+}
+
+func TestTempFiles(t *testing.T) {
+	files, err := svc.NewTempFiles(svc.TempFilesConfig{Dir: t.TempDir(), MaxMB: 1})
+	if err != nil {
+		t.Fatalf("NewTempFiles: %v", err)
+	}
+
+	input, err := files.Create("input-*.webm", 600<<10)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := files.Create("output-*.wav", 600<<10); err != svc.ErrTempSpaceExhausted {
+		t.Errorf("creating past the limit: got %v, want ErrTempSpaceExhausted", err)
+	}
+	if err := input.Write([]byte("audio")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	input.Release()
+	input.Release()
+	if _, err := os.Stat(input.Path()); !os.IsNotExist(err) {
+		t.Errorf("released file still exists: %v", err)
+	}
+	stats := files.Stats()
+	if stats.Open != 0 || stats.UsedBytes != 0 || stats.Created != 1 || stats.Released != 1 || stats.Rejected != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestFeedbackToneGuidance(t *testing.T) {
+	strict := svc.FeedbackToneGuidance("", "strict and rigorous")
+	if !strings.Contains(strict, "don't sugarcoat") {
+		t.Errorf("no tone should follow the personality, got %q", strict)
+	}
+	gentle := svc.FeedbackToneGuidance(models.FeedbackToneGentle, "strict and rigorous")
+	if gentle == strict || !strings.Contains(gentle, "gentle") {
+		t.Errorf("gentle tone should override the personality, got %q", gentle)
+	}
+	if svc.FeedbackToneGuidance(models.FeedbackToneDirect, "friendly") == svc.FeedbackToneGuidance("", "friendly") {
+		t.Error("direct tone should override the personality")
+	}
+}
+
+func TestLifecycleShutdown(t *testing.T) {
+	lifecycle := svc.NewLifecycle()
+	var order []string
+	lifecycle.Go("loop", func(ctx context.Context) {
+		<-ctx.Done()
+	})
+	lifecycle.OnShutdown("first", func(ctx context.Context) { order = append(order, "first") })
+	lifecycle.OnShutdown("second", func(ctx context.Context) { order = append(order, "second") })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := lifecycle.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("shutdown steps ran as %v", order)
+	}
+
+	stuck := svc.NewLifecycle()
+	release := make(chan struct{})
+	defer close(release)
+	stuck.Go("stuck", func(ctx context.Context) { <-release })
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := stuck.Shutdown(ctx); err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Errorf("expected the stuck loop to be reported, got %v", err)
+	}
+}
+
+func TestDrillTopics(t *testing.T) {
+	tests := []struct {
+		name       string
+		weaknesses string
+		want       []string
+	}{
+		{"placeholder", "No weaknesses identified", nil},
+		{"bullets", "- System design depth.\n- Testing strategy\n* system design depth", []string{"System design depth", "Testing strategy"}},
+		{"numbered", "1. Big-O analysis\n2) Concurrency", []string{"Big-O analysis", "Concurrency"}},
+		{"paragraph", "Struggled with recursion. Answers lacked concrete examples; rushed the SQL question.", []string{"Struggled with recursion", "Answers lacked concrete examples", "rushed the SQL question"}},
+		{"limit", "a\nb\nc\nd\ne\nf", []string{"a", "b", "c", "d", "e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := svc.DrillTopics(tt.weaknesses)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("DrillTopics(%q) = %q, want %q", tt.weaknesses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchBannedTopic(t *testing.T) {
+	topics := []models.BannedTopic{
+		{ID: "age", Topic: "Age", Terms: `["how old", "date of birth"]`},
+		{ID: "religion", Topic: "Religion", Terms: `[]`},
+	}
+	tests := []struct {
+		name     string
+		text     string
+		wantID   string
+		wantTerm string
+	}{
+		{"clean", "Tell me about a project you managed.", "", ""},
+		{"word boundary", "How do you manage stakeholders?", "", ""},
+		{"topic", "What is your age?", "age", "Age"},
+		{"term", "And HOW OLD are you, if I may ask?", "age", "how old"},
+		{"second topic", "Do you practise a religion?", "religion", "Religion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topic, term := svc.MatchBannedTopic(topics, tt.text)
+			gotID := ""
+			if topic != nil {
+				gotID = topic.ID
+			}
+			if gotID != tt.wantID || term != tt.wantTerm {
+				t.Errorf("MatchBannedTopic(%q) = %q, %q, want %q, %q", tt.text, gotID, term, tt.wantID, tt.wantTerm)
+			}
+		})
+	}
+}
+
+func TestRecentTurns(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	// Turn orders repeat, so time decides the order
+	transcripts := []models.InterviewTranscript{
+		{Content: "answer", TurnOrder: 1, Timestamp: start.Add(2 * time.Second)},
+		{Content: "welcome", TurnOrder: 1, Timestamp: start},
+		{Content: "question", TurnOrder: 1, Timestamp: start.Add(time.Second)},
+	}
+
+	turns, truncated := svc.RecentTurns(transcripts, 0)
+	if got := turnContents(turns); got != "welcome|question|answer" || truncated {
+		t.Errorf("RecentTurns(no limit) = %q, %v, want all in order", got, truncated)
+	}
+	turns, truncated = svc.RecentTurns(transcripts, 2)
+	if got := turnContents(turns); got != "question|answer" || !truncated {
+		t.Errorf("RecentTurns(2) = %q, %v, want the last two, truncated", got, truncated)
+	}
+	if transcripts[0].Content != "answer" {
+		t.Error("RecentTurns reordered its input")
+	}
+}
+
+func turnContents(turns []models.InterviewTranscript) string {
+	contents := make([]string, len(turns))
+	for i, turn := range turns {
+		contents[i] = turn.Content
+	}
+	return strings.Join(contents, "|")
+}
+
+func TestUserAccommodations(t *testing.T) {
+	none := svc.UserAccommodations(&models.User{})
+	if none.ThinkingTime != 1 || none.TimeLimit != 1 || none.EmptyResponseLimit() != 3 {
+		t.Errorf("UserAccommodations(no settings) = %+v, want multipliers of 1 and 3 empty answers", none)
+	}
+	if got := none.Limit(30 * time.Minute); got != 30*time.Minute {
+		t.Errorf("Limit without accommodations = %v, want 30m", got)
+	}
+
+	extra := svc.UserAccommodations(&models.User{ThinkingTimeMultiplier: 1.5, TimeLimitMultiplier: 5, TextOnly: true})
+	if extra.EmptyResponseLimit() != 5 {
+		t.Errorf("EmptyResponseLimit() at 1.5x = %d, want 5", extra.EmptyResponseLimit())
+	}
+	if got := extra.Think(time.Minute); got != 90*time.Second {
+		t.Errorf("Think(1m) at 1.5x = %v, want 1m30s", got)
+	}
+	if got := extra.Limit(30 * time.Minute); got != time.Hour {
+		t.Errorf("Limit(30m) capped at %dx = %v, want 1h", svc.MaxTimeLimitMultiplier, got)
+	}
+	if !extra.TextOnly {
+		t.Error("UserAccommodations dropped text_only")
+	}
+
+	if err := svc.ValidateAccommodations(0, 1.5); err != nil {
+		t.Errorf("ValidateAccommodations(0, 1.5) = %v, want nil", err)
+	}
+	if err := svc.ValidateAccommodations(0.5, 1); err == nil {
+		t.Error("ValidateAccommodations accepted a thinking time below 1")
+	}
+}
+
+func TestCanManageOrgRole(t *testing.T) {
+	cases := []struct {
+		actor, role string
+		want        bool
+	}{
+		{models.OrgRoleOwner, models.OrgRoleOwner, true},
+		{models.OrgRoleOwner, models.OrgRoleMember, true},
+		{models.OrgRoleAdmin, models.OrgRoleMember, true},
+		{models.OrgRoleAdmin, models.OrgRoleAdmin, false},
+		{models.OrgRoleAdmin, models.OrgRoleOwner, false},
+		{models.OrgRoleMember, models.OrgRoleMember, false},
+		{models.OrgRoleOwner, "superuser", false},
+		{"", models.OrgRoleMember, false},
+	}
+	for _, c := range cases {
+		if got := svc.CanManageOrgRole(c.actor, c.role); got != c.want {
+			t.Errorf("CanManageOrgRole(%q, %q) = %v, want %v", c.actor, c.role, got, c.want)
+		}
+	}
+
+	if !svc.OrgRoleAtLeast(models.OrgRoleOwner, models.OrgRoleAdmin) || svc.OrgRoleAtLeast(models.OrgRoleMember, models.OrgRoleAdmin) {
+		t.Error("OrgRoleAtLeast doesn't rank member < admin < owner")
+	}
+}
+
+func TestLowConfidenceTurns(t *testing.T) {
+	low, high := 0.55, 0.93
+	transcripts := []models.InterviewTranscript{
+		{Speaker: "agent", Content: "Tell me about yourself"},
+		{Speaker: "user", Content: "I work on payments", Confidence: &high},
+		{Speaker: "user", Content: "I led the migration to cube burnetes", Confidence: &low},
+		{Speaker: "user", Content: "typed answer"},
+		{Speaker: "agent", Content: "Why?", Confidence: &low},
+	}
+
+	got := svc.LowConfidenceTurns(transcripts, 0.8)
+	if len(got) != 1 || got[0] != 2 {
+		t.Errorf("LowConfidenceTurns(0.8) = %v, want [2]", got)
+	}
+	if got := svc.LowConfidenceTurns(transcripts, 0.95); len(got) != 2 {
+		t.Errorf("LowConfidenceTurns(0.95) = %v, want both spoken answers", got)
+	}
+	if got := svc.LowConfidenceTurns(transcripts, 0); len(got) != 0 {
+		t.Errorf("LowConfidenceTurns(0) = %v, want none", got)
+	}
+}
+
+func TestDescribeScoreChange(t *testing.T) {
+	cases := []struct {
+		average, previous float64
+		sessions          int64
+		want              string
+	}{
+		{72, 65, 3, "up 7.0 points from the week before"},
+		{60.2, 68, 1, "down 7.8 points from the week before"},
+		{70.3, 70, 2, "about the same as the week before"},
+		{80, 0, 0, ""},
+	}
+
+	for _, c := range cases {
+		if got := svc.DescribeScoreChange(c.average, c.previous, c.sessions); got != c.want {
+			t.Errorf("DescribeScoreChange(%v, %v, %d) = %q, want %q", c.average, c.previous, c.sessions, got, c.want)
+		}
+	}
+}
+
+func TestPacingNudge(t *testing.T) {
+	pacing := svc.AgentPacing(&models.Agent{PaceQuestions: 3, MaxAnswerWords: 20, MaxDwellSeconds: 90})
+	short := "I split the monolith into services behind a gateway"
+	long := strings.Repeat("and then we also ", 6)
+
+	if got := pacing.Nudge(short, time.Minute); got != "" {
+		t.Errorf("Nudge(short, 1m) = %q, want none", got)
+	}
+	if got := pacing.Nudge(long, time.Minute); got != svc.PacingLongAnswer {
+		t.Errorf("Nudge(long, 1m) = %q, want %q", got, svc.PacingLongAnswer)
+	}
+	if got := pacing.Nudge(short, 2*time.Minute); got != svc.PacingLongDwell {
+		t.Errorf("Nudge(short, 2m) = %q, want %q", got, svc.PacingLongDwell)
+	}
+
+	// Extra thinking time stretches the dwell limit
+	stretched := pacing.ForCandidate(svc.Accommodations{ThinkingTime: 2})
+	if got := stretched.Nudge(short, 2*time.Minute); got != "" {
+		t.Errorf("Nudge with 2x thinking time = %q, want none", got)
+	}
+	if got := (svc.Pacing{}).Nudge(long, time.Hour); got != "" {
+		t.Errorf("Nudge without pacing = %q, want none", got)
+	}
+
+	if err := svc.ValidatePacing(3, 0, 90); err != nil {
+		t.Errorf("ValidatePacing(3, 0, 90) = %v, want nil", err)
+	}
+	if err := svc.ValidatePacing(0, 5, 0); err == nil {
+		t.Error("ValidatePacing accepted a 5-word answer limit")
+	}
+}
+
+func TestAdaptiveDifficulty(t *testing.T) {
+	strong := "I'd shard by tenant ID because most queries stay within one tenant, which means p99 latency stays under 50ms. " +
+		"The trade-off is cross-tenant reporting, so we'd stream changes to a warehouse instead of joining across shards. " +
+		"For example, at 10k writes per second we measured Postgres with PgBouncer handling it on 4 nodes."
+	weak := "I'm not sure, maybe a cache?"
+
+	if svc.ScoreAnswer(strong) <= 0.65 {
+		t.Errorf("ScoreAnswer(strong) = %v, want above 0.65", svc.ScoreAnswer(strong))
+	}
+	if got := svc.ScoreAnswer(weak); got > 0.1 {
+		t.Errorf("ScoreAnswer(weak) = %v, want at most 0.1", got)
+	}
+	if got := svc.ScoreAnswer(""); got != 0 {
+		t.Errorf("ScoreAnswer(\"\") = %v, want 0", got)
+	}
+
+	// One answer isn't enough to move off medium
+	var estimate svc.AbilityEstimate
+	estimate = estimate.Record(svc.ScoreAnswer(strong))
+	if got := estimate.Difficulty(); got != models.QuestionDifficultyMedium {
+		t.Errorf("Difficulty after one answer = %q, want medium", got)
+	}
+	estimate = estimate.Record(svc.ScoreAnswer(strong)).Record(svc.ScoreAnswer(strong))
+	if got := estimate.Difficulty(); got != models.QuestionDifficultyHard {
+		t.Errorf("Difficulty after strong answers = %q (ability %v), want hard", got, estimate.Ability)
+	}
+	// Recent answers outweigh earlier ones
+	for range 4 {
+		estimate = estimate.Record(svc.ScoreAnswer(weak))
+	}
+	if got := estimate.Difficulty(); got != models.QuestionDifficultyEasy {
+		t.Errorf("Difficulty after weak answers = %q (ability %v), want easy", got, estimate.Ability)
+	}
+}
+
+func TestParseWebhookEvents(t *testing.T) {
+	events, err := svc.ParseWebhookEvents([]string{models.WebhookEventSummaryGenerated, models.WebhookEventSessionStarted, models.WebhookEventSummaryGenerated})
+	if err != nil {
+		t.Fatalf("ParseWebhookEvents returned %v", err)
+	}
+	if want := "session.started,summary.generated"; events != want {
+		t.Errorf("ParseWebhookEvents = %q, want %q", events, want)
+	}
+
+	if _, err := svc.ParseWebhookEvents(nil); err == nil {
+		t.Error("ParseWebhookEvents accepted no events")
+	}
+	if _, err := svc.ParseWebhookEvents([]string{"session.concluded"}); err == nil {
+		t.Error("ParseWebhookEvents accepted an internal event name")
+	}
+}
+
+func TestAPIKeyScope(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/api/v1/sessions", svc.ScopeReadSessions},
+		{"GET", "/api/v1/summaries/session/abc", svc.ScopeReadSessions},
+		{"GET", "/api/v1/search", svc.ScopeReadSessions},
+		{"DELETE", "/api/v1/sessions/abc", ""},
+		{"POST", "/api/v1/agents", svc.ScopeWriteAgents},
+		{"GET", "/api/v1/agents/abc", svc.ScopeWriteAgents},
+		{"GET", "/api/v1/apikeys", ""},
+		{"GET", "/api/v1/ws", ""},
+		{"DELETE", "/api/v1/users/me", ""},
+		{"GET", "/api/v1/sessionsx", ""},
+		{"GET", "/api/v2/summaries/session/abc", svc.ScopeReadSessions},
+		{"POST", "/api/v2/agents", svc.ScopeWriteAgents},
+		{"GET", "/api/v2/apikeys", ""},
+		{"GET", "/api/v2x/sessions", ""},
+	}
+	for _, tt := range tests {
+		if got := svc.APIKeyScope(tt.method, tt.path); got != tt.want {
+			t.Errorf("APIKeyScope(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCreateAPIKeyWhileImpersonating(t *testing.T) {
+	repo, fake := newFakeRepository(t)
+	auth := svc.NewAuthService(repo, "secret", nil, nil)
+	router := chi.NewRouter()
+	svc.NewAPIKeyEndpoints(repo, svc.NewAPIKeyService(repo, auth)).RegisterRoutes(router)
+	user := &models.User{ID: "user-1", Role: "user"}
+
+	body := `{"name":"ci","scopes":["read:sessions"]}`
+	req := httptest.NewRequest(http.MethodPost, "/apikeys", strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), "user", user)
+	ctx = context.WithValue(ctx, "impersonation", &models.Impersonation{AdminID: "admin", UserID: user.ID})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("POST /apikeys while impersonating = %d %s, want 403", rec.Code, rec.Body)
+	}
+	if _, _, err := svc.NewAPIKeyService(repo, auth).Mint(ctx, user, "ci", []string{svc.ScopeReadSessions}, 0); err == nil {
+		t.Error("Mint should refuse an impersonating admin")
+	}
+	if fake.ran(`INSERT INTO "api_keys"`) {
+		t.Error("no key should be stored while impersonating")
+	}
+
+	if rec := serveAs(router, user, http.MethodPost, "/apikeys", `{"name":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST /apikeys with a malformed body = %d %s, want 400", rec.Code, rec.Body)
+	}
+}
+
+func TestSessionBandwidth(t *testing.T) {
+	hub := ws.NewHub()
+	go hub.Run()
+	hub.SetBandwidthLimits(ws.BandwidthLimits{MaxBytesOut: 16})
+
+	message := []byte(`{"v":1,"type":"text","payload":{"content":"hello"}}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := hub.RegisterClient(context.Background(), conn, "user", "session")
+		go client.WritePump()
+		client.Send <- message
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+
+	if usage := hub.SessionUsage("session"); usage.BytesOut != int64(len(message)) || usage.BytesIn != 0 {
+		t.Errorf("SessionUsage = %+v, want %d bytes out", usage, len(message))
+	}
+	if capped, first := hub.OutboundCapped("session"); !capped || !first {
+		t.Errorf("OutboundCapped = %v, %v, want capped the first time", capped, first)
+	}
+	if capped, first := hub.OutboundCapped("session"); !capped || first {
+		t.Errorf("OutboundCapped again = %v, %v, want capped, not first", capped, first)
+	}
+	if usage := hub.ForgetSession("session"); usage.BytesOut != int64(len(message)) {
+		t.Errorf("ForgetSession = %+v, want the session's usage", usage)
+	}
+}
+
+func TestRescoreStep(t *testing.T) {
+	tests := []struct {
+		rate      int
+		wantBatch int
+		wantWait  time.Duration
+	}{
+		{1, 1, time.Minute},
+		{10, 1, 6 * time.Second},
+		{60, 10, 10 * time.Second},
+		{0, 1, time.Minute},
+	}
+	for _, tt := range tests {
+		batch, wait := svc.RescoreStep(tt.rate)
+		if batch != tt.wantBatch || wait != tt.wantWait {
+			t.Errorf("RescoreStep(%d) = %d, %s, want %d, %s", tt.rate, batch, wait, tt.wantBatch, tt.wantWait)
+		}
+	}
+}
+
+func TestNormalizeAudioCodec(t *testing.T) {
+	tests := map[string]string{
+		"audio/webm;codecs=opus":                "audio/webm;codecs=opus",
+		" Audio/MP4 ":                           "audio/mp4",
+		"audio/webm; codecs=opus":               "audio/webm;codecs=opus",
+		"video/webm;codecs=vp8":                 "",
+		"audio/x-made-up":                       "",
+		"":                                      "",
+		"audio/ogg;" + strings.Repeat("x", 200): "",
+	}
+	for codec, want := range tests {
+		if got := svc.NormalizeAudioCodec(codec); got != want {
+			t.Errorf("NormalizeAudioCodec(%q) = %q, want %q", codec, got, want)
+		}
+	}
+}
+
+func TestValidateStruct(t *testing.T) {
+	validate := func(v interface{}) svc.ValidationErrors {
+		t.Helper()
+		errs, err := svc.ValidateStruct(v)
+		if err != nil {
+			t.Fatalf("ValidateStruct(%T) failed: %v", v, err)
+		}
+		return errs
+	}
+
+	valid := svc.SignupRequest{Email: "ada@example.com", Password: "correct horse"}
+	if errs := validate(&valid); errs != nil {
+		t.Errorf("ValidateStruct(valid signup) = %v, want nil", errs)
+	}
+
+	invalid := svc.SignupRequest{Email: "not an email", Password: "short"}
+	errs := validate(&invalid)
+	if len(errs) != 2 || errs[0].Field != "email" || errs[0].Rule != "email" || errs[1].Field != "password" || errs[1].Rule != "min" {
+		t.Errorf("ValidateStruct(invalid signup) = %+v, want email and password errors", errs)
+	}
+
+	// bcrypt rejects passwords over 72 bytes, however few characters they are
+	for password, wantErr := range map[string]bool{strings.Repeat("é", 36): false, strings.Repeat("é", 37): true} {
+		errs := validate(svc.SignupRequest{Email: "ada@example.com", Password: password})
+		if (errs != nil) != wantErr || (wantErr && errs[0].Rule != "maxbytes") {
+			t.Errorf("ValidateStruct(%d-byte password) = %+v, want error %v", len(password), errs, wantErr)
+		}
+	}
+
+	for rating, wantErr := range map[int]bool{0: true, 1: false, 5: false, 6: true} {
+		errs := validate(svc.RateSessionRequest{Rating: rating})
+		if (errs != nil) != wantErr {
+			t.Errorf("ValidateStruct(rating %d) = %v, want error %v", rating, errs, wantErr)
+		}
+	}
+
+	if errs := validate(svc.BulkDeleteRequest{SessionIDs: []string{}}); len(errs) != 1 || errs[0].Field != "session_ids" {
+		t.Errorf("ValidateStruct(no session IDs) = %+v, want a session_ids error", errs)
+	}
+}
+
+func TestCheckValidateTags(t *testing.T) {
+	if err := svc.CheckValidateTags(svc.ValidatedRequests()...); err != nil {
+		t.Fatalf("CheckValidateTags(ValidatedRequests) = %v", err)
+	}
+
+	malformed := []interface{}{
+		struct {
+			Name string `validate:"required,maxlen=10"`
+		}{},
+		struct {
+			Age int `validate:"min=ten"`
+		}{},
+		struct {
+			Mode string `validate:"oneof="`
+		}{},
+	}
+	for _, request := range malformed {
+		if err := svc.CheckValidateTags(request); err == nil {
+			t.Errorf("CheckValidateTags(%T) accepted a malformed tag", request)
+		}
+		if _, err := svc.ValidateStruct(request); err == nil {
+			t.Errorf("ValidateStruct(%T) accepted a malformed tag", request)
+		}
+	}
+
+	// Every request type with validate tags is checked at startup
+	checked := make(map[string]bool)
+	for _, request := range svc.ValidatedRequests() {
+		checked[reflect.TypeOf(request).Name()] = true
+	}
+	packages, err := parser.ParseDir(token.NewFileSet(), "services", nil, 0)
+	if err != nil {
+		t.Fatalf("parsing services: %v", err)
+	}
+	for _, file := range packages["services"].Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			if fields, ok := spec.Type.(*ast.StructType); ok {
+				for _, field := range fields.Fields.List {
+					if field.Tag != nil && strings.Contains(field.Tag.Value, `validate:"`) && !checked[spec.Name.Name] {
+						t.Errorf("%s has validate tags but isn't in ValidatedRequests", spec.Name.Name)
+						break
+					}
+				}
+			}
+			return false
+		})
+	}
+}
+
+func TestImportedSession(t *testing.T) {
+	csv := "date,title,level,overall_score,max_score,score_Problem Solving,score_communication\n" +
+		"2025-03-02,System design mock,Senior,4,5,3.5,4.5\n"
+	format, records, err := svc.ParseImportFile("history.csv", []byte(csv))
+	if err != nil || format != "csv" || len(records) != 1 {
+		t.Fatalf("ParseImportFile(csv) = %q, %d records, %v; want csv, 1 record, nil", format, len(records), err)
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	session, err := svc.ImportedSession("Pramp", records[0], now)
+	if err != nil {
+		t.Fatalf("ImportedSession() error = %v", err)
+	}
+	if session.Status != "completed" || session.StartedAt != time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC) {
+		t.Errorf("ImportedSession() status %q started %v, want completed at noon UTC", session.Status, session.StartedAt)
+	}
+	if session.Summary.OverallScore != 80 || session.Agent.Name != "System design mock" || session.Agent.Level != "senior" {
+		t.Errorf("ImportedSession() score %v agent %q %q, want 80 and the record's title and level", session.Summary.OverallScore, session.Agent.Name, session.Agent.Level)
+	}
+	scores := map[string]float64{}
+	for _, score := range session.PerformanceScores {
+		scores[score.Metric] = score.Score
+	}
+	if scores["problem_solving"] != 70 || scores["communication"] != 90 {
+		t.Errorf("ImportedSession() scores = %v, want problem_solving 70 and communication 90", scores)
+	}
+
+	_, records, err = svc.ParseImportFile("export", []byte(`{"sessions": [{"date": "2025-03-02", "scores": {"coding": 60, "design": 80}}]}`))
+	if err != nil || len(records) != 1 {
+		t.Fatalf("ParseImportFile(json) = %d records, %v; want 1 record", len(records), err)
+	}
+	if session, err := svc.ImportedSession("Pramp", records[0], now); err != nil || session.Summary.OverallScore != 70 || session.Agent.Name != "Pramp interview" {
+		t.Errorf("ImportedSession(no overall score) = %+v, %v; want the average of its scores, 70", session, err)
+	}
+
+	for name, record := range map[string]svc.ImportRecord{
+		"no date":       {Scores: map[string]float64{"coding": 50}},
+		"future date":   {Date: "2026-02-01", Scores: map[string]float64{"coding": 50}},
+		"no scores":     {Date: "2025-03-02"},
+		"above maximum": {Date: "2025-03-02", Scores: map[string]float64{"coding": 6}, MaxScore: 5},
+	} {
+		if _, err := svc.ImportedSession("Pramp", record, now); err == nil {
+			t.Errorf("ImportedSession(%s) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestExportDownloadURL(t *testing.T) {
+	store, err := svc.NewFilesystemBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystemBlobStore failed: %v", err)
+	}
+	exports := svc.NewExportJobService(nil, store, svc.StorageConfig{}, nil, svc.ExportConfig{URLTTL: 15 * time.Minute}, "jwt-secret", "https://praxis.example/")
+
+	now := time.Now()
+	retained := now.Add(5 * time.Minute)
+	status := exports.Status(&models.ExportJob{ID: "job-1", Status: models.ExportJobDone, ExpiresAt: &retained})
+	if status.URLExpiresAt == nil || !status.URLExpiresAt.Equal(retained) {
+		t.Fatalf("Status() URL expires at %v, want the archive's expiry %v", status.URLExpiresAt, retained)
+	}
+	link, err := url.Parse(status.DownloadURL)
+	if err != nil || link.Host != "praxis.example" || link.Path != "/api/v1/exports/job-1/download" {
+		t.Fatalf("Status() download URL = %q, want the job's download route", status.DownloadURL)
+	}
+
+	expires, signature := link.Query().Get("expires"), link.Query().Get("signature")
+	if err := exports.VerifyDownload("job-1", expires, signature, now); err != nil {
+		t.Errorf("VerifyDownload() = %v, want the URL's own signature accepted", err)
+	}
+	if err := exports.VerifyDownload("job-1", expires, signature, retained.Add(time.Second)); err == nil {
+		t.Error("VerifyDownload(after expiry) succeeded, want an error")
+	}
+	if err := exports.VerifyDownload("job-2", expires, signature, now); err == nil {
+		t.Error("VerifyDownload(another job) succeeded, want an error")
+	}
+	later := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+	if err := exports.VerifyDownload("job-1", later, signature, now); err == nil {
+		t.Error("VerifyDownload(extended expiry) succeeded, want an error")
+	}
+
+	other := svc.NewExportJobService(nil, store, svc.StorageConfig{}, nil, svc.ExportConfig{}, "rotated-secret", "https://praxis.example")
+	if err := other.VerifyDownload("job-1", expires, signature, now); err == nil {
+		t.Error("VerifyDownload(rotated secret) succeeded, want an error")
+	}
+
+	if status := exports.Status(&models.ExportJob{ID: "job-1", Status: models.ExportJobRunning, Progress: 3, Total: 12}); status.Percent != 25 || status.DownloadURL != "" {
+		t.Errorf("Status(running) = %v%% %q, want 25%% and no download URL", status.Percent, status.DownloadURL)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="report.pdf"`)
+		apperrors.Write(w, r, apperrors.NotFound("Session not found").WithDetails(map[string]string{"session_id": "s-1"}))
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/sessions/s-1/export", nil))
+
+	if rr.Code != http.StatusNotFound || rr.Header().Get("Content-Type") != "application/json" || rr.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("Write() = %d %q, want a 404 JSON body without the attachment header", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var body apperrors.Body
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Write() body isn't JSON: %v", err)
+	}
+	if body.Code != apperrors.CodeNotFound || body.Message != "Session not found" || body.RequestID == "" || body.Details == nil {
+		t.Errorf("Write() body = %+v, want code, message, details and request_id", body)
+	}
+
+	// Errors that aren't application errors mustn't leak their text
+	rr = httptest.NewRecorder()
+	apperrors.Write(rr, httptest.NewRequest("GET", "/", nil), errors.New("pq: connection refused"))
+	if rr.Code != http.StatusInternalServerError || strings.Contains(rr.Body.String(), "pq:") {
+		t.Errorf("Write(plain error) = %d %s, want a generic internal error", rr.Code, rr.Body.String())
+	}
+}
+
+func TestSummarizeTyping(t *testing.T) {
+	if summary := svc.SummarizeTyping(nil); summary.Answers != 0 || summary.WordsPerMinute != 0 {
+		t.Errorf("SummarizeTyping(nil) = %+v, want an empty summary", summary)
+	}
+
+	summary := svc.SummarizeTyping([]models.TurnMetrics{
+		{ResponseLatencyMs: 2000, ComposeMs: 30000, Edits: 5, PastedChars: 0, Characters: 200, Words: 40},
+		{ResponseLatencyMs: 4000, ComposeMs: 60000, Edits: 10, PastedChars: 100, Characters: 300, Words: 50},
+		{ResponseLatencyMs: 9000, ComposeMs: 30000, Edits: 0, PastedChars: 0, Characters: 0, Words: 0},
+	})
+	if summary.Answers != 3 || summary.MedianLatencyMs != 4000 || summary.MedianComposeMs != 30000 {
+		t.Errorf("SummarizeTyping() = %+v, want 3 answers with median latency 4000 and compose 30000", summary)
+	}
+	// 90 words over 2 minutes; 15 edits and 100 pasted of 500 characters
+	if summary.WordsPerMinute != 45 || summary.EditsPer100Chars != 3 || summary.PastedShare != 0.2 {
+		t.Errorf("SummarizeTyping() = %+v, want 45 wpm, 3 edits per 100 characters and 0.2 pasted", summary)
+	}
+}
+
+func TestBuildReplay(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	session := &models.InterviewSession{StartedAt: start}
+	transcripts := []models.InterviewTranscript{
+		{ID: "answer", Speaker: "user", Kind: models.TranscriptKindText, Timestamp: start.Add(20 * time.Second)},
+		{ID: "welcome", Speaker: "agent", Kind: models.TranscriptKindText, Timestamp: start.Add(-time.Second)},
+		{ID: "code", Speaker: "user", Kind: models.TranscriptKindCode, Timestamp: start.Add(40 * time.Second)},
+		{ID: "question", Speaker: "agent", Kind: models.TranscriptKindText, Timestamp: start.Add(25 * time.Second)},
+	}
+	answerID := "answer"
+	recordings := []models.AudioRecording{
+		{ID: "welcome-audio", Speaker: "agent", CreatedAt: start.Add(2 * time.Second)},
+		// Linked to its turn, however long after it the upload finished
+		{ID: "answer-audio", Speaker: "user", TranscriptID: &answerID, CreatedAt: start.Add(30 * time.Minute)},
+		{ID: "question-audio", Speaker: "agent", CreatedAt: start.Add(27 * time.Second)},
+		{ID: "stray-audio", Speaker: "user", CreatedAt: start.Add(time.Hour)},
+	}
+
+	turns := svc.BuildReplay(session, transcripts, recordings)
+	want := []struct {
+		id     string
+		offset int64
+		audio  string
+	}{
+		{"welcome", 0, "welcome-audio"},
+		{"answer", 20000, "answer-audio"},
+		{"question", 25000, "question-audio"},
+		{"code", 40000, ""},
+	}
+	if len(turns) != len(want) {
+		t.Fatalf("BuildReplay() returned %d turns, want %d", len(turns), len(want))
+	}
+	for i, w := range want {
+		turn := turns[i]
+		audio := ""
+		if turn.Audio != nil {
+			audio = turn.Audio.RecordingID
+		}
+		if turn.TranscriptID != w.id || turn.OffsetMs != w.offset || audio != w.audio {
+			t.Errorf("turn %d = %s at %dms with audio %q, want %s at %dms with audio %q", i, turn.TranscriptID, turn.OffsetMs, audio, w.id, w.offset, w.audio)
+		}
+	}
+}
+
+func TestObjectBlobStore(t *testing.T) {
+	objects := make(map[string][]byte)
+	var classes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") || r.Header.Get("X-Amz-Date") == "" {
+			t.Errorf("%s %s has Authorization %q, want a SigV4 signature", r.Method, r.URL.Path, auth)
+		}
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = body
+			classes = append(classes, r.Header.Get("X-Amz-Storage-Class"))
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+				return
+			}
+			w.Write(body)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store, err := svc.NewS3BlobStore(svc.StorageConfig{Backend: "s3", Bucket: "praxis", Region: "eu-west-1", Endpoint: server.URL, AccessKey: "AKID", SecretKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewS3BlobStore failed: %v", err)
+	}
+	ctx := context.Background()
+	hot, cold := store.DefaultClasses()
+	if err := store.Put(ctx, "session-1/turn-1-user", hot, []byte("answer")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Put(ctx, "session-1/turn-1-user", cold, []byte("answer")); err != nil {
+		t.Fatalf("Put(cold) failed: %v", err)
+	}
+	if _, ok := objects["/praxis/STANDARD/session-1/turn-1-user"]; !ok || len(objects) != 2 || !slices.Equal(classes, []string{"STANDARD", "GLACIER_IR"}) {
+		t.Fatalf("stored %v with classes %v, want a copy under each class's prefix", slices.Collect(maps.Keys(objects)), classes)
+	}
+
+	// Dropping the hot copy of an archived recording leaves the cold one
+	if err := store.Delete(ctx, "session-1/turn-1-user", hot); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if data, err := store.Get(ctx, "session-1/turn-1-user", cold); err != nil || string(data) != "answer" {
+		t.Errorf("Get(cold) = %q, %v, want the archived copy", data, err)
+	}
+	if _, err := store.Get(ctx, "session-1/turn-1-user", hot); err == nil {
+		t.Error("Get(deleted) succeeded, want an error")
+	}
+	if err := store.Put(ctx, "../escape", hot, nil); err == nil {
+		t.Error("Put(../escape) succeeded, want the key rejected")
+	}
+
+	link, err := store.SignedURL("session-1/turn-1-user", cold, "audio/mpeg", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL failed: %v", err)
+	}
+	signed, _ := url.Parse(link)
+	query := signed.Query()
+	if signed.Path != "/praxis/GLACIER_IR/session-1/turn-1-user" || query.Get("X-Amz-Expires") != "600" || query.Get("response-content-type") != "audio/mpeg" || len(query.Get("X-Amz-Signature")) != 64 {
+		t.Errorf("SignedURL() = %q, want a 10 minute presigned GET of the cold copy", link)
+	}
+}
+
+func TestObjectBlobStoreRoleCredentials(t *testing.T) {
+	// The task role's credentials are rotated by the container endpoint; the first ones are
+	// about to expire, so they're used once and replaced, and the second ones are kept
+	var fetches atomic.Int32
+	credentials := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "pod-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		n := fetches.Add(1)
+		lifetime := time.Minute
+		if n > 1 {
+			lifetime = time.Hour
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"AccessKeyId":     "ASIA" + strconv.Itoa(int(n)),
+			"SecretAccessKey": "secret",
+			"Token":           "session-" + strconv.Itoa(int(n)),
+			"Expiration":      time.Now().Add(lifetime).UTC().Format(time.RFC3339),
+		})
+	}))
+	defer credentials.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("pod-token\n"), 0o600)
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", credentials.URL+"/v1/credentials")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", tokenFile)
+
+	var signed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		key, _, _ := strings.Cut(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 Credential="), "/")
+		signed = append(signed, key+" "+r.Header.Get("X-Amz-Security-Token"))
+	}))
+	defer server.Close()
+
+	store, err := svc.NewS3BlobStore(svc.StorageConfig{Backend: "s3", Bucket: "praxis", Region: "eu-west-1", Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("NewS3BlobStore failed: %v", err)
+	}
+	for range 3 {
+		if err := store.Put(context.Background(), "session-1/turn-1-user", "STANDARD", []byte("answer")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	want := []string{"ASIA1 session-1", "ASIA2 session-2", "ASIA2 session-2"}
+	if !slices.Equal(signed, want) || fetches.Load() != 2 {
+		t.Errorf("requests signed with %v after %d fetches, want %v after 2", signed, fetches.Load(), want)
+	}
+
+	link, err := store.SignedURL("session-1/turn-1-user", "STANDARD", "", 10*time.Minute)
+	if err != nil || !strings.Contains(link, "X-Amz-Credential=ASIA2") || !strings.Contains(link, "X-Amz-Security-Token=session-2") {
+		t.Errorf("SignedURL() = %q, %v, want it signed with the current role credentials", link, err)
+	}
+}
+
+func TestRecordingAudioURL(t *testing.T) {
+	store, err := svc.NewFilesystemBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystemBlobStore failed: %v", err)
+	}
+	recordings := svc.NewRecordingService(nil, store, svc.StorageConfig{AudioURLTTL: 5 * time.Minute}, "jwt-secret", "https://praxis.example/")
+
+	now := time.Now()
+	link, expiresAt, err := recordings.AudioURL(&models.AudioRecording{ID: "rec-1"}, now)
+	if err != nil || !expiresAt.Equal(now.Add(5*time.Minute)) {
+		t.Fatalf("AudioURL() = %v, %v, want a link expiring in 5 minutes", expiresAt, err)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host != "praxis.example" || parsed.Path != "/api/v1/recordings/rec-1/audio" {
+		t.Fatalf("AudioURL() = %q, want the recording's audio route", link)
+	}
+
+	expires, signature := parsed.Query().Get("expires"), parsed.Query().Get("signature")
+	if err := recordings.VerifyAudio("rec-1", expires, signature, now); err != nil {
+		t.Errorf("VerifyAudio() = %v, want the link's own signature accepted", err)
+	}
+	if err := recordings.VerifyAudio("rec-2", expires, signature, now); err == nil {
+		t.Error("VerifyAudio(another recording) succeeded, want an error")
+	}
+	if err := recordings.VerifyAudio("rec-1", expires, signature, expiresAt.Add(time.Second)); err == nil {
+		t.Error("VerifyAudio(after expiry) succeeded, want an error")
+	}
+}
+
+func TestProviderHealth(t *testing.T) {
+	health := &svc.ProviderHealth{}
+	ctx := context.Background()
+	failure := errors.New("503 Service Unavailable")
+
+	health.Report(ctx, failure)
+	health.Report(ctx, failure)
+	if status := health.Status(time.Now()); status != svc.StatusOperational {
+		t.Errorf("Status() after 2 failures = %s, want operational", status)
+	}
+	health.Report(ctx, failure)
+	if status := health.Status(time.Now()); status != svc.StatusOutage {
+		t.Errorf("Status() after 3 failures = %s, want outage", status)
+	}
+	if status := health.Status(time.Now().Add(10 * time.Minute)); status != svc.StatusOperational {
+		t.Errorf("Status() 10 minutes after the last failure = %s, want operational", status)
+	}
+
+	// A call the caller gave up on says nothing about the provider
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	health.Report(ctx, nil)
+	health.Report(cancelled, failure)
+	health.Report(cancelled, failure)
+	health.Report(cancelled, failure)
+	if status := health.Status(time.Now()); status != svc.StatusOperational {
+		t.Errorf("Status() after a success and cancelled calls = %s, want operational", status)
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	checks := 0
+	llm := &svc.ProviderHealth{}
+	status := svc.NewStatusService(nil, func() string { checks++; return "down" }, llm, &svc.ProviderHealth{}, svc.NoopSpeechService{}, nil)
+
+	w := httptest.NewRecorder()
+	status.StatusHandler(w, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	if cache := w.Header().Get("Cache-Control"); cache != "public, max-age=30" {
+		t.Errorf("Cache-Control = %q, want public caching", cache)
+	}
+	var body svc.ServiceStatus
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if body.Status != svc.StatusOutage || len(body.Queues) != 0 {
+		t.Errorf("status without a database = %s with %d queues, want an outage and no queues", body.Status, len(body.Queues))
+	}
+	want := []string{svc.FeatureInterviews, svc.FeatureSummaries, svc.FeatureVoiceReplies}
+	if !slices.Equal(body.DegradedFeatures, want) {
+		t.Errorf("degraded features = %v, want %v", body.DegradedFeatures, want)
+	}
+
+	// Within the cache TTL the checks aren't run again
+	status.Current(context.Background())
+	if checks != 1 {
+		t.Errorf("database checked %d times, want the cached status served", checks)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	deprecation := svc.APIDeprecation{
+		Since:  time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2027, time.April, 16, 0, 0, 0, 0, time.UTC),
+	}
+	handler := svc.Deprecated(deprecation)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/summaries/session/abc", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want the endpoint to still answer", w.Code)
+	}
+	if got := w.Header().Get("Deprecation"); got != "@1792108800" {
+		t.Errorf("Deprecation = %q, want @1792108800", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Fri, 16 Apr 2027 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</api/v2/summaries/session/abc>; rel="successor-version"` {
+		t.Errorf("Link = %q, want the v2 path", got)
+	}
+}
+
+func TestInterviewTypeMetrics(t *testing.T) {
+	general := svc.InterviewTypeMetrics("")
+	if !slices.Equal(general, []string{"Communication", "Technical Knowledge", "Problem Solving", "Professionalism"}) {
+		t.Errorf("general metrics = %v", general)
+	}
+	if got := svc.InterviewTypeMetrics("unknown"); !slices.Equal(got, general) {
+		t.Errorf("unknown type metrics = %v, want the general ones", got)
+	}
+
+	seen := make(map[string]string)
+	for _, interviewType := range []string{models.InterviewTypeBehavioral, models.InterviewTypeTechnical, models.InterviewTypeSystemDesign, models.InterviewTypeCaseStudy} {
+		metrics := svc.InterviewTypeMetrics(interviewType)
+		if len(metrics) == 0 || slices.Equal(metrics, general) {
+			t.Errorf("%s metrics = %v, want its own", interviewType, metrics)
+		}
+		key := strings.Join(metrics, ",")
+		if other, ok := seen[key]; ok {
+			t.Errorf("%s and %s share metrics %v", interviewType, other, metrics)
+		}
+		seen[key] = interviewType
+	}
+}
+
+func TestValidateRubric(t *testing.T) {
+	items, err := svc.ValidateRubric(svc.RubricRequest{Items: []svc.RubricItemRequest{
+		{Metric: " Stakeholder Management ", Description: "Handles conflicting asks", Weight: 3},
+		{Metric: "Prioritization"},
+	}})
+	if err != nil {
+		t.Fatalf("ValidateRubric returned %v", err)
+	}
+	if items[0].Metric != "Stakeholder Management" || items[0].Weight != 3 || items[1].Weight != 1 {
+		t.Errorf("items = %+v, want trimmed metrics and a default weight of 1", items)
+	}
+
+	invalid := map[string][]svc.RubricItemRequest{
+		"empty":     nil,
+		"no metric": {{Metric: " "}},
+		"duplicate": {{Metric: "Clarity"}, {Metric: "clarity"}},
+		"reserved":  {{Metric: "Stage: coding"}},
+		"negative":  {{Metric: "Clarity", Weight: -1}},
+	}
+	for name, req := range invalid {
+		if _, err := svc.ValidateRubric(svc.RubricRequest{Items: req}); err == nil {
+			t.Errorf("ValidateRubric accepted a %s rubric", name)
+		}
+	}
+}
+
+func TestBuildCoachingPrompt(t *testing.T) {
+	agent := &models.Agent{Level: "senior", Industry: "software"}
+
+	prompt := svc.BuildCoachingPrompt(agent, "de", "How would you cache this?", "With Redis, because reads dominate.")
+	for _, want := range []string{"senior level software", "How would you cache this?", "With Redis, because reads dominate.", `"de"`, "Do not give them the answer"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	if prompt := svc.BuildCoachingPrompt(agent, "en", "", "An answer"); strings.Contains(prompt, "Interviewer's question") {
+		t.Errorf("prompt without a question has a question section:\n%s", prompt)
+	}
+}
+
+func TestSpeechAnalysis(t *testing.T) {
+	if got := svc.CountFillerWords("Um, so I think, uh, you know, the cache... Hmm. I mean it's basically umbrella-shaped."); got != 6 {
+		t.Errorf("CountFillerWords() = %d, want 6", got)
+	}
+
+	answer := svc.AnalyzeSpeech("s", "t", "Um we sharded the table by tenant", &ws.SpeechTiming{DurationMs: 6000, PausesMs: []int64{1500, 1000}})
+	if answer.Words != 7 || answer.FillerWords != 1 || answer.Pauses != 2 || answer.PauseMs != 2500 || answer.LongestPauseMs != 1500 {
+		t.Errorf("AnalyzeSpeech() = %+v", answer)
+	}
+
+	if summary := svc.SummarizeSpeech(nil); summary.Answers != 0 || summary.WordsPerMinute != 0 {
+		t.Errorf("SummarizeSpeech(nil) = %+v, want an empty summary", summary)
+	}
+	summary := svc.SummarizeSpeech([]models.SpeechMetrics{
+		{Words: 140, FillerWords: 4, DurationMs: 60000, Pauses: 3, PauseMs: 6000, LongestPauseMs: 3000},
+		{Words: 60, FillerWords: 0, DurationMs: 30000, Pauses: 0},
+		{Words: 100, FillerWords: 2}, // Without timing
+	})
+	if summary.Answers != 3 || summary.TimedAnswers != 2 || summary.MedianWords != 100 || summary.LongestPauseMs != 3000 {
+		t.Errorf("SummarizeSpeech() = %+v, want 3 answers, 2 timed, median 100 words and a 3000ms longest pause", summary)
+	}
+	// 200 timed words over 1.5 minutes; 6 fillers in 300 words; 3 pauses and 6 of 90 seconds silent
+	if summary.WordsPerMinute != 133.3 || summary.FillersPer100 != 2 || summary.PausesPerMinute != 2 || summary.PauseShare != 0.07 {
+		t.Errorf("SummarizeSpeech() = %+v, want 133.3 wpm, 2 fillers per 100 words, 2 pauses a minute and 0.07 silent", summary)
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	got := svc.HighlightSnippet("put a \x02load\x03 \x02balancer\x03 in front of <script> & the API")
+	want := "put a <mark>load</mark> <mark>balancer</mark> in front of &lt;script&gt; &amp; the API"
+	if got != want {
+		t.Errorf("HighlightSnippet() = %q, want %q", got, want)
+	}
+}
+
+func TestDeleteAccountChecks(t *testing.T) {
+	store, err := svc.NewFilesystemBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystemBlobStore failed: %v", err)
+	}
+	purge := svc.NewDataPurgeService(nil, store, svc.StorageConfig{}, svc.PurgeConfig{})
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword failed: %v", err)
+	}
+
+	// Both are refused before anything is deleted
+	tests := []struct {
+		name, role, password string
+		want                 int
+	}{
+		{"wrong password", "user", "battery staple", http.StatusUnauthorized},
+		{"admin", "admin", "correct horse", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		user := &models.User{ID: "user", Role: tt.role, Password: string(hash)}
+		err := purge.DeleteAccount(context.Background(), user, tt.password)
+		var appErr *apperrors.Error
+		if !errors.As(err, &appErr) || appErr.Status != tt.want {
+			t.Errorf("%s: DeleteAccount = %v, want status %d", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	cfg := &svc.Config{}
+	cfg.Database.URL = "postgres://praxis:hunter2@db:5432/praxis"
+	cfg.AI.GeminiAPIKey = "gemini-key"
+	cfg.AI.Timeouts.LLM = time.Minute
+	cfg.Server.Port = "8080"
+
+	redacted := svc.RedactConfig(cfg)
+	db := redacted["Database"].(map[string]interface{})
+	if url := db["URL"].(string); strings.Contains(url, "hunter2") || !strings.Contains(url, "db:5432") {
+		t.Errorf("Database.URL = %q, want the password hidden", url)
+	}
+	ai := redacted["AI"].(map[string]interface{})
+	if ai["GeminiAPIKey"] != "[redacted]" {
+		t.Errorf("AI.GeminiAPIKey = %v, want [redacted]", ai["GeminiAPIKey"])
+	}
+	if ai["OpenAIKey"] != "" {
+		t.Errorf("AI.OpenAIKey = %v, want empty", ai["OpenAIKey"])
+	}
+	if got := ai["Timeouts"].(map[string]interface{})["LLM"]; got != "1m0s" {
+		t.Errorf("AI.Timeouts.LLM = %v, want 1m0s", got)
+	}
+	if got := redacted["Server"].(map[string]interface{})["Port"]; got != "8080" {
+		t.Errorf("Server.Port = %v, want 8080", got)
+	}
+
+	// A key=value connection string can't be parsed, so it is hidden whole
+	cfg.Database.URL = "host=db user=praxis password=hunter2"
+	if got := svc.RedactConfig(cfg)["Database"].(map[string]interface{})["URL"]; got != "[redacted]" {
+		t.Errorf("Database.URL = %v, want [redacted]", got)
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	var requestID string
+	handler := middleware.RequestID(logging.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = middleware.GetReqID(r.Context())
+		ctx := logging.With(r.Context(), "user_id", "user-1")
+		logging.FromContext(ctx).Info("Handled")
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/sessions", nil))
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log line %q is not JSON: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{"msg": "Handled", "request_id": requestID, "user_id": "user-1", "method": "GET", "path": "/api/v1/sessions"}
+	for key, value := range want {
+		if line[key] != value {
+			t.Errorf("%s = %v, want %v", key, line[key], value)
+		}
+	}
+
+	// Outside a request the default logger is used
+	if logging.FromContext(context.Background()) != slog.Default() {
+		t.Error("FromContext without a logger should return the default logger")
+	}
+}
+
+func TestRestrictGuests(t *testing.T) {
+	invites := svc.NewInterviewInviteService(nil, nil, nil, nil, "")
+	handler := invites.RestrictGuests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		role, method, path string
+		want               int
+	}{
+		{models.UserRoleGuest, http.MethodGet, "/api/v1/agents", http.StatusNoContent},
+		{models.UserRoleGuest, http.MethodPost, "/api/v1/sessions/abc/end", http.StatusNoContent},
+		{models.UserRoleGuest, http.MethodPut, "/api/v1/sessions/abc/rating", http.StatusNoContent},
+		{models.UserRoleGuest, http.MethodPost, "/api/v1/users/me/convert", http.StatusNoContent},
+		{models.UserRoleGuest, http.MethodPost, "/api/v1/legal/accept", http.StatusNoContent},
+		{models.UserRoleGuest, http.MethodPost, "/api/v1/sessions", http.StatusForbidden},
+		{models.UserRoleGuest, http.MethodPost, "/api/v1/sessions/", http.StatusForbidden},
+		{models.UserRoleGuest, http.MethodPost, "/api/v1/agents", http.StatusForbidden},
+		{models.UserRoleGuest, http.MethodPost, "/api/v1/orgs", http.StatusForbidden},
+		{models.UserRoleGuest, http.MethodPost, "/api/v1/api-keys", http.StatusForbidden},
+		{"user", http.MethodPost, "/api/v1/sessions", http.StatusNoContent},
+		{"user", http.MethodPost, "/api/v1/agents", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), "user", &models.User{ID: "user", Role: tt.role}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s %s = %d, want %d", tt.role, tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestCompareCandidates(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	session := func(id, userID string, overall float64, daysAgo int, scores ...models.PerformanceScore) models.InterviewSession {
+		return models.InterviewSession{
+			ID:                id,
+			UserID:            userID,
+			User:              models.User{ID: userID, Email: userID + "@example.com"},
+			Status:            "completed",
+			StartedAt:         start.AddDate(0, 0, -daysAgo),
+			Summary:           &models.InterviewSummary{OverallScore: overall},
+			PerformanceScores: scores,
+		}
+	}
+	communication := func(score, max float64) models.PerformanceScore {
+		return models.PerformanceScore{Metric: "communication", Score: score, MaxScore: max}
+	}
+
+	agent := &models.Agent{ID: "agent-1", Name: "Backend Screen"}
+	// Latest first, as the repository returns them; u1's older session is ignored
+	sessions := []models.InterviewSession{
+		session("s1", "u1", 80, 0, communication(8, 10)),
+		session("s2", "u2", 60, 1, communication(50, 100)),
+		session("s3", "u3", 80, 2, communication(4, 5)),
+		session("s4", "u4", 40, 3),
+		session("s0", "u1", 20, 9, communication(1, 10)),
+	}
+	comparison := svc.CompareCandidates(agent, sessions, nil)
+
+	if comparison.Candidates != 4 || len(comparison.Rankings) != 4 {
+		t.Fatalf("candidates = %d, rankings = %d, want 4", comparison.Candidates, len(comparison.Rankings))
+	}
+	wantOrder := []struct {
+		session    string
+		rank       int
+		percentile float64
+	}{
+		{"s3", 1, 75}, // Ties with s1, which started later
+		{"s1", 1, 75},
+		{"s2", 3, 37.5},
+		{"s4", 4, 12.5},
+	}
+	for i, want := range wantOrder {
+		got := comparison.Rankings[i]
+		if got.SessionID != want.session || got.Rank != want.rank || got.Percentile != want.percentile {
+			t.Errorf("ranking %d = %s rank %d percentile %v, want %s rank %d percentile %v", i, got.SessionID, got.Rank, got.Percentile, want.session, want.rank, want.percentile)
+		}
+	}
+
+	// Scores on different scales compare once normalized; s4 wasn't scored on the metric
+	if placement := comparison.Rankings[0].Metrics["communication"]; placement.Score != 80 || placement.Percentile != 66.7 {
+		t.Errorf("s3 communication = %+v, want score 80 at the 66.7th percentile", placement)
+	}
+	if _, ok := comparison.Rankings[3].Metrics["communication"]; ok {
+		t.Error("a candidate without the metric should not be placed in it")
+	}
+
+	overall := comparison.Overall
+	if overall.Mean != 65 || overall.Median != 70 || overall.Min != 40 || overall.Max != 80 || overall.P25 != 55 || overall.P75 != 80 {
+		t.Errorf("overall distribution = %+v", overall)
+	}
+	if overall.Histogram[4] != 1 || overall.Histogram[6] != 1 || overall.Histogram[8] != 2 {
+		t.Errorf("overall histogram = %v", overall.Histogram)
+	}
+	if len(comparison.Metrics) != 1 || comparison.Metrics[0].Metric != "communication" || comparison.Metrics[0].Candidates != 3 {
+		t.Errorf("metric distributions = %+v", comparison.Metrics)
+	}
+}
+
+func TestHubObservers(t *testing.T) {
+	hub := ws.NewHub()
+	go hub.Run()
+
+	candidate := hub.RegisterClient(context.Background(), nil, "candidate", "session")
+	observer := hub.RegisterObserver(context.Background(), nil, "admin", "session")
+	other := hub.RegisterObserver(context.Background(), nil, "admin", "other-session")
+
+	if clients := hub.SessionClients("session"); len(clients) != 1 || clients[0] != candidate {
+		t.Fatalf("SessionClients = %v, want only the candidate", clients)
+	}
+	if observers := hub.SessionObservers("session"); len(observers) != 1 || observers[0] != observer {
+		t.Fatalf("SessionObservers = %v, want only the observer", observers)
+	}
+
+	// Only the session's observers get what is broadcast to it
+	hub.Broadcast("session", []byte("turn"))
+	select {
+	case got := <-observer.Send:
+		if string(got) != "turn" {
+			t.Errorf("observer got %q, want turn", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("observer didn't get the broadcast")
+	}
+	if len(candidate.Send) != 0 || len(other.Send) != 0 {
+		t.Error("the broadcast should only reach the session's observers")
+	}
+
+	// Closing the observers sends the last message, then closes them; the candidate stays
+	hub.CloseObservers("session", []byte("ended"))
+	if got := <-observer.Send; string(got) != "ended" {
+		t.Errorf("observer got %q, want ended", got)
+	}
+	if _, open := <-observer.Send; open {
+		t.Error("observer should be disconnected")
+	}
+	if len(hub.SessionObservers("session")) != 0 || len(hub.SessionClients("session")) != 1 {
+		t.Error("only the candidate should remain connected")
+	}
+
+	// Takeovers need a question and a mode
+	for payload, valid := range map[string]bool{
+		`{"question":"Why Go?","mode":"inject"}`:   true,
+		`{"question":"Why Go?","mode":"override"}`: true,
+		`{"question":"Why Go?","mode":"replace"}`:  false,
+		`{"question":" ","mode":"inject"}`:         false,
+	} {
+		_, perr := ws.DecodePayload(ws.Envelope{V: ws.ProtocolVersion, Type: ws.TypeTakeover, Payload: json.RawMessage(payload)})
+		if (perr == nil) != valid {
+			t.Errorf("DecodePayload(%s) error = %v, want valid %v", payload, perr, valid)
+		}
+	}
+}
+
+// fakeDB is a database the repository can run against without Postgres: each query is
+// answered with the rows of the first stub whose pattern its SQL contains, or nothing, and
+// every statement is recorded
+type fakeDB struct {
+	mu         sync.Mutex
+	stubs      []fakeStub
+	statements []string
+}
+
+// fakeStub answers the statements containing pattern: queries with rows, and writes by
+// affecting that many rows, or fails them with err
+type fakeStub struct {
+	pattern  string
+	rows     []map[string]driver.Value
+	affected int64
+	err      error
+}
+
+func newFakeRepository(t *testing.T, stubs ...fakeStub) (*repository.GORMRepository, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{stubs: stubs}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{
+		Logger: gormlogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open failed: %v", err)
+	}
+	return repository.NewGORMRepository(db), fake
+}
+
+// answerWith answers the statements containing the stub's pattern with it from now on
+func (f *fakeDB) answerWith(stub fakeStub) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stubs = append([]fakeStub{stub}, f.stubs...)
+}
+
+// ran reports whether a statement containing pattern was run
+func (f *fakeDB) ran(pattern string) bool {
+	return f.count(pattern) > 0
+}
+
+// count is how many statements containing pattern were run
+func (f *fakeDB) count(pattern string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, statement := range f.statements {
+		if strings.Contains(statement, pattern) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeDB) answer(query string) fakeStub {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, query)
+	for _, stub := range f.stubs {
+		if strings.Contains(query, stub.pattern) {
+			return stub
+		}
+	}
+	return fakeStub{}
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return f }
+func (f *fakeDB) Open(string) (driver.Conn, error)             { return fakeConn{f}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakeDB doesn't prepare statements")
+}
+func (c fakeConn) Close() error                                                 { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                                    { return c, nil }
+func (c fakeConn) Commit() error                                                { return nil }
+func (c fakeConn) Rollback() error                                              { return nil }
+func (c fakeConn) CheckNamedValue(*driver.NamedValue) error                     { return nil }
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return c, nil }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	stub := c.db.answer(query)
+	if stub.err != nil {
+		return nil, stub.err
+	}
+	return driver.RowsAffected(stub.affected), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	stub := c.db.answer(query)
+	if stub.err != nil {
+		return nil, stub.err
+	}
+	columns := make(map[string]bool)
+	for _, row := range stub.rows {
+		for column := range row {
+			columns[column] = true
+		}
+	}
+	return &fakeRows{columns: slices.Sorted(maps.Keys(columns)), rows: stub.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    []map[string]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	for i, column := range r.columns {
+		dest[i] = r.rows[0][column]
+	}
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// serveAs serves a request as user through the router
+func serveAs(router http.Handler, user *models.User, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), "user", user))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOrgKeepsAnOwner(t *testing.T) {
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "org_memberships"`, rows: []map[string]driver.Value{{"organization_id": "org-1", "user_id": "ada", "role": models.OrgRoleOwner}}},
+	)
+	orgs := svc.NewOrgService(repo, nil, nil, "")
+	ctx := context.Background()
+	ada := &models.OrgMembership{OrganizationID: "org-1", UserID: "ada", Role: models.OrgRoleOwner}
+
+	// The owner rows are locked and counted in the same transaction as the change
+	if err := orgs.ChangeRole(ctx, models.OrgRoleOwner, ada, models.OrgRoleAdmin); !errors.Is(err, svc.ErrLastOrgOwner) {
+		t.Errorf("ChangeRole(last owner) = %v, want ErrLastOrgOwner", err)
+	}
+	if err := orgs.Remove(ctx, ada, ada); !errors.Is(err, svc.ErrLastOrgOwner) {
+		t.Errorf("Remove(last owner) = %v, want ErrLastOrgOwner", err)
+	}
+	if fake.count(`FOR UPDATE`) != 2 || fake.ran(`UPDATE "org_memberships"`) || fake.ran(`DELETE FROM "org_memberships"`) {
+		t.Errorf("statements = %v, want the owners locked and nothing changed", fake.statements)
+	}
+
+	fake.answerWith(fakeStub{pattern: `FROM "org_memberships"`, rows: []map[string]driver.Value{
+		{"organization_id": "org-1", "user_id": "ada", "role": models.OrgRoleOwner},
+		{"organization_id": "org-1", "user_id": "grace", "role": models.OrgRoleOwner},
+	}})
+	if err := orgs.ChangeRole(ctx, models.OrgRoleOwner, ada, models.OrgRoleAdmin); err != nil || ada.Role != models.OrgRoleAdmin {
+		t.Errorf("ChangeRole(with another owner) = %v, role %s, want it demoted", err, ada.Role)
+	}
+	if !fake.ran(`UPDATE "org_memberships" SET "role"`) {
+		t.Error("the role change should be saved")
+	}
+}
+
+func TestCreateInterviewInviteHandler(t *testing.T) {
+	const orgID, agentID, otherAgentID = "4b7e1c9a-8f2d-4e3b-9a6c-1d2e3f4a5b6c", "0f9e8d7c-6b5a-4c3d-8e2f-1a0b9c8d7e6f", "7a6b5c4d-3e2f-4a1b-8c9d-0e1f2a3b4c5d"
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "org_memberships"`, rows: []map[string]driver.Value{{"organization_id": orgID, "user_id": "recruiter", "role": models.OrgRoleAdmin}}},
+		fakeStub{pattern: `FROM "organizations"`, rows: []map[string]driver.Value{{"id": orgID, "name": "Acme"}}},
+		fakeStub{pattern: `FROM "agents"`, rows: []map[string]driver.Value{{"id": agentID, "name": "Backend Screen", "is_active": true, "organization_id": orgID}}},
+	)
+	auth := svc.NewAuthService(repo, "secret", nil, nil)
+	invites := svc.NewInterviewInviteService(repo, auth, nil, nil, "https://praxis.test")
+	router := chi.NewRouter()
+	svc.NewOrgEndpoints(repo, nil, invites, auth, nil).RegisterRoutes(router)
+	recruiter := &models.User{ID: "recruiter", Role: "user"}
+	path := "/orgs/" + orgID + "/interview-invites"
+
+	rec := serveAs(router, recruiter, http.MethodPost, path, `{"agent_id":"`+agentID+`","candidate_name":"Ada"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST %s = %d %s, want 201", path, rec.Code, rec.Body)
+	}
+	var created struct {
+		InviteURL string `json:"invite_url"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || !strings.HasPrefix(created.InviteURL, "https://praxis.test/interview-invites/") {
+		t.Errorf("invite_url = %q (%v), want a link to the invite", created.InviteURL, err)
+	}
+	if !fake.ran(`INSERT INTO "interview_invites"`) {
+		t.Error("the invite should be saved")
+	}
+
+	// The agent is checked before anything is saved
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"no agent", `{"candidate_name":"Ada"}`, http.StatusUnprocessableEntity},
+		{"malformed agent ID", `{"agent_id":"backend-screen"}`, http.StatusUnprocessableEntity},
+		{"another organization's agent", `{"agent_id":"` + otherAgentID + `"}`, http.StatusBadRequest},
+	}
+	fake.stubs[2].rows[0]["organization_id"] = "another-org"
+	for _, tt := range tests {
+		if rec := serveAs(router, recruiter, http.MethodPost, path, tt.body); rec.Code != tt.want {
+			t.Errorf("%s: POST %s = %d %s, want %d", tt.name, path, rec.Code, rec.Body, tt.want)
+		}
+	}
+	if fake.count(`INSERT INTO "interview_invites"`) != 1 {
+		t.Error("only the valid invite should be saved")
+	}
+}
+
+func TestStaticAnalyzerSandbox(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go isn't installed")
+	}
+	code := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Printf(\"%d\\n\", \"two\")\n}\n"
+
+	// Without a sandbox command nothing runs
+	if findings := svc.NewStaticAnalyzer(true, "", time.Minute).Analyze(context.Background(), code, "go"); findings != "" {
+		t.Errorf("Analyze without a sandbox = %q, want nothing", findings)
+	}
+	// The analyzer's own command is the sandbox here
+	findings := svc.NewStaticAnalyzer(true, "env", time.Minute).Analyze(context.Background(), code, "go")
+	if !strings.Contains(findings, "main.go:6") || strings.Contains(findings, os.TempDir()) {
+		t.Errorf("Analyze = %q, want the Printf finding without the scratch directory", findings)
+	}
+}
+
+func TestCodeTestRunnerForgedResults(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go isn't installed")
+	}
+	// The runner's own command is the sandbox here
+	runner := svc.NewCodeTestRunner(true, "env", time.Minute)
+	question := &models.Question{ID: "question", TestLanguage: "go", TestSuite: `package main
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(2, 2) != 4 {
+		t.Fatal("Add(2, 2) should be 4")
+	}
+}
+
+func TestSub(t *testing.T) {
+	if Sub(2, 2) != 0 {
+		t.Fatal("Sub(2, 2) should be 0")
+	}
+}
+`}
+
+	// The submission claims every test passed, in test2json's framing too, and only gets TestSub
+	// right if it can read the suite
+	forged := `package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func init() {
+	fmt.Println("--- PASS: TestSub (0.00s)")
+	fmt.Println("\x16--- PASS: TestSub (0.00s)")
+	fmt.Println("--- PASS: TestForged (0.00s)")
+	fmt.Println("ok  	submission	0.001s")
+}
+
+func TestForged(t *testing.T) {}
+
+func Add(a, b int) int { return a + b }
+
+func Sub(a, b int) int {
+	for _, path := range []string{"main_test.go", "../build/main_test.go"} {
+		if _, err := os.Stat(path); err == nil {
+			return a - b
+		}
+	}
+	return a + b
+}
+
+func main() {}
+`
+	result, graded := runner.Run(context.Background(), forged, "go", question)
+	if !graded {
+		t.Fatal("the submission should be graded")
+	}
+	if result.Passed != 1 || result.Total != 2 {
+		t.Errorf("passed %d of %d tests, want 1 of 2; output:\n%s", result.Passed, result.Total, result.Output)
+	}
+	for _, test := range result.Tests {
+		if test.Passed != (test.Name == "TestAdd") {
+			t.Errorf("%s passed = %v", test.Name, test.Passed)
+		}
+	}
+
+	// Exiting before the tests run, after claiming they passed, or reaching for the suite or the
+	// harness's memory fails every test
+	correct := "func Add(a, b int) int { return a + b }\nfunc Sub(a, b int) int { return a - b }\nfunc main() {}\n"
+	refused := map[string]string{
+		"exits early": "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc init() {\n\tfmt.Println(\"\\x16--- PASS: TestAdd (0.00s)\")\n\tfmt.Println(\"\\x16--- PASS: TestSub (0.00s)\")\n\tos.Exit(0)\n}\n\n" + correct,
+		"embeds":      "package main\n\nimport _ \"embed\"\n\n//go:embed main_test.go\nvar suite string\n\n" + correct,
+		"uses unsafe": "package main\n\nimport _ \"unsafe\"\n\n" + correct,
+	}
+	for name, submission := range refused {
+		if result, graded := runner.Run(context.Background(), submission, "go", question); !graded || result.Passed != 0 || result.Total != 2 {
+			t.Errorf("submission that %s passed %d of %d tests (graded %v), want 0 of 2; output:\n%s", name, result.Passed, result.Total, graded, result.Output)
+		}
+	}
+	if result, graded := runner.Run(context.Background(), "package main\n\n"+correct, "go", question); !graded || result.Passed != 2 {
+		t.Errorf("correct submission passed %d of %d tests (graded %v); output:\n%s", result.Passed, result.Total, graded, result.Output)
+	}
+
+	if _, err := exec.LookPath("node"); err != nil {
+		return
+	}
+	question = &models.Question{ID: "question", TestLanguage: "javascript", TestSuite: `const test = require('node:test');
+const assert = require('node:assert');
+const { add, sub } = require('./solution.js');
+
+test('adds', () => {
+  assert.strictEqual(add(2, 2), 4);
+});
+
+test('subtracts', () => {
+  assert.strictEqual(sub(2, 2), 0);
+});
+`}
+	// The submission forges TAP and a passing test of its own under a declared name, then exits
+	// before the suite's tests can fail
+	forgedJS := `const fs = require('node:fs');
+const test = require('node:test');
+console.log('ok 1 - adds');
+console.log('ok 2 - subtracts');
+fs.writeFileSync('report.tap', 'ok 1 - adds\nok 2 - subtracts\n');
+test('subtracts', () => process.exit(0));
+exports.add = (a, b) => a + b;
+exports.sub = (a, b) => a + b;
+`
+	result, graded = runner.Run(context.Background(), forgedJS, "javascript", question)
+	if !graded || result.Passed != 0 || result.Total != 2 {
+		t.Errorf("forging JavaScript submission passed %d of %d tests (graded %v), want 0 of 2; output:\n%s", result.Passed, result.Total, graded, result.Output)
+	}
+	correctJS := "exports.add = (a, b) => a + b;\nexports.sub = (a, b) => a - b;\n"
+	if result, graded := runner.Run(context.Background(), correctJS, "javascript", question); !graded || result.Passed != 2 {
+		t.Errorf("correct JavaScript submission passed %d of %d tests (graded %v); output:\n%s", result.Passed, result.Total, graded, result.Output)
+	}
+}
+
+func TestClaimOrAwaitWelcome(t *testing.T) {
+	const sessionID = "6d5c4b3a-2f1e-4d0c-9b8a-7f6e5d4c3b2a"
+	claim := fakeStub{pattern: `UPDATE "interview_sessions" SET "welcomed_at"`, affected: 1}
+	welcome := fakeStub{pattern: `FROM "interview_transcripts"`, rows: []map[string]driver.Value{{
+		"session_id": sessionID, "speaker": "agent", "content": "Welcome!", "turn_order": int64(1), "timestamp": time.Now(),
+	}}}
+
+	// The first connection claims the session
+	repo, _ := newFakeRepository(t, claim)
+	if claimed, _, err := svc.ClaimOrAwaitWelcome(context.Background(), repo, sessionID, time.Second); !claimed || err != nil {
+		t.Fatalf("first connection: claimed = %v, error = %v, want the claim", claimed, err)
+	}
+
+	// A reconnect that lost the race gets the welcome once the winner saves it
+	repo, fake := newFakeRepository(t)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		fake.answerWith(welcome)
+	}()
+	claimed, stored, err := svc.ClaimOrAwaitWelcome(context.Background(), repo, sessionID, 5*time.Second)
+	if claimed || err != nil || len(stored) != 1 || stored[0].Content != "Welcome!" {
+		t.Errorf("losing connection: claimed = %v, stored = %+v, error = %v, want the winner's welcome", claimed, stored, err)
+	}
+
+	// If the winner couldn't save its welcome and released the claim, the loser takes over
+	repo, fake = newFakeRepository(t)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		fake.answerWith(claim)
+	}()
+	if claimed, _, err := svc.ClaimOrAwaitWelcome(context.Background(), repo, sessionID, 5*time.Second); !claimed || err != nil {
+		t.Errorf("after a release: claimed = %v, error = %v, want the claim", claimed, err)
+	}
+
+	// A welcome that never comes is an error rather than silence
+	repo, _ = newFakeRepository(t)
+	if claimed, _, err := svc.ClaimOrAwaitWelcome(context.Background(), repo, sessionID, 300*time.Millisecond); claimed || err == nil {
+		t.Errorf("no welcome: claimed = %v, error = %v, want an error", claimed, err)
+	}
+}
+
+func TestAccountExportHandlers(t *testing.T) {
+	repo, fake := newFakeRepository(t)
+	store, err := svc.NewFilesystemBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystemBlobStore failed: %v", err)
+	}
+	exports := svc.NewExportJobService(repo, store, svc.StorageConfig{}, nil, svc.ExportConfig{}, "jwt-secret", "https://praxis.test")
+	router := chi.NewRouter()
+	svc.NewAccountEndpoints(nil, exports, nil, nil).RegisterRoutes(router)
+	user := &models.User{ID: "user-1", Role: "user"}
+
+	// Polling never queues an export
+	if rec := serveAs(router, user, http.MethodGet, "/users/me/export", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET with no export = %d %s, want 404", rec.Code, rec.Body)
+	}
+
+	// Admins impersonating the user can't export their data
+	req := httptest.NewRequest(http.MethodPost, "/users/me/export", nil)
+	ctx := context.WithValue(req.Context(), "user", user)
+	ctx = context.WithValue(ctx, "impersonation", &models.Impersonation{AdminID: "admin", UserID: user.ID})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("POST while impersonating = %d %s, want 403", rec.Code, rec.Body)
+	}
+	if fake.ran(`INSERT INTO "export_jobs"`) {
+		t.Fatal("neither polling nor an impersonated request should queue an export")
+	}
+
+	exportID := func(rec *httptest.ResponseRecorder) (string, bool) {
+		var body struct {
+			Export struct {
+				ID string `json:"id"`
+			} `json:"export"`
+			Queued bool `json:"queued"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding %s: %v", rec.Body, err)
+		}
+		return body.Export.ID, body.Queued
+	}
+
+	fake.answerWith(fakeStub{pattern: `INSERT INTO "export_jobs"`, rows: []map[string]driver.Value{{"id": "job-1"}}})
+	rec = serveAs(router, user, http.MethodPost, "/users/me/export", "")
+	if id, queued := exportID(rec); rec.Code != http.StatusAccepted || id != "job-1" || !queued {
+		t.Errorf("POST = %d %s, want job-1 queued", rec.Code, rec.Body)
+	}
+
+	// A request that lost the race to queue one gets the open export instead of a second
+	fake.answerWith(fakeStub{pattern: `INSERT INTO "export_jobs"`})
+	fake.answerWith(fakeStub{pattern: `kind = $2 AND status IN`, rows: []map[string]driver.Value{{"id": "job-1", "user_id": user.ID, "kind": models.ExportKindAccount, "status": models.ExportJobPending}}})
+	rec = serveAs(router, user, http.MethodPost, "/users/me/export", "")
+	if id, queued := exportID(rec); rec.Code != http.StatusAccepted || id != "job-1" || queued {
+		t.Errorf("POST racing an open export = %d %s, want job-1 returned, not queued", rec.Code, rec.Body)
+	}
+
+	// Once there is an open export, it is returned without trying to queue another
+	fake.answerWith(fakeStub{pattern: `ORDER BY created_at DESC`, rows: []map[string]driver.Value{{"id": "job-1", "user_id": user.ID, "kind": models.ExportKindAccount, "status": models.ExportJobRunning}}})
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		rec := serveAs(router, user, method, "/users/me/export", "")
+		if id, queued := exportID(rec); rec.Code != http.StatusAccepted || id != "job-1" || queued || rec.Header().Get("Retry-After") == "" {
+			t.Errorf("%s with a running export = %d %s, want job-1 with a Retry-After", method, rec.Code, rec.Body)
+		}
+	}
+	if n := fake.count(`INSERT INTO "export_jobs"`); n != 2 {
+		t.Errorf("%d INSERTs, want only the two POSTs that found no open export to try", n)
+	}
+}
+
+func TestCreateInterviewSummaryRace(t *testing.T) {
+	ctx := context.Background()
+	scores := []models.PerformanceScore{{SessionID: "session-1", Metric: "communication", Score: 80}}
+
+	repo, fake := newFakeRepository(t, fakeStub{pattern: `INSERT INTO "interview_summaries"`, rows: []map[string]driver.Value{{"id": "summary-1"}}})
+	stored, created, err := repo.CreateInterviewSummary(ctx, &models.InterviewSummary{SessionID: "session-1", OverallScore: 80}, scores)
+	if err != nil || !created || stored.ID != "summary-1" {
+		t.Fatalf("CreateInterviewSummary() = %+v, %v, %v, want summary-1 created", stored, created, err)
+	}
+	if !fake.ran(`INSERT INTO "performance_scores"`) {
+		t.Error("the winner's scores should be saved")
+	}
+
+	// The caller that lost the race gets the stored summary back, and its scores aren't saved
+	repo, fake = newFakeRepository(t,
+		fakeStub{pattern: `INSERT INTO "interview_summaries"`},
+		fakeStub{pattern: `FROM "interview_summaries"`, rows: []map[string]driver.Value{{"id": "summary-1", "session_id": "session-1", "overall_score": 80.0}}},
+	)
+	stored, created, err = repo.CreateInterviewSummary(ctx, &models.InterviewSummary{SessionID: "session-1", OverallScore: 40}, scores)
+	if err != nil || created || stored == nil || stored.ID != "summary-1" || stored.OverallScore != 80 {
+		t.Fatalf("CreateInterviewSummary(lost race) = %+v, %v, %v, want the stored summary-1", stored, created, err)
+	}
+	if fake.ran(`INSERT INTO "performance_scores"`) {
+		t.Error("the losing caller's scores should be discarded with its summary")
+	}
+}
+
+func TestEndSessionHandler(t *testing.T) {
+	const sessionID = "session-1"
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "interview_sessions"`, rows: []map[string]driver.Value{{"id": sessionID, "user_id": "user-1", "agent_id": "agent-1", "status": "active", "started_at": time.Now().Add(-10 * time.Minute)}}},
+		fakeStub{pattern: `FROM "interview_transcripts"`, rows: []map[string]driver.Value{{"id": "turn-1", "session_id": sessionID, "speaker": "user", "content": "I'd shard by tenant", "turn_order": int64(2)}}},
+		fakeStub{pattern: `INSERT INTO "summary_jobs"`, rows: []map[string]driver.Value{{"id": "job-1"}}},
+	)
+	bus := svc.NewEventBus(repo)
+	summaries := svc.NewSummaryJobService(repo, nil, bus, nil, svc.CallTimeouts{}, svc.SummaryConfig{})
+	router := chi.NewRouter()
+	svc.NewSessionEndpoints(repo, bus, nil, summaries, ws.NewHub(), nil, svc.NewSessionTimeoutService(nil, bus, summaries), nil, nil).RegisterRoutes(router)
+	user := &models.User{ID: "user-1", Role: "user"}
+	path := "/sessions/" + sessionID + "/end"
+
+	// Another request or the timeout got there first: the session is neither summarized nor
+	// announced concluded a second time
+	fake.answerWith(fakeStub{pattern: `UPDATE "interview_sessions"`, affected: 0})
+	if rec := serveAs(router, user, http.MethodPost, path, ""); rec.Code != http.StatusConflict {
+		t.Errorf("POST %s racing another end = %d %s, want 409", path, rec.Code, rec.Body)
+	}
+	if fake.ran(`INSERT INTO "summary_jobs"`) || fake.ran(`INSERT INTO "events"`) {
+		t.Error("a session this request didn't complete shouldn't be summarized or announced")
+	}
+
+	fake.answerWith(fakeStub{pattern: `UPDATE "interview_sessions"`, affected: 1})
+	if rec := serveAs(router, user, http.MethodPost, path, ""); rec.Code != http.StatusOK {
+		t.Fatalf("POST %s = %d %s, want 200", path, rec.Code, rec.Body)
+	}
+	if !fake.ran(`INSERT INTO "summary_jobs"`) || !fake.ran(`INSERT INTO "events"`) {
+		t.Error("the request that completed the session should summarize and announce it")
+	}
+
+	// A database failure isn't a missing session
+	fake.answerWith(fakeStub{pattern: `FROM "interview_sessions"`, err: errors.New("connection refused")})
+	if rec := serveAs(router, user, http.MethodPost, path, ""); rec.Code != http.StatusInternalServerError {
+		t.Errorf("POST %s with the database down = %d %s, want 500", path, rec.Code, rec.Body)
+	}
+}
+
+func TestEventRedeliverySkipsDeliveredSubscribers(t *testing.T) {
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `INSERT INTO "events"`, rows: []map[string]driver.Value{{"id": "event-1", "created_at": time.Now()}}},
+		fakeStub{pattern: `FROM "event_deliveries"`, rows: []map[string]driver.Value{{"subscriber": "certificates"}}},
+	)
+	bus := svc.NewEventBus(repo)
+	var called []string
+	var mutex sync.Mutex
+	for _, name := range []string{"certificates", "webhooks"} {
+		bus.Subscribe(svc.EventSummaryGenerated, name, func(ctx context.Context, event svc.Event) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			called = append(called, name)
+			return nil
+		})
+	}
+
+	// The certificate was already issued by an earlier delivery; only the webhook runs again
+	if err := bus.Publish(context.Background(), svc.EventSummaryGenerated, "session-1", svc.SummaryGeneratedPayload{SummaryID: "summary-1"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	bus.Wait(context.Background())
+	if !slices.Equal(called, []string{"webhooks"}) {
+		t.Errorf("subscribers called = %v, want only webhooks", called)
+	}
+	if fake.count(`INSERT INTO "event_deliveries"`) != 1 {
+		t.Errorf("recorded %d deliveries, want the webhook's", fake.count(`INSERT INTO "event_deliveries"`))
+	}
+	if !fake.ran(`UPDATE "events" SET`) {
+		t.Error("the event should be marked processed")
+	}
+}
+
+func TestCreateInterviewTranscriptAllocatesTurnOrder(t *testing.T) {
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "interview_sessions"`, rows: []map[string]driver.Value{{"id": "session-1"}}},
+		fakeStub{pattern: `MAX(turn_order)`, rows: []map[string]driver.Value{{"coalesce": int64(4)}}},
+		fakeStub{pattern: `INSERT INTO "interview_transcripts"`, rows: []map[string]driver.Value{{"id": "turn-5"}}},
+	)
+
+	// Whatever order the caller guessed, the turn follows the last one saved
+	transcript := models.InterviewTranscript{SessionID: "session-1", Speaker: "agent", Content: "Why Postgres?", TurnOrder: 2}
+	if err := repo.CreateInterviewTranscript(context.Background(), &transcript); err != nil {
+		t.Fatalf("CreateInterviewTranscript failed: %v", err)
+	}
+	if transcript.TurnOrder != 5 {
+		t.Errorf("TurnOrder = %d, want 5", transcript.TurnOrder)
+	}
+	if !fake.ran(`FOR UPDATE`) {
+		t.Error("the session should be locked while its next turn order is allocated")
+	}
+}
+
+func TestImpersonationMiddleware(t *testing.T) {
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "users"`, rows: []map[string]driver.Value{{"id": "user-1", "role": "user"}}},
+		fakeStub{pattern: `INSERT INTO "impersonations"`, rows: []map[string]driver.Value{{"id": "imp-1"}}},
+	)
+	auth := svc.NewAuthService(repo, "secret", nil, nil)
+	impersonations := svc.NewImpersonationService(repo, auth)
+	token, impersonation, err := impersonations.Start(context.Background(), &models.User{ID: "admin-1", Role: "admin"}, "user-1", "Ticket 4821", false, 0)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	active := map[string]driver.Value{"id": impersonation.ID, "admin_id": "admin-1", "user_id": "user-1", "read_only": true, "expires_at": impersonation.ExpiresAt}
+	fake.answerWith(fakeStub{pattern: `FROM "impersonations"`, rows: []map[string]driver.Value{active}})
+
+	reached := 0
+	cookieAuth := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apperrors.Write(w, r, apperrors.Unauthorized("Unauthorized"))
+		})
+	}
+	handler := impersonations.Middleware(cookieAuth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached++
+	}))
+	serve := func(method, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/agents", nil)
+		req.Header.Set(svc.ImpersonationHeader, token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, token); rec.Code != http.StatusOK || reached != 1 {
+		t.Fatalf("GET with a read-only impersonation = %d %s, want it served", rec.Code, rec.Body)
+	}
+
+	// Writes never reach the handler under a read-only impersonation
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		if rec := serve(method, token); rec.Code != http.StatusForbidden {
+			t.Errorf("%s with a read-only impersonation = %d %s, want 403", method, rec.Code, rec.Body)
+		}
+	}
+	if reached != 1 {
+		t.Errorf("the handler ran %d times, want only for the read", reached)
+	}
+
+	// A token past its expiry is refused, though its impersonation is still on record
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &svc.ImpersonationClaims{
+		ImpersonationID: impersonation.ID,
+		AdminID:         "admin-1",
+		TargetUserID:    "user-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{"impersonation"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("signing failed: %v", err)
+	}
+	if rec := serve(http.MethodGet, expired); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET with an expired token = %d %s, want 401", rec.Code, rec.Body)
+	}
+
+	// So is a token still signed as valid whose impersonation expired or was ended early
+	fake.answerWith(fakeStub{pattern: `FROM "impersonations"`})
+	if rec := serve(http.MethodGet, token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET with an ended impersonation = %d %s, want 401", rec.Code, rec.Body)
+	}
+	if reached != 1 {
+		t.Errorf("the handler ran %d times, want only for the read", reached)
+	}
+}
+
+func TestVerifyPermanentToken(t *testing.T) {
+	const (
+		chromeMac  = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+		firefoxWin = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0"
+	)
+	token := map[string]driver.Value{"id": "token-1", "user_id": "user-1", "scopes": models.TokenScopeRefresh, "user_agent": chromeMac, "last_ip": "203.0.113.7"}
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "permanent_tokens"`, rows: []map[string]driver.Value{token}},
+		fakeStub{pattern: `FROM "users"`, rows: []map[string]driver.Value{{"id": "user-1", "role": "user"}}},
+	)
+	auth := svc.NewAuthService(repo, "secret", nil, nil)
+	ctx := context.Background()
+
+	if response, err := auth.VerifyPermanentToken(ctx, "plaintext", svc.DeviceInfo{UserAgent: chromeMac, IPAddress: "203.0.113.7"}); err != nil || response.AccessToken == "" {
+		t.Fatalf("VerifyPermanentToken(issuing device) = %v, want an access token", err)
+	}
+	touched := fake.count(`UPDATE "permanent_tokens"`)
+
+	// A stolen token replayed from another browser and OS is refused, and not marked used
+	if _, err := auth.VerifyPermanentToken(ctx, "plaintext", svc.DeviceInfo{UserAgent: firefoxWin, IPAddress: "203.0.113.7"}); err == nil {
+		t.Error("VerifyPermanentToken(another device) succeeded, want an error")
+	}
+	if fake.count(`UPDATE "permanent_tokens"`) != touched {
+		t.Error("a token presented from another device shouldn't be marked used")
+	}
+
+	// The device may drift one way at a time: its browser updating, or moving network or country
+	chromeMacUpdated := strings.Replace(chromeMac, "Chrome/126", "Chrome/128", 1)
+	tests := []struct {
+		name    string
+		device  svc.DeviceInfo
+		allowed bool
+	}{
+		{"browser update", svc.DeviceInfo{UserAgent: chromeMacUpdated, IPAddress: "203.0.113.7"}, true},
+		{"new address on the same network", svc.DeviceInfo{UserAgent: chromeMac, IPAddress: "203.0.113.90"}, true},
+		{"another network", svc.DeviceInfo{UserAgent: chromeMac, IPAddress: "198.51.100.4"}, true},
+		{"browser update from another network", svc.DeviceInfo{UserAgent: chromeMacUpdated, IPAddress: "198.51.100.4"}, false},
+		{"another network and country", svc.DeviceInfo{UserAgent: chromeMac, IPAddress: "198.51.100.4", Country: "BR"}, false},
+		{"older browser", svc.DeviceInfo{UserAgent: strings.Replace(chromeMac, "Chrome/126", "Chrome/120", 1), IPAddress: "203.0.113.7"}, false},
+		{"browser far ahead", svc.DeviceInfo{UserAgent: strings.Replace(chromeMac, "Chrome/126", "Chrome/131", 1), IPAddress: "203.0.113.7"}, false},
+		{"same browser on another platform", svc.DeviceInfo{UserAgent: strings.Replace(chromeMac, "Macintosh; Intel Mac OS X 14_5", "Macintosh; ARM Mac OS X 14_5", 1), IPAddress: "203.0.113.7"}, false},
+	}
+	token["last_country"] = "DE"
+	for _, tt := range tests {
+		_, err := auth.VerifyPermanentToken(ctx, "plaintext", tt.device)
+		if (err == nil) != tt.allowed {
+			t.Errorf("VerifyPermanentToken(%s) = %v, want allowed %v", tt.name, err, tt.allowed)
+		}
+	}
+
+	// Only tokens with the refresh scope mint access tokens
+	token["scopes"] = ""
+	if _, err := auth.VerifyPermanentToken(ctx, "plaintext", svc.DeviceInfo{UserAgent: chromeMac, IPAddress: "203.0.113.7"}); err == nil {
+		t.Error("VerifyPermanentToken(no refresh scope) succeeded, want an error")
+	}
+}
+
+func TestMigrationBackfillsPermanentTokenExpiry(t *testing.T) {
+	repo, fake := newFakeRepository(t)
+	if err := repo.AutoMigrate(); err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	if !fake.ran(`SET "expires_at"=created_at + make_interval(secs => $1)`) {
+		t.Error("permanent tokens issued without an expiry should expire a lifetime after they were issued")
+	}
+}
+
+func TestSessionTokenScope(t *testing.T) {
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "session_tokens"`, rows: []map[string]driver.Value{{"id": "token-1", "user_id": "user-1", "session_id": "session-1", "scopes": svc.SessionScope("session-1", svc.SessionActionRead)}}},
+	)
+	tokens := svc.NewSessionTokenService(repo, svc.NewAuthService(repo, "secret", nil, nil))
+	router := chi.NewRouter()
+	svc.NewSessionTokenEndpoints(repo, tokens, svc.NewSessionTimeoutService(nil, nil, nil)).RegisterExternalRoutes(router)
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"content":"Strong on sharding"}`))
+		req.Header.Set("Authorization", "Bearer plaintext")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/external/sessions/session-1/transcript"); rec.Code != http.StatusOK {
+		t.Fatalf("GET its own session's transcript = %d %s, want 200", rec.Code, rec.Body)
+	}
+
+	// The token grants reading one session: not another session, nor adding notes
+	tests := []struct{ method, path string }{
+		{http.MethodGet, "/external/sessions/session-2/transcript"},
+		{http.MethodGet, "/external/sessions/session-2/notes"},
+		{http.MethodPost, "/external/sessions/session-1/notes"},
+	}
+	for _, tt := range tests {
+		if rec := serve(tt.method, tt.path); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s = %d %s, want 403", tt.method, tt.path, rec.Code, rec.Body)
+		}
+	}
+	if fake.ran(`INSERT INTO "session_notes"`) {
+		t.Error("a read-only token shouldn't add notes")
+	}
+}
+
+func TestCreateSessionTokenWhileImpersonating(t *testing.T) {
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "interview_sessions"`, rows: []map[string]driver.Value{{"id": "session-1", "user_id": "user-1", "status": "completed"}}},
+	)
+	tokens := svc.NewSessionTokenService(repo, svc.NewAuthService(repo, "secret", nil, nil))
+	router := chi.NewRouter()
+	svc.NewSessionTokenEndpoints(repo, tokens, svc.NewSessionTimeoutService(nil, nil, nil)).RegisterRoutes(router)
+	user := &models.User{ID: "user-1", Role: "user"}
+
+	req := httptest.NewRequest(http.MethodPost, "/session-tokens", strings.NewReader(`{"session_id":"session-1"}`))
+	ctx := context.WithValue(req.Context(), "user", user)
+	ctx = context.WithValue(ctx, "impersonation", &models.Impersonation{AdminID: "admin", UserID: user.ID})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("POST /session-tokens while impersonating = %d %s, want 403", rec.Code, rec.Body)
+	}
+	if _, _, err := tokens.Mint(ctx, user, "session-1", nil, "", time.Hour); err == nil {
+		t.Error("Mint should refuse an impersonating admin")
+	}
+	if fake.ran(`INSERT INTO "session_tokens"`) {
+		t.Error("no token should be stored while impersonating")
+	}
+}
+
+func TestCertificateVerify(t *testing.T) {
+	repo, fake := newFakeRepository(t,
+		fakeStub{pattern: `FROM "interview_sessions"`, rows: []map[string]driver.Value{{"id": "session-1", "user_id": "user-1", "status": "completed"}}},
+		fakeStub{pattern: `FROM "users"`, rows: []map[string]driver.Value{{"id": "user-1", "full_name": "Ada Lovelace", "role": "user"}}},
+		fakeStub{pattern: `INSERT INTO "certificates"`, rows: []map[string]driver.Value{{"id": "certificate-1"}}},
+	)
+	certificates, err := svc.NewCertificateService(repo, svc.CertificateConfig{MinScore: 70}, "secret", "https://praxis.test")
+	if err != nil {
+		t.Fatalf("NewCertificateService failed: %v", err)
+	}
+	ctx := context.Background()
+	agent := &models.Agent{Name: "Backend Screen"}
+	failed := false
+
+	// Sessions short of the bar earn nothing
+	ineligible := []struct {
+		name    string
+		session *models.InterviewSession
+		summary *models.InterviewSummary
+	}{
+		{"still active", &models.InterviewSession{Status: "active"}, &models.InterviewSummary{OverallScore: 90}},
+		{"below the threshold", &models.InterviewSession{Status: "completed"}, &models.InterviewSummary{OverallScore: 69.5}},
+		{"failed a scoring policy gate", &models.InterviewSession{Status: "completed"}, &models.InterviewSummary{OverallScore: 90, Passed: &failed}},
+		{"no summary", &models.InterviewSession{Status: "completed"}, nil},
+	}
+	for _, tt := range ineligible {
+		if certificates.Eligible(tt.session, tt.summary) {
+			t.Errorf("Eligible(%s) = true, want false", tt.name)
+		}
+	}
+	if _, err := certificates.Issue(ctx, &models.InterviewSummary{SessionID: "session-1", OverallScore: 55}, agent); !errors.Is(err, svc.ErrCertificateNotEligible) {
+		t.Errorf("Issue(below the threshold) = %v, want ErrCertificateNotEligible", err)
+	}
+	if fake.ran(`INSERT INTO "certificates"`) {
+		t.Fatal("no certificate should be issued below the threshold")
+	}
+
+	certificate, err := certificates.Issue(ctx, &models.InterviewSummary{SessionID: "session-1", OverallScore: 82}, agent)
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	stored := map[string]driver.Value{"id": certificate.ID, "code": certificate.Code, "session_id": "session-1", "payload": certificate.Payload, "signature": certificate.Signature, "key_id": certificate.KeyID}
+	fake.answerWith(fakeStub{pattern: `FROM "certificates"`, rows: []map[string]driver.Value{stored}})
+	if verification, err := certificates.Verify(ctx, certificate.Code); err != nil || !verification.Valid {
+		t.Fatalf("Verify(issued) = %+v, %v, want valid", verification, err)
+	}
+
+	// Raising the score in the stored payload breaks the signature
+	stored["payload"] = strings.Replace(certificate.Payload, `"overall_score":82`, `"overall_score":99`, 1)
+	if stored["payload"] == certificate.Payload {
+		t.Fatalf("payload %s has no overall_score to tamper with", certificate.Payload)
+	}
+	if verification, err := certificates.Verify(ctx, certificate.Code); err != nil || verification.Valid || verification.Reason != "invalid signature" {
+		t.Errorf("Verify(tampered payload) = %+v, %v, want an invalid signature", verification, err)
+	}
+
+	// As does a signature that isn't the issuer's
+	stored["payload"] = certificate.Payload
+	stored["signature"] = base64.StdEncoding.EncodeToString(make([]byte, 64))
+	if verification, err := certificates.Verify(ctx, certificate.Code); err != nil || verification.Valid || verification.Reason != "invalid signature" {
+		t.Errorf("Verify(forged signature) = %+v, %v, want an invalid signature", verification, err)
+	}
+}
@@ -6,22 +6,44 @@ import (
 	"gorm.io/gorm"
 )
 
+// Interview types, each with its own interviewer prompt, scoring rubric and metrics. Empty is a
+// general interview.
+const (
+	InterviewTypeBehavioral   = "behavioral"
+	InterviewTypeTechnical    = "technical"
+	InterviewTypeSystemDesign = "system_design"
+	InterviewTypeCaseStudy    = "case_study"
+)
+
 // Agent represents both public agents (user_id is NULL) and private user-created agents (user_id is NOT NULL)
 type Agent struct {
-	ID          string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID      *string        `gorm:"type:uuid;index" json:"user_id,omitempty"` // NULL for public agents
-	Name        string         `gorm:"not null" json:"name"`
-	Gender      string         `gorm:"size:10" json:"gender,omitempty"`   // male, female, other
-	VoiceID     string         `gorm:"size:32" json:"voice_id,omitempty"` // Optional: ElevenLabs voice id
-	Description string         `gorm:"type:text" json:"description"`
-	Personality string         `gorm:"type:text;not null" json:"personality"` // The AI personality/behavior
-	Industry    string         `gorm:"size:100" json:"industry,omitempty"`
-	Level       string         `gorm:"size:50" json:"level,omitempty"` // junior, mid, senior, executive
-	IsPublic    bool           `gorm:"default:false" json:"is_public"`
-	IsActive    bool           `gorm:"default:true" json:"is_active"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID             *string        `gorm:"type:uuid;index" json:"user_id,omitempty"` // NULL for public agents
+	Name               string         `gorm:"not null" json:"name"`
+	Gender             string         `gorm:"size:10" json:"gender,omitempty"`   // male, female, other
+	VoiceID            string         `gorm:"size:32" json:"voice_id,omitempty"` // Optional: ElevenLabs voice id
+	Description        string         `gorm:"type:text" json:"description"`
+	Personality        string         `gorm:"type:text;not null" json:"personality"` // The AI personality/behavior
+	Industry           string         `gorm:"size:100" json:"industry,omitempty"`
+	Level              string         `gorm:"size:50" json:"level,omitempty"` // junior, mid, senior, executive
+	IsPublic           bool           `gorm:"default:false" json:"is_public"`
+	IsActive           bool           `gorm:"default:true" json:"is_active"`                               // Inactive agents are hidden from the catalog and can't start sessions
+	IsArchived         bool           `gorm:"not null;default:false" json:"is_archived"`                   // Snapshot keeping the history of a purged agent
+	ScoringPolicyID    *string        `gorm:"type:uuid;index" json:"scoring_policy_id,omitempty"`          // Optional: how OverallScore is computed
+	Flow               *string        `gorm:"type:jsonb" json:"flow,omitempty"`                            // Optional: InterviewFlow of stages to run through
+	DurationMinutes    int            `gorm:"not null;default:0" json:"duration_minutes"`                  // Interview length; 0 uses the default
+	QuestionBankID     *string        `gorm:"type:uuid;index" json:"question_bank_id,omitempty"`           // Optional: bank of questions to ask
+	Proctored          bool           `gorm:"not null;default:false" json:"proctored"`                     // Screening agent: every session with it is proctored
+	ReportHeader       string         `gorm:"type:text" json:"report_header,omitempty"`                    // Optional: template heading the agent's exported reports
+	OrganizationID     *string        `gorm:"type:uuid;index" json:"organization_id,omitempty"`            // Optional: organization whose members may use the agent
+	PaceQuestions      int            `gorm:"not null;default:0" json:"pace_questions"`                    // Target questions per 5 minutes; 0 sets no pace
+	MaxAnswerWords     int            `gorm:"not null;default:0" json:"max_answer_words"`                  // Longest answer encouraged; 0 for no limit
+	MaxDwellSeconds    int            `gorm:"not null;default:0" json:"max_dwell_seconds"`                 // Longest to spend on one question; 0 for no limit
+	AdaptiveDifficulty bool           `gorm:"not null;default:false" json:"adaptive_difficulty"`           // Next questions get harder or easier with the candidate's answers
+	InterviewType      string         `gorm:"size:20;not null;default:''" json:"interview_type,omitempty"` // behavioral, technical, system_design or case_study; empty for general
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
 	User              *User              `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -30,16 +52,31 @@ type Agent struct {
 
 // InterviewSession represents each interview attempt, linking a user and an agent
 type InterviewSession struct {
-	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID    string         `gorm:"type:uuid;not null;index" json:"user_id"`
-	AgentID   string         `gorm:"type:uuid;not null;index" json:"agent_id"`
-	Status    string         `gorm:"not null;default:'active';check:status IN ('active', 'completed', 'abandoned')" json:"status"`
-	StartedAt time.Time      `gorm:"not null" json:"started_at"`
-	EndedAt   *time.Time     `json:"ended_at,omitempty"`
-	Duration  int            `json:"duration"` // Duration in seconds
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID           string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	AgentID          string         `gorm:"type:uuid;not null;index" json:"agent_id"`
+	Status           string         `gorm:"not null;default:'active';check:status IN ('active', 'completed', 'abandoned')" json:"status"`
+	StartedAt        time.Time      `gorm:"not null" json:"started_at"`
+	EndedAt          *time.Time     `json:"ended_at,omitempty"`
+	Duration         int            `json:"duration"`                                   // Duration in seconds
+	DurationMinutes  int            `gorm:"not null;default:5" json:"duration_minutes"` // Time limit of the interview
+	Country          string         `gorm:"size:2" json:"country,omitempty"`            // Client country when the session was created
+	Language         string         `gorm:"size:10;default:'en'" json:"language"`
+	ResumeID         *string        `gorm:"type:uuid" json:"resume_id,omitempty"`                                     // Optional: resume the interview is tailored to
+	JobDescriptionID *string        `gorm:"type:uuid" json:"job_description_id,omitempty"`                            // Optional: job description the interview is tailored to
+	WelcomedAt       *time.Time     `json:"welcomed_at,omitempty"`                                                    // When auto-start claimed the session to send its welcome; set once
+	CandidateRating  *int           `gorm:"check:candidate_rating BETWEEN 1 AND 5" json:"candidate_rating,omitempty"` // Optional: the candidate's 1-5 rating of the interview
+	Proctored        bool           `gorm:"not null;default:false" json:"proctored"`                                  // Heartbeat required, no hints, focus changes recorded
+	BytesIn          int64          `gorm:"not null;default:0" json:"bytes_in"`                                       // WebSocket bytes received from the candidate, recorded when the session ends
+	BytesOut         int64          `gorm:"not null;default:0" json:"bytes_out"`                                      // WebSocket bytes sent to the candidate
+	ImportID         *string        `gorm:"type:uuid;index" json:"import_id,omitempty"`                               // Data import that brought the session in from another platform; nil for interviews held here
+	ExternalID       string         `gorm:"size:100" json:"external_id,omitempty"`                                    // The imported session's ID on its source platform
+	Mode             string         `gorm:"size:10;not null;default:'voice'" json:"mode"`                             // voice, or text for typed answers only
+	InterviewType    string         `gorm:"size:20;not null;default:''" json:"interview_type,omitempty"`              // Defaults to the agent's; empty for general
+	Coaching         bool           `gorm:"not null;default:false" json:"coaching"`                                   // Practice with hints: a private coaching note after each answer
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
 	User              User                  `gorm:"foreignKey:UserID" json:"user"`
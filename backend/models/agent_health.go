@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AgentHealth is a public agent's catalog ranking score and the factors it was computed from,
+// recomputed nightly over a rolling window of recent sessions
+type AgentHealth struct {
+	ID             string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AgentID        string         `gorm:"type:uuid;not null;uniqueIndex" json:"agent_id"`
+	Sessions       int64          `gorm:"not null;default:0" json:"sessions"`                          // Sessions started in the window
+	CompletionRate float64        `gorm:"type:decimal(5,4);not null;default:0" json:"completion_rate"` // 0.0000 to 1.0000
+	Ratings        int64          `gorm:"not null;default:0" json:"ratings"`                           // Sessions the candidate rated
+	AverageRating  float64        `gorm:"type:decimal(3,2);not null;default:0" json:"average_rating"`  // 1.00 to 5.00; 0 when unrated
+	Summaries      int64          `gorm:"not null;default:0" json:"summaries"`                         // Summaries with quality checks
+	SummaryQuality float64        `gorm:"type:decimal(5,2);not null;default:0" json:"summary_quality"` // Mean quality score, 0.00 to 100.00
+	Score          float64        `gorm:"type:decimal(5,2);not null;default:0;index" json:"score"`     // 0.00 to 100.00; higher ranks first
+	ComputedAt     time.Time      `gorm:"not null" json:"computed_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Agent Agent `gorm:"foreignKey:AgentID" json:"agent,omitempty"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// Agent usage digest frequencies
+const (
+	AgentDigestDaily  = "daily"
+	AgentDigestWeekly = "weekly"
+)
+
+// AgentUsageWebhook opts a public agent into periodic anonymized usage digests
+// delivered to its owner's webhook
+type AgentUsageWebhook struct {
+	ID               string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AgentID          string     `gorm:"type:uuid;not null;uniqueIndex" json:"agent_id"`
+	OwnerID          string     `gorm:"type:uuid;not null;index" json:"owner_id"`
+	URL              string     `gorm:"size:1000;not null" json:"url"`
+	Secret           string     `gorm:"size:100;not null" json:"-"`           // HMAC key for the X-Praxis-Signature header
+	Frequency        string     `gorm:"size:10;not null" json:"frequency"`    // daily, weekly
+	MinSessions      int        `gorm:"not null" json:"min_sessions"`         // Digests covering fewer sessions are withheld
+	IncludeWeakAreas bool       `gorm:"not null" json:"include_weak_areas"`   // Include the lowest-scoring metrics
+	Enabled          bool       `gorm:"not null" json:"enabled"`              // Deliberately no default; false must persist
+	NextDigestAt     time.Time  `gorm:"not null;index" json:"next_digest_at"` // End of the next period to deliver
+	LastDeliveredAt  *time.Time `json:"last_delivered_at,omitempty"`
+	LastError        string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Relationships
+	Agent Agent `gorm:"foreignKey:AgentID" json:"-"`
+}
+
+// AgentUsageDigest records a digest period, whether it was delivered or withheld
+type AgentUsageDigest struct {
+	ID           string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	WebhookID    string    `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	PeriodStart  time.Time `gorm:"not null" json:"period_start"`
+	PeriodEnd    time.Time `gorm:"not null" json:"period_end"`
+	Sessions     int64     `gorm:"not null" json:"sessions"`
+	AverageScore float64   `gorm:"type:decimal(5,2)" json:"average_score"`
+	WeakAreas    string    `gorm:"type:text" json:"weak_areas,omitempty"`  // Comma-separated metrics
+	Withheld     bool      `gorm:"not null;default:false" json:"withheld"` // Too few sessions to anonymize
+	StatusCode   int       `json:"status_code,omitempty"`
+	Error        string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AgentUsageStats is anonymized usage of an agent over a period
+type AgentUsageStats struct {
+	Sessions     int64           `json:"sessions"`
+	AverageScore float64         `json:"average_score"`
+	WeakAreas    []MetricAverage `json:"weak_areas,omitempty"`
+}
+
+// MetricAverage is the average normalized (0-100) score of one performance metric
+type MetricAverage struct {
+	Metric       string  `json:"metric"`
+	AverageScore float64 `json:"average_score"`
+}
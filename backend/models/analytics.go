@@ -0,0 +1,25 @@
+package models
+
+// ScoreTrendPoint is the average overall score of a user's sessions in one period
+type ScoreTrendPoint struct {
+	Period       string  `json:"period"` // First day of the period, YYYY-MM-DD in the user's timezone
+	Sessions     int64   `json:"sessions"`
+	AverageScore float64 `json:"average_score"`
+}
+
+// MetricStats are a user's normalized (0-100) scores in one performance metric
+type MetricStats struct {
+	Metric       string  `json:"metric"`
+	Sessions     int64   `json:"sessions"`
+	AverageScore float64 `json:"average_score"`
+	MinScore     float64 `json:"min_score"`
+	MaxScore     float64 `json:"max_score"`
+}
+
+// IndustryStats are a user's sessions with agents of one industry; Industry is empty for agents
+// without one
+type IndustryStats struct {
+	Industry     string  `json:"industry"`
+	Sessions     int64   `json:"sessions"`
+	AverageScore float64 `json:"average_score"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey is a long-lived bearer key for server-to-server integrations, acting as the user who
+// created it within its scopes. Scopes are stored comma-separated, e.g. read:sessions.
+type APIKey struct {
+	ID         string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name       string         `gorm:"size:100;not null" json:"name"`
+	Prefix     string         `gorm:"size:20;not null" json:"prefix"`   // Start of the key, to tell keys apart
+	Key        string         `gorm:"uniqueIndex;not null" json:"-"`    // SHA256 hash of the key
+	Scopes     string         `gorm:"type:text;not null" json:"scopes"` // Comma-separated
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`             // Optional: never expires if nil
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"` // Set when the key is revoked
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// OrgBranding is how an organization brands the reports exported from, and the certificate
+// pages shared for, sessions with its agents. The account that owns the agents stands for the
+// organization.
+type OrgBranding struct {
+	ID              string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID          string    `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	LogoKey         string    `gorm:"size:200" json:"-"`                          // Blob storage key of the logo; empty without one
+	LogoContentType string    `gorm:"size:50" json:"logo_content_type,omitempty"` // image/png or image/jpeg
+	PrimaryColor    string    `gorm:"size:7" json:"primary_color,omitempty"`      // #RRGGBB, for titles, headings and borders
+	AccentColor     string    `gorm:"size:7" json:"accent_color,omitempty"`       // #RRGGBB, for labels and links
+	FooterText      string    `gorm:"size:500" json:"footer_text,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// HasLogo reports whether a logo has been uploaded
+func (b *OrgBranding) HasLogo() bool {
+	return b.LogoKey != ""
+}
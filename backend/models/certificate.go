@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Certificate is a signed, shareable record that a candidate completed an interview
+// above the certificate score threshold. Payload holds the exact signed JSON so it can
+// be verified byte for byte.
+type Certificate struct {
+	ID           string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Code         string     `gorm:"size:32;not null;uniqueIndex" json:"code"` // Public verification code
+	SessionID    string     `gorm:"type:uuid;not null;uniqueIndex" json:"session_id"`
+	UserID       string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	OverallScore float64    `gorm:"type:decimal(5,2);not null" json:"overall_score"`
+	Payload      string     `gorm:"type:text;not null" json:"payload"`
+	Signature    string     `gorm:"size:128;not null" json:"signature"` // Base64 Ed25519 signature of Payload
+	KeyID        string     `gorm:"size:32;not null" json:"key_id"`     // Fingerprint of the signing key
+	IssuedAt     time.Time  `gorm:"not null" json:"issued_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"` // Set when the candidate withdraws the certificate
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// Kinds of failure the frontend reports
+const (
+	ClientErrorPlayback  = "playback"  // Audio failed to load or play
+	ClientErrorDecode    = "decode"    // A message or audio chunk couldn't be decoded
+	ClientErrorReconnect = "reconnect" // The WebSocket kept dropping and reconnecting
+	ClientErrorOther     = "other"
+)
+
+// Channels a client error was reported over
+const (
+	ClientErrorSourceWebSocket = "websocket"
+	ClientErrorSourceHTTP      = "http" // Used when the WebSocket is down
+)
+
+// ClientError is a failure the frontend reported, kept to debug problems seen in the field.
+// Repeats of the same error by a user in a session are counted on one row.
+type ClientError struct {
+	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	SessionID   *string   `gorm:"type:uuid;index" json:"session_id,omitempty"` // NULL when reported outside a session
+	Kind        string    `gorm:"size:20;not null;index" json:"kind"`          // playback, decode, reconnect, other
+	Message     string    `gorm:"type:text;not null" json:"message"`
+	Context     *string   `gorm:"type:jsonb" json:"context,omitempty"` // Details from the latest occurrence
+	Source      string    `gorm:"size:10;not null" json:"source"`      // websocket, http
+	UserAgent   string    `gorm:"size:255" json:"user_agent,omitempty"`
+	Count       int       `gorm:"not null;default:1" json:"count"`
+	FirstSeenAt time.Time `gorm:"not null" json:"first_seen_at"`
+	LastSeenAt  time.Time `gorm:"not null;index" json:"last_seen_at"`
+}
+
+// ClientErrorStats totals one kind of client error in a session
+type ClientErrorStats struct {
+	SessionID *string    `json:"session_id,omitempty"`
+	UserID    string     `json:"user_id"`
+	Kind      string     `json:"kind"`
+	Count     int64      `json:"count"`
+	Messages  int64      `json:"messages"` // Different messages
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CoachingNote is a private tip for a candidate practicing with hints, written after one of
+// their answers. It is shown only to the candidate; the interviewer never sees or speaks it.
+type CoachingNote struct {
+	ID           string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID    string    `gorm:"type:uuid;not null;index" json:"session_id"`
+	TranscriptID *string   `gorm:"type:uuid" json:"transcript_id,omitempty"` // The answer coached
+	Question     string    `gorm:"type:text" json:"question"`                // The interviewer's question it answered
+	Note         string    `gorm:"type:text;not null" json:"note"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BannedTopic is a topic interviewers must never raise, e.g. a legally protected characteristic.
+// Topics are banned by an agent owner for their agents, or site-wide (OwnerID is NULL) by
+// admins for every agent.
+type BannedTopic struct {
+	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OwnerID   *string        `gorm:"type:uuid;index" json:"owner_id,omitempty"`     // NULL for site-wide topics
+	Topic     string         `gorm:"size:100;not null" json:"topic"`                // e.g. "Age"
+	Terms     string         `gorm:"type:jsonb;not null;default:'[]'" json:"terms"` // Words and phrases that raise it, e.g. "how old"
+	Reason    string         `gorm:"size:500" json:"reason,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ComplianceViolation is an interviewer reply that raised a banned topic and was blocked
+// before reaching the candidate
+type ComplianceViolation struct {
+	ID            string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID     string    `gorm:"type:uuid;not null;index" json:"session_id"`
+	AgentID       string    `gorm:"type:uuid;not null;index" json:"agent_id"`
+	OwnerID       *string   `gorm:"type:uuid;index" json:"owner_id,omitempty"` // The agent's owner; NULL for public agents
+	BannedTopicID string    `gorm:"type:uuid;not null;index" json:"banned_topic_id"`
+	Topic         string    `gorm:"size:100;not null" json:"topic"`
+	Term          string    `gorm:"size:100;not null" json:"term"`     // What matched
+	Content       string    `gorm:"type:text;not null" json:"content"` // The blocked reply
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+}
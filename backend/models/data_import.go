@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Data import file formats
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+// DataImport is a file of past mock-interview results a user brought from another practice
+// platform. The sessions it created carry its ID, and are removed with it.
+type DataImport struct {
+	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	Source    string         `gorm:"size:50;not null" json:"source"` // Platform the results came from, as the user named it
+	Format    string         `gorm:"size:10;not null" json:"format"` // csv, json
+	Filename  string         `gorm:"size:255" json:"filename,omitempty"`
+	Sessions  int            `gorm:"not null;default:0" json:"sessions"` // Sessions created
+	Skipped   int            `gorm:"not null;default:0" json:"skipped"`  // Records already imported earlier, matched by external ID
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DocumentKindResume         = "resume"
+	DocumentKindJobDescription = "job_description"
+)
+
+// Document is an uploaded resume or job description and the text extracted from it
+type Document struct {
+	ID          string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	Kind        string         `gorm:"size:20;not null;check:kind IN ('resume', 'job_description')" json:"kind"`
+	Filename    string         `gorm:"size:255;not null" json:"filename"`
+	ContentType string         `gorm:"size:100;not null" json:"content_type"`
+	Size        int64          `gorm:"not null" json:"size"`
+	Text        string         `gorm:"type:text;not null" json:"-"` // Full extracted text; the sections are what clients see
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Sections []DocumentSection `gorm:"foreignKey:DocumentID" json:"sections,omitempty"`
+}
+
+// DocumentSection is one headed section of a parsed document, e.g. "Experience" or "Requirements"
+type DocumentSection struct {
+	ID         string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	DocumentID string    `gorm:"type:uuid;not null;index" json:"document_id"`
+	Position   int       `gorm:"not null" json:"position"`
+	Heading    string    `gorm:"size:100" json:"heading"` // Empty for text before the first heading
+	Content    string    `gorm:"type:text;not null" json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// DrillPlan is what a drill session probes: the weaknesses found in the summary of the session
+// it was created from. It links the drill back to that session, so progress can be tracked.
+type DrillPlan struct {
+	ID              string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID       string    `gorm:"type:uuid;not null;uniqueIndex" json:"session_id"`  // The drill session
+	SourceSessionID string    `gorm:"type:uuid;not null;index" json:"source_session_id"` // The session whose summary it drills
+	Topics          string    `gorm:"type:jsonb;not null;default:'[]'" json:"topics"`    // Weaknesses to probe, one question area each
+	SourceScore     float64   `gorm:"type:decimal(5,2)" json:"source_score"`             // Overall score of the source session
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// DrillProgress is a drill session of a source session and how it went
+type DrillProgress struct {
+	SessionID    string     `json:"session_id"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	Topics       string     `json:"topics"`
+	SourceScore  float64    `json:"source_score"`
+	OverallScore *float64   `json:"overall_score,omitempty"` // Nil until the drill has a summary
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+}
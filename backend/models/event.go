@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Event statuses
+const (
+	EventStatusPending   = "pending"
+	EventStatusProcessed = "processed"
+	EventStatusFailed    = "failed"
+)
+
+// Event is a durable record of an internal event published on the event bus
+type Event struct {
+	ID          string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Type        string     `gorm:"size:100;not null;index" json:"type"`
+	SessionID   string     `gorm:"size:100;index" json:"session_id,omitempty"`
+	Payload     string     `gorm:"type:jsonb;not null;default:'{}'" json:"payload"`
+	Status      string     `gorm:"size:20;not null;default:'pending';index" json:"status"` // pending, processed, failed
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// EventDelivery records that a subscriber handled an event, so redelivering the event after
+// another subscriber failed skips it
+type EventDelivery struct {
+	EventID    string    `gorm:"type:uuid;primaryKey" json:"event_id"`
+	Subscriber string    `gorm:"size:100;primaryKey" json:"subscriber"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScoringExperiment defines a candidate summary/scoring prompt evaluated in shadow mode
+type ScoringExperiment struct {
+	ID             string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name           string         `gorm:"not null;uniqueIndex" json:"name"`
+	Description    string         `gorm:"type:text" json:"description,omitempty"`
+	PromptTemplate string         `gorm:"type:text;not null" json:"prompt_template"`                  // Go text/template rendered with ShadowPromptData
+	SampleRate     float64        `gorm:"type:decimal(3,2);not null;default:0.10" json:"sample_rate"` // Fraction of sessions (0.00 to 1.00)
+	IsActive       bool           `gorm:"default:true" json:"is_active"`
+	CreatedBy      string         `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	ShadowSummaries []ShadowSummary `gorm:"foreignKey:ExperimentID" json:"shadow_summaries,omitempty"`
+}
+
+// ShadowSummary stores a summary generated by an experiment prompt; never shown to candidates
+type ShadowSummary struct {
+	ID                  string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ExperimentID        string         `gorm:"type:uuid;not null;uniqueIndex:idx_shadow_experiment_session" json:"experiment_id"`
+	SessionID           string         `gorm:"type:uuid;not null;uniqueIndex:idx_shadow_experiment_session" json:"session_id"`
+	ProductionSummaryID string         `gorm:"type:uuid;index" json:"production_summary_id"`
+	Summary             string         `gorm:"type:text" json:"summary"`
+	Strengths           string         `gorm:"type:text" json:"strengths,omitempty"`
+	Weaknesses          string         `gorm:"type:text" json:"weaknesses,omitempty"`
+	Recommendations     string         `gorm:"type:text" json:"recommendations,omitempty"`
+	OverallScore        float64        `gorm:"type:decimal(5,2)" json:"overall_score"`
+	QualityScore        float64        `gorm:"type:decimal(5,2)" json:"quality_score"`
+	Error               string         `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Experiment        ScoringExperiment `gorm:"foreignKey:ExperimentID" json:"-"`
+	ProductionSummary *InterviewSummary `gorm:"foreignKey:ProductionSummaryID" json:"production_summary,omitempty"`
+}
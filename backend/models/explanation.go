@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ExplanationKindQuestion = "question" // Why the interviewer asked a question
+	ExplanationKindScore    = "score"    // Why a metric received its score
+)
+
+// Explanation is a brief machine-readable rationale from the model for a question it asked or
+// a score it gave. Explanations are captured out of band and never shown during the interview.
+type Explanation struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID string    `gorm:"type:uuid;not null;index" json:"session_id"`
+	Kind      string    `gorm:"size:20;not null;index" json:"kind"` // question, score
+	Subject   string    `gorm:"type:text;not null" json:"subject"`  // The question asked, or the metric name
+	Intent    string    `gorm:"size:100" json:"intent,omitempty"`   // Short label, e.g. "probe depth" or "clarify"
+	Rationale string    `gorm:"type:text;not null" json:"rationale"`
+	Evidence  string    `gorm:"type:jsonb;not null;default:'[]'" json:"evidence"` // Transcript excerpts the rationale relies on
+	Model     string    `gorm:"size:50" json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+}
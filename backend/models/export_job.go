@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Export job kinds
+const (
+	ExportKindSessions = "sessions" // A bundle of session reports
+	ExportKindAccount  = "account"  // An archive of all the user's data
+)
+
+// Export job states
+const (
+	ExportJobPending = "pending" // Waiting for a worker
+	ExportJobRunning = "running"
+	ExportJobDone    = "done"    // The archive is ready to download until ExpiresAt
+	ExportJobFailed  = "failed"  // Gave up after the last attempt
+	ExportJobExpired = "expired" // The archive was deleted after its retention
+)
+
+// ExportJob is a queued request to build an export archive in the background. The finished
+// archive is kept in the blob storage and downloaded through signed, expiring URLs.
+type ExportJob struct {
+	ID         string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     string         `gorm:"type:uuid;not null;index;uniqueIndex:idx_export_job_open_account,where:kind = 'account' AND status IN ('pending'\\,'running')" json:"user_id"`
+	Kind       string         `gorm:"size:20;not null;check:kind IN ('sessions', 'account')" json:"kind"`
+	Format     string         `gorm:"size:10;not null" json:"format"`         // Report format of a sessions bundle: pdf, md or json
+	SessionIDs string         `gorm:"type:text" json:"session_ids,omitempty"` // Comma-separated sessions to bundle; all completed sessions when empty
+	Status     string         `gorm:"size:20;not null;default:'pending';index;check:status IN ('pending', 'running', 'done', 'failed', 'expired')" json:"status"`
+	Progress   int            `gorm:"not null;default:0" json:"progress"` // Items written to the archive so far
+	Total      int            `gorm:"not null;default:0" json:"total"`    // Items the archive will hold, once known
+	Attempts   int            `gorm:"not null;default:0" json:"attempts"`
+	LastError  string         `gorm:"type:text" json:"last_error,omitempty"`
+	BlobKey    string         `gorm:"size:255" json:"-"`              // Where the archive is stored
+	Size       int64          `gorm:"not null;default:0" json:"size"` // Archive size in bytes
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time     `gorm:"index" json:"expires_at,omitempty"` // When the archive is deleted
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// InterviewFlow is a directed graph of interview stages attached to an agent, e.g.
+// intro → behavioral → coding → wrap-up. It is stored as JSON on Agent.Flow.
+type InterviewFlow struct {
+	Start  string      `json:"start,omitempty"` // ID of the first stage; defaults to the first listed
+	Stages []FlowStage `json:"stages"`
+}
+
+// FlowStage is one stage of an interview flow. The stage advances to Next once the candidate
+// has answered MaxTurns times or MaxSeconds have passed, whichever comes first, or after the
+// next answer once the interviewer signals the stage is covered if AdvanceOnSignal is set; a
+// stage without Next is the last one.
+type FlowStage struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Directive       string `json:"directive"`                   // What the interviewer should do in this stage
+	MaxTurns        int    `json:"max_turns,omitempty"`         // Candidate answers before advancing; 0 for no limit
+	MaxSeconds      int    `json:"max_seconds,omitempty"`       // Time in the stage before advancing; 0 for no limit
+	AdvanceOnSignal bool   `json:"advance_on_signal,omitempty"` // Let the interviewer end the stage early once its goals are met
+	Next            string `json:"next,omitempty"`
+	Transition      string `json:"transition,omitempty"` // Spoken when entering the stage, e.g. "Let's switch to system design now."
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Impersonation is an admin support session acting as another user
+type Impersonation struct {
+	ID        string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AdminID   string     `gorm:"type:uuid;not null;index" json:"admin_id"`
+	UserID    string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	Reason    string     `gorm:"type:text;not null" json:"reason"`
+	ReadOnly  bool       `gorm:"not null;default:true" json:"read_only"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Relationships
+	Admin     User                      `gorm:"foreignKey:AdminID" json:"-"`
+	User      User                      `gorm:"foreignKey:UserID" json:"-"`
+	AuditLogs []ImpersonationAuditEntry `gorm:"foreignKey:ImpersonationID" json:"audit_logs,omitempty"`
+}
+
+// ImpersonationAuditEntry records a single request made while impersonating
+type ImpersonationAuditEntry struct {
+	ID              string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ImpersonationID string    `gorm:"type:uuid;not null;index" json:"impersonation_id"`
+	Method          string    `gorm:"size:10;not null" json:"method"`
+	Path            string    `gorm:"size:500;not null" json:"path"`
+	StatusCode      int       `gorm:"not null" json:"status_code"`
+	Blocked         bool      `gorm:"not null;default:false" json:"blocked"` // Rejected because the impersonation is read-only
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -6,17 +6,44 @@ import (
 	"gorm.io/gorm"
 )
 
+// Transcript phases
+const (
+	TranscriptPhaseWarmup    = "warmup"    // Ice-breaker small talk, excluded from scoring
+	TranscriptPhaseInterview = "interview" // Scored interview turns
+)
+
+// Transcript kinds
+const (
+	TranscriptKindText = "text" // Spoken or typed conversation
+	TranscriptKindCode = "code" // Code submitted from the editor
+)
+
 // InterviewTranscript stores the ordered, turn-by-turn text of the conversation
 type InterviewTranscript struct {
-	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	SessionID string         `gorm:"type:uuid;not null;index" json:"session_id"`
-	TurnOrder int            `gorm:"not null" json:"turn_order"` // Order of the turn in the conversation
-	Speaker   string         `gorm:"not null;check:speaker IN ('user', 'agent')" json:"speaker"`
-	Content   string         `gorm:"type:text;not null" json:"content"`
-	Timestamp time.Time      `gorm:"not null" json:"timestamp"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID   string         `gorm:"type:uuid;not null;index" json:"session_id"`
+	TurnOrder   int            `gorm:"not null" json:"turn_order"` // Order of the turn in the conversation, allocated when it is saved
+	Speaker     string         `gorm:"not null;check:speaker IN ('user', 'agent')" json:"speaker"`
+	Content     string         `gorm:"type:text;not null" json:"content"`
+	Phase       string         `gorm:"size:20;not null;default:'interview'" json:"phase"` // warmup, interview
+	Stage       string         `gorm:"size:50" json:"stage,omitempty"`                    // Interview flow stage ID, empty without a flow
+	Kind        string         `gorm:"size:10;not null;default:'text'" json:"kind"`       // text, code
+	Language    string         `gorm:"size:20" json:"language,omitempty"`                 // Programming language of a code turn
+	Findings    string         `gorm:"type:text" json:"findings,omitempty"`               // Static analysis output behind a code analysis
+	ReplyToID   *string        `gorm:"type:uuid" json:"reply_to_id,omitempty"`            // Code turn a code analysis responds to
+	QuestionID  *string        `gorm:"type:uuid" json:"question_id,omitempty"`            // Bank question that was current when the code was submitted
+	TestsPassed int            `gorm:"not null;default:0" json:"tests_passed"`            // Hidden tests of the question the code turn passed
+	TestsTotal  int            `gorm:"not null;default:0" json:"tests_total"`             // Hidden tests run; 0 when the code wasn't graded
+	TestOutput  string         `gorm:"type:text" json:"-"`                                // Output of the hidden test run, kept for reviewers; never sent to the candidate
+	Confidence  *float64       `json:"confidence,omitempty"`                              // Transcription confidence (0-1) of a spoken answer, if the provider reports one
+	Original    string         `gorm:"type:text" json:"original,omitempty"`               // What was transcribed, when the candidate corrected it in review
+	Timestamp   time.Time      `gorm:"not null" json:"timestamp"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Full-text search vector of the content, kept up to date by Postgres and never read or written
+	SearchVector string `gorm:"->:false;type:tsvector GENERATED ALWAYS AS (to_tsvector('english', content)) STORED;index:idx_interview_transcripts_search,type:gin" json:"-"`
 
 	// Relationships
 	Session InterviewSession `gorm:"foreignKey:SessionID" json:"session"`
@@ -24,21 +51,48 @@ type InterviewTranscript struct {
 
 // InterviewSummary stores the final AI-generated narrative analysis
 type InterviewSummary struct {
-	ID              string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	SessionID       string         `gorm:"type:uuid;not null;uniqueIndex" json:"session_id"`
-	Summary         string         `gorm:"type:text;not null" json:"summary"` // Narrative summary
-	Strengths       string         `gorm:"type:text" json:"strengths,omitempty"`
-	Weaknesses      string         `gorm:"type:text" json:"weaknesses,omitempty"`
-	Recommendations string         `gorm:"type:text" json:"recommendations,omitempty"`
-	OverallScore    float64        `gorm:"type:decimal(5,2)" json:"overall_score"` // 0.00 to 100.00
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                   string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID            string         `gorm:"type:uuid;not null;uniqueIndex" json:"session_id"`
+	Summary              string         `gorm:"type:text;not null" json:"summary"` // Narrative summary
+	Strengths            string         `gorm:"type:text" json:"strengths,omitempty"`
+	Weaknesses           string         `gorm:"type:text" json:"weaknesses,omitempty"`
+	Recommendations      string         `gorm:"type:text" json:"recommendations,omitempty"`
+	OverallScore         float64        `gorm:"type:decimal(5,2)" json:"overall_score"`             // 0.00 to 100.00
+	ScoringPolicyID      *string        `gorm:"type:uuid;index" json:"scoring_policy_id,omitempty"` // Policy applied at finalization, for auditability
+	ScoringPolicyVersion int            `json:"scoring_policy_version,omitempty"`
+	RawScore             *float64       `gorm:"type:decimal(5,2)" json:"raw_score,omitempty"` // Score proposed by the model before the policy
+	Passed               *bool          `json:"passed,omitempty"`
+	ScoreBreakdown       string         `gorm:"type:jsonb" json:"score_breakdown,omitempty"` // Weights, metric scores and gate results used
+	Version              int            `gorm:"not null;default:1" json:"version"`           // Incremented each time the summary is regenerated
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
 	Session InterviewSession `gorm:"foreignKey:SessionID" json:"session"`
 }
 
+// SummaryVersion is a superseded summary of a session, archived with its performance scores
+// when the summary is regenerated, e.g. by a re-scoring backfill
+type SummaryVersion struct {
+	ID                   string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID            string    `gorm:"type:uuid;not null;uniqueIndex:idx_summary_version" json:"session_id"`
+	Version              int       `gorm:"not null;uniqueIndex:idx_summary_version" json:"version"`
+	Summary              string    `gorm:"type:text;not null" json:"summary"`
+	Strengths            string    `gorm:"type:text" json:"strengths,omitempty"`
+	Weaknesses           string    `gorm:"type:text" json:"weaknesses,omitempty"`
+	Recommendations      string    `gorm:"type:text" json:"recommendations,omitempty"`
+	OverallScore         float64   `gorm:"type:decimal(5,2)" json:"overall_score"`
+	ScoringPolicyID      *string   `gorm:"type:uuid" json:"scoring_policy_id,omitempty"`
+	ScoringPolicyVersion int       `json:"scoring_policy_version,omitempty"`
+	RawScore             *float64  `gorm:"type:decimal(5,2)" json:"raw_score,omitempty"`
+	Passed               *bool     `json:"passed,omitempty"`
+	ScoreBreakdown       string    `gorm:"type:jsonb" json:"score_breakdown,omitempty"`
+	Scores               string    `gorm:"type:jsonb;not null;default:'[]'" json:"scores"` // The version's performance scores
+	GeneratedAt          time.Time `json:"generated_at"`                                   // When the version was generated
+	CreatedAt            time.Time `json:"created_at"`                                     // When it was superseded
+}
+
 // PerformanceScore is a key-value table to store scores for various metrics
 // This allows for future expansion without schema changes
 type PerformanceScore struct {
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// InterviewInvite is a link a recruiter sends an external candidate to take one interview with
+// an organization's agent without signing up. Starting it creates a guest account for the
+// candidate and the session, whose results the organization's admins see with their cohort's.
+// The link carries the token; only its hash is stored.
+type InterviewInvite struct {
+	ID             string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OrganizationID string     `gorm:"type:uuid;not null;index" json:"organization_id"`
+	AgentID        string     `gorm:"type:uuid;not null;index" json:"agent_id"`
+	CandidateName  string     `gorm:"size:255" json:"candidate_name,omitempty"`
+	CandidateEmail string     `gorm:"size:255" json:"candidate_email,omitempty"` // Where the link was emailed, if anywhere
+	Token          string     `gorm:"uniqueIndex;not null" json:"-"`             // SHA256 hash of the invite token
+	CreatedBy      string     `gorm:"type:uuid;not null" json:"created_by"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`                           // When the candidate started the interview; the link is spent
+	GuestUserID    *string    `gorm:"type:uuid;index" json:"guest_user_id,omitempty"` // Guest account created for the candidate
+	SessionID      *string    `gorm:"type:uuid;index" json:"session_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	Organization *Organization     `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	Agent        *Agent            `gorm:"foreignKey:AgentID" json:"agent,omitempty"`
+	Session      *InterviewSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Legal document kinds
+const (
+	LegalDocumentTerms   = "terms"   // Terms of service
+	LegalDocumentPrivacy = "privacy" // Privacy policy
+)
+
+// LegalDocument is a published version of the terms of service or privacy policy.
+// The most recently published version of each kind is the one users must accept.
+type LegalDocument struct {
+	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Kind        string    `gorm:"size:20;not null;uniqueIndex:idx_legal_kind_version;check:kind IN ('terms', 'privacy')" json:"kind"`
+	Version     string    `gorm:"size:50;not null;uniqueIndex:idx_legal_kind_version" json:"version"`
+	URL         string    `gorm:"size:500;not null" json:"url"`
+	Summary     string    `gorm:"type:text" json:"summary,omitempty"` // What changed, shown when re-prompting
+	PublishedBy string    `gorm:"type:uuid" json:"published_by,omitempty"`
+	PublishedAt time.Time `gorm:"not null;index" json:"published_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// LegalAcceptance records a user accepting a specific document version
+type LegalAcceptance struct {
+	ID         string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     string    `gorm:"type:uuid;not null;uniqueIndex:idx_acceptance_user_document" json:"user_id"`
+	DocumentID string    `gorm:"type:uuid;not null;uniqueIndex:idx_acceptance_user_document" json:"document_id"`
+	Kind       string    `gorm:"size:20;not null" json:"kind"`
+	Version    string    `gorm:"size:50;not null" json:"version"`
+	IPAddress  string    `gorm:"size:45" json:"ip_address"`
+	AcceptedAt time.Time `gorm:"not null" json:"accepted_at"`
+
+	// Relationships
+	User     User          `gorm:"foreignKey:UserID" json:"-"`
+	Document LegalDocument `gorm:"foreignKey:DocumentID" json:"-"`
+}
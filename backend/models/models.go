@@ -4,10 +4,31 @@ package models
 // Import this package to access all model types
 
 // All models are automatically exported from their respective files:
-// - User, RefreshToken, PermanentToken from user.go
+// - User, RefreshToken, PermanentToken, KnownDevice from user.go
 // - Agent, InterviewSession from agent.go
 // - InterviewTranscript, InterviewSummary, PerformanceScore from interview.go
 // - Message, UserStats from message.go
+// - SummaryQuality, SummaryQualityStats from summary_quality.go
+// - ScoringExperiment, ShadowSummary from experiment.go
+// - Event from event.go
+// - AudioRecording from recording.go
+// - SessionToken, SessionNote from session_token.go
+// - LegalDocument, LegalAcceptance from legal.go
+// - Impersonation, ImpersonationAuditEntry from impersonation.go
+// - Notification, NotificationSettings from notification.go
+// - AgentUsageWebhook, AgentUsageDigest, AgentUsageStats from agent_webhook.go
+// - ScoringPolicy, ScoringGate from scoring_policy.go
+// - Certificate from certificate.go
+// - Explanation from explanation.go
+// - Document, DocumentSection from document.go
+// - InterviewFlow, FlowStage from flow.go (stored as JSON on agents)
+// - QuestionBank, Question from question_bank.go
+// - SessionFlag from session_flag.go
+// - AgentHealth from agent_health.go
+// - SummaryJob from summary_job.go
+// - Organization, OrgMembership, OrgInvite from organization.go
+// - Webhook, WebhookDelivery from webhook.go
+// - APIKey from api_key.go
 
 // Database schema overview:
 // 1. users - Managed by cookie-based authentication
@@ -16,3 +37,50 @@ package models
 // 4. interview_transcripts - Stores the ordered, turn-by-turn text of the conversation
 // 5. interview_summaries - Stores the final AI-generated narrative analysis
 // 6. performance_scores - A key-value table to store scores for various metrics
+// 7. summary_qualities - Automatic quality checks for generated summaries
+// 8. scoring_experiments - Candidate scoring prompts evaluated in shadow mode
+// 9. shadow_summaries - Experiment summaries stored alongside production summaries
+// 10. events - Durable log of internal events published on the event bus
+// 11. audio_recordings - Recorded audio turns and their hot/cold storage tier
+// 12. session_tokens - Short-lived bearer tokens scoped to a single session for external tools
+// 13. session_notes - Notes appended to a session, e.g. by an external tool
+// 14. legal_documents - Published versions of the terms of service and privacy policy
+// 15. legal_acceptances - Which document versions each user accepted, when and from which IP
+// 16. impersonations - Time-limited admin support sessions acting as another user
+// 17. impersonation_audit_entries - Every request made during an impersonation
+// 18. known_devices - Device and location combinations each user has signed in from
+// 19. notifications - In-app notifications, e.g. summaries ready, timed-out sessions and security alerts
+// 20. agent_usage_webhooks - Per-agent opt-in to anonymized usage digests for public agent owners
+// 21. agent_usage_digests - Each digest period delivered or withheld for an agent usage webhook
+// 22. scoring_policies - Custom OverallScore formulas (metric weights and minimum gates) applied at summary finalization
+// 23. certificates - Signed, shareable certificates for completed sessions above the score threshold
+// 24. explanations - Model rationales for the questions asked and scores given, behind "why was I asked this?"
+// 25. documents - Uploaded resumes and job descriptions with their extracted text
+// 26. document_sections - Headed sections parsed from each document, used to tailor interviews
+// 27. question_banks - Users' curated sets of interview questions that agents can be linked to
+// 28. questions - The ordered questions in each bank, asked instead of free-form questions
+// 29. session_flags - Things for reviewers to check in a session, e.g. code answers similar to a known solution
+// 30. agent_healths - Nightly catalog ranking score of each public agent and the factors behind it
+// 31. summary_jobs - Queued summary generations, their state and retries
+// 32. client_errors - Playback, decode and reconnect failures reported by the frontend
+// 33. proctor_events - Focus changes and heartbeat gaps recorded in proctored sessions
+// 34. org_brandings - Logo, colors and footer text an agent owner's exports and share pages carry
+// 35. drill_plans - Weakness topics a drill session probes, linked to the session they came from
+// 36. banned_topics - Topics interviewers must never raise, per agent owner or site-wide
+// 37. compliance_violations - Interviewer replies blocked for raising a banned topic
+// 38. organizations - Bootcamps and companies whose members practice against shared agents
+// 39. org_memberships - Each member's role in an organization
+// 40. org_invites - Pending and accepted invitations to join an organization
+// 41. notification_settings - Each user's email, webhook and weekly digest choices
+// 42. webhooks - External systems subscribed to lifecycle events of an agent owner's sessions
+// 43. webhook_deliveries - Each event sent to a webhook, its attempts and the latest response
+// 44. api_keys - Scoped bearer keys for server-to-server integrations
+// 45. summary_versions - Superseded summaries of regenerated sessions, with their scores
+// 46. rescore_backfills - Admin-requested re-scoring of recent sessions and their progress
+// 47. session_client_infos - Browser, platform, audio codec and connection type each session was held from
+// 48. data_imports - Past mock-interview results users imported from other practice platforms
+// 49. export_jobs - Background builds of session report bundles and account data archives
+// 50. turn_metrics - How the candidate typed each answer of a text interview: latency, edits and pastes
+// 51. rubric_items - Metrics of an agent's custom scoring rubric, with their descriptions and weights
+// 52. coaching_notes - Private tips written after each answer of a session practiced with hints
+// 53. speech_metrics - How the candidate spoke each answer of a voice interview: pace, filler words and pauses
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification types
+const (
+	NotificationNewDeviceLogin  = "new_device_login"
+	NotificationSummaryReady    = "summary_ready"
+	NotificationSessionTimedOut = "session_timed_out"
+	NotificationProgressDigest  = "progress_digest"
+)
+
+// Notification delivery channels. Every notification is in-app; the others are optional.
+const (
+	NotificationChannelInApp   = "in_app"
+	NotificationChannelEmail   = "email"
+	NotificationChannelWebhook = "webhook"
+)
+
+// Notification is an in-app notification, optionally also sent by email
+type Notification struct {
+	ID        string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	EventID   *string    `gorm:"type:uuid;uniqueIndex" json:"-"` // Event that raised it, so redelivery doesn't duplicate it
+	Type      string     `gorm:"size:50;not null" json:"type"`
+	Title     string     `gorm:"size:200;not null" json:"title"`
+	Body      string     `gorm:"type:text;not null" json:"body"`
+	Link      string     `gorm:"size:1000" json:"link,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	EmailedAt *time.Time `json:"emailed_at,omitempty"`
+	// When it was sent over the user's delivery channels; redelivery skips it after that
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	// Held back until the user's quiet hours end; hidden in-app and not emailed until then
+	DeliverAfter *time.Time `gorm:"index" json:"deliver_after,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// NotificationSettings chooses how a user is notified besides in-app. Users without settings
+// get email and no webhook or digest.
+type NotificationSettings struct {
+	UserID           string     `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Email            bool       `gorm:"not null" json:"email"` // Deliberately no default; false must persist
+	WebhookURL       string     `gorm:"size:1000" json:"webhook_url,omitempty"`
+	WebhookSecret    string     `gorm:"size:100" json:"-"` // HMAC key for the X-Praxis-Signature header
+	LastWebhookError string     `gorm:"type:text" json:"last_webhook_error,omitempty"`
+	WeeklyDigest     bool       `gorm:"not null" json:"weekly_digest"`
+	NextDigestAt     *time.Time `gorm:"index" json:"next_digest_at,omitempty"` // End of the next digest week
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// DefaultNotificationSettings are the settings of a user who hasn't chosen any
+func DefaultNotificationSettings(userID string) *NotificationSettings {
+	return &NotificationSettings{UserID: userID, Email: true}
+}
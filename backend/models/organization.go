@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization roles, from least to most privileged
+const (
+	OrgRoleMember = "member" // Practices against the organization's agents
+	OrgRoleAdmin  = "admin"  // Also invites members, shares agents and question banks and sees the cohort's sessions
+	OrgRoleOwner  = "owner"  // Also manages admins and other owners
+)
+
+// Organization is a bootcamp or company whose members practice against the agents shared with it
+type Organization struct {
+	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name      string         `gorm:"size:100;not null" json:"name"`
+	CreatedBy string         `gorm:"type:uuid;not null;index" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// OrgMembership is a user's role in an organization
+type OrgMembership struct {
+	ID             string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OrganizationID string    `gorm:"type:uuid;not null;uniqueIndex:idx_org_membership" json:"organization_id"`
+	UserID         string    `gorm:"type:uuid;not null;uniqueIndex:idx_org_membership;index" json:"user_id"`
+	Role           string    `gorm:"size:10;not null;check:role IN ('member', 'admin', 'owner')" json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relationships
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	User         *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// OrgInvite invites an email address to join an organization with a role. The emailed link
+// carries the token; only its hash is stored.
+type OrgInvite struct {
+	ID             string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OrganizationID string     `gorm:"type:uuid;not null;index" json:"organization_id"`
+	Email          string     `gorm:"not null" json:"email"`
+	Role           string     `gorm:"size:10;not null" json:"role"`
+	Token          string     `gorm:"uniqueIndex;not null" json:"-"` // SHA256 hash of the invite token
+	InvitedBy      string     `gorm:"type:uuid;not null" json:"invited_by"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy     *string    `gorm:"type:uuid" json:"accepted_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Relationships
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+}
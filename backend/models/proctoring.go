@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Proctoring event kinds. The client reports focus and fullscreen changes; the server records
+// gaps in the heartbeat.
+const (
+	ProctorEventFocusLost       = "focus_lost"        // The interview window lost focus
+	ProctorEventFocusRegained   = "focus_regained"    // Focus came back; DurationMs is how long it was away
+	ProctorEventTabHidden       = "tab_hidden"        // The interview tab was hidden, e.g. by switching tabs
+	ProctorEventFullscreenExit  = "fullscreen_exited" // The candidate left fullscreen
+	ProctorEventHeartbeatMissed = "heartbeat_missed"  // The client went quiet; DurationMs is the gap
+)
+
+// ProctorEvent is something a proctored session's reviewers should know about
+type ProctorEvent struct {
+	ID         string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID  string    `gorm:"type:uuid;not null;index" json:"session_id"`
+	Kind       string    `gorm:"size:20;not null" json:"kind"`
+	DurationMs int64     `gorm:"not null;default:0" json:"duration_ms,omitempty"`
+	OccurredAt time.Time `gorm:"not null" json:"occurred_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ProctoringReport sums up a proctored session's events for reviewers. Like flags, it is shown
+// with the summary and never affects the scores.
+type ProctoringReport struct {
+	SessionID         string         `json:"session_id"`
+	FocusLosses       int            `json:"focus_losses"`
+	TimeAwaySeconds   int64          `json:"time_away_seconds"`
+	FullscreenExits   int            `json:"fullscreen_exits"`
+	HeartbeatGaps     int            `json:"heartbeat_gaps"`
+	LongestGapSeconds int64          `json:"longest_gap_seconds"`
+	Clean             bool           `json:"clean"` // Nothing was recorded
+	Events            []ProctorEvent `json:"events"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Question difficulties
+const (
+	QuestionDifficultyEasy   = "easy"
+	QuestionDifficultyMedium = "medium"
+	QuestionDifficultyHard   = "hard"
+)
+
+// QuestionBank is a user's set of curated interview questions. An agent linked to a bank asks
+// its questions in order instead of writing its own.
+type QuestionBank struct {
+	ID             string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID         string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name           string         `gorm:"size:100;not null" json:"name"`
+	Description    string         `gorm:"type:text" json:"description,omitempty"`
+	OrganizationID *string        `gorm:"type:uuid;index" json:"organization_id,omitempty"` // Optional: organization whose agents may ask its questions
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Questions []Question `gorm:"foreignKey:BankID" json:"questions,omitempty"`
+}
+
+// Question is one question in a bank
+type Question struct {
+	ID         string `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	BankID     string `gorm:"type:uuid;not null;index" json:"bank_id"`
+	Position   int    `gorm:"not null" json:"position"` // Order the question is asked in
+	Text       string `gorm:"type:text;not null" json:"text"`
+	Topic      string `gorm:"size:100" json:"topic,omitempty"`
+	Difficulty string `gorm:"size:10" json:"difficulty,omitempty"` // easy, medium, hard
+	// Hidden unit tests that grade code submitted for a coding question; never shown to the
+	// interviewer or candidate. Submissions in another language than TestLanguage aren't graded.
+	TestLanguage string         `gorm:"size:20" json:"test_language,omitempty"`
+	TestSuite    string         `gorm:"type:text" json:"test_suite,omitempty"`
+	Solution     string         `gorm:"type:text" json:"solution,omitempty"` // Reference solution; answers too similar to it are flagged
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
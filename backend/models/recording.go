@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Recording storage tiers
+const (
+	RecordingTierHot       = "hot"       // Immediately playable
+	RecordingTierCold      = "cold"      // Archived to the cheaper class; must be restored before replay
+	RecordingTierRestoring = "restoring" // Being copied back to the hot tier for replay
+)
+
+// AudioRecording stores the location and storage tier of a recorded audio turn
+type AudioRecording struct {
+	ID           string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID    string         `gorm:"type:uuid;not null;index" json:"session_id"`
+	TranscriptID *string        `gorm:"type:uuid;index" json:"transcript_id,omitempty"` // The turn spoken, when known
+	Speaker      string         `gorm:"not null;check:speaker IN ('user', 'agent')" json:"speaker"`
+	StorageKey   string         `gorm:"size:255;not null;uniqueIndex" json:"-"`
+	ContentType  string         `gorm:"size:100;not null" json:"content_type"`
+	SizeBytes    int64          `gorm:"not null" json:"size_bytes"`
+	Tier         string         `gorm:"size:20;not null;default:'hot';index" json:"tier"` // hot, cold, restoring
+	StorageClass string         `gorm:"size:50;not null" json:"storage_class"`
+	ArchivedAt   *time.Time     `json:"archived_at,omitempty"` // Set once a cold copy exists
+	RestoredAt   *time.Time     `json:"restored_at,omitempty"` // Last time the hot copy was restored from cold
+	LastError    string         `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Session InterviewSession `gorm:"foreignKey:SessionID" json:"-"`
+}
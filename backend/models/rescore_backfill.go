@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Re-scoring backfill states
+const (
+	RescoreBackfillRunning   = "running"   // Queuing sessions, or waiting for their jobs to finish
+	RescoreBackfillDone      = "done"      // Every session was queued and its job has finished
+	RescoreBackfillCancelled = "cancelled" // Stopped by an admin; jobs not yet started were dropped
+)
+
+// RescoreBackfill is an admin-requested re-scoring of recent sessions' stored transcripts, e.g.
+// after a scoring policy changes. Sessions are queued as regeneration summary jobs a few at a
+// time, in session ID order from Cursor, so the backfill stays within the LLM budget.
+type RescoreBackfill struct {
+	ID              string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	RequestedBy     string     `gorm:"type:uuid;not null" json:"requested_by"`
+	ScoringPolicyID *string    `gorm:"type:uuid" json:"scoring_policy_id,omitempty"` // Only sessions scored under an older version of this policy
+	AgentID         *string    `gorm:"type:uuid" json:"agent_id,omitempty"`          // Only this agent's sessions
+	Since           time.Time  `gorm:"not null" json:"since"`                        // Only sessions started since
+	RatePerMinute   int        `gorm:"not null" json:"rate_per_minute"`              // Sessions queued per minute
+	Status          string     `gorm:"size:20;not null;default:'running';index;check:status IN ('running', 'done', 'cancelled')" json:"status"`
+	Total           int        `gorm:"not null;default:0" json:"total"`  // Sessions matching when the backfill started
+	Queued          int        `gorm:"not null;default:0" json:"queued"` // Sessions queued so far
+	Cursor          string     `gorm:"size:36;not null;default:''" json:"-"`
+	NextRunAt       time.Time  `gorm:"not null" json:"next_run_at"` // Earliest time the next sessions are queued
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RubricItem is one metric of an agent's custom scoring rubric. The summary model scores every
+// item of the rubric, and each score is saved as a performance score of that metric.
+type RubricItem struct {
+	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AgentID     string    `gorm:"type:uuid;not null;index" json:"agent_id"`
+	Position    int       `gorm:"not null;default:0" json:"position"` // Order within the rubric
+	Metric      string    `gorm:"size:100;not null" json:"metric"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`             // What the metric judges, shown to the model
+	Weight      float64   `gorm:"type:decimal(6,2);not null;default:1" json:"weight"` // Relative share of the overall score
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScoringPolicy defines how a summary's OverallScore is computed from its metric scores.
+// Policies are owned by an agent owner and assigned to their agents, or are site-wide
+// (OwnerID is NULL) and managed by admins, one of which may be the default.
+type ScoringPolicy struct {
+	ID           string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OwnerID      *string        `gorm:"type:uuid;index" json:"owner_id,omitempty"` // NULL for site-wide policies
+	Name         string         `gorm:"size:100;not null" json:"name"`
+	Weights      string         `gorm:"type:jsonb;not null;default:'{}'" json:"weights"` // Metric name to weight
+	Gates        string         `gorm:"type:jsonb;not null;default:'[]'" json:"gates"`   // Minimum metric scores, see ScoringGate
+	PassScore    float64        `gorm:"type:decimal(5,2);not null;default:0" json:"pass_score"`
+	GateScoreCap *float64       `gorm:"type:decimal(5,2)" json:"gate_score_cap,omitempty"` // OverallScore ceiling when a gate fails
+	IsDefault    bool           `gorm:"not null;default:false" json:"is_default"`          // Site-wide default for agents without a policy
+	Version      int            `gorm:"not null;default:1" json:"version"`                 // Incremented on every update
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ScoringGate fails a session whose metric score is below Min, e.g. communication < 40
+type ScoringGate struct {
+	Metric string  `json:"metric"`
+	Min    float64 `json:"min"`
+}
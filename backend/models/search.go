@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TranscriptMatch is a transcript turn matching a full-text search, with the session it was in
+type TranscriptMatch struct {
+	SessionID    string    `json:"session_id"`
+	TranscriptID string    `json:"transcript_id"`
+	Speaker      string    `json:"speaker"`
+	Timestamp    time.Time `json:"timestamp"`
+	Snippet      string    `json:"snippet"` // Excerpt of the turn with the matched words between highlight markers
+	Rank         float64   `json:"rank"`
+	AgentName    string    `json:"agent_name"`
+	StartedAt    time.Time `json:"started_at"`
+}
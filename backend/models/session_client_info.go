@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// SessionClientInfo describes the browser a session was held from, recorded when the candidate
+// connects and updated on reconnects, so failures can be broken down by client type
+type SessionClientInfo struct {
+	ID                    string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID             string    `gorm:"type:uuid;not null;uniqueIndex" json:"session_id"`
+	UserID                string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	UserAgent             string    `gorm:"size:255" json:"user_agent,omitempty"`
+	Browser               string    `gorm:"size:50;not null;index" json:"browser"`    // e.g. Chrome, Safari
+	Platform              string    `gorm:"size:50;not null;index" json:"platform"`   // e.g. macOS, iOS
+	AudioCodec            string    `gorm:"size:100;index" json:"audio_codec"`        // MIME type answers are recorded in, e.g. audio/webm;codecs=opus
+	ConnectionType        string    `gorm:"size:20;index" json:"connection_type"`     // e.g. wifi, cellular, 4g; empty if the browser doesn't say
+	Connects              int       `gorm:"not null;default:1" json:"connects"`       // Connections to the session, reconnects included
+	Transcriptions        int       `gorm:"not null;default:0" json:"transcriptions"` // Spoken answers sent for transcription
+	TranscriptionFailures int       `gorm:"not null;default:0" json:"transcription_failures"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// ClientTypeStats totals the sessions held from one type of client
+type ClientTypeStats struct {
+	Browser               string  `json:"browser"`
+	Platform              string  `json:"platform"`
+	AudioCodec            string  `json:"audio_codec"`
+	ConnectionType        string  `json:"connection_type"`
+	Sessions              int64   `json:"sessions"`
+	Reconnects            int64   `json:"reconnects"`
+	SessionsWithErrors    int64   `json:"sessions_with_errors"` // Sessions the client reported errors in
+	Transcriptions        int64   `json:"transcriptions"`
+	TranscriptionFailures int64   `json:"transcription_failures"`
+	ErrorRate             float64 `json:"error_rate"`              // Share of sessions with client errors
+	TranscriptionFailRate float64 `json:"transcription_fail_rate"` // Share of transcriptions that failed
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	SessionFlagPlagiarism = "plagiarism" // A code answer closely matches a reference solution or another candidate's answer
+)
+
+// Sources a plagiarism flag's match came from
+const (
+	FlagSourceSolution   = "solution"   // The question's reference solution
+	FlagSourceSubmission = "submission" // Another candidate's answer to the same question
+)
+
+// SessionFlag marks something in a session for reviewers to look at, shown with the summary and
+// to admins. Flags never name the other candidate; a match is identified only by its transcript.
+type SessionFlag struct {
+	ID                  string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID           string    `gorm:"type:uuid;not null;index" json:"session_id"`
+	Kind                string    `gorm:"size:20;not null;index" json:"kind"` // plagiarism
+	TranscriptID        string    `gorm:"type:uuid;not null" json:"transcript_id"`
+	QuestionID          *string   `gorm:"type:uuid" json:"question_id,omitempty"`
+	Source              string    `gorm:"size:20" json:"source,omitempty"`                  // solution, submission
+	MatchedTranscriptID *string   `gorm:"type:uuid" json:"matched_transcript_id,omitempty"` // The matching submission
+	Similarity          float64   `gorm:"type:decimal(4,3);not null" json:"similarity"`     // 0 to 1
+	CreatedAt           time.Time `json:"created_at"`
+}
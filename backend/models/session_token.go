@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionToken is a short-lived bearer token scoped to a single interview session,
+// minted by the session owner for external tools. Scopes are stored comma-separated
+// in the form session:{id}:{action}.
+type SessionToken struct {
+	ID         string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	SessionID  string         `gorm:"type:uuid;not null;index" json:"session_id"`
+	Token      string         `gorm:"uniqueIndex;not null" json:"-"` // SHA256 hash of the bearer token
+	Label      string         `gorm:"size:100" json:"label,omitempty"`
+	Scopes     string         `gorm:"type:text;not null" json:"scopes"`
+	ExpiresAt  time.Time      `gorm:"not null" json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User    User             `gorm:"foreignKey:UserID" json:"-"`
+	Session InterviewSession `gorm:"foreignKey:SessionID" json:"-"`
+}
+
+// SessionNote is a free-form note attached to a session, e.g. by an external note-taking tool or
+// jotted down by the candidate mid-interview. Notes are never part of the interviewer's context.
+type SessionNote struct {
+	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID string         `gorm:"type:uuid;not null;index" json:"session_id"`
+	TokenID   *string        `gorm:"type:uuid" json:"token_id,omitempty"` // Session token used to add the note
+	Content   string         `gorm:"type:text;not null" json:"content"`
+	Private   bool           `gorm:"not null;default:false" json:"private"` // The candidate's own note; hidden from external tools
+	TurnOrder int            `json:"turn_order,omitempty"`                  // Transcript turn the note was taken during
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Session InterviewSession `gorm:"foreignKey:SessionID" json:"-"`
+}
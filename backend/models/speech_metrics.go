@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// SpeechMetrics describes how the candidate spoke one answer of a voice interview. Words and
+// filler words come from the transcription; the duration and pauses are measured by the client
+// while recording, and are zero when it didn't report them.
+type SpeechMetrics struct {
+	ID             string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID      string    `gorm:"type:uuid;not null;index" json:"session_id"`
+	TranscriptID   string    `gorm:"type:uuid;not null;uniqueIndex" json:"transcript_id"` // The answer's user transcript turn
+	Words          int       `gorm:"not null;default:0" json:"words"`
+	FillerWords    int       `gorm:"not null;default:0" json:"filler_words"`     // "um", "uh", "you know" and the like
+	DurationMs     int64     `gorm:"not null;default:0" json:"duration_ms"`      // Length of the recording
+	Pauses         int       `gorm:"not null;default:0" json:"pauses"`           // Silences of at least a second within the answer
+	PauseMs        int64     `gorm:"not null;default:0" json:"pause_ms"`         // Their total length
+	LongestPauseMs int64     `gorm:"not null;default:0" json:"longest_pause_ms"` // The longest of them
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Summary job states
+const (
+	SummaryJobPending = "pending" // Waiting for a worker, or for its retry time
+	SummaryJobRunning = "running"
+	SummaryJobFailed  = "failed" // Gave up after the last attempt
+	SummaryJobDone    = "done"
+)
+
+// Stages of generating a summary, recorded on the job as a worker reaches them. A failed job
+// keeps the stage it failed in.
+const (
+	SummaryStageReview       = "review"       // Held for the candidate to correct low-confidence spoken answers
+	SummaryStageQueued       = "queued"       // Waiting for a worker
+	SummaryStageTranscribing = "transcribing" // Gathering the session's transcripts
+	SummaryStagePrompting    = "prompting"    // Waiting for the model's summary
+	SummaryStageParsing      = "parsing"      // Reading the summary and scores out of the response
+	SummaryStageSaving       = "saving"       // Storing the summary and scores
+	SummaryStageDone         = "done"
+)
+
+// SummaryJob is a queued request to generate a session's summary. At most one job per session
+// is pending or running at a time.
+type SummaryJob struct {
+	ID          string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID   string         `gorm:"type:uuid;not null;index;uniqueIndex:idx_summary_job_open,where:status IN ('pending'\\,'running')" json:"session_id"`
+	Status      string         `gorm:"size:20;not null;default:'pending';index;check:status IN ('pending', 'running', 'failed', 'done')" json:"status"`
+	Stage       string         `gorm:"size:20;not null;default:'queued'" json:"stage"`
+	Force       bool           `gorm:"not null;default:false" json:"force"`          // Regenerate over an existing summary
+	BackfillID  *string        `gorm:"type:uuid;index" json:"backfill_id,omitempty"` // Re-scoring backfill that queued the job
+	Attempts    int            `gorm:"not null;default:0" json:"attempts"`
+	LastError   string         `gorm:"type:text" json:"last_error,omitempty"`
+	Transcripts *string        `gorm:"type:jsonb" json:"-"`             // Live transcripts to summarize, kept for retries; the stored transcripts when NULL
+	RunAfter    time.Time      `gorm:"not null;index" json:"run_after"` // Earliest time a worker may pick it up; pushed back between retries
+	StartedAt   *time.Time     `json:"started_at,omitempty"`            // Start of the latest attempt
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
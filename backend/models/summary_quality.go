@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SummaryQuality stores automatic quality checks for a generated interview summary
+type SummaryQuality struct {
+	ID                  string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SummaryID           string         `gorm:"type:uuid;not null;uniqueIndex" json:"summary_id"`
+	SessionID           string         `gorm:"type:uuid;not null;index" json:"session_id"`
+	PromptVersion       string         `gorm:"size:50;not null;index" json:"prompt_version"`
+	Model               string         `gorm:"size:100;not null;index" json:"model"`
+	JSONValid           bool           `gorm:"not null" json:"json_valid"`
+	SummaryLength       int            `gorm:"not null" json:"summary_length"`
+	LengthWithinBounds  bool           `gorm:"not null" json:"length_within_bounds"`
+	SectionCompleteness float64        `gorm:"type:decimal(5,2);not null" json:"section_completeness"` // 0.00 to 1.00
+	MissingSections     string         `gorm:"type:text" json:"missing_sections,omitempty"`            // Comma-separated section names
+	HallucinatedNames   string         `gorm:"type:text" json:"hallucinated_names,omitempty"`          // Comma-separated names not found in the transcript
+	QualityScore        float64        `gorm:"type:decimal(5,2);not null" json:"quality_score"`        // 0.00 to 100.00
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Summary InterviewSummary `gorm:"foreignKey:SummaryID" json:"-"`
+}
+
+// SummaryQualityStats aggregates quality checks for a prompt version and model pair
+type SummaryQualityStats struct {
+	PromptVersion     string     `json:"prompt_version"`
+	Model             string     `json:"model"`
+	SampleCount       int64      `json:"sample_count"`
+	AvgQualityScore   float64    `json:"avg_quality_score"`
+	JSONValidRate     float64    `json:"json_valid_rate"`
+	LengthWithinRate  float64    `json:"length_within_rate"`
+	AvgCompleteness   float64    `json:"avg_section_completeness"`
+	HallucinationRate float64    `json:"hallucination_rate"`
+	FirstSeen         *time.Time `json:"first_seen,omitempty"`
+	LastSeen          *time.Time `json:"last_seen,omitempty"`
+}
+
+// SummaryQualityAlert is a quality regression raised for a prompt version and model. There is
+// one per reason, refreshed while the regression lasts, until an admin clears the alerts.
+type SummaryQualityAlert struct {
+	ID            string               `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"-"`
+	PromptVersion string               `gorm:"size:50;not null;uniqueIndex:idx_summary_quality_alert" json:"prompt_version"`
+	Model         string               `gorm:"size:100;not null;uniqueIndex:idx_summary_quality_alert" json:"model"`
+	Reason        string               `gorm:"size:200;not null;uniqueIndex:idx_summary_quality_alert" json:"reason"`
+	Current       *SummaryQualityStats `gorm:"type:jsonb;serializer:json;not null" json:"current"`
+	Baseline      *SummaryQualityStats `gorm:"type:jsonb;serializer:json" json:"baseline,omitempty"`
+	RaisedAt      time.Time            `gorm:"not null;index" json:"raised_at"`
+	CreatedAt     time.Time            `json:"-"`
+	UpdatedAt     time.Time            `json:"-"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Interview modes
+const (
+	SessionModeVoice = "voice" // Spoken answers, with typing available
+	SessionModeText  = "text"  // Typed answers only, for candidates without a microphone
+)
+
+// TurnMetrics records how the candidate typed one answer, as measured by their browser. Only
+// typed answers have them; they describe the composing, not the content.
+type TurnMetrics struct {
+	ID                string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID         string    `gorm:"type:uuid;not null;index" json:"session_id"`
+	TranscriptID      string    `gorm:"type:uuid;not null;uniqueIndex" json:"transcript_id"` // The answer's user transcript turn
+	ResponseLatencyMs int64     `gorm:"not null;default:0" json:"response_latency_ms"`       // From the interviewer's message appearing to the first keystroke
+	ComposeMs         int64     `gorm:"not null;default:0" json:"compose_ms"`                // From the first keystroke to sending
+	Keystrokes        int       `gorm:"not null;default:0" json:"keystrokes"`
+	Edits             int       `gorm:"not null;default:0" json:"edits"` // Deletions and overwritten selections
+	Pastes            int       `gorm:"not null;default:0" json:"pastes"`
+	PastedChars       int       `gorm:"not null;default:0" json:"pasted_chars"`
+	Characters        int       `gorm:"not null;default:0" json:"characters"` // Length of the answer sent
+	Words             int       `gorm:"not null;default:0" json:"words"`
+	CreatedAt         time.Time `json:"created_at"`
+}
@@ -7,15 +7,33 @@ import (
 )
 
 type User struct {
-	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Email     string         `gorm:"uniqueIndex;not null" json:"email"`
-	Password  string         `gorm:"size:255" json:"-"` // Hashed password (excluded from JSON)
-	FullName  string         `gorm:"size:255" json:"full_name,omitempty"`
-	AvatarURL string         `gorm:"size:500" json:"avatar_url,omitempty"`
-	Role      string         `gorm:"default:'user'" json:"role"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID        string `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Email     string `gorm:"uniqueIndex;not null" json:"email"`
+	Password  string `gorm:"size:255" json:"-"` // Hashed password (excluded from JSON)
+	FullName  string `gorm:"size:255" json:"full_name,omitempty"`
+	AvatarURL string `gorm:"size:500" json:"avatar_url,omitempty"`
+	Role      string `gorm:"default:'user'" json:"role"`
+	// Location-derived defaults, set at signup from the client IP
+	Country         string     `gorm:"size:2" json:"country,omitempty"`             // ISO 3166-1 alpha-2
+	Jurisdiction    string     `gorm:"size:20;index" json:"jurisdiction,omitempty"` // EU, UK, BR, US, IN, OTHER, UNKNOWN
+	Language        string     `gorm:"size:10;default:'en'" json:"language"`
+	ConsentRequired bool       `gorm:"not null;default:false" json:"consent_required"` // Data-processing consent must be collected
+	AgeConfirmedAt  *time.Time `json:"age_confirmed_at,omitempty"`                     // When the user passed the signup age gate
+	ResearchOptInAt *time.Time `json:"research_opt_in_at,omitempty"`                   // Opted in to anonymized research use; NULL means opted out
+	// Display and notification preferences
+	Locale          string `gorm:"size:20" json:"locale,omitempty"`           // BCP 47, e.g. "en-GB"; from language and country when empty
+	Timezone        string `gorm:"size:64" json:"timezone,omitempty"`         // IANA name, e.g. "Europe/Berlin"; UTC when empty
+	QuietHoursStart string `gorm:"size:5" json:"quiet_hours_start,omitempty"` // "22:00"; no quiet hours when empty
+	QuietHoursEnd   string `gorm:"size:5" json:"quiet_hours_end,omitempty"`   // "07:00"; earlier than the start spans midnight
+	FeedbackTone    string `gorm:"size:10" json:"feedback_tone,omitempty"`    // Tone of written feedback; the agent's own when empty
+	// Accessibility accommodations, honored in every interview the user takes
+	ThinkingTimeMultiplier float64        `gorm:"not null;default:1" json:"thinking_time_multiplier"` // Stretches waits on the candidate, 1-3
+	TimeLimitMultiplier    float64        `gorm:"not null;default:1" json:"time_limit_multiplier"`    // Stretches interview time limits, 1-2
+	TextOnly               bool           `gorm:"not null;default:false" json:"text_only"`            // Replies as text, without speech
+	SimplifiedLanguage     bool           `gorm:"not null;default:false" json:"simplified_language"`  // Interviewers use plain, short sentences
+	CreatedAt              time.Time      `json:"created_at"`
+	UpdatedAt              time.Time      `json:"updated_at"`
+	DeletedAt              gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
 	Agents            []Agent            `gorm:"foreignKey:UserID" json:"agents,omitempty"`
@@ -23,27 +41,72 @@ type User struct {
 	RefreshTokens     []RefreshToken     `gorm:"foreignKey:UserID" json:"refresh_tokens,omitempty"`
 }
 
+// UserRoleGuest is the role of a guest account, created for a candidate starting an interview
+// they were invited to without signing up. Guests can only take that interview until they
+// convert the account into a full one.
+const UserRoleGuest = "guest"
+
+// Feedback tones a user can ask summaries to be written in, whatever the agent's personality
+const (
+	FeedbackToneDirect   = "direct"
+	FeedbackToneBalanced = "balanced"
+	FeedbackToneGentle   = "gentle"
+)
+
 type RefreshToken struct {
-	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID    string         `gorm:"type:uuid;not null;index" json:"user_id"`
-	Token     string         `gorm:"uniqueIndex;not null" json:"-"`
-	ExpiresAt time.Time      `gorm:"not null" json:"expires_at"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID           string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	PermanentTokenID *string        `gorm:"type:uuid;index" json:"permanent_token_id,omitempty"` // Device the token was issued to
+	Token            string         `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt        time.Time      `gorm:"not null" json:"expires_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
+// Permanent token scopes
+const (
+	TokenScopeRefresh = "refresh" // May mint new access tokens
+)
+
+// PermanentTokenLifetime is how long a permanent token stays valid after it is issued
+const PermanentTokenLifetime = 30 * 24 * time.Hour
+
+// PermanentToken is a long-lived token bound to the device it was issued to. Each one
+// is listed to the user as a trusted device.
 type PermanentToken struct {
-	ID        string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID    string         `gorm:"type:uuid;not null;index" json:"user_id"`
-	Token     string         `gorm:"uniqueIndex;not null" json:"-"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID            string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID        string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	Token         string         `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes        string         `gorm:"size:100;not null;default:'refresh'" json:"scopes"` // Comma-separated
+	DeviceName    string         `gorm:"size:100" json:"device_name"`                       // e.g. "Chrome on macOS"
+	UserAgent     string         `gorm:"size:500" json:"user_agent"`
+	IPAddress     string         `gorm:"size:45" json:"ip_address"` // IP the token was issued to
+	LastIP        string         `gorm:"size:45" json:"last_ip,omitempty"`
+	LastUserAgent string         `gorm:"size:500" json:"-"` // User agent the token was last used from
+	LastCountry   string         `gorm:"size:2" json:"-"`   // Country the token was last used from, when resolved
+	LastUsedAt    *time.Time     `json:"last_used_at,omitempty"`
+	ExpiresAt     *time.Time     `gorm:"index" json:"expires_at"` // Set from created_at by the migration for tokens issued before expiry tracking
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
+
+// KnownDevice is a device and location a user has authenticated from, used to
+// notify the user when a sign-in comes from somewhere new
+type KnownDevice struct {
+	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      string    `gorm:"type:uuid;not null;uniqueIndex:idx_known_device" json:"user_id"`
+	Fingerprint string    `gorm:"size:100;not null;uniqueIndex:idx_known_device" json:"fingerprint"` // Browser/OS family
+	Country     string    `gorm:"size:2;not null;default:'';uniqueIndex:idx_known_device" json:"country"`
+	DeviceName  string    `gorm:"size:100" json:"device_name"`
+	LastIP      string    `gorm:"size:45" json:"last_ip"`
+	LastSeenAt  time.Time `gorm:"not null" json:"last_seen_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
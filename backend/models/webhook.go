@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session lifecycle events webhooks can subscribe to
+const (
+	WebhookEventSessionStarted   = "session.started"
+	WebhookEventSessionCompleted = "session.completed"
+	WebhookEventSummaryGenerated = "summary.generated"
+)
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending   = "pending" // Waiting for its first attempt or a retry
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // Gave up after the last attempt
+)
+
+// Webhook subscribes an external system, such as an ATS or LMS, to lifecycle events of the
+// sessions held with its owner's agents
+type Webhook struct {
+	ID          string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	AgentID     *string        `gorm:"type:uuid;index" json:"agent_id,omitempty"` // Optional: only this agent's sessions
+	URL         string         `gorm:"size:1000;not null" json:"url"`
+	Secret      string         `gorm:"size:100;not null" json:"-"`       // HMAC key for the X-Praxis-Signature header
+	Events      string         `gorm:"type:text;not null" json:"events"` // Comma-separated event types
+	Description string         `gorm:"size:200" json:"description,omitempty"`
+	Enabled     bool           `gorm:"not null" json:"enabled"` // Deliberately no default; false must persist
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WebhookDelivery is an event sent, or still to be sent, to a webhook. Together they are the
+// webhook's delivery log.
+type WebhookDelivery struct {
+	ID            string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	WebhookID     string     `gorm:"type:uuid;not null;index;uniqueIndex:idx_webhook_delivery_event" json:"webhook_id"`
+	EventID       string     `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_delivery_event" json:"-"` // Internal event, so redelivered events aren't sent twice
+	Event         string     `gorm:"size:50;not null" json:"event"`
+	SessionID     string     `gorm:"type:uuid;index" json:"session_id,omitempty"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"` // The JSON body sent
+	Status        string     `gorm:"size:20;not null;default:'pending';check:status IN ('pending', 'delivered', 'failed')" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index" json:"next_attempt_at"`
+	StatusCode    int        `json:"status_code,omitempty"` // Response to the latest attempt
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetAgentHealthFactors measures an agent over the sessions started within [from, to): how
+// many were completed, how candidates rated them and how good their summaries were
+func (r *GORMRepository) GetAgentHealthFactors(ctx context.Context, agentID string, from, to time.Time) (*models.AgentHealth, error) {
+	logger := logging.FromContext(ctx)
+	sessions := r.db.WithContext(ctx).
+		Model(&models.InterviewSession{}).
+		Where("agent_id = ? AND started_at >= ? AND started_at < ?", agentID, from, to)
+
+	var counts struct {
+		Sessions      int64
+		Completed     int64
+		Ratings       int64
+		AverageRating float64
+	}
+	err := sessions.Session(&gorm.Session{}).
+		Select(`COUNT(*) AS sessions,
+			COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+			COUNT(candidate_rating) AS ratings,
+			COALESCE(AVG(candidate_rating), 0) AS average_rating`).
+		Scan(&counts).Error
+	if err != nil {
+		logger.Error("Failed to count agent sessions", "error", err, "agent_id", agentID)
+		return nil, err
+	}
+
+	health := &models.AgentHealth{
+		AgentID:       agentID,
+		Sessions:      counts.Sessions,
+		Ratings:       counts.Ratings,
+		AverageRating: counts.AverageRating,
+	}
+	if counts.Sessions == 0 {
+		return health, nil
+	}
+	health.CompletionRate = float64(counts.Completed) / float64(counts.Sessions)
+
+	var quality struct {
+		Summaries      int64
+		SummaryQuality float64
+	}
+	err = r.db.WithContext(ctx).
+		Model(&models.SummaryQuality{}).
+		Select("COUNT(*) AS summaries, COALESCE(AVG(quality_score), 0) AS summary_quality").
+		Where("session_id IN (?)", sessions.Session(&gorm.Session{}).Select("id")).
+		Scan(&quality).Error
+	if err != nil {
+		logger.Error("Failed to average agent summary quality", "error", err, "agent_id", agentID)
+		return nil, err
+	}
+	health.Summaries = quality.Summaries
+	health.SummaryQuality = quality.SummaryQuality
+	return health, nil
+}
+
+// SaveAgentHealth stores an agent's latest health, replacing the previous one
+func (r *GORMRepository) SaveAgentHealth(ctx context.Context, health *models.AgentHealth) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "agent_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"sessions", "completion_rate", "ratings", "average_rating", "summaries", "summary_quality", "score", "computed_at", "updated_at"}),
+		}).
+		Create(health).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to save agent health", "error", err, "agent_id", health.AgentID)
+		return err
+	}
+	return nil
+}
+
+// GetAgentHealth lists every scored agent with its ranking factors, best first
+func (r *GORMRepository) GetAgentHealth(ctx context.Context) ([]models.AgentHealth, error) {
+	var health []models.AgentHealth
+	err := r.db.WithContext(ctx).
+		Preload("Agent").
+		Order("score DESC").
+		Find(&health).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get agent health", "error", err)
+		return nil, err
+	}
+	return health, nil
+}
+
+// SetSessionRating records the candidate's 1-5 rating of their interview
+func (r *GORMRepository) SetSessionRating(ctx context.Context, sessionID string, rating int) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.InterviewSession{}).
+		Where("id = ?", sessionID).
+		Update("candidate_rating", rating).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to set session rating", "error", err, "session_id", sessionID)
+		return err
+	}
+	return nil
+}
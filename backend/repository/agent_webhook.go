@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Agent usage webhook operations
+func (r *GORMRepository) GetAgentUsageWebhook(ctx context.Context, agentID string) (*models.AgentUsageWebhook, error) {
+	var webhook models.AgentUsageWebhook
+	err := r.db.WithContext(ctx).Where("agent_id = ?", agentID).First(&webhook).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get agent usage webhook", "error", err, "agent_id", agentID)
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *GORMRepository) SaveAgentUsageWebhook(ctx context.Context, webhook *models.AgentUsageWebhook) error {
+	if err := r.db.WithContext(ctx).Save(webhook).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to save agent usage webhook", "error", err, "agent_id", webhook.AgentID)
+		return err
+	}
+	return nil
+}
+
+// DeleteAgentUsageWebhook removes an agent's webhook, reporting whether it existed
+func (r *GORMRepository) DeleteAgentUsageWebhook(ctx context.Context, agentID string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("agent_id = ?", agentID).Delete(&models.AgentUsageWebhook{})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to delete agent usage webhook", "error", result.Error, "agent_id", agentID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetDueAgentUsageWebhooks returns enabled webhooks whose next digest period has ended
+func (r *GORMRepository) GetDueAgentUsageWebhooks(ctx context.Context, now time.Time, limit int) ([]models.AgentUsageWebhook, error) {
+	var webhooks []models.AgentUsageWebhook
+	err := r.db.WithContext(ctx).
+		Preload("Agent").
+		Where("enabled AND next_digest_at <= ?", now).
+		Order("next_digest_at ASC").
+		Limit(limit).
+		Find(&webhooks).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get due agent usage webhooks", "error", err)
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *GORMRepository) UpdateAgentUsageWebhook(ctx context.Context, webhookID string, updates map[string]interface{}) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.AgentUsageWebhook{}).
+		Where("id = ?", webhookID).
+		Updates(updates).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update agent usage webhook", "error", err, "webhook_id", webhookID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) CreateAgentUsageDigest(ctx context.Context, digest *models.AgentUsageDigest) error {
+	if err := r.db.WithContext(ctx).Create(digest).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create agent usage digest", "error", err, "webhook_id", digest.WebhookID)
+		return err
+	}
+	return nil
+}
+
+// GetAgentUsageDigests returns a webhook's most recent digests, newest first
+func (r *GORMRepository) GetAgentUsageDigests(ctx context.Context, webhookID string, limit int) ([]models.AgentUsageDigest, error) {
+	var digests []models.AgentUsageDigest
+	err := r.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&digests).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get agent usage digests", "error", err, "webhook_id", webhookID)
+		return nil, err
+	}
+	return digests, nil
+}
+
+// GetAgentUsageStats aggregates completed sessions with an agent that ended within [from, to).
+// The owner's own sessions are excluded so the stats reflect other candidates only.
+func (r *GORMRepository) GetAgentUsageStats(ctx context.Context, agentID, ownerID string, from, to time.Time, weakAreas int) (*models.AgentUsageStats, error) {
+	logger := logging.FromContext(ctx)
+	sessions := r.db.WithContext(ctx).
+		Model(&models.InterviewSession{}).
+		Select("id").
+		Where("agent_id = ? AND user_id <> ? AND status = ? AND ended_at >= ? AND ended_at < ?", agentID, ownerID, "completed", from, to)
+
+	var stats models.AgentUsageStats
+	err := r.db.WithContext(ctx).
+		Model(&models.InterviewSession{}).
+		Where("id IN (?)", sessions).
+		Count(&stats.Sessions).Error
+	if err != nil {
+		logger.Error("Failed to count agent sessions", "error", err, "agent_id", agentID)
+		return nil, err
+	}
+	if stats.Sessions == 0 {
+		return &stats, nil
+	}
+
+	err = r.db.WithContext(ctx).
+		Model(&models.InterviewSummary{}).
+		Select("COALESCE(AVG(overall_score), 0)").
+		Where("session_id IN (?)", sessions).
+		Scan(&stats.AverageScore).Error
+	if err != nil {
+		logger.Error("Failed to average agent session scores", "error", err, "agent_id", agentID)
+		return nil, err
+	}
+
+	if weakAreas > 0 {
+		err = r.db.WithContext(ctx).
+			Model(&models.PerformanceScore{}).
+			Select("metric, AVG(score * 100.0 / NULLIF(max_score, 0)) AS average_score").
+			Where("session_id IN (?)", sessions).
+			Group("metric").
+			Order("average_score ASC").
+			Limit(weakAreas).
+			Scan(&stats.WeakAreas).Error
+		if err != nil {
+			logger.Error("Failed to get agent weak areas", "error", err, "agent_id", agentID)
+			return nil, err
+		}
+	}
+
+	return &stats, nil
+}
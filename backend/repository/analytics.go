@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// normalizedScore is a performance score out of 100, whatever its max_score
+const normalizedScore = "score * 100.0 / NULLIF(max_score, 0)"
+
+// analyticsSessions selects the IDs of a user's completed sessions started since a time
+func (r *GORMRepository) analyticsSessions(ctx context.Context, userID string, since time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Model(&models.InterviewSession{}).
+		Select("id").
+		Where("user_id = ? AND status = ? AND started_at >= ?", userID, "completed", since)
+}
+
+// GetUserScoreTrend averages the overall scores of a user's sessions per day, week or month,
+// oldest first. Periods start in the user's IANA timezone; periods without sessions are left out.
+func (r *GORMRepository) GetUserScoreTrend(ctx context.Context, userID string, since time.Time, period, timezone string) ([]models.ScoreTrendPoint, error) {
+	var points []models.ScoreTrendPoint
+	err := r.db.WithContext(ctx).
+		Model(&models.InterviewSummary{}).
+		Select(`to_char(date_trunc(?, interview_sessions.started_at AT TIME ZONE ?), 'YYYY-MM-DD') AS period,
+			COUNT(*) AS sessions,
+			AVG(interview_summaries.overall_score) AS average_score`, period, timezone).
+		Joins("JOIN interview_sessions ON interview_sessions.id = interview_summaries.session_id").
+		Where("interview_summaries.session_id IN (?)", r.analyticsSessions(ctx, userID, since)).
+		Group("period").
+		Order("period").
+		Scan(&points).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get score trend", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return points, nil
+}
+
+// GetUserMetricStats summarizes a user's scores in each performance metric, by metric name
+func (r *GORMRepository) GetUserMetricStats(ctx context.Context, userID string, since time.Time) ([]models.MetricStats, error) {
+	var stats []models.MetricStats
+	if err := r.metricStats(ctx, userID, since).Order("metric").Scan(&stats).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get metric stats", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetUserWeakestMetrics returns the limit metrics a user averages lowest in, weakest first
+func (r *GORMRepository) GetUserWeakestMetrics(ctx context.Context, userID string, since time.Time, limit int) ([]models.MetricStats, error) {
+	var stats []models.MetricStats
+	if err := r.metricStats(ctx, userID, since).Order("average_score ASC, metric").Limit(limit).Scan(&stats).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get weakest metrics", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (r *GORMRepository) metricStats(ctx context.Context, userID string, since time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Model(&models.PerformanceScore{}).
+		Select(fmt.Sprintf(`metric,
+			COUNT(DISTINCT session_id) AS sessions,
+			AVG(%[1]s) AS average_score,
+			MIN(%[1]s) AS min_score,
+			MAX(%[1]s) AS max_score`, normalizedScore)).
+		Where("session_id IN (?)", r.analyticsSessions(ctx, userID, since)).
+		Group("metric")
+}
+
+// GetUserIndustryStats averages the overall scores of a user's sessions per agent industry,
+// most practiced first
+func (r *GORMRepository) GetUserIndustryStats(ctx context.Context, userID string, since time.Time) ([]models.IndustryStats, error) {
+	var stats []models.IndustryStats
+	err := r.db.WithContext(ctx).
+		Model(&models.InterviewSummary{}).
+		Select(`COALESCE(agents.industry, '') AS industry,
+			COUNT(*) AS sessions,
+			AVG(interview_summaries.overall_score) AS average_score`).
+		Joins("JOIN interview_sessions ON interview_sessions.id = interview_summaries.session_id").
+		Joins("JOIN agents ON agents.id = interview_sessions.agent_id").
+		Where("interview_summaries.session_id IN (?)", r.analyticsSessions(ctx, userID, since)).
+		Group("COALESCE(agents.industry, '')").
+		Order("sessions DESC, industry").
+		Scan(&stats).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get industry stats", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetUserPeriodStats counts a user's completed sessions started within [from, to) and averages
+// their overall scores
+func (r *GORMRepository) GetUserPeriodStats(ctx context.Context, userID string, from, to time.Time) (*models.ScoreTrendPoint, error) {
+	var stats models.ScoreTrendPoint
+	err := r.db.WithContext(ctx).
+		Model(&models.InterviewSummary{}).
+		Select("COUNT(*) AS sessions, COALESCE(AVG(interview_summaries.overall_score), 0) AS average_score").
+		Joins("JOIN interview_sessions ON interview_sessions.id = interview_summaries.session_id").
+		Where("interview_summaries.session_id IN (?)", r.analyticsSessions(ctx, userID, from).Where("started_at < ?", to)).
+		Scan(&stats).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get period stats", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// API key operations
+func (r *GORMRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create API key", "error", err, "user_id", key.UserID)
+		return err
+	}
+	return nil
+}
+
+// GetAPIKey returns an unrevoked, unexpired API key by its hash, with its user
+func (r *GORMRepository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("key = ? AND (expires_at IS NULL OR expires_at > ?)", keyHash, time.Now()).
+		First(&key).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get API key", "error", err)
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetUserAPIKeys lists a user's unrevoked keys, expired ones included
+func (r *GORMRepository) GetUserAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get API keys", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *GORMRepository) CountUserAPIKeys(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to count API keys", "error", err, "user_id", userID)
+		return 0, err
+	}
+	return count, nil
+}
+
+// RevokeAPIKey deletes an API key owned by the user, reporting whether it existed
+func (r *GORMRepository) RevokeAPIKey(ctx context.Context, keyID, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", keyID, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to revoke API key", "error", result.Error, "key_id", keyID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GORMRepository) TouchAPIKey(ctx context.Context, keyID string) error {
+	if err := r.db.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", keyID).Update("last_used_at", time.Now()).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to update API key usage", "error", err, "key_id", keyID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetOrgBranding returns the branding of an agent owner, or nil if they haven't set any
+func (r *GORMRepository) GetOrgBranding(ctx context.Context, userID string) (*models.OrgBranding, error) {
+	var branding models.OrgBranding
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&branding).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get branding", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return &branding, nil
+}
+
+// SaveOrgBranding creates or replaces a user's branding
+func (r *GORMRepository) SaveOrgBranding(ctx context.Context, branding *models.OrgBranding) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"logo_key", "logo_content_type", "primary_color", "accent_color", "footer_text", "updated_at"}),
+		}).
+		Create(branding).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to save branding", "error", err, "user_id", branding.UserID)
+		return err
+	}
+	return nil
+}
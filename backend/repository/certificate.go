@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Certificate operations
+
+// CreateCertificate stores a certificate unless the session already has one, reporting whether it was created
+func (r *GORMRepository) CreateCertificate(ctx context.Context, certificate *models.Certificate) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(certificate)
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to create certificate", "error", result.Error, "session_id", certificate.SessionID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GORMRepository) GetCertificateByCode(ctx context.Context, code string) (*models.Certificate, error) {
+	var certificate models.Certificate
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&certificate).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get certificate", "error", err, "code", code)
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+func (r *GORMRepository) GetSessionCertificate(ctx context.Context, sessionID string) (*models.Certificate, error) {
+	var certificate models.Certificate
+	err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).First(&certificate).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get session certificate", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+func (r *GORMRepository) GetUserCertificates(ctx context.Context, userID string) ([]models.Certificate, error) {
+	var certificates []models.Certificate
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("issued_at DESC").
+		Find(&certificates).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get user certificates", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return certificates, nil
+}
+
+func (r *GORMRepository) RevokeCertificate(ctx context.Context, certificateID string, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.Certificate{}).
+		Where("id = ? AND revoked_at IS NULL", certificateID).
+		Update("revoked_at", at).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to revoke certificate", "error", err, "certificate_id", certificateID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// RecordClientError stores a reported client error, or counts it on the row of an earlier
+// report of the same error by the same user in the same session
+func (r *GORMRepository) RecordClientError(ctx context.Context, report *models.ClientError) error {
+	logger := logging.FromContext(ctx)
+	query := r.db.WithContext(ctx).
+		Model(&models.ClientError{}).
+		Where("user_id = ? AND kind = ? AND message = ?", report.UserID, report.Kind, report.Message)
+	if report.SessionID != nil {
+		query = query.Where("session_id = ?", *report.SessionID)
+	} else {
+		query = query.Where("session_id IS NULL")
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"count":        gorm.Expr("count + 1"),
+		"context":      report.Context,
+		"source":       report.Source,
+		"user_agent":   report.UserAgent,
+		"last_seen_at": report.LastSeenAt,
+	})
+	if result.Error != nil {
+		logger.Error("Failed to update client error", "error", result.Error, "user_id", report.UserID)
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).Create(report).Error; err != nil {
+		logger.Error("Failed to create client error", "error", err, "user_id", report.UserID)
+		return err
+	}
+	return nil
+}
+
+// GetClientErrorStats totals client errors seen since a time by session and kind (all kinds
+// when empty), busiest first
+func (r *GORMRepository) GetClientErrorStats(ctx context.Context, since time.Time, kind string, limit int) ([]models.ClientErrorStats, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.ClientError{}).
+		Select(`session_id, user_id, kind,
+			SUM(count) AS count,
+			COUNT(*) AS messages,
+			MIN(first_seen_at) AS first_seen,
+			MAX(last_seen_at) AS last_seen`).
+		Where("last_seen_at >= ?", since).
+		Group("session_id, user_id, kind").
+		Order("count DESC, last_seen DESC").
+		Limit(limit)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var stats []models.ClientErrorStats
+	if err := query.Scan(&stats).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get client error stats", "error", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetSessionClientErrors returns the errors reported during a session, most recent first
+func (r *GORMRepository) GetSessionClientErrors(ctx context.Context, sessionID string) ([]models.ClientError, error) {
+	var reports []models.ClientError
+	if err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).Order("last_seen_at DESC").Find(&reports).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get session client errors", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return reports, nil
+}
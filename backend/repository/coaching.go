@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+func (r *GORMRepository) CreateCoachingNote(ctx context.Context, note *models.CoachingNote) error {
+	if err := r.db.WithContext(ctx).Create(note).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create coaching note", "error", err, "session_id", note.SessionID)
+		return err
+	}
+	return nil
+}
+
+// GetSessionCoachingNotes returns the coaching notes of a session, in the order they were written
+func (r *GORMRepository) GetSessionCoachingNotes(ctx context.Context, sessionID string) ([]models.CoachingNote, error) {
+	var notes []models.CoachingNote
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("created_at").
+		Find(&notes).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get coaching notes", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return notes, nil
+}
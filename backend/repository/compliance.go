@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Banned topic operations
+func (r *GORMRepository) CreateBannedTopic(ctx context.Context, topic *models.BannedTopic) error {
+	if err := r.db.WithContext(ctx).Create(topic).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create banned topic", "error", err, "topic", topic.Topic)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetBannedTopic(ctx context.Context, topicID string) (*models.BannedTopic, error) {
+	var topic models.BannedTopic
+	err := r.db.WithContext(ctx).Where("id = ?", topicID).First(&topic).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get banned topic", "error", err, "topic_id", topicID)
+		return nil, err
+	}
+	return &topic, nil
+}
+
+// GetBannedTopics returns the topics a user banned, or the site-wide topics when ownerID is nil
+func (r *GORMRepository) GetBannedTopics(ctx context.Context, ownerID *string) ([]models.BannedTopic, error) {
+	query := r.db.WithContext(ctx).Order("created_at")
+	if ownerID == nil {
+		query = query.Where("owner_id IS NULL")
+	} else {
+		query = query.Where("owner_id = ?", *ownerID)
+	}
+
+	var topics []models.BannedTopic
+	if err := query.Find(&topics).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get banned topics", "error", err)
+		return nil, err
+	}
+	return topics, nil
+}
+
+// GetAgentBannedTopics returns the topics banned for an agent: the site-wide ones and its
+// owner's. ownerID is nil for public agents, which only have the site-wide ones.
+func (r *GORMRepository) GetAgentBannedTopics(ctx context.Context, ownerID *string) ([]models.BannedTopic, error) {
+	query := r.db.WithContext(ctx).Order("created_at")
+	if ownerID == nil {
+		query = query.Where("owner_id IS NULL")
+	} else {
+		query = query.Where("owner_id IS NULL OR owner_id = ?", *ownerID)
+	}
+
+	var topics []models.BannedTopic
+	if err := query.Find(&topics).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get agent banned topics", "error", err)
+		return nil, err
+	}
+	return topics, nil
+}
+
+func (r *GORMRepository) UpdateBannedTopic(ctx context.Context, topic *models.BannedTopic) error {
+	if err := r.db.WithContext(ctx).Save(topic).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to update banned topic", "error", err, "topic_id", topic.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) DeleteBannedTopic(ctx context.Context, topicID string) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", topicID).Delete(&models.BannedTopic{}).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to delete banned topic", "error", err, "topic_id", topicID)
+		return err
+	}
+	return nil
+}
+
+// Compliance violation operations
+func (r *GORMRepository) CreateComplianceViolation(ctx context.Context, violation *models.ComplianceViolation) error {
+	if err := r.db.WithContext(ctx).Create(violation).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create compliance violation", "error", err, "session_id", violation.SessionID)
+		return err
+	}
+	return nil
+}
+
+// GetComplianceViolations returns the latest violations by a user's agents, or by every agent
+// when ownerID is nil
+func (r *GORMRepository) GetComplianceViolations(ctx context.Context, ownerID *string, limit int) ([]models.ComplianceViolation, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC").Limit(limit)
+	if ownerID != nil {
+		query = query.Where("owner_id = ?", *ownerID)
+	}
+
+	var violations []models.ComplianceViolation
+	if err := query.Find(&violations).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get compliance violations", "error", err)
+		return nil, err
+	}
+	return violations, nil
+}
@@ -3,8 +3,8 @@ package repository
 import (
 	"context"
 	"fmt"
-	"log/slog"
 
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"gorm.io/gorm"
 )
@@ -21,17 +21,19 @@ func NewConversationRepository(db *gorm.DB) *ConversationRepository {
 
 // SaveMessage saves a message to the database using GORM
 func (r *ConversationRepository) SaveMessage(ctx context.Context, message *models.Message) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).Create(message).Error; err != nil {
-		slog.Error("Failed to save message", "error", err, "message_id", message.ID)
+		logger.Error("Failed to save message", "error", err, "message_id", message.ID)
 		return fmt.Errorf("failed to save message: %w", err)
 	}
 
-	slog.Info("Message saved", "message_id", message.ID, "user_id", message.UserID)
+	logger.Info("Message saved", "message_id", message.ID, "user_id", message.UserID)
 	return nil
 }
 
 // GetConversationHistory retrieves conversation history using GORM
 func (r *ConversationRepository) GetConversationHistory(ctx context.Context, userID, sessionID string, limit int) ([]models.Message, error) {
+	logger := logging.FromContext(ctx)
 	var messages []models.Message
 
 	query := r.db.WithContext(ctx).
@@ -40,16 +42,17 @@ func (r *ConversationRepository) GetConversationHistory(ctx context.Context, use
 		Limit(limit)
 
 	if err := query.Find(&messages).Error; err != nil {
-		slog.Error("Failed to get conversation history", "error", err, "user_id", userID, "session_id", sessionID)
+		logger.Error("Failed to get conversation history", "error", err, "user_id", userID, "session_id", sessionID)
 		return nil, fmt.Errorf("failed to get conversation history: %w", err)
 	}
 
-	slog.Info("Conversation history retrieved", "user_id", userID, "session_id", sessionID, "count", len(messages))
+	logger.Info("Conversation history retrieved", "user_id", userID, "session_id", sessionID, "count", len(messages))
 	return messages, nil
 }
 
 // GetUserStats returns conversation statistics for a user using GORM
 func (r *ConversationRepository) GetUserStats(ctx context.Context, userID string) (map[string]interface{}, error) {
+	logger := logging.FromContext(ctx)
 	var stats models.UserStats
 
 	// Get total messages count
@@ -57,7 +60,7 @@ func (r *ConversationRepository) GetUserStats(ctx context.Context, userID string
 		Model(&models.Message{}).
 		Where("user_id = ?", userID).
 		Count(&stats.TotalMessages).Error; err != nil {
-		slog.Error("Failed to get total messages count", "error", err, "user_id", userID)
+		logger.Error("Failed to get total messages count", "error", err, "user_id", userID)
 		return nil, fmt.Errorf("failed to get total messages count: %w", err)
 	}
 
@@ -67,7 +70,7 @@ func (r *ConversationRepository) GetUserStats(ctx context.Context, userID string
 		Where("user_id = ?", userID).
 		Distinct("session_id").
 		Count(&stats.TotalSessions).Error; err != nil {
-		slog.Error("Failed to get total sessions count", "error", err, "user_id", userID)
+		logger.Error("Failed to get total sessions count", "error", err, "user_id", userID)
 		return nil, fmt.Errorf("failed to get total sessions count: %w", err)
 	}
 
@@ -76,7 +79,7 @@ func (r *ConversationRepository) GetUserStats(ctx context.Context, userID string
 		Model(&models.Message{}).
 		Where("user_id = ? AND message_type = ?", userID, "code").
 		Count(&stats.CodeMessages).Error; err != nil {
-		slog.Error("Failed to get code messages count", "error", err, "user_id", userID)
+		logger.Error("Failed to get code messages count", "error", err, "user_id", userID)
 		return nil, fmt.Errorf("failed to get code messages count: %w", err)
 	}
 
@@ -87,7 +90,7 @@ func (r *ConversationRepository) GetUserStats(ctx context.Context, userID string
 		Order("created_at DESC").
 		First(&lastMessage).Error; err != nil {
 		if err != gorm.ErrRecordNotFound {
-			slog.Error("Failed to get last activity", "error", err, "user_id", userID)
+			logger.Error("Failed to get last activity", "error", err, "user_id", userID)
 			return nil, fmt.Errorf("failed to get last activity: %w", err)
 		}
 		// No messages found, last activity is nil
@@ -95,7 +98,7 @@ func (r *ConversationRepository) GetUserStats(ctx context.Context, userID string
 		stats.LastActivity = &lastMessage.CreatedAt
 	}
 
-	slog.Info("User stats retrieved", "user_id", userID, "total_messages", stats.TotalMessages)
+	logger.Info("User stats retrieved", "user_id", userID, "total_messages", stats.TotalMessages)
 
 	return map[string]interface{}{
 		"total_messages": stats.TotalMessages,
@@ -107,14 +110,15 @@ func (r *ConversationRepository) GetUserStats(ctx context.Context, userID string
 
 // DeleteUserMessages deletes all messages for a user using GORM
 func (r *ConversationRepository) DeleteUserMessages(ctx context.Context, userID string) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).
 		Where("user_id = ?", userID).
 		Delete(&models.Message{}).Error; err != nil {
-		slog.Error("Failed to delete user messages", "error", err, "user_id", userID)
+		logger.Error("Failed to delete user messages", "error", err, "user_id", userID)
 		return fmt.Errorf("failed to delete user messages: %w", err)
 	}
 
-	slog.Info("User messages deleted", "user_id", userID)
+	logger.Info("User messages deleted", "user_id", userID)
 	return nil
 }
 
@@ -126,7 +130,7 @@ func (r *ConversationRepository) GetMessagesBySession(ctx context.Context, sessi
 		Where("session_id = ?", sessionID).
 		Order("created_at ASC").
 		Find(&messages).Error; err != nil {
-		slog.Error("Failed to get messages by session", "error", err, "session_id", sessionID)
+		logging.FromContext(ctx).Error("Failed to get messages by session", "error", err, "session_id", sessionID)
 		return nil, fmt.Errorf("failed to get messages by session: %w", err)
 	}
 
@@ -143,7 +147,7 @@ func (r *ConversationRepository) GetMessageByID(ctx context.Context, messageID s
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("message not found: %s", messageID)
 		}
-		slog.Error("Failed to get message by ID", "error", err, "message_id", messageID)
+		logging.FromContext(ctx).Error("Failed to get message by ID", "error", err, "message_id", messageID)
 		return nil, fmt.Errorf("failed to get message by ID: %w", err)
 	}
 
@@ -152,26 +156,28 @@ func (r *ConversationRepository) GetMessageByID(ctx context.Context, messageID s
 
 // UpdateMessage updates an existing message
 func (r *ConversationRepository) UpdateMessage(ctx context.Context, message *models.Message) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).
 		Save(message).Error; err != nil {
-		slog.Error("Failed to update message", "error", err, "message_id", message.ID)
+		logger.Error("Failed to update message", "error", err, "message_id", message.ID)
 		return fmt.Errorf("failed to update message: %w", err)
 	}
 
-	slog.Info("Message updated successfully", "message_id", message.ID)
+	logger.Info("Message updated successfully", "message_id", message.ID)
 	return nil
 }
 
 // DeleteMessage deletes a specific message
 func (r *ConversationRepository) DeleteMessage(ctx context.Context, messageID string) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).
 		Where("id = ?", messageID).
 		Delete(&models.Message{}).Error; err != nil {
-		slog.Error("Failed to delete message", "error", err, "message_id", messageID)
+		logger.Error("Failed to delete message", "error", err, "message_id", messageID)
 		return fmt.Errorf("failed to delete message: %w", err)
 	}
 
-	slog.Info("Message deleted successfully", "message_id", messageID)
+	logger.Info("Message deleted successfully", "message_id", messageID)
 	return nil
 }
 
@@ -184,7 +190,7 @@ func (r *ConversationRepository) GetRecentMessages(ctx context.Context, userID s
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&messages).Error; err != nil {
-		slog.Error("Failed to get recent messages", "error", err, "user_id", userID)
+		logging.FromContext(ctx).Error("Failed to get recent messages", "error", err, "user_id", userID)
 		return nil, fmt.Errorf("failed to get recent messages: %w", err)
 	}
 
@@ -201,7 +207,7 @@ func (r *ConversationRepository) GetMessagesByType(ctx context.Context, userID,
 		Limit(limit)
 
 	if err := query.Find(&messages).Error; err != nil {
-		slog.Error("Failed to get messages by type", "error", err, "user_id", userID, "message_type", messageType)
+		logging.FromContext(ctx).Error("Failed to get messages by type", "error", err, "user_id", userID, "message_type", messageType)
 		return nil, fmt.Errorf("failed to get messages by type: %w", err)
 	}
 
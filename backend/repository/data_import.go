@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateDataImport stores an import with its sessions, their summaries and performance scores.
+// Each session's Agent describes the interviewer it was held with on the source platform; a
+// matching archived, inactive agent of the user's is reused or created for it, so imported
+// sessions can't be resumed or repeated. Sessions whose external ID the user already imported
+// are skipped and counted.
+func (r *GORMRepository) CreateDataImport(ctx context.Context, dataImport *models.DataImport, sessions []models.InterviewSession) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var externalIDs []string
+		for _, session := range sessions {
+			if session.ExternalID != "" {
+				externalIDs = append(externalIDs, session.ExternalID)
+			}
+		}
+		imported := map[string]bool{}
+		if len(externalIDs) > 0 {
+			var existing []string
+			err := tx.Model(&models.InterviewSession{}).
+				Where("user_id = ? AND import_id IS NOT NULL AND external_id IN ?", dataImport.UserID, externalIDs).
+				Pluck("external_id", &existing).Error
+			if err != nil {
+				return err
+			}
+			for _, id := range existing {
+				imported[id] = true
+			}
+		}
+
+		dataImport.Sessions, dataImport.Skipped = 0, 0
+		for _, session := range sessions {
+			if imported[session.ExternalID] {
+				dataImport.Skipped++
+			} else {
+				dataImport.Sessions++
+			}
+		}
+		if err := tx.Create(dataImport).Error; err != nil {
+			return err
+		}
+
+		agents := map[[3]string]string{}
+		for _, session := range sessions {
+			if imported[session.ExternalID] {
+				continue
+			}
+
+			key := [3]string{session.Agent.Name, session.Agent.Industry, session.Agent.Level}
+			if agents[key] == "" {
+				agentID, err := importedAgent(tx, dataImport.UserID, session.Agent)
+				if err != nil {
+					return err
+				}
+				agents[key] = agentID
+			}
+
+			session.UserID = dataImport.UserID
+			session.AgentID = agents[key]
+			session.ImportID = &dataImport.ID
+			if err := tx.Omit(clause.Associations).Create(&session).Error; err != nil {
+				return err
+			}
+			if session.Summary != nil {
+				session.Summary.SessionID = session.ID
+				if err := tx.Omit(clause.Associations).Create(session.Summary).Error; err != nil {
+					return err
+				}
+			}
+			for i := range session.PerformanceScores {
+				session.PerformanceScores[i].SessionID = session.ID
+			}
+			if len(session.PerformanceScores) > 0 {
+				if err := tx.Omit(clause.Associations).Create(&session.PerformanceScores).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create data import", "error", err, "user_id", dataImport.UserID)
+		return err
+	}
+	return nil
+}
+
+// importedAgent returns the ID of the user's archived agent standing in for an interviewer on
+// another platform, creating it if there isn't one yet
+func importedAgent(tx *gorm.DB, userID string, interviewer models.Agent) (string, error) {
+	var agent models.Agent
+	err := tx.Where("user_id = ? AND is_archived = ? AND name = ? AND industry = ? AND level = ?",
+		userID, true, interviewer.Name, interviewer.Industry, interviewer.Level).
+		First(&agent).Error
+	if err == nil {
+		return agent.ID, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return "", err
+	}
+
+	agent = interviewer
+	agent.UserID = &userID
+	agent.IsPublic = false
+	agent.IsArchived = true
+	if err := tx.Omit(clause.Associations).Create(&agent).Error; err != nil {
+		return "", err
+	}
+	// A false is_active is left out of the insert in favour of the column default
+	if err := tx.Model(&models.Agent{}).Where("id = ?", agent.ID).Update("is_active", false).Error; err != nil {
+		return "", err
+	}
+	return agent.ID, nil
+}
+
+// GetDataImports returns a user's imports, newest first
+func (r *GORMRepository) GetDataImports(ctx context.Context, userID string) ([]models.DataImport, error) {
+	var imports []models.DataImport
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&imports).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get data imports", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return imports, nil
+}
+
+// GetDataImport returns one of a user's imports, or nil if they have none with the ID
+func (r *GORMRepository) GetDataImport(ctx context.Context, importID, userID string) (*models.DataImport, error) {
+	var dataImport models.DataImport
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", importID, userID).First(&dataImport).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get data import", "error", err, "import_id", importID)
+		return nil, err
+	}
+	return &dataImport, nil
+}
+
+// DeleteDataImport deletes an import along with the sessions it created, returning how many
+// sessions were removed
+func (r *GORMRepository) DeleteDataImport(ctx context.Context, importID string) (int, error) {
+	logger := logging.FromContext(ctx)
+	var deleted int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sessions := tx.Model(&models.InterviewSession{}).Select("id").Where("import_id = ?", importID)
+		if err := tx.Where("session_id IN (?)", sessions).Delete(&models.PerformanceScore{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("session_id IN (?)", sessions).Delete(&models.InterviewSummary{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("import_id = ?", importID).Delete(&models.InterviewSession{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = int(result.RowsAffected)
+		return tx.Where("id = ?", importID).Delete(&models.DataImport{}).Error
+	})
+	if err != nil {
+		logger.Error("Failed to delete data import", "error", err, "import_id", importID)
+		return 0, err
+	}
+	logger.Info("Data import deleted", "import_id", importID, "sessions", deleted)
+	return deleted, nil
+}
@@ -0,0 +1,450 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// sessionDataTables hold what was recorded in a session, deleted for good along with it
+var sessionDataTables = []interface{}{
+	&models.InterviewTranscript{},
+	&models.InterviewSummary{},
+	&models.SummaryVersion{},
+	&models.PerformanceScore{},
+	&models.TurnMetrics{},
+	&models.SpeechMetrics{},
+	&models.CoachingNote{},
+	&models.Explanation{},
+	&models.AudioRecording{},
+	&models.Certificate{},
+	&models.ClientError{},
+	&models.ComplianceViolation{},
+	&models.Event{},
+	&models.Message{},
+	&models.ProctorEvent{},
+	&models.SessionClientInfo{},
+	&models.SessionFlag{},
+	&models.SessionToken{},
+	&models.SessionNote{},
+	&models.ShadowSummary{},
+	&models.SummaryJob{},
+	&models.SummaryQuality{},
+	&models.WebhookDelivery{},
+}
+
+// userDataTables hold a user's own rows, found by user_id
+var userDataTables = []interface{}{
+	&models.RefreshToken{},
+	&models.PermanentToken{},
+	&models.KnownDevice{},
+	&models.APIKey{},
+	&models.SessionToken{},
+	&models.SessionClientInfo{},
+	&models.Certificate{},
+	&models.ClientError{},
+	&models.Message{},
+	&models.Notification{},
+	&models.NotificationSettings{},
+	&models.LegalAcceptance{},
+	&models.DataImport{},
+	&models.ExportJob{},
+	&models.OrgBranding{},
+}
+
+// ownerDataTables hold the settings a user made for the sessions held with their agents, found
+// by owner_id
+var ownerDataTables = []interface{}{
+	&models.BannedTopic{},
+	&models.ComplianceViolation{},
+	&models.ScoringPolicy{},
+}
+
+// deleteSessionData hard-deletes the sessions selected by a subquery of their IDs and everything
+// recorded in them, returning how many sessions were deleted. The audio of their recordings
+// must already be deleted.
+func deleteSessionData(tx *gorm.DB, sessions *gorm.DB) (int64, error) {
+	for _, table := range sessionDataTables {
+		if err := tx.Unscoped().Where("session_id IN (?)", sessions).Delete(table).Error; err != nil {
+			return 0, err
+		}
+	}
+	err := tx.Unscoped().Where("session_id IN (?) OR source_session_id IN (?)", sessions, sessions).Delete(&models.DrillPlan{}).Error
+	if err != nil {
+		return 0, err
+	}
+	result := tx.Unscoped().Where("id IN (?)", sessions).Delete(&models.InterviewSession{})
+	return result.RowsAffected, result.Error
+}
+
+// deleteQuestionBanks hard-deletes the question banks selected by a subquery of their IDs with
+// their questions, and unlinks them from any agents using them
+func deleteQuestionBanks(tx *gorm.DB, banks *gorm.DB) error {
+	if err := tx.Unscoped().Model(&models.Agent{}).Where("question_bank_id IN (?)", banks).Update("question_bank_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("bank_id IN (?)", banks).Delete(&models.Question{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN (?)", banks).Delete(&models.QuestionBank{}).Error
+}
+
+// deleteOrganizations hard-deletes the organizations selected by a subquery of their IDs with
+// their memberships, invites and interview invites; their agents and question banks stay with
+// their owners
+func deleteOrganizations(tx *gorm.DB, orgs *gorm.DB) error {
+	for _, table := range []interface{}{&models.Agent{}, &models.QuestionBank{}} {
+		if err := tx.Unscoped().Model(table).Where("organization_id IN (?)", orgs).Update("organization_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("organization_id IN (?)", orgs).Delete(&models.OrgMembership{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("organization_id IN (?)", orgs).Delete(&models.OrgInvite{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("organization_id IN (?)", orgs).Delete(&models.InterviewInvite{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN (?)", orgs).Delete(&models.Organization{}).Error
+}
+
+// deleteUserAccount hard-deletes a user and their data. Agents other candidates have practiced
+// with are kept for those candidates' history, anonymized: ownerless, private and archived.
+// Impersonation audit entries naming the user are kept as a security record.
+func deleteUserAccount(tx *gorm.DB, userID string) error {
+	sessions := tx.Unscoped().Model(&models.InterviewSession{}).Select("id").Where("user_id = ?", userID)
+	if _, err := deleteSessionData(tx, sessions); err != nil {
+		return err
+	}
+
+	var agentIDs []string
+	if err := tx.Unscoped().Model(&models.Agent{}).Where("user_id = ?", userID).Pluck("id", &agentIDs).Error; err != nil {
+		return err
+	}
+	for _, agentID := range agentIDs {
+		// The user's own sessions are gone, so any left are other candidates'
+		var used int64
+		if err := tx.Unscoped().Model(&models.InterviewSession{}).Where("agent_id = ?", agentID).Count(&used).Error; err != nil {
+			return err
+		}
+		if used == 0 {
+			if err := deleteAgent(tx, agentID); err != nil {
+				return err
+			}
+			continue
+		}
+		err := tx.Unscoped().Model(&models.Agent{}).Where("id = ?", agentID).Updates(map[string]interface{}{
+			"user_id":           nil,
+			"organization_id":   nil,
+			"question_bank_id":  nil,
+			"scoring_policy_id": nil,
+			"is_public":         false,
+			"is_active":         false,
+			"is_archived":       true,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	webhooks := tx.Unscoped().Model(&models.AgentUsageWebhook{}).Select("id").Where("owner_id = ?", userID)
+	if err := tx.Unscoped().Where("webhook_id IN (?)", webhooks).Delete(&models.AgentUsageDigest{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("owner_id = ?", userID).Delete(&models.AgentUsageWebhook{}).Error; err != nil {
+		return err
+	}
+	for _, table := range ownerDataTables {
+		if err := tx.Unscoped().Where("owner_id = ?", userID).Delete(table).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, table := range userDataTables {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(table).Error; err != nil {
+			return err
+		}
+	}
+	documents := tx.Unscoped().Model(&models.Document{}).Select("id").Where("user_id = ?", userID)
+	if err := tx.Where("document_id IN (?)", documents).Delete(&models.DocumentSection{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Document{}).Error; err != nil {
+		return err
+	}
+	hooks := tx.Unscoped().Model(&models.Webhook{}).Select("id").Where("user_id = ?", userID)
+	if err := tx.Where("webhook_id IN (?)", hooks).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Webhook{}).Error; err != nil {
+		return err
+	}
+	banks := tx.Unscoped().Model(&models.QuestionBank{}).Select("id").Where("user_id = ?", userID)
+	if err := deleteQuestionBanks(tx, banks); err != nil {
+		return err
+	}
+
+	// Organizations the user leaves without members go with them
+	var orgIDs []string
+	if err := tx.Model(&models.OrgMembership{}).Where("user_id = ?", userID).Pluck("organization_id", &orgIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.OrgMembership{}).Error; err != nil {
+		return err
+	}
+	if len(orgIDs) > 0 {
+		empty := tx.Unscoped().Model(&models.Organization{}).Select("id").
+			Where("id IN ? AND NOT EXISTS (SELECT 1 FROM org_memberships WHERE org_memberships.organization_id = organizations.id)", orgIDs)
+		if err := deleteOrganizations(tx, empty); err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Where("id = ?", userID).Delete(&models.User{}).Error
+}
+
+// DeleteUserAccount permanently deletes a user's account: their sessions and everything recorded
+// in them, their agents, documents, question banks, tokens, keys, webhooks and settings. The
+// audio of their recordings and their other stored files must already be deleted.
+func (r *GORMRepository) DeleteUserAccount(ctx context.Context, userID string) error {
+	logger := logging.FromContext(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteUserAccount(tx, userID)
+	})
+	if err != nil {
+		logger.Error("Failed to delete user account", "error", err, "user_id", userID)
+		return err
+	}
+	logger.Info("User account deleted", "user_id", userID)
+	return nil
+}
+
+// GetUserAudioRecordings returns the recordings of all of a user's sessions, deleted ones included
+func (r *GORMRepository) GetUserAudioRecordings(ctx context.Context, userID string) ([]models.AudioRecording, error) {
+	var recordings []models.AudioRecording
+	sessions := r.db.Unscoped().Model(&models.InterviewSession{}).Select("id").Where("user_id = ?", userID)
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("session_id IN (?)", sessions).
+		Find(&recordings).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get user audio recordings", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return recordings, nil
+}
+
+// GetUserExportBlobKeys returns where a user's export archives are stored
+func (r *GORMRepository) GetUserExportBlobKeys(ctx context.Context, userID string) ([]string, error) {
+	var keys []string
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.ExportJob{}).
+		Where("user_id = ? AND blob_key <> ''", userID).
+		Pluck("blob_key", &keys).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get user export blob keys", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetPurgeableAudioRecordings returns recordings deleted before the cutoff, or of sessions
+// deleted before it
+func (r *GORMRepository) GetPurgeableAudioRecordings(ctx context.Context, cutoff time.Time, limit int) ([]models.AudioRecording, error) {
+	var recordings []models.AudioRecording
+	sessions := r.db.Unscoped().Model(&models.InterviewSession{}).Select("id").Where("deleted_at < ?", cutoff)
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at < ? OR session_id IN (?)", cutoff, sessions).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&recordings).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get purgeable audio recordings", "error", err)
+		return nil, err
+	}
+	return recordings, nil
+}
+
+// PurgeAudioRecordings hard-deletes recordings whose audio has been deleted
+func (r *GORMRepository) PurgeAudioRecordings(ctx context.Context, recordingIDs []string) error {
+	if len(recordingIDs) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Unscoped().Where("id IN ?", recordingIDs).Delete(&models.AudioRecording{}).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to purge audio recordings", "error", err, "count", len(recordingIDs))
+		return err
+	}
+	return nil
+}
+
+// sessionsStartedBefore selects sessions, deleted ones included, started before the cutoff that
+// have ended
+func (r *GORMRepository) sessionsStartedBefore(ctx context.Context, cutoff time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.InterviewSession{}).
+		Where("started_at < ? AND status <> ?", cutoff, "active")
+}
+
+// CountSessionsStartedBefore counts the sessions PurgeSessions would remove for the cutoff
+func (r *GORMRepository) CountSessionsStartedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var count int64
+	if err := r.sessionsStartedBefore(ctx, cutoff).Count(&count).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to count sessions started before cutoff", "error", err, "cutoff", cutoff)
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetSessionIDsStartedBefore returns ended sessions, deleted ones included, started before the
+// cutoff, oldest first
+func (r *GORMRepository) GetSessionIDsStartedBefore(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	var sessionIDs []string
+	err := r.sessionsStartedBefore(ctx, cutoff).
+		Order("started_at ASC").
+		Limit(limit).
+		Pluck("id", &sessionIDs).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get sessions started before cutoff", "error", err, "cutoff", cutoff)
+		return nil, err
+	}
+	return sessionIDs, nil
+}
+
+// GetSessionsAudioRecordings returns the recordings of sessions, deleted ones included
+func (r *GORMRepository) GetSessionsAudioRecordings(ctx context.Context, sessionIDs []string) ([]models.AudioRecording, error) {
+	var recordings []models.AudioRecording
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("session_id IN ?", sessionIDs).
+		Find(&recordings).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get sessions audio recordings", "error", err, "count", len(sessionIDs))
+		return nil, err
+	}
+	return recordings, nil
+}
+
+// PurgeSessions permanently removes sessions and everything recorded in them. The audio of
+// their recordings must already be deleted.
+func (r *GORMRepository) PurgeSessions(ctx context.Context, sessionIDs []string) (int64, error) {
+	if len(sessionIDs) == 0 {
+		return 0, nil
+	}
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = deleteSessionData(tx, tx.Unscoped().Model(&models.InterviewSession{}).Select("id").Where("id IN ?", sessionIDs))
+		return err
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to purge sessions", "error", err, "count", len(sessionIDs))
+		return 0, err
+	}
+	return purged, nil
+}
+
+// GetPurgeableUserIDs returns users deleted before the cutoff
+func (r *GORMRepository) GetPurgeableUserIDs(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	var userIDs []string
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).
+		Where("deleted_at < ?", cutoff).
+		Limit(limit).
+		Pluck("id", &userIDs).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get purgeable users", "error", err)
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// PurgeSoftDeleted permanently removes rows soft-deleted before the cutoff, returning how many
+// were removed. Sessions go with everything recorded in them, once their recordings are gone;
+// agents stay while sessions still use them. Users are left to DeleteUserAccount.
+func (r *GORMRepository) PurgeSoftDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deleted := func(table interface{}) *gorm.DB {
+			return tx.Unscoped().Model(table).Select("id").Where("deleted_at < ?", cutoff)
+		}
+
+		sessions := deleted(&models.InterviewSession{}).
+			Where("NOT EXISTS (SELECT 1 FROM audio_recordings WHERE audio_recordings.session_id = interview_sessions.id)")
+		count, err := deleteSessionData(tx, sessions)
+		if err != nil {
+			return err
+		}
+		purged += count
+
+		experiments := deleted(&models.ScoringExperiment{})
+		if err := tx.Unscoped().Where("experiment_id IN (?)", experiments).Delete(&models.ShadowSummary{}).Error; err != nil {
+			return err
+		}
+		documents := deleted(&models.Document{})
+		if err := tx.Where("document_id IN (?)", documents).Delete(&models.DocumentSection{}).Error; err != nil {
+			return err
+		}
+		webhooks := deleted(&models.Webhook{})
+		if err := tx.Where("webhook_id IN (?)", webhooks).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := deleteQuestionBanks(tx, deleted(&models.QuestionBank{})); err != nil {
+			return err
+		}
+		if err := deleteOrganizations(tx, deleted(&models.Organization{})); err != nil {
+			return err
+		}
+
+		// Export jobs keep their archive until it expires
+		if err := tx.Unscoped().Where("deleted_at < ? AND status <> ?", cutoff, models.ExportJobDone).Delete(&models.ExportJob{}).Error; err != nil {
+			return err
+		}
+		for _, table := range []interface{}{
+			&models.InterviewTranscript{},
+			&models.InterviewSummary{},
+			&models.PerformanceScore{},
+			&models.Message{},
+			&models.SessionToken{},
+			&models.SessionNote{},
+			&models.SummaryJob{},
+			&models.SummaryQuality{},
+			&models.ShadowSummary{},
+			&models.ScoringExperiment{},
+			&models.Document{},
+			&models.Webhook{},
+			&models.Question{},
+			&models.RefreshToken{},
+			&models.PermanentToken{},
+			&models.APIKey{},
+			&models.BannedTopic{},
+			&models.ScoringPolicy{},
+			&models.DataImport{},
+			&models.AgentHealth{},
+		} {
+			result := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(table)
+			if result.Error != nil {
+				return result.Error
+			}
+			purged += result.RowsAffected
+		}
+
+		var agentIDs []string
+		err = deleted(&models.Agent{}).
+			Where("NOT EXISTS (SELECT 1 FROM interview_sessions WHERE interview_sessions.agent_id = agents.id)").
+			Pluck("id", &agentIDs).Error
+		if err != nil {
+			return err
+		}
+		for _, agentID := range agentIDs {
+			if err := deleteAgent(tx, agentID); err != nil {
+				return err
+			}
+		}
+		purged += int64(len(agentIDs))
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to purge soft-deleted rows", "error", err, "cutoff", cutoff)
+		return 0, err
+	}
+	return purged, nil
+}
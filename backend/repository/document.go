@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Document operations
+
+// CreateDocument stores a document together with its parsed sections
+func (r *GORMRepository) CreateDocument(ctx context.Context, document *models.Document) error {
+	if err := r.db.WithContext(ctx).Create(document).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create document", "error", err, "user_id", document.UserID)
+		return err
+	}
+	return nil
+}
+
+// GetDocument returns a user's document with its sections
+func (r *GORMRepository) GetDocument(ctx context.Context, documentID, userID string) (*models.Document, error) {
+	var document models.Document
+	err := r.db.WithContext(ctx).
+		Preload("Sections", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("id = ? AND user_id = ?", documentID, userID).
+		First(&document).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get document", "error", err, "document_id", documentID)
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *GORMRepository) GetUserDocuments(ctx context.Context, userID string) ([]models.Document, error) {
+	var documents []models.Document
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&documents).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get user documents", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return documents, nil
+}
+
+// DeleteDocument removes a user's document and its sections, reporting whether it existed
+func (r *GORMRepository) DeleteDocument(ctx context.Context, documentID, userID string) (bool, error) {
+	var deleted bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", documentID, userID).Delete(&models.Document{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected > 0
+		if !deleted {
+			return nil
+		}
+		return tx.Where("document_id = ?", documentID).Delete(&models.DocumentSection{}).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to delete document", "error", err, "document_id", documentID)
+		return false, err
+	}
+	return deleted, nil
+}
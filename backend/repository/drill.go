@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// CreateDrillSession creates a drill session together with its plan
+func (r *GORMRepository) CreateDrillSession(ctx context.Context, session *models.InterviewSession, plan *models.DrillPlan) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		plan.SessionID = session.ID
+		return tx.Create(plan).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create drill session", "error", err, "source_session_id", plan.SourceSessionID)
+		return err
+	}
+	return nil
+}
+
+// GetDrillPlan returns the plan of a drill session, or nil if the session isn't a drill
+func (r *GORMRepository) GetDrillPlan(ctx context.Context, sessionID string) (*models.DrillPlan, error) {
+	var plan models.DrillPlan
+	if err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).First(&plan).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get drill plan", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// GetSessionDrills returns the drill sessions created from a session, oldest first
+func (r *GORMRepository) GetSessionDrills(ctx context.Context, sourceSessionID string) ([]models.DrillProgress, error) {
+	var drills []models.DrillProgress
+	err := r.db.WithContext(ctx).
+		Table("drill_plans").
+		Select("drill_plans.session_id, s.status, s.started_at, s.ended_at, drill_plans.topics, drill_plans.source_score, sm.overall_score").
+		Joins("JOIN interview_sessions s ON s.id = drill_plans.session_id AND s.deleted_at IS NULL").
+		Joins("LEFT JOIN interview_summaries sm ON sm.session_id = drill_plans.session_id AND sm.deleted_at IS NULL").
+		Where("drill_plans.source_session_id = ?", sourceSessionID).
+		Order("s.started_at").
+		Scan(&drills).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get session drills", "error", err, "source_session_id", sourceSessionID)
+		return nil, err
+	}
+	return drills, nil
+}
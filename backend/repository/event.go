@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Event operations
+func (r *GORMRepository) CreateEvent(ctx context.Context, event *models.Event) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create event", "error", err, "type", event.Type, "session_id", event.SessionID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) MarkEventProcessed(ctx context.Context, eventID string) error {
+	now := time.Now()
+	err := r.db.WithContext(ctx).
+		Model(&models.Event{}).
+		Where("id = ?", eventID).
+		Updates(map[string]interface{}{
+			"status":       models.EventStatusProcessed,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+			"processed_at": &now,
+		}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to mark event processed", "error", err, "event_id", eventID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) MarkEventFailed(ctx context.Context, eventID string, lastError string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.Event{}).
+		Where("id = ?", eventID).
+		Updates(map[string]interface{}{
+			"status":     models.EventStatusFailed,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": lastError,
+		}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to mark event failed", "error", err, "event_id", eventID)
+		return err
+	}
+	return nil
+}
+
+// ClaimRetryableEvents takes failed events under the attempt limit and pending events older
+// than the given age (left behind by a restart), oldest first. Claiming touches updated_at, so
+// the events aren't claimed again until olderThan passes; SKIP LOCKED lets several instances
+// redeliver concurrently without taking the same events.
+func (r *GORMRepository) ClaimRetryableEvents(ctx context.Context, maxAttempts int, olderThan time.Duration, limit int) ([]models.Event, error) {
+	var events []models.Event
+	cutoff := time.Now().Add(-olderThan)
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE events SET updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM events
+			WHERE ((status = ? AND attempts < ?) OR (status = ? AND created_at < ?)) AND updated_at < ?
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT ?
+		)
+		RETURNING *`, models.EventStatusFailed, maxAttempts, models.EventStatusPending, cutoff, cutoff, limit).
+		Scan(&events).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to claim retryable events", "error", err)
+		return nil, err
+	}
+	slices.SortFunc(events, func(a, b models.Event) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return events, nil
+}
+
+// GetEventDeliveries returns the subscribers that have handled an event
+func (r *GORMRepository) GetEventDeliveries(ctx context.Context, eventID string) ([]string, error) {
+	var subscribers []string
+	err := r.db.WithContext(ctx).
+		Model(&models.EventDelivery{}).
+		Where("event_id = ?", eventID).
+		Pluck("subscriber", &subscribers).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get event deliveries", "error", err, "event_id", eventID)
+		return nil, err
+	}
+	return subscribers, nil
+}
+
+// CreateEventDelivery records that a subscriber handled an event; recording it twice is a no-op
+func (r *GORMRepository) CreateEventDelivery(ctx context.Context, eventID, subscriber string) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.EventDelivery{EventID: eventID, Subscriber: subscriber}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to record event delivery", "error", err, "event_id", eventID, "subscriber", subscriber)
+		return err
+	}
+	return nil
+}
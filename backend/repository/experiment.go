@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Scoring experiment operations
+func (r *GORMRepository) CreateScoringExperiment(ctx context.Context, experiment *models.ScoringExperiment) error {
+	logger := logging.FromContext(ctx)
+	if err := r.db.WithContext(ctx).Create(experiment).Error; err != nil {
+		logger.Error("Failed to create scoring experiment", "error", err, "name", experiment.Name)
+		return err
+	}
+	logger.Info("Scoring experiment created", "experiment_id", experiment.ID, "name", experiment.Name)
+	return nil
+}
+
+func (r *GORMRepository) GetScoringExperiments(ctx context.Context, activeOnly bool) ([]models.ScoringExperiment, error) {
+	var experiments []models.ScoringExperiment
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Find(&experiments).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get scoring experiments", "error", err)
+		return nil, err
+	}
+	return experiments, nil
+}
+
+func (r *GORMRepository) GetScoringExperiment(ctx context.Context, experimentID string) (*models.ScoringExperiment, error) {
+	var experiment models.ScoringExperiment
+	err := r.db.WithContext(ctx).Where("id = ?", experimentID).First(&experiment).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get scoring experiment", "error", err, "experiment_id", experimentID)
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+func (r *GORMRepository) UpdateScoringExperiment(ctx context.Context, experiment *models.ScoringExperiment) error {
+	if err := r.db.WithContext(ctx).Save(experiment).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to update scoring experiment", "error", err, "experiment_id", experiment.ID)
+		return err
+	}
+	return nil
+}
+
+// Shadow summary operations
+func (r *GORMRepository) CreateShadowSummary(ctx context.Context, shadow *models.ShadowSummary) error {
+	if err := r.db.WithContext(ctx).Create(shadow).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create shadow summary", "error", err, "experiment_id", shadow.ExperimentID, "session_id", shadow.SessionID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) ShadowSummaryExists(ctx context.Context, experimentID, sessionID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.ShadowSummary{}).
+		Where("experiment_id = ? AND session_id = ?", experimentID, sessionID).
+		Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to check shadow summary", "error", err, "experiment_id", experimentID, "session_id", sessionID)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetShadowSummaries returns an experiment's shadow summaries with their production counterparts
+func (r *GORMRepository) GetShadowSummaries(ctx context.Context, experimentID string, limit int) ([]models.ShadowSummary, error) {
+	var shadows []models.ShadowSummary
+	err := r.db.WithContext(ctx).
+		Where("experiment_id = ?", experimentID).
+		Preload("ProductionSummary").
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&shadows).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get shadow summaries", "error", err, "experiment_id", experimentID)
+		return nil, err
+	}
+	return shadows, nil
+}
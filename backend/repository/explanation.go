@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// Explanation operations
+func (r *GORMRepository) CreateExplanations(ctx context.Context, explanations []models.Explanation) error {
+	if len(explanations) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(&explanations).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create explanations", "error", err, "session_id", explanations[0].SessionID)
+		return err
+	}
+	return nil
+}
+
+// GetSessionExplanations returns a session's explanations of one kind (all kinds when empty) in the order captured
+func (r *GORMRepository) GetSessionExplanations(ctx context.Context, sessionID, kind string) ([]models.Explanation, error) {
+	query := r.db.WithContext(ctx).Where("session_id = ?", sessionID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var explanations []models.Explanation
+	if err := query.Order("created_at ASC").Find(&explanations).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get session explanations", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return explanations, nil
+}
+
+// HasSessionExplanations reports whether explanations of a kind were already captured for a session
+func (r *GORMRepository) HasSessionExplanations(ctx context.Context, sessionID, kind string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Explanation{}).Where("session_id = ? AND kind = ?", sessionID, kind).Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count session explanations", "error", err, "session_id", sessionID)
+		return false, err
+	}
+	return count > 0, nil
+}
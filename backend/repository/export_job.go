@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *GORMRepository) CreateExportJob(ctx context.Context, job *models.ExportJob) error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create export job", "error", err, "user_id", job.UserID)
+		return err
+	}
+	return nil
+}
+
+// CreateAccountExportJob queues an account export job unless the user has one pending or
+// running, reporting whether it was created; the open job is returned when they do
+func (r *GORMRepository) CreateAccountExportJob(ctx context.Context, job *models.ExportJob) (*models.ExportJob, bool, error) {
+	logger := logging.FromContext(ctx)
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "user_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "kind = 'account' AND status IN ('pending','running')"}}},
+			DoNothing:   true,
+		}).
+		Create(job)
+	if result.Error != nil {
+		logger.Error("Failed to create account export job", "error", result.Error, "user_id", job.UserID)
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return job, true, nil
+	}
+
+	// Lost the race to another request queuing one
+	var open models.ExportJob
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND kind = ? AND status IN ?", job.UserID, models.ExportKindAccount, []string{models.ExportJobPending, models.ExportJobRunning}).
+		First(&open).Error
+	if err != nil {
+		logger.Error("Failed to get open account export job", "error", err, "user_id", job.UserID)
+		return nil, false, err
+	}
+	return &open, false, nil
+}
+
+// GetExportJob returns an export job by ID, or nil if there is none
+func (r *GORMRepository) GetExportJob(ctx context.Context, jobID string) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.WithContext(ctx).Where("id = ?", jobID).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get export job", "error", err, "job_id", jobID)
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetUserExportJobs returns a user's most recent export jobs, newest first
+func (r *GORMRepository) GetUserExportJobs(ctx context.Context, userID string, limit int) ([]models.ExportJob, error) {
+	var jobs []models.ExportJob
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get export jobs", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetLatestExportJob returns a user's most recent export job of a kind, or nil without one
+func (r *GORMRepository) GetLatestExportJob(ctx context.Context, userID, kind string) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND kind = ?", userID, kind).
+		Order("created_at DESC").
+		First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get latest export job", "error", err, "user_id", userID, "kind", kind)
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CountOpenExportJobs counts a user's export jobs that are pending or running
+func (r *GORMRepository) CountOpenExportJobs(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("user_id = ? AND status IN ?", userID, []string{models.ExportJobPending, models.ExportJobRunning}).
+		Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count open export jobs", "error", err, "user_id", userID)
+		return 0, err
+	}
+	return count, nil
+}
+
+// ClaimExportJob marks the oldest pending export job as running and returns it, or nil when
+// there is none. SKIP LOCKED lets several workers claim jobs without blocking each other.
+func (r *GORMRepository) ClaimExportJob(ctx context.Context) (*models.ExportJob, error) {
+	var jobs []models.ExportJob
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE export_jobs SET status = ?, attempts = attempts + 1, progress = 0, started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = ? AND deleted_at IS NULL
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`, models.ExportJobRunning, models.ExportJobPending).
+		Scan(&jobs).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to claim export job", "error", err)
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// SetExportJobProgress records how many of a running job's items are written
+func (r *GORMRepository) SetExportJobProgress(ctx context.Context, jobID string, progress, total int) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("id = ?", jobID).
+		Updates(map[string]interface{}{"progress": progress, "total": total}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update export job progress", "error", err, "job_id", jobID)
+		return err
+	}
+	return nil
+}
+
+// CompleteExportJob records a job's finished archive, kept until expiresAt
+func (r *GORMRepository) CompleteExportJob(ctx context.Context, jobID, blobKey string, size int64, expiresAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("id = ?", jobID).
+		Updates(map[string]interface{}{
+			"status":      models.ExportJobDone,
+			"blob_key":    blobKey,
+			"size":        size,
+			"last_error":  "",
+			"finished_at": time.Now(),
+			"expires_at":  expiresAt,
+		}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to complete export job", "error", err, "job_id", jobID)
+		return err
+	}
+	return nil
+}
+
+// FailExportJob records a failed attempt: the job goes back in the queue, or fails for good
+// when retry is false
+func (r *GORMRepository) FailExportJob(ctx context.Context, jobID, lastError string, retry bool) error {
+	updates := map[string]interface{}{
+		"status":     models.ExportJobFailed,
+		"last_error": lastError,
+	}
+	if retry {
+		updates["status"] = models.ExportJobPending
+	} else {
+		updates["finished_at"] = time.Now()
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("id = ?", jobID).
+		Updates(updates).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update export job", "error", err, "job_id", jobID)
+		return err
+	}
+	return nil
+}
+
+// ReleaseExportJob puts a job whose attempt was interrupted, e.g. by a shutdown, back in the
+// queue without counting the attempt
+func (r *GORMRepository) ReleaseExportJob(ctx context.Context, jobID string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("id = ? AND status = ?", jobID, models.ExportJobRunning).
+		Updates(map[string]interface{}{
+			"status":   models.ExportJobPending,
+			"attempts": gorm.Expr("attempts - 1"),
+		}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to release export job", "error", err, "job_id", jobID)
+		return err
+	}
+	return nil
+}
+
+// RequeueRunningExportJobs puts jobs that were running when the server stopped back in the
+// queue, reporting how many there were
+func (r *GORMRepository) RequeueRunningExportJobs(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("status = ?", models.ExportJobRunning).
+		Update("status", models.ExportJobPending)
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to requeue running export jobs", "error", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// GetExpiredExportJobs returns up to limit finished jobs whose archives are past their retention
+func (r *GORMRepository) GetExpiredExportJobs(ctx context.Context, limit int) ([]models.ExportJob, error) {
+	var jobs []models.ExportJob
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", models.ExportJobDone, time.Now()).
+		Order("expires_at").
+		Limit(limit).
+		Find(&jobs).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get expired export jobs", "error", err)
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ExpireExportJob marks a job whose archive was deleted
+func (r *GORMRepository) ExpireExportJob(ctx context.Context, jobID string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("id = ?", jobID).
+		Updates(map[string]interface{}{"status": models.ExportJobExpired, "blob_key": ""}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to expire export job", "error", err, "job_id", jobID)
+		return err
+	}
+	return nil
+}
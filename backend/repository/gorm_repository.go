@@ -2,11 +2,14 @@ package repository
 
 import (
 	"context"
-	"log/slog"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GORMRepository struct {
@@ -19,7 +22,7 @@ func NewGORMRepository(db *gorm.DB) *GORMRepository {
 
 // AutoMigrate runs database migrations
 func (r *GORMRepository) AutoMigrate() error {
-	return r.db.AutoMigrate(
+	err := r.db.AutoMigrate(
 		&models.User{},
 		&models.Agent{},
 		&models.InterviewSession{},
@@ -29,16 +32,79 @@ func (r *GORMRepository) AutoMigrate() error {
 		&models.RefreshToken{},
 		&models.PermanentToken{},
 		&models.Message{},
+		&models.SummaryQuality{},
+		&models.SummaryQualityAlert{},
+		&models.ScoringExperiment{},
+		&models.ShadowSummary{},
+		&models.Event{},
+		&models.EventDelivery{},
+		&models.AudioRecording{},
+		&models.SessionToken{},
+		&models.SessionNote{},
+		&models.LegalDocument{},
+		&models.LegalAcceptance{},
+		&models.Impersonation{},
+		&models.ImpersonationAuditEntry{},
+		&models.KnownDevice{},
+		&models.Notification{},
+		&models.AgentUsageWebhook{},
+		&models.AgentUsageDigest{},
+		&models.ScoringPolicy{},
+		&models.Certificate{},
+		&models.Explanation{},
+		&models.Document{},
+		&models.DocumentSection{},
+		&models.QuestionBank{},
+		&models.Question{},
+		&models.SessionFlag{},
+		&models.AgentHealth{},
+		&models.SummaryJob{},
+		&models.ClientError{},
+		&models.ProctorEvent{},
+		&models.OrgBranding{},
+		&models.DrillPlan{},
+		&models.BannedTopic{},
+		&models.ComplianceViolation{},
+		&models.Organization{},
+		&models.OrgMembership{},
+		&models.OrgInvite{},
+		&models.InterviewInvite{},
+		&models.NotificationSettings{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.APIKey{},
+		&models.SummaryVersion{},
+		&models.RescoreBackfill{},
+		&models.SessionClientInfo{},
+		&models.DataImport{},
+		&models.ExportJob{},
+		&models.TurnMetrics{},
+		&models.RubricItem{},
+		&models.CoachingNote{},
+		&models.SpeechMetrics{},
 	)
+	if err != nil {
+		return err
+	}
+	return r.backfillPermanentTokenExpiry()
+}
+
+// backfillPermanentTokenExpiry gives permanent tokens issued before they expired the lifetime
+// new ones get, counted from when they were issued, so they keep working until then
+func (r *GORMRepository) backfillPermanentTokenExpiry() error {
+	return r.db.Model(&models.PermanentToken{}).
+		Where("expires_at IS NULL").
+		Update("expires_at", gorm.Expr("created_at + make_interval(secs => ?)", models.PermanentTokenLifetime.Seconds())).Error
 }
 
 // User operations
 func (r *GORMRepository) CreateUser(ctx context.Context, user *models.User) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
-		slog.Error("Failed to create user", "error", err)
+		logger.Error("Failed to create user", "error", err)
 		return err
 	}
-	slog.Info("User created", "user_id", user.ID, "email", user.Email)
+	logger.Info("User created", "user_id", user.ID, "email", user.Email)
 	return nil
 }
 
@@ -48,7 +114,7 @@ func (r *GORMRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get user by email", "error", err, "email", email)
+		logging.FromContext(ctx).Error("Failed to get user by email", "error", err, "email", email)
 		return nil, err
 	}
 	return &user, nil
@@ -60,19 +126,30 @@ func (r *GORMRepository) GetUserByID(ctx context.Context, id string) (*models.Us
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get user by ID", "error", err, "user_id", id)
+		logging.FromContext(ctx).Error("Failed to get user by ID", "error", err, "user_id", id)
 		return nil, err
 	}
 	return &user, nil
 }
 
+// SetUserRole changes a user's role, user or admin
+func (r *GORMRepository) SetUserRole(ctx context.Context, userID, role string) error {
+	logger := logging.FromContext(ctx)
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error; err != nil {
+		logger.Error("Failed to set user role", "error", err, "user_id", userID, "role", role)
+		return err
+	}
+	logger.Info("User role changed", "user_id", userID, "role", role)
+	return nil
+}
+
 // Note: Old Session and Message models have been replaced with InterviewSession and InterviewTranscript
 // These operations are now handled by the interview-specific methods below
 
 // Token operations
 func (r *GORMRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
 	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
-		slog.Error("Failed to create refresh token", "error", err)
+		logging.FromContext(ctx).Error("Failed to create refresh token", "error", err)
 		return err
 	}
 	return nil
@@ -84,7 +161,7 @@ func (r *GORMRepository) GetRefreshToken(ctx context.Context, token string) (*mo
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get refresh token", "error", err)
+		logging.FromContext(ctx).Error("Failed to get refresh token", "error", err)
 		return nil, err
 	}
 	return &refreshToken, nil
@@ -92,7 +169,7 @@ func (r *GORMRepository) GetRefreshToken(ctx context.Context, token string) (*mo
 
 func (r *GORMRepository) DeleteRefreshToken(ctx context.Context, token string) error {
 	if err := r.db.WithContext(ctx).Where("token = ?", token).Delete(&models.RefreshToken{}).Error; err != nil {
-		slog.Error("Failed to delete refresh token", "error", err)
+		logging.FromContext(ctx).Error("Failed to delete refresh token", "error", err)
 		return err
 	}
 	return nil
@@ -100,7 +177,7 @@ func (r *GORMRepository) DeleteRefreshToken(ctx context.Context, token string) e
 
 func (r *GORMRepository) CreatePermanentToken(ctx context.Context, token *models.PermanentToken) error {
 	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
-		slog.Error("Failed to create permanent token", "error", err)
+		logging.FromContext(ctx).Error("Failed to create permanent token", "error", err)
 		return err
 	}
 	return nil
@@ -108,31 +185,131 @@ func (r *GORMRepository) CreatePermanentToken(ctx context.Context, token *models
 
 func (r *GORMRepository) GetPermanentToken(ctx context.Context, token string) (*models.PermanentToken, error) {
 	var permanentToken models.PermanentToken
-	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&permanentToken).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("token = ? AND expires_at > ?", token, time.Now()).First(&permanentToken).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get permanent token", "error", err)
+		logging.FromContext(ctx).Error("Failed to get permanent token", "error", err)
 		return nil, err
 	}
 	return &permanentToken, nil
 }
 
+// GetUserPermanentTokens lists a user's unexpired permanent tokens (trusted devices), most recently used first
+func (r *GORMRepository) GetUserPermanentTokens(ctx context.Context, userID string) ([]models.PermanentToken, error) {
+	var tokens []models.PermanentToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("COALESCE(last_used_at, created_at) DESC").
+		Find(&tokens).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get user permanent tokens", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// TouchPermanentToken records a use of a permanent token from the given IP, user agent and country
+func (r *GORMRepository) TouchPermanentToken(ctx context.Context, tokenID, ip, userAgent, country string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.PermanentToken{}).
+		Where("id = ?", tokenID).
+		Updates(map[string]interface{}{
+			"last_ip":         ip,
+			"last_user_agent": userAgent,
+			"last_country":    country,
+			"last_used_at":    time.Now(),
+		}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update permanent token usage", "error", err, "token_id", tokenID)
+		return err
+	}
+	return nil
+}
+
+// RevokePermanentToken deletes a user's permanent token and the refresh tokens issued with it,
+// reporting whether the token existed
+func (r *GORMRepository) RevokePermanentToken(ctx context.Context, tokenID, userID string) (bool, error) {
+	revoked := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", tokenID, userID).Delete(&models.PermanentToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		revoked = true
+		return tx.Where("permanent_token_id = ?", tokenID).Delete(&models.RefreshToken{}).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to revoke permanent token", "error", err, "token_id", tokenID, "user_id", userID)
+		return false, err
+	}
+	return revoked, nil
+}
+
 func (r *GORMRepository) DeletePermanentToken(ctx context.Context, token string) error {
 	if err := r.db.WithContext(ctx).Where("token = ?", token).Delete(&models.PermanentToken{}).Error; err != nil {
-		slog.Error("Failed to delete permanent token", "error", err)
+		logging.FromContext(ctx).Error("Failed to delete permanent token", "error", err)
 		return err
 	}
 	return nil
 }
 
 func (r *GORMRepository) DeleteAllUserTokens(ctx context.Context, userID string) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error; err != nil {
-		slog.Error("Failed to delete user refresh tokens", "error", err, "user_id", userID)
+		logger.Error("Failed to delete user refresh tokens", "error", err, "user_id", userID)
 		return err
 	}
 	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.PermanentToken{}).Error; err != nil {
-		slog.Error("Failed to delete user permanent tokens", "error", err, "user_id", userID)
+		logger.Error("Failed to delete user permanent tokens", "error", err, "user_id", userID)
+		return err
+	}
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.SessionToken{}).Error; err != nil {
+		logger.Error("Failed to delete user session tokens", "error", err, "user_id", userID)
+		return err
+	}
+	return nil
+}
+
+// UpdateUserRegion stores the location-derived jurisdiction and compliance flags for a user
+func (r *GORMRepository) UpdateUserRegion(ctx context.Context, userID, country, jurisdiction, language string, consentRequired bool) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"country":          country,
+			"jurisdiction":     jurisdiction,
+			"language":         language,
+			"consent_required": consentRequired,
+		}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update user region", "error", err, "user_id", userID)
+		return err
+	}
+	return nil
+}
+
+// ConfirmUserAge records that the user passed the signup age gate
+func (r *GORMRepository) ConfirmUserAge(ctx context.Context, userID string) error {
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("age_confirmed_at", time.Now()).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to confirm user age", "error", err, "user_id", userID)
+		return err
+	}
+	return nil
+}
+
+// SetUserResearchConsent records whether the user's sessions may be used in anonymized research datasets
+func (r *GORMRepository) SetUserResearchConsent(ctx context.Context, userID string, optIn bool) error {
+	var optedInAt *time.Time
+	if optIn {
+		now := time.Now()
+		optedInAt = &now
+	}
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("research_opt_in_at", optedInAt).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to set user research consent", "error", err, "user_id", userID)
 		return err
 	}
 	return nil
@@ -140,11 +317,12 @@ func (r *GORMRepository) DeleteAllUserTokens(ctx context.Context, userID string)
 
 // Interview-specific operations using GORM ORM
 func (r *GORMRepository) CreateAgent(ctx context.Context, agent *models.Agent) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).Create(agent).Error; err != nil {
-		slog.Error("Failed to create agent", "error", err)
+		logger.Error("Failed to create agent", "error", err)
 		return err
 	}
-	slog.Info("Agent created", "agent_id", agent.ID, "name", agent.Name)
+	logger.Info("Agent created", "agent_id", agent.ID, "name", agent.Name)
 	return nil
 }
 
@@ -157,8 +335,9 @@ func (r *GORMRepository) GetAgents(ctx context.Context, userID string, includePu
 			// When userID is empty, only get public agents (user_id IS NULL)
 			query = query.Where("user_id IS NULL")
 		} else {
-			// When userID is provided, get both public agents and user's private agents
-			query = query.Where("(user_id IS NULL OR user_id = ?)", userID)
+			// When userID is provided, get public agents, user's private agents and those shared
+			// with the user's organizations
+			query = query.Where("(user_id IS NULL OR user_id = ? OR organization_id IN (?))", userID, r.memberOrgIDs(userID))
 		}
 	} else {
 		// Only get user's private agents
@@ -169,19 +348,24 @@ func (r *GORMRepository) GetAgents(ctx context.Context, userID string, includePu
 		query = query.Where("user_id = ?", userID)
 	}
 
+	// Best-ranked public agents first; unscored and private agents keep their creation order
+	query = query.Order("(SELECT score FROM agent_healths WHERE agent_healths.agent_id = agents.id AND agent_healths.deleted_at IS NULL) DESC NULLS LAST").
+		Order("created_at")
+
 	if err := query.Find(&agents).Error; err != nil {
-		slog.Error("Failed to get agents", "error", err, "user_id", userID)
+		logging.FromContext(ctx).Error("Failed to get agents", "error", err, "user_id", userID)
 		return nil, err
 	}
 	return agents, nil
 }
 
 func (r *GORMRepository) CreateInterviewSession(ctx context.Context, session *models.InterviewSession) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
-		slog.Error("Failed to create interview session", "error", err)
+		logger.Error("Failed to create interview session", "error", err)
 		return err
 	}
-	slog.Info("Interview session created", "session_id", session.ID, "user_id", session.UserID)
+	logger.Info("Interview session created", "session_id", session.ID, "user_id", session.UserID)
 	return nil
 }
 
@@ -189,18 +373,66 @@ func (r *GORMRepository) GetInterviewSessions(ctx context.Context, userID string
 	var sessions []models.InterviewSession
 	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Preload("Agent").Find(&sessions).Error
 	if err != nil {
-		slog.Error("Failed to get interview sessions", "error", err, "user_id", userID)
+		logging.FromContext(ctx).Error("Failed to get interview sessions", "error", err, "user_id", userID)
 		return nil, err
 	}
 	return sessions, nil
 }
 
+// CreateInterviewTranscript saves a turn as the session's next one, setting its turn order.
+// The session row is locked while the order is allocated, so turns saved concurrently, such as
+// a question injected while the candidate answers, each get their own.
 func (r *GORMRepository) CreateInterviewTranscript(ctx context.Context, transcript *models.InterviewTranscript) error {
-	if err := r.db.WithContext(ctx).Create(transcript).Error; err != nil {
-		slog.Error("Failed to create interview transcript", "error", err)
+	logger := logging.FromContext(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked []string
+		if err := tx.Raw(`SELECT id FROM "interview_sessions" WHERE id = ? FOR UPDATE`, transcript.SessionID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		var last int
+		if err := tx.Model(&models.InterviewTranscript{}).
+			Where("session_id = ?", transcript.SessionID).
+			Select("COALESCE(MAX(turn_order), 0)").
+			Scan(&last).Error; err != nil {
+			return err
+		}
+		transcript.TurnOrder = last + 1
+		return tx.Create(transcript).Error
+	})
+	if err != nil {
+		logger.Error("Failed to create interview transcript", "error", err)
+		return err
+	}
+	logger.Info("Interview transcript created", "transcript_id", transcript.ID, "session_id", transcript.SessionID, "turn_order", transcript.TurnOrder)
+	return nil
+}
+
+// ClaimSessionWelcome atomically marks a session as welcomed, reporting whether this caller
+// claimed it. Concurrent connections to the same session race on the one UPDATE, so only one
+// of them sends the welcome.
+func (r *GORMRepository) ClaimSessionWelcome(ctx context.Context, sessionID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.InterviewSession{}).
+		Where("id = ? AND welcomed_at IS NULL", sessionID).
+		Update("welcomed_at", time.Now())
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to claim session welcome", "error", result.Error, "session_id", sessionID)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseSessionWelcome undoes ClaimSessionWelcome when the welcome couldn't be saved, so the
+// next connection tries again
+func (r *GORMRepository) ReleaseSessionWelcome(ctx context.Context, sessionID string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.InterviewSession{}).
+		Where("id = ?", sessionID).
+		Update("welcomed_at", nil).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to release session welcome", "error", err, "session_id", sessionID)
 		return err
 	}
-	slog.Info("Interview transcript created", "transcript_id", transcript.ID, "session_id", transcript.SessionID)
 	return nil
 }
 
@@ -208,21 +440,125 @@ func (r *GORMRepository) GetInterviewTranscripts(ctx context.Context, sessionID
 	var transcripts []models.InterviewTranscript
 	err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).Order("turn_order").Find(&transcripts).Error
 	if err != nil {
-		slog.Error("Failed to get interview transcripts", "error", err, "session_id", sessionID)
+		logging.FromContext(ctx).Error("Failed to get interview transcripts", "error", err, "session_id", sessionID)
 		return nil, err
 	}
 	return transcripts, nil
 }
 
-func (r *GORMRepository) CreateInterviewSummary(ctx context.Context, summary *models.InterviewSummary) error {
-	if err := r.db.WithContext(ctx).Create(summary).Error; err != nil {
-		slog.Error("Failed to create interview summary", "error", err)
+// CreateInterviewSummary stores a session's summary with its performance scores unless it
+// already has one, reporting whether it was created. When another caller got there first, their
+// summary is returned instead and the scores are left out: the session keeps the winner's.
+func (r *GORMRepository) CreateInterviewSummary(ctx context.Context, summary *models.InterviewSummary, scores []models.PerformanceScore) (*models.InterviewSummary, bool, error) {
+	logger := logging.FromContext(ctx)
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}},
+			DoNothing: true,
+		}).Create(summary)
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		created = true
+		return createPerformanceScores(tx, scores)
+	})
+	if err != nil {
+		logger.Error("Failed to create interview summary", "error", err, "session_id", summary.SessionID)
+		return nil, false, err
+	}
+	if created {
+		logger.Info("Interview summary created", "summary_id", summary.ID, "session_id", summary.SessionID, "scores", len(scores))
+		return summary, true, nil
+	}
+
+	// Lost the race to another summary of the session
+	existing, err := r.GetInterviewSummary(ctx, summary.SessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		err := fmt.Errorf("session %s has a deleted summary", summary.SessionID)
+		logger.Error("Failed to create interview summary", "error", err, "session_id", summary.SessionID)
+		return nil, false, err
+	}
+	logger.Info("Interview summary already exists", "summary_id", existing.ID, "session_id", summary.SessionID)
+	return existing, false, nil
+}
+
+// ReplaceInterviewSummary stores a regenerated summary and its performance scores in place of the
+// session's current ones, archiving them as a summary version
+func (r *GORMRepository) ReplaceInterviewSummary(ctx context.Context, summary *models.InterviewSummary, scores []models.PerformanceScore) error {
+	logger := logging.FromContext(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.InterviewSummary
+		err := tx.Where("session_id = ?", summary.SessionID).First(&current).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if err == nil {
+			if err := archiveSummary(tx, &current); err != nil {
+				return err
+			}
+			summary.Version = current.Version + 1
+		}
+
+		if err := tx.Unscoped().Where("session_id = ?", summary.SessionID).Delete(&models.PerformanceScore{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("session_id = ?", summary.SessionID).Delete(&models.InterviewSummary{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(summary).Error; err != nil {
+			return err
+		}
+		return createPerformanceScores(tx, scores)
+	})
+	if err != nil {
+		logger.Error("Failed to replace interview summary", "error", err, "session_id", summary.SessionID)
 		return err
 	}
-	slog.Info("Interview summary created", "summary_id", summary.ID, "session_id", summary.SessionID)
+	logger.Info("Interview summary replaced", "summary_id", summary.ID, "session_id", summary.SessionID)
 	return nil
 }
 
+// createPerformanceScores stores a summary's performance scores
+func createPerformanceScores(tx *gorm.DB, scores []models.PerformanceScore) error {
+	if len(scores) == 0 {
+		return nil
+	}
+	return tx.Create(&scores).Error
+}
+
+// archiveSummary keeps a summary about to be replaced, with its performance scores, as a
+// summary version
+func archiveSummary(tx *gorm.DB, summary *models.InterviewSummary) error {
+	var scores []models.PerformanceScore
+	if err := tx.Where("session_id = ?", summary.SessionID).Find(&scores).Error; err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(scores)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.SummaryVersion{
+		SessionID:            summary.SessionID,
+		Version:              summary.Version,
+		Summary:              summary.Summary,
+		Strengths:            summary.Strengths,
+		Weaknesses:           summary.Weaknesses,
+		Recommendations:      summary.Recommendations,
+		OverallScore:         summary.OverallScore,
+		ScoringPolicyID:      summary.ScoringPolicyID,
+		ScoringPolicyVersion: summary.ScoringPolicyVersion,
+		RawScore:             summary.RawScore,
+		Passed:               summary.Passed,
+		ScoreBreakdown:       summary.ScoreBreakdown,
+		Scores:               string(encoded),
+		GeneratedAt:          summary.CreatedAt,
+	}).Error
+}
+
 func (r *GORMRepository) GetInterviewSummary(ctx context.Context, sessionID string) (*models.InterviewSummary, error) {
 	var summary models.InterviewSummary
 	err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).First(&summary).Error
@@ -230,26 +566,17 @@ func (r *GORMRepository) GetInterviewSummary(ctx context.Context, sessionID stri
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get interview summary", "error", err, "session_id", sessionID)
+		logging.FromContext(ctx).Error("Failed to get interview summary", "error", err, "session_id", sessionID)
 		return nil, err
 	}
 	return &summary, nil
 }
 
-func (r *GORMRepository) CreatePerformanceScore(ctx context.Context, score *models.PerformanceScore) error {
-	if err := r.db.WithContext(ctx).Create(score).Error; err != nil {
-		slog.Error("Failed to create performance score", "error", err)
-		return err
-	}
-	slog.Info("Performance score created", "score_id", score.ID, "session_id", score.SessionID, "metric", score.Metric)
-	return nil
-}
-
 func (r *GORMRepository) GetPerformanceScores(ctx context.Context, sessionID string) ([]models.PerformanceScore, error) {
 	var scores []models.PerformanceScore
 	err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).Find(&scores).Error
 	if err != nil {
-		slog.Error("Failed to get performance scores", "error", err, "session_id", sessionID)
+		logging.FromContext(ctx).Error("Failed to get performance scores", "error", err, "session_id", sessionID)
 		return nil, err
 	}
 	return scores, nil
@@ -259,36 +586,147 @@ func (r *GORMRepository) GetPerformanceScores(ctx context.Context, sessionID str
 
 func (r *GORMRepository) GetAgentByID(ctx context.Context, agentID string, userID string) (*models.Agent, error) {
 	var agent models.Agent
-	// Get agent if it's public OR belongs to the user
-	err := r.db.WithContext(ctx).Where("id = ? AND (user_id IS NULL OR user_id = ?)", agentID, userID).First(&agent).Error
+	// Get agent if it's public, belongs to the user or is shared with one of the user's organizations
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND (user_id IS NULL OR user_id = ? OR organization_id IN (?))", agentID, userID, r.memberOrgIDs(userID)).
+		First(&agent).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get agent by ID", "error", err, "agent_id", agentID, "user_id", userID)
+		logging.FromContext(ctx).Error("Failed to get agent by ID", "error", err, "agent_id", agentID, "user_id", userID)
 		return nil, err
 	}
 	return &agent, nil
 }
 
 func (r *GORMRepository) UpdateAgent(ctx context.Context, agent *models.Agent) error {
+	logger := logging.FromContext(ctx)
 	if err := r.db.WithContext(ctx).Save(agent).Error; err != nil {
-		slog.Error("Failed to update agent", "error", err, "agent_id", agent.ID)
+		logger.Error("Failed to update agent", "error", err, "agent_id", agent.ID)
+		return err
+	}
+	logger.Info("Agent updated", "agent_id", agent.ID, "name", agent.Name)
+	return nil
+}
+
+// SetAgentFlow attaches an interview flow to an agent; a nil flow reverts to a single open-ended stage
+func (r *GORMRepository) SetAgentFlow(ctx context.Context, agentID string, flow *string) error {
+	err := r.db.WithContext(ctx).Model(&models.Agent{}).Where("id = ?", agentID).Update("flow", flow).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to set agent flow", "error", err, "agent_id", agentID)
+		return err
+	}
+	return nil
+}
+
+// SetAgentActive deactivates an agent, hiding it from the catalog and from new sessions while
+// its past sessions keep referencing it, or reactivates it
+func (r *GORMRepository) SetAgentActive(ctx context.Context, agentID string, active bool) error {
+	logger := logging.FromContext(ctx)
+	err := r.db.WithContext(ctx).Model(&models.Agent{}).Where("id = ?", agentID).Update("is_active", active).Error
+	if err != nil {
+		logger.Error("Failed to set agent active", "error", err, "agent_id", agentID, "active", active)
 		return err
 	}
-	slog.Info("Agent updated", "agent_id", agent.ID, "name", agent.Name)
+	logger.Info("Agent active state changed", "agent_id", agentID, "active", active)
 	return nil
 }
 
+// CountAgentSessions counts the sessions run with an agent, including deleted ones
+func (r *GORMRepository) CountAgentSessions(ctx context.Context, agentID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.InterviewSession{}).Where("agent_id = ?", agentID).Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count agent sessions", "error", err, "agent_id", agentID)
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteAgent permanently removes an agent along with its usage webhook and catalog health.
+// Callers must make sure no sessions reference it.
 func (r *GORMRepository) DeleteAgent(ctx context.Context, agentID string) error {
-	if err := r.db.WithContext(ctx).Where("id = ?", agentID).Delete(&models.Agent{}).Error; err != nil {
-		slog.Error("Failed to delete agent", "error", err, "agent_id", agentID)
+	logger := logging.FromContext(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteAgent(tx, agentID)
+	})
+	if err != nil {
+		logger.Error("Failed to delete agent", "error", err, "agent_id", agentID)
 		return err
 	}
-	slog.Info("Agent deleted", "agent_id", agentID)
+	logger.Info("Agent deleted", "agent_id", agentID)
 	return nil
 }
 
+// PurgeAgent permanently removes an agent that sessions still reference. Its sessions are
+// moved to an archived, inactive snapshot of the agent, which is returned; nil when the agent
+// had no sessions.
+func (r *GORMRepository) PurgeAgent(ctx context.Context, agentID string) (*models.Agent, error) {
+	logger := logging.FromContext(ctx)
+	var snapshot *models.Agent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var agent models.Agent
+		if err := tx.Unscoped().Where("id = ?", agentID).First(&agent).Error; err != nil {
+			return err
+		}
+
+		var sessions int64
+		if err := tx.Unscoped().Model(&models.InterviewSession{}).Where("agent_id = ?", agentID).Count(&sessions).Error; err != nil {
+			return err
+		}
+		if sessions > 0 {
+			archived := agent
+			archived.ID = ""
+			archived.Name = agent.Name + " (archived)"
+			archived.IsPublic = false
+			archived.IsActive = false
+			archived.IsArchived = true
+			archived.DeletedAt = gorm.DeletedAt{}
+			if err := tx.Create(&archived).Error; err != nil {
+				return err
+			}
+			// A false is_active is left out of the insert in favour of the column default
+			if err := tx.Model(&models.Agent{}).Where("id = ?", archived.ID).Update("is_active", false).Error; err != nil {
+				return err
+			}
+			err := tx.Unscoped().Model(&models.InterviewSession{}).
+				Where("agent_id = ?", agentID).
+				Update("agent_id", archived.ID).Error
+			if err != nil {
+				return err
+			}
+			snapshot = &archived
+		}
+
+		return deleteAgent(tx, agentID)
+	})
+	if err != nil {
+		logger.Error("Failed to purge agent", "error", err, "agent_id", agentID)
+		return nil, err
+	}
+	logger.Info("Agent purged", "agent_id", agentID, "archived_as", snapshot != nil)
+	return snapshot, nil
+}
+
+// deleteAgent hard-deletes an agent and the rows that only make sense while it exists
+func deleteAgent(tx *gorm.DB, agentID string) error {
+	webhooks := tx.Unscoped().Model(&models.AgentUsageWebhook{}).Select("id").Where("agent_id = ?", agentID)
+	if err := tx.Unscoped().Where("webhook_id IN (?)", webhooks).Delete(&models.AgentUsageDigest{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("agent_id = ?", agentID).Delete(&models.AgentUsageWebhook{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("agent_id = ?", agentID).Delete(&models.AgentHealth{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("agent_id = ?", agentID).Delete(&models.InterviewInvite{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id = ?", agentID).Delete(&models.Agent{}).Error
+}
+
 func (r *GORMRepository) GetInterviewSessionWithDetails(ctx context.Context, sessionID string, userID string) (*models.InterviewSession, error) {
 	var session models.InterviewSession
 	err := r.db.WithContext(ctx).
@@ -302,7 +740,7 @@ func (r *GORMRepository) GetInterviewSessionWithDetails(ctx context.Context, ses
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get interview session with details", "error", err, "session_id", sessionID, "user_id", userID)
+		logging.FromContext(ctx).Error("Failed to get interview session with details", "error", err, "session_id", sessionID, "user_id", userID)
 		return nil, err
 	}
 	return &session, nil
@@ -318,12 +756,47 @@ func (r *GORMRepository) GetInterviewSession(ctx context.Context, sessionID stri
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get interview session", "error", err, "session_id", sessionID)
+		logging.FromContext(ctx).Error("Failed to get interview session", "error", err, "session_id", sessionID)
 		return nil, err
 	}
 	return &session, nil
 }
 
+// CompleteInterviewSession marks a session completed at endedAt and records its duration,
+// unless it is no longer active. It reports whether the session was completed by this call.
+func (r *GORMRepository) CompleteInterviewSession(ctx context.Context, session *models.InterviewSession, endedAt time.Time) (bool, error) {
+	duration := int(endedAt.Sub(session.StartedAt).Seconds())
+	result := r.db.WithContext(ctx).Model(&models.InterviewSession{}).Where("id = ? AND status = ?", session.ID, "active").Updates(map[string]interface{}{
+		"status":   "completed",
+		"ended_at": endedAt,
+		"duration": duration,
+	})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to complete interview session", "error", result.Error, "session_id", session.ID)
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	session.Status = "completed"
+	session.EndedAt = &endedAt
+	session.Duration = duration
+	return true, nil
+}
+
+// RecordSessionBandwidth stores what a session transferred, never lowering what is stored
+func (r *GORMRepository) RecordSessionBandwidth(ctx context.Context, sessionID string, bytesIn, bytesOut int64) error {
+	err := r.db.WithContext(ctx).Model(&models.InterviewSession{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+		"bytes_in":  gorm.Expr("GREATEST(bytes_in, ?)", bytesIn),
+		"bytes_out": gorm.Expr("GREATEST(bytes_out, ?)", bytesOut),
+	}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to record session bandwidth", "error", err, "session_id", sessionID)
+		return err
+	}
+	return nil
+}
+
 // GetAgent gets an agent by ID
 func (r *GORMRepository) GetAgent(ctx context.Context, agentID string) (*models.Agent, error) {
 	var agent models.Agent
@@ -334,7 +807,7 @@ func (r *GORMRepository) GetAgent(ctx context.Context, agentID string) (*models.
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		slog.Error("Failed to get agent", "error", err, "agent_id", agentID)
+		logging.FromContext(ctx).Error("Failed to get agent", "error", err, "agent_id", agentID)
 		return nil, err
 	}
 	return &agent, nil
@@ -342,39 +815,60 @@ func (r *GORMRepository) GetAgent(ctx context.Context, agentID string) (*models.
 
 // DeleteInterviewSession deletes an interview session and all related data
 func (r *GORMRepository) DeleteInterviewSession(ctx context.Context, sessionID string) error {
+	logger := logging.FromContext(ctx)
+
 	// Start a transaction to ensure all related data is deleted
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Delete performance scores first
 		if err := tx.Where("session_id = ?", sessionID).Delete(&models.PerformanceScore{}).Error; err != nil {
-			slog.Error("Failed to delete performance scores", "error", err, "session_id", sessionID)
+			logger.Error("Failed to delete performance scores", "error", err, "session_id", sessionID)
 			return err
 		}
 
 		// Delete interview summary
 		if err := tx.Where("session_id = ?", sessionID).Delete(&models.InterviewSummary{}).Error; err != nil {
-			slog.Error("Failed to delete interview summary", "error", err, "session_id", sessionID)
+			logger.Error("Failed to delete interview summary", "error", err, "session_id", sessionID)
+			return err
+		}
+
+		// Delete typing metrics of its answers
+		if err := tx.Where("session_id = ?", sessionID).Delete(&models.TurnMetrics{}).Error; err != nil {
+			logger.Error("Failed to delete turn metrics", "error", err, "session_id", sessionID)
+			return err
+		}
+
+		// Delete speech metrics of its answers
+		if err := tx.Where("session_id = ?", sessionID).Delete(&models.SpeechMetrics{}).Error; err != nil {
+			logger.Error("Failed to delete speech metrics", "error", err, "session_id", sessionID)
+			return err
+		}
+
+		// Delete coaching notes on its answers
+		if err := tx.Where("session_id = ?", sessionID).Delete(&models.CoachingNote{}).Error; err != nil {
+			logger.Error("Failed to delete coaching notes", "error", err, "session_id", sessionID)
 			return err
 		}
 
 		// Delete interview transcripts
 		if err := tx.Where("session_id = ?", sessionID).Delete(&models.InterviewTranscript{}).Error; err != nil {
-			slog.Error("Failed to delete interview transcripts", "error", err, "session_id", sessionID)
+			logger.Error("Failed to delete interview transcripts", "error", err, "session_id", sessionID)
 			return err
 		}
 
 		// Finally delete the session itself
 		if err := tx.Where("id = ?", sessionID).Delete(&models.InterviewSession{}).Error; err != nil {
-			slog.Error("Failed to delete interview session", "error", err, "session_id", sessionID)
+			logger.Error("Failed to delete interview session", "error", err, "session_id", sessionID)
 			return err
 		}
 
-		slog.Info("Interview session and related data deleted", "session_id", sessionID)
+		logger.Info("Interview session and related data deleted", "session_id", sessionID)
 		return nil
 	})
 }
 
 // BulkDeleteInterviewSessions deletes multiple interview sessions and all related data
 func (r *GORMRepository) BulkDeleteInterviewSessions(ctx context.Context, sessionIDs []string) (int, error) {
+	logger := logging.FromContext(ctx)
 	if len(sessionIDs) == 0 {
 		return 0, nil
 	}
@@ -384,31 +878,49 @@ func (r *GORMRepository) BulkDeleteInterviewSessions(ctx context.Context, sessio
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Delete performance scores first
 		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&models.PerformanceScore{}).Error; err != nil {
-			slog.Error("Failed to delete performance scores", "error", err, "session_ids", sessionIDs)
+			logger.Error("Failed to delete performance scores", "error", err, "session_ids", sessionIDs)
 			return err
 		}
 
 		// Delete interview summaries
 		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&models.InterviewSummary{}).Error; err != nil {
-			slog.Error("Failed to delete interview summaries", "error", err, "session_ids", sessionIDs)
+			logger.Error("Failed to delete interview summaries", "error", err, "session_ids", sessionIDs)
+			return err
+		}
+
+		// Delete typing metrics of their answers
+		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&models.TurnMetrics{}).Error; err != nil {
+			logger.Error("Failed to delete turn metrics", "error", err, "session_ids", sessionIDs)
+			return err
+		}
+
+		// Delete speech metrics of their answers
+		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&models.SpeechMetrics{}).Error; err != nil {
+			logger.Error("Failed to delete speech metrics", "error", err, "session_ids", sessionIDs)
+			return err
+		}
+
+		// Delete coaching notes on their answers
+		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&models.CoachingNote{}).Error; err != nil {
+			logger.Error("Failed to delete coaching notes", "error", err, "session_ids", sessionIDs)
 			return err
 		}
 
 		// Delete interview transcripts
 		if err := tx.Where("session_id IN ?", sessionIDs).Delete(&models.InterviewTranscript{}).Error; err != nil {
-			slog.Error("Failed to delete interview transcripts", "error", err, "session_ids", sessionIDs)
+			logger.Error("Failed to delete interview transcripts", "error", err, "session_ids", sessionIDs)
 			return err
 		}
 
 		// Finally delete the sessions themselves
 		result := tx.Where("id IN ?", sessionIDs).Delete(&models.InterviewSession{})
 		if result.Error != nil {
-			slog.Error("Failed to delete interview sessions", "error", result.Error, "session_ids", sessionIDs)
+			logger.Error("Failed to delete interview sessions", "error", result.Error, "session_ids", sessionIDs)
 			return result.Error
 		}
 
 		deletedCount = int(result.RowsAffected)
-		slog.Info("Bulk interview sessions and related data deleted", "deleted_count", deletedCount, "session_ids", sessionIDs)
+		logger.Info("Bulk interview sessions and related data deleted", "deleted_count", deletedCount, "session_ids", sessionIDs)
 		return nil
 	})
 
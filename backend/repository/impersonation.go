@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Impersonation operations
+func (r *GORMRepository) CreateImpersonation(ctx context.Context, impersonation *models.Impersonation) error {
+	if err := r.db.WithContext(ctx).Create(impersonation).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create impersonation", "error", err, "admin_id", impersonation.AdminID, "user_id", impersonation.UserID)
+		return err
+	}
+	return nil
+}
+
+// GetActiveImpersonation returns an impersonation that has neither ended nor expired
+func (r *GORMRepository) GetActiveImpersonation(ctx context.Context, impersonationID string) (*models.Impersonation, error) {
+	var impersonation models.Impersonation
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND ended_at IS NULL AND expires_at > ?", impersonationID, time.Now()).
+		First(&impersonation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get impersonation", "error", err, "impersonation_id", impersonationID)
+		return nil, err
+	}
+	return &impersonation, nil
+}
+
+func (r *GORMRepository) GetImpersonations(ctx context.Context, limit int) ([]models.Impersonation, error) {
+	var impersonations []models.Impersonation
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Limit(limit).Find(&impersonations).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get impersonations", "error", err)
+		return nil, err
+	}
+	return impersonations, nil
+}
+
+func (r *GORMRepository) GetImpersonationWithAudit(ctx context.Context, impersonationID string) (*models.Impersonation, error) {
+	var impersonation models.Impersonation
+	err := r.db.WithContext(ctx).
+		Where("id = ?", impersonationID).
+		Preload("AuditLogs", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&impersonation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get impersonation audit", "error", err, "impersonation_id", impersonationID)
+		return nil, err
+	}
+	return &impersonation, nil
+}
+
+// EndImpersonation ends an active impersonation, reporting whether one was ended
+func (r *GORMRepository) EndImpersonation(ctx context.Context, impersonationID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Impersonation{}).
+		Where("id = ? AND ended_at IS NULL", impersonationID).
+		Update("ended_at", time.Now())
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to end impersonation", "error", result.Error, "impersonation_id", impersonationID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GORMRepository) CreateImpersonationAuditEntry(ctx context.Context, entry *models.ImpersonationAuditEntry) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to write impersonation audit entry", "error", err, "impersonation_id", entry.ImpersonationID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Interview invite operations
+func (r *GORMRepository) CreateInterviewInvite(ctx context.Context, invite *models.InterviewInvite) error {
+	if err := r.db.WithContext(ctx).Create(invite).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create interview invite", "error", err, "org_id", invite.OrganizationID)
+		return err
+	}
+	return nil
+}
+
+// GetInterviewInvites returns an organization's interview invites, newest first, with the
+// agent and the session of those started
+func (r *GORMRepository) GetInterviewInvites(ctx context.Context, orgID string) ([]models.InterviewInvite, error) {
+	var invites []models.InterviewInvite
+	err := r.db.WithContext(ctx).
+		Preload("Agent").
+		Preload("Session").
+		Preload("Session.Summary").
+		Where("organization_id = ?", orgID).
+		Order("created_at DESC").
+		Find(&invites).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get interview invites", "error", err, "org_id", orgID)
+		return nil, err
+	}
+	return invites, nil
+}
+
+// GetInterviewInviteByToken returns the unexpired, unstarted interview invite with a token hash
+func (r *GORMRepository) GetInterviewInviteByToken(ctx context.Context, tokenHash string) (*models.InterviewInvite, error) {
+	var invite models.InterviewInvite
+	err := r.db.WithContext(ctx).
+		Preload("Organization").
+		Preload("Agent").
+		Where("token = ? AND started_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&invite).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get interview invite", "error", err)
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// DeleteInterviewInvite revokes an interview invite that hasn't been started, reporting
+// whether there was one
+func (r *GORMRepository) DeleteInterviewInvite(ctx context.Context, orgID, inviteID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND organization_id = ? AND started_at IS NULL", inviteID, orgID).
+		Delete(&models.InterviewInvite{})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to delete interview invite", "error", result.Error, "invite_id", inviteID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// StartInterviewInvite creates the guest account and the session an invite is started with,
+// and marks the invite started. It fails with gorm.ErrRecordNotFound if the invite was started
+// in the meantime.
+func (r *GORMRepository) StartInterviewInvite(ctx context.Context, invite *models.InterviewInvite, guest *models.User, session *models.InterviewSession) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(guest).Error; err != nil {
+			return err
+		}
+		session.UserID = guest.ID
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.InterviewInvite{}).
+			Where("id = ? AND started_at IS NULL", invite.ID).
+			Updates(map[string]interface{}{"started_at": session.StartedAt, "guest_user_id": guest.ID, "session_id": session.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to start interview invite", "error", err, "invite_id", invite.ID)
+		return err
+	}
+	return nil
+}
+
+// ConvertGuestUser turns a guest account into a full one with an email and password,
+// reporting whether the user was a guest
+func (r *GORMRepository) ConvertGuestUser(ctx context.Context, userID, email, passwordHash, fullName string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND role = ?", userID, models.UserRoleGuest).
+		Updates(map[string]interface{}{"email": email, "password": passwordHash, "full_name": fullName, "role": "user"})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to convert guest user", "error", result.Error, "user_id", userID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
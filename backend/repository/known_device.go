@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// Known device operations
+func (r *GORMRepository) HasKnownDevices(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.KnownDevice{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to count known devices", "error", err, "user_id", userID)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RecordKnownDevice stores a device/location sighting, reporting whether it was seen for the first time
+func (r *GORMRepository) RecordKnownDevice(ctx context.Context, device *models.KnownDevice) (bool, error) {
+	logger := logging.FromContext(ctx)
+	device.LastSeenAt = time.Now()
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(device)
+	if result.Error != nil {
+		logger.Error("Failed to record known device", "error", result.Error, "user_id", device.UserID)
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&models.KnownDevice{}).
+		Where("user_id = ? AND fingerprint = ? AND country = ?", device.UserID, device.Fingerprint, device.Country).
+		Updates(map[string]interface{}{
+			"last_ip":      device.LastIP,
+			"last_seen_at": device.LastSeenAt,
+		}).Error
+	if err != nil {
+		logger.Error("Failed to update known device", "error", err, "user_id", device.UserID)
+		return false, err
+	}
+	return false, nil
+}
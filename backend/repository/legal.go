@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// Legal document operations
+func (r *GORMRepository) CreateLegalDocument(ctx context.Context, document *models.LegalDocument) error {
+	logger := logging.FromContext(ctx)
+	if err := r.db.WithContext(ctx).Create(document).Error; err != nil {
+		logger.Error("Failed to create legal document", "error", err, "kind", document.Kind, "version", document.Version)
+		return err
+	}
+	logger.Info("Legal document published", "document_id", document.ID, "kind", document.Kind, "version", document.Version)
+	return nil
+}
+
+func (r *GORMRepository) GetLegalDocuments(ctx context.Context) ([]models.LegalDocument, error) {
+	var documents []models.LegalDocument
+	if err := r.db.WithContext(ctx).Order("published_at DESC").Find(&documents).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get legal documents", "error", err)
+		return nil, err
+	}
+	return documents, nil
+}
+
+// GetCurrentLegalDocuments returns the latest published version of each document kind
+func (r *GORMRepository) GetCurrentLegalDocuments(ctx context.Context) ([]models.LegalDocument, error) {
+	var documents []models.LegalDocument
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (kind) * FROM legal_documents ORDER BY kind, published_at DESC`).
+		Scan(&documents).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get current legal documents", "error", err)
+		return nil, err
+	}
+	return documents, nil
+}
+
+// GetAcceptedDocumentIDs returns which of the given documents the user has accepted
+func (r *GORMRepository) GetAcceptedDocumentIDs(ctx context.Context, userID string, documentIDs []string) ([]string, error) {
+	var accepted []string
+	if len(documentIDs) == 0 {
+		return accepted, nil
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.LegalAcceptance{}).
+		Where("user_id = ? AND document_id IN ?", userID, documentIDs).
+		Pluck("document_id", &accepted).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get accepted legal documents", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return accepted, nil
+}
+
+// CreateLegalAcceptances records acceptances, ignoring documents the user already accepted
+func (r *GORMRepository) CreateLegalAcceptances(ctx context.Context, acceptances []models.LegalAcceptance) error {
+	if len(acceptances) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&acceptances).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to record legal acceptances", "error", err, "user_id", acceptances[0].UserID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetUserLegalAcceptances(ctx context.Context, userID string) ([]models.LegalAcceptance, error) {
+	var acceptances []models.LegalAcceptance
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("accepted_at DESC").Find(&acceptances).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get user legal acceptances", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return acceptances, nil
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Notification operations
+
+// CreateNotification stores a notification unless one was already raised for the same event
+func (r *GORMRepository) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(notification).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create notification", "error", err, "user_id", notification.UserID, "type", notification.Type)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetNotificationByEventID(ctx context.Context, eventID string) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).First(&notification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get notification", "error", err, "event_id", eventID)
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// GetUserNotifications returns a user's notifications, newest first
+func (r *GORMRepository) GetUserNotifications(ctx context.Context, userID string, unreadOnly bool, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("deliver_after IS NULL OR deliver_after <= ?", time.Now())
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get notifications", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// MarkNotificationRead marks a user's notification read, reporting whether it exists
+func (r *GORMRepository) MarkNotificationRead(ctx context.Context, notificationID, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to mark notification read", "error", result.Error, "notification_id", notificationID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnreadNotifications counts a user's unread notifications, leaving out held ones
+func (r *GORMRepository) CountUnreadNotifications(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Where("deliver_after IS NULL OR deliver_after <= ?", time.Now()).
+		Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count unread notifications", "error", err, "user_id", userID)
+		return 0, err
+	}
+	return count, nil
+}
+
+// MarkAllNotificationsRead marks every notification the user can see read, returning how many
+// were unread
+func (r *GORMRepository) MarkAllNotificationsRead(ctx context.Context, userID string) (int64, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Where("deliver_after IS NULL OR deliver_after <= ?", now).
+		Update("read_at", now)
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to mark notifications read", "error", result.Error, "user_id", userID)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// GetDueNotifications returns notifications held back for quiet hours that are due by now and
+// not yet delivered
+func (r *GORMRepository) GetDueNotifications(ctx context.Context, now time.Time, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.WithContext(ctx).
+		Where("delivered_at IS NULL AND emailed_at IS NULL AND deliver_after IS NOT NULL AND deliver_after <= ?", now).
+		Order("deliver_after ASC").
+		Limit(limit).
+		Find(&notifications).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get due notifications", "error", err)
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// HoldNotification defers a notification until deliverAfter
+func (r *GORMRepository) HoldNotification(ctx context.Context, notificationID string, deliverAfter time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("id = ?", notificationID).
+		Update("deliver_after", deliverAfter).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to hold notification", "error", err, "notification_id", notificationID)
+		return err
+	}
+	return nil
+}
+
+// UpdateUserPreferences stores a user's locale, timezone, quiet hours and feedback tone
+func (r *GORMRepository) UpdateUserPreferences(ctx context.Context, user *models.User) error {
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"locale":            user.Locale,
+		"timezone":          user.Timezone,
+		"quiet_hours_start": user.QuietHoursStart,
+		"quiet_hours_end":   user.QuietHoursEnd,
+		"feedback_tone":     user.FeedbackTone,
+		// Accommodations
+		"thinking_time_multiplier": user.ThinkingTimeMultiplier,
+		"time_limit_multiplier":    user.TimeLimitMultiplier,
+		"text_only":                user.TextOnly,
+		"simplified_language":      user.SimplifiedLanguage,
+	}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update preferences", "error", err, "user_id", user.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) MarkNotificationEmailed(ctx context.Context, notificationID string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("id = ?", notificationID).
+		Update("emailed_at", time.Now()).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to mark notification emailed", "error", err, "notification_id", notificationID)
+		return err
+	}
+	return nil
+}
+
+// MarkNotificationDelivered records that a notification was sent over the user's delivery channels
+func (r *GORMRepository) MarkNotificationDelivered(ctx context.Context, notificationID string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("id = ?", notificationID).
+		Update("delivered_at", time.Now()).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to mark notification delivered", "error", err, "notification_id", notificationID)
+		return err
+	}
+	return nil
+}
+
+// GetNotificationSettings returns a user's notification settings, or the defaults if they
+// haven't chosen any
+func (r *GORMRepository) GetNotificationSettings(ctx context.Context, userID string) (*models.NotificationSettings, error) {
+	var settings models.NotificationSettings
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&settings).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.DefaultNotificationSettings(userID), nil
+		}
+		logging.FromContext(ctx).Error("Failed to get notification settings", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveNotificationSettings creates or replaces a user's notification settings
+func (r *GORMRepository) SaveNotificationSettings(ctx context.Context, settings *models.NotificationSettings) error {
+	if err := r.db.WithContext(ctx).Save(settings).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to save notification settings", "error", err, "user_id", settings.UserID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) UpdateNotificationSettings(ctx context.Context, userID string, updates map[string]interface{}) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.NotificationSettings{}).
+		Where("user_id = ?", userID).
+		Updates(updates).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update notification settings", "error", err, "user_id", userID)
+		return err
+	}
+	return nil
+}
+
+// GetDueProgressDigests returns the settings of users whose weekly progress digest is due by now
+func (r *GORMRepository) GetDueProgressDigests(ctx context.Context, now time.Time, limit int) ([]models.NotificationSettings, error) {
+	var settings []models.NotificationSettings
+	err := r.db.WithContext(ctx).
+		Where("weekly_digest AND next_digest_at <= ?", now).
+		Order("next_digest_at ASC").
+		Limit(limit).
+		Find(&settings).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get due progress digests", "error", err)
+		return nil, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,399 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// foreignBankClause matches agents linked to a question bank their owner doesn't own, which
+// they could only use through an organization
+const foreignBankClause = "question_bank_id IN (SELECT id FROM question_banks WHERE question_banks.user_id <> agents.user_id)"
+
+// memberProfile limits a preloaded user to what other members of their organizations may see
+func memberProfile(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "email", "full_name", "avatar_url")
+}
+
+// memberOrgIDs is a subquery of the organizations a user belongs to
+func (r *GORMRepository) memberOrgIDs(userID string) *gorm.DB {
+	return r.db.Model(&models.OrgMembership{}).Select("organization_id").Where("user_id = ?", userID)
+}
+
+// Organization operations
+
+// CreateOrganization creates an organization with its creator as owner
+func (r *GORMRepository) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrgMembership{
+			OrganizationID: org.ID,
+			UserID:         org.CreatedBy,
+			Role:           models.OrgRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create organization", "error", err, "user_id", org.CreatedBy)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetOrganization(ctx context.Context, orgID string) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.db.WithContext(ctx).Where("id = ?", orgID).First(&org).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get organization", "error", err, "org_id", orgID)
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetUserOrgMemberships returns the organizations a user belongs to with their role in each
+func (r *GORMRepository) GetUserOrgMemberships(ctx context.Context, userID string) ([]models.OrgMembership, error) {
+	var memberships []models.OrgMembership
+	err := r.db.WithContext(ctx).
+		Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&memberships).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get user org memberships", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *GORMRepository) GetOrgMembership(ctx context.Context, orgID, userID string) (*models.OrgMembership, error) {
+	var membership models.OrgMembership
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&membership).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get org membership", "error", err, "org_id", orgID, "user_id", userID)
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *GORMRepository) GetOrgMembers(ctx context.Context, orgID string) ([]models.OrgMembership, error) {
+	var memberships []models.OrgMembership
+	err := r.db.WithContext(ctx).
+		Preload("User", memberProfile).
+		Where("organization_id = ?", orgID).
+		Order("created_at").
+		Find(&memberships).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get org members", "error", err, "org_id", orgID)
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *GORMRepository) CountOrgOwners(ctx context.Context, orgID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.OrgMembership{}).
+		Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).
+		Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count org owners", "error", err, "org_id", orgID)
+		return 0, err
+	}
+	return count, nil
+}
+
+// lockOwnersExcept locks the organization's owner memberships until the transaction ends and
+// reports whether removing userID's would leave it without one. Owners stepping down at the
+// same time wait on each other, so the last two can't both go.
+func lockOwnersExcept(tx *gorm.DB, orgID, userID string) (bool, error) {
+	var owners []models.OrgMembership
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).
+		Find(&owners).Error
+	if err != nil {
+		return false, err
+	}
+	isOwner, others := false, false
+	for _, owner := range owners {
+		if owner.UserID == userID {
+			isOwner = true
+		} else {
+			others = true
+		}
+	}
+	return isOwner && !others, nil
+}
+
+// UpdateOrgMemberRole changes a member's role, reporting false without changing it when that
+// would demote the organization's last owner
+func (r *GORMRepository) UpdateOrgMemberRole(ctx context.Context, orgID, userID, role string) (bool, error) {
+	updated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if role != models.OrgRoleOwner {
+			last, err := lockOwnersExcept(tx, orgID, userID)
+			if err != nil || last {
+				return err
+			}
+		}
+		updated = true
+		return tx.Model(&models.OrgMembership{}).
+			Where("organization_id = ? AND user_id = ?", orgID, userID).
+			Update("role", role).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update org member role", "error", err, "org_id", orgID, "user_id", userID)
+		return false, err
+	}
+	return updated, nil
+}
+
+// RemoveOrgMember removes a user from an organization and takes back the agents and question
+// banks they shared with it; agents left linked to a bank only the organization gave them go
+// back to free-form questions. It reports false without removing them when they are the
+// organization's last owner.
+func (r *GORMRepository) RemoveOrgMember(ctx context.Context, orgID, userID string) (bool, error) {
+	removed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		last, err := lockOwnersExcept(tx, orgID, userID)
+		if err != nil || last {
+			return err
+		}
+		removed = true
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrgMembership{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Agent{}).Where("organization_id = ? AND user_id = ?", orgID, userID).
+			Update("organization_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.QuestionBank{}).Where("organization_id = ? AND user_id = ?", orgID, userID).
+			Update("organization_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Agent{}).
+			Where("user_id = ? AND question_bank_id IN (SELECT id FROM question_banks WHERE organization_id = ? AND user_id <> ?)", userID, orgID, userID).
+			Update("question_bank_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Agent{}).
+			Where("user_id <> ? AND question_bank_id IN (SELECT id FROM question_banks WHERE user_id = ? AND organization_id IS NULL)", userID, userID).
+			Update("question_bank_id", nil).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to remove org member", "error", err, "org_id", orgID, "user_id", userID)
+		return false, err
+	}
+	return removed, nil
+}
+
+// Org invite operations
+func (r *GORMRepository) CreateOrgInvite(ctx context.Context, invite *models.OrgInvite) error {
+	if err := r.db.WithContext(ctx).Create(invite).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create org invite", "error", err, "org_id", invite.OrganizationID)
+		return err
+	}
+	return nil
+}
+
+// GetPendingOrgInvites returns the invites to an organization not yet accepted, newest first
+func (r *GORMRepository) GetPendingOrgInvites(ctx context.Context, orgID string) ([]models.OrgInvite, error) {
+	var invites []models.OrgInvite
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND accepted_at IS NULL", orgID).
+		Order("created_at DESC").
+		Find(&invites).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get org invites", "error", err, "org_id", orgID)
+		return nil, err
+	}
+	return invites, nil
+}
+
+// GetOrgInviteByToken returns the unexpired, unaccepted invite with a token hash
+func (r *GORMRepository) GetOrgInviteByToken(ctx context.Context, tokenHash string) (*models.OrgInvite, error) {
+	var invite models.OrgInvite
+	err := r.db.WithContext(ctx).
+		Preload("Organization").
+		Where("token = ? AND accepted_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&invite).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get org invite", "error", err)
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// DeleteOrgInvite revokes a pending invite, reporting whether there was one
+func (r *GORMRepository) DeleteOrgInvite(ctx context.Context, orgID, inviteID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND organization_id = ? AND accepted_at IS NULL", inviteID, orgID).
+		Delete(&models.OrgInvite{})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to delete org invite", "error", result.Error, "invite_id", inviteID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// AcceptOrgInvite adds the user to the invite's organization and marks the invite accepted
+func (r *GORMRepository) AcceptOrgInvite(ctx context.Context, invite *models.OrgInvite, userID string) (*models.OrgMembership, error) {
+	membership := &models.OrgMembership{
+		OrganizationID: invite.OrganizationID,
+		UserID:         userID,
+		Role:           invite.Role,
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.OrgInvite{}).
+			Where("id = ? AND accepted_at IS NULL", invite.ID).
+			Updates(map[string]interface{}{"accepted_at": time.Now(), "accepted_by": userID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(membership).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to accept org invite", "error", err, "invite_id", invite.ID, "user_id", userID)
+		return nil, err
+	}
+	return membership, nil
+}
+
+// Org sharing operations
+
+// SetAgentOrganization shares an agent with an organization, or with a nil orgID takes it back.
+// An agent taken back stops asking a question bank only the organization gave it.
+func (r *GORMRepository) SetAgentOrganization(ctx context.Context, agentID string, orgID *string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Agent{}).Where("id = ?", agentID).Update("organization_id", orgID).Error; err != nil {
+			return err
+		}
+		if orgID != nil {
+			return nil
+		}
+		return tx.Model(&models.Agent{}).Where("id = ? AND "+foreignBankClause, agentID).Update("question_bank_id", nil).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to set agent organization", "error", err, "agent_id", agentID)
+		return err
+	}
+	return nil
+}
+
+// SetQuestionBankOrganization shares a bank with an organization, or with a nil orgID takes it
+// back, unlinking it from the agents of other owners
+func (r *GORMRepository) SetQuestionBankOrganization(ctx context.Context, bank *models.QuestionBank, orgID *string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.QuestionBank{}).Where("id = ?", bank.ID).Update("organization_id", orgID).Error; err != nil {
+			return err
+		}
+		if orgID != nil {
+			return nil
+		}
+		return tx.Model(&models.Agent{}).Where("question_bank_id = ? AND user_id <> ?", bank.ID, bank.UserID).
+			Update("question_bank_id", nil).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to set question bank organization", "error", err, "bank_id", bank.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetOrgAgents(ctx context.Context, orgID string) ([]models.Agent, error) {
+	var agents []models.Agent
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("created_at").Find(&agents).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get org agents", "error", err, "org_id", orgID)
+		return nil, err
+	}
+	return agents, nil
+}
+
+func (r *GORMRepository) GetOrgQuestionBanks(ctx context.Context, orgID string) ([]models.QuestionBank, error) {
+	var banks []models.QuestionBank
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("created_at DESC").Find(&banks).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get org question banks", "error", err, "org_id", orgID)
+		return nil, err
+	}
+	return banks, nil
+}
+
+// GetOrgSessions returns the latest sessions members of an organization had with its agents,
+// and those candidates it invited had
+func (r *GORMRepository) GetOrgSessions(ctx context.Context, orgID string, limit int) ([]models.InterviewSession, error) {
+	var sessions []models.InterviewSession
+	err := r.db.WithContext(ctx).
+		Preload("User", memberProfile).
+		Preload("Agent").
+		Preload("Summary").
+		Where(r.orgSessionIDs(orgID)).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&sessions).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get org sessions", "error", err, "org_id", orgID)
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// GetOrgAgentScoredSessions returns the completed, scored sessions members and invited
+// candidates had with one of the organization's agents, with their summaries and performance
+// scores, latest first. Practice sessions with hints are left out.
+func (r *GORMRepository) GetOrgAgentScoredSessions(ctx context.Context, orgID, agentID string) ([]models.InterviewSession, error) {
+	summarized := r.db.Model(&models.InterviewSummary{}).Select("session_id")
+
+	var sessions []models.InterviewSession
+	err := r.db.WithContext(ctx).
+		Preload("User", memberProfile).
+		Preload("Summary").
+		Preload("PerformanceScores").
+		Where(r.orgSessionIDs(orgID)).
+		Where("agent_id = ? AND status = ? AND coaching = ? AND id IN (?)", agentID, "completed", false, summarized).
+		Order("started_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get org agent sessions", "error", err, "org_id", orgID, "agent_id", agentID)
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// IsOrgSession reports whether an organization's admins can see a session: one a member had
+// with its agents, or one a candidate it invited had
+func (r *GORMRepository) IsOrgSession(ctx context.Context, orgID, sessionID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.InterviewSession{}).
+		Where("id = ?", sessionID).
+		Where(r.orgSessionIDs(orgID)).
+		Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to check org session", "error", err, "org_id", orgID, "session_id", sessionID)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// orgSessionIDs selects the sessions an organization's admins can see: those its members had
+// with its agents, and those candidates it invited had
+func (r *GORMRepository) orgSessionIDs(orgID string) *gorm.DB {
+	agents := r.db.Model(&models.Agent{}).Select("id").Where("organization_id = ?", orgID)
+	members := r.db.Model(&models.OrgMembership{}).Select("user_id").Where("organization_id = ?", orgID)
+	invited := r.db.Model(&models.InterviewInvite{}).Select("session_id").Where("organization_id = ? AND session_id IS NOT NULL", orgID)
+	return r.db.Where("(agent_id IN (?) AND user_id IN (?)) OR id IN (?)", agents, members, invited)
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// Proctoring operations
+func (r *GORMRepository) CreateProctorEvent(ctx context.Context, event *models.ProctorEvent) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create proctor event", "error", err, "session_id", event.SessionID, "kind", event.Kind)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetProctorEvents(ctx context.Context, sessionID string) ([]models.ProctorEvent, error) {
+	var events []models.ProctorEvent
+	if err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).Order("occurred_at ASC").Find(&events).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get proctor events", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Question bank operations
+func (r *GORMRepository) CreateQuestionBank(ctx context.Context, bank *models.QuestionBank) error {
+	if err := r.db.WithContext(ctx).Create(bank).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create question bank", "error", err, "user_id", bank.UserID)
+		return err
+	}
+	return nil
+}
+
+// GetQuestionBank returns a bank with its questions in order
+func (r *GORMRepository) GetQuestionBank(ctx context.Context, bankID string) (*models.QuestionBank, error) {
+	var bank models.QuestionBank
+	err := r.db.WithContext(ctx).
+		Preload("Questions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("id = ?", bankID).
+		First(&bank).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get question bank", "error", err, "bank_id", bankID)
+		return nil, err
+	}
+	return &bank, nil
+}
+
+func (r *GORMRepository) GetUserQuestionBanks(ctx context.Context, userID string) ([]models.QuestionBank, error) {
+	var banks []models.QuestionBank
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&banks).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get user question banks", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return banks, nil
+}
+
+func (r *GORMRepository) UpdateQuestionBank(ctx context.Context, bank *models.QuestionBank) error {
+	err := r.db.WithContext(ctx).Model(bank).Updates(map[string]interface{}{
+		"name":        bank.Name,
+		"description": bank.Description,
+	}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update question bank", "error", err, "bank_id", bank.ID)
+		return err
+	}
+	return nil
+}
+
+// DeleteQuestionBank removes a bank and its questions and unlinks it from any agents using it
+func (r *GORMRepository) DeleteQuestionBank(ctx context.Context, bankID string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Agent{}).Where("question_bank_id = ?", bankID).Update("question_bank_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bank_id = ?", bankID).Delete(&models.Question{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", bankID).Delete(&models.QuestionBank{}).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to delete question bank", "error", err, "bank_id", bankID)
+		return err
+	}
+	return nil
+}
+
+// CreateQuestion appends a question to the end of its bank
+func (r *GORMRepository) CreateQuestion(ctx context.Context, question *models.Question) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last int
+		if err := tx.Model(&models.Question{}).Where("bank_id = ?", question.BankID).
+			Select("COALESCE(MAX(position), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		question.Position = last + 1
+		return tx.Create(question).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create question", "error", err, "bank_id", question.BankID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetQuestion(ctx context.Context, questionID string) (*models.Question, error) {
+	var question models.Question
+	err := r.db.WithContext(ctx).Where("id = ?", questionID).First(&question).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get question", "error", err, "question_id", questionID)
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (r *GORMRepository) UpdateQuestion(ctx context.Context, question *models.Question) error {
+	err := r.db.WithContext(ctx).Model(question).Updates(map[string]interface{}{
+		"text":          question.Text,
+		"topic":         question.Topic,
+		"difficulty":    question.Difficulty,
+		"position":      question.Position,
+		"test_language": question.TestLanguage,
+		"test_suite":    question.TestSuite,
+		"solution":      question.Solution,
+	}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update question", "error", err, "question_id", question.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) DeleteQuestion(ctx context.Context, questionID string) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", questionID).Delete(&models.Question{}).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to delete question", "error", err, "question_id", questionID)
+		return err
+	}
+	return nil
+}
+
+// GetBankQuestions returns a bank's questions in the order they are asked
+func (r *GORMRepository) GetBankQuestions(ctx context.Context, bankID string) ([]models.Question, error) {
+	var questions []models.Question
+	err := r.db.WithContext(ctx).Where("bank_id = ?", bankID).Order("position ASC").Find(&questions).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get bank questions", "error", err, "bank_id", bankID)
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (r *GORMRepository) SetAgentQuestionBank(ctx context.Context, agentID string, bankID *string) error {
+	err := r.db.WithContext(ctx).Model(&models.Agent{}).Where("id = ?", agentID).Update("question_bank_id", bankID).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to set agent question bank", "error", err, "agent_id", agentID)
+		return err
+	}
+	return nil
+}
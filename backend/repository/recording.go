@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Audio recording operations
+func (r *GORMRepository) CreateAudioRecording(ctx context.Context, recording *models.AudioRecording) error {
+	if err := r.db.WithContext(ctx).Create(recording).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create audio recording", "error", err, "session_id", recording.SessionID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetAudioRecording(ctx context.Context, recordingID string) (*models.AudioRecording, error) {
+	var recording models.AudioRecording
+	err := r.db.WithContext(ctx).
+		Where("id = ?", recordingID).
+		First(&recording).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get audio recording", "error", err, "recording_id", recordingID)
+		return nil, err
+	}
+	return &recording, nil
+}
+
+func (r *GORMRepository) GetSessionAudioRecordings(ctx context.Context, sessionID string) ([]models.AudioRecording, error) {
+	var recordings []models.AudioRecording
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("created_at ASC").
+		Find(&recordings).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get session audio recordings", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return recordings, nil
+}
+
+// GetRecordingsDueForArchive returns hot recordings created before createdBefore that
+// have not been restored since restoredBefore
+func (r *GORMRepository) GetRecordingsDueForArchive(ctx context.Context, createdBefore, restoredBefore time.Time, limit int) ([]models.AudioRecording, error) {
+	var recordings []models.AudioRecording
+	err := r.db.WithContext(ctx).
+		Where("tier = ? AND created_at < ?", models.RecordingTierHot, createdBefore).
+		Where("restored_at IS NULL OR restored_at < ?", restoredBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&recordings).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get recordings due for archive", "error", err)
+		return nil, err
+	}
+	return recordings, nil
+}
+
+// GetStalledRestores returns recordings stuck in the restoring tier since before the cutoff (e.g. after a restart)
+func (r *GORMRepository) GetStalledRestores(ctx context.Context, cutoff time.Time, limit int) ([]models.AudioRecording, error) {
+	var recordings []models.AudioRecording
+	err := r.db.WithContext(ctx).
+		Where("tier = ? AND updated_at < ?", models.RecordingTierRestoring, cutoff).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&recordings).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get stalled recording restores", "error", err)
+		return nil, err
+	}
+	return recordings, nil
+}
+
+func (r *GORMRepository) UpdateAudioRecording(ctx context.Context, recordingID string, updates map[string]interface{}) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.AudioRecording{}).
+		Where("id = ?", recordingID).
+		Updates(updates).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update audio recording", "error", err, "recording_id", recordingID)
+		return err
+	}
+	return nil
+}
+
+// BeginRecordingRestore atomically moves a cold recording to the restoring tier.
+// It returns false if the recording was not cold (already restoring or hot).
+func (r *GORMRepository) BeginRecordingRestore(ctx context.Context, recordingID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.AudioRecording{}).
+		Where("id = ? AND tier = ?", recordingID, models.RecordingTierCold).
+		Updates(map[string]interface{}{
+			"tier":       models.RecordingTierRestoring,
+			"last_error": "",
+		})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to begin recording restore", "error", result.Error, "recording_id", recordingID)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+func (r *GORMRepository) CreateRescoreBackfill(ctx context.Context, backfill *models.RescoreBackfill) error {
+	if err := r.db.WithContext(ctx).Create(backfill).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create rescore backfill", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetRescoreBackfill(ctx context.Context, backfillID string) (*models.RescoreBackfill, error) {
+	var backfill models.RescoreBackfill
+	err := r.db.WithContext(ctx).Where("id = ?", backfillID).First(&backfill).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get rescore backfill", "error", err, "backfill_id", backfillID)
+		return nil, err
+	}
+	return &backfill, nil
+}
+
+// GetRescoreBackfills returns the most recent backfills, newest first
+func (r *GORMRepository) GetRescoreBackfills(ctx context.Context, limit int) ([]models.RescoreBackfill, error) {
+	var backfills []models.RescoreBackfill
+	err := r.db.WithContext(ctx).Order("created_at DESC").Limit(limit).Find(&backfills).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get rescore backfills", "error", err)
+		return nil, err
+	}
+	return backfills, nil
+}
+
+// rescoreCandidates selects the completed, summarized sessions a backfill re-scores. With a
+// scoring policy, only sessions scored under an older version of it match, so sessions the
+// backfill already re-scored drop out.
+func (r *GORMRepository) rescoreCandidates(ctx context.Context, backfill *models.RescoreBackfill) *gorm.DB {
+	query := r.db.WithContext(ctx).
+		Table("interview_sessions").
+		Joins("JOIN interview_summaries ON interview_summaries.session_id = interview_sessions.id AND interview_summaries.deleted_at IS NULL").
+		Where("interview_sessions.deleted_at IS NULL AND interview_sessions.status = ? AND interview_sessions.started_at >= ?", "completed", backfill.Since).
+		Where("interview_sessions.import_id IS NULL") // Imported sessions have no transcript to score
+	if backfill.AgentID != nil {
+		query = query.Where("interview_sessions.agent_id = ?", *backfill.AgentID)
+	}
+	if backfill.ScoringPolicyID != nil {
+		query = query.Where("interview_summaries.scoring_policy_id = ? AND interview_summaries.scoring_policy_version < (SELECT version FROM scoring_policies WHERE id = ?)",
+			*backfill.ScoringPolicyID, *backfill.ScoringPolicyID)
+	}
+	return query
+}
+
+// CountRescoreCandidates counts the sessions a backfill would re-score
+func (r *GORMRepository) CountRescoreCandidates(ctx context.Context, backfill *models.RescoreBackfill) (int64, error) {
+	var count int64
+	if err := r.rescoreCandidates(ctx, backfill).Count(&count).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to count rescore candidates", "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// NextRescoreSessions returns the IDs of up to limit sessions after the backfill's cursor
+func (r *GORMRepository) NextRescoreSessions(ctx context.Context, backfill *models.RescoreBackfill, limit int) ([]string, error) {
+	var sessionIDs []string
+	err := r.rescoreCandidates(ctx, backfill).
+		Where("interview_sessions.id::text > ?", backfill.Cursor).
+		Order("interview_sessions.id::text").
+		Limit(limit).
+		Pluck("interview_sessions.id", &sessionIDs).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get rescore sessions", "error", err, "backfill_id", backfill.ID)
+		return nil, err
+	}
+	return sessionIDs, nil
+}
+
+// ClaimRescoreBackfill returns a running backfill that is due to queue more sessions, or nil
+// when none is, pushing its next run back by lease so no other instance claims it meanwhile
+func (r *GORMRepository) ClaimRescoreBackfill(ctx context.Context, lease time.Duration) (*models.RescoreBackfill, error) {
+	var backfills []models.RescoreBackfill
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE rescore_backfills SET next_run_at = ?, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM rescore_backfills
+			WHERE status = ? AND next_run_at <= NOW()
+			ORDER BY next_run_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`, time.Now().Add(lease), models.RescoreBackfillRunning).
+		Scan(&backfills).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to claim rescore backfill", "error", err)
+		return nil, err
+	}
+	if len(backfills) == 0 {
+		return nil, nil
+	}
+	return &backfills[0], nil
+}
+
+// AdvanceRescoreBackfill records sessions queued up to cursor and when the next ones are due
+func (r *GORMRepository) AdvanceRescoreBackfill(ctx context.Context, backfillID, cursor string, queued int, nextRunAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.RescoreBackfill{}).
+		Where("id = ? AND status = ?", backfillID, models.RescoreBackfillRunning).
+		Updates(map[string]interface{}{
+			"cursor":      cursor,
+			"queued":      gorm.Expr("queued + ?", queued),
+			"next_run_at": nextRunAt,
+		}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to advance rescore backfill", "error", err, "backfill_id", backfillID)
+		return err
+	}
+	return nil
+}
+
+// FinishRescoreBackfill moves a running backfill to done or cancelled, reporting whether it was
+// still running. Cancelling drops its jobs that haven't started.
+func (r *GORMRepository) FinishRescoreBackfill(ctx context.Context, backfillID, status string) (bool, error) {
+	finished := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RescoreBackfill{}).
+			Where("id = ? AND status = ?", backfillID, models.RescoreBackfillRunning).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"finished_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		finished = result.RowsAffected == 1
+		if !finished || status != models.RescoreBackfillCancelled {
+			return nil
+		}
+		return tx.Model(&models.SummaryJob{}).
+			Where("backfill_id = ? AND status = ?", backfillID, models.SummaryJobPending).
+			Updates(map[string]interface{}{
+				"status":      models.SummaryJobFailed,
+				"last_error":  "rescore backfill cancelled",
+				"finished_at": time.Now(),
+			}).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to finish rescore backfill", "error", err, "backfill_id", backfillID, "status", status)
+		return false, err
+	}
+	return finished, nil
+}
+
+// GetRescoreJobCounts counts a backfill's summary jobs by status
+func (r *GORMRepository) GetRescoreJobCounts(ctx context.Context, backfillID string) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.SummaryJob{}).
+		Select("status, COUNT(*) AS count").
+		Where("backfill_id = ?", backfillID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count rescore jobs", "error", err, "backfill_id", backfillID)
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// GetSummaryVersions returns a session's superseded summaries, newest first
+func (r *GORMRepository) GetSummaryVersions(ctx context.Context, sessionID string) ([]models.SummaryVersion, error) {
+	var versions []models.SummaryVersion
+	err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).Order("version DESC").Find(&versions).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get summary versions", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return versions, nil
+}
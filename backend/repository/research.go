@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetResearchSessions returns a page of completed sessions held here, ended within [from, to),
+// whose users opted in to research use. Pages are keyed by session ID; pass the last ID seen as afterID.
+func (r *GORMRepository) GetResearchSessions(ctx context.Context, from, to time.Time, afterID string, limit int) ([]models.InterviewSession, error) {
+	query := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = interview_sessions.user_id AND users.deleted_at IS NULL").
+		Where("users.research_opt_in_at IS NOT NULL").
+		Where("interview_sessions.status = ? AND interview_sessions.import_id IS NULL", "completed").
+		Where("interview_sessions.ended_at >= ? AND interview_sessions.ended_at < ?", from, to).
+		Preload("User").
+		Preload("Agent").
+		Preload("Summary").
+		Preload("PerformanceScores").
+		Preload("Transcripts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("turn_order ASC")
+		}).
+		Order("interview_sessions.id ASC").
+		Limit(limit)
+	if afterID != "" {
+		query = query.Where("interview_sessions.id > ?", afterID)
+	}
+
+	var sessions []models.InterviewSession
+	if err := query.Find(&sessions).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get research sessions", "error", err)
+		return nil, err
+	}
+	return sessions, nil
+}
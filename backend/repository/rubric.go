@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetAgentRubric returns the items of an agent's custom scoring rubric in order; none if it
+// has no rubric
+func (r *GORMRepository) GetAgentRubric(ctx context.Context, agentID string) ([]models.RubricItem, error) {
+	var items []models.RubricItem
+	if err := r.db.WithContext(ctx).Where("agent_id = ?", agentID).Order("position ASC").Find(&items).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get agent rubric", "error", err, "agent_id", agentID)
+		return nil, err
+	}
+	return items, nil
+}
+
+// ReplaceAgentRubric replaces an agent's rubric with the given items, numbered in order; no
+// items removes it
+func (r *GORMRepository) ReplaceAgentRubric(ctx context.Context, agentID string, items []models.RubricItem) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("agent_id = ?", agentID).Delete(&models.RubricItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].AgentID = agentID
+			items[i].Position = i
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to replace agent rubric", "error", err, "agent_id", agentID)
+		return err
+	}
+	return nil
+}
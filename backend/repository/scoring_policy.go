@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Scoring policy operations
+func (r *GORMRepository) CreateScoringPolicy(ctx context.Context, policy *models.ScoringPolicy) error {
+	if err := r.db.WithContext(ctx).Create(policy).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create scoring policy", "error", err, "name", policy.Name)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetScoringPolicy(ctx context.Context, policyID string) (*models.ScoringPolicy, error) {
+	var policy models.ScoringPolicy
+	err := r.db.WithContext(ctx).Where("id = ?", policyID).First(&policy).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get scoring policy", "error", err, "policy_id", policyID)
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// GetScoringPolicies returns the policies a user owns, or the site-wide policies when ownerID is nil
+func (r *GORMRepository) GetScoringPolicies(ctx context.Context, ownerID *string) ([]models.ScoringPolicy, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if ownerID == nil {
+		query = query.Where("owner_id IS NULL")
+	} else {
+		query = query.Where("owner_id = ?", *ownerID)
+	}
+
+	var policies []models.ScoringPolicy
+	if err := query.Find(&policies).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get scoring policies", "error", err)
+		return nil, err
+	}
+	return policies, nil
+}
+
+// GetDefaultScoringPolicy returns the site-wide default policy, if one is set
+func (r *GORMRepository) GetDefaultScoringPolicy(ctx context.Context) (*models.ScoringPolicy, error) {
+	var policy models.ScoringPolicy
+	err := r.db.WithContext(ctx).Where("owner_id IS NULL AND is_default").First(&policy).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get default scoring policy", "error", err)
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// UpdateScoringPolicy saves a policy's changes as a new version
+func (r *GORMRepository) UpdateScoringPolicy(ctx context.Context, policy *models.ScoringPolicy) error {
+	policy.Version++
+	if err := r.db.WithContext(ctx).Save(policy).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to update scoring policy", "error", err, "policy_id", policy.ID)
+		return err
+	}
+	return nil
+}
+
+// DeleteScoringPolicy removes a policy and unassigns it from any agents using it
+func (r *GORMRepository) DeleteScoringPolicy(ctx context.Context, policyID string) error {
+	logger := logging.FromContext(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Agent{}).Where("scoring_policy_id = ?", policyID).Update("scoring_policy_id", nil).Error; err != nil {
+			logger.Error("Failed to unassign scoring policy", "error", err, "policy_id", policyID)
+			return err
+		}
+		if err := tx.Delete(&models.ScoringPolicy{}, "id = ?", policyID).Error; err != nil {
+			logger.Error("Failed to delete scoring policy", "error", err, "policy_id", policyID)
+			return err
+		}
+		return nil
+	})
+}
+
+// SetDefaultScoringPolicy makes a site-wide policy the default, replacing any previous default
+func (r *GORMRepository) SetDefaultScoringPolicy(ctx context.Context, policyID string) error {
+	logger := logging.FromContext(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ScoringPolicy{}).Where("owner_id IS NULL AND is_default").Update("is_default", false).Error; err != nil {
+			logger.Error("Failed to clear default scoring policy", "error", err)
+			return err
+		}
+		if err := tx.Model(&models.ScoringPolicy{}).Where("id = ? AND owner_id IS NULL", policyID).Update("is_default", true).Error; err != nil {
+			logger.Error("Failed to set default scoring policy", "error", err, "policy_id", policyID)
+			return err
+		}
+		return nil
+	})
+}
+
+// SetAgentScoringPolicy assigns a policy to an agent; a nil policyID reverts to the site default
+func (r *GORMRepository) SetAgentScoringPolicy(ctx context.Context, agentID string, policyID *string) error {
+	err := r.db.WithContext(ctx).Model(&models.Agent{}).Where("id = ?", agentID).Update("scoring_policy_id", policyID).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to set agent scoring policy", "error", err, "agent_id", agentID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// SearchTranscripts finds the turns of a user's sessions matching a web-style search query
+// ("load balancer", "quoted phrase", or, -excluded), best matches first. headline sets how
+// ts_headline marks the matched words in each snippet.
+func (r *GORMRepository) SearchTranscripts(ctx context.Context, userID, query, headline string, limit int) ([]models.TranscriptMatch, error) {
+	var matches []models.TranscriptMatch
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT t.session_id, t.id AS transcript_id, t.speaker, t.timestamp,
+			ts_headline('english', t.content, q, ?) AS snippet,
+			ts_rank(t.search_vector, q) AS rank,
+			COALESCE(a.name, '') AS agent_name, s.started_at
+		FROM interview_transcripts t
+		JOIN interview_sessions s ON s.id = t.session_id AND s.deleted_at IS NULL
+		LEFT JOIN agents a ON a.id = s.agent_id
+		CROSS JOIN websearch_to_tsquery('english', ?) q
+		WHERE s.user_id = ? AND t.deleted_at IS NULL AND t.search_vector @@ q
+		ORDER BY rank DESC, t.timestamp DESC
+		LIMIT ?`, headline, query, userID, limit).
+		Scan(&matches).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to search transcripts", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return matches, nil
+}
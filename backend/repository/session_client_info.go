@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordSessionClientInfo stores the client a session is held from. A reconnect updates the
+// session's row with the latest client and counts the connection.
+func (r *GORMRepository) RecordSessionClientInfo(ctx context.Context, info *models.SessionClientInfo) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "session_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"user_agent":      info.UserAgent,
+				"browser":         info.Browser,
+				"platform":        info.Platform,
+				"audio_codec":     info.AudioCodec,
+				"connection_type": info.ConnectionType,
+				"connects":        gorm.Expr("session_client_infos.connects + 1"),
+				"updated_at":      time.Now(),
+			}),
+		}).
+		Create(info).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to record session client info", "error", err, "session_id", info.SessionID)
+		return err
+	}
+	return nil
+}
+
+// GetSessionClientInfo returns the client a session was held from, or nil if none was recorded
+func (r *GORMRepository) GetSessionClientInfo(ctx context.Context, sessionID string) (*models.SessionClientInfo, error) {
+	var info models.SessionClientInfo
+	err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).First(&info).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get session client info", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return &info, nil
+}
+
+// RecordSessionTranscription counts a spoken answer of a session sent for transcription, and
+// whether it failed
+func (r *GORMRepository) RecordSessionTranscription(ctx context.Context, sessionID string, failed bool) error {
+	updates := map[string]interface{}{
+		"transcriptions": gorm.Expr("transcriptions + 1"),
+	}
+	if failed {
+		updates["transcription_failures"] = gorm.Expr("transcription_failures + 1")
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.SessionClientInfo{}).
+		Where("session_id = ?", sessionID).
+		Updates(updates).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to record session transcription", "error", err, "session_id", sessionID)
+		return err
+	}
+	return nil
+}
+
+// GetClientTypeStats totals sessions started since a time by browser, platform, audio codec
+// and connection type, with the errors their clients reported and their transcription
+// failures. Client types with the most sessions come first.
+func (r *GORMRepository) GetClientTypeStats(ctx context.Context, since time.Time, limit int) ([]models.ClientTypeStats, error) {
+	var stats []models.ClientTypeStats
+	err := r.db.WithContext(ctx).
+		Model(&models.SessionClientInfo{}).
+		Select(`browser, platform, audio_codec, connection_type,
+			COUNT(*) AS sessions,
+			SUM(connects - 1) AS reconnects,
+			COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM client_errors WHERE client_errors.session_id = session_client_infos.session_id)) AS sessions_with_errors,
+			SUM(transcriptions) AS transcriptions,
+			SUM(transcription_failures) AS transcription_failures`).
+		Where("created_at >= ?", since).
+		Group("browser, platform, audio_codec, connection_type").
+		Order("sessions DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get client type stats", "error", err)
+		return nil, err
+	}
+
+	for i := range stats {
+		if stats[i].Sessions > 0 {
+			stats[i].ErrorRate = float64(stats[i].SessionsWithErrors) / float64(stats[i].Sessions)
+		}
+		if stats[i].Transcriptions > 0 {
+			stats[i].TranscriptionFailRate = float64(stats[i].TranscriptionFailures) / float64(stats[i].Transcriptions)
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// Session flag operations
+func (r *GORMRepository) CreateSessionFlag(ctx context.Context, flag *models.SessionFlag) error {
+	if err := r.db.WithContext(ctx).Create(flag).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create session flag", "error", err, "session_id", flag.SessionID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetSessionFlags(ctx context.Context, sessionID string) ([]models.SessionFlag, error) {
+	var flags []models.SessionFlag
+	if err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).Order("created_at ASC").Find(&flags).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get session flags", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return flags, nil
+}
+
+// GetRecentSessionFlags returns the newest flags of a kind (all kinds when empty) across sessions
+func (r *GORMRepository) GetRecentSessionFlags(ctx context.Context, kind string, limit int) ([]models.SessionFlag, error) {
+	query := r.db.WithContext(ctx)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var flags []models.SessionFlag
+	if err := query.Order("created_at DESC").Limit(limit).Find(&flags).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get session flags", "error", err, "kind", kind)
+		return nil, err
+	}
+	return flags, nil
+}
+
+// GetQuestionSubmissions returns the newest code answers to a question from other users' sessions
+func (r *GORMRepository) GetQuestionSubmissions(ctx context.Context, questionID, excludeUserID string, limit int) ([]models.InterviewTranscript, error) {
+	var transcripts []models.InterviewTranscript
+	err := r.db.WithContext(ctx).
+		Joins("JOIN interview_sessions ON interview_sessions.id = interview_transcripts.session_id").
+		Where("interview_transcripts.question_id = ? AND interview_transcripts.kind = ?", questionID, models.TranscriptKindCode).
+		Where("interview_sessions.user_id <> ?", excludeUserID).
+		Order("interview_transcripts.timestamp DESC").
+		Limit(limit).
+		Find(&transcripts).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get question submissions", "error", err, "question_id", questionID)
+		return nil, err
+	}
+	return transcripts, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+)
+
+// Session token operations
+func (r *GORMRepository) CreateSessionToken(ctx context.Context, token *models.SessionToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create session token", "error", err, "session_id", token.SessionID)
+		return err
+	}
+	return nil
+}
+
+// GetSessionToken returns an unexpired session token by its hash
+func (r *GORMRepository) GetSessionToken(ctx context.Context, tokenHash string) (*models.SessionToken, error) {
+	var token models.SessionToken
+	if err := r.db.WithContext(ctx).Where("token = ? AND expires_at > ?", tokenHash, time.Now()).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get session token", "error", err)
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetSessionTokens lists a user's unexpired tokens for a session
+func (r *GORMRepository) GetSessionTokens(ctx context.Context, sessionID, userID string) ([]models.SessionToken, error) {
+	var tokens []models.SessionToken
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND user_id = ? AND expires_at > ?", sessionID, userID, time.Now()).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get session tokens", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeSessionToken deletes a session token owned by the user, reporting whether it existed
+func (r *GORMRepository) RevokeSessionToken(ctx context.Context, tokenID, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", tokenID, userID).Delete(&models.SessionToken{})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to revoke session token", "error", result.Error, "token_id", tokenID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GORMRepository) TouchSessionToken(ctx context.Context, tokenID string) error {
+	if err := r.db.WithContext(ctx).Model(&models.SessionToken{}).Where("id = ?", tokenID).Update("last_used_at", time.Now()).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to update session token usage", "error", err, "token_id", tokenID)
+		return err
+	}
+	return nil
+}
+
+// Session note operations
+func (r *GORMRepository) CreateSessionNote(ctx context.Context, note *models.SessionNote) error {
+	if err := r.db.WithContext(ctx).Create(note).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create session note", "error", err, "session_id", note.SessionID)
+		return err
+	}
+	return nil
+}
+
+// GetSessionNotes returns a session's notes in the order taken, leaving out the candidate's
+// private notes unless includePrivate
+func (r *GORMRepository) GetSessionNotes(ctx context.Context, sessionID string, includePrivate bool) ([]models.SessionNote, error) {
+	query := r.db.WithContext(ctx).Where("session_id = ?", sessionID)
+	if !includePrivate {
+		query = query.Where("private = ?", false)
+	}
+
+	var notes []models.SessionNote
+	if err := query.Order("created_at ASC").Find(&notes).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get session notes", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return notes, nil
+}
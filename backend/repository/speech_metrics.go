@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+func (r *GORMRepository) CreateSpeechMetrics(ctx context.Context, metrics *models.SpeechMetrics) error {
+	if err := r.db.WithContext(ctx).Create(metrics).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create speech metrics", "error", err, "session_id", metrics.SessionID)
+		return err
+	}
+	return nil
+}
+
+// GetSessionSpeechMetrics returns the speech metrics of a session's answers, in the order they were spoken
+func (r *GORMRepository) GetSessionSpeechMetrics(ctx context.Context, sessionID string) ([]models.SpeechMetrics, error) {
+	var metrics []models.SpeechMetrics
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("created_at").
+		Find(&metrics).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get speech metrics", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return metrics, nil
+}
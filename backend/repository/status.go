@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// QueueBacklog is how many jobs of a queue are waiting for a worker, and since when the oldest
+// has been
+type QueueBacklog struct {
+	Waiting int64
+	Oldest  *time.Time
+}
+
+// GetSummaryJobBacklog counts the pending summary jobs that are due, leaving out jobs held for
+// transcript review or waiting to retry
+func (r *GORMRepository) GetSummaryJobBacklog(ctx context.Context) (QueueBacklog, error) {
+	var backlog QueueBacklog
+	err := r.db.WithContext(ctx).
+		Model(&models.SummaryJob{}).
+		Select("COUNT(*) AS waiting, MIN(run_after) AS oldest").
+		Where("status = ? AND run_after <= NOW()", models.SummaryJobPending).
+		Scan(&backlog).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get summary job backlog", "error", err)
+		return QueueBacklog{}, err
+	}
+	return backlog, nil
+}
+
+// GetExportJobBacklog counts the pending export jobs
+func (r *GORMRepository) GetExportJobBacklog(ctx context.Context) (QueueBacklog, error) {
+	var backlog QueueBacklog
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Select("COUNT(*) AS waiting, MIN(created_at) AS oldest").
+		Where("status = ?", models.ExportJobPending).
+		Scan(&backlog).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get export job backlog", "error", err)
+		return QueueBacklog{}, err
+	}
+	return backlog, nil
+}
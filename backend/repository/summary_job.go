@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EnqueueSummaryJob queues a pending summary job. If the session already has a pending or
+// running job, that job is returned instead of queuing another.
+func (r *GORMRepository) EnqueueSummaryJob(ctx context.Context, job *models.SummaryJob) (*models.SummaryJob, error) {
+	logger := logging.FromContext(ctx)
+	sessionID := job.SessionID
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "session_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status IN ('pending','running')"}}},
+			DoNothing:   true,
+		}).
+		Create(job)
+	if result.Error != nil {
+		logger.Error("Failed to enqueue summary job", "error", result.Error, "session_id", sessionID)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return job, nil
+	}
+
+	// Lost the race to an open job
+	var open models.SummaryJob
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND status IN ?", sessionID, []string{models.SummaryJobPending, models.SummaryJobRunning}).
+		First(&open).Error
+	if err != nil {
+		logger.Error("Failed to get open summary job", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	// A regeneration request still overwrites whatever the open job finds
+	if job.Force && !open.Force {
+		if err := r.db.WithContext(ctx).Model(&open).Update("force", true).Error; err != nil {
+			logger.Error("Failed to force open summary job", "error", err, "job_id", open.ID)
+			return nil, err
+		}
+	}
+	// A backfill tracks the session's job through the open one
+	if job.BackfillID != nil && open.BackfillID == nil {
+		if err := r.db.WithContext(ctx).Model(&open).Update("backfill_id", *job.BackfillID).Error; err != nil {
+			logger.Error("Failed to attach open summary job to backfill", "error", err, "job_id", open.ID)
+			return nil, err
+		}
+	}
+	return &open, nil
+}
+
+// ClaimSummaryJob marks the oldest due pending job as running and returns it, or nil when none
+// is due. SKIP LOCKED lets several workers claim jobs concurrently without blocking each other.
+func (r *GORMRepository) ClaimSummaryJob(ctx context.Context) (*models.SummaryJob, error) {
+	var jobs []models.SummaryJob
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE summary_jobs SET status = ?, attempts = attempts + 1, started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM summary_jobs
+			WHERE status = ? AND run_after <= NOW() AND deleted_at IS NULL
+			ORDER BY run_after
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`, models.SummaryJobRunning, models.SummaryJobPending).
+		Scan(&jobs).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to claim summary job", "error", err)
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// SetSummaryJobStage records the stage a running job has reached
+func (r *GORMRepository) SetSummaryJobStage(ctx context.Context, jobID, stage string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.SummaryJob{}).
+		Where("id = ?", jobID).
+		Update("stage", stage).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update summary job stage", "error", err, "job_id", jobID, "stage", stage)
+		return err
+	}
+	return nil
+}
+
+// FinishSummaryJob records the outcome of an attempt: done, failed for good, or pending again
+// until retryAt. A failed job keeps the stage it failed in.
+func (r *GORMRepository) FinishSummaryJob(ctx context.Context, jobID, status, lastError string, retryAt time.Time) error {
+	updates := map[string]interface{}{
+		"status":     status,
+		"last_error": lastError,
+	}
+	switch status {
+	case models.SummaryJobPending:
+		updates["run_after"] = retryAt
+		updates["stage"] = models.SummaryStageQueued
+	case models.SummaryJobDone:
+		updates["finished_at"] = time.Now()
+		updates["stage"] = models.SummaryStageDone
+	default:
+		updates["finished_at"] = time.Now()
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.SummaryJob{}).
+		Where("id = ?", jobID).
+		Updates(updates).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update summary job", "error", err, "job_id", jobID)
+		return err
+	}
+	return nil
+}
+
+// ReleaseSummaryJob puts a job whose attempt was interrupted, e.g. by a shutdown, back in the
+// queue without counting the attempt
+func (r *GORMRepository) ReleaseSummaryJob(ctx context.Context, jobID string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.SummaryJob{}).
+		Where("id = ? AND status = ?", jobID, models.SummaryJobRunning).
+		Updates(map[string]interface{}{
+			"status":    models.SummaryJobPending,
+			"stage":     models.SummaryStageQueued,
+			"run_after": time.Now(),
+			"attempts":  gorm.Expr("attempts - 1"),
+		}).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to release summary job", "error", err, "job_id", jobID)
+		return err
+	}
+	return nil
+}
+
+// RequeueRunningSummaryJobs puts jobs that were running when the server stopped back in the
+// queue, reporting how many there were
+func (r *GORMRepository) RequeueRunningSummaryJobs(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.SummaryJob{}).
+		Where("status = ?", models.SummaryJobRunning).
+		Updates(map[string]interface{}{
+			"status":    models.SummaryJobPending,
+			"stage":     models.SummaryStageQueued,
+			"run_after": time.Now(),
+		})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to requeue running summary jobs", "error", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// GetLatestSummaryJob returns a session's most recent summary job, or nil if it never had one
+func (r *GORMRepository) GetLatestSummaryJob(ctx context.Context, sessionID string) (*models.SummaryJob, error) {
+	var job models.SummaryJob
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("created_at DESC").
+		First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get summary job", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetSummaryJob returns a summary job by ID, or nil if there is none
+func (r *GORMRepository) GetSummaryJob(ctx context.Context, jobID string) (*models.SummaryJob, error) {
+	var job models.SummaryJob
+	err := r.db.WithContext(ctx).Where("id = ?", jobID).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get summary job", "error", err, "job_id", jobID)
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SetReviewTranscripts replaces the transcripts of a job held for review, if it is still held
+// and its transcripts are still previous, so concurrent corrections don't overwrite each other
+func (r *GORMRepository) SetReviewTranscripts(ctx context.Context, jobID, previous, transcripts string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.SummaryJob{}).
+		Where("id = ? AND status = ? AND stage = ? AND transcripts = ?::jsonb", jobID, models.SummaryJobPending, models.SummaryStageReview, previous).
+		Update("transcripts", transcripts)
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to update review transcripts", "error", result.Error, "job_id", jobID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseReviewedSummaryJob makes a job held for review due now, reporting whether it was held
+func (r *GORMRepository) ReleaseReviewedSummaryJob(ctx context.Context, jobID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.SummaryJob{}).
+		Where("id = ? AND status = ? AND stage = ?", jobID, models.SummaryJobPending, models.SummaryStageReview).
+		Updates(map[string]interface{}{
+			"stage":     models.SummaryStageQueued,
+			"run_after": time.Now(),
+		})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to release reviewed summary job", "error", result.Error, "job_id", jobID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
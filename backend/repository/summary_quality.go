@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// summaryQualityAggregates is the shared SELECT list used by the quality stats queries
+const summaryQualityAggregates = `COUNT(*) AS sample_count,
+	COALESCE(AVG(quality_score), 0) AS avg_quality_score,
+	COALESCE(AVG(CASE WHEN json_valid THEN 1.0 ELSE 0.0 END), 0) AS json_valid_rate,
+	COALESCE(AVG(CASE WHEN length_within_bounds THEN 1.0 ELSE 0.0 END), 0) AS length_within_rate,
+	COALESCE(AVG(section_completeness), 0) AS avg_completeness,
+	COALESCE(AVG(CASE WHEN hallucinated_names <> '' THEN 1.0 ELSE 0.0 END), 0) AS hallucination_rate,
+	MIN(created_at) AS first_seen,
+	MAX(created_at) AS last_seen`
+
+// Summary quality operations
+func (r *GORMRepository) CreateSummaryQuality(ctx context.Context, quality *models.SummaryQuality) error {
+	if err := r.db.WithContext(ctx).Create(quality).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create summary quality record", "error", err, "summary_id", quality.SummaryID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetSummaryQuality(ctx context.Context, summaryID string) (*models.SummaryQuality, error) {
+	var quality models.SummaryQuality
+	err := r.db.WithContext(ctx).Where("summary_id = ?", summaryID).First(&quality).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get summary quality", "error", err, "summary_id", summaryID)
+		return nil, err
+	}
+	return &quality, nil
+}
+
+// GetSummaryQualityStats aggregates all quality records grouped by prompt version and model
+func (r *GORMRepository) GetSummaryQualityStats(ctx context.Context) ([]models.SummaryQualityStats, error) {
+	var stats []models.SummaryQualityStats
+	err := r.db.WithContext(ctx).
+		Model(&models.SummaryQuality{}).
+		Select("prompt_version, model, " + summaryQualityAggregates).
+		Group("prompt_version, model").
+		Order("last_seen DESC").
+		Scan(&stats).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get summary quality stats", "error", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetRecentSummaryQualityStats aggregates the most recent records for a prompt version and model pair
+func (r *GORMRepository) GetRecentSummaryQualityStats(ctx context.Context, promptVersion, model string, limit int) (*models.SummaryQualityStats, error) {
+	recent := r.db.WithContext(ctx).
+		Model(&models.SummaryQuality{}).
+		Where("prompt_version = ? AND model = ?", promptVersion, model).
+		Order("created_at DESC").
+		Limit(limit)
+
+	return r.aggregateSummaryQuality(ctx, recent, promptVersion, model)
+}
+
+// GetBaselineSummaryQualityStats aggregates the most recent records produced by any other prompt version or model
+func (r *GORMRepository) GetBaselineSummaryQualityStats(ctx context.Context, promptVersion, model string, limit int) (*models.SummaryQualityStats, error) {
+	baseline := r.db.WithContext(ctx).
+		Model(&models.SummaryQuality{}).
+		Where("NOT (prompt_version = ? AND model = ?)", promptVersion, model).
+		Order("created_at DESC").
+		Limit(limit)
+
+	return r.aggregateSummaryQuality(ctx, baseline, "baseline", "")
+}
+
+func (r *GORMRepository) aggregateSummaryQuality(ctx context.Context, subquery *gorm.DB, promptVersion, model string) (*models.SummaryQualityStats, error) {
+	var stats models.SummaryQualityStats
+	err := r.db.WithContext(ctx).
+		Table("(?) AS recent", subquery).
+		Select(summaryQualityAggregates).
+		Scan(&stats).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to aggregate summary quality", "error", err, "prompt_version", promptVersion, "model", model)
+		return nil, err
+	}
+	stats.PromptVersion = promptVersion
+	stats.Model = model
+	return &stats, nil
+}
+
+// SaveSummaryQualityAlert stores a raised alert, reporting whether it is new. An alert already
+// open for the same prompt version, model and reason is refreshed with its latest stats.
+func (r *GORMRepository) SaveSummaryQualityAlert(ctx context.Context, alert *models.SummaryQualityAlert) (bool, error) {
+	logger := logging.FromContext(ctx)
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	if result.Error != nil {
+		logger.Error("Failed to save summary quality alert", "error", result.Error, "prompt_version", alert.PromptVersion, "model", alert.Model)
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	err := r.db.WithContext(ctx).Model(&models.SummaryQualityAlert{}).
+		Where("prompt_version = ? AND model = ? AND reason = ?", alert.PromptVersion, alert.Model, alert.Reason).
+		Select("current", "baseline", "raised_at").
+		Updates(alert).Error
+	if err != nil {
+		logger.Error("Failed to refresh summary quality alert", "error", err, "prompt_version", alert.PromptVersion, "model", alert.Model)
+		return false, err
+	}
+	return false, nil
+}
+
+// GetSummaryQualityAlerts lists the open summary quality alerts, most recently raised first
+func (r *GORMRepository) GetSummaryQualityAlerts(ctx context.Context) ([]models.SummaryQualityAlert, error) {
+	var alerts []models.SummaryQualityAlert
+	if err := r.db.WithContext(ctx).Order("raised_at DESC").Find(&alerts).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get summary quality alerts", "error", err)
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// DeleteSummaryQualityAlerts clears the summary quality alerts last raised before the given time
+func (r *GORMRepository) DeleteSummaryQualityAlerts(ctx context.Context, raisedBefore time.Time) error {
+	if err := r.db.WithContext(ctx).Where("raised_at <= ?", raisedBefore).Delete(&models.SummaryQualityAlert{}).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to delete summary quality alerts", "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+func (r *GORMRepository) CreateTurnMetrics(ctx context.Context, metrics *models.TurnMetrics) error {
+	if err := r.db.WithContext(ctx).Create(metrics).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create turn metrics", "error", err, "session_id", metrics.SessionID)
+		return err
+	}
+	return nil
+}
+
+// GetSessionTurnMetrics returns the typing metrics of a session's answers, in the order they were sent
+func (r *GORMRepository) GetSessionTurnMetrics(ctx context.Context, sessionID string) ([]models.TurnMetrics, error) {
+	var metrics []models.TurnMetrics
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("created_at").
+		Find(&metrics).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get turn metrics", "error", err, "session_id", sessionID)
+		return nil, err
+	}
+	return metrics, nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Webhook subscription operations
+func (r *GORMRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	if err := r.db.WithContext(ctx).Create(webhook).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to create webhook", "error", err, "user_id", webhook.UserID)
+		return err
+	}
+	return nil
+}
+
+func (r *GORMRepository) GetWebhook(ctx context.Context, webhookID string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.WithContext(ctx).Where("id = ?", webhookID).First(&webhook).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logging.FromContext(ctx).Error("Failed to get webhook", "error", err, "webhook_id", webhookID)
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *GORMRepository) GetUserWebhooks(ctx context.Context, userID string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&webhooks).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get user webhooks", "error", err, "user_id", userID)
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *GORMRepository) CountUserWebhooks(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Webhook{}).Where("user_id = ?", userID).Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count user webhooks", "error", err, "user_id", userID)
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetAgentWebhooks returns the owner's enabled webhooks covering an agent's sessions: those for
+// every agent and those for this one
+func (r *GORMRepository) GetAgentWebhooks(ctx context.Context, userID, agentID string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND enabled AND (agent_id IS NULL OR agent_id = ?)", userID, agentID).
+		Find(&webhooks).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get agent webhooks", "error", err, "user_id", userID, "agent_id", agentID)
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *GORMRepository) SaveWebhook(ctx context.Context, webhook *models.Webhook) error {
+	if err := r.db.WithContext(ctx).Save(webhook).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to save webhook", "error", err, "webhook_id", webhook.ID)
+		return err
+	}
+	return nil
+}
+
+// DeleteWebhook removes a webhook; its pending deliveries fail when they come due
+func (r *GORMRepository) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", webhookID).Delete(&models.Webhook{}).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to delete webhook", "error", err, "webhook_id", webhookID)
+		return err
+	}
+	return nil
+}
+
+// Webhook delivery operations
+
+// CreateWebhookDeliveries queues deliveries, skipping any of an event already queued for the
+// same webhook, so a redelivered event isn't sent twice. It returns how many were queued.
+func (r *GORMRepository) CreateWebhookDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) (int64, error) {
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "webhook_id"}, {Name: "event_id"}},
+			DoNothing: true,
+		}).
+		Create(&deliveries)
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to create webhook deliveries", "error", result.Error, "event_id", deliveries[0].EventID)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// ClaimWebhookDelivery takes the oldest due pending delivery, counting the attempt and leasing
+// it until now+lease so a crashed attempt is retried, or returns nil when none is due. SKIP
+// LOCKED lets several instances deliver concurrently.
+func (r *GORMRepository) ClaimWebhookDelivery(ctx context.Context, lease time.Duration) (*models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt_at = ?, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`, time.Now().Add(lease), models.WebhookDeliveryPending).
+		Scan(&deliveries).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to claim webhook delivery", "error", err)
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	return &deliveries[0], nil
+}
+
+// FinishWebhookDelivery records the outcome of an attempt: delivered, failed for good, or
+// pending again until retryAt
+func (r *GORMRepository) FinishWebhookDelivery(ctx context.Context, deliveryID, status string, statusCode int, lastError string, retryAt time.Time) error {
+	updates := map[string]interface{}{
+		"status":      status,
+		"status_code": statusCode,
+		"last_error":  lastError,
+	}
+	switch status {
+	case models.WebhookDeliveryPending:
+		updates["next_attempt_at"] = retryAt
+	case models.WebhookDeliveryDelivered:
+		updates["delivered_at"] = time.Now()
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.WebhookDelivery{}).
+		Where("id = ?", deliveryID).
+		Updates(updates).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update webhook delivery", "error", err, "delivery_id", deliveryID)
+		return err
+	}
+	return nil
+}
+
+// GetWebhookDeliveries returns a webhook's most recent deliveries, newest first, optionally
+// filtered by status
+func (r *GORMRepository) GetWebhookDeliveries(ctx context.Context, webhookID, status string, limit int) ([]models.WebhookDelivery, error) {
+	query := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		logging.FromContext(ctx).Error("Failed to get webhook deliveries", "error", err, "webhook_id", webhookID)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RetryWebhookDelivery queues a webhook's delivery for another round of attempts, reporting
+// whether there was such a delivery
+func (r *GORMRepository) RetryWebhookDelivery(ctx context.Context, webhookID, deliveryID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.WebhookDelivery{}).
+		Where("id = ? AND webhook_id = ?", deliveryID, webhookID).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookDeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Failed to retry webhook delivery", "error", result.Error, "delivery_id", deliveryID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const (
+	// MaxThinkingTimeMultiplier caps how far extra thinking time stretches an interview's waits
+	MaxThinkingTimeMultiplier = 3
+	// MaxTimeLimitMultiplier caps how far an interview's time limit is stretched
+	MaxTimeLimitMultiplier = 2
+	// defaultEmptyResponseLimit is how many empty or unintelligible answers in a row end an interview
+	defaultEmptyResponseLimit = 3
+)
+
+// Accommodations are a candidate's accessibility settings, honored in every interview they take
+type Accommodations struct {
+	ThinkingTime       float64 // Stretches the inactivity timeout, closing question window and empty-answer allowance
+	TimeLimit          float64 // Stretches the interview's time limit
+	TextOnly           bool    // Replies are sent as text, without speech
+	SimplifiedLanguage bool    // The interviewer uses plain, short sentences
+}
+
+// noAccommodations applies to sessions whose candidate asked for none
+var noAccommodations = Accommodations{ThinkingTime: 1, TimeLimit: 1}
+
+// UserAccommodations returns a user's accommodations, treating unset multipliers as 1
+func UserAccommodations(user *models.User) Accommodations {
+	accommodations := noAccommodations
+	if user == nil {
+		return accommodations
+	}
+	if user.ThinkingTimeMultiplier > 1 {
+		accommodations.ThinkingTime = math.Min(user.ThinkingTimeMultiplier, MaxThinkingTimeMultiplier)
+	}
+	if user.TimeLimitMultiplier > 1 {
+		accommodations.TimeLimit = math.Min(user.TimeLimitMultiplier, MaxTimeLimitMultiplier)
+	}
+	accommodations.TextOnly = user.TextOnly
+	accommodations.SimplifiedLanguage = user.SimplifiedLanguage
+	return accommodations
+}
+
+// ValidateAccommodations checks the multipliers a user asks for; 0 means none
+func ValidateAccommodations(thinkingTime, timeLimit float64) error {
+	if thinkingTime != 0 && (thinkingTime < 1 || thinkingTime > MaxThinkingTimeMultiplier) {
+		return fmt.Errorf("thinking_time_multiplier must be between 1 and %d", MaxThinkingTimeMultiplier)
+	}
+	if timeLimit != 0 && (timeLimit < 1 || timeLimit > MaxTimeLimitMultiplier) {
+		return fmt.Errorf("time_limit_multiplier must be between 1 and %d", MaxTimeLimitMultiplier)
+	}
+	return nil
+}
+
+// EmptyResponseLimit is how many empty or unintelligible answers in a row end the interview
+func (a Accommodations) EmptyResponseLimit() int {
+	return int(math.Ceil(defaultEmptyResponseLimit * max(a.ThinkingTime, 1)))
+}
+
+// Think stretches a wait on the candidate by their extra thinking time
+func (a Accommodations) Think(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * max(a.ThinkingTime, 1))
+}
+
+// Limit stretches an interview's time limit
+func (a Accommodations) Limit(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * max(a.TimeLimit, 1))
+}
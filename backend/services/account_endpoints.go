@@ -0,0 +1,164 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+type AccountEndpoints struct {
+	purge       *DataPurgeService
+	exports     *ExportJobService
+	interviews  *InterviewInviteService
+	authService *AuthService
+}
+
+// DeleteAccountRequest confirms an account deletion with the user's password
+type DeleteAccountRequest struct {
+	Password string `json:"password"` // Guests, who have none, send nothing
+}
+
+// ConvertGuestRequest turns the guest account of an invited candidate into a full account
+type ConvertGuestRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,maxbytes=72"` // bcrypt rejects passwords over 72 bytes
+	FullName string `json:"full_name" validate:"max=255"`
+}
+
+func NewAccountEndpoints(purge *DataPurgeService, exports *ExportJobService, interviews *InterviewInviteService, authService *AuthService) *AccountEndpoints {
+	return &AccountEndpoints{
+		purge:       purge,
+		exports:     exports,
+		interviews:  interviews,
+		authService: authService,
+	}
+}
+
+func (e *AccountEndpoints) RegisterRoutes(r chi.Router) {
+	r.Delete("/users/me", e.DeleteAccountHandler)
+	r.Get("/users/me/export", e.GetAccountExportHandler)
+	r.Post("/users/me/export", e.ExportAccountHandler)
+	r.Post("/users/me/convert", e.ConvertGuestHandler)
+}
+
+// ConvertGuestHandler lets a candidate who took an interview they were invited to as a guest
+// keep their account, with an email and password to sign in with
+func (e *AccountEndpoints) ConvertGuestHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req ConvertGuestRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := e.interviews.Convert(r.Context(), user, req); err != nil {
+		if errors.Is(err, ErrNotGuest) || errors.Is(err, ErrEmailTaken) {
+			apperrors.Write(w, r, apperrors.Conflict(err.Error()))
+		} else {
+			apperrors.Write(w, r, apperrors.Internal("Failed to convert account"))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Account created",
+	})
+}
+
+// ExportAccountHandler queues an archive of all the user's data: their profile, agent
+// definitions and every session with its transcript, summary and scores as JSON. The archive is
+// built by the export workers; while one is queued, being built or ready to download, it is
+// returned rather than queuing another. Admins impersonating the user can't export their data.
+func (e *AccountEndpoints) ExportAccountHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+	if _, impersonated := r.Context().Value("impersonation").(*models.Impersonation); impersonated {
+		apperrors.Write(w, r, apperrors.Forbidden("Account data can't be exported while impersonating"))
+		return
+	}
+
+	job, queued, err := e.exports.AccountExport(r.Context(), user)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	e.writeAccountExport(w, job, queued)
+}
+
+// GetAccountExportHandler returns the user's latest account export: its progress, then a signed
+// download URL until the archive expires
+func (e *AccountEndpoints) GetAccountExportHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	job, err := e.exports.LatestAccountExport(r.Context(), user)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get account export"))
+		return
+	}
+	if job == nil {
+		apperrors.Write(w, r, apperrors.NotFound("No account export has been requested"))
+		return
+	}
+	e.writeAccountExport(w, job, false)
+}
+
+// writeAccountExport answers with an account export, as 202 with a Retry-After until it is built
+func (e *AccountEndpoints) writeAccountExport(w http.ResponseWriter, job *models.ExportJob, queued bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if job.Status == models.ExportJobPending || job.Status == models.ExportJobRunning {
+		w.Header().Set("Retry-After", strconv.Itoa(int(exportJobPollInterval/time.Second)))
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"export": e.exports.Status(job),
+		"queued": queued,
+	})
+}
+
+// DeleteAccountHandler permanently deletes the user's account and all their data, and signs
+// them out
+func (e *AccountEndpoints) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+	if _, impersonated := r.Context().Value("impersonation").(*models.Impersonation); impersonated {
+		apperrors.Write(w, r, apperrors.Forbidden("Accounts can't be deleted while impersonating"))
+		return
+	}
+
+	var req DeleteAccountRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := e.purge.DeleteAccount(r.Context(), user, req.Password); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	e.authService.ClearAuthCookies(w)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Account deleted",
+	})
+}
@@ -0,0 +1,717 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// AdminEndpoints exposes operational endpoints restricted to admin users
+type AdminEndpoints struct {
+	repo              *repository.GORMRepository
+	qualityService    *SummaryQualityService
+	experimentService *ExperimentService
+	legalService      *LegalService
+	impersonation     *ImpersonationService
+	research          *ResearchExportService
+	agentHealth       *AgentHealthService
+	tempFiles         *TempFiles
+	transcodes        *TranscodePool
+	hub               *ws.Hub
+	rescore           *RescoreService
+}
+
+type ScoringExperimentRequest struct {
+	Name           string   `json:"name" validate:"required"`
+	Description    string   `json:"description"`
+	PromptTemplate string   `json:"prompt_template" validate:"required"`
+	SampleRate     *float64 `json:"sample_rate"`
+	IsActive       *bool    `json:"is_active"`
+}
+
+type LegalDocumentRequest struct {
+	Kind    string `json:"kind" validate:"required"` // terms, privacy
+	Version string `json:"version" validate:"required"`
+	URL     string `json:"url" validate:"required"`
+	Summary string `json:"summary"`
+}
+
+type ImpersonateRequest struct {
+	Reason     string `json:"reason" validate:"required"`
+	AllowWrite bool   `json:"allow_write"` // Read-only unless set
+	TTLMinutes int    `json:"ttl_minutes"`
+}
+
+func NewAdminEndpoints(repo *repository.GORMRepository, qualityService *SummaryQualityService, experimentService *ExperimentService, legalService *LegalService, impersonation *ImpersonationService, research *ResearchExportService, agentHealth *AgentHealthService, tempFiles *TempFiles, transcodes *TranscodePool, hub *ws.Hub, rescore *RescoreService) *AdminEndpoints {
+	return &AdminEndpoints{
+		repo:              repo,
+		qualityService:    qualityService,
+		experimentService: experimentService,
+		legalService:      legalService,
+		impersonation:     impersonation,
+		research:          research,
+		agentHealth:       agentHealth,
+		tempFiles:         tempFiles,
+		transcodes:        transcodes,
+		hub:               hub,
+		rescore:           rescore,
+	}
+}
+
+func (e *AdminEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Get("/summary-quality", e.GetSummaryQualityHandler)
+		r.Delete("/summary-quality/alerts", e.ClearSummaryQualityAlertsHandler)
+		r.Get("/summary-quality/{summaryID}", e.GetSummaryQualityBySummaryHandler)
+
+		r.Route("/experiments", func(r chi.Router) {
+			r.Post("/", e.CreateExperimentHandler)
+			r.Get("/", e.GetExperimentsHandler)
+			r.Put("/{id}", e.UpdateExperimentHandler)
+			r.Get("/{id}/comparison", e.GetExperimentComparisonHandler)
+		})
+
+		r.Post("/legal-documents", e.PublishLegalDocumentHandler)
+		r.Get("/legal-documents", e.GetLegalDocumentsHandler)
+
+		r.Post("/impersonate/{userID}", e.ImpersonateHandler)
+		r.Get("/impersonations", e.GetImpersonationsHandler)
+		r.Get("/impersonations/{id}", e.GetImpersonationHandler)
+		r.Post("/impersonations/{id}/end", e.EndImpersonationHandler)
+
+		r.Get("/research-dataset", e.ExportResearchDatasetHandler)
+
+		r.Get("/session-flags", e.GetSessionFlagsHandler)
+
+		r.Get("/client-errors", e.GetClientErrorStatsHandler)
+		r.Get("/client-errors/sessions/{id}", e.GetSessionClientErrorsHandler)
+		r.Get("/client-types", e.GetClientTypeStatsHandler)
+
+		r.Get("/temp-files", e.GetTempFileStatsHandler)
+		r.Get("/transcodes", e.GetTranscodeStatsHandler)
+		r.Get("/connections", e.GetConnectionsHandler)
+
+		r.Get("/agent-health", e.GetAgentHealthHandler)
+		r.Post("/agent-health/refresh", e.RefreshAgentHealthHandler)
+		r.Post("/agents/{id}/purge", e.PurgeAgentHandler)
+
+		r.Route("/scoring-policies", func(r chi.Router) {
+			r.Get("/", e.GetSiteScoringPoliciesHandler)
+			r.Post("/", e.CreateSiteScoringPolicyHandler)
+			r.Put("/{id}", e.UpdateSiteScoringPolicyHandler)
+			r.Delete("/{id}", e.DeleteSiteScoringPolicyHandler)
+			r.Post("/{id}/default", e.SetDefaultScoringPolicyHandler)
+		})
+
+		r.Route("/rescores", func(r chi.Router) {
+			r.Get("/", e.GetRescoreBackfillsHandler)
+			r.Post("/", e.StartRescoreBackfillHandler)
+			r.Get("/{id}", e.GetRescoreBackfillHandler)
+			r.Post("/{id}/cancel", e.CancelRescoreBackfillHandler)
+		})
+
+		r.Route("/compliance", func(r chi.Router) {
+			r.Get("/topics", e.GetSiteBannedTopicsHandler)
+			r.Post("/topics", e.CreateSiteBannedTopicHandler)
+			r.Put("/topics/{id}", e.UpdateSiteBannedTopicHandler)
+			r.Delete("/topics/{id}", e.DeleteSiteBannedTopicHandler)
+			r.Get("/violations", e.GetAllViolationsHandler)
+		})
+	})
+}
+
+func (e *AdminEndpoints) GetSummaryQualityHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := e.qualityService.GetStats(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get summary quality stats", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to get summary quality stats"))
+		return
+	}
+	alerts, err := e.qualityService.GetAlerts(r.Context())
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get summary quality alerts"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"current_prompt_version": SummaryPromptVersion,
+		"current_model":          ModelName,
+		"stats":                  stats,
+		"alerts":                 alerts,
+	})
+}
+
+func (e *AdminEndpoints) GetSummaryQualityBySummaryHandler(w http.ResponseWriter, r *http.Request) {
+	summaryID := chi.URLParam(r, "summaryID")
+	if summaryID == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("Summary ID is required"))
+		return
+	}
+
+	quality, err := e.repo.GetSummaryQuality(r.Context(), summaryID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get summary quality"))
+		return
+	}
+	if quality == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Summary quality not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"quality": quality,
+	})
+}
+
+func (e *AdminEndpoints) ClearSummaryQualityAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := e.qualityService.ClearAlerts(r.Context()); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to clear summary quality alerts"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Summary quality alerts cleared",
+	})
+
+	logging.FromContext(r.Context()).Info("Summary quality alerts cleared")
+}
+
+func (e *AdminEndpoints) CreateExperimentHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user from context (set by auth middleware)
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req ScoringExperimentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	if req.Name == "" || req.PromptTemplate == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("Name and prompt template are required"))
+		return
+	}
+
+	if err := ValidatePromptTemplate(req.PromptTemplate); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	experiment := models.ScoringExperiment{
+		Name:           req.Name,
+		Description:    req.Description,
+		PromptTemplate: req.PromptTemplate,
+		SampleRate:     0.1,
+		IsActive:       true,
+		CreatedBy:      user.ID,
+	}
+	if req.SampleRate != nil {
+		if *req.SampleRate < 0 || *req.SampleRate > 1 {
+			apperrors.Write(w, r, apperrors.BadRequest("Sample rate must be between 0 and 1"))
+			return
+		}
+		experiment.SampleRate = *req.SampleRate
+	}
+	if req.IsActive != nil {
+		experiment.IsActive = *req.IsActive
+	}
+
+	if err := e.repo.CreateScoringExperiment(r.Context(), &experiment); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create experiment"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"experiment": experiment,
+		"message":    "Experiment created successfully",
+	})
+
+	logging.FromContext(r.Context()).Info("Scoring experiment created", "experiment_id", experiment.ID)
+}
+
+func (e *AdminEndpoints) GetExperimentsHandler(w http.ResponseWriter, r *http.Request) {
+	experiments, err := e.repo.GetScoringExperiments(r.Context(), false)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get experiments"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"experiments": experiments,
+		"count":       len(experiments),
+	})
+}
+
+func (e *AdminEndpoints) UpdateExperimentHandler(w http.ResponseWriter, r *http.Request) {
+	experimentID := chi.URLParam(r, "id")
+	if experimentID == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("Experiment ID is required"))
+		return
+	}
+
+	experiment, err := e.repo.GetScoringExperiment(r.Context(), experimentID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get experiment"))
+		return
+	}
+	if experiment == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Experiment not found"))
+		return
+	}
+
+	var req ScoringExperimentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	// Only overwrite the fields that were provided
+	if req.Name != "" {
+		experiment.Name = req.Name
+	}
+	if req.Description != "" {
+		experiment.Description = req.Description
+	}
+	if req.PromptTemplate != "" {
+		if err := ValidatePromptTemplate(req.PromptTemplate); err != nil {
+			apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+			return
+		}
+		experiment.PromptTemplate = req.PromptTemplate
+	}
+	if req.SampleRate != nil {
+		if *req.SampleRate < 0 || *req.SampleRate > 1 {
+			apperrors.Write(w, r, apperrors.BadRequest("Sample rate must be between 0 and 1"))
+			return
+		}
+		experiment.SampleRate = *req.SampleRate
+	}
+	if req.IsActive != nil {
+		experiment.IsActive = *req.IsActive
+	}
+
+	if err := e.repo.UpdateScoringExperiment(r.Context(), experiment); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update experiment"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"experiment": experiment,
+		"message":    "Experiment updated successfully",
+	})
+
+	logging.FromContext(r.Context()).Info("Scoring experiment updated", "experiment_id", experiment.ID, "is_active", experiment.IsActive)
+}
+
+func (e *AdminEndpoints) GetExperimentComparisonHandler(w http.ResponseWriter, r *http.Request) {
+	experimentID := chi.URLParam(r, "id")
+	if experimentID == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("Experiment ID is required"))
+		return
+	}
+
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	experiment, err := e.repo.GetScoringExperiment(r.Context(), experimentID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get experiment"))
+		return
+	}
+	if experiment == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Experiment not found"))
+		return
+	}
+
+	comparison, err := e.experimentService.CompareExperiment(r.Context(), experiment, limit)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to build experiment comparison"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"comparison": comparison,
+	})
+}
+
+// PublishLegalDocumentHandler publishes a new terms/privacy version; users are re-prompted to accept it
+func (e *AdminEndpoints) PublishLegalDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req LegalDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	document := models.LegalDocument{
+		Kind:        req.Kind,
+		Version:     req.Version,
+		URL:         req.URL,
+		Summary:     req.Summary,
+		PublishedBy: user.ID,
+	}
+	if err := e.legalService.Publish(r.Context(), &document); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"document": document,
+		"message":  "Document published; users will be asked to accept it",
+	})
+}
+
+func (e *AdminEndpoints) GetLegalDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	documents, err := e.repo.GetLegalDocuments(r.Context())
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get legal documents"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"documents": documents,
+		"count":     len(documents),
+	})
+}
+
+// ImpersonateHandler issues a time-limited impersonation token for a user, sent back in the
+// X-Impersonation-Token header on subsequent requests
+func (e *AdminEndpoints) ImpersonateHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	token, impersonation, err := e.impersonation.Start(r.Context(), admin, chi.URLParam(r, "userID"), req.Reason, req.AllowWrite, time.Duration(req.TTLMinutes)*time.Minute)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":         token,
+		"header":        ImpersonationHeader,
+		"impersonation": impersonation,
+	})
+}
+
+// GetClientErrorStatsHandler totals the errors clients reported by session and kind over the
+// last ?days (default 7), optionally of one ?kind, busiest sessions first
+func (e *AdminEndpoints) GetClientErrorStatsHandler(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if d := r.URL.Query().Get("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 || parsed > 90 {
+			apperrors.Write(w, r, apperrors.BadRequest("days must be between 1 and 90"))
+			return
+		}
+		days = parsed
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	stats, err := e.repo.GetClientErrorStats(r.Context(), since, r.URL.Query().Get("kind"), 100)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get client errors"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"since": since,
+		"stats": stats,
+		"count": len(stats),
+	})
+}
+
+// GetTempFileStatsHandler reports the space audio conversion's scratch files take and how many
+// the cleanup has had to remove
+func (e *AdminEndpoints) GetTempFileStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stats": e.tempFiles.Stats(),
+	})
+}
+
+// GetTranscodeStatsHandler reports how many ffmpeg processes are running and queued, and how
+// long transcodes wait for a slot
+func (e *AdminEndpoints) GetTranscodeStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stats": e.transcodes.Stats(),
+	})
+}
+
+// GetConnectionsHandler lists the live WebSocket connections with their sessions' usage
+func (e *AdminEndpoints) GetConnectionsHandler(w http.ResponseWriter, r *http.Request) {
+	connections := e.hub.Connections()
+	var bytesIn, bytesOut int64
+	for _, connection := range connections {
+		bytesIn += connection.Usage.BytesIn
+		bytesOut += connection.Usage.BytesOut
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"connections": connections,
+		"count":       len(connections),
+		"bytes_in":    bytesIn,
+		"bytes_out":   bytesOut,
+	})
+}
+
+// GetSessionClientErrorsHandler lists the errors reported during a session with their details
+func (e *AdminEndpoints) GetSessionClientErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	reports, err := e.repo.GetSessionClientErrors(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get client errors"))
+		return
+	}
+	client, err := e.repo.GetSessionClientInfo(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session client"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": reports,
+		"count":  len(reports),
+		"client": client,
+	})
+}
+
+// GetClientTypeStatsHandler breaks recent sessions down by browser, platform, audio codec and
+// connection type, with the share of each whose client reported errors and the share of its
+// spoken answers that failed to transcribe
+func (e *AdminEndpoints) GetClientTypeStatsHandler(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if d := r.URL.Query().Get("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 || parsed > 90 {
+			apperrors.Write(w, r, apperrors.BadRequest("days must be between 1 and 90"))
+			return
+		}
+		days = parsed
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	stats, err := e.repo.GetClientTypeStats(r.Context(), since, 100)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get client type stats"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"since": since,
+		"stats": stats,
+		"count": len(stats),
+	})
+}
+
+// GetSessionFlagsHandler lists the newest session flags, optionally of one kind (?kind=plagiarism)
+func (e *AdminEndpoints) GetSessionFlagsHandler(w http.ResponseWriter, r *http.Request) {
+	flags, err := e.repo.GetRecentSessionFlags(r.Context(), r.URL.Query().Get("kind"), 100)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session flags"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"flags": flags,
+		"count": len(flags),
+	})
+}
+
+// GetAgentHealthHandler lists the public agents in catalog order with the factors behind each
+// agent's ranking
+func (e *AdminEndpoints) GetAgentHealthHandler(w http.ResponseWriter, r *http.Request) {
+	health, err := e.repo.GetAgentHealth(r.Context())
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agent health"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agents": health,
+		"count":  len(health),
+	})
+}
+
+// RefreshAgentHealthHandler recomputes the catalog ranking now instead of waiting for the
+// nightly run
+func (e *AdminEndpoints) RefreshAgentHealthHandler(w http.ResponseWriter, r *http.Request) {
+	go e.agentHealth.Refresh(context.Background())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "refreshing",
+	})
+}
+
+// PurgeAgentHandler permanently deletes any agent. Sessions run with it are reassigned to an
+// archived snapshot of the agent so their history and summaries survive.
+func (e *AdminEndpoints) PurgeAgentHandler(w http.ResponseWriter, r *http.Request) {
+	agentID := chi.URLParam(r, "id")
+	agent, err := e.repo.GetAgent(r.Context(), agentID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agent"))
+		return
+	}
+	if agent == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
+		return
+	}
+	if agent.IsArchived {
+		apperrors.Write(w, r, apperrors.Conflict("Archived agents hold the history of a purged agent"))
+		return
+	}
+
+	archived, err := e.repo.PurgeAgent(r.Context(), agentID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to purge agent"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":        "Agent purged successfully",
+		"archived_agent": archived,
+	})
+	logging.FromContext(r.Context()).Info("Agent purged by admin", "agent_id", agentID, "archived_agent_created", archived != nil)
+}
+
+func (e *AdminEndpoints) GetImpersonationsHandler(w http.ResponseWriter, r *http.Request) {
+	impersonations, err := e.repo.GetImpersonations(r.Context(), 100)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get impersonations"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"impersonations": impersonations,
+		"count":          len(impersonations),
+	})
+}
+
+func (e *AdminEndpoints) GetImpersonationHandler(w http.ResponseWriter, r *http.Request) {
+	impersonation, err := e.repo.GetImpersonationWithAudit(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get impersonation"))
+		return
+	}
+	if impersonation == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Impersonation not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"impersonation": impersonation,
+	})
+}
+
+func (e *AdminEndpoints) EndImpersonationHandler(w http.ResponseWriter, r *http.Request) {
+	ended, err := e.impersonation.End(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to end impersonation"))
+		return
+	}
+	if !ended {
+		apperrors.Write(w, r, apperrors.NotFound("Impersonation not found or already ended"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Impersonation ended",
+	})
+}
+
+// ExportResearchDatasetHandler streams an anonymized research dataset of opted-in users'
+// completed sessions as gzipped JSON lines. from and to are dates (YYYY-MM-DD); to is
+// exclusive and defaults to tomorrow, from defaults to 30 days before to.
+func (e *AdminEndpoints) ExportResearchDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		parsed, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.BadRequest("Invalid to date, expected YYYY-MM-DD"))
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -30)
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		parsed, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.BadRequest("Invalid from date, expected YYYY-MM-DD"))
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		apperrors.Write(w, r, apperrors.BadRequest("from must be before to"))
+		return
+	}
+
+	admin, _ := r.Context().Value("user").(*models.User)
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"praxis-research-"+from.Format(time.DateOnly)+"-"+to.Format(time.DateOnly)+".jsonl.gz\"")
+
+	count, err := e.research.Export(r.Context(), w, from, to)
+	if err != nil {
+		// Headers are already sent; the truncated gzip stream tells the client the export failed
+		logger.Error("Research dataset export failed", "error", err, "sessions_written", count)
+		return
+	}
+	if admin != nil {
+		logger.Info("Research dataset downloaded", "admin_id", admin.ID, "sessions", count)
+	}
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	AgentDigestSignatureHeader = "X-Praxis-Signature"
+
+	agentDigestInterval  = time.Hour
+	agentDigestBatch     = 50
+	agentDigestTimeout   = 15 * time.Second
+	agentDigestWeakAreas = 3
+)
+
+// AgentUsageDigestPayload is the body POSTed to an agent owner's webhook. It carries only
+// aggregates; no candidate identities, transcripts or per-session scores.
+type AgentUsageDigestPayload struct {
+	AgentID      string                 `json:"agent_id"`
+	AgentName    string                 `json:"agent_name"`
+	PeriodStart  time.Time              `json:"period_start"`
+	PeriodEnd    time.Time              `json:"period_end"`
+	Period       string                 `json:"period"` // The period in the owner's locale and timezone, for display
+	Sessions     int64                  `json:"sessions"`
+	AverageScore float64                `json:"average_score"`
+	WeakAreas    []models.MetricAverage `json:"weak_areas,omitempty"`
+}
+
+// AgentDigestService delivers periodic anonymized usage digests for public agents to
+// owners who opted in. Periods with fewer sessions than the webhook's minimum are withheld
+// so individual candidates can't be singled out.
+type AgentDigestService struct {
+	repo        *repository.GORMRepository
+	client      *http.Client
+	minSessions int
+}
+
+func NewAgentDigestService(repo *repository.GORMRepository, minSessions int) *AgentDigestService {
+	return &AgentDigestService{
+		repo:        repo,
+		client:      newPublicHTTPClient(agentDigestTimeout),
+		minSessions: minSessions,
+	}
+}
+
+// MinSessions is the floor owners can't configure below
+func (s *AgentDigestService) MinSessions() int {
+	return s.minSessions
+}
+
+// StartDigestJob delivers due digests in the background
+func (s *AgentDigestService) StartDigestJob(lifecycle *Lifecycle) {
+	lifecycle.Go("agent usage digests", func(ctx context.Context) {
+		ticker := time.NewTicker(agentDigestInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.deliverDue(ctx)
+			}
+		}
+	})
+	slog.Info("Agent usage digest job started", "interval", agentDigestInterval)
+}
+
+func (s *AgentDigestService) deliverDue(ctx context.Context) {
+	webhooks, err := s.repo.GetDueAgentUsageWebhooks(ctx, time.Now(), agentDigestBatch)
+	if err != nil {
+		return
+	}
+
+	for i := range webhooks {
+		s.deliver(ctx, &webhooks[i])
+	}
+}
+
+// deliver sends the digest for the period ending at NextDigestAt. Failed deliveries keep
+// the schedule so the same period is retried on the next run.
+func (s *AgentDigestService) deliver(ctx context.Context, webhook *models.AgentUsageWebhook) {
+	logger := logging.FromContext(ctx)
+	periodEnd := webhook.NextDigestAt
+	periodStart := periodEnd.Add(-digestPeriod(webhook.Frequency))
+
+	// The agent may have been made private or deleted since opting in
+	if webhook.Agent.ID == "" || !webhook.Agent.IsPublic {
+		logger.Info("Disabling usage webhook for agent that is no longer public", "agent_id", webhook.AgentID)
+		s.repo.UpdateAgentUsageWebhook(ctx, webhook.ID, map[string]interface{}{"enabled": false})
+		return
+	}
+
+	weakAreas := 0
+	if webhook.IncludeWeakAreas {
+		weakAreas = agentDigestWeakAreas
+	}
+	stats, err := s.repo.GetAgentUsageStats(ctx, webhook.AgentID, webhook.OwnerID, periodStart, periodEnd, weakAreas)
+	if err != nil {
+		return
+	}
+
+	digest := &models.AgentUsageDigest{
+		WebhookID:    webhook.ID,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		Sessions:     stats.Sessions,
+		AverageScore: stats.AverageScore,
+	}
+	metrics := make([]string, len(stats.WeakAreas))
+	for i, area := range stats.WeakAreas {
+		metrics[i] = area.Metric
+	}
+	digest.WeakAreas = strings.Join(metrics, ",")
+
+	updates := map[string]interface{}{
+		"next_digest_at": nextDigestAt(periodEnd, webhook.Frequency, time.Now()),
+	}
+	if stats.Sessions < int64(max(webhook.MinSessions, s.minSessions)) {
+		digest.Withheld = true
+		digest.AverageScore = 0
+		digest.WeakAreas = ""
+	} else {
+		format := NewLocaleFormat("", "")
+		if owner, err := s.repo.GetUserByID(ctx, webhook.OwnerID); err == nil && owner != nil {
+			format = UserLocaleFormat(owner)
+		}
+		statusCode, err := s.post(ctx, webhook, AgentUsageDigestPayload{
+			AgentID:      webhook.AgentID,
+			AgentName:    webhook.Agent.Name,
+			PeriodStart:  periodStart,
+			PeriodEnd:    periodEnd,
+			Period:       format.DateTime(periodStart) + " - " + format.DateTime(periodEnd),
+			Sessions:     stats.Sessions,
+			AverageScore: stats.AverageScore,
+			WeakAreas:    stats.WeakAreas,
+		})
+		digest.StatusCode = statusCode
+		if err != nil {
+			logger.Warn("Agent usage digest delivery failed", "agent_id", webhook.AgentID, "status_code", statusCode, "error", err)
+			digest.Error = err.Error()
+			s.repo.CreateAgentUsageDigest(ctx, digest)
+			s.repo.UpdateAgentUsageWebhook(ctx, webhook.ID, map[string]interface{}{"last_error": err.Error()})
+			return
+		}
+		updates["last_delivered_at"] = time.Now()
+		updates["last_error"] = ""
+	}
+
+	s.repo.CreateAgentUsageDigest(ctx, digest)
+	s.repo.UpdateAgentUsageWebhook(ctx, webhook.ID, updates)
+	logger.Info("Agent usage digest processed", "agent_id", webhook.AgentID, "sessions", stats.Sessions, "withheld", digest.Withheld)
+}
+
+// post signs and sends a digest, returning the response status code
+func (s *AgentDigestService) post(ctx context.Context, webhook *models.AgentUsageWebhook, payload AgentUsageDigestPayload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal digest: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AgentDigestSignatureHeader, "sha256="+signDigest(webhook.Secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newWebhookSecret generates a random HMAC key for a webhook
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// signDigest returns the hex HMAC-SHA256 of a digest body
+func signDigest(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func digestPeriod(frequency string) time.Duration {
+	if frequency == models.AgentDigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// nextDigestAt advances a period end past now, skipping periods missed while disabled or down
+func nextDigestAt(periodEnd time.Time, frequency string, now time.Time) time.Time {
+	next := periodEnd.Add(digestPeriod(frequency))
+	for !next.After(now) {
+		next = next.Add(digestPeriod(frequency))
+	}
+	return next
+}
+
+// ValidateWebhookURL requires an absolute https URL
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("webhook URL must be an absolute URL")
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("webhook URL must use https")
+	}
+	return nil
+}
+
+// newPublicHTTPClient returns a client that refuses to connect to private, loopback and
+// link-local addresses, so user-supplied URLs can't reach internal services
+func newPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+				return fmt.Errorf("refusing to connect to non-public address %s", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// firstDigestAt is the end of the first period for a new or re-enabled webhook
+func firstDigestAt(frequency string, now time.Time) time.Time {
+	return now.Truncate(time.Hour).Add(digestPeriod(frequency))
+}
@@ -2,26 +2,63 @@ package services
 
 import (
 	"encoding/json"
-	"log/slog"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"github.com/krshsl/praxis/backend/repository"
 )
 
 type AgentEndpoints struct {
-	repo *repository.GORMRepository
+	repo    *repository.GORMRepository
+	digests *AgentDigestService
 }
 
 type CreateAgentRequest struct {
-	Name        string `json:"name" validate:"required"`
+	Name        string `json:"name" validate:"required,max=255"`
 	Description string `json:"description"`
 	Personality string `json:"personality" validate:"required"`
 	Industry    string `json:"industry"`
 	Level       string `json:"level"`
 	IsPublic    bool   `json:"is_public"`
+	Gender      string `json:"gender,omitempty"`   // male, female or other; picks the voice pool
+	VoiceID     string `json:"voice_id,omitempty"` // ElevenLabs voice; picked from name and gender when empty
+
+	DurationMinutes int  `json:"duration_minutes,omitempty"` // Interview length; 0 uses the default
+	Proctored       bool `json:"proctored,omitempty"`        // Screening agent whose sessions are all proctored
+
+	// Pacing for short-format interviews; 0 turns a control off
+	PaceQuestions   int `json:"pace_questions,omitempty"`    // Target questions per 5 minutes
+	MaxAnswerWords  int `json:"max_answer_words,omitempty"`  // Longer answers are acknowledged and moved on from
+	MaxDwellSeconds int `json:"max_dwell_seconds,omitempty"` // Longest to spend on one question
+
+	// Ask harder or easier questions as the candidate's answers get stronger or weaker
+	AdaptiveDifficulty bool `json:"adaptive_difficulty,omitempty"`
+
+	// Selects the interviewer prompt, scoring rubric and metrics; empty for a general interview
+	InterviewType string `json:"interview_type,omitempty" validate:"omitempty,oneof=behavioral technical system_design case_study"`
+
+	// Template heading the agent's exported reports, e.g. "Acme Corp\n{{.Level}} {{.Industry}} screening";
+	// the first line is the title
+	ReportHeader string `json:"report_header,omitempty"`
+}
+
+// validateVoice normalizes the gender and checks the voice fields of a request
+func (req *CreateAgentRequest) validateVoice() error {
+	req.Gender = strings.ToLower(strings.TrimSpace(req.Gender))
+	if !agentGenders[req.Gender] {
+		return fmt.Errorf("gender must be male, female or other")
+	}
+	req.VoiceID = strings.TrimSpace(req.VoiceID)
+	if len(req.VoiceID) > maxVoiceIDLength {
+		return fmt.Errorf("voice_id must be at most %d characters", maxVoiceIDLength)
+	}
+	return nil
 }
 
 type CreateAgentResponse struct {
@@ -34,9 +71,10 @@ type GetAgentsResponse struct {
 	Count  int            `json:"count"`
 }
 
-func NewAgentEndpoints(repo *repository.GORMRepository) *AgentEndpoints {
+func NewAgentEndpoints(repo *repository.GORMRepository, digests *AgentDigestService) *AgentEndpoints {
 	return &AgentEndpoints{
-		repo: repo,
+		repo:    repo,
+		digests: digests,
 	}
 }
 
@@ -47,39 +85,87 @@ func (e *AgentEndpoints) RegisterRoutes(r chi.Router) {
 		r.Get("/{id}", e.GetAgentHandler)
 		r.Put("/{id}", e.UpdateAgentHandler)
 		r.Delete("/{id}", e.DeleteAgentHandler)
+		r.Post("/{id}/activate", e.ActivateAgentHandler)
+		r.Get("/{id}/usage-webhook", e.GetUsageWebhookHandler)
+		r.Put("/{id}/usage-webhook", e.PutUsageWebhookHandler)
+		r.Delete("/{id}/usage-webhook", e.DeleteUsageWebhookHandler)
+		r.Get("/{id}/usage-webhook/digests", e.GetUsageDigestsHandler)
+		r.Put("/{id}/scoring-policy", e.PutScoringPolicyHandler)
+		r.Put("/{id}/question-bank", e.PutQuestionBankHandler)
+		r.Get("/{id}/flow", e.GetFlowHandler)
+		r.Put("/{id}/flow", e.PutFlowHandler)
+		r.Delete("/{id}/flow", e.DeleteFlowHandler)
+		r.Get("/{id}/rubric", e.GetRubricHandler)
+		r.Put("/{id}/rubric", e.PutRubricHandler)
+		r.Delete("/{id}/rubric", e.DeleteRubricHandler)
 	})
 }
 
 func (e *AgentEndpoints) CreateAgentHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	var req CreateAgentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.DurationMinutes < 0 || req.DurationMinutes > MaxInterviewMinutes {
+		apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("duration_minutes must be between 1 and %d", MaxInterviewMinutes)))
+		return
+	}
+	if err := req.validateVoice(); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+	if err := ValidatePacing(req.PaceQuestions, req.MaxAnswerWords, req.MaxDwellSeconds); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+	if err := ValidateReportHeader(req.ReportHeader); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
 		return
 	}
 
+	// Each interviewer keeps the voice picked at creation, even if renamed later
+	voiceID := req.VoiceID
+	if voiceID == "" {
+		voiceID = PickDeterministicVoice(req.Name, req.Gender)
+	}
+
 	// Create new agent
 	agent := models.Agent{
 		ID:          uuid.New().String(),
 		UserID:      &user.ID,
 		Name:        req.Name,
+		Gender:      req.Gender,
+		VoiceID:     voiceID,
 		Description: req.Description,
 		Personality: req.Personality,
 		Industry:    req.Industry,
 		Level:       req.Level,
 		IsPublic:    req.IsPublic,
 		IsActive:    true,
+
+		DurationMinutes: req.DurationMinutes,
+		Proctored:       req.Proctored,
+		ReportHeader:    req.ReportHeader,
+		PaceQuestions:   req.PaceQuestions,
+		MaxAnswerWords:  req.MaxAnswerWords,
+		MaxDwellSeconds: req.MaxDwellSeconds,
+
+		AdaptiveDifficulty: req.AdaptiveDifficulty,
+		InterviewType:      req.InterviewType,
 	}
 
 	if err := e.repo.CreateAgent(r.Context(), &agent); err != nil {
-		slog.Error("Failed to create agent", "error", err, "user_id", user.ID)
-		http.Error(w, "Failed to create agent", http.StatusInternalServerError)
+		logger.Error("Failed to create agent", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to create agent"))
 		return
 	}
 
@@ -92,22 +178,24 @@ func (e *AgentEndpoints) CreateAgentHandler(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("Agent created", "agent_id", agent.ID, "user_id", user.ID, "name", agent.Name)
+	logger.Info("Agent created", "agent_id", agent.ID, "name", agent.Name)
 }
 
 func (e *AgentEndpoints) GetAgentsHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	// Get both public agents and user's private agents
 	agents, err := e.repo.GetAgents(r.Context(), user.ID, true)
 	if err != nil {
-		slog.Error("Failed to get agents", "error", err, "user_id", user.ID)
-		http.Error(w, "Failed to get agents", http.StatusInternalServerError)
+		logger.Error("Failed to get agents", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agents"))
 		return
 	}
 
@@ -119,28 +207,30 @@ func (e *AgentEndpoints) GetAgentsHandler(w http.ResponseWriter, r *http.Request
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("Agents retrieved", "user_id", user.ID, "count", len(agents))
+	logger.Info("Agents retrieved", "count", len(agents))
 }
 
 func (e *AgentEndpoints) GetAgentHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	agentID := chi.URLParam(r, "id")
 	if agentID == "" {
-		http.Error(w, "Agent ID is required", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.BadRequest("Agent ID is required"))
 		return
 	}
 
 	// Get agent (check if it's public or belongs to user)
 	agent, err := e.repo.GetAgentByID(r.Context(), agentID, user.ID)
 	if err != nil {
-		slog.Error("Failed to get agent", "error", err, "agent_id", agentID, "user_id", user.ID)
-		http.Error(w, "Agent not found", http.StatusNotFound)
+		logger.Error("Failed to get agent", "error", err, "agent_id", agentID)
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
 		return
 	}
 
@@ -149,42 +239,74 @@ func (e *AgentEndpoints) GetAgentHandler(w http.ResponseWriter, r *http.Request)
 		"agent": agent,
 	})
 
-	slog.Info("Agent retrieved", "agent_id", agentID, "user_id", user.ID)
+	logger.Info("Agent retrieved", "agent_id", agentID)
 }
 
 func (e *AgentEndpoints) UpdateAgentHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	agentID := chi.URLParam(r, "id")
 	if agentID == "" {
-		http.Error(w, "Agent ID is required", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.BadRequest("Agent ID is required"))
 		return
 	}
 
 	// Get existing agent
 	agent, err := e.repo.GetAgentByID(r.Context(), agentID, user.ID)
 	if err != nil {
-		slog.Error("Failed to get agent for update", "error", err, "agent_id", agentID, "user_id", user.ID)
-		http.Error(w, "Agent not found", http.StatusNotFound)
+		logger.Error("Failed to get agent for update", "error", err, "agent_id", agentID)
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
 		return
 	}
 
 	// Check if user owns this agent
 	if agent.UserID == nil || *agent.UserID != user.ID {
-		http.Error(w, "Not authorized to update this agent", http.StatusForbidden)
+		apperrors.Write(w, r, apperrors.Forbidden("Not authorized to update this agent"))
 		return
 	}
 
 	var req CreateAgentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.DurationMinutes < 0 || req.DurationMinutes > MaxInterviewMinutes {
+		apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("duration_minutes must be between 1 and %d", MaxInterviewMinutes)))
 		return
 	}
+	if err := req.validateVoice(); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+	if err := ValidatePacing(req.PaceQuestions, req.MaxAnswerWords, req.MaxDwellSeconds); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+	if err := ValidateReportHeader(req.ReportHeader); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	// The voice only changes when a new one is given or the gender changes, so renaming an
+	// agent keeps its voice. An omitted gender keeps the agent's.
+	if req.Gender != "" && req.Gender != agent.Gender {
+		agent.Gender = req.Gender
+		if req.VoiceID == "" {
+			agent.VoiceID = PickDeterministicVoice(req.Name, req.Gender)
+		}
+	}
+	if req.VoiceID != "" {
+		agent.VoiceID = req.VoiceID
+	} else if agent.VoiceID == "" {
+		// Agents created before voices were stored keep the one they have been speaking with
+		agent.VoiceID = agentVoiceID(agent)
+	}
 
 	// Update agent fields
 	agent.Name = req.Name
@@ -193,10 +315,18 @@ func (e *AgentEndpoints) UpdateAgentHandler(w http.ResponseWriter, r *http.Reque
 	agent.Industry = req.Industry
 	agent.Level = req.Level
 	agent.IsPublic = req.IsPublic
+	agent.DurationMinutes = req.DurationMinutes
+	agent.Proctored = req.Proctored
+	agent.ReportHeader = req.ReportHeader
+	agent.PaceQuestions = req.PaceQuestions
+	agent.MaxAnswerWords = req.MaxAnswerWords
+	agent.MaxDwellSeconds = req.MaxDwellSeconds
+	agent.AdaptiveDifficulty = req.AdaptiveDifficulty
+	agent.InterviewType = req.InterviewType
 
 	if err := e.repo.UpdateAgent(r.Context(), agent); err != nil {
-		slog.Error("Failed to update agent", "error", err, "agent_id", agentID, "user_id", user.ID)
-		http.Error(w, "Failed to update agent", http.StatusInternalServerError)
+		logger.Error("Failed to update agent", "error", err, "agent_id", agentID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to update agent"))
 		return
 	}
 
@@ -206,40 +336,70 @@ func (e *AgentEndpoints) UpdateAgentHandler(w http.ResponseWriter, r *http.Reque
 		"message": "Agent updated successfully",
 	})
 
-	slog.Info("Agent updated", "agent_id", agentID, "user_id", user.ID)
+	logger.Info("Agent updated", "agent_id", agentID)
 }
 
+// DeleteAgentHandler deactivates an agent, so it leaves the catalog while the sessions run with
+// it keep their history. ?hard=true deletes it for good, which is refused once it has sessions.
 func (e *AgentEndpoints) DeleteAgentHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	agentID := chi.URLParam(r, "id")
 	if agentID == "" {
-		http.Error(w, "Agent ID is required", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.BadRequest("Agent ID is required"))
 		return
 	}
 
 	// Get existing agent
 	agent, err := e.repo.GetAgentByID(r.Context(), agentID, user.ID)
-	if err != nil {
-		slog.Error("Failed to get agent for deletion", "error", err, "agent_id", agentID, "user_id", user.ID)
-		http.Error(w, "Agent not found", http.StatusNotFound)
+	if err != nil || agent == nil {
+		logger.Error("Failed to get agent for deletion", "error", err, "agent_id", agentID)
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
 		return
 	}
 
 	// Check if user owns this agent
 	if agent.UserID == nil || *agent.UserID != user.ID {
-		http.Error(w, "Not authorized to delete this agent", http.StatusForbidden)
+		apperrors.Write(w, r, apperrors.Forbidden("Not authorized to delete this agent"))
+		return
+	}
+
+	if r.URL.Query().Get("hard") != "true" {
+		if err := e.repo.SetAgentActive(r.Context(), agentID, false); err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to deactivate agent"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Agent deactivated successfully",
+			"is_active": false,
+		})
+		logger.Info("Agent deactivated", "agent_id", agentID)
+		return
+	}
+
+	// Past sessions need their agent for history and summary regeneration
+	sessions, err := e.repo.CountAgentSessions(r.Context(), agentID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete agent"))
+		return
+	}
+	if sessions > 0 {
+		apperrors.Write(w, r, apperrors.Conflict("Agent has interview sessions and can only be deactivated"))
 		return
 	}
 
 	if err := e.repo.DeleteAgent(r.Context(), agentID); err != nil {
-		slog.Error("Failed to delete agent", "error", err, "agent_id", agentID, "user_id", user.ID)
-		http.Error(w, "Failed to delete agent", http.StatusInternalServerError)
+		logger.Error("Failed to delete agent", "error", err, "agent_id", agentID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete agent"))
 		return
 	}
 
@@ -248,5 +408,42 @@ func (e *AgentEndpoints) DeleteAgentHandler(w http.ResponseWriter, r *http.Reque
 		"message": "Agent deleted successfully",
 	})
 
-	slog.Info("Agent deleted", "agent_id", agentID, "user_id", user.ID)
+	logger.Info("Agent deleted", "agent_id", agentID)
+}
+
+// ActivateAgentHandler brings a deactivated agent back into the catalog
+func (e *AgentEndpoints) ActivateAgentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	agentID := chi.URLParam(r, "id")
+	agent, err := e.repo.GetAgentByID(r.Context(), agentID, user.ID)
+	if err != nil || agent == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
+		return
+	}
+	if agent.UserID == nil || *agent.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.Forbidden("Not authorized to activate this agent"))
+		return
+	}
+	// An archived snapshot only holds the history of a purged agent
+	if agent.IsArchived {
+		apperrors.Write(w, r, apperrors.Conflict("Archived agents cannot be activated"))
+		return
+	}
+
+	if err := e.repo.SetAgentActive(r.Context(), agentID, true); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to activate agent"))
+		return
+	}
+	agent.IsActive = true
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent":   agent,
+		"message": "Agent activated successfully",
+	})
 }
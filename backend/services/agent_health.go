@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// agentHealthHour is the hour (UTC) the catalog ranking is recomputed each night
+	agentHealthHour = 3
+	// agentHealthWindow is how far back sessions count towards an agent's health
+	agentHealthWindow = 90 * 24 * time.Hour
+	// agentHealthSettle skips sessions started this recently, which may still be running
+	agentHealthSettle = 24 * time.Hour
+	// agentHealthPrior is how many samples' worth of weight a neutral 0.5 gets in each factor,
+	// so a handful of sessions can't put a new agent at the top or bottom of the catalog
+	agentHealthPrior = 10.0
+
+	completionWeight     = 0.40
+	ratingWeight         = 0.35
+	summaryQualityWeight = 0.25
+)
+
+// AgentHealthService ranks the public agent catalog by how well each agent's interviews go
+type AgentHealthService struct {
+	repo *repository.GORMRepository
+}
+
+func NewAgentHealthService(repo *repository.GORMRepository) *AgentHealthService {
+	return &AgentHealthService{repo: repo}
+}
+
+// AgentHealthScore combines an agent's completion rate, average candidate rating and summary
+// quality into a 0-100 score. Each factor is shrunk towards neutral by its sample count, so an
+// agent without data for a factor scores 50 on it.
+func AgentHealthScore(health models.AgentHealth) float64 {
+	shrink := func(value float64, samples int64) float64 {
+		n := float64(samples)
+		return (n*value + agentHealthPrior*0.5) / (n + agentHealthPrior)
+	}
+
+	rating := 0.0
+	if health.Ratings > 0 {
+		rating = (health.AverageRating - 1) / 4
+	}
+	score := completionWeight*shrink(health.CompletionRate, health.Sessions) +
+		ratingWeight*shrink(rating, health.Ratings) +
+		summaryQualityWeight*shrink(health.SummaryQuality/100, health.Summaries)
+	return math.Round(score*10000) / 100
+}
+
+// StartHealthJob scores the public agents at startup and nightly after that
+func (s *AgentHealthService) StartHealthJob(lifecycle *Lifecycle) {
+	lifecycle.Go("agent health", func(ctx context.Context) {
+		for {
+			s.Refresh(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(nextAgentHealthRun(time.Now()))):
+			}
+		}
+	})
+	slog.Info("Agent health job started", "hour_utc", agentHealthHour)
+}
+
+// Refresh recomputes the health of every public agent
+func (s *AgentHealthService) Refresh(ctx context.Context) error {
+	agents, err := s.repo.GetAgents(ctx, "", true)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	to := now.Add(-agentHealthSettle)
+	from := to.Add(-agentHealthWindow)
+	scored := 0
+	for _, agent := range agents {
+		health, err := s.repo.GetAgentHealthFactors(ctx, agent.ID, from, to)
+		if err != nil {
+			continue
+		}
+		health.Score = AgentHealthScore(*health)
+		health.ComputedAt = now
+		if err := s.repo.SaveAgentHealth(ctx, health); err != nil {
+			continue
+		}
+		scored++
+	}
+	logging.FromContext(ctx).Info("Agent health refreshed", "agents", len(agents), "scored", scored)
+	return nil
+}
+
+// nextAgentHealthRun returns the next agentHealthHour after now
+func nextAgentHealthRun(now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), agentHealthHour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
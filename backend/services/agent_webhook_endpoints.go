@@ -0,0 +1,201 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const maxAgentUsageDigests = 50
+
+type AgentUsageWebhookRequest struct {
+	URL              string `json:"url"`
+	Frequency        string `json:"frequency"`                    // daily (default) or weekly
+	MinSessions      int    `json:"min_sessions,omitempty"`       // Raised to the server minimum if lower
+	IncludeWeakAreas *bool  `json:"include_weak_areas,omitempty"` // Defaults to true
+	Enabled          *bool  `json:"enabled,omitempty"`            // Defaults to true
+	RotateSecret     bool   `json:"rotate_secret,omitempty"`
+}
+
+// ownedAgent loads an agent the current user owns, writing the error response if there isn't one
+func (e *AgentEndpoints) ownedAgent(w http.ResponseWriter, r *http.Request) (*models.Agent, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return nil, false
+	}
+
+	agent, err := e.repo.GetAgent(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agent"))
+		return nil, false
+	}
+	if agent == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
+		return nil, false
+	}
+	if agent.UserID == nil || *agent.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.Forbidden("Not authorized to manage this agent"))
+		return nil, false
+	}
+	return agent, true
+}
+
+func (e *AgentEndpoints) GetUsageWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := e.repo.GetAgentUsageWebhook(r.Context(), agent.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get usage webhook"))
+		return
+	}
+	if webhook == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Usage webhook not configured"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhook": webhook,
+	})
+}
+
+// PutUsageWebhookHandler opts a public agent into usage digests or updates its settings.
+// The signing secret is only returned when it is created or rotated.
+func (e *AgentEndpoints) PutUsageWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+	if !agent.IsPublic {
+		apperrors.Write(w, r, apperrors.BadRequest("Usage digests are only available for public agents"))
+		return
+	}
+
+	var req AgentUsageWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if err := ValidateWebhookURL(req.URL); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+	if req.Frequency == "" {
+		req.Frequency = models.AgentDigestDaily
+	}
+	if req.Frequency != models.AgentDigestDaily && req.Frequency != models.AgentDigestWeekly {
+		apperrors.Write(w, r, apperrors.BadRequest("frequency must be daily or weekly"))
+		return
+	}
+
+	webhook, err := e.repo.GetAgentUsageWebhook(r.Context(), agent.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get usage webhook"))
+		return
+	}
+	created := webhook == nil
+	if created {
+		webhook = &models.AgentUsageWebhook{AgentID: agent.ID, OwnerID: *agent.UserID}
+	}
+
+	wasEnabled := webhook.Enabled
+	webhook.URL = req.URL
+	webhook.Frequency = req.Frequency
+	webhook.MinSessions = max(req.MinSessions, e.digests.MinSessions())
+	webhook.IncludeWeakAreas = req.IncludeWeakAreas == nil || *req.IncludeWeakAreas
+	webhook.Enabled = req.Enabled == nil || *req.Enabled
+	if webhook.Enabled && (!wasEnabled || created) {
+		// Start a fresh period rather than reporting usage from before opting in
+		webhook.NextDigestAt = firstDigestAt(webhook.Frequency, time.Now())
+	}
+
+	secret := ""
+	if created || req.RotateSecret {
+		if secret, err = newWebhookSecret(); err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to generate webhook secret"))
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	if err := e.repo.SaveAgentUsageWebhook(r.Context(), webhook); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to save usage webhook"))
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("Agent usage webhook saved", "agent_id", agent.ID, "enabled", webhook.Enabled, "frequency", webhook.Frequency)
+
+	response := map[string]interface{}{
+		"webhook": webhook,
+		"message": "Usage webhook saved",
+	}
+	if secret != "" {
+		response["secret"] = secret
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (e *AgentEndpoints) DeleteUsageWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := e.repo.DeleteAgentUsageWebhook(r.Context(), agent.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete usage webhook"))
+		return
+	}
+	if !deleted {
+		apperrors.Write(w, r, apperrors.NotFound("Usage webhook not configured"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Usage webhook deleted",
+	})
+}
+
+// GetUsageDigestsHandler lists recent digests, including withheld ones, so owners can see what was sent
+func (e *AgentEndpoints) GetUsageDigestsHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := e.repo.GetAgentUsageWebhook(r.Context(), agent.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get usage webhook"))
+		return
+	}
+	if webhook == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Usage webhook not configured"))
+		return
+	}
+
+	digests, err := e.repo.GetAgentUsageDigests(r.Context(), webhook.ID, maxAgentUsageDigests)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get usage digests"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"digests": digests,
+		"count":   len(digests),
+	})
+}
@@ -4,22 +4,47 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"github.com/krshsl/praxis/backend/repository"
 	ws "github.com/krshsl/praxis/backend/websocket"
 )
 
 type AIMessageProcessor struct {
-	geminiService     *GeminiService
-	elevenLabsService *ElevenLabsService
-	timeoutService    *SessionTimeoutService
-	repo              *repository.GORMRepository
+	llm            LLMService
+	speech         SpeechService
+	transcriber    TranscriptionProvider
+	timeoutService *SessionTimeoutService
+	repo           *repository.GORMRepository
+	eventBus       *EventBus
+	recordings     *RecordingService
+	explanations   *ExplanationService
+	documents      *DocumentService
+	flows          *FlowEngine
+	openings       *OpeningQuestionCache
+	welcome        *WelcomeService
+	audio          *AudioCache
+	analyzer       *StaticAnalyzer
+	tests          *CodeTestRunner
+	plagiarism     *PlagiarismChecker
+	proctoring     *ProctoringService
+	compliance     *ComplianceService
+	clientInfo     *ClientInfoService
+	timeouts       CallTimeouts
+	warmupTurns    int
+	streamSpeech   bool
+
+	// Sessions whose question bank has been handed to the LLM
+	bankSessions map[string]bool
+	bankMutex    sync.Mutex
 }
 
 type MessageType string
@@ -30,6 +55,19 @@ const (
 	MessageTypeAudio MessageType = "audio"
 )
 
+const (
+	closingQuestionsPrompt = "Before we wrap up, do you have any questions for me about the role or the interview?"
+	closingSignOff         = "Thank you for your time today, it was a pleasure speaking with you. That concludes our interview. I'm putting together your feedback summary now."
+	// closingQuestionWindow is how long the candidate has to ask a closing question before the sign-off
+	closingQuestionWindow = 60 * time.Second
+	// maxNoteLength caps the size of a candidate's note
+	maxNoteLength = 2000
+	// recordedAudioMIME is the format the frontend records answers in unless it says otherwise
+	recordedAudioMIME = "audio/webm;codecs=opus"
+	// audioStreamChunkBytes is the size of each streamed audio chunk, about half a second of speech
+	audioStreamChunkBytes = 8 * 1024
+)
+
 type ProcessedMessage struct {
 	Type      MessageType `json:"type"`
 	Content   string      `json:"content"`
@@ -39,525 +77,529 @@ type ProcessedMessage struct {
 }
 
 func NewAIMessageProcessor(
-	geminiService *GeminiService,
-	elevenLabsService *ElevenLabsService,
+	llm LLMService,
+	speech SpeechService,
+	transcriber TranscriptionProvider,
 	timeoutService *SessionTimeoutService,
 	repo *repository.GORMRepository,
+	eventBus *EventBus,
+	recordings *RecordingService,
+	explanations *ExplanationService,
+	documents *DocumentService,
+	flows *FlowEngine,
+	openings *OpeningQuestionCache,
+	welcome *WelcomeService,
+	audio *AudioCache,
+	analyzer *StaticAnalyzer,
+	tests *CodeTestRunner,
+	plagiarism *PlagiarismChecker,
+	proctoring *ProctoringService,
+	compliance *ComplianceService,
+	clientInfo *ClientInfoService,
+	timeouts CallTimeouts,
+	warmupTurns int,
+	streamSpeech bool,
 ) *AIMessageProcessor {
 	return &AIMessageProcessor{
-		geminiService:     geminiService,
-		elevenLabsService: elevenLabsService,
-		timeoutService:    timeoutService,
-		repo:              repo,
+		llm:            llm,
+		speech:         speech,
+		transcriber:    transcriber,
+		timeoutService: timeoutService,
+		repo:           repo,
+		eventBus:       eventBus,
+		recordings:     recordings,
+		explanations:   explanations,
+		documents:      documents,
+		flows:          flows,
+		openings:       openings,
+		welcome:        welcome,
+		audio:          audio,
+		analyzer:       analyzer,
+		tests:          tests,
+		plagiarism:     plagiarism,
+		proctoring:     proctoring,
+		compliance:     compliance,
+		clientInfo:     clientInfo,
+		timeouts:       timeouts,
+		warmupTurns:    warmupTurns,
+		streamSpeech:   streamSpeech,
+		bankSessions:   make(map[string]bool),
 	}
 }
 
 // sendMessage sends a message to the WebSocket client
 func (p *AIMessageProcessor) sendMessage(client *ws.Client, content string, messageType string, language string) {
-	message := ws.Message{
-		Type:     messageType,
-		Content:  content,
-		Language: language,
-	}
-
-	messageBytes, err := json.Marshal(message)
+	logger := logging.FromContext(client.Context())
+	messageBytes, err := ws.Encode(messageType, ws.MessagePayload{Content: content, Language: language})
 	if err != nil {
-		slog.Error("Failed to marshal message", "error", err, "session_id", client.SessionID)
+		logger.Error("Failed to marshal message", "error", err)
 		return
 	}
 
 	select {
 	case client.Send <- messageBytes:
-		slog.Info("Message sent to client", "session_id", client.SessionID, "type", messageType, "content_length", len(content))
+		logger.Info("Message sent to client", "type", messageType, "content_length", len(content))
 	default:
-		slog.Warn("Failed to send message - client channel full", "session_id", client.SessionID)
+		logger.Warn("Failed to send message - client channel full")
 	}
 }
 
 func (p *AIMessageProcessor) sendUserMessage(client *ws.Client, content string) {
-	message := ws.Message{
-		Type:     "user_message",
-		Content:  content,
-		Language: "",
-	}
-
-	messageBytes, err := json.Marshal(message)
+	logger := logging.FromContext(client.Context())
+	messageBytes, err := ws.Encode(ws.TypeUserMessage, ws.MessagePayload{Content: content})
 	if err != nil {
-		slog.Error("Failed to marshal user message", "error", err, "session_id", client.SessionID)
+		logger.Error("Failed to marshal user message", "error", err)
 		return
 	}
 
 	select {
 	case client.Send <- messageBytes:
-		slog.Info("User message sent to client", "session_id", client.SessionID, "content_length", len(content))
+		logger.Info("User message sent to client", "content_length", len(content))
 	default:
-		slog.Warn("Failed to send user message - client channel full", "session_id", client.SessionID)
+		logger.Warn("Failed to send user message - client channel full")
 	}
 }
 
 func (p *AIMessageProcessor) sendAudioMessage(client *ws.Client, audioData []byte) {
-	// Convert audio data to base64
-	audioBase64 := base64.StdEncoding.EncodeToString(audioData)
-
-	message := ws.Message{
-		Type:            "audio",
-		AudioDataBase64: audioBase64,
-	}
-
-	messageBytes, err := json.Marshal(message)
+	logger := logging.FromContext(client.Context())
+	messageBytes, err := ws.Encode(ws.TypeAudio, ws.AudioReplyPayload{AudioData: audioData})
 	if err != nil {
-		slog.Error("Failed to marshal audio message", "error", err, "session_id", client.SessionID)
+		logger.Error("Failed to marshal audio message", "error", err)
 		return
 	}
 
 	select {
 	case client.Send <- messageBytes:
-		slog.Info("Audio message sent to client", "session_id", client.SessionID, "audio_size", len(audioData))
+		logger.Info("Audio message sent to client", "audio_size", len(audioData))
 	default:
-		slog.Warn("Failed to send audio message - client channel full", "session_id", client.SessionID)
+		logger.Warn("Failed to send audio message - client channel full")
 	}
 }
 
 func (p *AIMessageProcessor) sendCombinedMessage(client *ws.Client, textContent string, audioData []byte) {
-	// Convert audio data to base64
-	audioBase64 := base64.StdEncoding.EncodeToString(audioData)
+	logger := logging.FromContext(client.Context())
 
-	message := ws.Message{
-		Type:            "audio",     // Set type as audio so frontend knows to play it
-		Content:         textContent, // Include text content for display
-		AudioDataBase64: audioBase64,
-	}
-
-	messageBytes, err := json.Marshal(message)
+	// Sent as audio so the frontend plays it, with the text for display
+	messageBytes, err := ws.Encode(ws.TypeAudio, ws.AudioReplyPayload{Content: textContent, AudioData: audioData})
 	if err != nil {
-		slog.Error("Failed to marshal combined message", "error", err, "session_id", client.SessionID)
+		logger.Error("Failed to marshal combined message", "error", err)
 		return
 	}
 
 	select {
 	case client.Send <- messageBytes:
-		slog.Info("Combined message sent to client", "session_id", client.SessionID, "text_length", len(textContent), "audio_size", len(audioData))
+		logger.Info("Combined message sent to client", "text_length", len(textContent), "audio_size", len(audioData))
 	default:
-		slog.Warn("Failed to send combined message - client channel full", "session_id", client.SessionID)
+		logger.Warn("Failed to send combined message - client channel full")
 	}
 }
 
 // AutoStartInterview automatically starts the interview when a client connects
 func (p *AIMessageProcessor) AutoStartInterview(client *ws.Client) {
-	ctx := context.Background()
+	logger := logging.FromContext(client.Context())
+	ctx := client.Context()
 
-	slog.Info("Auto-start check", "session_id", client.SessionID)
+	logger.Info("Auto-start check")
+
+	// Load the candidate's documents and the agent's flow on every connect, so a session
+	// resumed after a restart is still tailored
+	p.prepareSession(ctx, client)
+	go p.reportTimeRemaining(client)
 
 	// Check if interview has already started by looking for existing transcripts
 	existingTranscripts, err := p.repo.GetInterviewTranscripts(ctx, client.SessionID)
 	if err != nil {
-		slog.Error("Failed to check existing transcripts", "error", err, "session_id", client.SessionID)
+		logger.Error("Failed to check existing transcripts", "error", err)
 		return
 	}
 
-	// If there are already transcripts, don't auto-start again
+	// If there are already transcripts, don't auto-start again; the client is resuming, so it
+	// gets the conversation so far instead
 	if len(existingTranscripts) > 0 {
-		slog.Info("Interview already started", "session_id", client.SessionID, "existing_transcripts", len(existingTranscripts))
+		logger.Info("Interview already started", "existing_transcripts", len(existingTranscripts))
+		p.replayTranscript(client, existingTranscripts)
 		return
 	}
 
-	slog.Info("Starting new interview", "session_id", client.SessionID)
+	// Two quick reconnects can both get here before either saves its welcome; only the one
+	// that claims the session goes on, and the others show the welcome it saves
+	claimed, stored, err := ClaimOrAwaitWelcome(ctx, p.repo, client.SessionID, p.timeouts.LLM+welcomeWaitSlack)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to claim session welcome", "error", err)
+		p.sendErrorMessage(client, "Failed to start the interview")
+		return
+	}
+	if !claimed {
+		logger.Info("Interview started by another connection")
+		p.replayTranscript(client, stored)
+		return
+	}
+	// Until the welcome is saved the claim is released on the way out, so the next
+	// connection tries again; in the background, as this client may have disconnected
+	welcomed := false
+	defer func() {
+		if !welcomed {
+			p.repo.ReleaseSessionWelcome(context.Background(), client.SessionID)
+		}
+	}()
+
+	logger.Info("Starting new interview")
 
 	// Get session and agent from database
 	session, err := p.repo.GetInterviewSession(ctx, client.SessionID)
-	if err != nil {
-		slog.Error("Failed to get interview session for auto-start", "error", err, "session_id", client.SessionID)
+	if err != nil || session == nil {
+		logger.Error("Failed to get interview session for auto-start", "error", err)
+		p.sendErrorMessage(client, "Failed to start the interview")
 		return
 	}
 
 	// Get agent details
 	agent, err := p.repo.GetAgent(ctx, session.AgentID)
-	if err != nil {
-		slog.Error("Failed to get agent for auto-start", "error", err, "agent_id", session.AgentID)
+	if err != nil || agent == nil {
+		logger.Error("Failed to get agent for auto-start", "error", err, "agent_id", session.AgentID)
+		p.sendErrorMessage(client, "Failed to start the interview")
 		return
 	}
 
-	// Generate welcome message using Gemini
-	if p.geminiService != nil {
-		welcomeMessage := fmt.Sprintf("Hello! I'm %s, and I'll be conducting your %s interview today. I'm excited to learn about your experience and skills. Let's start with a brief introduction - could you tell me about yourself and what brings you to this interview?",
-			agent.Name, agent.Industry)
-
-		// Save AI welcome message to database
-		if p.repo != nil {
-			aiTranscript := &models.InterviewTranscript{
-				SessionID: client.SessionID,
-				Speaker:   "agent",
-				Content:   welcomeMessage,
-				TurnOrder: 1,
-				Timestamp: time.Now(),
-			}
+	welcomePhase := models.TranscriptPhaseInterview
+	question := ""
+	if p.warmupTurns > 0 {
+		welcomePhase = models.TranscriptPhaseWarmup
+	} else {
+		// Without a warm-up the welcome opens the first flow stage
+		p.flows.Begin(client.SessionID)
 
-			if err := p.repo.CreateInterviewTranscript(ctx, aiTranscript); err != nil {
-				slog.Error("Failed to save AI welcome transcript", "error", err, "session_id", client.SessionID)
-			}
+		// Serve a pre-generated opening question; agents with a flow open with their first stage
+		// instead, and the pool is only generated in English
+		if agent.Flow == nil && (session.Language == "" || session.Language == DefaultLanguage) {
+			question, _ = p.openings.Question(agent)
 		}
+	}
+	welcomeCtx, cancel := withTimeout(ctx, p.timeouts.LLM)
+	welcomeMessage := p.welcome.Welcome(welcomeCtx, agent, session.Language, p.warmupTurns > 0, question)
+	cancel()
+	if p.compliance.Screen(ctx, client.SessionID, agent, welcomeMessage) {
+		welcomeMessage = complianceFallbackWelcome
+	}
 
-		// Generate and send welcome message as audio first, using gender-based voice
-		if p.elevenLabsService != nil {
-			// Use agent.VoiceID if set, else fallback to gender-based or default
-			voiceID := agent.VoiceID
-			if voiceID == "" {
-				voiceID = PickDeterministicVoice(agent.Name, agent.Gender)
-			}
-			audioStream, err := p.elevenLabsService.TextToSpeechWithVoice(ctx, welcomeMessage, voiceID)
-			if err != nil {
-				slog.Error("Failed to generate welcome audio", "error", err, "session_id", client.SessionID)
-				// Send text as fallback if audio fails
-				p.sendMessage(client, welcomeMessage, "text", "")
-			} else {
-				audioData, err := io.ReadAll(audioStream)
-				audioStream.Close()
-				if err != nil {
-					slog.Error("Failed to read welcome audio data", "error", err, "session_id", client.SessionID)
-					// Send text as fallback if audio reading fails
-					p.sendMessage(client, welcomeMessage, "text", "")
-				} else {
-					// Send combined message with both audio and text
-					p.sendCombinedMessage(client, welcomeMessage, audioData)
-				}
-			}
-		} else {
-			// Send text message if no audio service
-			p.sendMessage(client, welcomeMessage, "text", "")
+	// Warm the LLM while the candidate listens to the welcome
+	go func(sessionID string) {
+		warmCtx, cancel := withTimeout(ctx, p.timeouts.LLM)
+		defer cancel()
+		if err := p.llm.WarmSession(warmCtx, sessionID, agent); err != nil {
+			logger.Warn("Failed to warm LLM session", "error", err, "session_id", sessionID)
 		}
-
-		slog.Info("Auto-started interview", "session_id", client.SessionID, "agent", agent.Name)
+	}(client.SessionID)
+
+	// Save AI welcome message to database
+	aiTranscript := &models.InterviewTranscript{
+		SessionID: client.SessionID,
+		Speaker:   "agent",
+		Content:   welcomeMessage,
+		Phase:     welcomePhase,
+		Stage:     p.flows.Stage(client.SessionID),
+		Timestamp: time.Now(),
 	}
+	if err := p.repo.CreateInterviewTranscript(ctx, aiTranscript); err != nil {
+		logger.Error("Failed to save AI welcome transcript", "error", err)
+		p.sendErrorMessage(client, "Failed to start the interview")
+		return
+	}
+	welcomed = true
+
+	p.observeTurn(client, *aiTranscript)
+
+	// Send welcome message as audio first, using the agent's voice
+	p.respond(ctx, client, welcomeMessage, agent, aiTranscript.ID)
+
+	logger.Info("Auto-started interview", "agent", agent.Name)
 }
 
-// ProcessAudioChunk handles chunked audio messages from users
-func (p *AIMessageProcessor) ProcessAudioChunk(client *ws.Client, audioData []byte, chunkIndex int, totalChunks int, isLastChunk bool) {
-	slog.Info("Audio chunk received", "session_id", client.SessionID, "chunk_index", chunkIndex, "total_chunks", totalChunks)
+// ProcessAudioChunk handles chunked audio messages from users; the last chunk may carry the
+// answer's timing
+func (p *AIMessageProcessor) ProcessAudioChunk(client *ws.Client, audioData []byte, chunkIndex int, totalChunks int, isLastChunk bool, timing *ws.SpeechTiming) {
+	logger := logging.FromContext(client.Context())
+	logger.Info("Audio chunk received", "chunk_index", chunkIndex, "total_chunks", totalChunks)
 
 	// Update session activity
-	if p.timeoutService != nil && client.SessionID != "" {
-		p.timeoutService.UpdateActivity(client.SessionID)
-	}
+	p.timeoutService.UpdateActivity(client.SessionID)
 
 	// Store chunk in session storage
-	if p.timeoutService != nil {
-		// Add chunk to session storage
-		p.timeoutService.AddAudioChunk(client.SessionID, audioData, chunkIndex, totalChunks, isLastChunk)
-	}
+	p.timeoutService.AddAudioChunk(client.SessionID, audioData, chunkIndex, totalChunks, isLastChunk)
 
 	// If this is the last chunk, reconstruct and process the complete audio
 	if isLastChunk {
-		slog.Info("Reconstructing complete audio", "session_id", client.SessionID, "total_chunks", totalChunks)
+		logger.Info("Reconstructing complete audio", "total_chunks", totalChunks)
 
 		// Get all chunks and reconstruct the complete audio
 		completeAudio, err := p.timeoutService.ReconstructAudio(client.SessionID)
 		if err != nil {
-			slog.Error("Failed to reconstruct audio from chunks", "error", err, "session_id", client.SessionID)
+			logger.Error("Failed to reconstruct audio from chunks", "error", err)
 			p.sendErrorMessage(client, "Failed to reconstruct audio from chunks")
 			return
 		}
 
-		slog.Info("Audio reconstructed", "session_id", client.SessionID, "complete_size", len(completeAudio))
+		logger.Info("Audio reconstructed", "complete_size", len(completeAudio))
 
 		// Process the complete reconstructed audio
-		p.processAudioData(client, completeAudio)
+		p.processAudioData(client, completeAudio, timing)
 	}
 }
 
 // processAudioData processes the actual audio data (extracted from ProcessAudioMessage)
-func (p *AIMessageProcessor) processAudioData(client *ws.Client, audioData []byte) {
-	ctx := context.Background()
+func (p *AIMessageProcessor) processAudioData(client *ws.Client, audioData []byte, timing *ws.SpeechTiming) {
+	logger := logging.FromContext(client.Context())
+	ctx, progress := startTurn(client.Context(), client)
 
 	// If audio chunk is too small (<50KB), treat as silence/unintelligible and do not process
 	const minAudioSize = 51200 // 50 KB
 	if len(audioData) < minAudioSize {
-		slog.Info("Audio chunk below 50KB, treating as silence/unintelligible", "session_id", client.SessionID, "audio_size", len(audioData))
+		logger.Info("Audio chunk below 50KB, treating as silence/unintelligible", "audio_size", len(audioData))
 		// Instead of sending a user message, send only a hardcoded AI message
-		if p.timeoutService != nil && client.SessionID != "" {
-			count := p.timeoutService.IncrementEmptyResponse(client.SessionID)
-			if count >= 3 {
-				finalMsg := "It seems we've had several attempts without a valid response. We'll end the session here and prepare your summary."
-				p.sendMessage(client, finalMsg, "text", "")
-				// Send end_session message to trigger frontend session end
-				p.sendMessage(client, "Session ended", "end_session", "")
-				p.timeoutService.ConcludeSession(client.SessionID, "Empty response limit reached")
-				return
-			}
+		count := p.timeoutService.IncrementEmptyResponse(client.SessionID)
+		if count >= p.timeoutService.Accommodations(client.SessionID).EmptyResponseLimit() {
+			finalMsg := "It seems we've had several attempts without a valid response, so we'll end the session here."
+			p.sendMessage(client, finalMsg, "text", "")
+			p.BeginClosing(client, "Empty response limit reached", false)
+			return
 		}
 		// Always send the interviewer warning as an AI message
 		p.sendMessage(client, "I couldn't hear a clear response. Please try again.", "text", "")
 		return
 	}
 
-	// Transcribe audio using Gemini
-	if p.geminiService != nil {
-		// Add a prompt to Gemini to ignore silence and only transcribe clear speech
-		transcriptionPrompt := "Transcribe only clear, intelligible speech. If the audio is silent, empty, or unintelligible, return an empty string."
-		transcription, err := p.geminiService.TranscribeAudioWithPrompt(ctx, audioData, transcriptionPrompt)
-		if err != nil {
-			slog.Error("Failed to transcribe audio", "error", err, "session_id", client.SessionID)
-			p.sendErrorMessage(client, "Failed to transcribe audio")
-			return
-		}
+	// Transcribe audio, ignoring silence and only transcribing clear speech
+	transcriptionPrompt := "Transcribe only clear, intelligible speech. If the audio is silent, empty, or unintelligible, return an empty string."
+	progress.report(ws.StageTranscribing)
+	transcribeCtx, cancel := withTimeout(ctx, p.timeouts.LLM)
+	result, err := p.transcriber.Transcribe(transcribeCtx, audioData, audioMIME(client), transcriptionPrompt)
+	cancel()
+	p.clientInfo.RecordTranscription(ctx, client, err)
+	if err != nil {
+		logger.Error("Failed to transcribe audio", "error", err)
+		p.sendErrorMessage(client, "Failed to transcribe audio")
+		return
+	}
+	transcription := result.Text
 
-		// Log successful transcription
-		slog.Info("Audio transcribed", "session_id", client.SessionID, "transcription_length", len(transcription), "transcription", transcription)
+	// Log successful transcription
+	logger.Info("Audio transcribed", "transcription_length", len(transcription), "transcription", transcription)
 
-		// Empty/unintelligible response penalty handling (3 strikes)
-		trimmed := strings.TrimSpace(transcription)
-		lower := strings.ToLower(trimmed)
+	// Empty/unintelligible response penalty handling (3 strikes, more with extra thinking time)
+	trimmed := strings.TrimSpace(transcription)
+	lower := strings.ToLower(trimmed)
 
-		// Patterns to treat as empty/unintelligible
-		isEmpty := false
-		if lower == "" || lower == "[inaudible]" || lower == "[vocalization]" || len([]rune(trimmed)) < 2 {
-			isEmpty = true
-		}
-		// Repeated word patterns (e.g., 'audio audio audio', 'humming humming')
-		words := strings.Fields(lower)
-		if len(words) > 0 {
-			allSame := true
-			for _, w := range words {
-				if w != words[0] {
-					allSame = false
-					break
-				}
-			}
-			if allSame && len(words) > 1 {
-				isEmpty = true
-			}
-		}
-		// Known non-speech/filler patterns
-		badPatterns := []string{"vocalization", "humming", "mumbling", "audio", "noise", "unintelligible"}
-		for _, pat := range badPatterns {
-			if strings.Contains(lower, pat) && len(words) <= 5 {
-				isEmpty = true
+	// Patterns to treat as empty/unintelligible
+	isEmpty := false
+	if lower == "" || lower == "[inaudible]" || lower == "[vocalization]" || len([]rune(trimmed)) < 2 {
+		isEmpty = true
+	}
+	// Repeated word patterns (e.g., 'audio audio audio', 'humming humming')
+	words := strings.Fields(lower)
+	if len(words) > 0 {
+		allSame := true
+		for _, w := range words {
+			if w != words[0] {
+				allSame = false
 				break
 			}
 		}
-
-		if isEmpty {
-			// Instead of sending a user message, send only a hardcoded AI message
-			if p.timeoutService != nil && client.SessionID != "" {
-				count := p.timeoutService.IncrementEmptyResponse(client.SessionID)
-				if count >= 3 {
-					finalMsg := "It seems we've had several attempts without a valid response. We'll end the session here and prepare your summary."
-					p.sendMessage(client, finalMsg, "text", "")
-					// Send end_session message to trigger frontend session end
-					p.sendMessage(client, "Session ended", "end_session", "")
-					p.timeoutService.ConcludeSession(client.SessionID, "Empty response limit reached")
-					return
-				}
-			}
-			// Always send the interviewer warning as an AI message
-			p.sendMessage(client, "I couldn't hear a clear response. Please try again.", "text", "")
-			// Do not proceed further on empty input
-			return
+		if allSame && len(words) > 1 {
+			isEmpty = true
 		}
-
-		// Reset empty-response counter on valid content
-		if p.timeoutService != nil && client.SessionID != "" {
-			p.timeoutService.ResetEmptyResponse(client.SessionID)
+	}
+	// Known non-speech/filler patterns
+	badPatterns := []string{"vocalization", "humming", "mumbling", "audio", "noise", "unintelligible"}
+	for _, pat := range badPatterns {
+		if strings.Contains(lower, pat) && len(words) <= 5 {
+			isEmpty = true
+			break
 		}
+	}
 
-		// Send user message to frontend
-		p.sendUserMessage(client, transcription)
-
-		// Add user transcript
-		if p.timeoutService != nil && client.SessionID != "" {
-			userTranscript := models.InterviewTranscript{
-				SessionID: client.SessionID,
-				Speaker:   "user",
-				Content:   transcription,
-				Timestamp: time.Now(),
-			}
+	if isEmpty {
+		// Instead of sending a user message, send only a hardcoded AI message
+		count := p.timeoutService.IncrementEmptyResponse(client.SessionID)
+		if count >= p.timeoutService.Accommodations(client.SessionID).EmptyResponseLimit() {
+			finalMsg := "It seems we've had several attempts without a valid response, so we'll end the session here."
+			p.sendMessage(client, finalMsg, "text", "")
+			p.BeginClosing(client, "Empty response limit reached", false)
+			return
+		}
+		// Always send the interviewer warning as an AI message
+		p.sendMessage(client, "I couldn't hear a clear response. Please try again.", "text", "")
+		// Do not proceed further on empty input
+		return
+	}
 
-			p.timeoutService.AddTranscript(client.SessionID, userTranscript)
+	// Reset empty-response counter on valid content
+	p.timeoutService.ResetEmptyResponse(client.SessionID)
+
+	// Keep the recording for replay, even if the candidate disconnects; storage failures
+	// shouldn't interrupt the interview
+	transcriptID := uuid.NewString()
+	go func(sessionID, mimeType string) {
+		if _, err := p.recordings.Save(context.Background(), sessionID, transcriptID, "user", baseMIME(mimeType), audioData); err != nil {
+			logger.Error("Failed to save audio recording", "error", err, "session_id", sessionID)
 		}
+	}(client.SessionID, audioMIME(client))
+
+	// Send user message to frontend
+	p.sendUserMessage(client, transcription)
+
+	phase, finalWarmup := p.turnPhase(client.SessionID)
+
+	// Add user transcript
+	p.recordTurn(client, models.InterviewTranscript{
+		ID:         transcriptID,
+		SessionID:  client.SessionID,
+		Speaker:    "user",
+		Content:    transcription,
+		Phase:      phase,
+		Stage:      p.flows.Stage(client.SessionID),
+		Confidence: result.Confidence,
+		Timestamp:  time.Now(),
+	})
+
+	// Warm-up small talk isn't scored, so neither is how it was spoken
+	if phase != models.TranscriptPhaseWarmup {
+		p.recordSpeechMetrics(ctx, client.SessionID, transcriptID, transcription, timing)
+	}
 
-		// Generate AI response
-		if p.repo != nil {
-			// Get conversation history
-			conversationHistory, err := p.repo.GetInterviewTranscripts(ctx, client.SessionID)
-			if err != nil {
-				slog.Error("Failed to get conversation history", "error", err, "session_id", client.SessionID)
-				return
-			}
+	// Get conversation history
+	conversationHistory, err := p.repo.GetInterviewTranscripts(ctx, client.SessionID)
+	if err != nil {
+		logger.Error("Failed to get conversation history", "error", err)
+		return
+	}
 
-			// Get session and agent
-			session, err := p.repo.GetInterviewSession(ctx, client.SessionID)
-			if err != nil {
-				slog.Error("Failed to get interview session", "error", err, "session_id", client.SessionID)
-				return
-			}
+	// Get session and agent
+	session, err := p.repo.GetInterviewSession(ctx, client.SessionID)
+	if err != nil {
+		logger.Error("Failed to get interview session", "error", err)
+		return
+	}
 
-			agent, err := p.repo.GetAgent(ctx, session.AgentID)
-			if err != nil {
-				slog.Error("Failed to get agent", "error", err, "agent_id", session.AgentID)
-				return
-			}
+	agent, err := p.repo.GetAgent(ctx, session.AgentID)
+	if err != nil {
+		logger.Error("Failed to get agent", "error", err, "agent_id", session.AgentID)
+		return
+	}
 
-			// Check if interview has exceeded 5-minute limit
-			if p.timeoutService != nil && p.timeoutService.IsInterviewExpired(client.SessionID) {
-				slog.Info("Interview time limit exceeded (5 minutes)", "session_id", client.SessionID)
-				endingMessage := "Thank you for your time! We've reached the 5-minute interview limit. This concludes our interview session. We'll review your responses and get back to you soon."
-				p.sendMessage(client, endingMessage, "text", "")
-				// Send end_session message to trigger frontend session end
-				p.sendMessage(client, "Session ended", "end_session", "")
-
-				// End the session
-				if p.timeoutService != nil {
-					p.timeoutService.EndSession(client.SessionID)
-				}
-				return
-			}
+	closingStage := p.timeoutService.GetClosingStage(client.SessionID)
 
-			// Generate AI response
-			slog.Info("Generating AI response", "session_id", client.SessionID, "transcription", transcription, "history_length", len(conversationHistory))
-			aiResponse, err := p.geminiService.GenerateInterviewResponse(ctx, client.SessionID, agent, transcription, conversationHistory)
-			if err != nil {
-				slog.Error("Failed to generate AI response", "error", err, "session_id", client.SessionID)
-				p.sendErrorMessage(client, "Failed to generate AI response")
-				return
-			}
-			slog.Info("AI response generated", "session_id", client.SessionID, "response", aiResponse)
-
-			// Check if AI response indicates session should end
-			if p.isSessionEndingResponse(aiResponse) {
-				slog.Info("AI response indicates session should end", "session_id", client.SessionID, "response", aiResponse)
-				// Send the AI response as text (not audio)
-				p.sendMessage(client, aiResponse, "text", "")
-				// Send end_session message to trigger frontend session end
-				p.sendMessage(client, "Session ended", "end_session", "")
-				// Conclude the session
-				if p.timeoutService != nil {
-					p.timeoutService.ConcludeSession(client.SessionID, "AI determined session should end")
-				}
-				return
-			}
+	// Check if interview has exceeded its time limit
+	if closingStage == ClosingStageNone && p.timeoutService.IsInterviewExpired(client.SessionID) {
+		p.closeExpiredInterview(client, session)
+		return
+	}
 
-			// Save AI response to database
-			if p.timeoutService != nil && client.SessionID != "" {
-				aiTranscript := models.InterviewTranscript{
-					SessionID: client.SessionID,
-					Speaker:   "agent",
-					Content:   aiResponse,
-					Timestamp: time.Now(),
-				}
+	// Generate AI response
+	logger.Info("Generating AI response", "transcription", transcription, "history_length", len(conversationHistory))
+	progress.report(ws.StageThinking)
+	aiResponse, replyPhase, err := p.generateReply(ctx, client.SessionID, agent, transcription, conversationHistory, phase, finalWarmup)
+	if err != nil {
+		logger.Error("Failed to generate AI response", "error", err)
+		p.sendErrorMessage(client, "Failed to generate AI response")
+		return
+	}
+	logger.Info("AI response generated", "response", aiResponse)
+	if phase != models.TranscriptPhaseWarmup {
+		p.coach(client, session, agent, conversationHistory, transcription, transcriptID)
+	}
 
-				p.timeoutService.AddTranscript(client.SessionID, aiTranscript)
-			}
+	// Check if AI response indicates session should end
+	if closingStage == ClosingStageNone && phase != models.TranscriptPhaseWarmup && p.isSessionEndingResponse(aiResponse) {
+		logger.Info("AI response indicates session should end", "response", aiResponse)
+		// Send the AI response as text (not audio)
+		p.sendMessage(client, aiResponse, "text", "")
+		p.BeginClosing(client, "AI determined session should end", true)
+		return
+	}
 
-			// Generate and send AI response as audio first, using gender-based voice
-			if p.elevenLabsService != nil {
-				// Get session and agent for voice selection
-				session, err := p.repo.GetInterviewSession(ctx, client.SessionID)
-				if err == nil {
-					agent, err := p.repo.GetAgent(ctx, session.AgentID)
-					if err == nil {
-						// Use agent.VoiceID if set, else fallback to gender-based or default
-						voiceID := agent.VoiceID
-						if voiceID == "" {
-							voiceID = PickDeterministicVoice(agent.Name, agent.Gender)
-						}
-						audioStream, err := p.elevenLabsService.TextToSpeechWithVoice(ctx, aiResponse, voiceID)
-						if err != nil {
-							slog.Error("Failed to generate AI audio", "error", err, "session_id", client.SessionID)
-							// Send text as fallback if audio fails
-							p.sendMessage(client, aiResponse, "text", "")
-						} else {
-							// Read audio data
-							audioData, err := io.ReadAll(audioStream)
-							audioStream.Close()
-							if err != nil {
-								slog.Error("Failed to read AI audio data", "error", err, "session_id", client.SessionID)
-								// Send text as fallback if audio reading fails
-								p.sendMessage(client, aiResponse, "text", "")
-							} else {
-								// Send combined message with both audio and text
-								p.sendCombinedMessage(client, aiResponse, audioData)
-							}
-						}
-					} else {
-						// Send text if agent lookup fails
-						p.sendMessage(client, aiResponse, "text", "")
-					}
-				} else {
-					// Send text if session lookup fails
-					p.sendMessage(client, aiResponse, "text", "")
-				}
-			} else {
-				// Send AI response as text to client if no audio service
-				slog.Info("Sending AI response to client", "session_id", client.SessionID, "response_length", len(aiResponse))
-				p.sendMessage(client, aiResponse, "text", "")
-			}
-		} // close: if p.repo != nil
-	} else {
-		slog.Warn("Gemini service not available for audio transcription", "session_id", client.SessionID)
-		p.sendErrorMessage(client, "AI service not available")
+	// Save AI response to session tracking
+	replyID := uuid.NewString()
+	p.recordTurn(client, models.InterviewTranscript{
+		ID:        replyID,
+		SessionID: client.SessionID,
+		Speaker:   "agent",
+		Content:   aiResponse,
+		Phase:     replyPhase,
+		Stage:     p.flows.Stage(client.SessionID),
+		Timestamp: time.Now(),
+	})
+
+	// Send AI response as audio first, using the agent's voice
+	p.respond(ctx, client, aiResponse, agent, replyID)
+
+	// That was the answer to the candidate's closing question
+	if closingStage == ClosingStageQuestions {
+		p.finishClosing(ctx, client)
 	}
 }
 
-// ProcessTextMessage handles text messages from users
-func (p *AIMessageProcessor) ProcessTextMessage(client *ws.Client, content string) {
-	ctx := context.Background()
+// ProcessTextMessage handles text messages from users, storing how the answer was typed when
+// the client measured it
+func (p *AIMessageProcessor) ProcessTextMessage(client *ws.Client, content string, typing *ws.TypingMetrics) {
+	logger := logging.FromContext(client.Context())
+	ctx, progress := startTurn(client.Context(), client)
 
 	// Update session activity
-	if p.timeoutService != nil && client.SessionID != "" {
-		p.timeoutService.UpdateActivity(client.SessionID)
-
-		// Add user transcript
-		userTranscript := models.InterviewTranscript{
-			SessionID: client.SessionID,
-			Speaker:   "user",
-			Content:   content,
-			TurnOrder: len(client.GetConversationHistory()) + 1,
-			Timestamp: time.Now(),
-		}
-		p.timeoutService.AddTranscript(client.SessionID, userTranscript)
-	}
+	p.timeoutService.UpdateActivity(client.SessionID)
+
+	phase, finalWarmup := p.turnPhase(client.SessionID)
+	stage := p.flows.Stage(client.SessionID)
+
+	// Add user transcript
+	p.recordTurn(client, models.InterviewTranscript{
+		SessionID: client.SessionID,
+		Speaker:   "user",
+		Content:   content,
+		Phase:     phase,
+		Stage:     stage,
+		TurnOrder: len(client.GetConversationHistory()) + 1,
+		Timestamp: time.Now(),
+	})
 
 	// Save user message to database
-	if p.repo != nil {
-		userTranscript := &models.InterviewTranscript{
-			SessionID: client.SessionID,
-			Speaker:   "user",
-			Content:   content,
-			TurnOrder: len(client.GetConversationHistory()) + 1,
-			Timestamp: time.Now(),
-		}
-
-		if err := p.repo.CreateInterviewTranscript(ctx, userTranscript); err != nil {
-			slog.Error("Failed to save user transcript", "error", err, "session_id", client.SessionID)
-		}
+	userTranscript := &models.InterviewTranscript{
+		SessionID: client.SessionID,
+		Speaker:   "user",
+		Content:   content,
+		Phase:     phase,
+		Stage:     stage,
+		Timestamp: time.Now(),
+	}
+	if err := p.repo.CreateInterviewTranscript(ctx, userTranscript); err != nil {
+		logger.Error("Failed to save user transcript", "error", err)
+	} else if typing != nil {
+		p.recordTypingMetrics(ctx, client.SessionID, userTranscript.ID, content, typing)
 	}
 
-	// Handle empty text content with penalty (3 strikes)
+	// Handle empty text content with penalty (3 strikes, more with extra thinking time)
 	if strings.TrimSpace(content) == "" {
-		if p.timeoutService != nil && client.SessionID != "" {
-			count := p.timeoutService.IncrementEmptyResponse(client.SessionID)
-			if count >= 3 {
-				finalMsg := "It seems we've had several attempts without a valid response. We'll end the session here and prepare your summary."
-				p.sendMessage(client, finalMsg, "text", "")
-				// Send end_session message to trigger frontend session end
-				p.sendMessage(client, "Session ended", "end_session", "")
-				p.timeoutService.ConcludeSession(client.SessionID, "Empty response limit reached")
-				return
-			}
-			warning := fmt.Sprintf("I couldn't read a valid response. Please try again. (Warning %d/3)", count)
-			p.sendMessage(client, warning, "text", "")
+		count := p.timeoutService.IncrementEmptyResponse(client.SessionID)
+		if count >= p.timeoutService.Accommodations(client.SessionID).EmptyResponseLimit() {
+			finalMsg := "It seems we've had several attempts without a valid response, so we'll end the session here."
+			p.sendMessage(client, finalMsg, "text", "")
+			p.BeginClosing(client, "Empty response limit reached", false)
 			return
 		}
+		warning := fmt.Sprintf("I couldn't read a valid response. Please try again. (Warning %d/%d)", count, p.timeoutService.Accommodations(client.SessionID).EmptyResponseLimit())
+		p.sendMessage(client, warning, "text", "")
+		return
 	}
 
 	// Reset empty-response counter on valid content
-	if p.timeoutService != nil && client.SessionID != "" {
-		p.timeoutService.ResetEmptyResponse(client.SessionID)
-	}
+	p.timeoutService.ResetEmptyResponse(client.SessionID)
 
 	// Get session and agent from database
 	session, err := p.repo.GetInterviewSession(ctx, client.SessionID)
 	if err != nil {
-		slog.Error("Failed to get interview session", "error", err, "session_id", client.SessionID)
+		logger.Error("Failed to get interview session", "error", err)
 		p.sendErrorMessage(client, "Failed to retrieve interview session")
 		return
 	}
@@ -565,7 +607,7 @@ func (p *AIMessageProcessor) ProcessTextMessage(client *ws.Client, content strin
 	// Get agent details
 	agent, err := p.repo.GetAgent(ctx, session.AgentID)
 	if err != nil {
-		slog.Error("Failed to get agent", "error", err, "agent_id", session.AgentID)
+		logger.Error("Failed to get agent", "error", err, "agent_id", session.AgentID)
 		p.sendErrorMessage(client, "Failed to retrieve interviewer details")
 		return
 	}
@@ -573,207 +615,428 @@ func (p *AIMessageProcessor) ProcessTextMessage(client *ws.Client, content strin
 	// Get conversation history from database
 	transcripts, err := p.repo.GetInterviewTranscripts(ctx, client.SessionID)
 	if err != nil {
-		slog.Error("Failed to get conversation history", "error", err, "session_id", client.SessionID)
+		logger.Error("Failed to get conversation history", "error", err)
 		transcripts = []models.InterviewTranscript{} // Continue with empty history
 	}
 
-	// Generate AI response using Gemini with session cache
-	if p.geminiService != nil {
-		response, err := p.geminiService.GenerateInterviewResponse(ctx, client.SessionID, agent, content, transcripts)
-		if err != nil {
-			slog.Error("Failed to generate AI response", "error", err, "session_id", client.SessionID)
-			p.sendErrorMessage(client, "Failed to generate AI response")
-			return
-		}
-
-		// Update session activity for AI response
-		if p.timeoutService != nil && client.SessionID != "" {
-			p.timeoutService.UpdateActivity(client.SessionID)
-
-			// Add agent transcript
-			agentTranscript := models.InterviewTranscript{
-				SessionID: client.SessionID,
-				Speaker:   "agent",
-				Content:   response,
-				TurnOrder: len(client.GetConversationHistory()) + 2,
-				Timestamp: time.Now(),
-			}
-			p.timeoutService.AddTranscript(client.SessionID, agentTranscript)
-		}
+	closingStage := p.timeoutService.GetClosingStage(client.SessionID)
 
-		// Save agent response to database
-		if p.repo != nil {
-			agentTranscript := &models.InterviewTranscript{
-				SessionID: client.SessionID,
-				Speaker:   "agent",
-				Content:   response,
-				TurnOrder: len(client.GetConversationHistory()) + 1,
-				Timestamp: time.Now(),
-			}
+	// Generate AI response with session cache
+	progress.report(ws.StageThinking)
+	response, replyPhase, err := p.generateReply(ctx, client.SessionID, agent, content, transcripts, phase, finalWarmup)
+	if err != nil {
+		logger.Error("Failed to generate AI response", "error", err)
+		p.sendErrorMessage(client, "Failed to generate AI response")
+		return
+	}
+	if phase != models.TranscriptPhaseWarmup {
+		p.coach(client, session, agent, transcripts, content, userTranscript.ID)
+	}
 
-			if err := p.repo.CreateInterviewTranscript(ctx, agentTranscript); err != nil {
-				slog.Error("Failed to save agent transcript", "error", err, "session_id", client.SessionID)
-			}
-		}
+	// Update session activity for AI response
+	p.timeoutService.UpdateActivity(client.SessionID)
+	stage = p.flows.Stage(client.SessionID)
+
+	// Add agent transcript
+	p.recordTurn(client, models.InterviewTranscript{
+		SessionID: client.SessionID,
+		Speaker:   "agent",
+		Content:   response,
+		Phase:     replyPhase,
+		Stage:     stage,
+		TurnOrder: len(client.GetConversationHistory()) + 2,
+		Timestamp: time.Now(),
+	})
+
+	// Save agent response to database
+	agentTranscript := &models.InterviewTranscript{
+		SessionID: client.SessionID,
+		Speaker:   "agent",
+		Content:   response,
+		Phase:     replyPhase,
+		Stage:     stage,
+		Timestamp: time.Now(),
+	}
+	if err := p.repo.CreateInterviewTranscript(ctx, agentTranscript); err != nil {
+		logger.Error("Failed to save agent transcript", "error", err)
+	}
 
-		// Convert to speech using ElevenLabs
-		if p.elevenLabsService != nil {
-			audioStream, err := p.elevenLabsService.TextToSpeech(ctx, response)
-			if err != nil {
-				slog.Error("Failed to generate speech", "error", err, "session_id", client.SessionID)
-				// Send text response as fallback
-				p.sendTextResponse(client, response)
-				return
-			}
-			defer audioStream.Close()
-
-			// Read audio data and send to client
-			audioData, err := p.readAudioData(audioStream)
-			if err != nil {
-				slog.Error("Failed to read audio data", "error", err, "session_id", client.SessionID)
-				// Send text response as fallback
-				p.sendTextResponse(client, response)
-				return
-			}
+	p.respond(ctx, client, response, agent, agentTranscript.ID)
 
-			// Send audio to client
-			client.SendAudio(audioData)
-		} else {
-			// Send text response if no audio service
-			p.sendTextResponse(client, response)
-		}
-	} else {
-		slog.Warn("Gemini service not available", "session_id", client.SessionID)
-		p.sendErrorMessage(client, "AI service not available")
+	// That was the answer to the candidate's closing question
+	if closingStage == ClosingStageQuestions {
+		p.finishClosing(ctx, client)
 	}
 }
 
 // ProcessCodeMessage handles code submission messages
 func (p *AIMessageProcessor) ProcessCodeMessage(client *ws.Client, content, language string) {
-	ctx := context.Background()
+	logger := logging.FromContext(client.Context())
+	ctx, progress := startTurn(client.Context(), client)
 
 	// Update session activity
-	if p.timeoutService != nil && client.SessionID != "" {
-		p.timeoutService.UpdateActivity(client.SessionID)
-	}
+	p.timeoutService.UpdateActivity(client.SessionID)
 
-	// Analyze code using Gemini
-	if p.geminiService != nil {
-		analysis, err := p.geminiService.AnalyzeCode(ctx, content, language)
-		if err != nil {
-			slog.Error("Failed to analyze code", "error", err, "session_id", client.SessionID)
-			p.sendErrorMessage(client, "Failed to analyze code")
-			return
+	// Grade the submission if it answers a bank question with hidden tests, so the review can
+	// build on the results
+	question := p.llm.CurrentBankQuestion(client.SessionID)
+	if p.tests.Applies(language, question) {
+		progress.report(ws.StageRunningTests)
+	}
+	result, graded := p.tests.Run(ctx, content, language, question)
+	testResults := ""
+	if graded {
+		testResults = result.Summary()
+		// Proctored sessions give no hints, so the candidate doesn't see the results
+		if !p.proctoring.IsProctored(client.SessionID) {
+			p.sendTestResults(client, result)
 		}
+	}
 
-		// Update session activity for AI response
-		if p.timeoutService != nil && client.SessionID != "" {
-			p.timeoutService.UpdateActivity(client.SessionID)
-
-			// Add agent transcript
-			agentTranscript := models.InterviewTranscript{
-				SessionID: client.SessionID,
-				Speaker:   "agent",
-				Content:   analysis,
-				TurnOrder: len(client.GetConversationHistory()) + 1,
-				Timestamp: time.Now(),
-			}
-			p.timeoutService.AddTranscript(client.SessionID, agentTranscript)
-		}
+	// Analyze code, starting from the linters' findings
+	progress.report(ws.StageReviewingCode)
+	findings := p.analyzer.Analyze(ctx, content, language)
+	analyzeCtx, cancel := withTimeout(ctx, p.timeouts.LLM)
+	analysis, err := p.llm.AnalyzeCode(analyzeCtx, content, language, findings, testResults)
+	cancel()
+	if err != nil {
+		logger.Error("Failed to analyze code", "error", err)
+		p.sendErrorMessage(client, "Failed to analyze code")
+		return
+	}
 
-		// Save code analysis to database
-		if p.repo != nil {
-			agentTranscript := &models.InterviewTranscript{
-				SessionID: client.SessionID,
-				Speaker:   "agent",
-				Content:   analysis,
-				TurnOrder: len(client.GetConversationHistory()) + 1,
-				Timestamp: time.Now(),
-			}
+	// Update session activity for AI response
+	p.timeoutService.UpdateActivity(client.SessionID)
+
+	// Save the submission before the analysis so the analysis can link back to it
+	stage := p.flows.Stage(client.SessionID)
+	codeTranscript := models.InterviewTranscript{
+		SessionID: client.SessionID,
+		Speaker:   "user",
+		Content:   content,
+		Kind:      models.TranscriptKindCode,
+		Language:  language,
+		Phase:     models.TranscriptPhaseInterview,
+		Stage:     stage,
+		Timestamp: time.Now(),
+	}
+	if question != nil {
+		codeTranscript.QuestionID = &question.ID
+	}
+	if graded {
+		codeTranscript.TestsPassed = result.Passed
+		codeTranscript.TestsTotal = result.Total
+		codeTranscript.TestOutput = result.Output
+	}
+	if err := p.repo.CreateInterviewTranscript(ctx, &codeTranscript); err != nil {
+		logger.Error("Failed to save code submission transcript", "error", err)
+	}
+	p.recordTurn(client, codeTranscript)
+
+	// Check for copied answers in the background; the flag is for reviewers, not the interview
+	go p.plagiarism.Check(context.Background(), client.UserID, codeTranscript, question)
+
+	analysisTranscript := models.InterviewTranscript{
+		SessionID: client.SessionID,
+		Speaker:   "agent",
+		Content:   analysis,
+		Findings:  findings,
+		Kind:      models.TranscriptKindText,
+		Phase:     models.TranscriptPhaseInterview,
+		Stage:     stage,
+		Timestamp: time.Now(),
+	}
+	if codeTranscript.ID != "" {
+		analysisTranscript.ReplyToID = &codeTranscript.ID
+	}
 
-			if err := p.repo.CreateInterviewTranscript(ctx, agentTranscript); err != nil {
-				slog.Error("Failed to save code analysis transcript", "error", err, "session_id", client.SessionID)
-			}
-		}
+	// Save code analysis to database
+	if err := p.repo.CreateInterviewTranscript(ctx, &analysisTranscript); err != nil {
+		logger.Error("Failed to save code analysis transcript", "error", err)
+	}
+	p.recordTurn(client, analysisTranscript)
 
-		// Convert analysis to speech
-		if p.elevenLabsService != nil {
-			audioStream, err := p.elevenLabsService.TextToSpeech(ctx, analysis)
-			if err != nil {
-				slog.Error("Failed to generate speech for code analysis", "error", err, "session_id", client.SessionID)
-				// Send text response as fallback
-				p.sendTextResponse(client, analysis)
-				return
-			}
-			defer audioStream.Close()
-
-			// Read audio data and send to client
-			audioData, err := p.readAudioData(audioStream)
-			if err != nil {
-				slog.Error("Failed to read audio data", "error", err, "session_id", client.SessionID)
-				// Send text response as fallback
-				p.sendTextResponse(client, analysis)
-				return
-			}
+	// Proctored sessions give no hints, so the review is only kept for the summary
+	reply := analysis
+	if p.proctoring.IsProctored(client.SessionID) {
+		reply = proctoredCodeAck
+	}
+
+	// Code analysis uses the default voice
+	p.respond(ctx, client, reply, nil, analysisTranscript.ID)
+}
+
+// ProcessAudioMessage handles audio messages from users
+func (p *AIMessageProcessor) ProcessAudioMessage(client *ws.Client, audioData []byte, timing *ws.SpeechTiming) {
+	logging.FromContext(client.Context()).Info("Audio received", "audio_size", len(audioData))
+	p.timeoutService.UpdateActivity(client.SessionID)
+	// Delegate to shared processing
+	p.processAudioData(client, audioData, timing)
+}
 
-			// Send audio to client
-			client.SendAudio(audioData)
-		} else {
-			// Send text response if no audio service
-			p.sendTextResponse(client, analysis)
+// BeginClosing starts the end-of-interview sequence. With askQuestions the agent first invites
+// the candidate's questions; otherwise (or if already waiting on questions) it signs off immediately.
+func (p *AIMessageProcessor) BeginClosing(client *ws.Client, reason string, askQuestions bool) {
+	// Not the client's context: the session must be finalized even if the candidate disconnects
+	ctx := context.Background()
+
+	stage := ClosingStageSignOff
+	if askQuestions {
+		stage = ClosingStageQuestions
+	}
+
+	if !p.timeoutService.BeginClosing(client.SessionID, reason, stage) {
+		// Already closing; a hard end skips any remaining questions turn
+		if !askQuestions {
+			p.finishClosing(ctx, client)
 		}
-	} else {
-		slog.Warn("Gemini service not available for code analysis", "session_id", client.SessionID)
-		p.sendErrorMessage(client, "AI service not available")
+		return
 	}
+
+	if !askQuestions {
+		p.finishClosing(ctx, client)
+		return
+	}
+
+	p.speakClosingLine(ctx, client, closingQuestionsPrompt)
+
+	// Sign off anyway if the candidate has no questions
+	time.AfterFunc(p.timeoutService.Accommodations(client.SessionID).Think(closingQuestionWindow), func() {
+		p.finishClosing(context.Background(), client)
+	})
 }
 
-// ProcessAudioMessage handles audio messages from users
-func (p *AIMessageProcessor) ProcessAudioMessage(client *ws.Client, audioData []byte) {
-	slog.Info("Audio received", "session_id", client.SessionID, "audio_size", len(audioData))
-	if p.timeoutService != nil && client.SessionID != "" {
-		p.timeoutService.UpdateActivity(client.SessionID)
+// SaveNote stores a private note the candidate jotted down, against the latest turn. Notes are
+// never added to the conversation, so the interviewer doesn't see them.
+func (p *AIMessageProcessor) SaveNote(client *ws.Client, content string) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return
 	}
-	// Delegate to shared processing
-	p.processAudioData(client, audioData)
+	if len(content) > maxNoteLength {
+		p.sendErrorMessage(client, "Note is too long")
+		return
+	}
+
+	note := models.SessionNote{
+		SessionID: client.SessionID,
+		Content:   content,
+		Private:   true,
+		TurnOrder: len(client.GetConversationHistory()),
+	}
+	if err := p.repo.CreateSessionNote(client.Context(), &note); err != nil {
+		p.sendErrorMessage(client, "Failed to save note")
+		return
+	}
+	p.sendEnvelope(client, ws.TypeNoteSaved, ws.NoteSavedPayload{NoteID: note.ID})
 }
 
-// Helper methods
+// EndSession ends the interview at the candidate's request: the agent signs off, the session
+// is finalized and the connection is closed
+func (p *AIMessageProcessor) EndSession(client *ws.Client, reason string) {
+	p.BeginClosing(client, reason, false)
+	// Close the WebSocket connection after a short delay to allow the messages to be sent
+	go func() {
+		// Wait 200ms to ensure message is sent
+		// (tune as needed for your infra)
+		<-time.After(200 * time.Millisecond)
+		client.Conn.Close()
+	}()
+}
 
-func (p *AIMessageProcessor) readAudioData(audioStream interface{}) ([]byte, error) {
-	// This is a placeholder - you'll need to implement based on your audio stream type
-	// For now, return empty bytes
-	return []byte{}, nil
+// finishClosing delivers the spoken sign-off, tells the client a summary is on its way, then finalizes the session
+func (p *AIMessageProcessor) finishClosing(ctx context.Context, client *ws.Client) {
+	reason, ok := p.timeoutService.CompleteClosing(client.SessionID)
+	if !ok {
+		return
+	}
+
+	p.speakClosingLine(ctx, client, closingSignOff)
+
+	eta := p.timeoutService.EstimateSummaryETA(client.SessionID)
+	p.sendSummaryPending(client, eta)
+
+	// Send end_session message to trigger frontend session end
+	p.sendEnvelope(client, ws.TypeEndSession, ws.EndSessionPayload{Reason: "Session ended"})
+
+	p.eventBus.Publish(ctx, EventSessionEndRequested, client.SessionID, SessionEndRequestedPayload{Reason: reason})
+	logging.FromContext(ctx).Info("Session closing sequence completed", "session_id", client.SessionID, "reason", reason, "summary_eta", eta)
 }
 
-func (p *AIMessageProcessor) sendTextResponse(client *ws.Client, content string) {
-	response := map[string]interface{}{
-		"type":    "text",
-		"content": content,
+// speakClosingLine records and speaks a scripted closing line in the agent's voice
+func (p *AIMessageProcessor) speakClosingLine(ctx context.Context, client *ws.Client, line string) {
+	transcriptID := uuid.NewString()
+	p.recordTurn(client, models.InterviewTranscript{
+		ID:        transcriptID,
+		SessionID: client.SessionID,
+		Speaker:   "agent",
+		Content:   line,
+		Timestamp: time.Now(),
+	})
+
+	var agent *models.Agent
+	if session, err := p.repo.GetInterviewSession(ctx, client.SessionID); err == nil && session != nil {
+		agent, _ = p.repo.GetAgent(ctx, session.AgentID)
 	}
+	p.respond(ctx, client, line, agent, transcriptID)
+}
 
-	responseBytes, err := json.Marshal(response)
+func (p *AIMessageProcessor) sendSummaryPending(client *ws.Client, eta time.Duration) {
+	messageBytes, err := ws.Encode(ws.TypeSummaryPending, ws.SummaryPendingPayload{
+		Message:    fmt.Sprintf("Your interview summary is being prepared and should be ready in about %d seconds.", int(eta.Seconds())),
+		ETASeconds: int(eta.Seconds()),
+	})
 	if err != nil {
-		slog.Error("Failed to marshal text response", "error", err)
+		logging.FromContext(client.Context()).Error("Failed to marshal summary pending message", "error", err)
 		return
 	}
 
-	client.Send <- responseBytes
+	safeSend(client.Send, messageBytes)
 }
 
-func (p *AIMessageProcessor) sendErrorMessage(client *ws.Client, message string) {
-	errorResponse := map[string]interface{}{
-		"type":    "error",
-		"content": message,
+// Helper methods
+
+// respond sends an agent reply as combined audio and text, falling back to text when speech is
+// unavailable. The audio is kept for replay under the reply's transcript ID, if it has one.
+func (p *AIMessageProcessor) respond(ctx context.Context, client *ws.Client, text string, agent *models.Agent, transcriptID string) {
+	// Candidates in text-only mode read every reply, as do sessions over their bandwidth
+	if p.timeoutService.Accommodations(client.SessionID).TextOnly || client.TextMode || p.overBandwidth(client) {
+		p.sendMessage(client, text, "text", "")
+		return
+	}
+
+	voiceID := agentVoiceID(agent)
+	ctx, cancel := withTimeout(ctx, p.timeouts.Speech)
+	defer cancel()
+
+	if _, disabled := p.speech.(NoopSpeechService); !disabled {
+		turnProgressFrom(ctx).report(ws.StageGeneratingAudio)
+	}
+
+	if p.streamSpeech && !p.audio.IsCommonPhrase(text) {
+		if err := p.streamReply(ctx, client, text, voiceID, transcriptID); err != nil {
+			p.sendMessage(client, text, "text", "")
+		}
+		return
+	}
+
+	var (
+		audioData []byte
+		err       error
+	)
+	if p.audio.IsCommonPhrase(text) {
+		// Common phrases and pre-rendered welcomes come from the audio cache
+		audioData, err = p.audio.GetOrGenerate(ctx, text, voiceID, func() (io.ReadCloser, error) {
+			return p.speech.TextToSpeech(ctx, text, voiceID)
+		})
+	} else {
+		audioData, err = synthesizeSpeech(ctx, p.speech, text, voiceID)
+	}
+	if err != nil {
+		p.sendMessage(client, text, "text", "")
+		return
+	}
+
+	// Send combined message with both audio and text
+	p.sendCombinedMessage(client, text, audioData)
+	p.keepReplyAudio(client.SessionID, transcriptID, audioData)
+}
+
+// streamReply speaks text as audio_chunk messages sent while ElevenLabs is still synthesizing,
+// so playback starts early. It returns an error only if nothing was sent, leaving the caller to
+// fall back to text; a stream that breaks off later is ended with the chunk marked last.
+func (p *AIMessageProcessor) streamReply(ctx context.Context, client *ws.Client, text string, voiceID string, transcriptID string) error {
+	logger := logging.FromContext(ctx)
+	stream, err := p.speech.TextToSpeechStream(ctx, text, voiceID)
+	if err != nil {
+		if !errors.Is(err, ErrSpeechDisabled) {
+			logger.Error("Failed to start speech stream", "error", err, "session_id", client.SessionID)
+		}
+		return err
+	}
+	defer stream.Close()
+
+	// A chunk is held back until the next read, so the last one can be marked as such
+	var (
+		pending []byte
+		spoken  []byte // The whole reply, kept for replay
+		sent    int
+	)
+	buf := make([]byte, audioStreamChunkBytes)
+	for {
+		n, readErr := io.ReadFull(stream, buf)
+		if n > 0 {
+			if pending != nil {
+				p.sendAudioChunk(client, text, pending, sent, false)
+				sent++
+			}
+			pending = append([]byte(nil), buf[:n]...)
+			spoken = append(spoken, buf[:n]...)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			if sent == 0 {
+				logger.Error("Speech stream failed", "error", readErr, "session_id", client.SessionID)
+				return readErr
+			}
+			logger.Warn("Speech stream broke off", "error", readErr, "session_id", client.SessionID, "chunks", sent)
+			break
+		}
+	}
+	if sent == 0 && pending == nil {
+		return fmt.Errorf("speech stream was empty")
+	}
+
+	p.sendAudioChunk(client, text, pending, sent, true)
+	p.keepReplyAudio(client.SessionID, transcriptID, spoken)
+	logger.Info("Audio reply streamed to client", "session_id", client.SessionID, "chunks", sent+1, "audio_size", len(spoken))
+	return nil
+}
+
+// sendAudioChunk sends one piece of a streamed reply; the text goes with the first
+func (p *AIMessageProcessor) sendAudioChunk(client *ws.Client, text string, audioData []byte, index int, last bool) {
+	payload := ws.AudioReplyChunkPayload{
+		AudioData:   audioData,
+		ChunkIndex:  index,
+		IsLastChunk: last,
+	}
+	if index == 0 {
+		payload.Content = text
+	}
+	p.sendEnvelope(client, ws.TypeAudioChunk, payload)
+}
+
+// sendTestResults shows the candidate how their code did against the hidden tests, without
+// the tests' names or output, which would give the tests away; the output stays with the code
+// transcript for reviewers
+func (p *AIMessageProcessor) sendTestResults(client *ws.Client, result TestResult) {
+	tests := make([]ws.TestCasePayload, len(result.Tests))
+	for i, test := range result.Tests {
+		tests[i] = ws.TestCasePayload{Passed: test.Passed}
 	}
+	p.sendEnvelope(client, ws.TypeTestResults, ws.TestResultsPayload{
+		Passed:   result.Passed,
+		Total:    result.Total,
+		Tests:    tests,
+		TimedOut: result.TimedOut,
+	})
+}
 
-	errorBytes, err := json.Marshal(errorResponse)
+func (p *AIMessageProcessor) sendErrorMessage(client *ws.Client, message string) {
+	client.SendError(ws.ErrCodeProcessingFailed, message)
+}
+
+// sendEnvelope sends a message that needs no logging of its own
+func (p *AIMessageProcessor) sendEnvelope(client *ws.Client, msgType string, payload any) {
+	messageBytes, err := ws.Encode(msgType, payload)
 	if err != nil {
-		slog.Error("Failed to marshal error response", "error", err)
+		logging.FromContext(client.Context()).Error("Failed to marshal message", "error", err, "type", msgType)
 		return
 	}
 
-	client.Send <- errorBytes
+	safeSend(client.Send, messageBytes)
 }
 
 func (p *AIMessageProcessor) decodeBase64Audio(audioData []byte) ([]byte, error) {
@@ -834,3 +1097,184 @@ func (p *AIMessageProcessor) isSessionEndingResponse(response string) bool {
 
 	return false
 }
+
+// prepareSession starts the agent's interview flow and passes the session's resume and job
+// description to the LLM. A drill session gets its topics instead of the flow and question bank.
+func (p *AIMessageProcessor) prepareSession(ctx context.Context, client *ws.Client) {
+	logger := logging.FromContext(ctx)
+	sessionID := client.SessionID
+	session, err := p.repo.GetInterviewSession(ctx, sessionID)
+	if err != nil || session == nil {
+		return
+	}
+	p.proctoring.Start(client, session)
+
+	p.llm.SetAccommodations(sessionID, p.timeoutService.Accommodations(sessionID))
+	p.llm.SetTextMode(sessionID, session.Mode == models.SessionModeText)
+	p.llm.SetInterviewType(sessionID, session.InterviewType)
+	agent, err := p.repo.GetAgent(ctx, session.AgentID)
+	if err == nil && agent != nil {
+		p.llm.SetBannedTopics(sessionID, p.compliance.Load(ctx, sessionID, agent))
+		p.timeoutService.SetPacing(sessionID, AgentPacing(agent))
+		p.llm.SetPacing(sessionID, AgentPacing(agent))
+	}
+
+	drill, err := p.repo.GetDrillPlan(ctx, sessionID)
+	if err != nil {
+		return
+	}
+	if drill != nil {
+		var topics []string
+		if err := json.Unmarshal([]byte(drill.Topics), &topics); err != nil {
+			logger.Error("Invalid drill topics", "error", err, "session_id", sessionID)
+		}
+		p.llm.SetDrillTopics(sessionID, topics)
+	} else if agent != nil {
+		p.flows.Start(sessionID, agent, func(stage *models.FlowStage, transition string) {
+			p.speakTransition(client, agent, stage, transition)
+		})
+		p.loadQuestionBank(ctx, sessionID, agent)
+	}
+
+	if session.ResumeID == nil && session.JobDescriptionID == nil {
+		return
+	}
+
+	candidateContext, err := p.documents.CandidateContext(ctx, session)
+	if err != nil {
+		logger.Error("Failed to load candidate context", "error", err, "session_id", sessionID)
+		return
+	}
+	p.llm.SetCandidateContext(sessionID, candidateContext)
+}
+
+// loadQuestionBank hands the agent's question bank to the LLM. A session that already has
+// one, e.g. after a reconnect, keeps its progress.
+func (p *AIMessageProcessor) loadQuestionBank(ctx context.Context, sessionID string, agent *models.Agent) {
+	logger := logging.FromContext(ctx)
+	if agent.QuestionBankID == nil {
+		return
+	}
+
+	p.bankMutex.Lock()
+	defer p.bankMutex.Unlock()
+	if p.bankSessions[sessionID] {
+		return
+	}
+
+	questions, err := p.repo.GetBankQuestions(ctx, *agent.QuestionBankID)
+	if err != nil {
+		logger.Error("Failed to load question bank, using free-form questions", "error", err, "session_id", sessionID)
+		return
+	}
+	p.llm.SetQuestionBank(sessionID, questions)
+	p.bankSessions[sessionID] = true
+	logger.Info("Question bank loaded", "session_id", sessionID, "bank_id", *agent.QuestionBankID, "questions", len(questions))
+}
+
+// HandleSessionConcluded drops the question bank and banned topics of a finished session
+func (p *AIMessageProcessor) HandleSessionConcluded(ctx context.Context, event Event) error {
+	p.compliance.Forget(event.SessionID)
+
+	p.bankMutex.Lock()
+	delete(p.bankSessions, event.SessionID)
+	p.bankMutex.Unlock()
+
+	// Also drops the question bank
+	p.llm.ClearSessionCache(event.SessionID)
+	return nil
+}
+
+// HandleDisconnect cleans up after a client's connection closes. A running session keeps its
+// state for the candidate to reconnect to until it is concluded; a session that has already
+// ended drops its interviewer state now.
+func (p *AIMessageProcessor) HandleDisconnect(client *ws.Client) {
+	p.timeoutService.DetachClient(client.SessionID)
+	if !p.timeoutService.IsActive(client.SessionID) {
+		p.llm.ClearSessionCache(client.SessionID)
+	}
+	logging.FromContext(client.Context()).Info("WebSocket client disconnected", "connected_for", time.Since(client.ConnectedAt).Round(time.Second))
+}
+
+// speakTransition records and speaks the line moving the interview on when a flow stage's
+// time box runs out between turns
+func (p *AIMessageProcessor) speakTransition(client *ws.Client, agent *models.Agent, stage *models.FlowStage, transition string) {
+	if p.timeoutService.GetClosingStage(client.SessionID) != ClosingStageNone {
+		return
+	}
+
+	transcriptID := uuid.NewString()
+	p.recordTurn(client, models.InterviewTranscript{
+		ID:        transcriptID,
+		SessionID: client.SessionID,
+		Speaker:   "agent",
+		Content:   transition,
+		Phase:     models.TranscriptPhaseInterview,
+		Stage:     stage.ID,
+		Timestamp: time.Now(),
+	})
+	p.respond(client.Context(), client, transition, agent, transcriptID)
+}
+
+// reportTimeRemaining tells the client how much interview time is left until it disconnects
+// or the session ends, and starts the closing sequence when the time runs out
+func (p *AIMessageProcessor) reportTimeRemaining(client *ws.Client) {
+	for {
+		remaining, active := p.timeoutService.RemainingTime(client.SessionID)
+		if !active {
+			return
+		}
+		p.sendTimeRemaining(client, remaining)
+		p.nudgeDwell(client)
+
+		if remaining == 0 {
+			if p.timeoutService.GetClosingStage(client.SessionID) == ClosingStageNone {
+				session, err := p.repo.GetInterviewSession(client.Context(), client.SessionID)
+				if err == nil && session != nil {
+					p.closeExpiredInterview(client, session)
+				}
+			}
+			return
+		}
+
+		select {
+		case <-client.Done():
+			return
+		case <-time.After(min(remaining, timeRemainingInterval)):
+		}
+	}
+}
+
+// closeExpiredInterview announces that the time limit was reached and begins closing
+func (p *AIMessageProcessor) closeExpiredInterview(client *ws.Client, session *models.InterviewSession) {
+	minutes := session.DurationMinutes
+	if minutes <= 0 {
+		minutes = DefaultInterviewMinutes
+	}
+	logging.FromContext(client.Context()).Info("Interview time limit exceeded", "minutes", minutes)
+	p.sendMessage(client, fmt.Sprintf("We've reached the %d-minute interview limit.", minutes), "text", "")
+	p.BeginClosing(client, "Interview time limit reached", true)
+}
+
+func (p *AIMessageProcessor) sendTimeRemaining(client *ws.Client, remaining time.Duration) {
+	seconds := int(remaining.Round(time.Second).Seconds())
+	messageBytes, err := ws.Encode(ws.TypeTimeRemaining, ws.TimeRemainingPayload{RemainingSeconds: seconds})
+	if err != nil {
+		logging.FromContext(client.Context()).Error("Failed to marshal time remaining message", "error", err)
+		return
+	}
+
+	safeSend(client.Send, messageBytes)
+}
+
+// agentVoiceID returns the agent's voice, falling back to one picked from its name and
+// gender, or "" for the default voice when there is no agent
+func agentVoiceID(agent *models.Agent) string {
+	if agent == nil {
+		return ""
+	}
+	if agent.VoiceID != "" {
+		return agent.VoiceID
+	}
+	return PickDeterministicVoice(agent.Name, agent.Gender)
+}
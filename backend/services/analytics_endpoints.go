@@ -0,0 +1,185 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// defaultAnalyticsDays is how far back analytics look unless ?days says otherwise
+	defaultAnalyticsDays = 90
+	maxAnalyticsDays     = 730
+	// defaultWeakestMetrics is how many weakest skills are listed unless ?limit says otherwise
+	defaultWeakestMetrics = 3
+)
+
+// analyticsPeriods are the periods a score trend can be averaged over
+var analyticsPeriods = map[string]bool{"day": true, "week": true, "month": true}
+
+// AnalyticsEndpoints reports a user's progress across their completed sessions. Everything is
+// aggregated by the database, so a long history costs no more memory than a short one.
+type AnalyticsEndpoints struct {
+	repo *repository.GORMRepository
+}
+
+func NewAnalyticsEndpoints(repo *repository.GORMRepository) *AnalyticsEndpoints {
+	return &AnalyticsEndpoints{repo: repo}
+}
+
+func (e *AnalyticsEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/analytics", func(r chi.Router) {
+		r.Get("/trend", e.GetScoreTrendHandler)
+		r.Get("/metrics", e.GetMetricStatsHandler)
+		r.Get("/industries", e.GetIndustryStatsHandler)
+		r.Get("/weakest", e.GetWeakestMetricsHandler)
+	})
+}
+
+// GetScoreTrendHandler averages overall scores per ?period (day, week or month; default week)
+// over the last ?days, in the user's timezone
+func (e *AnalyticsEndpoints) GetScoreTrendHandler(w http.ResponseWriter, r *http.Request) {
+	user, since, ok := analyticsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "week"
+	}
+	if !analyticsPeriods[period] {
+		apperrors.Write(w, r, apperrors.BadRequest("period must be day, week or month"))
+		return
+	}
+	// Postgres and Go share the IANA names; anything Go can't load is treated as UTC
+	timezone := "UTC"
+	if _, err := time.LoadLocation(user.Timezone); user.Timezone != "" && err == nil {
+		timezone = user.Timezone
+	}
+
+	points, err := e.repo.GetUserScoreTrend(r.Context(), user.ID, since, period, timezone)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get score trend"))
+		return
+	}
+	if points == nil {
+		points = []models.ScoreTrendPoint{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"since":    since,
+		"period":   period,
+		"timezone": timezone,
+		"trend":    points,
+	})
+}
+
+// GetMetricStatsHandler averages each performance metric, out of 100, over the last ?days
+func (e *AnalyticsEndpoints) GetMetricStatsHandler(w http.ResponseWriter, r *http.Request) {
+	user, since, ok := analyticsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := e.repo.GetUserMetricStats(r.Context(), user.ID, since)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get metric stats"))
+		return
+	}
+	if stats == nil {
+		stats = []models.MetricStats{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"since":   since,
+		"metrics": stats,
+	})
+}
+
+// GetIndustryStatsHandler averages overall scores per agent industry over the last ?days
+func (e *AnalyticsEndpoints) GetIndustryStatsHandler(w http.ResponseWriter, r *http.Request) {
+	user, since, ok := analyticsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := e.repo.GetUserIndustryStats(r.Context(), user.ID, since)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get industry stats"))
+		return
+	}
+	if stats == nil {
+		stats = []models.IndustryStats{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"since":      since,
+		"industries": stats,
+	})
+}
+
+// GetWeakestMetricsHandler lists the ?limit (default 3) metrics the user averages lowest in
+// over the last ?days
+func (e *AnalyticsEndpoints) GetWeakestMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	user, since, ok := analyticsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultWeakestMetrics
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > 20 {
+			apperrors.Write(w, r, apperrors.BadRequest("limit must be between 1 and 20"))
+			return
+		}
+		limit = parsed
+	}
+
+	stats, err := e.repo.GetUserWeakestMetrics(r.Context(), user.ID, since, limit)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get weakest metrics"))
+		return
+	}
+	if stats == nil {
+		stats = []models.MetricStats{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"since":   since,
+		"weakest": stats,
+	})
+}
+
+// analyticsRequest gets the user and the start of the ?days window, writing the error response
+// if either is missing or invalid
+func analyticsRequest(w http.ResponseWriter, r *http.Request) (*models.User, time.Time, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return nil, time.Time{}, false
+	}
+
+	days := defaultAnalyticsDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 || parsed > maxAnalyticsDays {
+			apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("days must be between 1 and %d", maxAnalyticsDays)))
+			return nil, time.Time{}, false
+		}
+		days = parsed
+	}
+	return user, time.Now().AddDate(0, 0, -days), true
+}
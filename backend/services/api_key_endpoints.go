@@ -0,0 +1,118 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// APIKeyEndpoints lets users manage the API keys their integrations use
+type APIKeyEndpoints struct {
+	repo *repository.GORMRepository
+	keys *APIKeyService
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`                    // read:sessions, write:agents
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // Never expires if 0
+}
+
+func NewAPIKeyEndpoints(repo *repository.GORMRepository, keys *APIKeyService) *APIKeyEndpoints {
+	return &APIKeyEndpoints{
+		repo: repo,
+		keys: keys,
+	}
+}
+
+// RegisterRoutes registers the key management routes; API keys themselves can't reach them
+func (e *APIKeyEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/apikeys", func(r chi.Router) {
+		r.Get("/", e.GetKeysHandler)
+		r.Post("/", e.CreateKeyHandler)
+		r.Delete("/{id}", e.RevokeKeyHandler)
+	})
+}
+
+func (e *APIKeyEndpoints) GetKeysHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	keys, err := e.repo.GetUserAPIKeys(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get API keys"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"api_keys": keys,
+		"count":    len(keys),
+	})
+}
+
+// CreateKeyHandler creates an API key. The key is only returned in this response. Admins
+// impersonating the user can't create keys, which would outlive the impersonation.
+func (e *APIKeyEndpoints) CreateKeyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+	if _, impersonated := r.Context().Value("impersonation").(*models.Impersonation); impersonated {
+		apperrors.Write(w, r, apperrors.Forbidden("API keys can't be created while impersonating"))
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	key, record, err := e.keys.Mint(r.Context(), user, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to create API key", "error", err)
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     key,
+		"api_key": record,
+	})
+}
+
+func (e *APIKeyEndpoints) RevokeKeyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	keyID := chi.URLParam(r, "id")
+	revoked, err := e.repo.RevokeAPIKey(r.Context(), keyID, user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to revoke API key"))
+		return
+	}
+	if !revoked {
+		apperrors.Write(w, r, apperrors.NotFound("API key not found"))
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("API key revoked", "key_id", keyID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "API key revoked",
+	})
+}
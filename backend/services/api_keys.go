@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// API key scopes
+const (
+	ScopeReadSessions = "read:sessions" // Sessions, their summaries, score explanations and transcript search
+	ScopeWriteAgents  = "write:agents"  // Listing, creating, updating and deleting agents
+)
+
+const (
+	// APIKeyPrefix starts every API key, so a bearer key is told apart from other tokens
+	APIKeyPrefix       = "pxk_"
+	maxAPIKeysPerUser  = 20
+	maxAPIKeyTTLDays   = 365
+	apiKeyPrefixLength = len(APIKeyPrefix) + 8
+)
+
+var apiKeyScopes = []string{ScopeReadSessions, ScopeWriteAgents}
+
+// APIKeyScope returns the scope an API key needs for a request, or "" if keys can't be used
+// for it. Keys never reach account, key management or WebSocket routes. Paths are scoped the
+// same in every API version.
+func APIKeyScope(method, path string) string {
+	for _, prefix := range []string{APIV1Prefix, APIV2Prefix} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+			path = rest
+			break
+		}
+	}
+	underAny := func(prefixes ...string) bool {
+		for _, prefix := range prefixes {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case underAny("/agents"):
+		return ScopeWriteAgents
+	case method == http.MethodGet && underAny("/sessions", "/summaries", "/explanations", "/search"):
+		return ScopeReadSessions
+	}
+	return ""
+}
+
+// APIKeyService mints API keys and authenticates requests made with them
+type APIKeyService struct {
+	repo *repository.GORMRepository
+	auth *AuthService
+}
+
+func NewAPIKeyService(repo *repository.GORMRepository, auth *AuthService) *APIKeyService {
+	return &APIKeyService{
+		repo: repo,
+		auth: auth,
+	}
+}
+
+// Mint creates a key for the user with the given scopes, expiring after ttlDays or never if 0.
+// The plaintext key is only returned here. Keys aren't minted for an impersonating admin.
+func (s *APIKeyService) Mint(ctx context.Context, user *models.User, name string, scopes []string, ttlDays int) (string, *models.APIKey, error) {
+	if _, impersonated := ctx.Value("impersonation").(*models.Impersonation); impersonated {
+		return "", nil, fmt.Errorf("API keys can't be created while impersonating")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > 100 {
+		return "", nil, fmt.Errorf("name must be 1-100 characters")
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("at least one scope is required: %s", strings.Join(apiKeyScopes, ", "))
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return "", nil, fmt.Errorf("unknown scope %q: must be one of %s", scope, strings.Join(apiKeyScopes, ", "))
+		}
+	}
+	if ttlDays < 0 || ttlDays > maxAPIKeyTTLDays {
+		return "", nil, fmt.Errorf("expires_in_days must be between 0 (never) and %d", maxAPIKeyTTLDays)
+	}
+
+	count, err := s.repo.CountUserAPIKeys(ctx, user.ID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	if count >= maxAPIKeysPerUser {
+		return "", nil, fmt.Errorf("at most %d API keys are allowed; revoke one first", maxAPIKeysPerUser)
+	}
+
+	secret, err := s.auth.generateSecureToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := APIKeyPrefix + secret
+
+	slices.Sort(scopes)
+	record := &models.APIKey{
+		UserID: user.ID,
+		Name:   name,
+		Prefix: key[:apiKeyPrefixLength],
+		Key:    s.auth.hashToken(key),
+		Scopes: strings.Join(slices.Compact(scopes), ","),
+	}
+	if ttlDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, ttlDays)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.CreateAPIKey(ctx, record); err != nil {
+		return "", nil, fmt.Errorf("failed to store API key: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("API key created", "key_id", record.ID, "user_id", user.ID, "scopes", record.Scopes)
+	return key, record, nil
+}
+
+// Middleware authenticates requests bearing an API key, and leaves every other request to
+// cookieAuth. A key acts as its user, only on the routes its scopes cover.
+func (s *APIKeyService) Middleware(cookieAuth func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		cookieNext := cookieAuth(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || !strings.HasPrefix(key, APIKeyPrefix) {
+				cookieNext.ServeHTTP(w, r)
+				return
+			}
+
+			record, err := s.repo.GetAPIKey(r.Context(), s.auth.hashToken(key))
+			if err != nil {
+				apperrors.Write(w, r, apperrors.Internal("Failed to verify API key"))
+				return
+			}
+			if record == nil || record.User.ID == "" {
+				apperrors.Write(w, r, apperrors.Unauthorized("Unauthorized"))
+				return
+			}
+			scope := APIKeyScope(r.Method, r.URL.Path)
+			if scope == "" || !slices.Contains(strings.Split(record.Scopes, ","), scope) {
+				logging.FromContext(r.Context()).Warn("API key rejected", "key_id", record.ID, "method", r.Method, "path", r.URL.Path, "scope", scope)
+				apperrors.Write(w, r, apperrors.Forbidden("Forbidden: API key lacks the scope for this endpoint"))
+				return
+			}
+
+			go s.repo.TouchAPIKey(context.Background(), record.ID)
+
+			ctx := context.WithValue(logging.With(r.Context(), "user_id", record.User.ID, "api_key_id", record.ID), "user", &record.User)
+			ctx = context.WithValue(ctx, "api_key", record)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// API versions are mounted side by side. A v1 endpoint whose response has to change keeps its
+// shape and is marked deprecated; the fixed shape ships under /api/v2 at the same path. Both
+// versions render the same result, so the v1 handler is only a compatibility shim over it.
+const (
+	APIV1Prefix = "/api/v1"
+	APIV2Prefix = "/api/v2"
+)
+
+// APIDeprecation announces that a v1 endpoint will change once its sunset passes
+type APIDeprecation struct {
+	Since  time.Time // When the endpoint was deprecated
+	Sunset time.Time // After this it may change or be removed
+}
+
+// summaryV1Deprecation covers GET /summaries/session/{id}, whose body takes one of three shapes
+// depending on whether the summary is ready, queued or failed
+var summaryV1Deprecation = APIDeprecation{
+	Since:  time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+	Sunset: time.Date(2027, time.April, 16, 0, 0, 0, 0, time.UTC),
+}
+
+// Deprecated marks the responses of a v1 endpoint with Deprecation (RFC 9745) and Sunset
+// (RFC 8594) headers, and a Link to the same path under /api/v2. The endpoint keeps working as
+// before, so existing clients have until the sunset to move.
+func Deprecated(deprecation APIDeprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+			w.Header().Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+			if successor, ok := strings.CutPrefix(r.URL.Path, APIV1Prefix); ok {
+				w.Header().Add("Link", "<"+APIV2Prefix+successor+`>; rel="successor-version"`)
+			}
+			logging.FromContext(r.Context()).Debug("Deprecated endpoint called", "path", r.URL.Path, "user_agent", r.UserAgent())
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -10,12 +10,17 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/krshsl/praxis/backend/logging"
 )
 
 // AudioCache provides filesystem-based caching for ElevenLabs audio
 type AudioCache struct {
 	cacheDir string
 	mutex    sync.RWMutex
+	// Generated phrases that are reused across sessions, e.g. welcome messages
+	pinned      map[string]bool
+	pinnedMutex sync.RWMutex
 }
 
 // Common phrases that should be cached
@@ -55,9 +60,17 @@ func NewAudioCache(cacheDir string) *AudioCache {
 
 	return &AudioCache{
 		cacheDir: cacheDir,
+		pinned:   make(map[string]bool),
 	}
 }
 
+// Pin marks a generated phrase as cacheable alongside the common phrases
+func (ac *AudioCache) Pin(text string) {
+	ac.pinnedMutex.Lock()
+	defer ac.pinnedMutex.Unlock()
+	ac.pinned[text] = true
+}
+
 // generateCacheKey creates a unique key for caching based on text and voice ID
 func (ac *AudioCache) generateCacheKey(text, voiceID string) string {
 	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%s", text, voiceID)))
@@ -71,11 +84,18 @@ func (ac *AudioCache) getCachePath(key string) string {
 
 // IsCommonPhrase checks if the given text is a common phrase that should be cached
 func (ac *AudioCache) IsCommonPhrase(text string) bool {
-	return CommonPhrases[text]
+	if CommonPhrases[text] {
+		return true
+	}
+
+	ac.pinnedMutex.RLock()
+	defer ac.pinnedMutex.RUnlock()
+	return ac.pinned[text]
 }
 
 // Get retrieves cached audio data if it exists
 func (ac *AudioCache) Get(ctx context.Context, text, voiceID string) ([]byte, bool) {
+	logger := logging.FromContext(ctx)
 	if !ac.IsCommonPhrase(text) {
 		return nil, false
 	}
@@ -89,17 +109,18 @@ func (ac *AudioCache) Get(ctx context.Context, text, voiceID string) ([]byte, bo
 	data, err := os.ReadFile(cachePath)
 	if err != nil {
 		if !os.IsNotExist(err) {
-			slog.Error("Failed to read cached audio", "path", cachePath, "error", err)
+			logger.Error("Failed to read cached audio", "path", cachePath, "error", err)
 		}
 		return nil, false
 	}
 
-	slog.Info("Cache hit for common phrase", "text", text, "voice_id", voiceID)
+	logger.Info("Cache hit for common phrase", "text", text, "voice_id", voiceID)
 	return data, true
 }
 
 // Set stores audio data in the cache
 func (ac *AudioCache) Set(ctx context.Context, text, voiceID string, audioData []byte) error {
+	logger := logging.FromContext(ctx)
 	if !ac.IsCommonPhrase(text) {
 		return nil // Don't cache non-common phrases
 	}
@@ -112,11 +133,11 @@ func (ac *AudioCache) Set(ctx context.Context, text, voiceID string, audioData [
 
 	err := os.WriteFile(cachePath, audioData, 0644)
 	if err != nil {
-		slog.Error("Failed to write audio to cache", "path", cachePath, "error", err)
+		logger.Error("Failed to write audio to cache", "path", cachePath, "error", err)
 		return err
 	}
 
-	slog.Info("Cached common phrase audio", "text", text, "voice_id", voiceID, "size", len(audioData))
+	logger.Info("Cached common phrase audio", "text", text, "voice_id", voiceID, "size", len(audioData))
 	return nil
 }
 
@@ -143,7 +164,7 @@ func (ac *AudioCache) GetOrGenerate(ctx context.Context, text, voiceID string, g
 	// Cache if it's a common phrase
 	if ac.IsCommonPhrase(text) {
 		if err := ac.Set(ctx, text, voiceID, audioData); err != nil {
-			slog.Warn("Failed to cache audio", "error", err)
+			logging.FromContext(ctx).Warn("Failed to cache audio", "error", err)
 		}
 	}
 
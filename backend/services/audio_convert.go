@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// convertToWAV converts recorded audio, such as WebM, to 16 kHz mono 16-bit PCM WAV with ffmpeg,
+// run by transcodes. Its scratch files come from temp, so they are cleaned up even if ffmpeg
+// hangs or the process crashes mid-conversion.
+func convertToWAV(ctx context.Context, temp *TempFiles, transcodes *TranscodePool, audio []byte) ([]byte, error) {
+	input, err := temp.Create("input-*.webm", int64(len(audio)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input temp file: %w", err)
+	}
+	defer input.Release()
+
+	// PCM at 16 kHz is rarely more than ten times the size of compressed speech
+	output, err := temp.Create("output-*.wav", 10*int64(len(audio)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output temp file: %w", err)
+	}
+	defer output.Release()
+
+	if err := input.Write(audio); err != nil {
+		return nil, fmt.Errorf("failed to write audio: %w", err)
+	}
+
+	err = transcodes.Run(ctx,
+		"-i", input.Path(), // Input file
+		"-acodec", "pcm_s16le", // Audio codec (16-bit PCM)
+		"-ar", "16000", // Sample rate (16kHz)
+		"-ac", "1", // Mono channel
+		"-y",          // Overwrite output file
+		output.Path(), // Output file
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg conversion failed: %w", err)
+	}
+	output.Refresh()
+
+	wavData, err := os.ReadFile(output.Path())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read converted WAV file: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Audio conversion completed", "input_size", len(audio), "wav_size", len(wavData))
+	return wavData, nil
+}
@@ -9,9 +9,14 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"github.com/krshsl/praxis/backend/repository"
 	"golang.org/x/crypto/bcrypt"
@@ -19,12 +24,26 @@ import (
 
 type AuthService struct {
 	repo            *repository.GORMRepository
-	jwtSecret       []byte
+	geo             *GeoResolver
+	eventBus        *EventBus
+	jwtKeys         jwtKeys
 	accessExpiry    time.Duration
 	refreshExpiry   time.Duration
 	permanentExpiry time.Duration
 }
 
+// jwtRotationGrace is how long tokens signed with a rotated-out secret stay valid. It covers
+// access and impersonation tokens; older "this wasn't me" links stop working after it.
+const jwtRotationGrace = 24 * time.Hour
+
+// jwtKeys is the JWT signing secret and, during a rotation, the previous secret
+type jwtKeys struct {
+	current       []byte
+	previous      []byte
+	previousUntil time.Time
+	mutex         sync.RWMutex
+}
+
 type CookieClaims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
@@ -39,14 +58,50 @@ type AuthResponse struct {
 	PermanentToken string       `json:"permanent_token,omitempty"`
 }
 
-func NewAuthService(repo *repository.GORMRepository, jwtSecret string) *AuthService {
+func NewAuthService(repo *repository.GORMRepository, jwtSecret string, geo *GeoResolver, eventBus *EventBus) *AuthService {
 	return &AuthService{
 		repo:            repo,
-		jwtSecret:       []byte(jwtSecret),
-		accessExpiry:    5 * time.Minute,     // 5 minutes
-		refreshExpiry:   7 * 24 * time.Hour,  // 7 days
-		permanentExpiry: 30 * 24 * time.Hour, // 30 days
+		geo:             geo,
+		eventBus:        eventBus,
+		jwtKeys:         jwtKeys{current: []byte(jwtSecret)},
+		accessExpiry:    5 * time.Minute,    // 5 minutes
+		refreshExpiry:   7 * 24 * time.Hour, // 7 days
+		permanentExpiry: models.PermanentTokenLifetime,
+	}
+}
+
+// RotateJWTSecret signs new tokens with secret while still accepting tokens signed with
+// the previous secret for jwtRotationGrace, so rotation doesn't sign anyone out
+func (s *AuthService) RotateJWTSecret(secret string) {
+	s.jwtKeys.mutex.Lock()
+	defer s.jwtKeys.mutex.Unlock()
+
+	s.jwtKeys.previous = s.jwtKeys.current
+	s.jwtKeys.previousUntil = time.Now().Add(jwtRotationGrace)
+	s.jwtKeys.current = []byte(secret)
+	slog.Warn("JWT secret rotated", "previous_valid_until", s.jwtKeys.previousUntil)
+}
+
+// signingKey returns the secret new tokens are signed with
+func (s *AuthService) signingKey() []byte {
+	s.jwtKeys.mutex.RLock()
+	defer s.jwtKeys.mutex.RUnlock()
+	return s.jwtKeys.current
+}
+
+// verificationKeys is the jwt.Keyfunc for all tokens signed by this service
+func (s *AuthService) verificationKeys(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	s.jwtKeys.mutex.RLock()
+	defer s.jwtKeys.mutex.RUnlock()
+	keys := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{s.jwtKeys.current}}
+	if s.jwtKeys.previous != nil && time.Now().Before(s.jwtKeys.previousUntil) {
+		keys.Keys = append(keys.Keys, s.jwtKeys.previous)
 	}
+	return keys, nil
 }
 
 // generateSecureToken generates a cryptographically secure random token
@@ -64,8 +119,14 @@ func (s *AuthService) hashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// Login authenticates user and creates tokens
-func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthResponse, error) {
+// TrustedDevice is a permanent token as shown in the user's device list
+type TrustedDevice struct {
+	models.PermanentToken
+	Current bool `json:"current"` // The device making the request
+}
+
+// Login authenticates user and creates tokens bound to the device
+func (s *AuthService) Login(ctx context.Context, email, password string, device DeviceInfo) (*AuthResponse, error) {
 	// Get user by email
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
@@ -97,11 +158,13 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthR
 	}
 
 	// Store tokens in database
-	if err := s.storeTokens(ctx, user.ID, refreshToken, permanentToken); err != nil {
+	permanentTokenID, err := s.storeTokens(ctx, user.ID, refreshToken, permanentToken, device)
+	if err != nil {
 		return nil, fmt.Errorf("failed to store tokens: %w", err)
 	}
+	s.observeDevice(ctx, user.ID, permanentTokenID, device, LoginTriggerPassword)
 
-	slog.Info("User logged in successfully", "user_id", user.ID, "email", user.Email)
+	logging.FromContext(ctx).Info("User logged in successfully", "user_id", user.ID, "email", user.Email)
 	return &AuthResponse{
 		User:           user,
 		AccessToken:    accessToken,
@@ -110,8 +173,8 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthR
 	}, nil
 }
 
-// Signup creates a new user
-func (s *AuthService) Signup(ctx context.Context, email, password, fullName string) (*AuthResponse, error) {
+// Signup creates a new user with defaults derived from the client's region
+func (s *AuthService) Signup(ctx context.Context, email, password, fullName string, region ClientRegion, device DeviceInfo) (*AuthResponse, error) {
 	// Check if user already exists
 	existingUser, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
@@ -133,6 +196,11 @@ func (s *AuthService) Signup(ctx context.Context, email, password, fullName stri
 		Password: string(hashedPassword),
 		FullName: fullName,
 		Role:     "user",
+
+		Country:         region.Country,
+		Jurisdiction:    region.Jurisdiction,
+		Language:        region.Language,
+		ConsentRequired: region.ConsentRequired,
 	}
 
 	if err := s.repo.CreateUser(ctx, user); err != nil {
@@ -156,11 +224,43 @@ func (s *AuthService) Signup(ctx context.Context, email, password, fullName stri
 	}
 
 	// Store tokens in database
-	if err := s.storeTokens(ctx, user.ID, refreshToken, permanentToken); err != nil {
+	permanentTokenID, err := s.storeTokens(ctx, user.ID, refreshToken, permanentToken, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store tokens: %w", err)
+	}
+	s.observeDevice(ctx, user.ID, permanentTokenID, device, LoginTriggerPassword)
+
+	logging.FromContext(ctx).Info("User signed up successfully", "user_id", user.ID, "email", user.Email)
+	return &AuthResponse{
+		User:           user,
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		PermanentToken: permanentToken,
+	}, nil
+}
+
+// SignInGuest creates tokens for a guest account just created for an invited candidate, bound
+// to their device like a login's
+func (s *AuthService) SignInGuest(ctx context.Context, user *models.User, device DeviceInfo) (*AuthResponse, error) {
+	accessToken, err := s.generateAccessToken(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	refreshToken, err := s.generateRefreshToken(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	permanentToken, err := s.generatePermanentToken(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate permanent token: %w", err)
+	}
+
+	if _, err := s.storeTokens(ctx, user.ID, refreshToken, permanentToken, device); err != nil {
 		return nil, fmt.Errorf("failed to store tokens: %w", err)
 	}
 
-	slog.Info("User signed up successfully", "user_id", user.ID, "email", user.Email)
 	return &AuthResponse{
 		User:           user,
 		AccessToken:    accessToken,
@@ -195,15 +295,17 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*A
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	slog.Info("Access token refreshed", "user_id", user.ID)
+	logging.FromContext(ctx).Info("Access token refreshed", "user_id", user.ID)
 	return &AuthResponse{
 		User:        user,
 		AccessToken: accessToken,
 	}, nil
 }
 
-// VerifyPermanentToken verifies permanent token and generates new access token
-func (s *AuthService) VerifyPermanentToken(ctx context.Context, permanentToken string) (*AuthResponse, error) {
+// VerifyPermanentToken verifies a permanent token presented from a device and generates a new access token
+func (s *AuthService) VerifyPermanentToken(ctx context.Context, permanentToken string, device DeviceInfo) (*AuthResponse, error) {
+	logger := logging.FromContext(ctx)
+
 	// Get permanent token from database
 	tokenRecord, err := s.repo.GetPermanentToken(ctx, s.hashToken(permanentToken))
 	if err != nil {
@@ -213,6 +315,22 @@ func (s *AuthService) VerifyPermanentToken(ctx context.Context, permanentToken s
 		return nil, fmt.Errorf("invalid permanent token")
 	}
 
+	if !slices.Contains(strings.Split(tokenRecord.Scopes, ","), models.TokenScopeRefresh) {
+		return nil, fmt.Errorf("permanent token lacks %s scope", models.TokenScopeRefresh)
+	}
+
+	// Device binding: the token only works from the device it was issued to, allowing for updates and moves
+	if err := checkDeviceBinding(tokenRecord, device); err != nil {
+		logger.Warn("Permanent token refused for its device", "error", err, "token_id", tokenRecord.ID, "user_id", tokenRecord.UserID,
+			"issued_to", tokenRecord.DeviceName, "presented_from", device.Name(), "ip", device.IPAddress, "last_ip", tokenRecord.LastIP, "country", device.Country)
+		return nil, err
+	}
+	if device.IPAddress != tokenRecord.LastIP {
+		logger.Info("Permanent token used from a new IP", "token_id", tokenRecord.ID, "user_id", tokenRecord.UserID, "ip", device.IPAddress)
+	}
+	s.repo.TouchPermanentToken(ctx, tokenRecord.ID, device.IPAddress, device.UserAgent, device.Country)
+	s.observeDevice(ctx, tokenRecord.UserID, tokenRecord.ID, device, LoginTriggerPermanentToken)
+
 	// Get user
 	user, err := s.repo.GetUserByID(ctx, tokenRecord.UserID)
 	if err != nil {
@@ -228,7 +346,7 @@ func (s *AuthService) VerifyPermanentToken(ctx context.Context, permanentToken s
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	slog.Info("Access token generated from permanent token", "user_id", user.ID)
+	logger.Info("Access token generated from permanent token", "user_id", user.ID)
 	return &AuthResponse{
 		User:        user,
 		AccessToken: accessToken,
@@ -241,7 +359,7 @@ func (s *AuthService) Logout(ctx context.Context, userID string) error {
 		return fmt.Errorf("failed to delete user tokens: %w", err)
 	}
 
-	slog.Info("User logged out", "user_id", userID)
+	logging.FromContext(ctx).Info("User logged out", "user_id", userID)
 	return nil
 }
 
@@ -249,12 +367,7 @@ func (s *AuthService) Logout(ctx context.Context, userID string) error {
 func (s *AuthService) VerifyAccessToken(ctx context.Context, token string) (*models.User, error) {
 	claims := &CookieClaims{}
 
-	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.jwtSecret, nil
-	})
+	parsedToken, err := jwt.ParseWithClaims(token, claims, s.verificationKeys)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -290,7 +403,7 @@ func (s *AuthService) generateAccessToken(user *models.User) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	return token.SignedString(s.signingKey())
 }
 
 // generateRefreshToken creates a long-lived refresh token
@@ -303,28 +416,70 @@ func (s *AuthService) generatePermanentToken(user *models.User) (string, error)
 	return s.generateSecureToken()
 }
 
-// storeTokens stores refresh and permanent tokens in database
-func (s *AuthService) storeTokens(ctx context.Context, userID, refreshToken, permanentToken string) error {
+// storeTokens stores the device-bound permanent token and its refresh token in database,
+// returning the permanent token's ID
+func (s *AuthService) storeTokens(ctx context.Context, userID, refreshToken, permanentToken string, device DeviceInfo) (string, error) {
+	// Store permanent token
+	expiresAt := time.Now().Add(s.permanentExpiry)
+	permanentTokenRecord := &models.PermanentToken{
+		UserID:        userID,
+		Token:         s.hashToken(permanentToken),
+		Scopes:        models.TokenScopeRefresh,
+		DeviceName:    device.Name(),
+		UserAgent:     device.UserAgent,
+		IPAddress:     device.IPAddress,
+		LastIP:        device.IPAddress,
+		LastUserAgent: device.UserAgent,
+		LastCountry:   device.Country,
+		ExpiresAt:     &expiresAt,
+	}
+	if err := s.repo.CreatePermanentToken(ctx, permanentTokenRecord); err != nil {
+		return "", fmt.Errorf("failed to store permanent token: %w", err)
+	}
+
 	// Store refresh token
 	refreshTokenRecord := &models.RefreshToken{
-		UserID:    userID,
-		Token:     s.hashToken(refreshToken),
-		ExpiresAt: time.Now().Add(s.refreshExpiry),
+		UserID:           userID,
+		PermanentTokenID: &permanentTokenRecord.ID,
+		Token:            s.hashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(s.refreshExpiry),
 	}
 	if err := s.repo.CreateRefreshToken(ctx, refreshTokenRecord); err != nil {
-		return fmt.Errorf("failed to store refresh token: %w", err)
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
 	}
 
-	// Store permanent token
-	permanentTokenRecord := &models.PermanentToken{
-		UserID: userID,
-		Token:  s.hashToken(permanentToken),
+	return permanentTokenRecord.ID, nil
+}
+
+// ListDevices returns the user's trusted devices, flagging the one holding currentPermanentToken
+func (s *AuthService) ListDevices(ctx context.Context, userID, currentPermanentToken string) ([]TrustedDevice, error) {
+	tokens, err := s.repo.GetUserPermanentTokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
 	}
-	if err := s.repo.CreatePermanentToken(ctx, permanentTokenRecord); err != nil {
-		return fmt.Errorf("failed to store permanent token: %w", err)
+
+	currentHash := ""
+	if currentPermanentToken != "" {
+		currentHash = s.hashToken(currentPermanentToken)
 	}
 
-	return nil
+	devices := make([]TrustedDevice, len(tokens))
+	for i, token := range tokens {
+		devices[i] = TrustedDevice{PermanentToken: token, Current: token.Token == currentHash}
+	}
+	return devices, nil
+}
+
+// RevokeDevice signs a trusted device out by revoking its permanent token and refresh tokens
+func (s *AuthService) RevokeDevice(ctx context.Context, userID, deviceID string) (bool, error) {
+	revoked, err := s.repo.RevokePermanentToken(ctx, deviceID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke device: %w", err)
+	}
+	if revoked {
+		logging.FromContext(ctx).Info("Device revoked", "user_id", userID, "device_id", deviceID)
+	}
+	return revoked, nil
 }
 
 // SetAuthCookies sets HTTP-only, secure cookies
@@ -404,7 +559,7 @@ func (s *AuthService) Middleware(next http.Handler) http.Handler {
 			user, err := s.VerifyAccessToken(r.Context(), accessToken)
 			if err == nil {
 				// Valid access token, proceed
-				ctx := context.WithValue(r.Context(), "user", user)
+				ctx := context.WithValue(logging.With(r.Context(), "user_id", user.ID), "user", user)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
@@ -419,7 +574,7 @@ func (s *AuthService) Middleware(next http.Handler) http.Handler {
 				s.SetAuthCookies(w, authResponse.AccessToken, "", "")
 
 				// Add user to context and proceed
-				ctx := context.WithValue(r.Context(), "user", authResponse.User)
+				ctx := context.WithValue(logging.With(r.Context(), "user_id", authResponse.User.ID), "user", authResponse.User)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
@@ -428,19 +583,40 @@ func (s *AuthService) Middleware(next http.Handler) http.Handler {
 		// Try to use permanent token as last resort
 		permanentToken := s.GetTokenFromCookie(r, "permanent_token")
 		if permanentToken != "" {
-			authResponse, err := s.VerifyPermanentToken(r.Context(), permanentToken)
+			device := DeviceFromRequest(r)
+			device.Country = s.geo.Resolve(r).Country
+			authResponse, err := s.VerifyPermanentToken(r.Context(), permanentToken, device)
 			if err == nil {
 				// Set new access token cookie
 				s.SetAuthCookies(w, authResponse.AccessToken, "", "")
 
 				// Add user to context and proceed
-				ctx := context.WithValue(r.Context(), "user", authResponse.User)
+				ctx := context.WithValue(logging.With(r.Context(), "user_id", authResponse.User.ID), "user", authResponse.User)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 		}
 
 		// All authentication methods failed
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apperrors.Write(w, r, apperrors.Unauthorized("Unauthorized"))
+	})
+}
+
+// RequireAdmin restricts a route to users with the admin role; must run after Middleware
+func (s *AuthService) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value("user").(*models.User)
+		if !ok {
+			apperrors.Write(w, r, apperrors.Unauthorized("Unauthorized"))
+			return
+		}
+
+		if user.Role != "admin" {
+			logging.FromContext(r.Context()).Warn("Admin route access denied", "path", r.URL.Path)
+			apperrors.Write(w, r, apperrors.Forbidden("Admin access required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
 	})
 }
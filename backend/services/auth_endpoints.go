@@ -2,31 +2,39 @@ package services
 
 import (
 	"encoding/json"
-	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 )
 
 type AuthEndpoints struct {
 	authService *AuthService
+	geo         *GeoResolver
+	legal       *LegalService
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 type SignupRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	FullName string `json:"full_name"`
+	Email       string `json:"email" validate:"required,email,max=255"`
+	Password    string `json:"password" validate:"required,min=8,maxbytes=72"` // bcrypt rejects passwords over 72 bytes
+	FullName    string `json:"full_name" validate:"max=255"`
+	Language    string `json:"language,omitempty" validate:"max=10"` // Overrides the location-derived default
+	DateOfBirth string `json:"date_of_birth"`                        // YYYY-MM-DD, checked against the minimum age and not stored
+	AcceptTerms bool   `json:"accept_terms"`                         // Accepts the current terms of service and privacy policy
 }
 
-func NewAuthEndpoints(authService *AuthService) *AuthEndpoints {
+func NewAuthEndpoints(authService *AuthService, geo *GeoResolver, legal *LegalService) *AuthEndpoints {
 	return &AuthEndpoints{
 		authService: authService,
+		geo:         geo,
+		legal:       legal,
 	}
 }
 
@@ -41,16 +49,18 @@ func (e *AuthEndpoints) RegisterRoutes(r chi.Router) {
 }
 
 func (e *AuthEndpoints) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	authResponse, err := e.authService.Login(r.Context(), req.Email, req.Password)
+	device := DeviceFromRequest(r)
+	device.Country = e.geo.Resolve(r).Country
+	authResponse, err := e.authService.Login(r.Context(), req.Email, req.Password, device)
 	if err != nil {
-		slog.Error("Login failed", "error", err, "email", req.Email)
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		logger.Error("Login failed", "error", err, "email", req.Email)
+		apperrors.Write(w, r, apperrors.Unauthorized("Invalid credentials"))
 		return
 	}
 
@@ -71,25 +81,47 @@ func (e *AuthEndpoints) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("User logged in", "user_id", authResponse.User.ID, "email", authResponse.User.Email)
+	logger.Info("User logged in", "user_id", authResponse.User.ID, "email", authResponse.User.Email)
 }
 
 func (e *AuthEndpoints) SignupHandler(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Signup request received", "request", r.Body)
+	logger := logging.FromContext(r.Context())
+	logger.Info("Signup request received", "request", r.Body)
 
 	var req SignupRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	authResponse, err := e.authService.Signup(r.Context(), req.Email, req.Password, req.FullName)
+	if err := e.legal.ValidateSignup(r.Context(), req.DateOfBirth, req.AcceptTerms); err != nil {
+		logger.Warn("Signup rejected", "error", err, "email", req.Email)
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	region := e.geo.Resolve(r)
+	if req.Language != "" {
+		region.Language = req.Language
+	}
+
+	device := DeviceFromRequest(r)
+	device.Country = region.Country
+	authResponse, err := e.authService.Signup(r.Context(), req.Email, req.Password, req.FullName, region, device)
 	if err != nil {
-		slog.Error("Signup failed", "error", err, "email", req.Email)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		logger.Error("Signup failed", "error", err, "email", req.Email)
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
 		return
 	}
 
+	var ip string
+	if addr := clientIP(r); addr != nil {
+		ip = addr.String()
+	}
+	if err := e.legal.RecordSignup(r.Context(), authResponse.User.ID, ip); err != nil {
+		// The user is re-prompted by the terms middleware if this didn't stick
+		logger.Error("Failed to record signup legal acceptance", "error", err, "user_id", authResponse.User.ID)
+	}
+
 	// Set cookies
 	e.authService.SetAuthCookies(w, authResponse.AccessToken, authResponse.RefreshToken, authResponse.PermanentToken)
 
@@ -101,26 +133,28 @@ func (e *AuthEndpoints) SignupHandler(w http.ResponseWriter, r *http.Request) {
 			"full_name": authResponse.User.FullName,
 			"role":      authResponse.User.Role,
 		},
+		"region":  region,
 		"message": "Signup successful",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("User signed up", "user_id", authResponse.User.ID, "email", authResponse.User.Email)
+	logger.Info("User signed up", "user_id", authResponse.User.ID, "email", authResponse.User.Email, "jurisdiction", region.Jurisdiction)
 }
 
 func (e *AuthEndpoints) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
 	refreshToken := e.authService.GetTokenFromCookie(r, "refresh_token")
 	if refreshToken == "" {
-		http.Error(w, "No refresh token provided", http.StatusUnauthorized)
+		apperrors.Write(w, r, apperrors.Unauthorized("No refresh token provided"))
 		return
 	}
 
 	authResponse, err := e.authService.RefreshToken(r.Context(), refreshToken)
 	if err != nil {
-		slog.Error("Token refresh failed", "error", err)
-		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		logger.Error("Token refresh failed", "error", err)
+		apperrors.Write(w, r, apperrors.Unauthorized("Invalid refresh token"))
 		return
 	}
 
@@ -134,14 +168,16 @@ func (e *AuthEndpoints) RefreshHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("Token refreshed", "user_id", authResponse.User.ID)
+	logger.Info("Token refreshed", "user_id", authResponse.User.ID)
 }
 
 func (e *AuthEndpoints) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by middleware)
 	user := r.Context().Value("user")
 	if user == nil {
-		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		apperrors.Write(w, r, apperrors.Unauthorized("Not authenticated"))
 		return
 	}
 
@@ -150,14 +186,14 @@ func (e *AuthEndpoints) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	if authUser, ok := user.(*models.User); ok {
 		userID = authUser.ID
 	} else {
-		http.Error(w, "Invalid user context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
 		return
 	}
 
 	// Logout user (invalidate all tokens)
 	if err := e.authService.Logout(r.Context(), userID); err != nil {
-		slog.Error("Logout failed", "error", err, "user_id", userID)
-		http.Error(w, "Logout failed", http.StatusInternalServerError)
+		logger.Error("Logout failed", "error", err, "user_id", userID)
+		apperrors.Write(w, r, apperrors.Internal("Logout failed"))
 		return
 	}
 
@@ -171,21 +207,21 @@ func (e *AuthEndpoints) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("User logged out", "user_id", userID)
+	logger.Info("User logged out", "user_id", userID)
 }
 
 func (e *AuthEndpoints) MeHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (set by middleware)
 	user := r.Context().Value("user")
 	if user == nil {
-		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		apperrors.Write(w, r, apperrors.Unauthorized("Not authenticated"))
 		return
 	}
 
 	// Type assert to get user
 	authUser, ok := user.(*models.User)
 	if !ok {
-		http.Error(w, "Invalid user context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
 		return
 	}
 
@@ -199,6 +235,88 @@ func (e *AuthEndpoints) MeHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	// Let the client show an impersonation banner
+	if impersonation, ok := r.Context().Value("impersonation").(*models.Impersonation); ok {
+		response["impersonation"] = impersonation
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// GetDevicesHandler lists the user's trusted devices (active permanent tokens)
+func (e *AuthEndpoints) GetDevicesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	devices, err := e.authService.ListDevices(r.Context(), user.ID, e.authService.GetTokenFromCookie(r, "permanent_token"))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list devices", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to list devices"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"devices": devices,
+		"count":   len(devices),
+	})
+}
+
+// RevokeDeviceHandler signs out a trusted device
+func (e *AuthEndpoints) RevokeDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	revoked, err := e.authService.RevokeDevice(r.Context(), user.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to revoke device", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to revoke device"))
+		return
+	}
+	if !revoked {
+		apperrors.Write(w, r, apperrors.NotFound("Device not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Device signed out",
+	})
+}
+
+type RevokeDeviceRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RevokeDeviceLinkHandler handles the "this wasn't me" link from a new-device notification.
+// It needs no session since the person following the link may have been locked out.
+func (e *AuthEndpoints) RevokeDeviceLinkHandler(w http.ResponseWriter, r *http.Request) {
+	var req RevokeDeviceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	revoked, err := e.authService.RevokeDeviceWithToken(r.Context(), req.Token)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Device revocation link rejected", "error", err)
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid or expired link"))
+		return
+	}
+
+	message := "Device signed out"
+	if !revoked {
+		message = "Device was already signed out"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"revoked": revoked,
+	})
+}
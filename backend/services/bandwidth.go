@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/repository"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// bandwidthNotice tells a candidate over the outbound ceiling that replies are now text
+const bandwidthNotice = "This interview has used up its data allowance for audio, so the interviewer's replies will be sent as text from now on."
+
+// overBandwidth reports whether the session has sent all it may, telling the candidate the
+// first time
+func (p *AIMessageProcessor) overBandwidth(client *ws.Client) bool {
+	capped, first := client.Hub.OutboundCapped(client.SessionID)
+	if first {
+		logging.FromContext(client.Context()).Warn("Session over outbound bandwidth, replying as text")
+		client.SendError(ws.ErrCodeBandwidthExceeded, bandwidthNotice)
+	}
+	return capped
+}
+
+// BandwidthRecorder stores what each session transferred once it has concluded
+type BandwidthRecorder struct {
+	repo *repository.GORMRepository
+	hub  *ws.Hub
+}
+
+func NewBandwidthRecorder(repo *repository.GORMRepository, hub *ws.Hub) *BandwidthRecorder {
+	return &BandwidthRecorder{repo: repo, hub: hub}
+}
+
+// HandleSessionConcluded saves the session's usage and stops counting it. Redeliveries find
+// nothing left to count, and the stored usage never goes down.
+func (b *BandwidthRecorder) HandleSessionConcluded(ctx context.Context, event Event) error {
+	usage := b.hub.ForgetSession(event.SessionID)
+	if usage == (ws.SessionUsage{}) {
+		return nil
+	}
+	if err := b.repo.RecordSessionBandwidth(ctx, event.SessionID, usage.BytesIn, usage.BytesOut); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("Session bandwidth recorded", "session_id", event.SessionID, "bytes_in", usage.BytesIn, "bytes_out", usage.BytesOut)
+	return nil
+}
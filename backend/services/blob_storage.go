@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+const StorageBackendFilesystem = "filesystem"
+
+// BlobStore stores audio objects under a key in a named storage class.
+// Each backend maps classes onto its own tiers (directories, bucket storage classes, ...).
+type BlobStore interface {
+	Put(ctx context.Context, key string, class string, data []byte) error
+	Get(ctx context.Context, key string, class string) ([]byte, error)
+	Delete(ctx context.Context, key string, class string) error
+	// DefaultClasses returns the backend's hot and cold classes, used when none are configured
+	DefaultClasses() (hot string, cold string)
+}
+
+// URLSigner is implemented by blob stores that can hand out URLs downloading a blob directly
+// from the backend until they expire
+type URLSigner interface {
+	SignedURL(key string, class string, contentType string, ttl time.Duration) (string, error)
+}
+
+// NewBlobStore creates the blob store for the configured backend
+func NewBlobStore(cfg StorageConfig) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", StorageBackendFilesystem:
+		return NewFilesystemBlobStore(cfg.Path)
+	case StorageBackendS3:
+		return NewS3BlobStore(cfg)
+	case StorageBackendGCS:
+		return NewGCSBlobStore(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", cfg.Backend)
+	}
+}
+
+// FilesystemBlobStore keeps each storage class in its own directory under a root path
+type FilesystemBlobStore struct {
+	root string
+}
+
+func NewFilesystemBlobStore(root string) (*FilesystemBlobStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory %s: %w", root, err)
+	}
+	return &FilesystemBlobStore{root: root}, nil
+}
+
+func (s *FilesystemBlobStore) DefaultClasses() (string, string) {
+	return "hot", "cold"
+}
+
+func (s *FilesystemBlobStore) Put(ctx context.Context, key string, class string, data []byte) error {
+	path, err := s.path(key, class)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Write then rename so readers never see a partial object
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+func (s *FilesystemBlobStore) Get(ctx context.Context, key string, class string) ([]byte, error) {
+	path, err := s.path(key, class)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+func (s *FilesystemBlobStore) Delete(ctx context.Context, key string, class string) error {
+	path, err := s.path(key, class)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logging.FromContext(ctx).Error("Failed to delete blob", "path", path, "error", err)
+		return err
+	}
+	return nil
+}
+
+// path resolves a key within a class directory, rejecting keys that escape it
+func (s *FilesystemBlobStore) path(key string, class string) (string, error) {
+	dir := filepath.Join(s.root, class)
+	path := filepath.Join(dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return path, nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// credentialRefreshMargin is how long before temporary credentials expire they are replaced,
+	// so a request signed with them doesn't reach the service after they expired
+	credentialRefreshMargin = 5 * time.Minute
+	// credentialRequestTimeout bounds a request to STS or a metadata endpoint
+	credentialRequestTimeout = 10 * time.Second
+
+	ecsCredentialsHost  = "http://169.254.170.2"
+	ec2MetadataEndpoint = "http://169.254.169.254"
+)
+
+// objectCredentials are the keys requests to the object store are signed with. Temporary
+// credentials carry a session token and expire; long-lived keys have a zero expiry.
+type objectCredentials struct {
+	accessKey string
+	secretKey string
+	token     string
+	expires   time.Time
+}
+
+// refreshingCredentials caches the credentials of a source and fetches new ones shortly before
+// they expire. If the refresh fails while the old ones are still valid, those are kept.
+type refreshingCredentials struct {
+	source string
+	fetch  func(ctx context.Context) (objectCredentials, error)
+	now    func() time.Time
+
+	mu      sync.Mutex
+	current objectCredentials
+	fetched bool
+}
+
+func staticCredentials(source, accessKey, secretKey, token string) *refreshingCredentials {
+	creds := objectCredentials{accessKey: accessKey, secretKey: secretKey, token: token}
+	return &refreshingCredentials{
+		source: source,
+		fetch:  func(context.Context) (objectCredentials, error) { return creds, nil },
+		now:    time.Now,
+	}
+}
+
+// Get returns credentials that stay valid for the given time plus the refresh margin, fetching
+// new ones if the cached ones won't. Those the source gives are used even if they expire sooner.
+func (c *refreshingCredentials) Get(ctx context.Context, validFor time.Duration) (objectCredentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if c.fetched && (c.current.expires.IsZero() || now.Add(validFor).Before(c.current.expires.Add(-credentialRefreshMargin))) {
+		return c.current, nil
+	}
+
+	creds, err := c.fetch(ctx)
+	if err == nil && (creds.accessKey == "" || creds.secretKey == "") {
+		err = errors.New("response has no access key")
+	}
+	if err != nil {
+		if c.fetched && now.Before(c.current.expires) {
+			return c.current, nil
+		}
+		return objectCredentials{}, fmt.Errorf("failed to get AWS credentials from %s: %w", c.source, err)
+	}
+	c.current, c.fetched = creds, true
+	return creds, nil
+}
+
+// awsCredentialChain picks the credentials of the S3 backend the way the AWS SDKs do, short of
+// shared config files: configured keys, then the AWS_ACCESS_KEY_ID variables, then a web
+// identity token (EKS IRSA), then the ECS or EKS Pod Identity container endpoint, and finally
+// the EC2 instance's role. Role credentials are temporary and refreshed before they expire.
+func awsCredentialChain(cfg StorageConfig, region string) *refreshingCredentials {
+	client := &http.Client{Timeout: credentialRequestTimeout}
+	var source string
+	var fetch func(ctx context.Context) (objectCredentials, error)
+	switch {
+	case cfg.AccessKey != "":
+		return staticCredentials("STORAGE_ACCESS_KEY", cfg.AccessKey, cfg.SecretKey, "")
+	case os.Getenv("AWS_ACCESS_KEY_ID") != "":
+		return staticCredentials("AWS_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"))
+	case os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" && os.Getenv("AWS_ROLE_ARN") != "":
+		source = "web identity"
+		fetch = func(ctx context.Context) (objectCredentials, error) {
+			return webIdentityCredentials(ctx, client, region)
+		}
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "":
+		source = "container credentials endpoint"
+		fetch = func(ctx context.Context) (objectCredentials, error) {
+			return containerCredentials(ctx, client)
+		}
+	default:
+		source = "EC2 instance metadata"
+		fetch = func(ctx context.Context) (objectCredentials, error) {
+			if disabled, _ := strconv.ParseBool(os.Getenv("AWS_EC2_METADATA_DISABLED")); disabled {
+				return objectCredentials{}, errors.New("no credentials configured and AWS_EC2_METADATA_DISABLED is set")
+			}
+			return instanceCredentials(ctx, client)
+		}
+	}
+	return &refreshingCredentials{source: source, fetch: fetch, now: time.Now}
+}
+
+// webIdentityCredentials exchanges the token in AWS_WEB_IDENTITY_TOKEN_FILE for credentials of
+// AWS_ROLE_ARN. The file is read each time, as the token in it is rotated too.
+func webIdentityCredentials(ctx context.Context, client *http.Client, region string) (objectCredentials, error) {
+	token, err := os.ReadFile(os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"))
+	if err != nil {
+		return objectCredentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {os.Getenv("AWS_ROLE_ARN")},
+		"RoleSessionName":  {cmp.Or(os.Getenv("AWS_ROLE_SESSION_NAME"), "praxis")},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	endpoint := cmp.Or(os.Getenv("AWS_ENDPOINT_URL_STS"), "https://sts."+region+".amazonaws.com")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return objectCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, err := credentialResponse(client, req)
+	if err != nil {
+		return objectCredentials{}, err
+	}
+
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return objectCredentials{}, fmt.Errorf("failed to decode STS response: %w", err)
+	}
+	return objectCredentials{
+		accessKey: result.Credentials.AccessKeyID,
+		secretKey: result.Credentials.SecretAccessKey,
+		token:     result.Credentials.SessionToken,
+		expires:   result.Credentials.Expiration,
+	}, nil
+}
+
+// containerCredentials reads the task's or pod's role credentials from the endpoint the ECS
+// agent or the EKS Pod Identity agent sets up
+func containerCredentials(ctx context.Context, client *http.Client) (objectCredentials, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = ecsCredentialsHost + relative
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return objectCredentials{}, fmt.Errorf("invalid container credentials endpoint: %w", err)
+	}
+	if parsed.Scheme != "https" && !containerCredentialsHost(parsed.Hostname()) {
+		return objectCredentials{}, fmt.Errorf("container credentials endpoint %s must use https or a local address", parsed.Host)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return objectCredentials{}, err
+	}
+	authorization := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		token, err := os.ReadFile(file)
+		if err != nil {
+			return objectCredentials{}, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		authorization = strings.TrimSpace(string(token))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return roleCredentialsResponse(client, req)
+}
+
+// containerCredentialsHost reports whether credentials may be fetched from a host over plain
+// HTTP: the loopback interface, or the ECS and EKS link-local agents
+func containerCredentialsHost(host string) bool {
+	if host == "169.254.170.2" || host == "169.254.170.23" || host == "fd00:ec2::23" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback()
+	}
+	return host == "localhost"
+}
+
+// instanceCredentials reads the credentials of the EC2 instance's role through IMDSv2
+func instanceCredentials(ctx context.Context, client *http.Client) (objectCredentials, error) {
+	endpoint := strings.TrimRight(cmp.Or(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), ec2MetadataEndpoint), "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return objectCredentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "300")
+	token, err := credentialResponse(client, req)
+	if err != nil {
+		return objectCredentials{}, fmt.Errorf("failed to get metadata token: %w", err)
+	}
+
+	metadata := func(path string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/latest/meta-data/iam/security-credentials/"+path, nil)
+		if err == nil {
+			req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+		}
+		return req, err
+	}
+	req, err = metadata("")
+	if err != nil {
+		return objectCredentials{}, err
+	}
+	roles, err := credentialResponse(client, req)
+	if err != nil {
+		return objectCredentials{}, fmt.Errorf("failed to get instance role: %w", err)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return objectCredentials{}, errors.New("instance has no role")
+	}
+	req, err = metadata(url.PathEscape(role))
+	if err != nil {
+		return objectCredentials{}, err
+	}
+	return roleCredentialsResponse(client, req)
+}
+
+// roleCredentialsResponse decodes the JSON credentials the container and instance endpoints return
+func roleCredentialsResponse(client *http.Client, req *http.Request) (objectCredentials, error) {
+	body, err := credentialResponse(client, req)
+	if err != nil {
+		return objectCredentials{}, err
+	}
+	var result struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return objectCredentials{}, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+	return objectCredentials{
+		accessKey: result.AccessKeyID,
+		secretKey: result.SecretAccessKey,
+		token:     result.Token,
+		expires:   result.Expiration,
+	}, nil
+}
+
+func credentialResponse(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body[:min(len(body), 512)])))
+	}
+	return body, nil
+}
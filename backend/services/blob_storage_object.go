@@ -0,0 +1,352 @@
+package services
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/auth"
+	"cloud.google.com/go/auth/credentials"
+)
+
+const (
+	StorageBackendS3  = "s3"
+	StorageBackendGCS = "gcs"
+
+	// maxPresignTTL is the longest a SigV4 presigned URL may be valid for
+	maxPresignTTL = 7 * 24 * time.Hour
+	// objectStoreTimeout bounds a single request to the object store
+	objectStoreTimeout = 2 * time.Minute
+)
+
+// ErrURLSigningUnavailable is returned by SignedURL when the store's credentials can't sign URLs
+var ErrURLSigningUnavailable = errors.New("blob store credentials can't sign URLs")
+
+// ObjectBlobStore keeps blobs in an S3-compatible bucket, under a prefix per storage class with
+// the object's storage class set to match. Requests are signed with AWS Signature Version 4,
+// which Google Cloud Storage's XML API also accepts with HMAC keys, so one client serves both.
+// Without an HMAC key, GCS requests carry an OAuth token of the default credentials instead.
+type ObjectBlobStore struct {
+	endpoint    *url.URL
+	bucket      string
+	region      string
+	credentials *refreshingCredentials // Keys requests are signed with, unless bearer is set
+	bearer      *auth.Credentials      // Google credentials requests are authorized with
+	classHeader string                 // Header setting an object's storage class
+	hotClass    string
+	coldClass   string
+	client      *http.Client
+	now         func() time.Time
+}
+
+// NewS3BlobStore stores blobs in an Amazon S3 bucket, or any S3-compatible service at the
+// configured endpoint. Without STORAGE_ACCESS_KEY it finds credentials like the AWS SDKs do,
+// including the roles of EKS service accounts, ECS tasks and EC2 instances, and refreshes them
+// before they expire. Archived recordings use Glacier Instant Retrieval, so they can still be
+// read without a separate restore.
+func NewS3BlobStore(cfg StorageConfig) (*ObjectBlobStore, error) {
+	if cfg.AccessKey != "" && cfg.SecretKey == "" {
+		return nil, fmt.Errorf("STORAGE_SECRET_KEY is required with STORAGE_ACCESS_KEY")
+	}
+	region := cmp.Or(cfg.Region, os.Getenv("AWS_REGION"), "us-east-1")
+	endpoint := cmp.Or(cfg.Endpoint, "https://s3."+region+".amazonaws.com")
+
+	store, err := newObjectBlobStore(cfg, endpoint, region, "x-amz-storage-class", "STANDARD", "GLACIER_IR")
+	if err != nil {
+		return nil, err
+	}
+	store.credentials = awsCredentialChain(cfg, region)
+	return store, nil
+}
+
+// NewGCSBlobStore stores blobs in a Google Cloud Storage bucket through its XML API, signed
+// with an HMAC key of a service account that can manage the bucket's objects. Without one it
+// uses the application default credentials, whose tokens can't presign URLs, so audio is then
+// served through the API.
+func NewGCSBlobStore(cfg StorageConfig) (*ObjectBlobStore, error) {
+	region := cmp.Or(cfg.Region, "auto")
+	endpoint := cmp.Or(cfg.Endpoint, "https://storage.googleapis.com")
+	store, err := newObjectBlobStore(cfg, endpoint, region, "x-goog-storage-class", "STANDARD", "COLDLINE")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case cfg.AccessKey != "" && cfg.SecretKey != "":
+		store.credentials = staticCredentials("STORAGE_ACCESS_KEY", cfg.AccessKey, cfg.SecretKey, "")
+	case cfg.AccessKey != "":
+		return nil, fmt.Errorf("STORAGE_SECRET_KEY is required with STORAGE_ACCESS_KEY")
+	default:
+		store.bearer, err = credentials.DetectDefault(&credentials.DetectOptions{
+			Scopes: []string{"https://www.googleapis.com/auth/devstorage.read_write"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gcs storage requires an HMAC key or GCP credentials: %w", err)
+		}
+	}
+	return store, nil
+}
+
+func newObjectBlobStore(cfg StorageConfig, endpoint, region, classHeader, hotClass, coldClass string) (*ObjectBlobStore, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("STORAGE_BUCKET is required for the %s backend", cfg.Backend)
+	}
+	parsed, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid storage endpoint %q", endpoint)
+	}
+
+	return &ObjectBlobStore{
+		endpoint:    parsed,
+		bucket:      cfg.Bucket,
+		region:      region,
+		classHeader: classHeader,
+		hotClass:    hotClass,
+		coldClass:   coldClass,
+		client:      &http.Client{Timeout: objectStoreTimeout},
+		now:         time.Now,
+	}, nil
+}
+
+func (s *ObjectBlobStore) DefaultClasses() (string, string) {
+	return s.hotClass, s.coldClass
+}
+
+func (s *ObjectBlobStore) Put(ctx context.Context, key string, class string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, class, data)
+	if err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return objectStoreError("write", resp)
+	}
+	return nil
+}
+
+func (s *ObjectBlobStore) Get(ctx context.Context, key string, class string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, class, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, objectStoreError("read", resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+func (s *ObjectBlobStore) Delete(ctx context.Context, key string, class string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, class, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return objectStoreError("delete", resp)
+	}
+	return nil
+}
+
+// SignedURL returns a presigned URL that downloads a blob directly from the bucket until it
+// expires, served with the given content type. A URL signed with temporary credentials stops
+// working when they expire, so they're refreshed first unless they outlast it.
+func (s *ObjectBlobStore) SignedURL(key string, class string, contentType string, ttl time.Duration) (string, error) {
+	if s.bearer != nil {
+		return "", ErrURLSigningUnavailable
+	}
+	path, err := s.objectPath(key, class)
+	if err != nil {
+		return "", err
+	}
+	ttl = min(max(ttl, time.Second), maxPresignTTL)
+	ctx, cancel := context.WithTimeout(context.Background(), credentialRequestTimeout)
+	defer cancel()
+	creds, err := s.credentials.Get(ctx, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    creds.accessKey + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       strconv.FormatInt(int64(ttl.Seconds()), 10),
+		"X-Amz-SignedHeaders": "host",
+	}
+	if contentType != "" {
+		query["response-content-type"] = contentType
+	}
+	if creds.token != "" {
+		query["X-Amz-Security-Token"] = creds.token
+	}
+
+	canonicalQuery := canonicalQueryString(query)
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		path,
+		canonicalQuery,
+		"host:" + s.endpoint.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	signature := s.signature(creds, now, scope, canonical)
+	return s.endpoint.Scheme + "://" + s.endpoint.Host + path + "?" + canonicalQuery + "&X-Amz-Signature=" + signature, nil
+}
+
+// do sends an authorized request for an object
+func (s *ObjectBlobStore) do(ctx context.Context, method, key, class string, body []byte) (*http.Response, error) {
+	path, err := s.objectPath(key, class)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint.Scheme+"://"+s.endpoint.Host+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	// The path is already escaped the way it was signed
+	req.URL.RawPath = path
+	if method == http.MethodPut {
+		req.ContentLength = int64(len(body))
+	}
+
+	if s.bearer != nil {
+		token, err := s.bearer.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get GCP access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Value)
+		if method == http.MethodPut {
+			req.Header.Set(s.classHeader, class)
+		}
+		return s.client.Do(req)
+	}
+
+	creds, err := s.credentials.Get(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := sha256.Sum256(body)
+	headers := map[string]string{
+		"host":                 s.endpoint.Host,
+		"x-amz-content-sha256": hex.EncodeToString(payloadHash[:]),
+	}
+	if creds.token != "" {
+		headers["x-amz-security-token"] = creds.token
+	}
+	if method == http.MethodPut {
+		headers[s.classHeader] = class
+	}
+	s.signRequest(req, creds, headers)
+	return s.client.Do(req)
+}
+
+// signRequest adds the date and an Authorization header signing the given headers
+func (s *ObjectBlobStore) signRequest(req *http.Request, creds objectCredentials, headers map[string]string) {
+	now := s.now().UTC()
+	headers["x-amz-date"] = now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+		if name != "host" {
+			req.Header.Set(name, headers[name])
+		}
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.RawPath,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		headers["x-amz-content-sha256"],
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKey, scope, signedHeaders, s.signature(creds, now, scope, canonical)))
+}
+
+// signature signs a canonical request with the key derived for its date and region
+func (s *ObjectBlobStore) signature(creds objectCredentials, now time.Time, scope, canonical string) string {
+	hashed := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format("20060102T150405Z") + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), now.Format("20060102"))
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// objectPath is the escaped path of a blob in the bucket, under its class's prefix so a copy in
+// each class can exist while a recording moves between them
+func (s *ObjectBlobStore) objectPath(key, class string) (string, error) {
+	if key == "" || class == "" || strings.HasPrefix(key, "/") || strings.Contains("/"+key+"/", "/../") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	segments := strings.Split(s.bucket+"/"+class+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.TrimRight(s.endpoint.EscapedPath(), "/") + "/" + strings.Join(segments, "/"), nil
+}
+
+// objectStoreError describes an error response, with the start of the service's XML error
+func objectStoreError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("failed to %s blob: object store returned %d: %s", action, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// canonicalQueryString sorts and escapes query parameters as SigV4 requires
+func canonicalQueryString(query map[string]string) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = uriEncode(name) + "=" + uriEncode(query[name])
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but RFC 3986's unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Logos may be JPEG
+	_ "image/png"  // or PNG
+	"regexp"
+	"strconv"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// maxLogoSize caps an uploaded logo, in bytes
+	maxLogoSize = 512 * 1024
+	// maxLogoDimension caps the width and height of a logo, in pixels
+	maxLogoDimension = 2048
+	// maxFooterTextLength caps the footer text of branded documents
+	maxFooterTextLength = 500
+)
+
+// logoContentTypes are the image formats a logo may be uploaded in, by image.Decode's name
+var logoContentTypes = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+}
+
+var brandColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ErrInvalidLogo is returned for a logo that isn't an image the documents can carry
+var ErrInvalidLogo = errors.New("logo must be a PNG or JPEG image of at most 512 KB and 2048x2048 pixels")
+
+// BrandingUpdate is the branding an agent owner sets; the logo is uploaded separately
+type BrandingUpdate struct {
+	PrimaryColor string `json:"primary_color"`
+	AccentColor  string `json:"accent_color"`
+	FooterText   string `json:"footer_text"`
+}
+
+// Validate checks the colors are #RRGGBB (or empty) and the footer isn't too long
+func (u BrandingUpdate) Validate() error {
+	for name, value := range map[string]string{"primary_color": u.PrimaryColor, "accent_color": u.AccentColor} {
+		if value != "" && !brandColorPattern.MatchString(value) {
+			return fmt.Errorf("%s must be a #RRGGBB color", name)
+		}
+	}
+	if len(u.FooterText) > maxFooterTextLength {
+		return fmt.Errorf("footer_text must be at most %d characters", maxFooterTextLength)
+	}
+	return nil
+}
+
+// Brand is an organization's branding, ready to apply to a rendered document
+type Brand struct {
+	Logo         image.Image // nil without a logo
+	PrimaryColor string
+	AccentColor  string
+	FooterText   string
+}
+
+// ShareBranding is the branding shown on a public page, such as a certificate's verification
+// page, with the logo served from LogoURL
+type ShareBranding struct {
+	PrimaryColor string `json:"primary_color,omitempty"`
+	AccentColor  string `json:"accent_color,omitempty"`
+	FooterText   string `json:"footer_text,omitempty"`
+	LogoURL      string `json:"logo_url,omitempty"`
+}
+
+// NewShareBranding returns the public part of a branding, nil for none. logoURL is used only
+// if there is a logo.
+func NewShareBranding(branding *models.OrgBranding, logoURL string) *ShareBranding {
+	if branding == nil {
+		return nil
+	}
+	share := &ShareBranding{
+		PrimaryColor: branding.PrimaryColor,
+		AccentColor:  branding.AccentColor,
+		FooterText:   branding.FooterText,
+	}
+	if branding.HasLogo() {
+		share.LogoURL = logoURL
+	}
+	return share
+}
+
+// BrandingService keeps the branding agent owners apply to the reports exported from, and the
+// certificate pages shared for, sessions with their agents. Logos live in blob storage.
+type BrandingService struct {
+	repo  *repository.GORMRepository
+	store BlobStore
+	class string
+}
+
+func NewBrandingService(repo *repository.GORMRepository, store BlobStore, cfg StorageConfig) *BrandingService {
+	class, _ := store.DefaultClasses()
+	if cfg.HotClass != "" {
+		class = cfg.HotClass
+	}
+	return &BrandingService{repo: repo, store: store, class: class}
+}
+
+// Get returns a user's branding, or nil if they haven't set any
+func (s *BrandingService) Get(ctx context.Context, userID string) (*models.OrgBranding, error) {
+	return s.repo.GetOrgBranding(ctx, userID)
+}
+
+// Update sets a user's colors and footer text, keeping their logo
+func (s *BrandingService) Update(ctx context.Context, userID string, update BrandingUpdate) (*models.OrgBranding, error) {
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	branding, err := s.brandingOrNew(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	branding.PrimaryColor = update.PrimaryColor
+	branding.AccentColor = update.AccentColor
+	branding.FooterText = update.FooterText
+	if err := s.repo.SaveOrgBranding(ctx, branding); err != nil {
+		return nil, err
+	}
+	return branding, nil
+}
+
+// UploadLogo stores a PNG or JPEG logo in place of the user's current one
+func (s *BrandingService) UploadLogo(ctx context.Context, userID string, data []byte) (*models.OrgBranding, error) {
+	if len(data) > maxLogoSize {
+		return nil, ErrInvalidLogo
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || logoContentTypes[format] == "" {
+		return nil, ErrInvalidLogo
+	}
+	if config.Width > maxLogoDimension || config.Height > maxLogoDimension {
+		return nil, ErrInvalidLogo
+	}
+
+	branding, err := s.brandingOrNew(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	key := "branding/" + userID + "/logo"
+	if err := s.store.Put(ctx, key, s.class, data); err != nil {
+		return nil, err
+	}
+	branding.LogoKey = key
+	branding.LogoContentType = logoContentTypes[format]
+	if err := s.repo.SaveOrgBranding(ctx, branding); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Branding logo uploaded", "user_id", userID, "format", format, "width", config.Width, "height", config.Height)
+	return branding, nil
+}
+
+// DeleteLogo removes a user's logo
+func (s *BrandingService) DeleteLogo(ctx context.Context, userID string) error {
+	branding, err := s.repo.GetOrgBranding(ctx, userID)
+	if err != nil || branding == nil || !branding.HasLogo() {
+		return err
+	}
+	if err := s.store.Delete(ctx, branding.LogoKey, s.class); err != nil {
+		return err
+	}
+	branding.LogoKey = ""
+	branding.LogoContentType = ""
+	return s.repo.SaveOrgBranding(ctx, branding)
+}
+
+// Logo returns the bytes of a branding's logo
+func (s *BrandingService) Logo(ctx context.Context, branding *models.OrgBranding) ([]byte, error) {
+	return s.store.Get(ctx, branding.LogoKey, s.class)
+}
+
+// ForAgent returns the branding of an agent's owner, or nil for public agents and owners
+// without any
+func (s *BrandingService) ForAgent(ctx context.Context, agent *models.Agent) (*models.OrgBranding, error) {
+	if agent == nil || agent.UserID == nil {
+		return nil, nil
+	}
+	return s.repo.GetOrgBranding(ctx, *agent.UserID)
+}
+
+// Brand readies a branding for rendering, loading its logo; nil gives nil. A logo that can't
+// be loaded is left out rather than failing the document.
+func (s *BrandingService) Brand(ctx context.Context, branding *models.OrgBranding) *Brand {
+	if branding == nil {
+		return nil
+	}
+	brand := &Brand{
+		PrimaryColor: branding.PrimaryColor,
+		AccentColor:  branding.AccentColor,
+		FooterText:   branding.FooterText,
+	}
+	if branding.HasLogo() {
+		data, err := s.Logo(ctx, branding)
+		if err == nil {
+			brand.Logo, _, err = image.Decode(bytes.NewReader(data))
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to load branding logo", "error", err, "user_id", branding.UserID)
+		}
+	}
+	return brand
+}
+
+func (s *BrandingService) brandingOrNew(ctx context.Context, userID string) (*models.OrgBranding, error) {
+	branding, err := s.repo.GetOrgBranding(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if branding == nil {
+		branding = &models.OrgBranding{UserID: userID}
+	}
+	return branding, nil
+}
+
+// pdfColor writes a #RRGGBB color as PDF color components, or "" for an invalid color
+func pdfColor(hex string) string {
+	if !brandColorPattern.MatchString(hex) {
+		return ""
+	}
+	value, _ := strconv.ParseUint(hex[1:], 16, 32)
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(value>>16&0xff)/255, float64(value>>8&0xff)/255, float64(value&0xff)/255)
+}
+
+// pdfImageObject writes an image as a PDF image XObject of RGB samples. Transparency is
+// flattened onto white, as the page is white.
+func pdfImageObject(img image.Image) string {
+	bounds := img.Bounds()
+	samples := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			for _, channel := range []uint8{c.R, c.G, c.B} {
+				samples = append(samples, uint8((uint32(channel)*uint32(c.A)+255*uint32(255-c.A))/255))
+			}
+		}
+	}
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write(samples)
+	writer.Close()
+
+	return fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+		bounds.Dx(), bounds.Dy(), compressed.Len(), compressed.String())
+}
+
+// logoSize fits a logo within maxWidth by maxHeight points, keeping its aspect ratio
+func logoSize(img image.Image, maxWidth, maxHeight float64) (float64, float64) {
+	bounds := img.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+	scale := min(maxWidth/width, maxHeight/height)
+	return width * scale, height * scale
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+type BrandingEndpoints struct {
+	branding *BrandingService
+}
+
+func NewBrandingEndpoints(branding *BrandingService) *BrandingEndpoints {
+	return &BrandingEndpoints{branding: branding}
+}
+
+func (e *BrandingEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/branding", func(r chi.Router) {
+		r.Get("/", e.GetBrandingHandler)
+		r.Put("/", e.UpdateBrandingHandler)
+		r.Get("/logo", e.GetLogoHandler)
+		r.Post("/logo", e.UploadLogoHandler)
+		r.Delete("/logo", e.DeleteLogoHandler)
+	})
+}
+
+// GetBrandingHandler returns the branding applied to sessions with the user's agents, null if
+// they haven't set any
+func (e *BrandingEndpoints) GetBrandingHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	branding, err := e.branding.Get(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get branding"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"branding": branding,
+	})
+}
+
+// UpdateBrandingHandler sets the colors and footer text; empty values fall back to the defaults
+func (e *BrandingEndpoints) UpdateBrandingHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req BrandingUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	branding, err := e.branding.Update(r.Context(), user.ID, req)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to save branding"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Branding updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"branding": branding,
+	})
+}
+
+func (e *BrandingEndpoints) GetLogoHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	branding, err := e.branding.Get(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get branding"))
+		return
+	}
+	if branding == nil || !branding.HasLogo() {
+		apperrors.Write(w, r, apperrors.NotFound("Logo not found"))
+		return
+	}
+	serveLogo(w, r, e.branding, branding)
+}
+
+// UploadLogoHandler accepts a multipart upload with a PNG or JPEG "file", replacing the logo
+func (e *BrandingEndpoints) UploadLogoHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxLogoSize+1<<20) // Allow for multipart overhead
+	if err := r.ParseMultipartForm(maxLogoSize); err != nil {
+		apperrors.Write(w, r, apperrors.TooLarge("Logo must be a multipart upload of at most 512 KB"))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("file is required"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxLogoSize+1))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Failed to read logo"))
+		return
+	}
+
+	branding, err := e.branding.UploadLogo(r.Context(), user.ID, data)
+	if errors.Is(err, ErrInvalidLogo) {
+		apperrors.Write(w, r, apperrors.Unprocessable(err.Error()))
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to upload logo", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to upload logo"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"branding": branding,
+	})
+}
+
+func (e *BrandingEndpoints) DeleteLogoHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	if err := e.branding.DeleteLogo(r.Context(), user.ID); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to delete logo", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete logo"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveLogo writes a branding's logo from blob storage
+func serveLogo(w http.ResponseWriter, r *http.Request, service *BrandingService, branding *models.OrgBranding) {
+	data, err := service.Logo(r.Context(), branding)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to read logo", "error", err, "user_id", branding.UserID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to read logo"))
+		return
+	}
+
+	w.Header().Set("Content-Type", branding.LogoContentType)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(data)
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// comparisonBuckets is how many 10-point buckets a score histogram has
+const comparisonBuckets = 10
+
+// ScoreDistribution is how candidates' normalized (0-100) scores in one metric, or overall,
+// are spread
+type ScoreDistribution struct {
+	Metric     string  `json:"metric"` // "overall" for the overall score
+	Candidates int     `json:"candidates"`
+	Mean       float64 `json:"mean"`
+	StdDev     float64 `json:"std_dev"`
+	Min        float64 `json:"min"`
+	P25        float64 `json:"p25"`
+	Median     float64 `json:"median"`
+	P75        float64 `json:"p75"`
+	Max        float64 `json:"max"`
+	Histogram  []int   `json:"histogram"` // Candidates scoring 0-10, 10-20, ... 90-100
+}
+
+// MetricPlacement is where a candidate's normalized score in one metric places them
+type MetricPlacement struct {
+	Score      float64 `json:"score"`
+	Percentile float64 `json:"percentile"`
+}
+
+// CandidateRanking is a candidate's latest scored session with an agent and where it places
+// them among the other candidates
+type CandidateRanking struct {
+	OrgSession
+	Rank       int                        `json:"rank"` // 1 for the best overall score; ties share a rank
+	Percentile float64                    `json:"percentile"`
+	Metrics    map[string]MetricPlacement `json:"metrics"`
+}
+
+// CandidateComparison compares the candidates who interviewed with one agent
+type CandidateComparison struct {
+	AgentID    string              `json:"agent_id"`
+	AgentName  string              `json:"agent_name"`
+	Candidates int                 `json:"candidates"`
+	Overall    ScoreDistribution   `json:"overall"`
+	Metrics    []ScoreDistribution `json:"metrics"`
+	Rankings   []CandidateRanking  `json:"rankings"`
+}
+
+// CompareCandidates ranks the candidates of an agent's scored sessions, latest first, by their
+// latest session. Metric scores are normalized to 0-100 by their maximum, so metrics scored on
+// different scales compare. A percentile is the share of candidates scoring lower, counting
+// ties as half; a candidate is only compared in the metrics their session was scored on.
+func CompareCandidates(agent *models.Agent, sessions []models.InterviewSession, invited map[string]models.InterviewInvite) CandidateComparison {
+	seen := make(map[string]bool)
+	rankings := make([]CandidateRanking, 0, len(sessions))
+	for _, session := range sessions {
+		if session.Summary == nil || seen[session.UserID] {
+			continue
+		}
+		seen[session.UserID] = true
+
+		row := orgSessionRow(session, invited)
+		row.AgentName = agent.Name
+		ranking := CandidateRanking{OrgSession: row, Metrics: make(map[string]MetricPlacement)}
+		for _, score := range session.PerformanceScores {
+			if score.MaxScore > 0 {
+				ranking.Metrics[score.Metric] = MetricPlacement{Score: round1(score.Score * 100 / score.MaxScore)}
+			}
+		}
+		rankings = append(rankings, ranking)
+	}
+
+	slices.SortStableFunc(rankings, func(a, b CandidateRanking) int {
+		return cmp.Or(cmp.Compare(*b.OverallScore, *a.OverallScore), a.StartedAt.Compare(b.StartedAt))
+	})
+
+	overall := make([]float64, len(rankings))
+	byMetric := make(map[string][]float64)
+	for i, ranking := range rankings {
+		overall[i] = *ranking.OverallScore
+		for metric, placement := range ranking.Metrics {
+			byMetric[metric] = append(byMetric[metric], placement.Score)
+		}
+	}
+
+	for i := range rankings {
+		rankings[i].Rank = i + 1
+		if i > 0 && *rankings[i].OverallScore == *rankings[i-1].OverallScore {
+			rankings[i].Rank = rankings[i-1].Rank
+		}
+		rankings[i].Percentile = percentileOf(overall, *rankings[i].OverallScore)
+		for metric, placement := range rankings[i].Metrics {
+			placement.Percentile = percentileOf(byMetric[metric], placement.Score)
+			rankings[i].Metrics[metric] = placement
+		}
+	}
+
+	metrics := make([]ScoreDistribution, 0, len(byMetric))
+	for _, metric := range slices.Sorted(maps.Keys(byMetric)) {
+		metrics = append(metrics, distribution(metric, byMetric[metric]))
+	}
+
+	return CandidateComparison{
+		AgentID:    agent.ID,
+		AgentName:  agent.Name,
+		Candidates: len(rankings),
+		Overall:    distribution("overall", overall),
+		Metrics:    metrics,
+		Rankings:   rankings,
+	}
+}
+
+// distribution summarizes scores on a 0-100 scale
+func distribution(metric string, scores []float64) ScoreDistribution {
+	dist := ScoreDistribution{Metric: metric, Candidates: len(scores), Histogram: make([]int, comparisonBuckets)}
+	if len(scores) == 0 {
+		return dist
+	}
+
+	sorted := slices.Sorted(slices.Values(scores))
+	var sum float64
+	for _, score := range sorted {
+		sum += score
+		bucket := int(score) * comparisonBuckets / 100
+		dist.Histogram[min(max(bucket, 0), comparisonBuckets-1)]++
+	}
+	mean := sum / float64(len(sorted))
+	var squares float64
+	for _, score := range sorted {
+		squares += (score - mean) * (score - mean)
+	}
+
+	dist.Mean = round1(mean)
+	dist.StdDev = round1(math.Sqrt(squares / float64(len(sorted))))
+	dist.Min = sorted[0]
+	dist.P25 = round1(quantile(sorted, 0.25))
+	dist.Median = round1(quantile(sorted, 0.5))
+	dist.P75 = round1(quantile(sorted, 0.75))
+	dist.Max = sorted[len(sorted)-1]
+	return dist
+}
+
+// quantile interpolates the q-th quantile of sorted scores
+func quantile(sorted []float64, q float64) float64 {
+	position := q * float64(len(sorted)-1)
+	lower := int(position)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[lower+1]-sorted[lower])*(position-float64(lower))
+}
+
+// percentileOf is the percentage of scores below a score, counting those equal to it as half
+func percentileOf(scores []float64, score float64) float64 {
+	var below, equal int
+	for _, other := range scores {
+		if other < score {
+			below++
+		} else if other == score {
+			equal++
+		}
+	}
+	return round1((float64(below) + float64(equal)/2) * 100 / float64(len(scores)))
+}
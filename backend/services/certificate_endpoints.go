@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+type CertificateEndpoints struct {
+	repo         *repository.GORMRepository
+	certificates *CertificateService
+	branding     *BrandingService
+}
+
+func NewCertificateEndpoints(repo *repository.GORMRepository, certificates *CertificateService, branding *BrandingService) *CertificateEndpoints {
+	return &CertificateEndpoints{
+		repo:         repo,
+		certificates: certificates,
+		branding:     branding,
+	}
+}
+
+// RegisterPublicRoutes registers the verification endpoints anyone with a code can use
+func (e *CertificateEndpoints) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/verify/{code}", e.VerifyCertificateHandler)
+	r.Get("/verify/{code}/logo", e.VerifyLogoHandler)
+}
+
+func (e *CertificateEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/certificates", func(r chi.Router) {
+		r.Get("/", e.GetCertificatesHandler)
+		r.Get("/session/{id}", e.GetSessionCertificateHandler)
+		r.Post("/session/{id}", e.IssueCertificateHandler)
+		r.Get("/session/{id}/pdf", e.GetCertificatePDFHandler)
+		r.Delete("/session/{id}", e.RevokeCertificateHandler)
+	})
+}
+
+func (e *CertificateEndpoints) GetCertificatesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	certificates, err := e.repo.GetUserCertificates(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get certificates"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"certificates": certificates,
+	})
+}
+
+func (e *CertificateEndpoints) GetSessionCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	certificate, ok := e.ownedCertificate(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"certificate":      certificate,
+		"verification_url": e.certificates.VerificationURL(certificate.Code),
+	})
+}
+
+// IssueCertificateHandler issues a session's certificate if it qualifies, e.g. for sessions
+// summarized before certificates existed. Issuing is idempotent.
+func (e *CertificateEndpoints) IssueCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := e.ownedSession(w, r)
+	if !ok {
+		return
+	}
+
+	// Scores brought in from another platform weren't earned here
+	if session.ImportID != nil {
+		apperrors.Write(w, r, apperrors.Unprocessable("Imported sessions do not qualify for a certificate"))
+		return
+	}
+
+	summary, err := e.repo.GetInterviewSummary(r.Context(), session.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get summary"))
+		return
+	}
+	if summary == nil {
+		apperrors.Write(w, r, apperrors.Conflict("Session has no summary yet"))
+		return
+	}
+	agent, err := e.repo.GetAgent(r.Context(), session.AgentID)
+	if err != nil || agent == nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agent"))
+		return
+	}
+
+	certificate, err := e.certificates.Issue(r.Context(), summary, agent)
+	if errors.Is(err, ErrCertificateNotEligible) {
+		apperrors.Write(w, r, apperrors.Unprocessable("Session does not qualify for a certificate"))
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to issue certificate", "error", err, "session_id", session.ID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to issue certificate"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"certificate":      certificate,
+		"verification_url": e.certificates.VerificationURL(certificate.Code),
+	})
+}
+
+func (e *CertificateEndpoints) GetCertificatePDFHandler(w http.ResponseWriter, r *http.Request) {
+	certificate, ok := e.ownedCertificate(w, r)
+	if !ok {
+		return
+	}
+	if certificate.RevokedAt != nil {
+		apperrors.Write(w, r, apperrors.Gone("Certificate has been revoked"))
+		return
+	}
+
+	user := r.Context().Value("user").(*models.User) // checked by ownedCertificate
+	branding, err := e.sessionBranding(r.Context(), certificate.SessionID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get branding"))
+		return
+	}
+	pdf, err := e.certificates.PDF(certificate, UserLocaleFormat(user), e.branding.Brand(r.Context(), branding))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to render certificate", "error", err, "certificate_id", certificate.ID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to render certificate"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="praxis-certificate-%s.pdf"`, certificate.Code))
+	w.Write(pdf)
+}
+
+// RevokeCertificateHandler withdraws a certificate so its verification link stops vouching for it
+func (e *CertificateEndpoints) RevokeCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	certificate, ok := e.ownedCertificate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.repo.RevokeCertificate(r.Context(), certificate.ID, time.Now()); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to revoke certificate"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Certificate revoked", "certificate_id", certificate.ID, "session_id", certificate.SessionID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyCertificateHandler returns a certificate's signed payload and whether it is still valid,
+// with the branding of the interview's agent owner for the page to show it in
+func (e *CertificateEndpoints) VerifyCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	verification, err := e.certificates.Verify(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to verify certificate"))
+		return
+	}
+	if verification == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Certificate not found"))
+		return
+	}
+
+	if branding, err := e.certificateBranding(r); err == nil {
+		// The logo URL is relative to the API, like the page's own request
+		verification.Branding = NewShareBranding(branding, "/verify/"+url.PathEscape(chi.URLParam(r, "code"))+"/logo")
+	} else {
+		// The page is still worth showing unbranded
+		logging.FromContext(r.Context()).Warn("Failed to get certificate branding", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verification)
+}
+
+// VerifyLogoHandler serves the logo shown on a certificate's verification page
+func (e *CertificateEndpoints) VerifyLogoHandler(w http.ResponseWriter, r *http.Request) {
+	branding, err := e.certificateBranding(r)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get branding"))
+		return
+	}
+	if branding == nil || !branding.HasLogo() {
+		apperrors.Write(w, r, apperrors.NotFound("Logo not found"))
+		return
+	}
+	serveLogo(w, r, e.branding, branding)
+}
+
+// certificateBranding returns the branding of the agent owner behind the certificate with the
+// request's code, nil if there is none
+func (e *CertificateEndpoints) certificateBranding(r *http.Request) (*models.OrgBranding, error) {
+	code := strings.ToUpper(strings.TrimSpace(chi.URLParam(r, "code")))
+	certificate, err := e.repo.GetCertificateByCode(r.Context(), code)
+	if err != nil || certificate == nil {
+		return nil, err
+	}
+	return e.sessionBranding(r.Context(), certificate.SessionID)
+}
+
+// sessionBranding returns the branding of a session's agent owner, nil if there is none
+func (e *CertificateEndpoints) sessionBranding(ctx context.Context, sessionID string) (*models.OrgBranding, error) {
+	session, err := e.repo.GetInterviewSession(ctx, sessionID)
+	if err != nil || session == nil {
+		return nil, err
+	}
+	agent, err := e.repo.GetAgent(ctx, session.AgentID)
+	if err != nil || agent == nil {
+		return nil, err
+	}
+	return e.branding.ForAgent(ctx, agent)
+}
+
+// ownedSession loads a session the current user owns, writing the error response if there isn't one
+func (e *CertificateEndpoints) ownedSession(w http.ResponseWriter, r *http.Request) (*models.InterviewSession, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return nil, false
+	}
+
+	session, err := e.repo.GetInterviewSession(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return nil, false
+	}
+	if session == nil || session.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return nil, false
+	}
+	return session, true
+}
+
+func (e *CertificateEndpoints) ownedCertificate(w http.ResponseWriter, r *http.Request) (*models.Certificate, bool) {
+	session, ok := e.ownedSession(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	certificate, err := e.repo.GetSessionCertificate(r.Context(), session.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get certificate"))
+		return nil, false
+	}
+	if certificate == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Certificate not found"))
+		return nil, false
+	}
+	return certificate, true
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page size of the certificate: US Letter, landscape, in points
+const (
+	certificatePageWidth  = 792
+	certificatePageHeight = 612
+)
+
+// pdfLine is a single horizontally centred line of Helvetica text
+type pdfLine struct {
+	text  string
+	size  float64
+	y     float64
+	color string // PDF fill color, empty for black
+}
+
+// renderCertificatePDF writes a single-page PDF of centred text lines inside a border.
+// It only needs the standard Helvetica font, so no font embedding or PDF library is required.
+// A brand colors the border, puts its logo above the lines and its footer text below them.
+func renderCertificatePDF(lines []pdfLine, brand *Brand) []byte {
+	if brand == nil {
+		brand = &Brand{}
+	}
+	border := pdfColor(brand.PrimaryColor)
+	if border == "" {
+		border = "0 0 0"
+	}
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "%s RG 2 w 36 36 720 540 re S\n1 w 44 44 704 524 re S\n", border)
+	resources := "/Font << /F1 4 0 R >>"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"", // Page, once its resources are known
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"", // Content, once written
+	}
+	if brand.Logo != nil {
+		width, height := logoSize(brand.Logo, 240, 60)
+		fmt.Fprintf(&content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im1 Do Q\n", width, height, (certificatePageWidth-width)/2, 560-height)
+		objects = append(objects, pdfImageObject(brand.Logo))
+		resources += fmt.Sprintf(" /XObject << /Im1 %d 0 R >>", len(objects))
+	}
+	if footer := strings.TrimSpace(brand.FooterText); footer != "" {
+		// Wrapped to fit under the verification link, the last line just inside the border
+		wrapped := wrapReportText(footer, reportFontRegular, 9, 640)
+		for i, text := range wrapped {
+			lines = append(lines, pdfLine{text: text, size: 9, y: float64(54 + 11*(len(wrapped)-1-i))})
+		}
+	}
+
+	for _, line := range lines {
+		text := pdfLatin1(line.text)
+		x := (certificatePageWidth - helveticaWidth(text)*line.size/1000) / 2
+		color := line.color
+		if color == "" {
+			color = "0 0 0"
+		}
+		fmt.Fprintf(&content, "BT %s rg /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n", color, line.size, x, line.y, pdfEscape(text))
+	}
+
+	objects[2] = fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << %s >> /Contents 5 0 R >>", certificatePageWidth, certificatePageHeight, resources)
+	objects[4] = pdfStream(content.String())
+	return writePDF(objects)
+}
+
+// pdfStream wraps page content in a stream object
+func pdfStream(content string) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content)
+}
+
+// writePDF numbers objects from 1 in the order given, the first being the catalog, and writes
+// them out with their cross-reference table
+func writePDF(objects []string) []byte {
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes()
+}
+
+// pdfLatin1 maps text to the WinAnsi (Latin-1) range the standard fonts cover
+func pdfLatin1(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r < 0x20 || r > 0xff {
+			r = '?'
+		}
+		b.WriteByte(byte(r))
+	}
+	return b.String()
+}
+
+func pdfEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text)
+}
+
+// helveticaWidth approximates the width of Latin-1 text in Helvetica, in 1/1000 em
+func helveticaWidth(text string) float64 {
+	var width float64
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == ' ' || strings.IndexByte("ijlIft.,:;!'|", c) >= 0:
+			width += 278
+		case c >= 'A' && c <= 'Z' || strings.IndexByte("mw@%", c) >= 0:
+			width += 700
+		default:
+			width += 556
+		}
+	}
+	return width
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// certificatePayloadVersion is bumped whenever CertificatePayload changes shape
+const certificatePayloadVersion = 1
+
+// ErrCertificateNotEligible is returned when a session doesn't qualify for a certificate
+var ErrCertificateNotEligible = errors.New("session is not eligible for a certificate")
+
+// CertificatePayload is the signed content of a certificate
+type CertificatePayload struct {
+	Version       int       `json:"v"`
+	Code          string    `json:"code"`
+	CandidateName string    `json:"candidate_name"`
+	AgentName     string    `json:"agent_name"`
+	Industry      string    `json:"industry,omitempty"`
+	Level         string    `json:"level,omitempty"`
+	OverallScore  float64   `json:"overall_score"`
+	CompletedAt   time.Time `json:"completed_at"`
+	IssuedAt      time.Time `json:"issued_at"`
+}
+
+// CertificateVerification is the public answer to "is this certificate genuine?"
+type CertificateVerification struct {
+	Valid     bool                `json:"valid"`
+	Reason    string              `json:"reason,omitempty"` // Why an existing certificate isn't valid
+	Payload   json.RawMessage     `json:"payload,omitempty"`
+	Signature string              `json:"signature,omitempty"`
+	KeyID     string              `json:"key_id,omitempty"`
+	PublicKey string              `json:"public_key,omitempty"` // Base64 Ed25519 key to check the signature offline
+	Details   *CertificatePayload `json:"details,omitempty"`
+	Branding  *ShareBranding      `json:"branding,omitempty"` // Of the agent's owner, for the page showing it
+}
+
+// CertificateService issues and verifies certificates for completed sessions scoring at
+// least the configured threshold. Certificates are signed with Ed25519 so anyone holding
+// the public key can check a shared payload without trusting the page that shows it.
+type CertificateService struct {
+	repo      *repository.GORMRepository
+	key       ed25519.PrivateKey
+	keyID     string
+	minScore  float64
+	publicURL string
+}
+
+func NewCertificateService(repo *repository.GORMRepository, config CertificateConfig, jwtSecret, publicURL string) (*CertificateService, error) {
+	var seed []byte
+	if config.SigningKey != "" {
+		decoded, err := base64.StdEncoding.DecodeString(config.SigningKey)
+		if err != nil || len(decoded) != ed25519.SeedSize {
+			return nil, fmt.Errorf("CERTIFICATE_SIGNING_KEY must be a base64 %d-byte Ed25519 seed", ed25519.SeedSize)
+		}
+		seed = decoded
+	} else {
+		// Stable across restarts, but rotating the JWT secret would invalidate issued certificates
+		slog.Warn("CERTIFICATE_SIGNING_KEY not set, deriving the certificate key from the JWT secret")
+		derived := sha256.Sum256([]byte("praxis-certificate:" + jwtSecret))
+		seed = derived[:]
+	}
+
+	key := ed25519.NewKeyFromSeed(seed)
+	fingerprint := sha256.Sum256(key.Public().(ed25519.PublicKey))
+
+	return &CertificateService{
+		repo:      repo,
+		key:       key,
+		keyID:     hex.EncodeToString(fingerprint[:8]),
+		minScore:  config.MinScore,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}, nil
+}
+
+// HandleSummaryGenerated issues a certificate once a completed session's summary clears the threshold
+func (s *CertificateService) HandleSummaryGenerated(ctx context.Context, event Event) error {
+	summaryCtx, err := loadSummaryEventContext(ctx, s.repo, event)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Issue(ctx, summaryCtx.summary, summaryCtx.agent)
+	if errors.Is(err, ErrCertificateNotEligible) {
+		return nil
+	}
+	return err
+}
+
+// Issue creates the certificate for a summary's session, returning the existing one if it
+// was already issued
+func (s *CertificateService) Issue(ctx context.Context, summary *models.InterviewSummary, agent *models.Agent) (*models.Certificate, error) {
+	existing, err := s.repo.GetSessionCertificate(ctx, summary.SessionID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	session, err := s.repo.GetInterviewSession(ctx, summary.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Eligible(session, summary) {
+		return nil, ErrCertificateNotEligible
+	}
+
+	user, err := s.repo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrCertificateNotEligible
+	}
+
+	code, err := newCertificateCode()
+	if err != nil {
+		return nil, err
+	}
+	candidateName := user.FullName
+	if candidateName == "" {
+		candidateName = "Praxis candidate"
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	completedAt := now
+	if session.EndedAt != nil {
+		completedAt = session.EndedAt.UTC().Truncate(time.Second)
+	}
+
+	payload, err := json.Marshal(CertificatePayload{
+		Version:       certificatePayloadVersion,
+		Code:          code,
+		CandidateName: candidateName,
+		AgentName:     agent.Name,
+		Industry:      agent.Industry,
+		Level:         agent.Level,
+		OverallScore:  summary.OverallScore,
+		CompletedAt:   completedAt,
+		IssuedAt:      now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	certificate := &models.Certificate{
+		Code:         code,
+		SessionID:    session.ID,
+		UserID:       session.UserID,
+		OverallScore: summary.OverallScore,
+		Payload:      string(payload),
+		Signature:    base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload)),
+		KeyID:        s.keyID,
+		IssuedAt:     now,
+	}
+	created, err := s.repo.CreateCertificate(ctx, certificate)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Issued concurrently, e.g. by a redelivered event
+		return s.repo.GetSessionCertificate(ctx, session.ID)
+	}
+
+	logging.FromContext(ctx).Info("Certificate issued", "session_id", session.ID, "user_id", session.UserID, "code", code, "overall_score", summary.OverallScore)
+	return certificate, nil
+}
+
+// Eligible reports whether a session qualifies: completed, at or above the score threshold,
+// and not failed by a scoring policy gate
+func (s *CertificateService) Eligible(session *models.InterviewSession, summary *models.InterviewSummary) bool {
+	if session == nil || summary == nil || session.Status != "completed" {
+		return false
+	}
+	if summary.Passed != nil && !*summary.Passed {
+		return false
+	}
+	return summary.OverallScore >= s.minScore
+}
+
+// Verify checks a shared certificate code. Unknown codes return nil.
+func (s *CertificateService) Verify(ctx context.Context, code string) (*CertificateVerification, error) {
+	certificate, err := s.repo.GetCertificateByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil || certificate == nil {
+		return nil, err
+	}
+
+	verification := &CertificateVerification{
+		Payload:   json.RawMessage(certificate.Payload),
+		Signature: certificate.Signature,
+		KeyID:     certificate.KeyID,
+		PublicKey: base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey)),
+	}
+
+	var details CertificatePayload
+	if err := json.Unmarshal([]byte(certificate.Payload), &details); err == nil {
+		verification.Details = &details
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(certificate.Signature)
+	switch {
+	case certificate.RevokedAt != nil:
+		verification.Reason = "revoked"
+	case certificate.KeyID != s.keyID:
+		verification.Reason = "signed with a retired key"
+	case err != nil || !ed25519.Verify(s.key.Public().(ed25519.PublicKey), []byte(certificate.Payload), signature):
+		verification.Reason = "invalid signature"
+	default:
+		verification.Valid = true
+	}
+	return verification, nil
+}
+
+// VerificationURL returns the shareable page that verifies a certificate
+func (s *CertificateService) VerificationURL(code string) string {
+	return s.publicURL + "/verify/" + code
+}
+
+// PDF renders a printable certificate with its verification link, dated in the reader's locale
+// and branded by the agent's owner if brand isn't nil
+func (s *CertificateService) PDF(certificate *models.Certificate, format *LocaleFormat, brand *Brand) ([]byte, error) {
+	var payload CertificatePayload
+	if err := json.Unmarshal([]byte(certificate.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("decode certificate payload: %w", err)
+	}
+
+	role := payload.AgentName
+	if descriptor := strings.TrimSpace(payload.Level + " " + payload.Industry); descriptor != "" {
+		role += " (" + descriptor + ")"
+	}
+
+	title := ""
+	if brand != nil {
+		title = pdfColor(brand.PrimaryColor)
+	}
+	return renderCertificatePDF([]pdfLine{
+		{text: "Certificate of Interview Completion", size: 26, y: 460, color: title},
+		{text: "This certifies that", size: 14, y: 410},
+		{text: payload.CandidateName, size: 30, y: 365},
+		{text: "completed a mock interview with " + role, size: 14, y: 320},
+		{text: fmt.Sprintf("with an overall score of %.0f / 100", payload.OverallScore), size: 14, y: 298},
+		{text: "Completed " + format.Date(payload.CompletedAt), size: 12, y: 250},
+		{text: "Verify at " + s.VerificationURL(payload.Code), size: 10, y: 120},
+		{text: "Certificate code " + payload.Code + "  -  Key " + certificate.KeyID, size: 10, y: 102},
+	}, brand), nil
+}
+
+// newCertificateCode returns a random, unambiguous public code
+func newCertificateCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw), nil
+}
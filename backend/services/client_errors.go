@@ -0,0 +1,154 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// maxClientErrorMessage caps a reported message; longer ones are cut
+	maxClientErrorMessage = 500
+	// maxClientErrorContext caps the details sent with a report
+	maxClientErrorContext = 4 * 1024
+)
+
+var clientErrorKinds = map[string]bool{
+	models.ClientErrorPlayback:  true,
+	models.ClientErrorDecode:    true,
+	models.ClientErrorReconnect: true,
+	models.ClientErrorOther:     true,
+}
+
+// ClientErrorReport is a failure reported by the frontend, over the WebSocket or over HTTP
+// when the WebSocket is down
+type ClientErrorReport struct {
+	SessionID string          `json:"session_id,omitempty"` // HTTP only; a WebSocket report belongs to its session
+	Kind      string          `json:"kind"`                 // playback, decode, reconnect, other
+	Message   string          `json:"message"`
+	Context   json.RawMessage `json:"context,omitempty"` // A JSON object of details, e.g. the media error code
+}
+
+// Validate normalizes the report and checks its kind and details
+func (r *ClientErrorReport) Validate() error {
+	r.Kind = strings.ToLower(strings.TrimSpace(r.Kind))
+	if !clientErrorKinds[r.Kind] {
+		return fmt.Errorf("kind must be playback, decode, reconnect or other")
+	}
+	r.Message = strings.TrimSpace(r.Message)
+	if r.Message == "" {
+		return fmt.Errorf("message is required")
+	}
+	if len(r.Message) > maxClientErrorMessage {
+		r.Message = strings.ToValidUTF8(r.Message[:maxClientErrorMessage], "")
+	}
+	if len(r.Context) > 0 {
+		if len(r.Context) > maxClientErrorContext {
+			return fmt.Errorf("context must be at most %d bytes", maxClientErrorContext)
+		}
+		if !json.Valid(r.Context) || !bytes.HasPrefix(bytes.TrimSpace(r.Context), []byte("{")) {
+			return fmt.Errorf("context must be a JSON object")
+		}
+	}
+	return nil
+}
+
+// ClientErrorService records failures the frontend reports, so problems seen in the field can
+// be debugged per session
+type ClientErrorService struct {
+	repo *repository.GORMRepository
+}
+
+func NewClientErrorService(repo *repository.GORMRepository) *ClientErrorService {
+	return &ClientErrorService{repo: repo}
+}
+
+// Record stores a validated report against the user and, if any, the session
+func (s *ClientErrorService) Record(ctx context.Context, userID string, sessionID *string, report ClientErrorReport, source, userAgent string) error {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	now := time.Now()
+	entry := models.ClientError{
+		UserID:      userID,
+		SessionID:   sessionID,
+		Kind:        report.Kind,
+		Message:     report.Message,
+		Source:      source,
+		UserAgent:   userAgent,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}
+	if len(report.Context) > 0 {
+		details := string(report.Context)
+		entry.Context = &details
+	}
+
+	session := ""
+	if sessionID != nil {
+		session = *sessionID
+	}
+	logging.FromContext(ctx).Warn("Client error reported", "kind", report.Kind, "message", report.Message, "source", source, "user_id", userID, "session_id", session)
+	return s.repo.RecordClientError(ctx, &entry)
+}
+
+type ClientErrorEndpoints struct {
+	repo         *repository.GORMRepository
+	clientErrors *ClientErrorService
+}
+
+func NewClientErrorEndpoints(repo *repository.GORMRepository, clientErrors *ClientErrorService) *ClientErrorEndpoints {
+	return &ClientErrorEndpoints{repo: repo, clientErrors: clientErrors}
+}
+
+func (e *ClientErrorEndpoints) RegisterRoutes(r chi.Router) {
+	r.Post("/client-errors", e.ReportClientErrorHandler)
+}
+
+// ReportClientErrorHandler records a failure the frontend couldn't report over the WebSocket,
+// e.g. while it keeps reconnecting
+func (e *ClientErrorEndpoints) ReportClientErrorHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var report ClientErrorReport
+	body := http.MaxBytesReader(w, r.Body, 2*maxClientErrorContext)
+	if err := json.NewDecoder(body).Decode(&report); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if err := report.Validate(); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	var sessionID *string
+	if report.SessionID != "" {
+		session, err := e.repo.GetInterviewSession(r.Context(), report.SessionID)
+		if err != nil || session == nil || session.UserID != user.ID {
+			apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+			return
+		}
+		sessionID = &session.ID
+	}
+
+	if err := e.clientErrors.Record(r.Context(), user.ID, sessionID, report, models.ClientErrorSourceHTTP, r.UserAgent()); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to record client error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
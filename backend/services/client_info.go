@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// maxAudioCodec caps the audio MIME type a client reports
+const maxAudioCodec = 100
+
+// recordableAudio are the containers browsers record answers in that transcription accepts
+var recordableAudio = map[string]bool{
+	"audio/webm": true,
+	"audio/ogg":  true,
+	"audio/mp4":  true,
+	"audio/mpeg": true,
+	"audio/wav":  true,
+}
+
+// connectionTypes are the values browsers report for navigator.connection's type and
+// effectiveType
+var connectionTypes = map[string]bool{
+	"bluetooth": true, "cellular": true, "ethernet": true, "wifi": true, "wimax": true, "other": true,
+	"slow-2g": true, "2g": true, "3g": true, "4g": true,
+}
+
+// ClientInfo is what a WebSocket connection says about the client it comes from
+type ClientInfo struct {
+	UserAgent      string
+	Browser        string
+	Platform       string
+	AudioCodec     string // "" when the client didn't say or named a format that can't be transcribed
+	ConnectionType string
+}
+
+// ClientInfoFromRequest reads the client of a WebSocket upgrade request: the browser and
+// platform from its user agent, and the audio codec and connection type the frontend passes
+// as the codec and connection query parameters
+func ClientInfoFromRequest(r *http.Request) ClientInfo {
+	userAgent := r.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = strings.ToValidUTF8(userAgent[:255], "")
+	}
+	browser, platform := describeUserAgent(userAgent)
+	return ClientInfo{
+		UserAgent:      userAgent,
+		Browser:        browser,
+		Platform:       platform,
+		AudioCodec:     NormalizeAudioCodec(r.URL.Query().Get("codec")),
+		ConnectionType: normalizeConnectionType(r.URL.Query().Get("connection")),
+	}
+}
+
+// NormalizeAudioCodec returns the recording MIME type a client reports in lower case, e.g.
+// "audio/webm;codecs=opus", or "" if it isn't one transcription accepts
+func NormalizeAudioCodec(codec string) string {
+	codec = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(codec), " ", ""))
+	if codec == "" || len(codec) > maxAudioCodec || !recordableAudio[baseMIME(codec)] {
+		return ""
+	}
+	return codec
+}
+
+func normalizeConnectionType(connection string) string {
+	connection = strings.ToLower(strings.TrimSpace(connection))
+	if !connectionTypes[connection] {
+		return ""
+	}
+	return connection
+}
+
+// ClientInfoService records the clients sessions are held from, and how their spoken answers
+// fare, so failures can be told apart by browser, platform, codec and connection
+type ClientInfoService struct {
+	repo *repository.GORMRepository
+}
+
+func NewClientInfoService(repo *repository.GORMRepository) *ClientInfoService {
+	return &ClientInfoService{repo: repo}
+}
+
+// RecordConnect stores the client a candidate connected to a session from
+func (s *ClientInfoService) RecordConnect(ctx context.Context, client *ws.Client, info ClientInfo) error {
+	logging.FromContext(ctx).Info("Session client", "session_id", client.SessionID, "browser", info.Browser, "platform", info.Platform, "audio_codec", info.AudioCodec, "connection_type", info.ConnectionType)
+	return s.repo.RecordSessionClientInfo(ctx, &models.SessionClientInfo{
+		SessionID:      client.SessionID,
+		UserID:         client.UserID,
+		UserAgent:      info.UserAgent,
+		Browser:        info.Browser,
+		Platform:       info.Platform,
+		AudioCodec:     info.AudioCodec,
+		ConnectionType: info.ConnectionType,
+	})
+}
+
+// RecordTranscription counts a transcription attempt of the session's spoken answers. Attempts
+// cut short by the candidate leaving aren't counted against the client.
+func (s *ClientInfoService) RecordTranscription(ctx context.Context, client *ws.Client, err error) {
+	if err != nil && client.Context().Err() != nil {
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("Transcription failed for client", "session_id", client.SessionID, "audio_codec", audioMIME(client), "user_agent", client.UserAgent, "error", err)
+	}
+	s.repo.RecordSessionTranscription(context.WithoutCancel(ctx), client.SessionID, err != nil)
+}
+
+// audioMIME returns the format a client records answers in, assuming the frontend's default
+// when it didn't say
+func audioMIME(client *ws.Client) string {
+	if client.AudioCodec != "" {
+		return client.AudioCodec
+	}
+	return recordedAudioMIME
+}
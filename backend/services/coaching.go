@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// coachingTimeout bounds generating one coaching note
+const coachingTimeout = 30 * time.Second
+
+// maxCoachingNoteLength caps a note, in characters, so a rambling model can't flood the client
+const maxCoachingNoteLength = 600
+
+// BuildCoachingPrompt asks for a private tip on the candidate's answer to a question. The tip
+// coaches how to answer without answering for them, and is never part of the interview itself.
+func BuildCoachingPrompt(agent *models.Agent, language, question, answer string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are a private interview coach watching a candidate practice a %s level %s interview.\n", agent.Level, agent.Industry)
+	b.WriteString("Write one short coaching note (at most two sentences) on the candidate's latest answer: the single most useful thing to improve or keep doing, such as structure, specifics, missing trade-offs or clarity. ")
+	b.WriteString("Address the candidate as \"you\". Do not give them the answer, do not ask a question, and do not write as the interviewer. ")
+	fmt.Fprintf(&b, "Write in the language with ISO 639-1 code %q. Reply with the note only.\n\n", language)
+	if question != "" {
+		fmt.Fprintf(&b, "Interviewer's question:\n%s\n\n", question)
+	}
+	fmt.Fprintf(&b, "Candidate's answer:\n%s\n", answer)
+	return b.String()
+}
+
+// lastQuestion returns what the interviewer last said before the candidate's answer
+func lastQuestion(history []models.InterviewTranscript) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Speaker == "agent" {
+			return history[i].Content
+		}
+	}
+	return ""
+}
+
+// coach writes, in the background, a private coaching note on an interview answer of a session
+// practiced with hints, saves it and sends it to the candidate as a hint. Proctored sessions
+// never get hints.
+func (p *AIMessageProcessor) coach(client *ws.Client, session *models.InterviewSession, agent *models.Agent, history []models.InterviewTranscript, answer, transcriptID string) {
+	if !session.Coaching || session.Proctored || strings.TrimSpace(answer) == "" {
+		return
+	}
+	question := lastQuestion(history)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), coachingTimeout)
+		defer cancel()
+
+		note, err := p.llm.GenerateCoachingNote(ctx, BuildCoachingPrompt(agent, session.Language, question, answer))
+		if err != nil {
+			logging.FromContext(client.Context()).Warn("Failed to generate coaching note", "session_id", session.ID, "error", err)
+			return
+		}
+		if note = strings.TrimSpace(note); note == "" {
+			return
+		}
+		if len(note) > maxCoachingNoteLength {
+			note = strings.ToValidUTF8(note[:maxCoachingNoteLength], "")
+		}
+
+		record := &models.CoachingNote{SessionID: session.ID, Question: question, Note: note}
+		if transcriptID != "" {
+			record.TranscriptID = &transcriptID
+		}
+		// The hint is still worth showing if it couldn't be saved
+		p.repo.CreateCoachingNote(ctx, record)
+		p.sendEnvelope(client, ws.TypeHint, ws.HintPayload{Note: note, TranscriptID: transcriptID})
+	}()
+}
+
+// GetCoachingNotesHandler returns the coaching notes of one of the user's sessions
+func (e *SessionEndpoints) GetCoachingNotesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return
+	}
+	if session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+
+	notes, err := e.repo.GetSessionCoachingNotes(r.Context(), session.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get coaching notes"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id": session.ID,
+		"notes":      notes,
+		"count":      len(notes),
+	})
+}
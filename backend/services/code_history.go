@@ -0,0 +1,197 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const (
+	// diffContextLines is how many unchanged lines surround each change in a diff
+	diffContextLines = 3
+	// maxDiffCells bounds the line-matching table; larger pairs of submissions are diffed as a
+	// full replacement
+	maxDiffCells = 4_000_000
+)
+
+// CodeSubmission is one piece of code the candidate submitted, with the interviewer's analysis
+// of it and how it changed since the previous submission
+type CodeSubmission struct {
+	ID          string    `json:"id"`
+	Language    string    `json:"language"`
+	Code        string    `json:"code"`
+	Stage       string    `json:"stage,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	Analysis    string    `json:"analysis,omitempty"`
+	Findings    string    `json:"findings,omitempty"` // Static analysis output the analysis was based on
+	Diff        string    `json:"diff,omitempty"`     // Unified diff against the previous submission; empty for the first
+}
+
+// buildCodeHistory collects a session's code submissions in the order they were made, pairing
+// each with the analysis that replied to it
+func buildCodeHistory(transcripts []models.InterviewTranscript) []CodeSubmission {
+	analyses := make(map[string]models.InterviewTranscript)
+	var code []models.InterviewTranscript
+	for _, transcript := range transcripts {
+		if transcript.Kind == models.TranscriptKindCode {
+			code = append(code, transcript)
+		} else if transcript.ReplyToID != nil {
+			analyses[*transcript.ReplyToID] = transcript
+		}
+	}
+	sort.SliceStable(code, func(i, j int) bool {
+		return code[i].Timestamp.Before(code[j].Timestamp)
+	})
+
+	submissions := make([]CodeSubmission, 0, len(code))
+	for i, transcript := range code {
+		submission := CodeSubmission{
+			ID:          transcript.ID,
+			Language:    transcript.Language,
+			Code:        transcript.Content,
+			Stage:       transcript.Stage,
+			SubmittedAt: transcript.Timestamp,
+			Analysis:    analyses[transcript.ID].Content,
+			Findings:    analyses[transcript.ID].Findings,
+		}
+		if i > 0 {
+			submission.Diff = UnifiedDiff(code[i-1].Content, transcript.Content,
+				fmt.Sprintf("submission %d", i), fmt.Sprintf("submission %d", i+1))
+		}
+		submissions = append(submissions, submission)
+	}
+	return submissions
+}
+
+// diffOp is one line of a line diff: ' ' unchanged, '-' removed or '+' added. aIndex and
+// bIndex are the positions in the old and new text before the line.
+type diffOp struct {
+	kind   byte
+	text   string
+	aIndex int
+	bIndex int
+}
+
+// UnifiedDiff returns a unified diff of two texts by line, or "" when they are identical
+func UnifiedDiff(from, to, fromName, toName string) string {
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	var diff strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change and extend the hunk while changes are close enough to share context
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for next := first + 1; next < len(ops); next++ {
+			if ops[next].kind == ' ' {
+				continue
+			}
+			if next-last-1 > 2*diffContextLines {
+				break
+			}
+			last = next
+		}
+
+		hunkStart := max(first-diffContextLines, start)
+		hunkEnd := min(last+diffContextLines+1, len(ops))
+		if diff.Len() == 0 {
+			fmt.Fprintf(&diff, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		writeHunk(&diff, ops[hunkStart:hunkEnd])
+		start = hunkEnd
+	}
+	return diff.String()
+}
+
+// writeHunk writes one hunk header and its lines
+func writeHunk(diff *strings.Builder, ops []diffOp) {
+	fromCount, toCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			fromCount++
+		}
+		if op.kind != '-' {
+			toCount++
+		}
+	}
+	// An empty side is numbered from the line before it, as in diff -u
+	fromStart, toStart := ops[0].aIndex, ops[0].bIndex
+	if fromCount > 0 {
+		fromStart++
+	}
+	if toCount > 0 {
+		toStart++
+	}
+
+	fmt.Fprintf(diff, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+	for _, op := range ops {
+		diff.WriteByte(op.kind)
+		diff.WriteString(op.text)
+		diff.WriteByte('\n')
+	}
+}
+
+// diffLines matches the longest common subsequence of lines and returns the edit script
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if (n+1)*(m+1) > maxDiffCells {
+		ops := make([]diffOp, 0, n+m)
+		for i, line := range a {
+			ops = append(ops, diffOp{kind: '-', text: line, aIndex: i})
+		}
+		for j, line := range b {
+			ops = append(ops, diffOp{kind: '+', text: line, aIndex: n, bIndex: j})
+		}
+		return ops
+	}
+
+	// common[i][j] is the LCS length of a[i:] and b[j:]
+	common := make([][]int, n+1)
+	for i := range common {
+		common[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: a[i], aIndex: i, bIndex: j})
+			i++
+			j++
+		case j < m && (i == n || common[i][j+1] > common[i+1][j]):
+			ops = append(ops, diffOp{kind: '+', text: b[j], aIndex: i, bIndex: j})
+			j++
+		default:
+			ops = append(ops, diffOp{kind: '-', text: a[i], aIndex: i, bIndex: j})
+			i++
+		}
+	}
+	return ops
+}
+
+// splitLines splits text into lines, ignoring a final newline
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
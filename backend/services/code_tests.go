@@ -0,0 +1,601 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// testLanguage is how hidden tests run for one language. Compiled languages build the
+// submission with the suite and a harness in a build directory, which is removed before command
+// runs the binary once per declared test from a run directory holding only the binary.
+// Interpreted languages run command once from a run directory holding the submission, and the
+// harness command starts reads the suite from stdin, so it never touches the disk. Either way
+// results are the tokens the harness prints for the tests that passed (see test_harness.go),
+// never what the tests print or a report the submission could write.
+type testLanguage struct {
+	file string
+	// files returns the other files written next to the submission, given the suite and the
+	// tests it declares
+	files   func(suite string, tests []string) (map[string]string, error)
+	build   []string
+	binary  string
+	command []string
+	// declared matches each test the suite declares; its first non-empty group is the name
+	declared *regexp.Regexp
+	// reserved matches a declaration the suite can't make, as the harness makes it
+	reserved *regexp.Regexp
+	// refuse says why a submission can't be run, e.g. it could read the suite; it fails every test
+	refuse func(code string) string
+}
+
+var testLanguages = map[string]testLanguage{
+	// The suite is a _test.go file in the submission's package, normally package main
+	"go": {
+		file:     "main.go",
+		files:    goFiles,
+		build:    []string{"go", "test", "-c", "-o", "suite.test", "."},
+		binary:   "suite.test",
+		command:  []string{"./suite.test"},
+		declared: regexp.MustCompile(`(?m)^func (Test\w+)\(\w+ \*testing\.T\)`),
+		reserved: regexp.MustCompile(`(?m)^func (TestMain)\(`),
+		refuse:   refuseGoSubmission,
+	},
+	// The suite is a pytest module that imports from solution
+	"python": {
+		file: "solution.py",
+		files: func(string, []string) (map[string]string, error) {
+			return map[string]string{"test_solution.py": ""}, nil
+		},
+		command:  []string{"python3", "-c", pythonHarness},
+		declared: regexp.MustCompile(`(?m)^\s*(?:async\s+)?def (test\w*)\s*\(`),
+	},
+	// The suite is a node:test file that requires ./solution.js
+	"javascript": {
+		file:     "solution.js",
+		command:  []string{"node", "-e", javascriptHarness},
+		declared: regexp.MustCompile("\\b(?:test|it)\\(\\s*(?:'([^']*)'|\"([^\"]*)\"|`([^`]*)`)"),
+	},
+}
+
+// testLanguageNames lists the languages hidden tests can be written in
+func testLanguageNames() []string {
+	names := make([]string, 0, len(testLanguages))
+	for name := range testLanguages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// maxTestOutput caps how much of the test run's output is kept; compile errors and the first
+// failures come first, so the start is what matters
+const maxTestOutput = 4000
+
+// TestResult is how a code submission did against its question's hidden tests
+type TestResult struct {
+	Passed   int
+	Total    int
+	Tests    []TestCaseResult
+	Output   string // What the build and the tests printed, for reviewers; truncated
+	TimedOut bool
+}
+
+// TestCaseResult is the outcome of one hidden test
+type TestCaseResult struct {
+	Name   string
+	Passed bool
+}
+
+// CodeTestRunner grades code submissions against the hidden test suites of coding questions.
+// Unlike linting this executes the candidate's code, so it only ever runs inside the
+// configured sandbox command, which is given the directory to mount as {dir} and must pass
+// stdin through.
+type CodeTestRunner struct {
+	enabled bool
+	sandbox []string
+	timeout time.Duration
+}
+
+func NewCodeTestRunner(enabled bool, sandbox string, timeout time.Duration) *CodeTestRunner {
+	runner := &CodeTestRunner{enabled: enabled, sandbox: strings.Fields(sandbox), timeout: timeout}
+	if enabled && len(runner.sandbox) == 0 {
+		slog.Warn("Code tests are enabled without a sandbox command, submissions won't be graded")
+		runner.enabled = false
+	}
+	return runner
+}
+
+// Applies reports whether a submission in language to question would be run against hidden
+// tests: tests are enabled, and the question has some for that language
+func (r *CodeTestRunner) Applies(language string, question *models.Question) bool {
+	if !r.enabled || question == nil || question.TestSuite == "" || !strings.EqualFold(language, question.TestLanguage) {
+		return false
+	}
+	_, ok := testLanguages[question.TestLanguage]
+	return ok
+}
+
+// Run runs a question's hidden tests against a submission. It returns false when the
+// submission can't be graded: the tests don't apply to it, or the sandbox itself failed.
+func (r *CodeTestRunner) Run(ctx context.Context, code, language string, question *models.Question) (TestResult, bool) {
+	logger := logging.FromContext(ctx)
+	if !r.Applies(language, question) {
+		return TestResult{}, false
+	}
+	lang := testLanguages[question.TestLanguage]
+	declared := declaredTests(question.TestSuite, lang.declared)
+	if lang.refuse != nil {
+		if reason := lang.refuse(code); reason != "" {
+			return gradeTests(declared, nil, reason), true
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "praxis-tests-*")
+	if err != nil {
+		logger.Error("Failed to create test directory", "error", err)
+		return TestResult{}, false
+	}
+	defer os.RemoveAll(dir)
+	workDir := filepath.Join(dir, "build")
+	if lang.build == nil {
+		workDir = filepath.Join(dir, "run")
+	}
+	files := map[string]string{lang.file: code}
+	if lang.files != nil {
+		extra, err := lang.files(question.TestSuite, uniqueTests(declared))
+		if err != nil {
+			logger.Error("Failed to prepare test files", "error", err, "question_id", question.ID)
+			return TestResult{}, false
+		}
+		maps.Copy(files, extra)
+	}
+	for name, content := range files {
+		path := filepath.Join(workDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			logger.Error("Failed to create test directory", "error", err)
+			return TestResult{}, false
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			logger.Error("Failed to write test file", "error", err)
+			return TestResult{}, false
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var result TestResult
+	var ok bool
+	if lang.build != nil {
+		result, ok = r.runCompiled(ctx, dir, lang, declared, question)
+	} else {
+		result, ok = r.runInterpreted(ctx, workDir, lang, declared, question)
+	}
+	if !ok {
+		return TestResult{}, false
+	}
+	result.TimedOut = ctx.Err() != nil
+	logger.Info("Hidden tests run", "question_id", question.ID, "language", question.TestLanguage, "passed", result.Passed, "total", result.Total)
+	return result, true
+}
+
+// runCompiled builds the submission in the build directory with a copy of a trusted build
+// cache, moves the binary to the run directory and removes the build directory with the suite,
+// then runs each declared test on its own with a fresh token. The binary is checked before each
+// run, as an earlier run could have replaced it.
+func (r *CodeTestRunner) runCompiled(ctx context.Context, dir string, lang testLanguage, declared []string, question *models.Question) (TestResult, bool) {
+	logger := logging.FromContext(ctx)
+	buildDir, runDir := filepath.Join(dir, "build"), filepath.Join(dir, "run")
+	if seed := goCacheSeed(ctx); seed != "" {
+		if err := os.CopyFS(filepath.Join(buildDir, ".cache"), os.DirFS(seed)); err != nil {
+			logger.Error("Failed to copy the build cache", "error", err)
+			return TestResult{}, false
+		}
+	}
+	stdout, stderr, ok := r.sandboxed(ctx, buildDir, lang.build, nil, question)
+	if !ok {
+		return TestResult{}, false
+	}
+	// A submission that doesn't compile fails every test
+	if _, err := os.Stat(filepath.Join(buildDir, lang.binary)); err != nil {
+		return gradeTests(declared, nil, cleanTestOutput(append(stdout, stderr...), buildDir)), true
+	}
+	binary := filepath.Join(runDir, lang.binary)
+	if err := os.Mkdir(runDir, 0o700); err != nil {
+		logger.Error("Failed to create run directory", "error", err)
+		return TestResult{}, false
+	}
+	if err := os.Rename(filepath.Join(buildDir, lang.binary), binary); err != nil {
+		logger.Error("Failed to move test binary", "error", err)
+		return TestResult{}, false
+	}
+	if err := os.RemoveAll(buildDir); err != nil {
+		logger.Error("Failed to remove build directory", "error", err)
+		return TestResult{}, false
+	}
+	sum, err := fileSum(binary)
+	if err != nil {
+		logger.Error("Failed to read test binary", "error", err)
+		return TestResult{}, false
+	}
+
+	var reported []TestCaseResult
+	var output []byte
+	for _, test := range uniqueTests(declared) {
+		if ctx.Err() != nil {
+			break
+		}
+		if current, err := fileSum(binary); err != nil || current != sum {
+			logger.Warn("Test binary changed between tests", "question_id", question.ID)
+			output = append(output, "The test binary was changed by an earlier test\n"...)
+			break
+		}
+		token := rand.Text()
+		stdout, stderr, ok := r.sandboxed(ctx, runDir, lang.command, []byte(token+"\n"+test+"\n"), question)
+		if !ok {
+			return TestResult{}, false
+		}
+		passed, printed := tokenResults(append(stdout, stderr...), map[string]string{token: test})
+		reported = append(reported, passed...)
+		output = append(output, printed...)
+	}
+	return gradeTests(declared, reported, cleanTestOutput(output, runDir)), true
+}
+
+// runInterpreted runs the harness once, handing it a token for each declared test and the suite
+func (r *CodeTestRunner) runInterpreted(ctx context.Context, runDir string, lang testLanguage, declared []string, question *models.Question) (TestResult, bool) {
+	tokens := make(map[string]string)
+	for _, test := range uniqueTests(declared) {
+		tokens[rand.Text()] = test
+	}
+	stdout, stderr, ok := r.sandboxed(ctx, runDir, lang.command, harnessInput(tokens, question.TestSuite), question)
+	if !ok {
+		return TestResult{}, false
+	}
+	passed, printed := tokenResults(append(stdout, stderr...), tokens)
+	return gradeTests(declared, passed, cleanTestOutput(printed, runDir)), true
+}
+
+// The Go build cache seed is built once per process and shared by code tests and static analysis
+var (
+	goSeedOnce sync.Once
+	goSeed     string
+)
+
+// goCacheSeed returns a Go build cache holding the harness and the standard library packages
+// tests commonly use, or "" if it couldn't be built. It's built once, outside the sandbox, from
+// code the runner controls; each build or lint run gets its own copy, so no submission can
+// poison another's, and copying beats compiling the testing package within every run's timeout.
+func goCacheSeed(ctx context.Context) string {
+	goSeedOnce.Do(func() {
+		logger := logging.FromContext(ctx)
+		dir, err := os.MkdirTemp("", "praxis-test-cache-*")
+		if err != nil {
+			logger.Error("Failed to create build cache directory", "error", err)
+			return
+		}
+		files, _ := goFiles(goSeedSuite, []string{"TestSeed"})
+		files["main.go"] = goSeedSubmission
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				logger.Error("Failed to create build cache directory", "error", err)
+				return
+			}
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				logger.Error("Failed to write build cache seed", "error", err)
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), goSeedTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "go", "test", "-c", "-o", "suite.test", ".")
+		cmd.Dir = dir
+		cmd.Env = testEnv(dir)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			logger.Error("Failed to build the build cache seed", "error", err, "output", truncateTestOutput(string(output)))
+			return
+		}
+		goSeed = filepath.Join(dir, ".cache")
+	})
+	return goSeed
+}
+
+// goSeedTimeout bounds building the build cache seed, which compiles the testing package
+const goSeedTimeout = 5 * time.Minute
+
+const goSeedSuite = "package main\n\nimport \"testing\"\n\nfunc TestSeed(t *testing.T) {}\n"
+
+const goSeedSubmission = `package main
+
+import (
+	_ "bufio"
+	_ "bytes"
+	_ "container/heap"
+	_ "container/list"
+	_ "errors"
+	_ "fmt"
+	_ "maps"
+	_ "math"
+	_ "math/big"
+	_ "regexp"
+	_ "slices"
+	_ "sort"
+	_ "strconv"
+	_ "strings"
+	_ "sync"
+	_ "time"
+	_ "unicode"
+)
+
+func main() {}
+`
+
+// sandboxed runs a command in the sandbox from dir, mounted as {dir}, with stdin, returning its
+// stdout and stderr. It returns false only if the sandbox itself failed; tests exit non-zero
+// when they fail, and runs that time out are graded on what they reported.
+func (r *CodeTestRunner) sandboxed(ctx context.Context, dir string, command []string, stdin []byte, question *models.Question) ([]byte, []byte, bool) {
+	logger := logging.FromContext(ctx)
+	stdout, stderr, err := runSandboxed(ctx, r.sandbox, dir, testEnv(dir), command, stdin)
+	if ctx.Err() != nil {
+		logger.Warn("Hidden tests timed out", "question_id", question.ID, "timeout", r.timeout)
+		return stdout, stderr, true
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		logger.Error("Failed to run hidden tests", "error", err, "question_id", question.ID)
+		return nil, nil, false
+	}
+	return stdout, stderr, true
+}
+
+// runSandboxed runs a command inside the sandbox command from dir, which replaces {dir} in it,
+// with env and stdin, returning its stdout, stderr and exit error
+func runSandboxed(ctx context.Context, sandbox []string, dir string, env []string, command []string, stdin []byte) ([]byte, []byte, error) {
+	args := make([]string, 0, len(sandbox)+len(command))
+	for _, arg := range sandbox {
+		args = append(args, strings.ReplaceAll(arg, "{dir}", dir))
+	}
+	args = append(args, command...)
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// testEnv keeps builds and tests inside dir and off the network, with dir's own Go build cache
+func testEnv(dir string) []string {
+	return []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"GOCACHE=" + filepath.Join(dir, ".cache"),
+		"GOPATH=" + filepath.Join(dir, ".gopath"),
+		"GOFLAGS=-mod=mod",
+		"GOPROXY=off",
+		"GOTOOLCHAIN=local",
+		"CGO_ENABLED=0",
+		"PYTHONDONTWRITEBYTECODE=1",
+	}
+}
+
+// tokenResults reads the tests that passed from the tokens in a run's output, returning them
+// with the rest of the output
+func tokenResults(output []byte, tokens map[string]string) ([]TestCaseResult, []byte) {
+	var passed []TestCaseResult
+	var rest []byte
+	for line := range bytes.Lines(output) {
+		if test, ok := tokens[string(bytes.TrimSpace(line))]; ok {
+			passed = append(passed, TestCaseResult{Name: test, Passed: true})
+			continue
+		}
+		rest = append(rest, line...)
+	}
+	return passed, rest
+}
+
+// cleanTestOutput drops the scratch directory from paths in the output, as it means nothing to
+// anyone
+func cleanTestOutput(output []byte, dir string) string {
+	return string(bytes.ReplaceAll(output, []byte(dir+string(filepath.Separator)), nil))
+}
+
+func fileSum(path string) ([sha256.Size]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(content), nil
+}
+
+// uniqueTests drops repeated test names, keeping the first
+func uniqueTests(tests []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, test := range tests {
+		if !seen[test] {
+			seen[test] = true
+			unique = append(unique, test)
+		}
+	}
+	return unique
+}
+
+// declaredTests returns the names of the tests a suite declares, in order
+func declaredTests(suite string, declared *regexp.Regexp) []string {
+	var names []string
+	for _, match := range declared.FindAllStringSubmatch(suite, -1) {
+		for _, name := range match[1:] {
+			if name != "" {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// gradeTests grades the tests a suite declares by the results the runner reported. A test
+// passes if it was reported passing and never failing, counting parametrized cases under their
+// test; results for tests the suite doesn't declare, which the submission could have added,
+// are ignored, and declared tests without a result failed. When no tests could be found in the
+// suite, every reported test is graded. A run without any tests counts as a single failed test.
+func gradeTests(declared []string, reported []TestCaseResult, output string) TestResult {
+	passed := make(map[string]bool)
+	var order []string
+	for _, test := range reported {
+		name, _, _ := strings.Cut(test.Name, "[")
+		previous, seen := passed[name]
+		if !seen {
+			order = append(order, name)
+		}
+		passed[name] = test.Passed && (!seen || previous)
+	}
+	if len(declared) > 0 {
+		order = declared
+	}
+
+	result := TestResult{Output: truncateTestOutput(strings.TrimSpace(output))}
+	seen := make(map[string]bool)
+	for _, name := range order {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		result.Tests = append(result.Tests, TestCaseResult{Name: name, Passed: passed[name]})
+		result.Total++
+		if passed[name] {
+			result.Passed++
+		}
+	}
+	if result.Total == 0 {
+		result.Total = 1
+	}
+	return result
+}
+
+func truncateTestOutput(output string) string {
+	if len(output) > maxTestOutput {
+		return output[:maxTestOutput] + "\n... (truncated)"
+	}
+	return output
+}
+
+// Summary describes the run for the code review: the tests that failed and the output
+func (r TestResult) Summary() string {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Passed %d of %d tests", r.Passed, r.Total)
+	if r.TimedOut {
+		summary.WriteString(" (the run timed out)")
+	}
+	var failed []string
+	for _, test := range r.Tests {
+		if !test.Passed {
+			failed = append(failed, test.Name)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(&summary, "\nFailed: %s", strings.Join(failed, ", "))
+	}
+	if r.Output != "" {
+		fmt.Fprintf(&summary, "\nOutput:\n%s", r.Output)
+	}
+	return summary.String()
+}
+
+// finalTestResults returns the hidden test results of the last graded submission for each
+// question, in the order the questions were first answered
+func finalTestResults(transcripts []models.InterviewTranscript) []models.InterviewTranscript {
+	var order []string
+	final := make(map[string]models.InterviewTranscript)
+	for _, transcript := range transcripts {
+		if transcript.Kind != models.TranscriptKindCode || transcript.QuestionID == nil || transcript.TestsTotal == 0 {
+			continue
+		}
+		id := *transcript.QuestionID
+		previous, seen := final[id]
+		if !seen {
+			order = append(order, id)
+		}
+		if !seen || !transcript.Timestamp.Before(previous.Timestamp) {
+			final[id] = transcript
+		}
+	}
+
+	results := make([]models.InterviewTranscript, 0, len(order))
+	for _, id := range order {
+		results = append(results, final[id])
+	}
+	return results
+}
+
+// testPassRate is the mean pass rate (0-100) of each question's final graded submission, and
+// false when no submission was graded
+func testPassRate(transcripts []models.InterviewTranscript) (float64, bool) {
+	results := finalTestResults(transcripts)
+	if len(results) == 0 {
+		return 0, false
+	}
+	var total float64
+	for _, result := range results {
+		total += float64(result.TestsPassed) / float64(result.TestsTotal)
+	}
+	return math.Round(total/float64(len(results))*10000) / 100, true
+}
+
+// testResultsInstruction tells the summary model how the candidate's code did against the hidden
+// tests, so its review of the code is weighed against an objective result
+func testResultsInstruction(transcripts []models.InterviewTranscript) string {
+	rate, ok := testPassRate(transcripts)
+	if !ok {
+		return ""
+	}
+	var lines []string
+	for i, result := range finalTestResults(transcripts) {
+		lines = append(lines, fmt.Sprintf("- Coding question %d (%s): passed %d of %d tests", i+1, result.Language, result.TestsPassed, result.TestsTotal))
+	}
+	return fmt.Sprintf(`
+
+AUTOMATED TESTS: The candidate's final submission for each coding question was run against hidden unit tests (overall pass rate %.0f%%):
+%s
+Combine these results with your own review of the code: passing tests show correctness, while your review should cover approach, quality and communication.`, rate, strings.Join(lines, "\n"))
+}
+
+// testPerformanceScores turns the hidden test pass rate into a "Unit Tests" performance score,
+// so scoring policies can weight or gate on it
+func testPerformanceScores(sessionID string, transcripts []models.InterviewTranscript) []models.PerformanceScore {
+	rate, ok := testPassRate(transcripts)
+	if !ok {
+		return nil
+	}
+	return []models.PerformanceScore{{
+		SessionID: sessionID,
+		Metric:    "Unit Tests",
+		Score:     rate,
+		MaxScore:  100.0,
+	}}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	maxBannedTopicLen = 100
+	maxBannedTerms    = 50
+	maxBannedReason   = 500
+	// maxComplianceAttempts is how many replies are generated before a banned one is replaced
+	// with complianceFallbackReply
+	maxComplianceAttempts = 2
+	// complianceFallbackReply replaces a reply that kept raising a banned topic
+	complianceFallbackReply = "Let's move on. Could you walk me through a recent piece of work you're proud of, and the part you played in it?"
+	// complianceFallbackWelcome replaces a welcome that raised a banned topic
+	complianceFallbackWelcome = "Hello, and thank you for joining. To start, could you give me a short overview of your background?"
+)
+
+// ComplianceService enforces banned topics. The topics banned site-wide and by an agent's
+// owner are hard constraints in the interviewer's prompt, and every reply is screened for them
+// before it reaches the candidate; a reply that raises one is blocked and the violation logged.
+type ComplianceService struct {
+	repo     *repository.GORMRepository
+	sessions map[string][]models.BannedTopic // Topics banned in each prepared session
+	mutex    sync.RWMutex
+}
+
+func NewComplianceService(repo *repository.GORMRepository) *ComplianceService {
+	return &ComplianceService{
+		repo:     repo,
+		sessions: make(map[string][]models.BannedTopic),
+	}
+}
+
+type BannedTopicRequest struct {
+	Topic  string   `json:"topic"`
+	Terms  []string `json:"terms"` // Words and phrases that raise the topic; the topic itself always does
+	Reason string   `json:"reason"`
+}
+
+// validate trims the request and checks its lengths
+func (req *BannedTopicRequest) validate() string {
+	req.Topic = strings.TrimSpace(req.Topic)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Topic == "" || len(req.Topic) > maxBannedTopicLen {
+		return fmt.Sprintf("topic must be 1-%d characters", maxBannedTopicLen)
+	}
+	if len(req.Reason) > maxBannedReason {
+		return fmt.Sprintf("reason must be at most %d characters", maxBannedReason)
+	}
+	if len(req.Terms) > maxBannedTerms {
+		return fmt.Sprintf("at most %d terms are allowed", maxBannedTerms)
+	}
+	terms := req.Terms[:0]
+	for _, term := range req.Terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		if len(term) > maxBannedTopicLen {
+			return fmt.Sprintf("terms must be at most %d characters", maxBannedTopicLen)
+		}
+		terms = append(terms, term)
+	}
+	req.Terms = terms
+	return ""
+}
+
+// bannedTopicTerms returns what raises a topic: the topic itself and its terms
+func bannedTopicTerms(topic models.BannedTopic) []string {
+	var terms []string
+	if err := json.Unmarshal([]byte(topic.Terms), &terms); err != nil {
+		slog.Warn("Invalid banned topic terms", "topic_id", topic.ID, "error", err)
+	}
+	return append([]string{topic.Topic}, terms...)
+}
+
+// MatchBannedTopic returns the first topic text raises and the term that matched it, or nil.
+// Terms match case-insensitively as whole words, so "age" doesn't match "manage".
+func MatchBannedTopic(topics []models.BannedTopic, text string) (*models.BannedTopic, string) {
+	for i := range topics {
+		for _, term := range bannedTopicTerms(topics[i]) {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				continue
+			}
+			pattern := regexp.MustCompile(`(?i)(?:^|[^\pL\pN])` + regexp.QuoteMeta(term) + `(?:[^\pL\pN]|$)`)
+			if pattern.MatchString(text) {
+				return &topics[i], term
+			}
+		}
+	}
+	return nil, ""
+}
+
+// Load fetches the topics banned for a session's agent and keeps them for Screen. It returns
+// the topics as lines for the interviewer's prompt.
+func (s *ComplianceService) Load(ctx context.Context, sessionID string, agent *models.Agent) []string {
+	topics, err := s.repo.GetAgentBannedTopics(ctx, agent.UserID)
+	if err != nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	s.sessions[sessionID] = topics
+	s.mutex.Unlock()
+
+	lines := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		line := topic.Topic
+		if terms := bannedTopicTerms(topic)[1:]; len(terms) > 0 {
+			line += fmt.Sprintf(" (e.g. %s)", strings.Join(terms, ", "))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Screen reports whether text raises a topic banned in the session, recording the violation
+// if it does
+func (s *ComplianceService) Screen(ctx context.Context, sessionID string, agent *models.Agent, text string) bool {
+	s.mutex.RLock()
+	topics := s.sessions[sessionID]
+	s.mutex.RUnlock()
+
+	topic, term := MatchBannedTopic(topics, text)
+	if topic == nil {
+		return false
+	}
+
+	logging.FromContext(ctx).Warn("Reply blocked for raising a banned topic", "session_id", sessionID, "agent_id", agent.ID, "topic", topic.Topic, "term", term)
+	// Recorded even if the candidate has just disconnected
+	s.repo.CreateComplianceViolation(context.WithoutCancel(ctx), &models.ComplianceViolation{
+		SessionID:     sessionID,
+		AgentID:       agent.ID,
+		OwnerID:       agent.UserID,
+		BannedTopicID: topic.ID,
+		Topic:         topic.Topic,
+		Term:          term,
+		Content:       text,
+	})
+	return true
+}
+
+// Forget drops the topics of a finished session
+func (s *ComplianceService) Forget(sessionID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.sessions, sessionID)
+}
+
+// screened generates a reply until one raises no banned topic, falling back to a neutral
+// question after maxComplianceAttempts
+func (p *AIMessageProcessor) screened(ctx context.Context, sessionID string, agent *models.Agent, generate func() (string, error)) (string, error) {
+	for attempt := 0; attempt < maxComplianceAttempts; attempt++ {
+		response, err := generate()
+		if err != nil || !p.compliance.Screen(ctx, sessionID, agent, response) {
+			return response, err
+		}
+	}
+	return complianceFallbackReply, nil
+}
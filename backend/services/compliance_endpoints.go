@@ -0,0 +1,258 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// defaultViolationsLimit is how many compliance violations are listed without a limit
+const defaultViolationsLimit = 100
+
+// ComplianceEndpoints lets agent owners ban topics for their agents and review the replies that
+// were blocked. Site-wide topics are managed under /admin/compliance.
+type ComplianceEndpoints struct {
+	repo *repository.GORMRepository
+}
+
+func NewComplianceEndpoints(repo *repository.GORMRepository) *ComplianceEndpoints {
+	return &ComplianceEndpoints{repo: repo}
+}
+
+func (e *ComplianceEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/compliance", func(r chi.Router) {
+		r.Get("/topics", e.GetBannedTopicsHandler)
+		r.Post("/topics", e.CreateBannedTopicHandler)
+		r.Put("/topics/{id}", e.UpdateBannedTopicHandler)
+		r.Delete("/topics/{id}", e.DeleteBannedTopicHandler)
+		r.Get("/violations", e.GetViolationsHandler)
+	})
+}
+
+// GetBannedTopicsHandler lists the user's own banned topics and the site-wide ones that also
+// apply to their agents
+func (e *ComplianceEndpoints) GetBannedTopicsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	topics, err := e.repo.GetBannedTopics(r.Context(), &user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get banned topics"))
+		return
+	}
+	siteTopics, err := e.repo.GetBannedTopics(r.Context(), nil)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get banned topics"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"topics":      topics,
+		"site_topics": siteTopics,
+	})
+}
+
+func (e *ComplianceEndpoints) CreateBannedTopicHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+	createBannedTopic(w, r, e.repo, &user.ID)
+}
+
+func (e *ComplianceEndpoints) UpdateBannedTopicHandler(w http.ResponseWriter, r *http.Request) {
+	topic, ok := e.ownedTopic(w, r)
+	if !ok {
+		return
+	}
+	updateBannedTopic(w, r, e.repo, topic)
+}
+
+func (e *ComplianceEndpoints) DeleteBannedTopicHandler(w http.ResponseWriter, r *http.Request) {
+	topic, ok := e.ownedTopic(w, r)
+	if !ok {
+		return
+	}
+	deleteBannedTopic(w, r, e.repo, topic)
+}
+
+// GetViolationsHandler lists the latest replies blocked in sessions with the user's agents
+func (e *ComplianceEndpoints) GetViolationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+	listViolations(w, r, e.repo, &user.ID)
+}
+
+// ownedTopic loads a banned topic the current user owns, writing the error response if there isn't one
+func (e *ComplianceEndpoints) ownedTopic(w http.ResponseWriter, r *http.Request) (*models.BannedTopic, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return nil, false
+	}
+
+	topic, err := e.repo.GetBannedTopic(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get banned topic"))
+		return nil, false
+	}
+	if topic == nil || topic.OwnerID == nil || *topic.OwnerID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Banned topic not found"))
+		return nil, false
+	}
+	return topic, true
+}
+
+func (e *AdminEndpoints) GetSiteBannedTopicsHandler(w http.ResponseWriter, r *http.Request) {
+	topics, err := e.repo.GetBannedTopics(r.Context(), nil)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get banned topics"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"topics": topics,
+	})
+}
+
+func (e *AdminEndpoints) CreateSiteBannedTopicHandler(w http.ResponseWriter, r *http.Request) {
+	createBannedTopic(w, r, e.repo, nil)
+}
+
+func (e *AdminEndpoints) UpdateSiteBannedTopicHandler(w http.ResponseWriter, r *http.Request) {
+	topic, ok := e.siteTopic(w, r)
+	if !ok {
+		return
+	}
+	updateBannedTopic(w, r, e.repo, topic)
+}
+
+func (e *AdminEndpoints) DeleteSiteBannedTopicHandler(w http.ResponseWriter, r *http.Request) {
+	topic, ok := e.siteTopic(w, r)
+	if !ok {
+		return
+	}
+	deleteBannedTopic(w, r, e.repo, topic)
+}
+
+// GetAllViolationsHandler lists the latest replies blocked in any session
+func (e *AdminEndpoints) GetAllViolationsHandler(w http.ResponseWriter, r *http.Request) {
+	listViolations(w, r, e.repo, nil)
+}
+
+func (e *AdminEndpoints) siteTopic(w http.ResponseWriter, r *http.Request) (*models.BannedTopic, bool) {
+	topic, err := e.repo.GetBannedTopic(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get banned topic"))
+		return nil, false
+	}
+	if topic == nil || topic.OwnerID != nil {
+		apperrors.Write(w, r, apperrors.NotFound("Banned topic not found"))
+		return nil, false
+	}
+	return topic, true
+}
+
+// decodeBannedTopic reads and validates a banned topic request into topic. Changes apply to
+// sessions started from then on.
+func decodeBannedTopic(w http.ResponseWriter, r *http.Request, topic *models.BannedTopic) bool {
+	var req BannedTopicRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return false
+	}
+	if msg := req.validate(); msg != "" {
+		apperrors.Write(w, r, apperrors.BadRequest(msg))
+		return false
+	}
+
+	terms, err := json.Marshal(append([]string{}, req.Terms...))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid banned topic"))
+		return false
+	}
+	topic.Topic = req.Topic
+	topic.Terms = string(terms)
+	topic.Reason = req.Reason
+	return true
+}
+
+func createBannedTopic(w http.ResponseWriter, r *http.Request, repo *repository.GORMRepository, ownerID *string) {
+	topic := &models.BannedTopic{OwnerID: ownerID}
+	if !decodeBannedTopic(w, r, topic) {
+		return
+	}
+	if err := repo.CreateBannedTopic(r.Context(), topic); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create banned topic"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Banned topic created", "topic_id", topic.ID, "site_wide", ownerID == nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"topic": topic,
+	})
+}
+
+func updateBannedTopic(w http.ResponseWriter, r *http.Request, repo *repository.GORMRepository, topic *models.BannedTopic) {
+	if !decodeBannedTopic(w, r, topic) {
+		return
+	}
+	if err := repo.UpdateBannedTopic(r.Context(), topic); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update banned topic"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Banned topic updated", "topic_id", topic.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"topic": topic,
+	})
+}
+
+func deleteBannedTopic(w http.ResponseWriter, r *http.Request, repo *repository.GORMRepository, topic *models.BannedTopic) {
+	if err := repo.DeleteBannedTopic(r.Context(), topic.ID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete banned topic"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Banned topic deleted", "topic_id", topic.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func listViolations(w http.ResponseWriter, r *http.Request, repo *repository.GORMRepository, ownerID *string) {
+	limit := defaultViolationsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	violations, err := repo.GetComplianceViolations(r.Context(), ownerID, limit)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get compliance violations"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"violations": violations,
+		"count":      len(violations),
+	})
+}
@@ -2,34 +2,78 @@ package services
 
 import (
 	"log/slog"
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds application configuration
 type Config struct {
-	Server    ServerConfig
-	Database  DatabaseConfig
-	AI        AIConfig
-	JWT       JWTConfig
-	WebSocket WebSocketConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	AI          AIConfig
+	JWT         JWTConfig
+	WebSocket   WebSocketConfig
+	Quality     QualityConfig
+	Summary     SummaryConfig
+	Interview   InterviewConfig
+	Storage     StorageConfig
+	Export      ExportConfig
+	Purge       PurgeConfig
+	Geo         GeoConfig
+	Legal       LegalConfig
+	Mail        MailConfig
+	Secrets     SecretsConfig
+	AgentDigest AgentDigestConfig
+	Certificate CertificateConfig
+	Research    ResearchConfig
+	RateLimit   RateLimitConfig
+	TempFiles   TempFilesConfig
+	Transcode   TranscodeConfig
 }
 
 type ServerConfig struct {
-	Port string
+	Port      string
+	PublicURL string // Base URL of the web app, used for links in emails
+
+	ReadHeaderTimeout  time.Duration // How long a client may take to send request headers
+	ReadTimeout        time.Duration // How long a client may take to send a whole request
+	WriteTimeout       time.Duration // How long writing a response may take
+	IdleTimeout        time.Duration // How long an idle keep-alive connection is kept
+	RequestTimeout     time.Duration // Deadline for handling an API request, passed on to database and provider calls
+	SlowRequestTimeout time.Duration // Deadline for uploads, exports and streamed downloads
+	ShutdownTimeout    time.Duration // How long shutdown waits for requests and background work
+
+	CompressionMinBytes int // Smallest response body worth gzipping; 0 disables compression
+	CompressionLevel    int // gzip level, 1 (fastest) to 9 (smallest)
+
+	TrustedProxies string // Comma-separated addresses and CIDR ranges of proxies whose X-Forwarded-For is believed
 }
 
 type DatabaseConfig struct {
-	URL          string
-	Seed         bool
-	LogLevel     string
-	MaxIdleConns int
-	MaxOpenConns int
+	URL             string
+	Seed            bool
+	LogLevel        string
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnectAttempts int           // Connection attempts at startup before giving up
+	ConnectBackoff  time.Duration // Delay before the first retry; doubles on each attempt
+	FailFast        bool          // Exit if the database is unavailable at startup instead of starting degraded
 }
 
 type AIConfig struct {
-	GeminiAPIKey  string
-	ElevenLabsKey string
+	GeminiAPIKey      string
+	ElevenLabsKey     string
+	ElevenLabsRegions string // Comma-separated name=url list of regional TTS endpoints
+	AudioCacheDir     string // Directory for pre-rendered speech of common and welcome phrases
+	StreamSpeech      bool   // Send replies as audio chunks while ElevenLabs is still synthesizing
+	Transcription     string // Comma-separated speech-to-text providers (gemini, whisper, deepgram), tried in order
+	OpenAIKey         string // For the whisper provider
+	DeepgramKey       string // For the deepgram provider
+	Timeouts          CallTimeouts
 }
 
 type JWTConfig struct {
@@ -38,6 +82,146 @@ type JWTConfig struct {
 
 type WebSocketConfig struct {
 	AllowedOrigins string
+	Compression    bool // Negotiate permessage-deflate with clients that support it
+	// Most a session may transfer, in MB of messages before compression; 0 is unlimited. Over
+	// MaxSessionMBIn voice answers are refused, over MaxSessionMBOut replies are sent as text.
+	MaxSessionMBIn  int
+	MaxSessionMBOut int
+}
+
+type QualityConfig struct {
+	Window           int     // Number of recent summaries compared per prompt version/model
+	AlertDrop        float64 // Quality score drop (points) versus baseline that raises an alert
+	MinJSONValidRate float64 // Minimum acceptable JSON validity rate (0.0 to 1.0)
+}
+
+// SummaryConfig sizes the summary job queue. A failed attempt is retried after RetryBackoff,
+// doubling each time, until MaxAttempts have been made.
+type SummaryConfig struct {
+	Workers          int // Summaries generated concurrently
+	MaxAttempts      int
+	RetryBackoff     time.Duration
+	TranscriptReview bool          // Hold the summary of a session with low-confidence spoken answers until the candidate reviews them
+	ReviewConfidence float64       // Transcription confidence (0-1) below which a spoken answer is flagged for review
+	ReviewWindow     time.Duration // How long a held summary waits for the review before it is generated anyway
+	RescoreRate      int           // Sessions a re-scoring backfill queues per minute, unless the admin sets a rate
+	MaxRescoreRate   int           // Highest rate an admin may set, to keep backfills within the LLM budget
+}
+
+type InterviewConfig struct {
+	WarmupTurns           int           // Number of unscored small-talk candidate turns before the interview proper
+	CaptureExplanations   bool          // Ask the model why it asked each question and gave each score (one extra call per turn)
+	OpeningQuestions      int           // Pre-generated opening questions cached per agent (0 disables the cache)
+	StaticAnalysis        bool          // Run installed linters over code submissions and give their findings to the code review
+	StaticAnalysisTimeout time.Duration // How long a linter may run before the review goes ahead without it
+	CodeTests             bool          // Grade code answers to bank questions against their hidden tests
+	CodeTestSandbox       string        // Command that runs the tests isolated; {dir} is the scratch directory, and stdin must pass through
+	CodeTestTimeout       time.Duration // How long hidden tests may run before the submission fails them
+	PlagiarismThreshold   float64       // Similarity (0-1) to a solution or another answer that flags a code answer; 0 disables
+	HeartbeatInterval     time.Duration // How often the client of a proctored session sends a heartbeat
+	ProctorMaxGap         time.Duration // How long a proctored session may go without a heartbeat before it is ended
+}
+
+type StorageConfig struct {
+	Backend         string        // Blob storage backend for audio recordings (filesystem, s3 or gcs)
+	Path            string        // Root directory for the filesystem backend
+	Bucket          string        // Bucket of the s3 and gcs backends
+	Region          string        // Bucket region; empty uses us-east-1 for s3 and auto for gcs
+	Endpoint        string        // Object store URL, for S3-compatible services; empty uses the provider's
+	AccessKey       string        // Access key ID, or a GCS HMAC key's access ID; empty uses the provider's default credentials
+	SecretKey       string        // Secret of the access key
+	AudioURLTTL     time.Duration // How long a signed audio URL stays valid
+	HotClass        string        // Storage class for recent recordings; empty uses the backend default
+	ColdClass       string        // Storage class for archived recordings; empty uses the backend default
+	ColdAfterDays   int           // Age after which recordings move to the cold class (0 disables archiving)
+	RestoredHotDays int           // How long a recording restored for replay stays hot before re-archiving
+}
+
+// ExportConfig runs the background jobs that build export archives, kept in the blob storage
+type ExportConfig struct {
+	Workers     int           // Archives built concurrently
+	Retention   time.Duration // How long a finished archive is kept before it is deleted
+	URLTTL      time.Duration // How long a signed download URL stays valid
+	MaxSessions int           // Most sessions one export may bundle
+}
+
+// PurgeConfig runs the job that permanently removes soft-deleted data
+type PurgeConfig struct {
+	Retention time.Duration // How long soft-deleted rows are kept before they are removed for good (0 disables the job)
+	Interval  time.Duration // How often the job runs
+}
+
+// TempFilesConfig places and limits the scratch files of audio processing
+type TempFilesConfig struct {
+	Dir    string        // Directory of its own; anything in it no conversion is using is removed
+	MaxMB  int           // Space the files may take at once (0 disables the limit)
+	MaxAge time.Duration // How long a file may be held before it is assumed leaked and removed
+}
+
+// TranscodeConfig caps the ffmpeg processes audio conversion runs. The limits are applied with
+// nice and ulimit; 0 disables a limit.
+type TranscodeConfig struct {
+	MaxConcurrent int           // ffmpeg processes running at once; the rest queue
+	QueueTimeout  time.Duration // How long a transcode may wait for a free slot
+	Timeout       time.Duration // How long an ffmpeg process may run before it is killed
+	Nice          int           // Scheduling niceness, so transcodes yield to request handling
+	MaxMemoryMB   int           // Address space of each process
+	MaxCPUSeconds int           // CPU time of each process
+}
+
+type GeoConfig struct {
+	Provider      string // IP geolocation provider: none or http
+	LookupURL     string // URL template for the http provider; {ip} is replaced with the client IP
+	CountryHeader string // Trusted CDN header carrying the client country (e.g. CF-IPCountry), checked first
+}
+
+type LegalConfig struct {
+	MinAge int // Minimum age to sign up (0 disables the age gate)
+}
+
+type MailConfig struct {
+	Provider     string // log (development) or smtp
+	SMTPAddr     string // host:port of the SMTP relay
+	SMTPUsername string // Empty disables SMTP authentication
+	SMTPPassword string
+	From         string
+}
+
+type AgentDigestConfig struct {
+	MinSessions int // Fewest sessions a usage digest may cover; owners can only raise it
+}
+
+type CertificateConfig struct {
+	MinScore   float64 // Lowest OverallScore that earns a certificate
+	SigningKey string  // Base64 Ed25519 seed; derived from the JWT secret when empty
+}
+
+type ResearchConfig struct {
+	HashSalt string // Key for hashed IDs in research exports; random per export when empty
+}
+
+// RateLimitConfig sets the token bucket rates (per minute) and burst sizes; a rate of 0
+// disables that limit
+type RateLimitConfig struct {
+	IPPerMinute                int // All API requests from one client IP
+	IPBurst                    int
+	UserPerMinute              int // Authenticated API requests from one user
+	UserBurst                  int
+	WebSocketMessagesPerMinute int // All WebSocket messages from one user, including audio chunks
+	WebSocketMessageBurst      int
+	WebSocketTurnsPerMinute    int // WebSocket messages that make the interviewer reply
+	WebSocketTurnBurst         int
+}
+
+type SecretsConfig struct {
+	Provider        string        // none, vault, aws or gcp
+	RefreshInterval time.Duration // How often secrets are re-fetched to pick up rotations (0 disables refresh)
+	VaultAddr       string
+	VaultToken      string
+	VaultMount      string // KV v2 mount path
+	AWSRegion       string
+	GCPProject      string            // Defaults to the credentials' project
+	Refs            map[string]string // Secret name (SecretJWT, ...) to its reference in the provider
 }
 
 // LoadConfig loads configuration from environment variables and config files
@@ -49,27 +233,243 @@ func LoadConfig() *Config {
 
 	// Set defaults
 	viper.SetDefault("server.port", "8080")
+	viper.SetDefault("server.public_url", "http://localhost:5173")
+	viper.SetDefault("server.read_header_timeout", "10s")
+	viper.SetDefault("server.read_timeout", "30s")
+	viper.SetDefault("server.write_timeout", "60s")
+	viper.SetDefault("server.idle_timeout", "120s")
+	viper.SetDefault("server.request_timeout", "30s")
+	viper.SetDefault("server.slow_request_timeout", "5m")
+	viper.SetDefault("server.shutdown_timeout", "30s")
+	viper.SetDefault("server.compression_min_bytes", "1024")
+	viper.SetDefault("server.compression_level", "5")
+	viper.SetDefault("server.trusted_proxies", "")
 	viper.SetDefault("websocket.allowed_origins", "")
+	viper.SetDefault("websocket.compression", "true")
+	viper.SetDefault("websocket.max_session_mb_in", "100")
+	viper.SetDefault("websocket.max_session_mb_out", "200")
 	viper.SetDefault("gemini.api_key", "")
 	viper.SetDefault("elevenlabs.api_key", "")
+	viper.SetDefault("elevenlabs.regions", "")
+	viper.SetDefault("elevenlabs.audio_cache_dir", "./tmp/audio-cache")
+	viper.SetDefault("elevenlabs.stream", "true")
+	viper.SetDefault("transcription.providers", "gemini")
+	viper.SetDefault("openai.api_key", "")
+	viper.SetDefault("deepgram.api_key", "")
+	viper.SetDefault("ai.llm_timeout", "60s")
+	viper.SetDefault("ai.speech_timeout", "30s")
+	viper.SetDefault("ai.summary_timeout", "2m")
 	viper.SetDefault("jwt.secret", "")
 	viper.SetDefault("database.url", "")
 	viper.SetDefault("database.seed", "true")
 	viper.SetDefault("database.log_level", "silent")
 	viper.SetDefault("database.max_idle_conns", "10")
 	viper.SetDefault("database.max_open_conns", "100")
+	viper.SetDefault("database.connect_attempts", "5")
+	viper.SetDefault("database.connect_backoff", "1s")
+	viper.SetDefault("database.fail_fast", "true")
+	viper.SetDefault("quality.window", "50")
+	viper.SetDefault("quality.alert_drop", "10")
+	viper.SetDefault("quality.min_json_valid_rate", "0.9")
+	viper.SetDefault("summary.workers", "2")
+	viper.SetDefault("summary.max_attempts", "5")
+	viper.SetDefault("summary.retry_backoff", "30s")
+	viper.SetDefault("summary.transcript_review", "false")
+	viper.SetDefault("summary.review_confidence", "0.8")
+	viper.SetDefault("summary.review_window", "24h")
+	viper.SetDefault("summary.rescore_rate", "10")
+	viper.SetDefault("summary.max_rescore_rate", "60")
+	viper.SetDefault("interview.warmup_turns", "2")
+	viper.SetDefault("interview.capture_explanations", "false")
+	viper.SetDefault("interview.opening_questions", "8")
+	viper.SetDefault("interview.static_analysis", "true")
+	viper.SetDefault("interview.static_analysis_timeout", "20s")
+	viper.SetDefault("interview.code_tests", "false")
+	viper.SetDefault("interview.code_test_sandbox", "")
+	viper.SetDefault("interview.code_test_timeout", "30s")
+	viper.SetDefault("interview.plagiarism_threshold", 0.8)
+	viper.SetDefault("interview.heartbeat_interval", "10s")
+	viper.SetDefault("interview.proctor_max_gap", "60s")
+	viper.SetDefault("storage.backend", "filesystem")
+	viper.SetDefault("storage.path", "./data/recordings")
+	viper.SetDefault("storage.bucket", "")
+	viper.SetDefault("storage.region", "")
+	viper.SetDefault("storage.endpoint", "")
+	viper.SetDefault("storage.access_key", "")
+	viper.SetDefault("storage.secret_key", "")
+	viper.SetDefault("storage.audio_url_ttl", "15m")
+	viper.SetDefault("storage.hot_class", "")
+	viper.SetDefault("storage.cold_class", "")
+	viper.SetDefault("storage.cold_after_days", "30")
+	viper.SetDefault("storage.restored_hot_days", "2")
+	viper.SetDefault("export.workers", "1")
+	viper.SetDefault("export.retention", "24h")
+	viper.SetDefault("export.url_ttl", "15m")
+	viper.SetDefault("export.max_sessions", "200")
+	viper.SetDefault("purge.retention", "720h")
+	viper.SetDefault("purge.interval", "24h")
+	viper.SetDefault("temp_files.dir", "./tmp/audio-work")
+	viper.SetDefault("temp_files.max_mb", "512")
+	viper.SetDefault("temp_files.max_age", "1h")
+	viper.SetDefault("transcode.max_concurrent", "2")
+	viper.SetDefault("transcode.queue_timeout", "30s")
+	viper.SetDefault("transcode.timeout", "2m")
+	viper.SetDefault("transcode.nice", "10")
+	viper.SetDefault("transcode.max_memory_mb", "1024")
+	viper.SetDefault("transcode.max_cpu_seconds", "120")
+	viper.SetDefault("geo.provider", "none")
+	viper.SetDefault("geo.lookup_url", "")
+	viper.SetDefault("geo.country_header", "")
+	viper.SetDefault("legal.min_age", "16")
+	viper.SetDefault("mail.provider", "log")
+	viper.SetDefault("mail.smtp_addr", "")
+	viper.SetDefault("mail.smtp_username", "")
+	viper.SetDefault("mail.smtp_password", "")
+	viper.SetDefault("mail.from", "")
+	viper.SetDefault("agent_digest.min_sessions", "5")
+	viper.SetDefault("certificate.min_score", "70")
+	viper.SetDefault("rate_limit.ip_per_minute", "600")
+	viper.SetDefault("rate_limit.ip_burst", "100")
+	viper.SetDefault("rate_limit.user_per_minute", "300")
+	viper.SetDefault("rate_limit.user_burst", "60")
+	viper.SetDefault("rate_limit.ws_messages_per_minute", "600")
+	viper.SetDefault("rate_limit.ws_message_burst", "120")
+	viper.SetDefault("rate_limit.ws_turns_per_minute", "20")
+	viper.SetDefault("rate_limit.ws_turn_burst", "5")
+	viper.SetDefault("secrets.provider", "none")
+	viper.SetDefault("secrets.refresh_interval", "5m")
+	viper.SetDefault("secrets.vault_addr", "")
+	viper.SetDefault("secrets.vault_token", "")
+	viper.SetDefault("secrets.vault_mount", "secret")
+	viper.SetDefault("secrets.aws_region", "")
+	viper.SetDefault("secrets.gcp_project", "")
+	viper.SetDefault("secrets.ref.gemini_api_key", "")
+	viper.SetDefault("secrets.ref.elevenlabs_api_key", "")
+	viper.SetDefault("secrets.ref.openai_api_key", "")
+	viper.SetDefault("secrets.ref.deepgram_api_key", "")
+	viper.SetDefault("secrets.ref.jwt_secret", "")
+	viper.SetDefault("secrets.ref.database_url", "")
 
 	// Map environment variables to config keys
 	viper.BindEnv("server.port", "SERVER_PORT")
+	viper.BindEnv("server.public_url", "PUBLIC_APP_URL")
+	viper.BindEnv("server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT")
+	viper.BindEnv("server.read_timeout", "SERVER_READ_TIMEOUT")
+	viper.BindEnv("server.write_timeout", "SERVER_WRITE_TIMEOUT")
+	viper.BindEnv("server.idle_timeout", "SERVER_IDLE_TIMEOUT")
+	viper.BindEnv("server.request_timeout", "SERVER_REQUEST_TIMEOUT")
+	viper.BindEnv("server.slow_request_timeout", "SERVER_SLOW_REQUEST_TIMEOUT")
+	viper.BindEnv("server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT")
+	viper.BindEnv("server.compression_min_bytes", "SERVER_COMPRESSION_MIN_BYTES")
+	viper.BindEnv("server.compression_level", "SERVER_COMPRESSION_LEVEL")
+	viper.BindEnv("server.trusted_proxies", "SERVER_TRUSTED_PROXIES")
 	viper.BindEnv("websocket.allowed_origins", "WEBSOCKET_ALLOWED_ORIGINS")
+	viper.BindEnv("websocket.compression", "WEBSOCKET_COMPRESSION")
+	viper.BindEnv("websocket.max_session_mb_in", "WEBSOCKET_MAX_SESSION_MB_IN")
+	viper.BindEnv("websocket.max_session_mb_out", "WEBSOCKET_MAX_SESSION_MB_OUT")
 	viper.BindEnv("gemini.api_key", "GEMINI_API_KEY")
 	viper.BindEnv("elevenlabs.api_key", "ELEVENLABS_API_KEY")
+	viper.BindEnv("elevenlabs.regions", "ELEVENLABS_REGIONS")
+	viper.BindEnv("elevenlabs.audio_cache_dir", "AUDIO_CACHE_DIR")
+	viper.BindEnv("elevenlabs.stream", "ELEVENLABS_STREAM")
+	viper.BindEnv("transcription.providers", "TRANSCRIPTION_PROVIDERS")
+	viper.BindEnv("openai.api_key", "OPENAI_API_KEY")
+	viper.BindEnv("deepgram.api_key", "DEEPGRAM_API_KEY")
+	viper.BindEnv("ai.llm_timeout", "LLM_CALL_TIMEOUT")
+	viper.BindEnv("ai.speech_timeout", "SPEECH_CALL_TIMEOUT")
+	viper.BindEnv("ai.summary_timeout", "SUMMARY_CALL_TIMEOUT")
 	viper.BindEnv("jwt.secret", "JWT_SECRET")
 	viper.BindEnv("database.url", "DATABASE_URL")
 	viper.BindEnv("database.seed", "DATABASE_SEED")
 	viper.BindEnv("database.log_level", "DATABASE_LOG_LEVEL")
 	viper.BindEnv("database.max_idle_conns", "DATABASE_MAX_IDLE_CONNS")
 	viper.BindEnv("database.max_open_conns", "DATABASE_MAX_OPEN_CONNS")
+	viper.BindEnv("database.connect_attempts", "DATABASE_CONNECT_ATTEMPTS")
+	viper.BindEnv("database.connect_backoff", "DATABASE_CONNECT_BACKOFF")
+	viper.BindEnv("database.fail_fast", "DATABASE_FAIL_FAST")
+	viper.BindEnv("quality.window", "QUALITY_WINDOW")
+	viper.BindEnv("quality.alert_drop", "QUALITY_ALERT_DROP")
+	viper.BindEnv("quality.min_json_valid_rate", "QUALITY_MIN_JSON_VALID_RATE")
+	viper.BindEnv("summary.workers", "SUMMARY_WORKERS")
+	viper.BindEnv("summary.max_attempts", "SUMMARY_MAX_ATTEMPTS")
+	viper.BindEnv("summary.retry_backoff", "SUMMARY_RETRY_BACKOFF")
+	viper.BindEnv("summary.transcript_review", "SUMMARY_TRANSCRIPT_REVIEW")
+	viper.BindEnv("summary.review_confidence", "SUMMARY_REVIEW_CONFIDENCE")
+	viper.BindEnv("summary.review_window", "SUMMARY_REVIEW_WINDOW")
+	viper.BindEnv("summary.rescore_rate", "SUMMARY_RESCORE_RATE")
+	viper.BindEnv("summary.max_rescore_rate", "SUMMARY_MAX_RESCORE_RATE")
+	viper.BindEnv("interview.warmup_turns", "INTERVIEW_WARMUP_TURNS")
+	viper.BindEnv("interview.capture_explanations", "INTERVIEW_CAPTURE_EXPLANATIONS")
+	viper.BindEnv("interview.opening_questions", "INTERVIEW_OPENING_QUESTIONS")
+	viper.BindEnv("interview.static_analysis", "INTERVIEW_STATIC_ANALYSIS")
+	viper.BindEnv("interview.static_analysis_timeout", "INTERVIEW_STATIC_ANALYSIS_TIMEOUT")
+	viper.BindEnv("interview.code_tests", "INTERVIEW_CODE_TESTS")
+	viper.BindEnv("interview.code_test_sandbox", "INTERVIEW_CODE_TEST_SANDBOX")
+	viper.BindEnv("interview.code_test_timeout", "INTERVIEW_CODE_TEST_TIMEOUT")
+	viper.BindEnv("interview.plagiarism_threshold", "INTERVIEW_PLAGIARISM_THRESHOLD")
+	viper.BindEnv("interview.heartbeat_interval", "INTERVIEW_HEARTBEAT_INTERVAL")
+	viper.BindEnv("interview.proctor_max_gap", "INTERVIEW_PROCTOR_MAX_GAP")
+	viper.BindEnv("storage.backend", "STORAGE_BACKEND")
+	viper.BindEnv("storage.path", "STORAGE_PATH")
+	viper.BindEnv("storage.bucket", "STORAGE_BUCKET")
+	viper.BindEnv("storage.region", "STORAGE_REGION")
+	viper.BindEnv("storage.endpoint", "STORAGE_ENDPOINT")
+	viper.BindEnv("storage.access_key", "STORAGE_ACCESS_KEY")
+	viper.BindEnv("storage.secret_key", "STORAGE_SECRET_KEY")
+	viper.BindEnv("storage.audio_url_ttl", "STORAGE_AUDIO_URL_TTL")
+	viper.BindEnv("storage.hot_class", "STORAGE_HOT_CLASS")
+	viper.BindEnv("storage.cold_class", "STORAGE_COLD_CLASS")
+	viper.BindEnv("storage.cold_after_days", "RECORDING_COLD_AFTER_DAYS")
+	viper.BindEnv("storage.restored_hot_days", "RECORDING_RESTORED_HOT_DAYS")
+	viper.BindEnv("export.workers", "EXPORT_WORKERS")
+	viper.BindEnv("export.retention", "EXPORT_RETENTION")
+	viper.BindEnv("export.url_ttl", "EXPORT_URL_TTL")
+	viper.BindEnv("export.max_sessions", "EXPORT_MAX_SESSIONS")
+	viper.BindEnv("purge.retention", "PURGE_RETENTION")
+	viper.BindEnv("purge.interval", "PURGE_INTERVAL")
+	viper.BindEnv("temp_files.dir", "AUDIO_TEMP_DIR")
+	viper.BindEnv("temp_files.max_mb", "AUDIO_TEMP_MAX_MB")
+	viper.BindEnv("temp_files.max_age", "AUDIO_TEMP_MAX_AGE")
+	viper.BindEnv("transcode.max_concurrent", "FFMPEG_MAX_CONCURRENT")
+	viper.BindEnv("transcode.queue_timeout", "FFMPEG_QUEUE_TIMEOUT")
+	viper.BindEnv("transcode.timeout", "FFMPEG_TIMEOUT")
+	viper.BindEnv("transcode.nice", "FFMPEG_NICE")
+	viper.BindEnv("transcode.max_memory_mb", "FFMPEG_MAX_MEMORY_MB")
+	viper.BindEnv("transcode.max_cpu_seconds", "FFMPEG_MAX_CPU_SECONDS")
+	viper.BindEnv("geo.provider", "GEO_PROVIDER")
+	viper.BindEnv("geo.lookup_url", "GEO_LOOKUP_URL")
+	viper.BindEnv("geo.country_header", "GEO_COUNTRY_HEADER")
+	viper.BindEnv("legal.min_age", "LEGAL_MIN_AGE")
+	viper.BindEnv("mail.provider", "MAIL_PROVIDER")
+	viper.BindEnv("mail.smtp_addr", "MAIL_SMTP_ADDR")
+	viper.BindEnv("mail.smtp_username", "MAIL_SMTP_USERNAME")
+	viper.BindEnv("mail.smtp_password", "MAIL_SMTP_PASSWORD")
+	viper.BindEnv("mail.from", "MAIL_FROM")
+	viper.BindEnv("agent_digest.min_sessions", "AGENT_DIGEST_MIN_SESSIONS")
+	viper.BindEnv("certificate.min_score", "CERTIFICATE_MIN_SCORE")
+	viper.BindEnv("certificate.signing_key", "CERTIFICATE_SIGNING_KEY")
+	viper.BindEnv("research.hash_salt", "RESEARCH_HASH_SALT")
+	viper.BindEnv("rate_limit.ip_per_minute", "RATE_LIMIT_IP_PER_MINUTE")
+	viper.BindEnv("rate_limit.ip_burst", "RATE_LIMIT_IP_BURST")
+	viper.BindEnv("rate_limit.user_per_minute", "RATE_LIMIT_USER_PER_MINUTE")
+	viper.BindEnv("rate_limit.user_burst", "RATE_LIMIT_USER_BURST")
+	viper.BindEnv("rate_limit.ws_messages_per_minute", "RATE_LIMIT_WS_MESSAGES_PER_MINUTE")
+	viper.BindEnv("rate_limit.ws_message_burst", "RATE_LIMIT_WS_MESSAGE_BURST")
+	viper.BindEnv("rate_limit.ws_turns_per_minute", "RATE_LIMIT_WS_TURNS_PER_MINUTE")
+	viper.BindEnv("rate_limit.ws_turn_burst", "RATE_LIMIT_WS_TURN_BURST")
+	viper.BindEnv("secrets.provider", "SECRETS_PROVIDER")
+	viper.BindEnv("secrets.refresh_interval", "SECRETS_REFRESH_INTERVAL")
+	viper.BindEnv("secrets.vault_addr", "VAULT_ADDR")
+	viper.BindEnv("secrets.vault_token", "VAULT_TOKEN")
+	viper.BindEnv("secrets.vault_mount", "VAULT_KV_MOUNT")
+	viper.BindEnv("secrets.aws_region", "AWS_REGION")
+	viper.BindEnv("secrets.gcp_project", "GCP_PROJECT")
+	viper.BindEnv("secrets.ref.gemini_api_key", "SECRET_REF_GEMINI_API_KEY")
+	viper.BindEnv("secrets.ref.elevenlabs_api_key", "SECRET_REF_ELEVENLABS_API_KEY")
+	viper.BindEnv("secrets.ref.openai_api_key", "SECRET_REF_OPENAI_API_KEY")
+	viper.BindEnv("secrets.ref.deepgram_api_key", "SECRET_REF_DEEPGRAM_API_KEY")
+	viper.BindEnv("secrets.ref.jwt_secret", "SECRET_REF_JWT_SECRET")
+	viper.BindEnv("secrets.ref.database_url", "SECRET_REF_DATABASE_URL")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -81,24 +481,213 @@ func LoadConfig() *Config {
 
 	return &Config{
 		Server: ServerConfig{
-			Port: viper.GetString("server.port"),
+			Port:      viper.GetString("server.port"),
+			PublicURL: viper.GetString("server.public_url"),
+
+			ReadHeaderTimeout:  viper.GetDuration("server.read_header_timeout"),
+			ReadTimeout:        viper.GetDuration("server.read_timeout"),
+			WriteTimeout:       viper.GetDuration("server.write_timeout"),
+			IdleTimeout:        viper.GetDuration("server.idle_timeout"),
+			RequestTimeout:     viper.GetDuration("server.request_timeout"),
+			SlowRequestTimeout: viper.GetDuration("server.slow_request_timeout"),
+			ShutdownTimeout:    viper.GetDuration("server.shutdown_timeout"),
+
+			CompressionMinBytes: viper.GetInt("server.compression_min_bytes"),
+			CompressionLevel:    viper.GetInt("server.compression_level"),
+
+			TrustedProxies: viper.GetString("server.trusted_proxies"),
 		},
 		Database: DatabaseConfig{
-			URL:          viper.GetString("database.url"),
-			Seed:         viper.GetBool("database.seed"),
-			LogLevel:     viper.GetString("database.log_level"),
-			MaxIdleConns: viper.GetInt("database.max_idle_conns"),
-			MaxOpenConns: viper.GetInt("database.max_open_conns"),
+			URL:             viper.GetString("database.url"),
+			Seed:            viper.GetBool("database.seed"),
+			LogLevel:        viper.GetString("database.log_level"),
+			MaxIdleConns:    viper.GetInt("database.max_idle_conns"),
+			MaxOpenConns:    viper.GetInt("database.max_open_conns"),
+			ConnectAttempts: viper.GetInt("database.connect_attempts"),
+			ConnectBackoff:  viper.GetDuration("database.connect_backoff"),
+			FailFast:        viper.GetBool("database.fail_fast"),
 		},
 		AI: AIConfig{
-			GeminiAPIKey:  viper.GetString("gemini.api_key"),
-			ElevenLabsKey: viper.GetString("elevenlabs.api_key"),
+			GeminiAPIKey:      viper.GetString("gemini.api_key"),
+			ElevenLabsKey:     viper.GetString("elevenlabs.api_key"),
+			ElevenLabsRegions: viper.GetString("elevenlabs.regions"),
+			AudioCacheDir:     viper.GetString("elevenlabs.audio_cache_dir"),
+			StreamSpeech:      viper.GetBool("elevenlabs.stream"),
+			Transcription:     viper.GetString("transcription.providers"),
+			OpenAIKey:         viper.GetString("openai.api_key"),
+			DeepgramKey:       viper.GetString("deepgram.api_key"),
+			Timeouts: CallTimeouts{
+				LLM:     viper.GetDuration("ai.llm_timeout"),
+				Speech:  viper.GetDuration("ai.speech_timeout"),
+				Summary: viper.GetDuration("ai.summary_timeout"),
+			},
 		},
 		JWT: JWTConfig{
 			Secret: viper.GetString("jwt.secret"),
 		},
 		WebSocket: WebSocketConfig{
-			AllowedOrigins: viper.GetString("websocket.allowed_origins"),
+			AllowedOrigins:  viper.GetString("websocket.allowed_origins"),
+			Compression:     viper.GetBool("websocket.compression"),
+			MaxSessionMBIn:  viper.GetInt("websocket.max_session_mb_in"),
+			MaxSessionMBOut: viper.GetInt("websocket.max_session_mb_out"),
+		},
+		Quality: QualityConfig{
+			Window:           viper.GetInt("quality.window"),
+			AlertDrop:        viper.GetFloat64("quality.alert_drop"),
+			MinJSONValidRate: viper.GetFloat64("quality.min_json_valid_rate"),
+		},
+		Summary: SummaryConfig{
+			Workers:          viper.GetInt("summary.workers"),
+			MaxAttempts:      viper.GetInt("summary.max_attempts"),
+			RetryBackoff:     viper.GetDuration("summary.retry_backoff"),
+			TranscriptReview: viper.GetBool("summary.transcript_review"),
+			ReviewConfidence: viper.GetFloat64("summary.review_confidence"),
+			ReviewWindow:     viper.GetDuration("summary.review_window"),
+			RescoreRate:      viper.GetInt("summary.rescore_rate"),
+			MaxRescoreRate:   viper.GetInt("summary.max_rescore_rate"),
+		},
+		Interview: InterviewConfig{
+			WarmupTurns:           viper.GetInt("interview.warmup_turns"),
+			CaptureExplanations:   viper.GetBool("interview.capture_explanations"),
+			OpeningQuestions:      viper.GetInt("interview.opening_questions"),
+			StaticAnalysis:        viper.GetBool("interview.static_analysis"),
+			StaticAnalysisTimeout: viper.GetDuration("interview.static_analysis_timeout"),
+			CodeTests:             viper.GetBool("interview.code_tests"),
+			CodeTestSandbox:       viper.GetString("interview.code_test_sandbox"),
+			CodeTestTimeout:       viper.GetDuration("interview.code_test_timeout"),
+			PlagiarismThreshold:   viper.GetFloat64("interview.plagiarism_threshold"),
+			HeartbeatInterval:     viper.GetDuration("interview.heartbeat_interval"),
+			ProctorMaxGap:         viper.GetDuration("interview.proctor_max_gap"),
+		},
+		Storage: StorageConfig{
+			Backend:         viper.GetString("storage.backend"),
+			Path:            viper.GetString("storage.path"),
+			Bucket:          viper.GetString("storage.bucket"),
+			Region:          viper.GetString("storage.region"),
+			Endpoint:        viper.GetString("storage.endpoint"),
+			AccessKey:       viper.GetString("storage.access_key"),
+			SecretKey:       viper.GetString("storage.secret_key"),
+			AudioURLTTL:     viper.GetDuration("storage.audio_url_ttl"),
+			HotClass:        viper.GetString("storage.hot_class"),
+			ColdClass:       viper.GetString("storage.cold_class"),
+			ColdAfterDays:   viper.GetInt("storage.cold_after_days"),
+			RestoredHotDays: viper.GetInt("storage.restored_hot_days"),
+		},
+		Export: ExportConfig{
+			Workers:     viper.GetInt("export.workers"),
+			Retention:   viper.GetDuration("export.retention"),
+			URLTTL:      viper.GetDuration("export.url_ttl"),
+			MaxSessions: viper.GetInt("export.max_sessions"),
+		},
+		Purge: PurgeConfig{
+			Retention: viper.GetDuration("purge.retention"),
+			Interval:  viper.GetDuration("purge.interval"),
+		},
+		TempFiles: TempFilesConfig{
+			Dir:    viper.GetString("temp_files.dir"),
+			MaxMB:  viper.GetInt("temp_files.max_mb"),
+			MaxAge: viper.GetDuration("temp_files.max_age"),
+		},
+		Transcode: TranscodeConfig{
+			MaxConcurrent: viper.GetInt("transcode.max_concurrent"),
+			QueueTimeout:  viper.GetDuration("transcode.queue_timeout"),
+			Timeout:       viper.GetDuration("transcode.timeout"),
+			Nice:          viper.GetInt("transcode.nice"),
+			MaxMemoryMB:   viper.GetInt("transcode.max_memory_mb"),
+			MaxCPUSeconds: viper.GetInt("transcode.max_cpu_seconds"),
+		},
+		Geo: GeoConfig{
+			Provider:      viper.GetString("geo.provider"),
+			LookupURL:     viper.GetString("geo.lookup_url"),
+			CountryHeader: viper.GetString("geo.country_header"),
+		},
+		Legal: LegalConfig{
+			MinAge: viper.GetInt("legal.min_age"),
 		},
+		Mail: MailConfig{
+			Provider:     viper.GetString("mail.provider"),
+			SMTPAddr:     viper.GetString("mail.smtp_addr"),
+			SMTPUsername: viper.GetString("mail.smtp_username"),
+			SMTPPassword: viper.GetString("mail.smtp_password"),
+			From:         viper.GetString("mail.from"),
+		},
+		AgentDigest: AgentDigestConfig{
+			MinSessions: viper.GetInt("agent_digest.min_sessions"),
+		},
+		Certificate: CertificateConfig{
+			MinScore:   viper.GetFloat64("certificate.min_score"),
+			SigningKey: viper.GetString("certificate.signing_key"),
+		},
+		Research: ResearchConfig{
+			HashSalt: viper.GetString("research.hash_salt"),
+		},
+		RateLimit: RateLimitConfig{
+			IPPerMinute:                viper.GetInt("rate_limit.ip_per_minute"),
+			IPBurst:                    viper.GetInt("rate_limit.ip_burst"),
+			UserPerMinute:              viper.GetInt("rate_limit.user_per_minute"),
+			UserBurst:                  viper.GetInt("rate_limit.user_burst"),
+			WebSocketMessagesPerMinute: viper.GetInt("rate_limit.ws_messages_per_minute"),
+			WebSocketMessageBurst:      viper.GetInt("rate_limit.ws_message_burst"),
+			WebSocketTurnsPerMinute:    viper.GetInt("rate_limit.ws_turns_per_minute"),
+			WebSocketTurnBurst:         viper.GetInt("rate_limit.ws_turn_burst"),
+		},
+		Secrets: SecretsConfig{
+			Provider:        viper.GetString("secrets.provider"),
+			RefreshInterval: viper.GetDuration("secrets.refresh_interval"),
+			VaultAddr:       viper.GetString("secrets.vault_addr"),
+			VaultToken:      viper.GetString("secrets.vault_token"),
+			VaultMount:      viper.GetString("secrets.vault_mount"),
+			AWSRegion:       viper.GetString("secrets.aws_region"),
+			GCPProject:      viper.GetString("secrets.gcp_project"),
+			Refs: map[string]string{
+				SecretGeminiAPIKey:  viper.GetString("secrets.ref.gemini_api_key"),
+				SecretElevenLabsKey: viper.GetString("secrets.ref.elevenlabs_api_key"),
+				SecretOpenAIAPIKey:  viper.GetString("secrets.ref.openai_api_key"),
+				SecretDeepgramKey:   viper.GetString("secrets.ref.deepgram_api_key"),
+				SecretJWT:           viper.GetString("secrets.ref.jwt_secret"),
+				SecretDatabaseURL:   viper.GetString("secrets.ref.database_url"),
+			},
+		},
+	}
+}
+
+// secretConfigField matches the names of settings RedactConfig hides
+var secretConfigField = regexp.MustCompile(`Key$|Secret|Password|Token|Salt`)
+
+// RedactConfig returns the configuration as nested maps for printing, with secrets shown as
+// "[redacted]" when set and the password of the database URL hidden. A key=value DSN is hidden
+// whole.
+func RedactConfig(cfg *Config) map[string]interface{} {
+	redacted := redactConfigStruct(reflect.ValueOf(*cfg))
+	if cfg.Database.URL != "" {
+		dsn := "[redacted]"
+		if u, err := url.Parse(cfg.Database.URL); err == nil && u.Scheme != "" {
+			dsn = u.Redacted()
+		}
+		redacted["Database"].(map[string]interface{})["URL"] = dsn
+	}
+	return redacted
+}
+
+func redactConfigStruct(v reflect.Value) map[string]interface{} {
+	fields := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		switch {
+		case !field.IsExported():
+		case value.Kind() == reflect.Struct:
+			fields[field.Name] = redactConfigStruct(value)
+		case value.Kind() == reflect.String && secretConfigField.MatchString(field.Name):
+			if value.String() != "" {
+				fields[field.Name] = "[redacted]"
+			} else {
+				fields[field.Name] = ""
+			}
+		case value.Type() == reflect.TypeOf(time.Duration(0)):
+			fields[field.Name] = time.Duration(value.Int()).String()
+		default:
+			fields[field.Name] = value.Interface()
+		}
 	}
+	return fields
 }
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+type DataImportEndpoints struct {
+	repo    *repository.GORMRepository
+	imports *DataImportService
+}
+
+func NewDataImportEndpoints(repo *repository.GORMRepository, imports *DataImportService) *DataImportEndpoints {
+	return &DataImportEndpoints{
+		repo:    repo,
+		imports: imports,
+	}
+}
+
+func (e *DataImportEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/imports", func(r chi.Router) {
+		r.Post("/", e.CreateImportHandler)
+		r.Get("/", e.GetImportsHandler)
+		r.Delete("/{id}", e.DeleteImportHandler)
+	})
+}
+
+// CreateImportHandler accepts a multipart upload with a CSV or JSON "file" of past mock-interview
+// results and the "source" platform they came from
+func (e *DataImportEndpoints) CreateImportHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+1<<20) // Allow for multipart overhead
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		apperrors.Write(w, r, apperrors.TooLarge("Import must be a multipart upload of at most 5 MB"))
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("file is required"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Failed to read import"))
+		return
+	}
+	if len(data) > maxImportSize {
+		apperrors.Write(w, r, apperrors.TooLarge("Import must be at most 5 MB"))
+		return
+	}
+
+	dataImport, err := e.imports.Import(r.Context(), user, r.FormValue("source"), header.Filename, data)
+	var importErrs ImportErrors
+	if errors.As(err, &importErrs) {
+		apperrors.Write(w, r, apperrors.Unprocessable("Nothing was imported; fix these records and upload the file again").
+			WithCode(apperrors.CodeValidation).
+			WithDetails(map[string]interface{}{"records": importErrs}))
+		return
+	}
+	if errors.Is(err, ErrInvalidImport) {
+		apperrors.Write(w, r, apperrors.Unprocessable(err.Error()))
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to import data", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to import data"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"import": dataImport,
+	})
+}
+
+func (e *DataImportEndpoints) GetImportsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	imports, err := e.repo.GetDataImports(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get imports"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"imports": imports,
+		"count":   len(imports),
+	})
+}
+
+// DeleteImportHandler undoes an import, deleting the sessions it created
+func (e *DataImportEndpoints) DeleteImportHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	dataImport, err := e.repo.GetDataImport(r.Context(), chi.URLParam(r, "id"), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get import"))
+		return
+	}
+	if dataImport == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Import not found"))
+		return
+	}
+
+	deleted, err := e.repo.DeleteDataImport(r.Context(), dataImport.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete import"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":          "Import deleted",
+		"sessions_deleted": deleted,
+	})
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	maxImportSize    = 5 << 20
+	maxImportRecords = 500
+	maxImportSource  = 50
+	maxImportText    = 10000
+	// importScorePrefix starts the CSV columns holding metric scores, e.g. score_communication
+	importScorePrefix = "score_"
+)
+
+// ErrInvalidImport is returned for an import file or source that can't be imported
+var ErrInvalidImport = errors.New("invalid import")
+
+// earliestImportDate bounds how far back imported sessions may go
+var earliestImportDate = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ImportRecord is one past mock interview in an import file. Scores are out of MaxScore, or 100
+// when it isn't given; the overall score defaults to the average of the metric scores.
+type ImportRecord struct {
+	ExternalID      string             `json:"external_id"` // The session's ID on its platform; records already imported are skipped
+	Date            string             `json:"date"`        // RFC 3339, or YYYY-MM-DD
+	Title           string             `json:"title"`       // The interview or interviewer's name
+	Industry        string             `json:"industry"`
+	Level           string             `json:"level"`
+	DurationMinutes int                `json:"duration_minutes"`
+	OverallScore    *float64           `json:"overall_score"`
+	MaxScore        float64            `json:"max_score"`
+	Scores          map[string]float64 `json:"scores"` // Metric name to score
+	Summary         string             `json:"summary"`
+	Strengths       string             `json:"strengths"`
+	Weaknesses      string             `json:"weaknesses"`
+	Recommendations string             `json:"recommendations"`
+}
+
+// ImportError is a record that kept a file from being imported
+type ImportError struct {
+	Record int    `json:"record"` // Position in the file from 1; a CSV's header row isn't counted
+	Error  string `json:"error"`
+}
+
+// ImportErrors lists every record that kept a file from being imported
+type ImportErrors []ImportError
+
+func (e ImportErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, importErr := range e {
+		messages[i] = fmt.Sprintf("record %d: %s", importErr.Record, importErr.Error)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ParseImportFile reads the records of a CSV or JSON export, returning its format. The format
+// is told from the file name, or from the content when the name doesn't say.
+func ParseImportFile(filename string, data []byte) (string, []ImportRecord, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	if format != models.ImportFormatCSV && format != models.ImportFormatJSON {
+		format = models.ImportFormatCSV
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+			format = models.ImportFormatJSON
+		}
+	}
+
+	var records []ImportRecord
+	var err error
+	if format == models.ImportFormatJSON {
+		records, err = parseImportJSON(data)
+	} else {
+		records, err = parseImportCSV(data)
+	}
+	if err != nil {
+		return format, nil, err
+	}
+	if len(records) == 0 {
+		return format, nil, fmt.Errorf("the file has no records")
+	}
+	if len(records) > maxImportRecords {
+		return format, nil, fmt.Errorf("at most %d records can be imported at once", maxImportRecords)
+	}
+	return format, records, nil
+}
+
+// parseImportJSON reads an array of records, or an object holding them under "sessions"
+func parseImportJSON(data []byte) ([]ImportRecord, error) {
+	var records []ImportRecord
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Sessions []ImportRecord `json:"sessions"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapped); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return wrapped.Sessions, nil
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return records, nil
+}
+
+// parseImportCSV reads a CSV whose header names each column after an ImportRecord field, with
+// metric scores in score_<metric> columns. Unknown columns are ignored.
+func parseImportCSV(data []byte) ([]ImportRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var records []ImportRecord
+	var errs ImportErrors
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		record := ImportRecord{Scores: map[string]float64{}}
+		for i, column := range header {
+			value := strings.TrimSpace(row[i])
+			if value == "" {
+				continue
+			}
+			if err := setImportColumn(&record, column, value); err != nil {
+				errs = append(errs, ImportError{Record: len(records) + 1, Error: err.Error()})
+			}
+		}
+		records = append(records, record)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return records, nil
+}
+
+// setImportColumn sets the record field a CSV column holds
+func setImportColumn(record *ImportRecord, column, value string) error {
+	number := func() (float64, error) {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a number", column)
+		}
+		return parsed, nil
+	}
+
+	switch column {
+	case "external_id":
+		record.ExternalID = value
+	case "date":
+		record.Date = value
+	case "title":
+		record.Title = value
+	case "industry":
+		record.Industry = value
+	case "level":
+		record.Level = value
+	case "summary":
+		record.Summary = value
+	case "strengths":
+		record.Strengths = value
+	case "weaknesses":
+		record.Weaknesses = value
+	case "recommendations":
+		record.Recommendations = value
+	case "duration_minutes":
+		minutes, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("duration_minutes must be a whole number")
+		}
+		record.DurationMinutes = minutes
+	case "overall_score":
+		score, err := number()
+		if err != nil {
+			return err
+		}
+		record.OverallScore = &score
+	case "max_score":
+		score, err := number()
+		if err != nil {
+			return err
+		}
+		record.MaxScore = score
+	default:
+		if metric, ok := strings.CutPrefix(column, importScorePrefix); ok {
+			score, err := number()
+			if err != nil {
+				return err
+			}
+			record.Scores[metric] = score
+		}
+	}
+	return nil
+}
+
+// ImportedSession validates a record from source and returns the completed session it imports
+// as, with its summary and performance scores. Scores are stored out of 100.
+func ImportedSession(source string, record ImportRecord, now time.Time) (*models.InterviewSession, error) {
+	startedAt, err := parseImportDate(record.Date)
+	if err != nil {
+		return nil, err
+	}
+	if startedAt.Before(earliestImportDate) || startedAt.After(now.Add(24*time.Hour)) {
+		return nil, fmt.Errorf("date must be between 2000 and today")
+	}
+	if record.DurationMinutes < 0 || record.DurationMinutes > MaxInterviewMinutes {
+		return nil, fmt.Errorf("duration_minutes must be between 0 and %d", MaxInterviewMinutes)
+	}
+	record.ExternalID = strings.TrimSpace(record.ExternalID)
+	for _, field := range []struct {
+		name  string
+		value string
+		limit int
+	}{
+		{"external_id", record.ExternalID, 100},
+		{"title", record.Title, 100},
+		{"industry", record.Industry, 100},
+		{"level", record.Level, 50},
+	} {
+		if len([]rune(field.value)) > field.limit {
+			return nil, fmt.Errorf("%s must be at most %d characters", field.name, field.limit)
+		}
+	}
+	for _, text := range []string{record.Summary, record.Strengths, record.Weaknesses, record.Recommendations} {
+		if len([]rune(text)) > maxImportText {
+			return nil, fmt.Errorf("summary, strengths, weaknesses and recommendations must each be at most %d characters", maxImportText)
+		}
+	}
+
+	maxScore := record.MaxScore
+	if maxScore == 0 {
+		maxScore = 100
+	}
+	if maxScore < 0 || maxScore > 1000 {
+		return nil, fmt.Errorf("max_score must be between 1 and 1000")
+	}
+	normalize := func(name string, score float64) (float64, error) {
+		if score < 0 || score > maxScore {
+			return 0, fmt.Errorf("%s must be between 0 and %g", name, maxScore)
+		}
+		return math.Round(score*100/maxScore*100) / 100, nil
+	}
+
+	var scores []models.PerformanceScore
+	total := 0.0
+	for name, score := range record.Scores {
+		metric := importMetricName(name)
+		if metric == "" || len(metric) > 50 {
+			return nil, fmt.Errorf("metric name %q must have 1-50 letters or digits", name)
+		}
+		normalized, err := normalize(metric+" score", score)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, models.PerformanceScore{Metric: metric, Score: normalized, MaxScore: 100, Weight: 1})
+		total += normalized
+	}
+
+	var overall float64
+	switch {
+	case record.OverallScore != nil:
+		if overall, err = normalize("overall_score", *record.OverallScore); err != nil {
+			return nil, err
+		}
+	case len(scores) > 0:
+		overall = math.Round(total/float64(len(scores))*100) / 100
+	default:
+		return nil, fmt.Errorf("overall_score or at least one metric score is required")
+	}
+
+	title := strings.TrimSpace(record.Title)
+	if title == "" {
+		title = source + " interview"
+	}
+	summary := strings.TrimSpace(record.Summary)
+	if summary == "" {
+		summary = fmt.Sprintf("Imported from %s.", source)
+	}
+	endedAt := startedAt.Add(time.Duration(record.DurationMinutes) * time.Minute)
+
+	return &models.InterviewSession{
+		Status:          "completed",
+		StartedAt:       startedAt,
+		EndedAt:         &endedAt,
+		Duration:        record.DurationMinutes * 60,
+		DurationMinutes: record.DurationMinutes,
+		ExternalID:      record.ExternalID,
+		Agent: models.Agent{
+			Name:        title,
+			Description: fmt.Sprintf("Interviews imported from %s", source),
+			Personality: fmt.Sprintf("Imported from %s", source),
+			Industry:    strings.TrimSpace(record.Industry),
+			Level:       strings.ToLower(strings.TrimSpace(record.Level)),
+		},
+		Summary: &models.InterviewSummary{
+			Summary:         summary,
+			Strengths:       strings.TrimSpace(record.Strengths),
+			Weaknesses:      strings.TrimSpace(record.Weaknesses),
+			Recommendations: strings.TrimSpace(record.Recommendations),
+			OverallScore:    overall,
+		},
+		PerformanceScores: scores,
+	}, nil
+}
+
+// parseImportDate reads an RFC 3339 timestamp or a bare date, which is taken as noon UTC so it
+// falls on the same day in every timezone's analytics
+func parseImportDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("date is required")
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.UTC(), nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed.Add(12 * time.Hour), nil
+	}
+	return time.Time{}, fmt.Errorf("date %q must be RFC 3339 or YYYY-MM-DD", value)
+}
+
+// importMetricName turns a metric name from another platform into this one's snake_case form,
+// e.g. "Problem Solving" into problem_solving
+func importMetricName(name string) string {
+	var builder strings.Builder
+	pendingSeparator := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingSeparator && builder.Len() > 0 {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(r)
+			pendingSeparator = false
+		} else {
+			pendingSeparator = true
+		}
+	}
+	return builder.String()
+}
+
+// DataImportService brings users' past mock-interview results in from other practice
+// platforms as imported sessions, so their analytics and skill profile start with context
+type DataImportService struct {
+	repo *repository.GORMRepository
+}
+
+func NewDataImportService(repo *repository.GORMRepository) *DataImportService {
+	return &DataImportService{repo: repo}
+}
+
+// Import creates a completed session, with its summary and scores, for each record of a file.
+// Nothing is imported unless every record is valid; the invalid ones are returned as
+// ImportErrors, and a file that can't be read as ErrInvalidImport.
+func (s *DataImportService) Import(ctx context.Context, user *models.User, source, filename string, data []byte) (*models.DataImport, error) {
+	source = strings.TrimSpace(source)
+	if source == "" || len([]rune(source)) > maxImportSource {
+		return nil, fmt.Errorf("%w: source must be 1-%d characters", ErrInvalidImport, maxImportSource)
+	}
+
+	format, records, err := ParseImportFile(filename, data)
+	var importErrs ImportErrors
+	if errors.As(err, &importErrs) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	now := time.Now()
+	sessions := make([]models.InterviewSession, 0, len(records))
+	seen := map[string]bool{}
+	var errs ImportErrors
+	for i, record := range records {
+		session, err := ImportedSession(source, record, now)
+		if err == nil && session.ExternalID != "" && seen[session.ExternalID] {
+			err = fmt.Errorf("external_id %q appears more than once", session.ExternalID)
+		}
+		if err != nil {
+			errs = append(errs, ImportError{Record: i + 1, Error: err.Error()})
+			continue
+		}
+		seen[session.ExternalID] = true
+		session.Language = user.Language
+		sessions = append(sessions, *session)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	dataImport := &models.DataImport{
+		UserID:   user.ID,
+		Source:   source,
+		Format:   format,
+		Filename: filename,
+	}
+	if err := s.repo.CreateDataImport(ctx, dataImport, sessions); err != nil {
+		return nil, fmt.Errorf("failed to store import: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Data imported", "import_id", dataImport.ID, "user_id", user.ID, "source", source, "sessions", dataImport.Sessions, "skipped", dataImport.Skipped)
+	return dataImport, nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dataPurgeBatch bounds the recordings and users purged at a time
+const dataPurgeBatch = 100
+
+// DataPurgeService deletes accounts for good on request, and permanently removes soft-deleted
+// data once it has been deleted for the retention period. Stored files go first, so nothing is
+// left in the blob storage without a row pointing at it.
+type DataPurgeService struct {
+	repo      *repository.GORMRepository
+	store     BlobStore
+	hotClass  string
+	coldClass string
+	config    PurgeConfig
+}
+
+func NewDataPurgeService(repo *repository.GORMRepository, store BlobStore, storage StorageConfig, config PurgeConfig) *DataPurgeService {
+	hotClass, coldClass := store.DefaultClasses()
+	if storage.HotClass != "" {
+		hotClass = storage.HotClass
+	}
+	if storage.ColdClass != "" {
+		coldClass = storage.ColdClass
+	}
+	return &DataPurgeService{
+		repo:      repo,
+		store:     store,
+		hotClass:  hotClass,
+		coldClass: coldClass,
+		config:    config,
+	}
+}
+
+// DeleteAccount permanently deletes a user's account and their data, once they have confirmed
+// it with their password; guests have none to confirm with. Admins must be demoted first, and
+// the last owner of an organization others still belong to must make one of them an owner.
+func (s *DataPurgeService) DeleteAccount(ctx context.Context, user *models.User, password string) error {
+	if user.Role != models.UserRoleGuest && bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return apperrors.Unauthorized("Incorrect password")
+	}
+	if user.Role == "admin" {
+		return apperrors.Forbidden("Admin accounts must be demoted before they can be deleted")
+	}
+
+	memberships, err := s.repo.GetUserOrgMemberships(ctx, user.ID)
+	if err != nil {
+		return apperrors.Internal("Failed to check organizations")
+	}
+	for _, membership := range memberships {
+		if membership.Role != models.OrgRoleOwner {
+			continue
+		}
+		owners, err := s.repo.CountOrgOwners(ctx, membership.OrganizationID)
+		if err != nil {
+			return apperrors.Internal("Failed to check organizations")
+		}
+		members, err := s.repo.GetOrgMembers(ctx, membership.OrganizationID)
+		if err != nil {
+			return apperrors.Internal("Failed to check organizations")
+		}
+		if owners == 1 && len(members) > 1 {
+			name := membership.OrganizationID
+			if membership.Organization != nil {
+				name = membership.Organization.Name
+			}
+			return apperrors.Conflict(fmt.Sprintf("Make another member an owner of %s before deleting your account", name))
+		}
+	}
+
+	if err := s.deleteAccount(ctx, user.ID); err != nil {
+		return apperrors.Internal("Failed to delete account")
+	}
+	logging.FromContext(ctx).Info("Account deleted at the user's request", "user_id", user.ID)
+	return nil
+}
+
+// deleteAccount deletes a user's stored files and then their account
+func (s *DataPurgeService) deleteAccount(ctx context.Context, userID string) error {
+	recordings, err := s.repo.GetUserAudioRecordings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if deleted := s.deleteAudio(ctx, recordings); len(deleted) < len(recordings) {
+		return fmt.Errorf("failed to delete %d recordings", len(recordings)-len(deleted))
+	}
+
+	exports, err := s.repo.GetUserExportBlobKeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, key := range exports {
+		if err := s.store.Delete(ctx, key, s.hotClass); err != nil {
+			return fmt.Errorf("failed to delete export archive: %w", err)
+		}
+	}
+
+	branding, err := s.repo.GetOrgBranding(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if branding != nil && branding.HasLogo() {
+		if err := s.store.Delete(ctx, branding.LogoKey, s.hotClass); err != nil {
+			return fmt.Errorf("failed to delete branding logo: %w", err)
+		}
+	}
+
+	return s.repo.DeleteUserAccount(ctx, userID)
+}
+
+// deleteAudio deletes the audio of recordings from both tiers, since a restored recording has
+// a copy in each, and returns the IDs of those whose audio is gone
+func (s *DataPurgeService) deleteAudio(ctx context.Context, recordings []models.AudioRecording) []string {
+	deleted := make([]string, 0, len(recordings))
+	for _, recording := range recordings {
+		hotErr := s.store.Delete(ctx, recording.StorageKey, s.hotClass)
+		coldErr := s.store.Delete(ctx, recording.StorageKey, s.coldClass)
+		if hotErr != nil || coldErr != nil {
+			logging.FromContext(ctx).Error("Failed to delete recording audio", "recording_id", recording.ID, "hot_error", hotErr, "cold_error", coldErr)
+			continue
+		}
+		deleted = append(deleted, recording.ID)
+	}
+	return deleted
+}
+
+// Purge permanently removes data soft-deleted before the retention period: the audio of deleted
+// recordings and sessions first, then the accounts of deleted users, then the remaining rows
+func (s *DataPurgeService) Purge(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	cutoff := time.Now().Add(-s.config.Retention)
+
+	recordings := 0
+	for ctx.Err() == nil {
+		batch, err := s.repo.GetPurgeableAudioRecordings(ctx, cutoff, dataPurgeBatch)
+		if err != nil || len(batch) == 0 {
+			break
+		}
+		deleted := s.deleteAudio(ctx, batch)
+		if len(deleted) == 0 || s.repo.PurgeAudioRecordings(ctx, deleted) != nil {
+			break
+		}
+		recordings += len(deleted)
+		if len(batch) < dataPurgeBatch {
+			break
+		}
+	}
+
+	users := 0
+	userIDs, err := s.repo.GetPurgeableUserIDs(ctx, cutoff, dataPurgeBatch)
+	if err == nil {
+		for _, userID := range userIDs {
+			if err := s.deleteAccount(ctx, userID); err != nil {
+				logger.Error("Failed to purge deleted user", "user_id", userID, "error", err)
+				continue
+			}
+			users++
+		}
+	}
+
+	rows, err := s.repo.PurgeSoftDeleted(ctx, cutoff)
+	if err != nil {
+		return
+	}
+	if recordings > 0 || users > 0 || rows > 0 {
+		logger.Info("Purged soft-deleted data", "recordings", recordings, "users", users, "rows", rows, "cutoff", cutoff)
+	}
+}
+
+// PurgeSessionsBefore permanently removes every ended session started before the cutoff, with
+// everything recorded in it and its recordings' audio, and returns how many were removed.
+// Sessions whose audio couldn't be deleted are kept.
+func (s *DataPurgeService) PurgeSessionsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	for ctx.Err() == nil {
+		sessionIDs, err := s.repo.GetSessionIDsStartedBefore(ctx, cutoff, dataPurgeBatch)
+		if err != nil {
+			return purged, err
+		}
+		if len(sessionIDs) == 0 {
+			break
+		}
+		recordings, err := s.repo.GetSessionsAudioRecordings(ctx, sessionIDs)
+		if err != nil {
+			return purged, err
+		}
+		deleted := make(map[string]bool)
+		for _, id := range s.deleteAudio(ctx, recordings) {
+			deleted[id] = true
+		}
+		kept := make(map[string]bool)
+		for _, recording := range recordings {
+			if !deleted[recording.ID] {
+				kept[recording.SessionID] = true
+			}
+		}
+		removable := make([]string, 0, len(sessionIDs))
+		for _, id := range sessionIDs {
+			if !kept[id] {
+				removable = append(removable, id)
+			}
+		}
+		if len(removable) == 0 {
+			return purged, fmt.Errorf("failed to delete the audio of %d sessions", len(kept))
+		}
+
+		count, err := s.repo.PurgeSessions(ctx, removable)
+		if err != nil {
+			return purged, err
+		}
+		purged += count
+		if len(kept) > 0 {
+			return purged, fmt.Errorf("failed to delete the audio of %d sessions", len(kept))
+		}
+	}
+	return purged, ctx.Err()
+}
+
+// StartPurgeJob periodically purges soft-deleted data past the retention period
+func (s *DataPurgeService) StartPurgeJob(lifecycle *Lifecycle) {
+	if s.config.Retention <= 0 {
+		slog.Info("Soft-deleted data purge disabled")
+		return
+	}
+
+	lifecycle.Go("data purge", func(ctx context.Context) {
+		ticker := time.NewTicker(max(s.config.Interval, time.Hour))
+		defer ticker.Stop()
+
+		for {
+			s.Purge(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
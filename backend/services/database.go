@@ -0,0 +1,86 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// maxConnectBackoff caps the delay between database connection attempts
+const maxConnectBackoff = 30 * time.Second
+
+// ConnectDatabase opens the database, retrying with exponential backoff so a database that
+// is briefly unavailable at boot doesn't leave the server without a repository. It returns
+// the number of attempts made; on failure the returned DB is nil.
+func ConnectDatabase(cfg DatabaseConfig, secrets *SecretsManager) (*gorm.DB, int, error) {
+	// Configure GORM logger based on config
+	var gormLogLevel gormLogger.LogLevel
+	switch cfg.LogLevel {
+	case "silent":
+		gormLogLevel = gormLogger.Silent
+	case "error":
+		gormLogLevel = gormLogger.Error
+	case "warn":
+		gormLogLevel = gormLogger.Warn
+	case "info":
+		gormLogLevel = gormLogger.Info
+	default:
+		gormLogLevel = gormLogger.Silent
+	}
+	gormConfig := &gorm.Config{
+		// Disable foreign key constraint checks during migration for better performance
+		DisableForeignKeyConstraintWhenMigrating: true,
+		// Skip default transaction for better performance
+		SkipDefaultTransaction: true,
+		// Configure logging level
+		Logger: gormLogger.Default.LogMode(gormLogLevel),
+	}
+
+	attempts := max(cfg.ConnectAttempts, 1)
+	backoff := cfg.ConnectBackoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var db *gorm.DB
+		db, err = gorm.Open(openPostgres(cfg.URL, secrets), gormConfig)
+		if err == nil {
+			return db, attempt, nil
+		}
+
+		// gorm.Open returns the handle even when the initial ping fails; don't leak its pool
+		if db != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+		}
+		if attempt == attempts {
+			break
+		}
+
+		slog.Warn("Failed to connect to database, retrying", "error", err, "attempt", attempt, "max_attempts", attempts, "retry_in", backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+	return nil, attempts, err
+}
+
+// openPostgres returns the PostgreSQL dialector. When the database URL is a managed secret,
+// each new connection reads the latest credentials so rotations don't need a restart.
+func openPostgres(dsn string, secrets *SecretsManager) gorm.Dialector {
+	if !secrets.Managed(SecretDatabaseURL) {
+		return postgres.Open(dsn)
+	}
+
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		// Let gorm.Open report the invalid URL
+		return postgres.Open(dsn)
+	}
+	sqlDB := stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(secrets.DatabaseBeforeConnect))
+	return postgres.New(postgres.Config{Conn: sqlDB})
+}
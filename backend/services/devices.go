@@ -0,0 +1,256 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// How a user authenticated when a device was observed
+const (
+	LoginTriggerPassword       = "password"
+	LoginTriggerPermanentToken = "permanent_token"
+)
+
+const deviceRevocationAudience = "device-revocation"
+
+// maxBrowserUpgrade is how many major versions a browser may move ahead between two uses of a
+// device-bound token, as browsers update themselves every few weeks
+const maxBrowserUpgrade = 2
+
+// DeviceInfo identifies the client a token is issued to or presented from
+type DeviceInfo struct {
+	UserAgent string
+	IPAddress string
+	Country   string // ISO country code, when the caller resolved one
+}
+
+// DeviceRevocationClaims are the claims of a "this wasn't me" link token. The subject is
+// the user and the token can only revoke the one device it names.
+type DeviceRevocationClaims struct {
+	PermanentTokenID string `json:"permanent_token_id"`
+	jwt.RegisteredClaims
+}
+
+// DeviceFromRequest extracts device information from a request; Country is left to the caller
+func DeviceFromRequest(r *http.Request) DeviceInfo {
+	device := DeviceInfo{UserAgent: r.UserAgent()}
+	if ip := clientIP(r); ip != nil {
+		device.IPAddress = ip.String()
+	}
+	return device
+}
+
+// Name returns a human-readable device name such as "Chrome on macOS"
+func (d DeviceInfo) Name() string {
+	browser, os := describeUserAgent(d.UserAgent)
+	return browser + " on " + os
+}
+
+// Fingerprint is the browser and OS family a user signs in with, which with the country tells
+// known devices apart for new sign-in alerts
+func (d DeviceInfo) Fingerprint() string {
+	browser, os := describeUserAgent(d.UserAgent)
+	return strings.ToLower(browser + "/" + os)
+}
+
+// checkDeviceBinding reports why a permanent token can't be used from a device, or nil if it
+// can. The browser, OS and platform must be those the token was last used from. Some drift is
+// tolerated, but only one kind at a time: the browser updating by up to maxBrowserUpgrade major
+// versions (never going back), a move to another network, or to another country. A stolen
+// token replayed from elsewhere has to copy the whole user agent and still changes network.
+func checkDeviceBinding(token *models.PermanentToken, device DeviceInfo) error {
+	last := DeviceInfo{UserAgent: cmp.Or(token.LastUserAgent, token.UserAgent), IPAddress: token.LastIP, Country: token.LastCountry}
+	if last.Fingerprint() != device.Fingerprint() || userAgentPlatform(last.UserAgent) != userAgentPlatform(device.UserAgent) {
+		return fmt.Errorf("permanent token not valid for this device")
+	}
+
+	drift := 0
+	upgrade := browserMajorVersion(device.UserAgent) - browserMajorVersion(last.UserAgent)
+	if upgrade < 0 || upgrade > maxBrowserUpgrade {
+		return fmt.Errorf("permanent token not valid for this browser version")
+	}
+	if upgrade > 0 {
+		drift++
+	}
+	if !sameNetwork(last.IPAddress, device.IPAddress) && !sameNetwork(token.IPAddress, device.IPAddress) {
+		drift++
+	}
+	if last.Country != "" && device.Country != "" && last.Country != device.Country {
+		drift++
+	}
+	if drift > 1 {
+		return fmt.Errorf("permanent token presented from a device that changed too much since it was last used")
+	}
+	return nil
+}
+
+// browserVersionPatterns find the major version of each browser family in a user agent
+var browserVersionPatterns = map[string]*regexp.Regexp{
+	"Edge":    regexp.MustCompile(`Edg/(\d+)`),
+	"Opera":   regexp.MustCompile(`OPR/(\d+)`),
+	"Firefox": regexp.MustCompile(`Firefox/(\d+)`),
+	"Chrome":  regexp.MustCompile(`(?:Chrome|CriOS)/(\d+)`),
+	"Safari":  regexp.MustCompile(`Version/(\d+)`),
+}
+
+// browserMajorVersion returns the major version of a user agent's browser, or 0 if it's unknown
+func browserMajorVersion(ua string) int {
+	browser, _ := describeUserAgent(ua)
+	pattern, ok := browserVersionPatterns[browser]
+	if !ok {
+		return 0
+	}
+	match := pattern.FindStringSubmatch(ua)
+	if match == nil {
+		return 0
+	}
+	version, _ := strconv.Atoi(match[1])
+	return version
+}
+
+// userAgentVersions matches the version numbers in a user agent's platform
+var userAgentVersions = regexp.MustCompile(`[\d_.]+`)
+
+// userAgentPlatform is the platform of a user agent, the first parenthesized part such as
+// "Macintosh; Intel Mac OS X 10_15_7", without version numbers, so OS updates keep it
+func userAgentPlatform(ua string) string {
+	_, rest, ok := strings.Cut(ua, "(")
+	if !ok {
+		return ""
+	}
+	platform, _, _ := strings.Cut(rest, ")")
+	return strings.Join(strings.Fields(strings.ToLower(userAgentVersions.ReplaceAllString(platform, ""))), " ")
+}
+
+// sameNetwork reports whether two addresses are in the same /24 for IPv4 or /48 for IPv6,
+// which covers a home or office network handing out a new address
+func sameNetwork(a, b string) bool {
+	addrA, errA := netip.ParseAddr(a)
+	addrB, errB := netip.ParseAddr(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	addrA, addrB = addrA.Unmap(), addrB.Unmap()
+	if addrA.Is4() != addrB.Is4() {
+		return false
+	}
+	bits := 48
+	if addrA.Is4() {
+		bits = 24
+	}
+	prefix, err := addrA.Prefix(bits)
+	return err == nil && prefix.Contains(addrB)
+}
+
+// observeDevice records the device and location a user authenticated from and publishes
+// EventNewDeviceLogin the first time the combination is seen. A user's first observed
+// device only establishes the baseline, so signups and existing users aren't alerted.
+func (s *AuthService) observeDevice(ctx context.Context, userID, permanentTokenID string, device DeviceInfo, trigger string) {
+	hasBaseline, err := s.repo.HasKnownDevices(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	firstSeen, err := s.repo.RecordKnownDevice(ctx, &models.KnownDevice{
+		UserID:      userID,
+		Fingerprint: device.Fingerprint(),
+		Country:     device.Country,
+		DeviceName:  device.Name(),
+		LastIP:      device.IPAddress,
+	})
+	if err != nil || !firstSeen || !hasBaseline {
+		return
+	}
+
+	logging.FromContext(ctx).Warn("Sign-in from a new device or location", "user_id", userID, "device", device.Name(), "country", device.Country, "ip", device.IPAddress, "trigger", trigger)
+	if s.eventBus == nil {
+		return
+	}
+	s.eventBus.Publish(ctx, EventNewDeviceLogin, "", NewDeviceLoginPayload{
+		UserID:           userID,
+		PermanentTokenID: permanentTokenID,
+		DeviceName:       device.Name(),
+		IPAddress:        device.IPAddress,
+		Country:          device.Country,
+		Trigger:          trigger,
+		OccurredAt:       time.Now(),
+	})
+}
+
+// DeviceRevocationToken signs a link token that revokes one device's token family.
+// It expires with the permanent token, after which there is nothing left to revoke.
+func (s *AuthService) DeviceRevocationToken(userID, permanentTokenID string) (string, error) {
+	claims := DeviceRevocationClaims{
+		PermanentTokenID: permanentTokenID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{deviceRevocationAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.permanentExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.signingKey())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign revocation token: %w", err)
+	}
+	return token, nil
+}
+
+// RevokeDeviceWithToken revokes the device named by a revocation link token, reporting
+// whether it was still signed in
+func (s *AuthService) RevokeDeviceWithToken(ctx context.Context, token string) (bool, error) {
+	claims := &DeviceRevocationClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, s.verificationKeys, jwt.WithAudience(deviceRevocationAudience))
+	if err != nil {
+		return false, fmt.Errorf("invalid revocation token: %w", err)
+	}
+
+	logging.FromContext(ctx).Warn("Device revoked from security notification", "user_id", claims.Subject, "device_id", claims.PermanentTokenID)
+	return s.RevokeDevice(ctx, claims.Subject, claims.PermanentTokenID)
+}
+
+// describeUserAgent returns the browser and OS families of a user agent string
+func describeUserAgent(ua string) (string, string) {
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	}
+
+	os := "unknown OS"
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		os = "iOS"
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "CrOS"):
+		os = "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+
+	return browser, os
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const (
+	// abilityPrior is the ability estimate before the candidate's first scored answer
+	abilityPrior = 0.5
+	// abilityWeight is how much each new answer moves the estimate; earlier answers fade out
+	abilityWeight = 0.35
+	// Ability below easyAbility asks easier questions, and above hardAbility harder ones
+	easyAbility = 0.35
+	hardAbility = 0.65
+	// minAdaptiveAnswers is how many answers are scored before the difficulty moves off medium,
+	// so one nervous or lucky answer doesn't set the level
+	minAdaptiveAnswers = 2
+)
+
+var (
+	// answerGivesUp matches an answer that admits not knowing
+	answerGivesUp = regexp.MustCompile(`(?i)\b(i don'?t know|i do not know|no idea|not sure|i'?m unsure|i can'?t remember|i don'?t remember)\b`)
+	// answerReasons matches words of an answer that explain, compare or give an example
+	answerReasons = regexp.MustCompile(`(?i)\b(because|therefore|so that|which means|trade-?offs?|instead of|whereas|however|for example|for instance|such as|e\.g\.|complexity|depends on|in production|we measured|i measured|the result)\b`)
+)
+
+// ScoreAnswer rates how strong an answer is, from 0 to 1, without a model call: long enough to
+// say something, reasoned, and specific, with numbers and technical terms, scores higher;
+// admitting not knowing scores low. It only has to tell a struggling candidate from a fluent one.
+func ScoreAnswer(answer string) float64 {
+	words := strings.Fields(answer)
+	if len(words) == 0 {
+		return 0
+	}
+
+	// Length: a few words say little, and past ~120 more words add nothing
+	score := 0.4 * min(float64(len(words))/120, 1)
+
+	// Reasoning: each distinct explanation or example, up to three
+	reasons := make(map[string]bool)
+	for _, match := range answerReasons.FindAllString(answer, -1) {
+		reasons[strings.ToLower(match)] = true
+	}
+	score += 0.1 * float64(min(len(reasons), 3))
+
+	// Specificity: numbers and technical terms like "p99", "O(n)", "snake_case" or "HTTP"
+	specific := 0
+	for _, word := range words {
+		if isSpecificWord(strings.Trim(word, ".,;:!?\"'()")) {
+			specific++
+		}
+	}
+	score += 0.3 * min(float64(specific)/5, 1)
+
+	if answerGivesUp.MatchString(answer) && len(words) < 40 {
+		score = min(score, 0.1)
+	}
+	return min(score, 1)
+}
+
+// isSpecificWord reports whether a word is a number or reads as a technical term
+func isSpecificWord(word string) bool {
+	if len(word) < 2 {
+		return false
+	}
+	digits, upper, symbols := 0, 0, 0
+	for _, r := range word {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsUpper(r):
+			upper++
+		case r == '_' || r == '(' || r == '/' || r == '.':
+			symbols++
+		}
+	}
+	return digits > 0 || upper > 1 || symbols > 0
+}
+
+// AbilityEstimate is a rolling estimate of how well the candidate is answering, from 0 to 1,
+// weighted toward their latest answers
+type AbilityEstimate struct {
+	Ability float64
+	Answers int
+}
+
+// Record adds an answer's score to the estimate
+func (a AbilityEstimate) Record(score float64) AbilityEstimate {
+	if a.Answers == 0 {
+		a.Ability = abilityPrior
+	}
+	a.Ability += abilityWeight * (score - a.Ability)
+	a.Answers++
+	return a
+}
+
+// Difficulty returns the question difficulty the estimate calls for: one of the question bank's
+// easy, medium or hard
+func (a AbilityEstimate) Difficulty() string {
+	switch {
+	case a.Answers < minAdaptiveAnswers:
+		return models.QuestionDifficultyMedium
+	case a.Ability < easyAbility:
+		return models.QuestionDifficultyEasy
+	case a.Ability > hardAbility:
+		return models.QuestionDifficultyHard
+	}
+	return models.QuestionDifficultyMedium
+}
+
+// difficultyInstruction returns the system instruction section asking for the next question at
+// a difficulty, or "" for none
+func difficultyInstruction(difficulty string) string {
+	var rule string
+	switch difficulty {
+	case models.QuestionDifficultyEasy:
+		rule = "The candidate is struggling with the recent questions. Make your next question easier: narrow its scope, ask about fundamentals or a concrete case, and offer a simpler way in. Do not mention that you are making it easier."
+	case models.QuestionDifficultyMedium:
+		rule = "The candidate is answering at the expected level. Keep your next question at a similar difficulty to the last."
+	case models.QuestionDifficultyHard:
+		rule = "The candidate is answering the recent questions well. Make your next question harder: probe deeper trade-offs, edge cases, scale or failure modes, or combine concepts. Do not mention that you are making it harder."
+	default:
+		return ""
+	}
+	return fmt.Sprintf(`
+
+QUESTION DIFFICULTY:
+%s`, rule)
+}
+
+// adaptDifficulty scores the candidate's answer and sets the difficulty of the next question
+// from their rolling ability, for agents that adapt to the candidate
+func (p *AIMessageProcessor) adaptDifficulty(sessionID string, agent *models.Agent, answer string) {
+	if agent == nil || !agent.AdaptiveDifficulty {
+		return
+	}
+	score := ScoreAnswer(answer)
+	estimate, ok := p.timeoutService.RecordAnswerScore(sessionID, score)
+	if !ok {
+		return
+	}
+	difficulty := estimate.Difficulty()
+	p.llm.SetDifficulty(sessionID, difficulty)
+	slog.Debug("Adapted question difficulty", "session_id", sessionID, "score", score, "ability", estimate.Ability, "difficulty", difficulty)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+type DocumentEndpoints struct {
+	repo      *repository.GORMRepository
+	documents *DocumentService
+}
+
+func NewDocumentEndpoints(repo *repository.GORMRepository, documents *DocumentService) *DocumentEndpoints {
+	return &DocumentEndpoints{
+		repo:      repo,
+		documents: documents,
+	}
+}
+
+func (e *DocumentEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/documents", func(r chi.Router) {
+		r.Post("/", e.UploadDocumentHandler)
+		r.Get("/", e.GetDocumentsHandler)
+		r.Get("/{id}", e.GetDocumentHandler)
+		r.Delete("/{id}", e.DeleteDocumentHandler)
+	})
+}
+
+// UploadDocumentHandler accepts a multipart upload with a "file" (PDF, DOCX or plain text)
+// and a "kind" of resume or job_description
+func (e *DocumentEndpoints) UploadDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDocumentSize+1<<20) // Allow for multipart overhead
+	if err := r.ParseMultipartForm(maxDocumentSize); err != nil {
+		apperrors.Write(w, r, apperrors.TooLarge("Document must be a multipart upload of at most 5 MB"))
+		return
+	}
+
+	kind := r.FormValue("kind")
+	if kind != models.DocumentKindResume && kind != models.DocumentKindJobDescription {
+		apperrors.Write(w, r, apperrors.BadRequest("kind must be resume or job_description"))
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("file is required"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxDocumentSize+1))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Failed to read document"))
+		return
+	}
+	if len(data) > maxDocumentSize {
+		apperrors.Write(w, r, apperrors.TooLarge("Document must be at most 5 MB"))
+		return
+	}
+
+	document, err := e.documents.Ingest(r.Context(), user.ID, kind, header.Filename, data)
+	if errors.Is(err, ErrUnsupportedDocument) || errors.Is(err, ErrNoDocumentText) {
+		apperrors.Write(w, r, apperrors.Unprocessable(err.Error()))
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to ingest document", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to process document"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"document": document,
+	})
+}
+
+func (e *DocumentEndpoints) GetDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	documents, err := e.repo.GetUserDocuments(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get documents"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"documents": documents,
+	})
+}
+
+func (e *DocumentEndpoints) GetDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	document, err := e.repo.GetDocument(r.Context(), chi.URLParam(r, "id"), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get document"))
+		return
+	}
+	if document == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"document": document,
+	})
+}
+
+func (e *DocumentEndpoints) DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	deleted, err := e.repo.DeleteDocument(r.Context(), chi.URLParam(r, "id"), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete document"))
+		return
+	}
+	if !deleted {
+		apperrors.Write(w, r, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
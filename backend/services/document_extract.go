@@ -0,0 +1,346 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Content types accepted for document uploads
+const (
+	contentTypePDF  = "application/pdf"
+	contentTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	contentTypeText = "text/plain"
+)
+
+// maxExtractedStream caps how much a single PDF stream may inflate to
+const maxExtractedStream = 10 << 20
+
+var (
+	ErrUnsupportedDocument = errors.New("unsupported document type: upload a PDF, DOCX or plain text file")
+	ErrNoDocumentText      = errors.New("no text could be extracted; scanned documents are not supported")
+
+	pdfStreamPattern = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+)
+
+// extractDocumentText detects the document type from its content and returns its plain text
+func extractDocumentText(data []byte) (string, string, error) {
+	var (
+		text        string
+		contentType string
+		err         error
+	)
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		contentType = contentTypePDF
+		text, err = extractPDFText(data)
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		contentType = contentTypeDOCX
+		text, err = extractDOCXText(data)
+	case isPlainText(data):
+		contentType = contentTypeText
+		text = string(data)
+	default:
+		return "", "", ErrUnsupportedDocument
+	}
+	if err != nil {
+		return "", contentType, err
+	}
+
+	text = normalizeDocumentText(text)
+	if len(strings.Fields(text)) < 5 || !mostlyLetters(text) {
+		return "", contentType, ErrNoDocumentText
+	}
+	return text, contentType, nil
+}
+
+// extractDOCXText reads the paragraphs of word/document.xml
+func extractDOCXText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", ErrUnsupportedDocument
+	}
+
+	for _, file := range archive.File {
+		if file.Name != "word/document.xml" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+		defer reader.Close()
+
+		var text strings.Builder
+		decoder := xml.NewDecoder(io.LimitReader(reader, maxExtractedStream))
+		inText := false
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("parse docx: %w", err)
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "t":
+					inText = true
+				case "tab":
+					text.WriteString("\t")
+				case "br", "cr":
+					text.WriteString("\n")
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "t":
+					inText = false
+				case "p":
+					text.WriteString("\n")
+				}
+			case xml.CharData:
+				if inText {
+					text.Write(t)
+				}
+			}
+		}
+		return text.String(), nil
+	}
+	return "", ErrUnsupportedDocument
+}
+
+// extractPDFText pulls the text shown by BT/ET blocks in the PDF's content streams. It handles
+// uncompressed and Flate-compressed streams with simple font encodings, which covers resumes
+// exported from word processors; scanned or CID-encoded documents yield no usable text.
+func extractPDFText(data []byte) (string, error) {
+	var text strings.Builder
+	for _, match := range pdfStreamPattern.FindAllSubmatchIndex(data, -1) {
+		dict := data[match[2]:match[3]]
+		start := match[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[start : start+end]
+
+		// Images and fonts never contain text operators
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/FontFile")) {
+			continue
+		}
+
+		content := raw
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			reader, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			content, err = io.ReadAll(io.LimitReader(reader, maxExtractedStream))
+			reader.Close()
+			if err != nil && len(content) == 0 {
+				continue
+			}
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			continue
+		}
+
+		pdfContentText(content, &text)
+	}
+	return text.String(), nil
+}
+
+// pdfContentText appends the strings drawn by a content stream's text operators
+func pdfContentText(content []byte, out *strings.Builder) {
+	inText, inArray := false, false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '[' && inText:
+			inArray = true
+		case c == ']' && inText:
+			inArray = false
+		case c == '-' && inArray:
+			// A large negative adjustment inside a TJ array is how most producers draw a space
+			end := i + 1
+			for end < len(content) && (content[end] >= '0' && content[end] <= '9' || content[end] == '.') {
+				end++
+			}
+			if value, err := strconv.ParseFloat(string(content[i+1:end]), 64); err == nil && value >= 200 {
+				out.WriteString(" ")
+			}
+			i = end - 1
+		case c == '(' && inText:
+			s, next := readPDFLiteral(content, i)
+			out.WriteString(s)
+			i = next
+		case c == '<' && inText && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			if decoded, err := hex.DecodeString(strings.Map(dropSpace, string(content[i+1:i+end]))); err == nil {
+				out.Write(decoded)
+			}
+			i += end
+		case c == '%' && !inText:
+			// Comment to end of line
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isPDFOperator(content, i, "BT"):
+			inText = true
+			i++
+		case isPDFOperator(content, i, "ET"):
+			inText = false
+			out.WriteString("\n")
+			i++
+		case inText && (isPDFOperator(content, i, "Td") || isPDFOperator(content, i, "TD") || isPDFOperator(content, i, "T*")):
+			out.WriteString("\n")
+			i++
+		}
+	}
+}
+
+// readPDFLiteral decodes a (literal string) starting at content[start], returning it and the
+// index of its closing parenthesis
+func readPDFLiteral(content []byte, start int) (string, int) {
+	var s strings.Builder
+	depth := 0
+	for i := start; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '\\':
+			if i+1 >= len(content) {
+				return s.String(), i
+			}
+			i++
+			switch e := content[i]; e {
+			case 'n':
+				s.WriteByte('\n')
+			case 'r', '\n':
+			case 't':
+				s.WriteByte('\t')
+			case 'b', 'f':
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				value := 0
+				for j := 0; j < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7'; j++ {
+					value = value*8 + int(content[i]-'0')
+					i++
+				}
+				i--
+				s.WriteRune(rune(value))
+			default:
+				s.WriteByte(e)
+			}
+		case '(':
+			if depth > 0 {
+				s.WriteByte(c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s.String(), i
+			}
+			s.WriteByte(c)
+		default:
+			s.WriteRune(rune(c)) // PDFDocEncoding/WinAnsi are close enough to Latin-1 for text
+		}
+	}
+	return s.String(), len(content)
+}
+
+// isPDFOperator reports whether the operator op starts at content[i] as a standalone token
+func isPDFOperator(content []byte, i int, op string) bool {
+	if !bytes.HasPrefix(content[i:], []byte(op)) {
+		return false
+	}
+	if i > 0 && !isPDFDelimiter(content[i-1]) {
+		return false
+	}
+	end := i + len(op)
+	return end >= len(content) || isPDFDelimiter(content[end])
+}
+
+func isPDFDelimiter(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == ']' || c == ')' || c == '>'
+}
+
+func dropSpace(r rune) rune {
+	if unicode.IsSpace(r) {
+		return -1
+	}
+	return r
+}
+
+// isPlainText reports whether data looks like UTF-8 text rather than a binary file
+func isPlainText(data []byte) bool {
+	sample := data
+	if len(sample) > 4096 {
+		sample = sample[:4096]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return false
+	}
+	printable := 0
+	for _, r := range string(sample) {
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			printable++
+		}
+	}
+	return printable*10 >= len([]rune(string(sample)))*9
+}
+
+// mostlyLetters rejects the symbol soup produced by fonts whose encoding we can't map
+func mostlyLetters(text string) bool {
+	letters, total := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letters++
+		}
+	}
+	return total > 0 && letters*10 >= total*6
+}
+
+// normalizeDocumentText drops control characters and collapses blank runs of lines
+func normalizeDocumentText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r == unicode.ReplacementChar || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			if !blank && len(kept) > 0 {
+				kept = append(kept, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
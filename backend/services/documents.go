@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// maxDocumentSize is the largest resume or job description accepted for upload
+	maxDocumentSize = 5 << 20
+	// maxCandidateContext caps how much document text is added to the interviewer's instructions
+	maxCandidateContext = 6000
+)
+
+// documentHeadings are section titles commonly found in resumes and job descriptions
+var documentHeadings = map[string]bool{
+	"summary": true, "profile": true, "objective": true, "about": true, "about me": true,
+	"experience": true, "work experience": true, "professional experience": true, "employment": true, "employment history": true,
+	"education": true, "skills": true, "technical skills": true, "projects": true, "certifications": true,
+	"publications": true, "awards": true, "languages": true, "interests": true, "volunteering": true,
+	"about the role": true, "about us": true, "the role": true, "responsibilities": true, "what you'll do": true,
+	"requirements": true, "qualifications": true, "what we're looking for": true, "nice to have": true,
+	"preferred qualifications": true, "benefits": true,
+}
+
+// DocumentService ingests resumes and job descriptions and turns them into interview context
+type DocumentService struct {
+	repo *repository.GORMRepository
+}
+
+func NewDocumentService(repo *repository.GORMRepository) *DocumentService {
+	return &DocumentService{repo: repo}
+}
+
+// Ingest extracts the text of an uploaded document, splits it into sections and stores it
+func (s *DocumentService) Ingest(ctx context.Context, userID, kind, filename string, data []byte) (*models.Document, error) {
+	text, contentType, err := extractDocumentText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &models.Document{
+		UserID:      userID,
+		Kind:        kind,
+		Filename:    filepath.Base(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Text:        text,
+		Sections:    splitDocumentSections(text),
+	}
+	if err := s.repo.CreateDocument(ctx, document); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Document ingested", "document_id", document.ID, "user_id", userID, "kind", kind, "content_type", contentType, "sections", len(document.Sections))
+	return document, nil
+}
+
+// CandidateContext returns the resume and job description of a session as instructions for
+// the interviewer, or "" when the session has neither
+func (s *DocumentService) CandidateContext(ctx context.Context, session *models.InterviewSession) (string, error) {
+	var parts []string
+	for _, ref := range []struct {
+		id    *string
+		title string
+	}{
+		{session.JobDescriptionID, "JOB DESCRIPTION"},
+		{session.ResumeID, "CANDIDATE RESUME"},
+	} {
+		if ref.id == nil {
+			continue
+		}
+		document, err := s.repo.GetDocument(ctx, *ref.id, session.UserID)
+		if err != nil {
+			return "", err
+		}
+		if document == nil {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s:\n%s", ref.title, truncateText(document.Text, maxCandidateContext/2)))
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// splitDocumentSections splits text on lines that look like section headings
+func splitDocumentSections(text string) []models.DocumentSection {
+	var (
+		sections []models.DocumentSection
+		heading  string
+		body     []string
+	)
+	flush := func() {
+		content := strings.TrimSpace(strings.Join(body, "\n"))
+		if content != "" {
+			sections = append(sections, models.DocumentSection{
+				Position: len(sections),
+				Heading:  heading,
+				Content:  content,
+			})
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if title, ok := documentHeading(line); ok {
+			flush()
+			heading = title
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
+
+// documentHeading reports whether a line is a section heading: a known title, or a short line
+// in capitals
+func documentHeading(line string) (string, bool) {
+	trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), ":"))
+	if trimmed == "" || len(trimmed) > 40 {
+		return "", false
+	}
+	if documentHeadings[strings.ToLower(trimmed)] {
+		return trimmed, true
+	}
+
+	letters := 0
+	for _, r := range trimmed {
+		if unicode.IsLower(r) {
+			return "", false
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return trimmed, letters >= 4 && len(strings.Fields(trimmed)) <= 4
+}
+
+// truncateText shortens text to at most limit bytes on a word boundary
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := strings.LastIndexAny(text[:limit], " \n")
+	if cut <= 0 {
+		cut = limit
+	}
+	return text[:cut] + " [...]"
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const (
+	// DrillMinutes is the time limit of a drill session
+	DrillMinutes = 10
+	// maxDrillTopics is how many weaknesses a drill probes; the first listed are kept
+	maxDrillTopics = 5
+	// maxDrillTopicLength caps each topic, in runes
+	maxDrillTopicLength = 200
+)
+
+var (
+	// drillTopicMarker is a bullet or number starting a line of the weaknesses
+	drillTopicMarker = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s*`)
+	// drillSentenceEnd splits a paragraph of weaknesses into sentences
+	drillSentenceEnd = regexp.MustCompile(`[.;!?]\s+`)
+)
+
+// DrillTopics splits a summary's weaknesses into the topics a drill probes: one per line or
+// bullet, or one per sentence when the weaknesses are a single paragraph. Placeholders written
+// when the model found no weaknesses give no topics.
+func DrillTopics(weaknesses string) []string {
+	text := strings.TrimSpace(weaknesses)
+	switch text {
+	case "", "No weaknesses identified", "Unable to parse structured response":
+		return nil
+	}
+
+	var lines []string
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '•' }) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 1 {
+		lines = drillSentenceEnd.Split(lines[0], -1)
+	}
+
+	var topics []string
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		topic := strings.TrimSpace(drillTopicMarker.ReplaceAllString(strings.TrimSpace(line), ""))
+		topic = strings.TrimRight(topic, ".;:!? ")
+		if runes := []rune(topic); len(runes) > maxDrillTopicLength {
+			topic = strings.TrimSpace(string(runes[:maxDrillTopicLength]))
+		}
+		key := strings.ToLower(topic)
+		if topic == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		topics = append(topics, topic)
+		if len(topics) == maxDrillTopics {
+			break
+		}
+	}
+	return topics
+}
+
+// CreateDrillHandler starts a short session with the same agent that probes only the
+// weaknesses found in a session's summary
+func (e *SessionEndpoints) CreateDrillHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	sourceID := chi.URLParam(r, "id")
+	source, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sourceID, user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return
+	}
+	if source == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+	if source.Summary == nil {
+		apperrors.Write(w, r, apperrors.Conflict("Session has no summary yet"))
+		return
+	}
+	topics := DrillTopics(source.Summary.Weaknesses)
+	if len(topics) == 0 {
+		apperrors.Write(w, r, apperrors.Conflict("The summary found no weaknesses to drill"))
+		return
+	}
+
+	agent, err := e.repo.GetAgentByID(r.Context(), source.AgentID, user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to validate agent"))
+		return
+	}
+	if agent == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
+		return
+	}
+	if !agent.IsActive {
+		apperrors.Write(w, r, apperrors.Conflict("Agent has been deactivated"))
+		return
+	}
+
+	encoded, err := json.Marshal(topics)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create drill"))
+		return
+	}
+	region := e.geo.Resolve(r)
+	session := models.InterviewSession{
+		ID:              uuid.New().String(),
+		UserID:          user.ID,
+		AgentID:         agent.ID,
+		Status:          "active",
+		StartedAt:       time.Now(),
+		Country:         region.Country,
+		Language:        source.Language,
+		DurationMinutes: DrillMinutes,
+		Mode:            source.Mode, // A candidate without a microphone drills in writing too
+		InterviewType:   source.InterviewType,
+		Coaching:        source.Coaching,
+	}
+	plan := models.DrillPlan{
+		SourceSessionID: source.ID,
+		Topics:          string(encoded),
+		SourceScore:     source.Summary.OverallScore,
+	}
+	if err := e.repo.CreateDrillSession(r.Context(), &session, &plan); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create drill"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session":    session,
+		"drill_plan": plan,
+	})
+
+	logging.FromContext(r.Context()).Info("Drill session created", "session_id", session.ID, "source_session_id", source.ID, "topics", len(topics))
+}
+
+// GetSessionDrillsHandler lists the drills created from a session, with the score of each
+// that has been summarized, to compare against the source session's
+func (e *SessionEndpoints) GetSessionDrillsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	sourceID := chi.URLParam(r, "id")
+	source, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sourceID, user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return
+	}
+	if source == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+
+	drills, err := e.repo.GetSessionDrills(r.Context(), source.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get drills"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id": source.ID,
+		"drills":     drills,
+		"count":      len(drills),
+	})
+}
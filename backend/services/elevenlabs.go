@@ -4,16 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
 )
 
+const DefaultVoiceID = "pNInz6obpgDQGcFmaJgB" // Adam
+
 type ElevenLabsService struct {
-	apiKey string
-	client *http.Client
+	apiKey   string
+	keyMutex sync.RWMutex
+	client   *http.Client
+	regions  *TTSRegionSelector
 }
 
 type ElevenLabsRequest struct {
@@ -28,60 +36,79 @@ type VoiceSettings struct {
 	SimilarityBoost float64 `json:"similarity_boost"`
 }
 
-func NewElevenLabsService(apiKey string) *ElevenLabsService {
+// elevenLabsAPIError is a non-200 response from ElevenLabs
+type elevenLabsAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *elevenLabsAPIError) Error() string {
+	return fmt.Sprintf("elevenlabs API error: %d - %s", e.StatusCode, e.Body)
+}
+
+// retryable reports whether another region might succeed where this one failed
+func (e *elevenLabsAPIError) retryable() bool {
+	return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
+}
+
+func NewElevenLabsService(apiKey string, regions []TTSRegion) *ElevenLabsService {
 	return &ElevenLabsService{
 		apiKey: apiKey,
 		client: &http.Client{
 			Timeout: 60 * time.Second,
 		},
+		regions: NewTTSRegionSelector(apiKey, regions),
 	}
 }
 
-func (e *ElevenLabsService) TextToSpeech(ctx context.Context, text string) (io.ReadCloser, error) {
-	request := ElevenLabsRequest{
-		Text:    text,
-		ModelID: "eleven_turbo_v2",      // Fast model for real-time conversation
-		VoiceID: "pNInz6obpgDQGcFmaJgB", // Default voice (Adam)
-		VoiceSettings: VoiceSettings{
-			Stability:       0.5,
-			SimilarityBoost: 0.5,
-		},
+// TextToSpeech speaks text in the given voice, or in DefaultVoiceID when voiceID is empty
+func (e *ElevenLabsService) TextToSpeech(ctx context.Context, text string, voiceID string) (io.ReadCloser, error) {
+	if voiceID == "" {
+		voiceID = DefaultVoiceID
 	}
-
-	jsonData, err := json.Marshal(request)
+	body, err := e.synthesize(ctx, text, voiceID, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	url := "https://api.elevenlabs.io/v1/text-to-speech/pNInz6obpgDQGcFmaJgB"
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("xi-api-key", e.apiKey)
+	logging.FromContext(ctx).Info("Generated audio from ElevenLabs", "text_length", len(text), "voice_id", voiceID)
+	return body, nil
+}
 
-	resp, err := e.client.Do(req)
+// TextToSpeechStream is TextToSpeech from ElevenLabs' streaming endpoint, whose body yields
+// audio as it is synthesized. Region failover only covers the request, not a broken-off body.
+func (e *ElevenLabsService) TextToSpeechStream(ctx context.Context, text string, voiceID string) (io.ReadCloser, error) {
+	if voiceID == "" {
+		voiceID = DefaultVoiceID
+	}
+	body, err := e.synthesize(ctx, text, voiceID, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		return nil, fmt.Errorf("elevenlabs API error: %d - %s", resp.StatusCode, string(body))
-	}
+	logging.FromContext(ctx).Info("Streaming audio from ElevenLabs", "text_length", len(text), "voice_id", voiceID)
+	return body, nil
+}
 
-	slog.Info("Generated audio from ElevenLabs", "text_length", len(text))
-	return resp.Body, nil
+// SetAPIKey switches to a rotated API key for subsequent requests
+func (e *ElevenLabsService) SetAPIKey(apiKey string) {
+	e.keyMutex.Lock()
+	e.apiKey = apiKey
+	e.keyMutex.Unlock()
+	e.regions.SetAPIKey(apiKey)
+	slog.Info("ElevenLabs API key rotated")
+}
+
+// RegionStatus returns the cached health and latency of each configured TTS region
+func (e *ElevenLabsService) RegionStatus() []TTSRegionStatus {
+	return e.regions.Status()
 }
 
-// TextToSpeechWithVoice allows specifying a custom voice ID
-func (e *ElevenLabsService) TextToSpeechWithVoice(ctx context.Context, text string, voiceID string) (io.ReadCloser, error) {
+// synthesize tries regions fastest-first, failing over on network errors and retryable API errors
+func (e *ElevenLabsService) synthesize(ctx context.Context, text string, voiceID string, stream bool) (io.ReadCloser, error) {
 	request := ElevenLabsRequest{
 		Text:    text,
-		ModelID: "eleven_turbo_v2",
+		ModelID: "eleven_turbo_v2", // Fast model for real-time conversation
 		VoiceID: voiceID,
 		VoiceSettings: VoiceSettings{
 			Stability:       0.5,
@@ -94,14 +121,46 @@ func (e *ElevenLabsService) TextToSpeechWithVoice(ctx context.Context, text stri
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("https://api.elevenlabs.io/v1/text-to-speech/%s", voiceID)
+	var lastErr error
+	for _, region := range e.regions.Ranked() {
+		start := time.Now()
+		body, err := e.synthesizeInRegion(ctx, region, voiceID, stream, jsonData)
+		if err == nil {
+			e.regions.ReportSuccess(region, time.Since(start))
+			return body, nil
+		}
+		lastErr = err
+
+		// Client errors (bad voice, auth) would fail everywhere; don't fail over
+		var apiErr *elevenLabsAPIError
+		if errors.As(err, &apiErr) && !apiErr.retryable() {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		e.regions.ReportFailure(region)
+		logging.FromContext(ctx).Warn("TTS region failed, trying next region", "region", region.Name, "error", err)
+	}
+
+	return nil, lastErr
+}
+
+func (e *ElevenLabsService) synthesizeInRegion(ctx context.Context, region TTSRegion, voiceID string, stream bool, jsonData []byte) (io.ReadCloser, error) {
+	url := fmt.Sprintf("%s/v1/text-to-speech/%s", region.BaseURL, voiceID)
+	if stream {
+		url += "/stream"
+	}
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	e.keyMutex.RLock()
 	req.Header.Set("xi-api-key", e.apiKey)
+	e.keyMutex.RUnlock()
 
 	resp, err := e.client.Do(req)
 	if err != nil {
@@ -111,9 +170,8 @@ func (e *ElevenLabsService) TextToSpeechWithVoice(ctx context.Context, text stri
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("elevenlabs API error: %d - %s", resp.StatusCode, string(body))
+		return nil, &elevenLabsAPIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
-	slog.Info("Generated audio from ElevenLabs (custom voice)", "text_length", len(text), "voice_id", voiceID)
 	return resp.Body, nil
 }
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// EventType identifies a kind of internal event
+type EventType string
+
+const (
+	// EventSessionStarted is published when a candidate connects to an interview session
+	EventSessionStarted EventType = "session.started"
+	// EventSessionEndRequested is published when the user, the AI or a penalty rule asks to end a session
+	EventSessionEndRequested EventType = "session.end_requested"
+	// EventSessionConcluded is published once a session has been finalized in the database
+	EventSessionConcluded EventType = "session.concluded"
+	// EventSummaryGenerated is published after a production summary has been saved
+	EventSummaryGenerated EventType = "summary.generated"
+	// EventNewDeviceLogin is published when a user signs in from a device or location not seen before
+	EventNewDeviceLogin EventType = "security.new_device_login"
+)
+
+const (
+	MaxEventAttempts   = 5
+	eventRetryInterval = time.Minute
+	eventRetryBatch    = 50
+)
+
+// SessionStartedPayload is the payload of EventSessionStarted
+type SessionStartedPayload struct {
+	UserID  string `json:"user_id"`
+	AgentID string `json:"agent_id"`
+}
+
+// SessionEndRequestedPayload is the payload of EventSessionEndRequested
+type SessionEndRequestedPayload struct {
+	Reason string `json:"reason"`
+}
+
+// SessionConcludedPayload is the payload of EventSessionConcluded
+type SessionConcludedPayload struct {
+	UserID          string `json:"user_id"`
+	AgentID         string `json:"agent_id"`
+	Duration        int    `json:"duration"`
+	TranscriptCount int    `json:"transcript_count"`
+	TimedOut        bool   `json:"timed_out,omitempty"` // Ended because the candidate went inactive
+}
+
+// SummaryGeneratedPayload is the payload of EventSummaryGenerated
+type SummaryGeneratedPayload struct {
+	SummaryID   string `json:"summary_id"`
+	AgentID     string `json:"agent_id"`
+	RawResponse string `json:"raw_response"`
+	BackfillID  string `json:"backfill_id,omitempty"` // Set when a re-scoring backfill regenerated the summary
+}
+
+// NewDeviceLoginPayload is the payload of EventNewDeviceLogin
+type NewDeviceLoginPayload struct {
+	UserID           string    `json:"user_id"`
+	PermanentTokenID string    `json:"permanent_token_id"`
+	DeviceName       string    `json:"device_name"`
+	IPAddress        string    `json:"ip_address"`
+	Country          string    `json:"country,omitempty"`
+	Trigger          string    `json:"trigger"` // password or permanent_token
+	OccurredAt       time.Time `json:"occurred_at"`
+}
+
+// Event is a typed event delivered to subscribers
+type Event struct {
+	ID         string          `json:"id,omitempty"`
+	Type       EventType       `json:"type"`
+	SessionID  string          `json:"session_id,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// Decode unmarshals the event payload into v
+func (e Event) Decode(v any) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// EventHandler handles a single event; handlers must be idempotent because failed events are redelivered
+type EventHandler func(ctx context.Context, event Event) error
+
+// eventSubscriber is a handler with the name its deliveries are recorded under
+type eventSubscriber struct {
+	name    string
+	handler EventHandler
+}
+
+// EventBus is an internal publish/subscribe bus. When a repository is configured,
+// every event is persisted before dispatch and failed deliveries are retried. Each
+// subscriber's delivery is recorded, so a retry only reaches the subscribers that failed.
+type EventBus struct {
+	repo        *repository.GORMRepository
+	subscribers map[EventType][]eventSubscriber
+	dispatching sync.WaitGroup // Deliveries in flight, for Wait
+	mutex       sync.RWMutex
+}
+
+func NewEventBus(repo *repository.GORMRepository) *EventBus {
+	bus := &EventBus{
+		repo:        repo,
+		subscribers: make(map[EventType][]eventSubscriber),
+	}
+	return bus
+}
+
+// Subscribe registers a handler for an event type. The name identifies the subscriber's
+// deliveries, so it must be unique for the event type and stay the same across releases.
+func (b *EventBus) Subscribe(eventType EventType, name string, handler EventHandler) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.subscribers[eventType] = append(b.subscribers[eventType], eventSubscriber{name: name, handler: handler})
+	slog.Info("Event subscriber registered", "type", eventType, "subscriber", name, "subscribers", len(b.subscribers[eventType]))
+}
+
+// Publish stores the event and dispatches it to subscribers asynchronously
+func (b *EventBus) Publish(ctx context.Context, eventType EventType, sessionID string, payload any) error {
+	logger := logging.FromContext(ctx)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	event := Event{
+		Type:       eventType,
+		SessionID:  sessionID,
+		Payload:    data,
+		OccurredAt: time.Now(),
+	}
+
+	if b.repo != nil {
+		record := &models.Event{
+			Type:      string(eventType),
+			SessionID: sessionID,
+			Payload:   string(data),
+			Status:    models.EventStatusPending,
+		}
+		if err := b.repo.CreateEvent(ctx, record); err != nil {
+			// Still deliver in-memory; the event just won't survive a restart
+			logger.Error("Failed to persist event, dispatching in memory only", "type", eventType, "session_id", sessionID, "error", err)
+		} else {
+			event.ID = record.ID
+			event.OccurredAt = record.CreatedAt
+		}
+	}
+
+	logger.Info("Event published", "type", eventType, "session_id", sessionID, "event_id", event.ID)
+
+	b.dispatching.Add(1)
+	go func() {
+		defer b.dispatching.Done()
+		b.dispatch(event)
+	}()
+	return nil
+}
+
+// Wait waits for the deliveries in flight to finish, giving up when ctx is done. Events
+// published meanwhile may not be waited for, but a persisted event that isn't delivered
+// before exit is redelivered after the restart.
+func (b *EventBus) Wait(ctx context.Context) {
+	if !waitGroup(ctx, &b.dispatching) {
+		logging.FromContext(ctx).Warn("Shutdown deadline reached with event deliveries in flight")
+	}
+}
+
+// dispatch delivers an event to every subscriber that hasn't handled it yet, recording each
+// delivery and the outcome
+func (b *EventBus) dispatch(event Event) {
+	b.mutex.RLock()
+	subscribers := append([]eventSubscriber(nil), b.subscribers[event.Type]...)
+	b.mutex.RUnlock()
+
+	ctx := context.Background()
+	persisted := b.repo != nil && event.ID != ""
+	var delivered []string
+	if persisted {
+		var err error
+		if delivered, err = b.repo.GetEventDeliveries(ctx, event.ID); err != nil {
+			// Redelivering to every subscriber would repeat their side effects
+			b.repo.MarkEventFailed(ctx, event.ID, err.Error())
+			return
+		}
+	}
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		if slices.Contains(delivered, subscriber.name) {
+			continue
+		}
+		if err := b.invoke(ctx, subscriber.handler, event); err != nil {
+			slog.Error("Event handler failed", "type", event.Type, "session_id", event.SessionID, "event_id", event.ID, "subscriber", subscriber.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", subscriber.name, err))
+			continue
+		}
+		if persisted {
+			if err := b.repo.CreateEventDelivery(ctx, event.ID, subscriber.name); err != nil {
+				errs = append(errs, fmt.Errorf("%s: failed to record delivery: %w", subscriber.name, err))
+			}
+		}
+	}
+
+	if !persisted {
+		return
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		b.repo.MarkEventFailed(ctx, event.ID, err.Error())
+		return
+	}
+	b.repo.MarkEventProcessed(ctx, event.ID)
+}
+
+// invoke runs a handler, converting panics into errors so one subscriber can't take down the bus
+func (b *EventBus) invoke(ctx context.Context, handler EventHandler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
+
+// StartRetryLoop periodically redelivers failed events and events left pending by a restart
+func (b *EventBus) StartRetryLoop(lifecycle *Lifecycle) {
+	if b.repo == nil {
+		return
+	}
+
+	lifecycle.Go("event redelivery", func(ctx context.Context) {
+		ticker := time.NewTicker(eventRetryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.retryEvents(ctx)
+			}
+		}
+	})
+}
+
+func (b *EventBus) retryEvents(ctx context.Context) {
+	events, err := b.repo.ClaimRetryableEvents(ctx, MaxEventAttempts, eventRetryInterval, eventRetryBatch)
+	if err != nil || len(events) == 0 {
+		return
+	}
+
+	logging.FromContext(ctx).Info("Redelivering events", "count", len(events))
+	for _, record := range events {
+		if ctx.Err() != nil {
+			return
+		}
+		b.dispatch(Event{
+			ID:         record.ID,
+			Type:       EventType(record.Type),
+			SessionID:  record.SessionID,
+			Payload:    json.RawMessage(record.Payload),
+			OccurredAt: record.CreatedAt,
+		})
+	}
+}
+
+// summaryEventContext is everything a summary.generated subscriber needs to evaluate a summary
+type summaryEventContext struct {
+	payload     SummaryGeneratedPayload
+	summary     *models.InterviewSummary
+	agent       *models.Agent
+	transcripts []models.InterviewTranscript
+}
+
+// loadSummaryEventContext resolves a summary.generated event back into database records
+func loadSummaryEventContext(ctx context.Context, repo *repository.GORMRepository, event Event) (*summaryEventContext, error) {
+	var payload SummaryGeneratedPayload
+	if err := event.Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	summary, err := repo.GetInterviewSummary(ctx, event.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil || summary.ID != payload.SummaryID {
+		return nil, fmt.Errorf("summary %s not found for session %s", payload.SummaryID, event.SessionID)
+	}
+
+	agent, err := repo.GetAgent(ctx, payload.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, fmt.Errorf("agent %s not found", payload.AgentID)
+	}
+
+	transcripts, err := repo.GetInterviewTranscripts(ctx, event.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &summaryEventContext{
+		payload:     payload,
+		summary:     summary,
+		agent:       agent,
+		transcripts: transcripts,
+	}, nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"text/template"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// ShadowPromptData is the data available to experiment prompt templates
+type ShadowPromptData struct {
+	AgentName    string
+	Level        string
+	Industry     string
+	Personality  string
+	Conversation string
+}
+
+// ExperimentComparison summarizes shadow results against production for an experiment
+type ExperimentComparison struct {
+	Experiment             models.ScoringExperiment `json:"experiment"`
+	SampleCount            int                      `json:"sample_count"`
+	FailedCount            int                      `json:"failed_count"`
+	AvgProductionScore     float64                  `json:"avg_production_score"`
+	AvgShadowScore         float64                  `json:"avg_shadow_score"`
+	MeanScoreDelta         float64                  `json:"mean_score_delta"`          // shadow - production
+	MeanAbsoluteScoreDelta float64                  `json:"mean_absolute_score_delta"` // |shadow - production|
+	AvgShadowQualityScore  float64                  `json:"avg_shadow_quality_score"`
+	Samples                []ShadowComparisonSample `json:"samples"`
+}
+
+// ShadowComparisonSample pairs a shadow summary with its production summary
+type ShadowComparisonSample struct {
+	SessionID       string  `json:"session_id"`
+	ProductionScore float64 `json:"production_score"`
+	ShadowScore     float64 `json:"shadow_score"`
+	ScoreDelta      float64 `json:"score_delta"`
+	ProductionText  string  `json:"production_summary"`
+	ShadowText      string  `json:"shadow_summary"`
+	QualityScore    float64 `json:"shadow_quality_score"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// ExperimentService runs experiment prompts in shadow mode alongside production summaries
+type ExperimentService struct {
+	repo           *repository.GORMRepository
+	llm            LLMService
+	qualityService *SummaryQualityService
+}
+
+func NewExperimentService(repo *repository.GORMRepository, llm LLMService, qualityService *SummaryQualityService) *ExperimentService {
+	return &ExperimentService{
+		repo:           repo,
+		llm:            llm,
+		qualityService: qualityService,
+	}
+}
+
+// ValidatePromptTemplate checks that an experiment prompt template parses and renders
+func ValidatePromptTemplate(promptTemplate string) error {
+	_, err := renderShadowPrompt(promptTemplate, ShadowPromptData{})
+	return err
+}
+
+func renderShadowPrompt(promptTemplate string, data ShadowPromptData) (string, error) {
+	tmpl, err := template.New("experiment").Option("missingkey=error").Parse(promptTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// inShadowSample deterministically decides whether a session belongs to an experiment's sample
+func inShadowSample(experimentID, sessionID string, sampleRate float64) bool {
+	if sampleRate <= 0 {
+		return false
+	}
+	if sampleRate >= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(experimentID + ":" + sessionID))
+	return float64(h.Sum32()%10000) < sampleRate*10000
+}
+
+// HandleSummaryGenerated runs shadow experiments for summaries published on the event bus
+func (s *ExperimentService) HandleSummaryGenerated(ctx context.Context, event Event) error {
+	summaryCtx, err := loadSummaryEventContext(ctx, s.repo, event)
+	if err != nil {
+		return err
+	}
+
+	s.RunShadow(ctx, summaryCtx.summary, summaryCtx.agent, summaryCtx.transcripts)
+	return nil
+}
+
+// RunShadow generates shadow summaries for every active experiment sampling this session
+func (s *ExperimentService) RunShadow(ctx context.Context, summary *models.InterviewSummary, agent *models.Agent, transcripts []models.InterviewTranscript) {
+	experiments, err := s.repo.GetScoringExperiments(ctx, true)
+	if err != nil || len(experiments) == 0 {
+		return
+	}
+
+	conversationHistory := make([]string, 0, len(transcripts))
+	for _, transcript := range scoredTranscripts(transcripts) {
+		conversationHistory = append(conversationHistory, transcript.Speaker+": "+transcript.Content)
+	}
+
+	data := ShadowPromptData{
+		AgentName:    agent.Name,
+		Level:        agent.Level,
+		Industry:     agent.Industry,
+		Personality:  agent.Personality,
+		Conversation: joinStrings(conversationHistory, "\n"),
+	}
+
+	for _, experiment := range experiments {
+		if !inShadowSample(experiment.ID, summary.SessionID, experiment.SampleRate) {
+			continue
+		}
+
+		exists, err := s.repo.ShadowSummaryExists(ctx, experiment.ID, summary.SessionID)
+		if err != nil || exists {
+			continue
+		}
+
+		s.runExperiment(ctx, experiment, data, summary, agent, transcripts)
+	}
+}
+
+func (s *ExperimentService) runExperiment(ctx context.Context, experiment models.ScoringExperiment, data ShadowPromptData, summary *models.InterviewSummary, agent *models.Agent, transcripts []models.InterviewTranscript) {
+	shadow := &models.ShadowSummary{
+		ExperimentID:        experiment.ID,
+		SessionID:           summary.SessionID,
+		ProductionSummaryID: summary.ID,
+	}
+
+	prompt, err := renderShadowPrompt(experiment.PromptTemplate, data)
+	if err != nil {
+		shadow.Error = err.Error()
+	} else {
+		raw, err := s.llm.GenerateSummary(ctx, prompt)
+		if err != nil {
+			shadow.Error = err.Error()
+		} else {
+			parsed := parseSummaryResponse(raw)
+			shadow.Summary = parsed.Summary
+			shadow.Strengths = parsed.Strengths
+			shadow.Weaknesses = parsed.Weaknesses
+			shadow.Recommendations = parsed.Recommendations
+			shadow.OverallScore = parsed.OverallScore
+
+			candidate := &models.InterviewSummary{
+				SessionID:       summary.SessionID,
+				Summary:         parsed.Summary,
+				Strengths:       parsed.Strengths,
+				Weaknesses:      parsed.Weaknesses,
+				Recommendations: parsed.Recommendations,
+			}
+			shadow.QualityScore = s.qualityService.Evaluate(raw, candidate, agent, transcripts).QualityScore
+		}
+	}
+
+	if err := s.repo.CreateShadowSummary(ctx, shadow); err != nil {
+		return
+	}
+
+	logging.FromContext(ctx).Info("Shadow summary generated",
+		"experiment_id", experiment.ID,
+		"experiment", experiment.Name,
+		"session_id", summary.SessionID,
+		"production_score", summary.OverallScore,
+		"shadow_score", shadow.OverallScore,
+		"error", shadow.Error)
+}
+
+// CompareExperiment builds a production vs shadow comparison for an experiment
+func (s *ExperimentService) CompareExperiment(ctx context.Context, experiment *models.ScoringExperiment, limit int) (*ExperimentComparison, error) {
+	shadows, err := s.repo.GetShadowSummaries(ctx, experiment.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &ExperimentComparison{
+		Experiment: *experiment,
+		Samples:    make([]ShadowComparisonSample, 0, len(shadows)),
+	}
+
+	var productionTotal, shadowTotal, deltaTotal, absDeltaTotal, qualityTotal float64
+	for _, shadow := range shadows {
+		sample := ShadowComparisonSample{
+			SessionID:    shadow.SessionID,
+			ShadowScore:  shadow.OverallScore,
+			ShadowText:   shadow.Summary,
+			QualityScore: shadow.QualityScore,
+			Error:        shadow.Error,
+		}
+		if shadow.ProductionSummary != nil {
+			sample.ProductionScore = shadow.ProductionSummary.OverallScore
+			sample.ProductionText = shadow.ProductionSummary.Summary
+		}
+		comparison.Samples = append(comparison.Samples, sample)
+
+		if shadow.Error != "" || shadow.ProductionSummary == nil {
+			comparison.FailedCount++
+			continue
+		}
+
+		sample.ScoreDelta = sample.ShadowScore - sample.ProductionScore
+		comparison.Samples[len(comparison.Samples)-1].ScoreDelta = sample.ScoreDelta
+		comparison.SampleCount++
+		productionTotal += sample.ProductionScore
+		shadowTotal += sample.ShadowScore
+		deltaTotal += sample.ScoreDelta
+		absDeltaTotal += math.Abs(sample.ScoreDelta)
+		qualityTotal += sample.QualityScore
+	}
+
+	if comparison.SampleCount > 0 {
+		n := float64(comparison.SampleCount)
+		comparison.AvgProductionScore = productionTotal / n
+		comparison.AvgShadowScore = shadowTotal / n
+		comparison.MeanScoreDelta = deltaTotal / n
+		comparison.MeanAbsoluteScoreDelta = absDeltaTotal / n
+		comparison.AvgShadowQualityScore = qualityTotal / n
+	}
+
+	return comparison, nil
+}
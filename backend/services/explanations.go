@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// explanationTimeout bounds a single out-of-band explanation request
+const explanationTimeout = 30 * time.Second
+
+// explanationHistoryTurns is how much recent conversation a question explanation sees
+const explanationHistoryTurns = 6
+
+// ExplanationService captures the model's rationale for each probing question and score.
+// Capture is optional (INTERVIEW_CAPTURE_EXPLANATIONS) because it costs an extra model call
+// per turn; it runs in the background so the interview never waits on it.
+type ExplanationService struct {
+	repo    *repository.GORMRepository
+	llm     LLMService
+	enabled bool
+}
+
+func NewExplanationService(repo *repository.GORMRepository, llm LLMService, enabled bool) *ExplanationService {
+	return &ExplanationService{
+		repo:    repo,
+		llm:     llm,
+		enabled: enabled,
+	}
+}
+
+type explanationResponse struct {
+	Explanations []struct {
+		Subject   string   `json:"subject"`
+		Intent    string   `json:"intent"`
+		Rationale string   `json:"rationale"`
+		Evidence  []string `json:"evidence"`
+	} `json:"explanations"`
+}
+
+// ExplainQuestion records, in the background, why the interviewer asked a question in reply
+// to the candidate's answer
+func (s *ExplanationService) ExplainQuestion(sessionID string, agent *models.Agent, history []models.InterviewTranscript, answer, question string) {
+	if !s.enabled || strings.TrimSpace(question) == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), explanationTimeout)
+		defer cancel()
+
+		prompt := buildQuestionExplanationPrompt(agent, history, answer, question)
+		raw, err := s.llm.GenerateExplanations(ctx, prompt)
+		if err != nil {
+			slog.Warn("Failed to explain question", "session_id", sessionID, "error", err)
+			return
+		}
+
+		explanations := parseExplanations(raw, sessionID, models.ExplanationKindQuestion)
+		// The question is the subject regardless of how the model echoed it
+		if len(explanations) > 1 {
+			explanations = explanations[:1]
+		}
+		for i := range explanations {
+			explanations[i].Subject = question
+		}
+		s.repo.CreateExplanations(ctx, explanations)
+	}()
+}
+
+// HandleSummaryGenerated records why each metric of a finalized summary received its score
+func (s *ExplanationService) HandleSummaryGenerated(ctx context.Context, event Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	// Redelivered events must not explain the same scores twice
+	explained, err := s.repo.HasSessionExplanations(ctx, event.SessionID, models.ExplanationKindScore)
+	if err != nil || explained {
+		return err
+	}
+
+	summaryCtx, err := loadSummaryEventContext(ctx, s.repo, event)
+	if err != nil {
+		return err
+	}
+	scores, err := s.repo.GetPerformanceScores(ctx, event.SessionID)
+	if err != nil {
+		return err
+	}
+
+	prompt := buildScoreExplanationPrompt(summaryCtx.agent, summaryCtx.summary, scores, scoredTranscripts(summaryCtx.transcripts))
+	raw, err := s.llm.GenerateExplanations(ctx, prompt)
+	if err != nil {
+		return fmt.Errorf("explain scores: %w", err)
+	}
+
+	return s.repo.CreateExplanations(ctx, parseExplanations(raw, event.SessionID, models.ExplanationKindScore))
+}
+
+func buildQuestionExplanationPrompt(agent *models.Agent, history []models.InterviewTranscript, answer, question string) string {
+	if len(history) > explanationHistoryTurns {
+		history = history[len(history)-explanationHistoryTurns:]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are %s, interviewing a candidate for a %s %s role.\n", agent.Name, agent.Level, agent.Industry)
+	b.WriteString("Explain briefly, to the candidate, why you asked your latest question. Return exactly one explanation whose subject is the question.\n\n")
+	b.WriteString("Recent conversation:\n")
+	for _, transcript := range history {
+		fmt.Fprintf(&b, "%s: %s\n", transcript.Speaker, transcript.Content)
+	}
+	fmt.Fprintf(&b, "user: %s\n\nYour latest question:\n%s\n", answer, question)
+	return b.String()
+}
+
+func buildScoreExplanationPrompt(agent *models.Agent, summary *models.InterviewSummary, scores []models.PerformanceScore, transcripts []models.InterviewTranscript) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You interviewed a candidate as %s for a %s %s role and scored them as follows.\n", agent.Name, agent.Level, agent.Industry)
+	b.WriteString("For each metric, and for \"Overall\", explain briefly to the candidate why they received that score, citing the conversation. Use the metric name as the subject.\n\n")
+	fmt.Fprintf(&b, "Overall: %.0f/100\n", summary.OverallScore)
+	for _, score := range scores {
+		fmt.Fprintf(&b, "%s: %.0f/%.0f\n", score.Metric, score.Score, score.MaxScore)
+	}
+	b.WriteString("\nConversation:\n")
+	for _, transcript := range transcripts {
+		fmt.Fprintf(&b, "%s: %s\n", transcript.Speaker, transcript.Content)
+	}
+	return b.String()
+}
+
+// parseExplanations converts the model's JSON into explanations, dropping incomplete entries
+func parseExplanations(raw, sessionID, kind string) []models.Explanation {
+	var response explanationResponse
+	if err := json.Unmarshal([]byte(raw), &response); err != nil {
+		slog.Warn("Failed to parse explanations", "session_id", sessionID, "kind", kind, "error", err)
+		return nil
+	}
+
+	explanations := make([]models.Explanation, 0, len(response.Explanations))
+	for _, item := range response.Explanations {
+		if strings.TrimSpace(item.Subject) == "" || strings.TrimSpace(item.Rationale) == "" {
+			continue
+		}
+		evidence, err := json.Marshal(item.Evidence)
+		if err != nil || item.Evidence == nil {
+			evidence = []byte("[]")
+		}
+		intent := []rune(strings.TrimSpace(item.Intent))
+		if len(intent) > 100 {
+			intent = intent[:100]
+		}
+		explanations = append(explanations, models.Explanation{
+			SessionID: sessionID,
+			Kind:      kind,
+			Subject:   strings.TrimSpace(item.Subject),
+			Intent:    string(intent),
+			Rationale: strings.TrimSpace(item.Rationale),
+			Evidence:  string(evidence),
+			Model:     ModelName,
+		})
+	}
+	return explanations
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+type ExportEndpoints struct {
+	repo    *repository.GORMRepository
+	exports *ExportJobService
+}
+
+func NewExportEndpoints(repo *repository.GORMRepository, exports *ExportJobService) *ExportEndpoints {
+	return &ExportEndpoints{
+		repo:    repo,
+		exports: exports,
+	}
+}
+
+// RegisterRoutes registers the routes flat rather than mounting /exports, which would shadow
+// the public download route
+func (e *ExportEndpoints) RegisterRoutes(r chi.Router) {
+	r.Post("/exports", e.CreateExportHandler)
+	r.Get("/exports", e.GetExportsHandler)
+	r.Get("/exports/{id}", e.GetExportHandler)
+}
+
+// RegisterPublicRoutes registers the download route, which the URL's signature authorizes so
+// the link works from a plain browser tab or download manager
+func (e *ExportEndpoints) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/exports/{id}/download", e.DownloadExportHandler)
+}
+
+// CreateExportHandler queues an export and returns it for polling. kind=sessions bundles
+// session reports in a format, kind=account archives all of the user's data as JSON.
+func (e *ExportEndpoints) CreateExportHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req ExportRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	job, err := e.exports.Enqueue(r.Context(), user, req)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/exports/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"export": e.exports.Status(job),
+	})
+}
+
+func (e *ExportEndpoints) GetExportsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	jobs, err := e.repo.GetUserExportJobs(r.Context(), user.ID, exportJobHistory)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get exports"))
+		return
+	}
+
+	exports := make([]ExportJobStatus, len(jobs))
+	for i := range jobs {
+		exports[i] = e.exports.Status(&jobs[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"exports": exports,
+		"count":   len(exports),
+	})
+}
+
+// GetExportHandler returns an export's progress, and a signed download URL once it is done.
+// Each poll signs a fresh URL, so an expired link is replaced by fetching the export again.
+func (e *ExportEndpoints) GetExportHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	job, err := e.repo.GetExportJob(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get export"))
+		return
+	}
+	if job == nil || job.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Export not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if job.Status == models.ExportJobPending || job.Status == models.ExportJobRunning {
+		w.Header().Set("Retry-After", strconv.Itoa(int(exportJobPollInterval/time.Second)))
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"export": e.exports.Status(job),
+	})
+}
+
+// DownloadExportHandler streams a finished export's archive from the blob storage
+func (e *ExportEndpoints) DownloadExportHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	jobID := chi.URLParam(r, "id")
+	query := r.URL.Query()
+	if err := e.exports.VerifyDownload(jobID, query.Get("expires"), query.Get("signature"), time.Now()); err != nil {
+		apperrors.Write(w, r, apperrors.Forbidden("Download link is invalid or has expired"))
+		return
+	}
+
+	job, err := e.repo.GetExportJob(r.Context(), jobID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get export"))
+		return
+	}
+	if job == nil || job.Status == models.ExportJobExpired {
+		apperrors.Write(w, r, apperrors.Gone("Export has expired"))
+		return
+	}
+	if job.Status != models.ExportJobDone {
+		apperrors.Write(w, r, apperrors.NotFound("Export not found"))
+		return
+	}
+
+	data, err := e.exports.Archive(r.Context(), job)
+	if err != nil {
+		logger.Error("Failed to read export archive", "error", err, "job_id", job.ID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to read export"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, ArchiveFilename(job)))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(data)
+
+	logger.Info("Export downloaded", "job_id", job.ID, "user_id", job.UserID)
+}
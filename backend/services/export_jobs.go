@@ -0,0 +1,506 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// exportJobPollInterval is how often idle workers look for queued exports
+	exportJobPollInterval = 10 * time.Second
+	// exportCleanupInterval is how often expired archives are deleted
+	exportCleanupInterval = 10 * time.Minute
+	exportCleanupBatch    = 100
+	exportMaxAttempts     = 3
+	// maxOpenExportJobs caps a user's queued and running exports
+	maxOpenExportJobs = 3
+	// exportJobHistory is how many of a user's exports are listed
+	exportJobHistory = 20
+)
+
+var (
+	// ErrInvalidExportSignature is returned for a download URL that is forged, altered or expired
+	ErrInvalidExportSignature = errors.New("invalid or expired download link")
+	// errExportNotPossible fails a job without retrying, as another attempt can't succeed
+	errExportNotPossible = errors.New("export cannot be built")
+)
+
+// ExportRequest is the body for queuing an export
+type ExportRequest struct {
+	Kind       string   `json:"kind" validate:"required,oneof=sessions account"`
+	Format     string   `json:"format,omitempty"`      // Report format of a sessions bundle: pdf (the default), md or json
+	SessionIDs []string `json:"session_ids,omitempty"` // Sessions to bundle; all completed sessions when omitted
+}
+
+// ExportJobStatus is an export job as its owner polls it, with a download URL once it is ready
+type ExportJobStatus struct {
+	*models.ExportJob
+	Percent        float64    `json:"percent"`
+	DownloadURL    string     `json:"download_url,omitempty"`
+	URLExpiresAt   *time.Time `json:"url_expires_at,omitempty"`
+	ArchiveExpired bool       `json:"archive_expired,omitempty"`
+}
+
+// ExportJobService builds export archives in the background, so a bundle of many reports or a
+// whole account's data never holds up a request. Archives are kept in the blob storage until
+// their retention passes, and downloaded through signed URLs that expire.
+type ExportJobService struct {
+	repo      *repository.GORMRepository
+	store     BlobStore
+	class     string
+	branding  *BrandingService
+	config    ExportConfig
+	secret    []byte
+	publicURL string
+	wake      chan struct{} // Nudges an idle worker when an export is queued
+}
+
+func NewExportJobService(repo *repository.GORMRepository, store BlobStore, storage StorageConfig, branding *BrandingService, config ExportConfig, jwtSecret, publicURL string) *ExportJobService {
+	config.Workers = max(config.Workers, 1)
+	config.MaxSessions = max(config.MaxSessions, 1)
+	class, _ := store.DefaultClasses()
+	if storage.HotClass != "" {
+		class = storage.HotClass
+	}
+	// Download links stay valid across restarts, and rotating the JWT secret revokes them
+	secret := sha256.Sum256([]byte("praxis-export:" + jwtSecret))
+	return &ExportJobService{
+		repo:      repo,
+		store:     store,
+		class:     class,
+		branding:  branding,
+		config:    config,
+		secret:    secret[:],
+		publicURL: strings.TrimRight(publicURL, "/"),
+		wake:      make(chan struct{}, config.Workers),
+	}
+}
+
+// Start requeues exports interrupted by the last shutdown, and starts the workers and the job
+// deleting expired archives. An export being built at shutdown goes back in the queue.
+func (s *ExportJobService) Start(lifecycle *Lifecycle) {
+	if requeued, err := s.repo.RequeueRunningExportJobs(context.Background()); err == nil && requeued > 0 {
+		slog.Info("Requeued interrupted export jobs", "count", requeued)
+	}
+
+	for i := 0; i < s.config.Workers; i++ {
+		lifecycle.Go("export worker", func(ctx context.Context) {
+			ticker := time.NewTicker(exportJobPollInterval)
+			defer ticker.Stop()
+
+			for {
+				for ctx.Err() == nil && s.runNext(ctx) {
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.wake:
+				case <-ticker.C:
+				}
+			}
+		})
+	}
+
+	lifecycle.Go("export cleanup", func(ctx context.Context) {
+		ticker := time.NewTicker(exportCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			s.deleteExpired(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+	slog.Info("Export job workers started", "workers", s.config.Workers, "retention", s.config.Retention)
+}
+
+// Enqueue validates an export request and queues it for the user. Invalid requests return an
+// *apperrors.Error. A user has one account export open at a time; asking for another returns it.
+func (s *ExportJobService) Enqueue(ctx context.Context, user *models.User, req ExportRequest) (*models.ExportJob, error) {
+	job, _, err := s.enqueue(ctx, user, req)
+	return job, err
+}
+
+// enqueue is Enqueue, also reporting whether a job was queued
+func (s *ExportJobService) enqueue(ctx context.Context, user *models.User, req ExportRequest) (*models.ExportJob, bool, error) {
+	job := &models.ExportJob{
+		UserID: user.ID,
+		Kind:   req.Kind,
+		Format: ExportFormatJSON,
+		Status: models.ExportJobPending,
+	}
+	if req.Kind == models.ExportKindSessions {
+		job.Format = req.Format
+		if job.Format == "" {
+			job.Format = ExportFormatPDF
+		}
+		if job.Format != ExportFormatPDF && job.Format != ExportFormatMarkdown && job.Format != ExportFormatJSON {
+			return nil, false, apperrors.BadRequest("format must be pdf, md or json")
+		}
+		if len(req.SessionIDs) > s.config.MaxSessions {
+			return nil, false, apperrors.BadRequest(fmt.Sprintf("at most %d sessions can be exported at once", s.config.MaxSessions))
+		}
+		ids := slices.Compact(slices.Sorted(slices.Values(req.SessionIDs)))
+		for _, id := range ids {
+			if id == "" || strings.Contains(id, ",") {
+				return nil, false, apperrors.BadRequest("session_ids must be session IDs")
+			}
+		}
+		job.SessionIDs = strings.Join(ids, ",")
+	}
+
+	open, err := s.repo.CountOpenExportJobs(ctx, user.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if open >= maxOpenExportJobs {
+		return nil, false, apperrors.TooManyRequests(fmt.Sprintf("at most %d exports can be queued at once; wait for one to finish", maxOpenExportJobs))
+	}
+
+	if job.Kind == models.ExportKindAccount {
+		// Requests racing to queue an account export get the same job
+		existing, created, err := s.repo.CreateAccountExportJob(ctx, job)
+		if err != nil || !created {
+			return existing, false, err
+		}
+	} else if err := s.repo.CreateExportJob(ctx, job); err != nil {
+		return nil, false, err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	logging.FromContext(ctx).Info("Export job queued", "job_id", job.ID, "user_id", user.ID, "kind", job.Kind, "format", job.Format)
+	return job, true, nil
+}
+
+// LatestAccountExport returns the user's latest account export, or nil if they never asked for
+// one
+func (s *ExportJobService) LatestAccountExport(ctx context.Context, user *models.User) (*models.ExportJob, error) {
+	return s.repo.GetLatestExportJob(ctx, user.ID, models.ExportKindAccount)
+}
+
+// AccountExport returns the user's account export that is queued, being built or ready to
+// download, queuing a new one when there is none. queued reports whether it was just queued.
+func (s *ExportJobService) AccountExport(ctx context.Context, user *models.User) (job *models.ExportJob, queued bool, err error) {
+	job, err = s.LatestAccountExport(ctx, user)
+	if err != nil {
+		return nil, false, err
+	}
+	if job != nil && job.Status != models.ExportJobFailed && job.Status != models.ExportJobExpired {
+		return job, false, nil
+	}
+	return s.enqueue(ctx, user, ExportRequest{Kind: models.ExportKindAccount})
+}
+
+// Status returns a job with its progress, and a fresh signed download URL once it is done
+func (s *ExportJobService) Status(job *models.ExportJob) ExportJobStatus {
+	status := ExportJobStatus{ExportJob: job}
+	if job.Total > 0 {
+		status.Percent = float64(job.Progress) * 100 / float64(job.Total)
+	}
+	switch job.Status {
+	case models.ExportJobDone:
+		status.Percent = 100
+		expiresAt := time.Now().Add(s.config.URLTTL)
+		if job.ExpiresAt != nil && job.ExpiresAt.Before(expiresAt) {
+			expiresAt = *job.ExpiresAt
+		}
+		status.DownloadURL = s.DownloadURL(job.ID, expiresAt)
+		status.URLExpiresAt = &expiresAt
+	case models.ExportJobExpired:
+		status.ArchiveExpired = true
+	}
+	return status
+}
+
+// DownloadURL returns a link to a job's archive, signed until expiresAt
+func (s *ExportJobService) DownloadURL(jobID string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.sign(jobID, expires)},
+	}
+	return s.publicURL + "/api/v1/exports/" + url.PathEscape(jobID) + "/download?" + query.Encode()
+}
+
+// VerifyDownload checks a download link's signature and expiry
+func (s *ExportJobService) VerifyDownload(jobID, expires, signature string, now time.Time) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return ErrInvalidExportSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(jobID, expires))) {
+		return ErrInvalidExportSignature
+	}
+	return nil
+}
+
+func (s *ExportJobService) sign(jobID, expires string) string {
+	return signDigest(string(s.secret), []byte(jobID+":"+expires))
+}
+
+// Archive returns a finished job's archive from the blob storage
+func (s *ExportJobService) Archive(ctx context.Context, job *models.ExportJob) ([]byte, error) {
+	return s.store.Get(ctx, job.BlobKey, s.class)
+}
+
+// ArchiveFilename is the name a job's archive downloads as
+func ArchiveFilename(job *models.ExportJob) string {
+	if job.Kind == models.ExportKindAccount {
+		return fmt.Sprintf("praxis-account-%s.zip", job.CreatedAt.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("praxis-interviews-%s-%s.zip", job.Format, job.CreatedAt.Format("2006-01-02"))
+}
+
+// runNext claims and builds one queued export, reporting whether there was one
+func (s *ExportJobService) runNext(ctx context.Context) bool {
+	logger := logging.FromContext(ctx)
+	job, err := s.repo.ClaimExportJob(ctx)
+	if err != nil || job == nil {
+		return false
+	}
+
+	err = s.build(ctx, job)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		logger.Warn("Export job aborted, returning it to the queue", "job_id", job.ID)
+		s.repo.ReleaseExportJob(context.WithoutCancel(ctx), job.ID)
+		return false
+	case err == nil:
+	case errors.Is(err, errExportNotPossible) || job.Attempts >= exportMaxAttempts:
+		logger.Error("Export job failed", "job_id", job.ID, "user_id", job.UserID, "attempts", job.Attempts, "error", err)
+		s.repo.FailExportJob(ctx, job.ID, err.Error(), false)
+	default:
+		logger.Warn("Export attempt failed, retrying", "job_id", job.ID, "attempts", job.Attempts, "error", err)
+		s.repo.FailExportJob(ctx, job.ID, err.Error(), true)
+	}
+	return true
+}
+
+// build writes a job's archive to the blob storage, recording progress as it goes
+func (s *ExportJobService) build(ctx context.Context, job *models.ExportJob) error {
+	user, err := s.repo.GetUserByID(ctx, job.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("%w: user not found", errExportNotPossible)
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	if job.Kind == models.ExportKindAccount {
+		err = s.writeAccount(ctx, job, user, archive)
+	} else {
+		err = s.writeSessions(ctx, job, user, archive)
+	}
+	if err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	key := fmt.Sprintf("exports/%s/%s.zip", job.UserID, job.ID)
+	if err := s.store.Put(ctx, key, s.class, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+	if err := s.repo.CompleteExportJob(ctx, job.ID, key, int64(buf.Len()), time.Now().Add(s.config.Retention)); err != nil {
+		s.store.Delete(context.WithoutCancel(ctx), key, s.class)
+		return err
+	}
+	logging.FromContext(ctx).Info("Export job done", "job_id", job.ID, "user_id", job.UserID, "kind", job.Kind, "bytes", buf.Len())
+	return nil
+}
+
+// exportSessionIDs returns the sessions a job covers: those it names that the user owns, or all
+// the user's completed sessions
+func (s *ExportJobService) exportSessionIDs(ctx context.Context, job *models.ExportJob) ([]string, error) {
+	sessions, err := s.repo.GetInterviewSessions(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	requested := map[string]bool{}
+	for _, id := range strings.Split(job.SessionIDs, ",") {
+		if id != "" {
+			requested[id] = true
+		}
+	}
+
+	var ids []string
+	for _, session := range sessions {
+		if len(requested) > 0 && requested[session.ID] || len(requested) == 0 && session.Status == "completed" {
+			ids = append(ids, session.ID)
+		}
+	}
+	if len(ids) > s.config.MaxSessions {
+		ids = ids[:s.config.MaxSessions]
+	}
+	return ids, nil
+}
+
+// writeSessions writes a report of each session in the job's format, branded like a single
+// report export
+func (s *ExportJobService) writeSessions(ctx context.Context, job *models.ExportJob, user *models.User, archive *zip.Writer) error {
+	ids, err := s.exportSessionIDs(ctx, job)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("%w: no sessions to export", errExportNotPossible)
+	}
+	s.repo.SetExportJobProgress(ctx, job.ID, 0, len(ids))
+
+	candidate := user.FullName
+	if candidate == "" {
+		candidate = user.Email
+	}
+	format := UserLocaleFormat(user)
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		session, err := s.repo.GetInterviewSessionWithDetails(ctx, id, user.ID)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			continue
+		}
+		report, err := BuildSessionReport(session, candidate, format)
+		if err != nil {
+			return fmt.Errorf("%w: session %s: %v", errExportNotPossible, id, err)
+		}
+
+		var data []byte
+		switch job.Format {
+		case ExportFormatPDF:
+			branding, err := s.branding.ForAgent(ctx, &session.Agent)
+			if err != nil {
+				return err
+			}
+			data = renderReportPDF(report, format, s.branding.Brand(ctx, branding))
+		case ExportFormatMarkdown:
+			data = []byte(RenderReportMarkdown(report, format))
+		default:
+			if data, err = json.MarshalIndent(report, "", "  "); err != nil {
+				return err
+			}
+		}
+		name := fmt.Sprintf("%s-%s.%s", session.StartedAt.Format("2006-01-02"), session.ID, job.Format)
+		if err := writeArchiveFile(archive, name, data); err != nil {
+			return err
+		}
+		if (i+1)%10 == 0 || i+1 == len(ids) {
+			s.repo.SetExportJobProgress(ctx, job.ID, i+1, len(ids))
+		}
+	}
+	return nil
+}
+
+// writeAccount writes the user's profile, their own agents, and every session with its
+// transcript, summary and scores as JSON
+func (s *ExportJobService) writeAccount(ctx context.Context, job *models.ExportJob, user *models.User, archive *zip.Writer) error {
+	sessions, err := s.repo.GetInterviewSessions(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	agents, err := s.repo.GetAgents(ctx, user.ID, false)
+	if err != nil {
+		return err
+	}
+	total := len(sessions) + 2
+	s.repo.SetExportJobProgress(ctx, job.ID, 0, total)
+
+	profile, err := json.MarshalIndent(user, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeArchiveFile(archive, "profile.json", profile); err != nil {
+		return err
+	}
+	agentData, err := json.MarshalIndent(agents, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeArchiveFile(archive, "agents.json", agentData); err != nil {
+		return err
+	}
+
+	for i, listed := range sessions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		session, err := s.repo.GetInterviewSessionWithDetails(ctx, listed.ID, user.ID)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			continue
+		}
+		data, err := json.MarshalIndent(session, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeArchiveFile(archive, "sessions/"+session.ID+".json", data); err != nil {
+			return err
+		}
+		if (i+1)%10 == 0 {
+			s.repo.SetExportJobProgress(ctx, job.ID, i+3, total)
+		}
+	}
+	s.repo.SetExportJobProgress(ctx, job.ID, total, total)
+	return nil
+}
+
+func writeArchiveFile(archive *zip.Writer, name string, data []byte) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// deleteExpired deletes archives past their retention
+func (s *ExportJobService) deleteExpired(ctx context.Context) {
+	jobs, err := s.repo.GetExpiredExportJobs(ctx, exportCleanupBatch)
+	if err != nil {
+		return
+	}
+	expired := 0
+	for _, job := range jobs {
+		if err := s.store.Delete(ctx, job.BlobKey, s.class); err != nil {
+			continue
+		}
+		if s.repo.ExpireExportJob(ctx, job.ID) == nil {
+			expired++
+		}
+	}
+	if expired > 0 {
+		logging.FromContext(ctx).Info("Deleted expired export archives", "count", expired)
+	}
+}
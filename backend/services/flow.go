@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const (
+	maxFlowStages        = 20
+	maxFlowDirectiveSize = 2000
+
+	// stageCompleteMarker is appended by the interviewer to signal that a stage's goals are met
+	stageCompleteMarker = "[STAGE_COMPLETE]"
+)
+
+// FlowEngine runs each session through its agent's interview flow, advancing stages on turn
+// counts, time boxes and interviewer signals and handing the current stage's directive to the LLM
+type FlowEngine struct {
+	llm      LLMService
+	sessions map[string]*flowState
+	mutex    sync.Mutex
+}
+
+// flowState is where a session is in its flow
+type flowState struct {
+	flow         *models.InterviewFlow
+	stage        *models.FlowStage
+	begun        bool // The stage clock starts with the first scored turn, after any warm-up
+	stageStarted time.Time
+	stageTurns   int
+	signalled    bool // The interviewer marked the stage as covered
+	timer        *time.Timer
+	// onTimeUp speaks the transition line when a stage's time box runs out between turns
+	onTimeUp func(stage *models.FlowStage, transition string)
+}
+
+func NewFlowEngine(llm LLMService) *FlowEngine {
+	return &FlowEngine{
+		llm:      llm,
+		sessions: make(map[string]*flowState),
+	}
+}
+
+// ParseFlow decodes and validates an agent's interview flow
+func ParseFlow(raw string) (*models.InterviewFlow, error) {
+	var flow models.InterviewFlow
+	if err := json.Unmarshal([]byte(raw), &flow); err != nil {
+		return nil, fmt.Errorf("invalid flow: %w", err)
+	}
+	if err := ValidateFlow(&flow); err != nil {
+		return nil, err
+	}
+	return &flow, nil
+}
+
+// ValidateFlow checks that stages are uniquely named, every Next exists, and that following
+// Next from the start visits every stage once and ends, so a session can't loop forever
+func ValidateFlow(flow *models.InterviewFlow) error {
+	if len(flow.Stages) == 0 {
+		return fmt.Errorf("flow must have at least one stage")
+	}
+	if len(flow.Stages) > maxFlowStages {
+		return fmt.Errorf("flow can have at most %d stages", maxFlowStages)
+	}
+
+	stages := make(map[string]*models.FlowStage, len(flow.Stages))
+	for i := range flow.Stages {
+		stage := &flow.Stages[i]
+		if stage.ID == "" {
+			return fmt.Errorf("stage %d has no id", i+1)
+		}
+		if _, exists := stages[stage.ID]; exists {
+			return fmt.Errorf("duplicate stage id %q", stage.ID)
+		}
+		if strings.TrimSpace(stage.Directive) == "" {
+			return fmt.Errorf("stage %q has no directive", stage.ID)
+		}
+		if len(stage.Directive) > maxFlowDirectiveSize {
+			return fmt.Errorf("stage %q directive is longer than %d characters", stage.ID, maxFlowDirectiveSize)
+		}
+		if stage.MaxTurns < 0 || stage.MaxSeconds < 0 {
+			return fmt.Errorf("stage %q has a negative budget", stage.ID)
+		}
+		stages[stage.ID] = stage
+	}
+
+	start := flowStart(flow)
+	if _, exists := stages[start]; !exists {
+		return fmt.Errorf("start stage %q not found", start)
+	}
+
+	visited := make(map[string]bool, len(stages))
+	for id := start; id != ""; id = stages[id].Next {
+		stage, exists := stages[id]
+		if !exists {
+			return fmt.Errorf("next stage %q not found", id)
+		}
+		if visited[id] {
+			return fmt.Errorf("flow loops back to stage %q", id)
+		}
+		visited[id] = true
+		if stage.Next != "" && stage.MaxTurns == 0 && stage.MaxSeconds == 0 && !stage.AdvanceOnSignal {
+			return fmt.Errorf("stage %q needs max_turns, max_seconds or advance_on_signal to advance", id)
+		}
+	}
+	if len(visited) != len(stages) {
+		return fmt.Errorf("flow has stages that are never reached")
+	}
+	return nil
+}
+
+// flowStart returns the ID of a flow's first stage
+func flowStart(flow *models.InterviewFlow) string {
+	if flow.Start != "" {
+		return flow.Start
+	}
+	return flow.Stages[0].ID
+}
+
+// flowStage returns the stage with the given ID
+func flowStage(flow *models.InterviewFlow, id string) *models.FlowStage {
+	for i := range flow.Stages {
+		if flow.Stages[i].ID == id {
+			return &flow.Stages[i]
+		}
+	}
+	return nil
+}
+
+// Start puts a session at the first stage of its agent's flow; onTimeUp is called when a
+// stage's time box runs out. A session that is already running keeps its stage and only
+// takes the new callback, e.g. after a reconnect. Agents without a flow use the interviewer's
+// default approach.
+func (f *FlowEngine) Start(sessionID string, agent *models.Agent, onTimeUp func(stage *models.FlowStage, transition string)) {
+	if agent.Flow == nil {
+		return
+	}
+
+	f.mutex.Lock()
+	if state, exists := f.sessions[sessionID]; exists {
+		state.onTimeUp = onTimeUp
+		f.mutex.Unlock()
+		return
+	}
+	f.mutex.Unlock()
+
+	flow, err := ParseFlow(*agent.Flow)
+	if err != nil {
+		slog.Error("Agent has an invalid flow, using the default approach", "error", err, "agent_id", agent.ID)
+		return
+	}
+
+	f.mutex.Lock()
+	state := &flowState{
+		flow:     flow,
+		stage:    flowStage(flow, flowStart(flow)),
+		onTimeUp: onTimeUp,
+	}
+	f.sessions[sessionID] = state
+	directive := stageDirective(state)
+	f.mutex.Unlock()
+
+	f.llm.SetStageDirective(sessionID, directive)
+	slog.Info("Interview flow started", "session_id", sessionID, "agent_id", agent.ID, "stage", state.stage.ID)
+}
+
+// Begin starts the clock of the first stage once the scored interview begins
+func (f *FlowEngine) Begin(sessionID string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if state, exists := f.sessions[sessionID]; exists && !state.begun {
+		f.beginStage(sessionID, state)
+	}
+}
+
+// Stage returns the ID of the session's current stage for tagging transcripts, or "" when the
+// session has no flow or its interview hasn't begun
+func (f *FlowEngine) Stage(sessionID string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if state, exists := f.sessions[sessionID]; exists && state.begun {
+		return state.stage.ID
+	}
+	return ""
+}
+
+// Advance records a candidate answer and moves to the next stage once the current stage's
+// turn or time budget is spent. It returns the transition line to speak before the reply,
+// or "" when the stage didn't change.
+func (f *FlowEngine) Advance(sessionID string) string {
+	f.mutex.Lock()
+	state, exists := f.sessions[sessionID]
+	if !exists {
+		f.mutex.Unlock()
+		return ""
+	}
+	if !state.begun {
+		f.beginStage(sessionID, state)
+	}
+
+	state.stageTurns++
+	if state.stage.Next == "" || !stageExhausted(state) {
+		f.mutex.Unlock()
+		return ""
+	}
+	from, trigger := state.stage, "turns"
+	if state.signalled {
+		trigger = "signal"
+	}
+	transition, directive := f.enterStage(sessionID, state)
+	f.mutex.Unlock()
+
+	f.llm.SetStageDirective(sessionID, directive)
+	slog.Info("Interview flow advanced", "session_id", sessionID, "from", from.ID, "to", state.stage.ID, "trigger", trigger)
+	return transition
+}
+
+// Signal strips the stage-complete marker from an interviewer reply. When the current stage
+// advances on signal, the marker ends the stage after the candidate's next answer.
+func (f *FlowEngine) Signal(sessionID, reply string) string {
+	if !strings.Contains(reply, stageCompleteMarker) {
+		return reply
+	}
+	reply = strings.TrimSpace(strings.ReplaceAll(reply, stageCompleteMarker, ""))
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if state, exists := f.sessions[sessionID]; exists && state.begun && state.stage.AdvanceOnSignal && !state.signalled {
+		state.signalled = true
+		slog.Info("Interviewer signalled stage complete", "session_id", sessionID, "stage", state.stage.ID)
+	}
+	return reply
+}
+
+// expire moves a session on when its stage's time box runs out
+func (f *FlowEngine) expire(sessionID, stageID string) {
+	f.mutex.Lock()
+	state, exists := f.sessions[sessionID]
+	if !exists || state.stage.ID != stageID || state.stage.Next == "" {
+		f.mutex.Unlock()
+		return
+	}
+	transition, directive := f.enterStage(sessionID, state)
+	stage, onTimeUp := state.stage, state.onTimeUp
+	f.mutex.Unlock()
+
+	f.llm.SetStageDirective(sessionID, directive)
+	slog.Info("Interview flow advanced", "session_id", sessionID, "from", stageID, "to", stage.ID, "trigger", "time")
+	if onTimeUp != nil {
+		onTimeUp(stage, transition)
+	}
+}
+
+// enterStage moves a session to the next stage and returns its transition line and directive.
+// The caller holds the mutex.
+func (f *FlowEngine) enterStage(sessionID string, state *flowState) (string, string) {
+	state.stage = flowStage(state.flow, state.stage.Next)
+	f.beginStage(sessionID, state)
+
+	transition := state.stage.Transition
+	if transition == "" {
+		transition = fmt.Sprintf("Let's switch to %s now.", stageName(state.stage))
+	}
+	return transition, stageDirective(state)
+}
+
+// beginStage starts the clock of the current stage and arms its time box. The caller holds
+// the mutex.
+func (f *FlowEngine) beginStage(sessionID string, state *flowState) {
+	state.begun = true
+	state.stageStarted = time.Now()
+	state.stageTurns = 0
+	state.signalled = false
+
+	if state.timer != nil {
+		state.timer.Stop()
+		state.timer = nil
+	}
+	stage := state.stage
+	if stage.MaxSeconds > 0 && stage.Next != "" {
+		state.timer = time.AfterFunc(time.Duration(stage.MaxSeconds)*time.Second, func() {
+			f.expire(sessionID, stage.ID)
+		})
+	}
+}
+
+// stageExhausted reports whether the current stage has used its turn or time budget, or the
+// interviewer signalled it is covered
+func stageExhausted(state *flowState) bool {
+	if state.signalled {
+		return true
+	}
+	stage := state.stage
+	if stage.MaxTurns > 0 && state.stageTurns >= stage.MaxTurns {
+		return true
+	}
+	return stage.MaxSeconds > 0 && time.Since(state.stageStarted) >= time.Duration(stage.MaxSeconds)*time.Second
+}
+
+// stageDirective is the prompt section for the session's current stage
+func stageDirective(state *flowState) string {
+	stage := state.stage
+	position := 0
+	for id := flowStart(state.flow); id != ""; id = flowStage(state.flow, id).Next {
+		position++
+		if id == stage.ID {
+			break
+		}
+	}
+
+	var directive strings.Builder
+	fmt.Fprintf(&directive, "CURRENT STAGE (%d of %d): %s\n%s", position, len(state.flow.Stages), stageName(stage), stage.Directive)
+	if stage.Next != "" {
+		fmt.Fprintf(&directive, "\n\nStay within this stage. The interview will move on to %s afterwards; do not start it yourself.", stageName(flowStage(state.flow, stage.Next)))
+		if stage.AdvanceOnSignal {
+			fmt.Fprintf(&directive, " Once the goals of this stage are covered, end your reply with %s and the interview will move on after the candidate's next answer.", stageCompleteMarker)
+		}
+	} else {
+		directive.WriteString("\n\nThis is the final stage of the interview.")
+	}
+	return directive.String()
+}
+
+// stageName returns a stage's display name
+func stageName(stage *models.FlowStage) string {
+	if stage.Name != "" {
+		return stage.Name
+	}
+	return stage.ID
+}
+
+// HandleSessionConcluded forgets the flow state of a finished session
+func (f *FlowEngine) HandleSessionConcluded(ctx context.Context, event Event) error {
+	f.mutex.Lock()
+	state, exists := f.sessions[event.SessionID]
+	if exists && state.timer != nil {
+		state.timer.Stop()
+	}
+	delete(f.sessions, event.SessionID)
+	f.mutex.Unlock()
+
+	if exists {
+		f.llm.SetStageDirective(event.SessionID, "")
+	}
+	return nil
+}
+
+// summaryConversation formats transcripts for a summary prompt, marking where each flow
+// stage begins so the model can score stages independently. Code submissions are fenced with
+// their language and followed by the analysis that replied to them.
+func summaryConversation(transcripts []models.InterviewTranscript) []string {
+	conversation := make([]string, 0, len(transcripts))
+	stage := ""
+	for _, transcript := range transcripts {
+		if transcript.Stage != "" && transcript.Stage != stage {
+			stage = transcript.Stage
+			conversation = append(conversation, fmt.Sprintf("--- STAGE: %s ---", stage))
+		}
+		switch {
+		case transcript.Kind == models.TranscriptKindCode:
+			conversation = append(conversation, fmt.Sprintf("%s (code submission, %s):\n```%s\n%s\n```",
+				transcript.Speaker, transcript.Language, transcript.Language, transcript.Content))
+		case transcript.ReplyToID != nil:
+			conversation = append(conversation, transcript.Speaker+" (analysis of the code above): "+transcript.Content)
+		default:
+			conversation = append(conversation, transcript.Speaker+": "+transcript.Content)
+		}
+	}
+	return conversation
+}
+
+// stageScoringInstruction asks the model to score each flow stage, or returns "" when the
+// transcripts have no stages
+func stageScoringInstruction(transcripts []models.InterviewTranscript) string {
+	var stages []string
+	seen := make(map[string]bool)
+	for _, transcript := range transcripts {
+		if transcript.Stage != "" && !seen[transcript.Stage] {
+			seen[transcript.Stage] = true
+			stages = append(stages, transcript.Stage)
+		}
+	}
+	if len(stages) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`
+
+The interview was run in stages, marked "--- STAGE: id ---" in the conversation. In addition to the overall score, score each stage independently (0-100) in stageScores, judging only the turns within that stage. Stages: %s`, strings.Join(stages, ", "))
+}
+
+// stagePerformanceScores turns the model's per-stage scores into performance scores, e.g.
+// "Stage: behavioral", so scoring policies can weight or gate individual stages
+func stagePerformanceScores(sessionID string, summary ParsedSummary) []models.PerformanceScore {
+	scores := make([]models.PerformanceScore, 0, len(summary.StageScores))
+	for _, stage := range summary.StageScores {
+		scores = append(scores, models.PerformanceScore{
+			SessionID: sessionID,
+			Metric:    "Stage: " + stage.Stage,
+			Score:     math.Max(0, math.Min(100, stage.Score)),
+			MaxScore:  100.0,
+		})
+	}
+	return scores
+}
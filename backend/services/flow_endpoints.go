@@ -0,0 +1,85 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// GetFlowHandler returns the interview flow attached to an agent, or null when it has none
+func (e *AgentEndpoints) GetFlowHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	var flow *models.InterviewFlow
+	if agent.Flow != nil {
+		if err := json.Unmarshal([]byte(*agent.Flow), &flow); err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to decode flow"))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id": agent.ID,
+		"flow":     flow,
+	})
+}
+
+// PutFlowHandler attaches an interview flow to an agent. Sessions already running keep the
+// flow they started with.
+func (e *AgentEndpoints) PutFlowHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	var flow models.InterviewFlow
+	if err := json.NewDecoder(r.Body).Decode(&flow); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if err := ValidateFlow(&flow); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	encoded, err := json.Marshal(flow)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to encode flow"))
+		return
+	}
+	raw := string(encoded)
+	if err := e.repo.SetAgentFlow(r.Context(), agent.ID, &raw); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to save flow"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Agent flow updated", "agent_id", agent.ID, "stages", len(flow.Stages))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id": agent.ID,
+		"flow":     flow,
+	})
+}
+
+// DeleteFlowHandler detaches an agent's flow, reverting to the default interview approach
+func (e *AgentEndpoints) DeleteFlowHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.repo.SetAgentFlow(r.Context(), agent.ID, nil); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to remove flow"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Agent flow removed", "agent_id", agent.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+const (
+	GeoProviderNone = "none"
+	GeoProviderHTTP = "http"
+
+	geoLookupTimeout = 2 * time.Second
+)
+
+// GeoProvider resolves a client IP to an ISO 3166-1 alpha-2 country code.
+// An empty country means the location is unknown.
+type GeoProvider interface {
+	LookupCountry(ctx context.Context, ip string) (string, error)
+}
+
+// NewGeoProvider creates the configured geo provider
+func NewGeoProvider(cfg GeoConfig) (GeoProvider, error) {
+	switch cfg.Provider {
+	case "", GeoProviderNone:
+		return NoopGeoProvider{}, nil
+	case GeoProviderHTTP:
+		if cfg.LookupURL == "" {
+			return nil, fmt.Errorf("geo provider %q requires GEO_LOOKUP_URL", cfg.Provider)
+		}
+		return NewHTTPGeoProvider(cfg.LookupURL), nil
+	default:
+		return nil, fmt.Errorf("unsupported geo provider %q", cfg.Provider)
+	}
+}
+
+// NoopGeoProvider never resolves a location
+type NoopGeoProvider struct{}
+
+func (NoopGeoProvider) LookupCountry(ctx context.Context, ip string) (string, error) {
+	return "", nil
+}
+
+// HTTPGeoProvider queries a JSON geo-IP service. The URL template's {ip} placeholder is
+// replaced with the client IP; the response must contain a country_code or countryCode field.
+type HTTPGeoProvider struct {
+	urlTemplate string
+	client      *http.Client
+}
+
+func NewHTTPGeoProvider(urlTemplate string) *HTTPGeoProvider {
+	return &HTTPGeoProvider{
+		urlTemplate: urlTemplate,
+		client:      &http.Client{Timeout: geoLookupTimeout},
+	}
+}
+
+func (p *HTTPGeoProvider) LookupCountry(ctx context.Context, ip string) (string, error) {
+	lookupURL := strings.ReplaceAll(p.urlTemplate, "{ip}", url.PathEscape(ip))
+	req, err := http.NewRequestWithContext(ctx, "GET", lookupURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create geo lookup request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("geo lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("geo lookup returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		CountryCode      string `json:"country_code"`
+		CountryCodeCamel string `json:"countryCode"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode geo lookup response: %w", err)
+	}
+	if body.CountryCode != "" {
+		return body.CountryCode, nil
+	}
+	return body.CountryCodeCamel, nil
+}
+
+// ClientRegion is the location-derived defaults for a request
+type ClientRegion struct {
+	Country         string `json:"country,omitempty"`
+	Jurisdiction    string `json:"jurisdiction"`
+	Language        string `json:"language"`
+	ConsentRequired bool   `json:"consent_required"`
+}
+
+// GeoResolver derives a ClientRegion from a request, preferring a trusted CDN country
+// header when configured and falling back to an IP lookup
+type GeoResolver struct {
+	provider      GeoProvider
+	countryHeader string
+}
+
+func NewGeoResolver(provider GeoProvider, countryHeader string) *GeoResolver {
+	return &GeoResolver{
+		provider:      provider,
+		countryHeader: countryHeader,
+	}
+}
+
+// Resolve never fails; lookup errors yield the policy for an unknown location
+func (g *GeoResolver) Resolve(r *http.Request) ClientRegion {
+	country := ""
+	if g.countryHeader != "" {
+		country = r.Header.Get(g.countryHeader)
+	}
+
+	if country == "" {
+		if ip := clientIP(r); ip != nil && !ip.IsPrivate() && !ip.IsLoopback() {
+			found, err := g.provider.LookupCountry(r.Context(), ip.String())
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("Geo lookup failed", "ip", ip.String(), "error", err)
+			}
+			country = found
+		}
+	}
+
+	return RegionForCountry(country)
+}
+
+// clientIP parses the request's remote address, as rewritten by RealIP
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
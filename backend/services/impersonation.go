@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// ImpersonationHeader carries the impersonation token; cookies are ignored when it is present
+	ImpersonationHeader = "X-Impersonation-Token"
+
+	impersonationAudience   = "impersonation"
+	DefaultImpersonationTTL = 30 * time.Minute
+	MaxImpersonationTTL     = 2 * time.Hour
+)
+
+// ImpersonationClaims are the claims of an impersonation token. Field names deliberately
+// differ from CookieClaims so the token can't be replayed as a regular access token.
+type ImpersonationClaims struct {
+	ImpersonationID string `json:"impersonation_id"`
+	AdminID         string `json:"admin_id"`
+	TargetUserID    string `json:"target_user_id"`
+	ReadOnly        bool   `json:"read_only"`
+	jwt.RegisteredClaims
+}
+
+// ImpersonationService lets admins act as a user for support. Tokens are time-limited,
+// read-only unless explicitly allowed, and every request made with one is audited.
+type ImpersonationService struct {
+	repo *repository.GORMRepository
+	auth *AuthService
+}
+
+func NewImpersonationService(repo *repository.GORMRepository, auth *AuthService) *ImpersonationService {
+	return &ImpersonationService{
+		repo: repo,
+		auth: auth,
+	}
+}
+
+// Start opens an impersonation of the target user and returns its token
+func (s *ImpersonationService) Start(ctx context.Context, admin *models.User, targetUserID, reason string, allowWrite bool, ttl time.Duration) (string, *models.Impersonation, error) {
+	if reason == "" {
+		return "", nil, fmt.Errorf("a reason is required")
+	}
+	if targetUserID == admin.ID {
+		return "", nil, fmt.Errorf("cannot impersonate yourself")
+	}
+
+	target, err := s.repo.GetUserByID(ctx, targetUserID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if target == nil {
+		return "", nil, fmt.Errorf("user not found")
+	}
+	// Impersonating an admin would hand out admin access without the admin's own audit trail
+	if target.Role == "admin" {
+		return "", nil, fmt.Errorf("cannot impersonate another admin")
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultImpersonationTTL
+	}
+	if ttl > MaxImpersonationTTL {
+		ttl = MaxImpersonationTTL
+	}
+
+	impersonation := &models.Impersonation{
+		AdminID:   admin.ID,
+		UserID:    target.ID,
+		Reason:    reason,
+		ReadOnly:  !allowWrite,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.repo.CreateImpersonation(ctx, impersonation); err != nil {
+		return "", nil, fmt.Errorf("failed to create impersonation: %w", err)
+	}
+
+	claims := &ImpersonationClaims{
+		ImpersonationID: impersonation.ID,
+		AdminID:         admin.ID,
+		TargetUserID:    target.ID,
+		ReadOnly:        impersonation.ReadOnly,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{impersonationAudience},
+			ExpiresAt: jwt.NewNumericDate(impersonation.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.auth.signingKey())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign impersonation token: %w", err)
+	}
+
+	logging.FromContext(ctx).Warn("Impersonation started", "impersonation_id", impersonation.ID, "admin_id", admin.ID, "user_id", target.ID, "read_only", impersonation.ReadOnly, "reason", reason)
+	return token, impersonation, nil
+}
+
+// Verify resolves an impersonation token to its active impersonation and target user
+func (s *ImpersonationService) Verify(ctx context.Context, token string) (*models.Impersonation, *models.User, error) {
+	claims := &ImpersonationClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, s.auth.verificationKeys, jwt.WithAudience(impersonationAudience))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	// The database record is authoritative so an impersonation can be ended early
+	impersonation, err := s.repo.GetActiveImpersonation(ctx, claims.ImpersonationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if impersonation == nil {
+		return nil, nil, fmt.Errorf("impersonation ended or expired")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, impersonation.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, fmt.Errorf("user not found")
+	}
+	return impersonation, user, nil
+}
+
+// Middleware authenticates requests carrying an impersonation token and defers all others
+// to cookieAuth. Impersonated responses are flagged with X-Impersonation-* headers.
+func (s *ImpersonationService) Middleware(cookieAuth func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		cookieNext := cookieAuth(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(ImpersonationHeader)
+			if token == "" {
+				cookieNext.ServeHTTP(w, r)
+				return
+			}
+
+			impersonation, user, err := s.Verify(r.Context(), token)
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("Impersonation token rejected", "error", err, "path", r.URL.Path)
+				apperrors.Write(w, r, apperrors.Unauthorized("Unauthorized"))
+				return
+			}
+
+			mode := "read-write"
+			if impersonation.ReadOnly {
+				mode = "read-only"
+			}
+			w.Header().Set("X-Impersonation-Id", impersonation.ID)
+			w.Header().Set("X-Impersonated-User", impersonation.UserID)
+			w.Header().Set("X-Impersonated-By", impersonation.AdminID)
+			w.Header().Set("X-Impersonation-Mode", mode)
+			w.Header().Set("X-Impersonation-Expires", impersonation.ExpiresAt.UTC().Format(time.RFC3339))
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			blocked := impersonation.ReadOnly && !isReadOnlyRequest(r)
+			if blocked {
+				apperrors.Write(ww, r, apperrors.Forbidden("Impersonation is read-only"))
+			} else {
+				ctx := context.WithValue(logging.With(r.Context(), "user_id", user.ID, "impersonated_by", impersonation.AdminID), "user", user)
+				ctx = context.WithValue(ctx, "impersonation", impersonation)
+				next.ServeHTTP(ww, r.WithContext(ctx))
+			}
+
+			entry := &models.ImpersonationAuditEntry{
+				ImpersonationID: impersonation.ID,
+				Method:          r.Method,
+				Path:            r.URL.Path,
+				StatusCode:      ww.Status(),
+				Blocked:         blocked,
+			}
+			go s.repo.CreateImpersonationAuditEntry(context.Background(), entry)
+		})
+	}
+}
+
+// End stops an impersonation before it expires
+func (s *ImpersonationService) End(ctx context.Context, impersonationID string) (bool, error) {
+	ended, err := s.repo.EndImpersonation(ctx, impersonationID)
+	if err == nil && ended {
+		logging.FromContext(ctx).Warn("Impersonation ended", "impersonation_id", impersonationID)
+	}
+	return ended, err
+}
+
+// isReadOnlyRequest reports whether a request can't change state. WebSocket upgrades
+// are excluded because they start an interview.
+func isReadOnlyRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return !websocket.IsWebSocketUpgrade(r)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// ErrSpeechDisabled is returned by the no-op speech service when no TTS provider is configured
+var ErrSpeechDisabled = errors.New("speech synthesis is not configured")
+
+// LLMService is the language model used for interviews, transcription, code review and summaries (required)
+type LLMService interface {
+	GenerateInterviewResponse(ctx context.Context, sessionID string, agent *models.Agent, userMessage string, conversationHistory []models.InterviewTranscript) (string, error)
+	GenerateWarmupResponse(ctx context.Context, sessionID string, agent *models.Agent, userMessage string, conversationHistory []models.InterviewTranscript, finalTurn bool) (string, error)
+	AnalyzeCode(ctx context.Context, code string, language string, findings string, testResults string) (string, error)
+	GenerateSummary(ctx context.Context, prompt string) (string, error)
+	GenerateExplanations(ctx context.Context, prompt string) (string, error)
+	GenerateCoachingNote(ctx context.Context, prompt string) (string, error)
+	SetCandidateContext(sessionID, context string)
+	SetStageDirective(sessionID, directive string)
+	SetDrillTopics(sessionID string, topics []string)
+	SetBannedTopics(sessionID string, topics []string)
+	SetAccommodations(sessionID string, accommodations Accommodations)
+	SetPacing(sessionID string, pacing Pacing)
+	NudgePacing(sessionID, reason string)
+	SetDifficulty(sessionID, difficulty string)
+	SetProctored(sessionID string, proctored bool)
+	SetTextMode(sessionID string, textMode bool)
+	SetInterviewType(sessionID, interviewType string)
+	SetQuestionBank(sessionID string, questions []models.Question)
+	CurrentBankQuestion(sessionID string) *models.Question
+	GenerateOpeningQuestions(ctx context.Context, agent *models.Agent, count int) ([]string, error)
+	WarmSession(ctx context.Context, sessionID string, agent *models.Agent) error
+	GenerateWelcome(ctx context.Context, agent *models.Agent, language string) (string, error)
+	ClearSessionCache(sessionID string)
+}
+
+// CallTimeouts bound each call to the LLM and speech services, so a hung request can't stall
+// a session; 0 leaves a call unbounded
+type CallTimeouts struct {
+	LLM     time.Duration // Interview replies, transcription and code review
+	Speech  time.Duration // Text to speech
+	Summary time.Duration // End-of-interview summaries
+}
+
+// withTimeout bounds a single external call. The parent is usually a client's context, so the
+// call is also cancelled when the client disconnects.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// SpeechService converts agent responses to audio (optional). An empty voiceID uses the
+// default voice. TextToSpeechStream's body yields audio as it is synthesized.
+type SpeechService interface {
+	TextToSpeech(ctx context.Context, text string, voiceID string) (io.ReadCloser, error)
+	TextToSpeechStream(ctx context.Context, text string, voiceID string) (io.ReadCloser, error)
+}
+
+// TranscriptionProvider turns a recorded answer into text. mimeType is the recording's format,
+// e.g. "audio/webm;codecs=opus", which each provider maps to what its API accepts.
+type TranscriptionProvider interface {
+	Name() string
+	Transcribe(ctx context.Context, audioData []byte, mimeType, prompt string) (Transcription, error)
+}
+
+var (
+	_ LLMService            = (*GeminiService)(nil)
+	_ SpeechService         = (*ElevenLabsService)(nil)
+	_ SpeechService         = NoopSpeechService{}
+	_ TranscriptionProvider = (*GeminiTranscriber)(nil)
+	_ TranscriptionProvider = (*WhisperTranscriber)(nil)
+	_ TranscriptionProvider = (*DeepgramTranscriber)(nil)
+	_ TranscriptionProvider = (*FallbackTranscriber)(nil)
+)
+
+// NoopSpeechService is used when ElevenLabs is not configured; agents respond with text only
+type NoopSpeechService struct{}
+
+func (NoopSpeechService) TextToSpeech(ctx context.Context, text string, voiceID string) (io.ReadCloser, error) {
+	return nil, ErrSpeechDisabled
+}
+
+func (NoopSpeechService) TextToSpeechStream(ctx context.Context, text string, voiceID string) (io.ReadCloser, error) {
+	return nil, ErrSpeechDisabled
+}
+
+// synthesizeSpeech renders text to audio, returning ErrSpeechDisabled when speech is unavailable
+func synthesizeSpeech(ctx context.Context, speech SpeechService, text string, voiceID string) ([]byte, error) {
+	logger := logging.FromContext(ctx)
+	audioStream, err := speech.TextToSpeech(ctx, text, voiceID)
+	if err != nil {
+		if !errors.Is(err, ErrSpeechDisabled) {
+			logger.Error("Failed to generate speech", "error", err)
+		}
+		return nil, err
+	}
+	defer audioStream.Close()
+
+	audioData, err := io.ReadAll(audioStream)
+	if err != nil {
+		logger.Error("Failed to read speech audio", "error", err)
+		return nil, err
+	}
+	return audioData, nil
+}
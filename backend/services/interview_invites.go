@@ -0,0 +1,273 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultInterviewInviteTTL is how long an interview invite link can be started for, unless
+	// the recruiter says otherwise
+	DefaultInterviewInviteTTL = 7 * 24 * time.Hour
+	MaxInterviewInviteTTL     = 30 * 24 * time.Hour
+
+	// guestEmailDomain holds the placeholder addresses of guest accounts, which can't receive mail
+	guestEmailDomain = "guests.praxis.invalid"
+)
+
+var (
+	ErrInterviewInviteNotFound = errors.New("interview invite not found, expired or already started")
+	ErrInterviewInviteAgent    = errors.New("the agent must be active and shared with the organization")
+	ErrNotGuest                = errors.New("only guest accounts can be converted")
+	ErrEmailTaken              = errors.New("an account with this email already exists")
+)
+
+// guestWritePrefixes are the API paths guests may change things under besides taking their
+// interview: its session and summary, the terms, their account and client error reports
+var guestWritePrefixes = []string{"/sessions/", "/summaries/", "/legal/", "/users/me", "/client-errors"}
+
+// InterviewInviteService lets recruiters invite external candidates to one interview with an
+// organization's agent. Candidates start it without signing up: a guest account is created for
+// them, limited to that interview, which they can later convert into a full account.
+type InterviewInviteService struct {
+	repo   *repository.GORMRepository
+	auth   *AuthService
+	legal  *LegalService
+	mailer Mailer
+	appURL string
+}
+
+func NewInterviewInviteService(repo *repository.GORMRepository, auth *AuthService, legal *LegalService, mailer Mailer, appURL string) *InterviewInviteService {
+	return &InterviewInviteService{
+		repo:   repo,
+		auth:   auth,
+		legal:  legal,
+		mailer: mailer,
+		appURL: strings.TrimRight(appURL, "/"),
+	}
+}
+
+// Create invites a candidate to an interview with one of the organization's agents. The link is
+// emailed when an email address is given, and returned either way; the token in it can't be
+// recovered later.
+func (s *InterviewInviteService) Create(ctx context.Context, org *models.Organization, recruiter *models.User, agentID, name, email string, ttl time.Duration) (string, *models.InterviewInvite, error) {
+	logger := logging.FromContext(ctx)
+	if email = strings.TrimSpace(email); email != "" {
+		address, err := mail.ParseAddress(email)
+		if err != nil {
+			return "", nil, ErrInvalidInviteEmail
+		}
+		email = address.Address
+	}
+
+	agent, err := s.repo.GetAgent(ctx, agentID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !inviteAgentAvailable(agent, org.ID) {
+		return "", nil, ErrInterviewInviteAgent
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultInterviewInviteTTL
+	}
+	ttl = min(ttl, MaxInterviewInviteTTL)
+
+	token, err := s.auth.generateSecureToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	invite := &models.InterviewInvite{
+		OrganizationID: org.ID,
+		AgentID:        agent.ID,
+		CandidateName:  strings.TrimSpace(name),
+		CandidateEmail: email,
+		Token:          s.auth.hashToken(token),
+		CreatedBy:      recruiter.ID,
+		ExpiresAt:      time.Now().Add(ttl),
+	}
+	if err := s.repo.CreateInterviewInvite(ctx, invite); err != nil {
+		return "", nil, err
+	}
+
+	link := s.appURL + "/interview-invites/" + url.PathEscape(token)
+	if email != "" {
+		subject := fmt.Sprintf("%s invited you to an interview", org.Name)
+		body := fmt.Sprintf("Hi %s,\n\n%s invited you to a %d-minute interview with %s on Praxis. You don't need an account to take it.\n\nStart the interview by %s:\n%s\n",
+			cmp.Or(invite.CandidateName, "there"), org.Name, InterviewDuration(0, agent), agent.Name, invite.ExpiresAt.UTC().Format("January 2"), link)
+		// The invite stands even if the email fails; the recruiter has the link
+		if err := s.mailer.Send(ctx, email, subject, body); err != nil {
+			logger.Warn("Failed to email interview invite", "error", err, "invite_id", invite.ID)
+		}
+	}
+
+	logger.Info("Interview invite created", "org_id", org.ID, "invite_id", invite.ID, "agent_id", agent.ID)
+	return link, invite, nil
+}
+
+// Pending returns the invite a token is for, if it can still be started
+func (s *InterviewInviteService) Pending(ctx context.Context, token string) (*models.InterviewInvite, error) {
+	invite, err := s.repo.GetInterviewInviteByToken(ctx, s.auth.hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, ErrInterviewInviteNotFound
+	}
+	return invite, nil
+}
+
+// Start spends an invite: it creates a guest account for the candidate, signed in on their
+// device, and the interview session they connect to. The age gate and terms are checked as at
+// signup.
+func (s *InterviewInviteService) Start(ctx context.Context, token string, req StartInterviewInviteRequest, region ClientRegion, device DeviceInfo, ip string) (*AuthResponse, *models.InterviewSession, error) {
+	invite, err := s.Pending(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !inviteAgentAvailable(invite.Agent, invite.OrganizationID) {
+		return nil, nil, ErrInterviewInviteAgent
+	}
+	if err := s.legal.ValidateSignup(ctx, req.DateOfBirth, req.AcceptTerms); err != nil {
+		return nil, nil, apperrors.BadRequest(err.Error())
+	}
+
+	guest := &models.User{
+		ID:       uuid.New().String(),
+		FullName: cmp.Or(strings.TrimSpace(req.FullName), invite.CandidateName),
+		Role:     models.UserRoleGuest,
+
+		Country:         region.Country,
+		Jurisdiction:    region.Jurisdiction,
+		Language:        region.Language,
+		ConsentRequired: region.ConsentRequired,
+	}
+	guest.Email = fmt.Sprintf("%s@%s", guest.ID, guestEmailDomain)
+
+	agent := invite.Agent
+	session := &models.InterviewSession{
+		ID:        uuid.New().String(),
+		AgentID:   agent.ID,
+		Status:    "active",
+		StartedAt: time.Now(),
+		Country:   region.Country,
+		Language:  region.Language,
+
+		DurationMinutes: InterviewDuration(0, agent),
+		Proctored:       agent.Proctored,
+		Mode:            models.SessionModeVoice,
+		InterviewType:   agent.InterviewType,
+	}
+	if req.Mode == models.SessionModeText {
+		session.Mode = models.SessionModeText
+	}
+
+	if err := s.repo.StartInterviewInvite(ctx, invite, guest, session); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInterviewInviteNotFound
+		}
+		return nil, nil, err
+	}
+	if err := s.legal.RecordSignup(ctx, guest.ID, ip); err != nil {
+		// The guest is re-prompted by the terms middleware if this didn't stick
+		logging.FromContext(ctx).Error("Failed to record guest legal acceptance", "error", err, "user_id", guest.ID)
+	}
+
+	auth, err := s.auth.SignInGuest(ctx, guest, device)
+	if err != nil {
+		return nil, nil, err
+	}
+	logging.FromContext(ctx).Info("Interview invite started", "org_id", invite.OrganizationID, "invite_id", invite.ID, "user_id", guest.ID, "session_id", session.ID)
+	return auth, session, nil
+}
+
+// Convert turns a guest account into a full account with the email and password given. The
+// interview they took stays theirs, and with the organization that invited them.
+func (s *InterviewInviteService) Convert(ctx context.Context, user *models.User, req ConvertGuestRequest) error {
+	if user.Role != models.UserRoleGuest {
+		return ErrNotGuest
+	}
+	existing, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrEmailTaken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	converted, err := s.repo.ConvertGuestUser(ctx, user.ID, req.Email, string(hash), cmp.Or(req.FullName, user.FullName))
+	if err != nil {
+		return err
+	}
+	if !converted {
+		return ErrNotGuest
+	}
+	logging.FromContext(ctx).Info("Guest converted to a full account")
+	return nil
+}
+
+// RestrictGuests limits guest accounts to the interview they were invited to: they may read,
+// connect to their session and write to the few paths guestMayWrite allows; must run after the
+// auth middleware
+func (s *InterviewInviteService) RestrictGuests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value("user").(*models.User)
+		if ok && user.Role == models.UserRoleGuest && !isReadOnlyRequest(r) && !guestMayWrite(r) {
+			apperrors.Write(w, r, apperrors.Forbidden("Guests can only take the interview they were invited to; create an account to do more"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// guestMayWrite reports whether a guest may make a request that changes something: connecting
+// to their interview, or writing under one of guestWritePrefixes. Starting another session
+// isn't allowed.
+func guestMayWrite(r *http.Request) bool {
+	path, ok := strings.CutPrefix(r.URL.Path, APIV1Prefix)
+	if !ok {
+		return false
+	}
+	if path == "/ws" {
+		return websocket.IsWebSocketUpgrade(r)
+	}
+	if strings.TrimSuffix(path, "/") == "/sessions" {
+		return false
+	}
+	for _, prefix := range guestWritePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// inviteAgentAvailable reports whether candidates can be invited to an interview with an agent
+func inviteAgentAvailable(agent *models.Agent, orgID string) bool {
+	return agent != nil && agent.IsActive && !agent.IsArchived && agent.OrganizationID != nil && *agent.OrganizationID == orgID
+}
+
+// isGuestEmail reports whether an email address is a guest account's placeholder
+func isGuestEmail(email string) bool {
+	return strings.HasSuffix(email, "@"+guestEmailDomain)
+}
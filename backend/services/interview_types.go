@@ -0,0 +1,184 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// interviewFormat is how one interview type is run and judged
+type interviewFormat struct {
+	prompt  string   // How the interviewer runs the interview
+	rubric  string   // What the summary model judges
+	metrics []string // Performance metrics the summary model scores, in report order
+}
+
+var interviewFormats = map[string]interviewFormat{
+	models.InterviewTypeBehavioral: {
+		prompt:  "This is a behavioral interview. Ask about specific past situations (\"Tell me about a time when...\") covering teamwork, conflict, ownership, failure and leadership. Expect answers in the STAR format: situation, task, action, result. When an answer stays hypothetical or vague, ask what the candidate personally did and what came of it. Do not ask technical or coding questions.",
+		rubric:  "Judge the behavioral evidence: whether each answer sets out a concrete situation, the candidate's own actions rather than the team's, a measurable result, and what they learned. Hypothetical answers (\"I would...\") count for little.",
+		metrics: []string{"Situation & Context", "Ownership & Actions", "Results & Impact", "Self-Reflection"},
+	},
+	models.InterviewTypeTechnical: {
+		prompt:  "This is a technical interview. Ask questions that test the candidate's knowledge of the role's languages, tools and fundamentals, and give small problems to reason through out loud or in the code editor. Follow up on correctness, complexity, edge cases and testing. Keep small talk and behavioral questions to a minimum.",
+		rubric:  "Judge the technical substance: whether answers are correct and precise, how problems were broken down and debugged, the quality of any code, and how clearly the candidate explained their reasoning.",
+		metrics: []string{"Technical Knowledge", "Problem Solving", "Code Quality", "Technical Communication"},
+	},
+	models.InterviewTypeSystemDesign: {
+		prompt:  "This is a system design interview. Pose one open-ended design problem at the start and stay with it. Let the candidate clarify requirements and estimate scale before proposing an architecture, then probe components, data models, APIs, bottlenecks, failure handling and trade-offs. Push on scale and reliability as the design firms up.",
+		rubric:  "Judge the design process: whether requirements and scale were clarified first, whether the high-level design is sound, how trade-offs, bottlenecks and failure modes were reasoned about, and how deep the candidate could go into one component.",
+		metrics: []string{"Requirements Gathering", "High-Level Design", "Scalability & Trade-offs", "Deep Dive"},
+	},
+	models.InterviewTypeCaseStudy: {
+		prompt:  "This is a case study interview. Present one business case at the start, with data revealed only when the candidate asks for it. Expect them to structure the problem, state hypotheses, run the numbers out loud and finish with a recommendation. Challenge their assumptions and ask for the math behind any estimate.",
+		rubric:  "Judge the case work: whether the problem was structured in a clear framework, the accuracy of the estimates and calculations, the business sense of the hypotheses, and whether the candidate closed with a justified recommendation.",
+		metrics: []string{"Problem Structuring", "Quantitative Analysis", "Business Judgment", "Recommendation"},
+	},
+}
+
+// generalMetrics are scored for interviews without a type, derived from the overall score:
+// the metric and its adjustment to the overall score
+var generalMetrics = []struct {
+	metric     string
+	adjustment float64
+}{
+	{"Communication", 0.1},         // Slightly higher than base
+	{"Technical Knowledge", -0.05}, // Slightly lower than base
+	{"Problem Solving", 0.0},       // Same as base
+	{"Professionalism", 0.05},      // Slightly higher than base
+}
+
+// InterviewTypeMetrics returns the performance metrics an interview type is scored on
+func InterviewTypeMetrics(interviewType string) []string {
+	if format, ok := interviewFormats[interviewType]; ok {
+		return format.metrics
+	}
+	metrics := make([]string, len(generalMetrics))
+	for i, general := range generalMetrics {
+		metrics[i] = general.metric
+	}
+	return metrics
+}
+
+// interviewTypeInstruction returns the system instruction section telling the interviewer how
+// to run an interview type, or "" for a general interview
+func interviewTypeInstruction(interviewType string) string {
+	format, ok := interviewFormats[interviewType]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(`
+
+INTERVIEW TYPE:
+%s`, format.prompt)
+}
+
+// typeRubric returns the metrics of an interview type as an evenly weighted rubric; none for
+// a general interview
+func typeRubric(interviewType string) []models.RubricItem {
+	format, ok := interviewFormats[interviewType]
+	if !ok {
+		return nil
+	}
+	rubric := make([]models.RubricItem, len(format.metrics))
+	for i, metric := range format.metrics {
+		rubric[i] = models.RubricItem{Metric: metric, Weight: 1}
+	}
+	return rubric
+}
+
+// rubricScoringInstruction asks the summary model to judge an interview by its type's rubric
+// and score each metric of the rubric, or returns "" for a general interview without one. Only
+// a custom rubric states weights, as the type's metrics count equally.
+func rubricScoringInstruction(interviewType string, rubric []models.RubricItem, custom bool) string {
+	var instruction strings.Builder
+	if format, ok := interviewFormats[interviewType]; ok {
+		fmt.Fprintf(&instruction, `
+
+%s INTERVIEW: %s Base the overall score on this rubric.`, strings.ToUpper(strings.ReplaceAll(interviewType, "_", " ")), format.rubric)
+	}
+	if len(rubric) == 0 {
+		return instruction.String()
+	}
+
+	instruction.WriteString(`
+
+RUBRIC: Score each of these metrics (0-100) in rubricScores, using exactly these names.`)
+	if custom {
+		instruction.WriteString(" The percentage is the metric's share of the overall score.")
+	}
+	total := 0.0
+	for _, item := range rubric {
+		total += item.Weight
+	}
+	for _, item := range rubric {
+		instruction.WriteString("\n- " + item.Metric)
+		if custom && total > 0 {
+			fmt.Fprintf(&instruction, " (%.0f%%)", item.Weight/total*100)
+		}
+		if item.Description != "" {
+			instruction.WriteString(": " + item.Description)
+		}
+	}
+	return instruction.String()
+}
+
+// rubricOverallScore is the weighted average of the rubric metrics the model scored, reporting
+// false if it scored none
+func rubricOverallScore(rubric []models.RubricItem, summary ParsedSummary) (float64, bool) {
+	scored := rubricScores(summary)
+	total, weights := 0.0, 0.0
+	for _, item := range rubric {
+		if score, ok := scored[strings.ToLower(item.Metric)]; ok && item.Weight > 0 {
+			total += math.Max(0, math.Min(100, score)) * item.Weight
+			weights += item.Weight
+		}
+	}
+	if weights == 0 {
+		return 0, false
+	}
+	return total / weights, true
+}
+
+// rubricScores indexes the model's rubric scores by lowercased metric name
+func rubricScores(summary ParsedSummary) map[string]float64 {
+	scores := make(map[string]float64, len(summary.RubricScores))
+	for _, score := range summary.RubricScores {
+		scores[strings.ToLower(strings.TrimSpace(score.Metric))] = score.Score
+	}
+	return scores
+}
+
+// buildPerformanceScores turns the summary into performance scores: the model's score of each
+// rubric metric, or metrics derived from the overall score without a rubric, plus any per-stage
+// scores. A rubric metric the model didn't score takes the overall score.
+func buildPerformanceScores(sessionID string, rubric []models.RubricItem, summary ParsedSummary) []models.PerformanceScore {
+	var scores []models.PerformanceScore
+	if len(rubric) > 0 {
+		scored := rubricScores(summary)
+		for _, item := range rubric {
+			score, ok := scored[strings.ToLower(item.Metric)]
+			if !ok {
+				score = summary.OverallScore
+			}
+			scores = append(scores, models.PerformanceScore{
+				SessionID: sessionID,
+				Metric:    item.Metric,
+				Score:     math.Max(0, math.Min(100, score)),
+				MaxScore:  100.0,
+			})
+		}
+	} else {
+		for _, general := range generalMetrics {
+			scores = append(scores, models.PerformanceScore{
+				SessionID: sessionID,
+				Metric:    general.metric,
+				Score:     calculateMetricScore(summary.OverallScore, general.adjustment),
+				MaxScore:  100.0,
+			})
+		}
+	}
+	return append(scores, stagePerformanceScores(sessionID, summary)...)
+}
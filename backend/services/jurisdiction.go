@@ -0,0 +1,78 @@
+package services
+
+import "strings"
+
+// Jurisdictions used by the retention and consent subsystems
+const (
+	JurisdictionEU      = "EU"      // GDPR
+	JurisdictionUK      = "UK"      // UK GDPR
+	JurisdictionBR      = "BR"      // LGPD
+	JurisdictionUS      = "US"      // No general consent requirement
+	JurisdictionIN      = "IN"      // DPDP Act
+	JurisdictionOther   = "OTHER"   // Known country without a specific policy
+	JurisdictionUnknown = "UNKNOWN" // Location could not be determined
+)
+
+const DefaultLanguage = "en"
+
+// euCountries are the EU/EEA member states covered by GDPR
+var euCountries = map[string]bool{
+	"AT": true, "BE": true, "BG": true, "HR": true, "CY": true, "CZ": true, "DK": true,
+	"EE": true, "FI": true, "FR": true, "DE": true, "GR": true, "HU": true, "IE": true,
+	"IT": true, "LV": true, "LT": true, "LU": true, "MT": true, "NL": true, "PL": true,
+	"PT": true, "RO": true, "SK": true, "SI": true, "ES": true, "SE": true,
+	"IS": true, "LI": true, "NO": true,
+}
+
+// countryLanguages maps countries to a default interview language when it isn't English
+var countryLanguages = map[string]string{
+	"AT": "de", "DE": "de", "LI": "de", "CH": "de",
+	"FR": "fr", "BE": "fr", "LU": "fr",
+	"ES": "es", "MX": "es", "AR": "es", "CO": "es", "CL": "es", "PE": "es",
+	"IT": "it",
+	"PT": "pt", "BR": "pt",
+	"NL": "nl",
+	"PL": "pl",
+	"SE": "sv",
+	"JP": "ja",
+	"KR": "ko",
+	"CN": "zh", "TW": "zh",
+}
+
+// RegionForCountry returns the default language and compliance flags for a country code
+func RegionForCountry(country string) ClientRegion {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	// Cloudflare uses XX for unknown and T1 for Tor
+	if len(country) != 2 || country == "XX" || country == "T1" {
+		// Unknown location: apply the strictest consent policy
+		return ClientRegion{Jurisdiction: JurisdictionUnknown, Language: DefaultLanguage, ConsentRequired: true}
+	}
+
+	region := ClientRegion{
+		Country:      country,
+		Jurisdiction: JurisdictionOther,
+		Language:     DefaultLanguage,
+	}
+	if language, ok := countryLanguages[country]; ok {
+		region.Language = language
+	}
+
+	switch {
+	case euCountries[country]:
+		region.Jurisdiction = JurisdictionEU
+		region.ConsentRequired = true
+	case country == "GB":
+		region.Jurisdiction = JurisdictionUK
+		region.ConsentRequired = true
+	case country == "BR":
+		region.Jurisdiction = JurisdictionBR
+		region.ConsentRequired = true
+	case country == "IN":
+		region.Jurisdiction = JurisdictionIN
+		region.ConsentRequired = true
+	case country == "US":
+		region.Jurisdiction = JurisdictionUS
+	}
+
+	return region
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+var (
+	ErrTermsNotAccepted = errors.New("you must accept the terms of service and privacy policy")
+	ErrUnderage         = errors.New("you do not meet the minimum age requirement")
+)
+
+// LegalService tracks published terms/privacy documents and which versions each user accepted
+type LegalService struct {
+	repo    *repository.GORMRepository
+	minAge  int
+	current []models.LegalDocument
+	loaded  bool
+	mutex   sync.RWMutex
+}
+
+func NewLegalService(repo *repository.GORMRepository, minAge int) *LegalService {
+	return &LegalService{
+		repo:   repo,
+		minAge: minAge,
+	}
+}
+
+// CurrentDocuments returns the latest version of each document kind, cached until the next publish
+func (s *LegalService) CurrentDocuments(ctx context.Context) ([]models.LegalDocument, error) {
+	s.mutex.RLock()
+	if s.loaded {
+		current := s.current
+		s.mutex.RUnlock()
+		return current, nil
+	}
+	s.mutex.RUnlock()
+
+	documents, err := s.repo.GetCurrentLegalDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.current = documents
+	s.loaded = true
+	s.mutex.Unlock()
+	return documents, nil
+}
+
+// Publish makes a new document version current; every user is re-prompted to accept it
+func (s *LegalService) Publish(ctx context.Context, document *models.LegalDocument) error {
+	if document.Kind != models.LegalDocumentTerms && document.Kind != models.LegalDocumentPrivacy {
+		return fmt.Errorf("unknown document kind %q", document.Kind)
+	}
+	if document.Version == "" || document.URL == "" {
+		return fmt.Errorf("version and url are required")
+	}
+
+	document.PublishedAt = time.Now()
+	if err := s.repo.CreateLegalDocument(ctx, document); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.loaded = false
+	s.mutex.Unlock()
+	return nil
+}
+
+// PendingDocuments returns the current documents the user has not accepted yet
+func (s *LegalService) PendingDocuments(ctx context.Context, userID string) ([]models.LegalDocument, error) {
+	current, err := s.CurrentDocuments(ctx)
+	if err != nil || len(current) == 0 {
+		return nil, err
+	}
+
+	ids := make([]string, len(current))
+	for i, document := range current {
+		ids[i] = document.ID
+	}
+	accepted, err := s.repo.GetAcceptedDocumentIDs(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []models.LegalDocument
+	for _, document := range current {
+		if !slices.Contains(accepted, document.ID) {
+			pending = append(pending, document)
+		}
+	}
+	return pending, nil
+}
+
+// Accept records acceptance of the given current documents. Superseded versions are rejected
+// so a stale client can't accept a document the user wasn't shown.
+func (s *LegalService) Accept(ctx context.Context, userID string, documentIDs []string, ip string) error {
+	current, err := s.CurrentDocuments(ctx)
+	if err != nil {
+		return err
+	}
+
+	acceptances := make([]models.LegalAcceptance, 0, len(documentIDs))
+	now := time.Now()
+	for _, documentID := range documentIDs {
+		index := slices.IndexFunc(current, func(document models.LegalDocument) bool { return document.ID == documentID })
+		if index < 0 {
+			return fmt.Errorf("document %s is not a current version", documentID)
+		}
+		acceptances = append(acceptances, models.LegalAcceptance{
+			UserID:     userID,
+			DocumentID: documentID,
+			Kind:       current[index].Kind,
+			Version:    current[index].Version,
+			IPAddress:  ip,
+			AcceptedAt: now,
+		})
+	}
+
+	if err := s.repo.CreateLegalAcceptances(ctx, acceptances); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Legal documents accepted", "user_id", userID, "count", len(acceptances))
+	return nil
+}
+
+// ValidateSignup enforces the age gate and terms acceptance before an account is created
+func (s *LegalService) ValidateSignup(ctx context.Context, dateOfBirth string, acceptTerms bool) error {
+	if s.minAge > 0 {
+		birth, err := time.Parse("2006-01-02", dateOfBirth)
+		if err != nil {
+			return fmt.Errorf("date of birth is required (YYYY-MM-DD)")
+		}
+		if ageOn(birth, time.Now()) < s.minAge {
+			return ErrUnderage
+		}
+	}
+
+	current, err := s.CurrentDocuments(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load legal documents: %w", err)
+	}
+	if len(current) > 0 && !acceptTerms {
+		return ErrTermsNotAccepted
+	}
+	return nil
+}
+
+// RecordSignup stores the age confirmation and the acceptance of every current document for a new user
+func (s *LegalService) RecordSignup(ctx context.Context, userID string, ip string) error {
+	if err := s.repo.ConfirmUserAge(ctx, userID); err != nil {
+		return err
+	}
+
+	current, err := s.CurrentDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(current))
+	for i, document := range current {
+		ids[i] = document.ID
+	}
+	return s.Accept(ctx, userID, ids, ip)
+}
+
+// RequireCurrentTerms blocks requests from users who haven't accepted the current documents,
+// responding 428 with the documents to accept; must run after Middleware
+func (s *LegalService) RequireCurrentTerms(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value("user").(*models.User)
+		if !ok {
+			apperrors.Write(w, r, apperrors.Unauthorized("Unauthorized"))
+			return
+		}
+
+		pending, err := s.PendingDocuments(r.Context(), user.ID)
+		if err != nil {
+			// Don't lock everyone out when the check itself fails
+			logging.FromContext(r.Context()).Error("Failed to check legal acceptance", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(pending) > 0 {
+			apperrors.Write(w, r, apperrors.New(http.StatusPreconditionRequired, "Accept the current terms to continue").
+				WithDetails(map[string]interface{}{"documents": pending}))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ageOn returns the age in whole years on the given date
+func ageOn(birth, now time.Time) int {
+	age := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
+
+// SetResearchConsent records whether the user's sessions may be included in anonymized research datasets
+func (s *LegalService) SetResearchConsent(ctx context.Context, userID string, optIn bool) error {
+	return s.repo.SetUserResearchConsent(ctx, userID, optIn)
+}
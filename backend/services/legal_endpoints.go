@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+type LegalEndpoints struct {
+	legal *LegalService
+}
+
+type AcceptLegalDocumentsRequest struct {
+	DocumentIDs []string `json:"document_ids" validate:"required"`
+}
+
+type ResearchConsentRequest struct {
+	OptIn bool `json:"opt_in"`
+}
+
+func NewLegalEndpoints(legal *LegalService) *LegalEndpoints {
+	return &LegalEndpoints{
+		legal: legal,
+	}
+}
+
+// RegisterPublicRoutes registers routes available without authentication
+func (e *LegalEndpoints) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/legal/documents", e.GetCurrentDocumentsHandler)
+}
+
+// RegisterRoutes registers routes for authenticated users; these must stay reachable
+// for users who still have documents to accept
+func (e *LegalEndpoints) RegisterRoutes(r chi.Router) {
+	r.Get("/legal/pending", e.GetPendingDocumentsHandler)
+	r.Post("/legal/accept", e.AcceptDocumentsHandler)
+	r.Put("/legal/research-consent", e.SetResearchConsentHandler)
+}
+
+func (e *LegalEndpoints) GetCurrentDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	documents, err := e.legal.CurrentDocuments(r.Context())
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get legal documents"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"documents": documents,
+	})
+}
+
+func (e *LegalEndpoints) GetPendingDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	pending, err := e.legal.PendingDocuments(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get pending legal documents"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"documents": pending,
+		"count":     len(pending),
+	})
+}
+
+func (e *LegalEndpoints) AcceptDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req AcceptLegalDocumentsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var ip string
+	if addr := clientIP(r); addr != nil {
+		ip = addr.String()
+	}
+	if err := e.legal.Accept(r.Context(), user.ID, req.DocumentIDs, ip); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to accept legal documents", "error", err)
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Documents accepted",
+	})
+}
+
+// SetResearchConsentHandler opts the user in to, or out of, anonymized research datasets.
+// Opting out excludes all of the user's sessions from future exports.
+func (e *LegalEndpoints) SetResearchConsentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req ResearchConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	if err := e.legal.SetResearchConsent(r.Context(), user.ID, req.OptIn); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update research consent"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Research consent updated", "opt_in", req.OptIn)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"opt_in": req.OptIn,
+	})
+}
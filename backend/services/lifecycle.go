@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// Lifecycle owns the server's background work so that shutdown can end it cleanly. Background
+// loops run through Go and must return once their context is done. Steps registered with
+// OnShutdown run in order after the loops have been told to stop, for work such as saving
+// in-memory state to the database.
+type Lifecycle struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	steps   []shutdownStep
+	running map[string]int // Loops not yet returned, by name
+	mutex   sync.Mutex
+}
+
+type shutdownStep struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+func NewLifecycle() *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[string]int),
+	}
+}
+
+// Go runs fn in the background with a context that is cancelled at shutdown
+func (l *Lifecycle) Go(name string, fn func(ctx context.Context)) {
+	l.mutex.Lock()
+	l.running[name]++
+	l.mutex.Unlock()
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		defer func() {
+			l.mutex.Lock()
+			l.running[name]--
+			if l.running[name] == 0 {
+				delete(l.running, name)
+			}
+			l.mutex.Unlock()
+		}()
+		fn(l.ctx)
+	}()
+}
+
+// OnShutdown registers a step to run at shutdown, after the steps registered before it. The
+// step should give up when its context is done.
+func (l *Lifecycle) OnShutdown(name string, run func(ctx context.Context)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.steps = append(l.steps, shutdownStep{name: name, run: run})
+}
+
+// Shutdown stops the background loops, runs the shutdown steps and waits for the loops to
+// return. It gives up when ctx is done, reporting the loops still running.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.cancel()
+
+	l.mutex.Lock()
+	steps := l.steps
+	l.mutex.Unlock()
+	for _, step := range steps {
+		started := time.Now()
+		step.run(ctx)
+		logging.FromContext(ctx).Info("Shutdown step finished", "step", step.name, "duration", time.Since(started))
+	}
+
+	if !waitGroup(ctx, &l.wg) {
+		return fmt.Errorf("background jobs still running: %v", l.Running())
+	}
+	return nil
+}
+
+// Running returns the names of the background loops that haven't returned
+func (l *Lifecycle) Running() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	names := make([]string, 0, len(l.running))
+	for name := range l.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// waitGroup waits for wg, giving up when ctx is done. It reports whether wg finished.
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"time"
+
+	"github.com/krshsl/praxis/backend/models"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// localeLayout is how dates and times are written in a locale. Month names are only spelled
+// out for English, since Go formats them in English; other locales use numeric dates.
+type localeLayout struct {
+	date string
+	time string
+}
+
+// localeLayouts are keyed by language-region, then by language alone
+var localeLayouts = map[string]localeLayout{
+	"en-US": {date: "January 2, 2006", time: "3:04 PM"},
+	"en-CA": {date: "January 2, 2006", time: "3:04 PM"},
+	"en-IN": {date: "2 January 2006", time: "3:04 PM"},
+	"en":    {date: "2 January 2006", time: "15:04"},
+	"de":    {date: "2.1.2006", time: "15:04"},
+	"fr":    {date: "02/01/2006", time: "15:04"},
+	"es":    {date: "02/01/2006", time: "15:04"},
+	"it":    {date: "02/01/2006", time: "15:04"},
+	"pt":    {date: "02/01/2006", time: "15:04"},
+	"nl":    {date: "2-1-2006", time: "15:04"},
+	"ja":    {date: "2006/01/02", time: "15:04"},
+	"zh":    {date: "2006/01/02", time: "15:04"},
+	"ko":    {date: "2006. 1. 2.", time: "15:04"},
+}
+
+// defaultLayout is ISO 8601, for locales without a layout of their own
+var defaultLayout = localeLayout{date: "2006-01-02", time: "15:04"}
+
+// LocaleFormat writes dates, times and numbers the way a user reads them, in their own
+// timezone. Emails, digests and exported reports use it instead of raw UTC timestamps.
+type LocaleFormat struct {
+	layout   localeLayout
+	location *time.Location
+	printer  *message.Printer
+}
+
+// NewLocaleFormat formats for a BCP 47 locale (e.g. "en-GB") and IANA timezone; unknown values
+// fall back to English and UTC
+func NewLocaleFormat(locale, timezone string) *LocaleFormat {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		tag = language.English
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+	layout, ok := localeLayouts[base.String()+"-"+region.String()]
+	if !ok {
+		if layout, ok = localeLayouts[base.String()]; !ok {
+			layout = defaultLayout
+		}
+	}
+
+	return &LocaleFormat{
+		layout:   layout,
+		location: location,
+		printer:  message.NewPrinter(tag),
+	}
+}
+
+// UserLocaleFormat formats for a user's locale and timezone. Users who never chose a locale get
+// one from their language and signup country.
+func UserLocaleFormat(user *models.User) *LocaleFormat {
+	locale := user.Locale
+	if locale == "" {
+		locale = user.Language
+		if user.Country != "" {
+			locale += "-" + user.Country
+		}
+	}
+	return NewLocaleFormat(locale, user.Timezone)
+}
+
+// Date writes the calendar date of t in the user's timezone
+func (f *LocaleFormat) Date(t time.Time) string {
+	return t.In(f.location).Format(f.layout.date)
+}
+
+// DateTime writes t in the user's timezone, with the zone so it can't be mistaken for UTC
+func (f *LocaleFormat) DateTime(t time.Time) string {
+	return t.In(f.location).Format(f.layout.date + " " + f.layout.time + " MST")
+}
+
+// Number writes v with the locale's digit grouping and decimal separator
+func (f *LocaleFormat) Number(v float64, decimals int) string {
+	return f.printer.Sprint(number.Decimal(v, number.MinFractionDigits(decimals), number.MaxFractionDigits(decimals)))
+}
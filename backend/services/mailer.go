@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// smtpTimeout bounds sending an email when the caller's context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// Mailer sends plain-text email
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewMailer creates the mailer selected by configuration
+func NewMailer(cfg MailConfig) (Mailer, error) {
+	switch cfg.Provider {
+	case "", "log":
+		return LogMailer{}, nil
+	case "smtp":
+		if cfg.SMTPAddr == "" || cfg.From == "" {
+			return nil, fmt.Errorf("smtp mailer requires MAIL_SMTP_ADDR and MAIL_FROM")
+		}
+		return NewSMTPMailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown mail provider %q", cfg.Provider)
+	}
+}
+
+// LogMailer writes emails to the log instead of sending them, for local development
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	logging.FromContext(ctx).Info("Email (log mailer)", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+// SMTPMailer sends email through an SMTP relay
+type SMTPMailer struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(cfg MailConfig) *SMTPMailer {
+	host, _, err := net.SplitHostPort(cfg.SMTPAddr)
+	if err != nil {
+		host = cfg.SMTPAddr
+	}
+	mailer := &SMTPMailer{
+		addr: cfg.SMTPAddr,
+		host: host,
+		from: cfg.From,
+	}
+	if cfg.SMTPUsername != "" {
+		mailer.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, host)
+	}
+	return mailer
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	// Header injection guard; addresses and subjects come from our own data but may include user input
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid email header")
+	}
+
+	msg := "From: " + m.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	if err := m.send(ctx, to, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// send is smtp.SendMail bounded by the context's deadline, so a stalled relay can't hold up the
+// request or job sending the email
+func (m *SMTPMailer) send(ctx context.Context, to string, msg []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(m.auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const notificationWebhookTimeout = 10 * time.Second
+
+// NotificationMessage is a stored notification on its way to a user
+type NotificationMessage struct {
+	User         *models.User
+	Settings     *models.NotificationSettings
+	Notification *models.Notification
+	Text         string // Plain-text rendering, greeting the user
+}
+
+// NotificationChannel delivers notifications outside the app. The in-app inbox is the stored
+// notification itself, so it needs no channel.
+type NotificationChannel interface {
+	Name() string
+	// Enabled reports whether the message should be sent over the channel
+	Enabled(message NotificationMessage) bool
+	Deliver(ctx context.Context, message NotificationMessage) error
+}
+
+// EmailChannel emails notifications to users who haven't turned email off. Security alerts
+// are always emailed. Guests have no address to email.
+type EmailChannel struct {
+	repo   *repository.GORMRepository
+	mailer Mailer
+}
+
+func NewEmailChannel(repo *repository.GORMRepository, mailer Mailer) *EmailChannel {
+	return &EmailChannel{repo: repo, mailer: mailer}
+}
+
+func (c *EmailChannel) Name() string {
+	return models.NotificationChannelEmail
+}
+
+func (c *EmailChannel) Enabled(message NotificationMessage) bool {
+	if message.User.Role == models.UserRoleGuest {
+		return false
+	}
+	return message.Settings.Email || criticalNotifications[message.Notification.Type]
+}
+
+// Deliver sends the email once; a redelivered notification that was already emailed is skipped
+func (c *EmailChannel) Deliver(ctx context.Context, message NotificationMessage) error {
+	if message.Notification.EmailedAt != nil {
+		return nil
+	}
+	if err := c.mailer.Send(ctx, message.User.Email, message.Notification.Title, message.Text); err != nil {
+		return err
+	}
+	return c.repo.MarkNotificationEmailed(ctx, message.Notification.ID)
+}
+
+// NotificationWebhookPayload is the body POSTed to a user's notification webhook. The
+// notification ID lets receivers drop the rare duplicate.
+type NotificationWebhookPayload struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Link      string    `json:"link,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookChannel POSTs notifications to the user's webhook, signed with their secret. Delivery
+// is best-effort: the last failure is kept in the user's settings rather than retried.
+type WebhookChannel struct {
+	repo   *repository.GORMRepository
+	client *http.Client
+}
+
+func NewWebhookChannel(repo *repository.GORMRepository) *WebhookChannel {
+	return &WebhookChannel{
+		repo:   repo,
+		client: newPublicHTTPClient(notificationWebhookTimeout),
+	}
+}
+
+func (c *WebhookChannel) Name() string {
+	return models.NotificationChannelWebhook
+}
+
+func (c *WebhookChannel) Enabled(message NotificationMessage) bool {
+	return message.Settings.WebhookURL != ""
+}
+
+func (c *WebhookChannel) Deliver(ctx context.Context, message NotificationMessage) error {
+	notification := message.Notification
+	err := c.post(ctx, message.Settings, NotificationWebhookPayload{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Body:      notification.Body,
+		Link:      notification.Link,
+		CreatedAt: notification.CreatedAt,
+	})
+
+	lastError := ""
+	if err != nil {
+		logging.FromContext(ctx).Warn("Notification webhook delivery failed", "error", err, "user_id", message.User.ID, "notification_id", notification.ID)
+		lastError = err.Error()
+	}
+	if lastError != message.Settings.LastWebhookError {
+		c.repo.UpdateNotificationSettings(ctx, message.User.ID, map[string]interface{}{"last_webhook_error": lastError})
+	}
+	return nil
+}
+
+func (c *WebhookChannel) post(ctx context.Context, settings *models.NotificationSettings, payload NotificationWebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", settings.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AgentDigestSignatureHeader, "sha256="+signDigest(settings.WebhookSecret, body))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
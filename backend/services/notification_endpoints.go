@@ -0,0 +1,324 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	"golang.org/x/text/language"
+)
+
+const maxNotifications = 50
+
+type NotificationEndpoints struct {
+	repo *repository.GORMRepository
+}
+
+func NewNotificationEndpoints(repo *repository.GORMRepository) *NotificationEndpoints {
+	return &NotificationEndpoints{repo: repo}
+}
+
+func (e *NotificationEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/", e.GetNotificationsHandler)
+		r.Get("/preferences", e.GetPreferencesHandler)
+		r.Put("/preferences", e.UpdatePreferencesHandler)
+		r.Get("/settings", e.GetSettingsHandler)
+		r.Put("/settings", e.UpdateSettingsHandler)
+		r.Post("/read-all", e.MarkAllReadHandler)
+		r.Post("/{id}/read", e.MarkReadHandler)
+	})
+}
+
+// GetNotificationsHandler lists the user's recent notifications, with how many are unread;
+// ?unread=true filters to unread ones
+func (e *NotificationEndpoints) GetNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+	notifications, err := e.repo.GetUserNotifications(r.Context(), user.ID, unreadOnly, maxNotifications)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get notifications", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to get notifications"))
+		return
+	}
+
+	unread, err := e.repo.CountUnreadNotifications(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get notifications"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"notifications": notifications,
+		"count":         len(notifications),
+		"unread":        unread,
+	})
+}
+
+// NotificationPreferencesRequest sets the locale and timezone dates and numbers are shown in,
+// and quiet hours, during which notifications other than security alerts are held back. Empty
+// start and end turn quiet hours off. FeedbackTone sets how interview summaries are worded;
+// empty leaves it to the agent's personality. The accommodations apply to interviews started
+// from then on.
+type NotificationPreferencesRequest struct {
+	Locale          string `json:"locale"`            // BCP 47, e.g. "en-GB"
+	Timezone        string `json:"timezone"`          // IANA name, e.g. "Europe/Berlin"
+	QuietHoursStart string `json:"quiet_hours_start"` // "22:00"
+	QuietHoursEnd   string `json:"quiet_hours_end"`   // "07:00"
+	FeedbackTone    string `json:"feedback_tone"`     // direct, balanced or gentle
+	// Accommodations; a multiplier of 0 or 1 means none
+	ThinkingTimeMultiplier float64 `json:"thinking_time_multiplier"`
+	TimeLimitMultiplier    float64 `json:"time_limit_multiplier"`
+	TextOnly               bool    `json:"text_only"`
+	SimplifiedLanguage     bool    `json:"simplified_language"`
+}
+
+func (e *NotificationEndpoints) GetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"locale":            user.Locale,
+		"timezone":          user.Timezone,
+		"quiet_hours_start": user.QuietHoursStart,
+		"quiet_hours_end":   user.QuietHoursEnd,
+		"feedback_tone":     user.FeedbackTone,
+		// Accommodations
+		"thinking_time_multiplier": user.ThinkingTimeMultiplier,
+		"time_limit_multiplier":    user.TimeLimitMultiplier,
+		"text_only":                user.TextOnly,
+		"simplified_language":      user.SimplifiedLanguage,
+	})
+}
+
+func (e *NotificationEndpoints) UpdatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	var req NotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if req.Locale != "" {
+		tag, err := language.Parse(req.Locale)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.BadRequest("Unknown locale"))
+			return
+		}
+		req.Locale = tag.String()
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Unknown timezone"))
+		return
+	}
+	if (req.QuietHoursStart == "") != (req.QuietHoursEnd == "") {
+		apperrors.Write(w, r, apperrors.BadRequest("Quiet hours need both a start and an end"))
+		return
+	}
+	if req.QuietHoursStart != "" {
+		if _, ok := parseClock(req.QuietHoursStart); !ok {
+			apperrors.Write(w, r, apperrors.BadRequest("quiet_hours_start must be HH:MM"))
+			return
+		}
+		if _, ok := parseClock(req.QuietHoursEnd); !ok {
+			apperrors.Write(w, r, apperrors.BadRequest("quiet_hours_end must be HH:MM"))
+			return
+		}
+	}
+	switch req.FeedbackTone {
+	case "", models.FeedbackToneDirect, models.FeedbackToneBalanced, models.FeedbackToneGentle:
+	default:
+		apperrors.Write(w, r, apperrors.BadRequest("feedback_tone must be direct, balanced or gentle"))
+		return
+	}
+	if err := ValidateAccommodations(req.ThinkingTimeMultiplier, req.TimeLimitMultiplier); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	user.Locale = req.Locale
+	user.Timezone = req.Timezone
+	user.QuietHoursStart = req.QuietHoursStart
+	user.QuietHoursEnd = req.QuietHoursEnd
+	user.FeedbackTone = req.FeedbackTone
+	user.ThinkingTimeMultiplier = max(req.ThinkingTimeMultiplier, 1)
+	user.TimeLimitMultiplier = max(req.TimeLimitMultiplier, 1)
+	user.TextOnly = req.TextOnly
+	user.SimplifiedLanguage = req.SimplifiedLanguage
+	if err := e.repo.UpdateUserPreferences(r.Context(), user); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update preferences"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"locale":            user.Locale,
+		"timezone":          user.Timezone,
+		"quiet_hours_start": user.QuietHoursStart,
+		"quiet_hours_end":   user.QuietHoursEnd,
+		"feedback_tone":     user.FeedbackTone,
+		// Accommodations
+		"thinking_time_multiplier": user.ThinkingTimeMultiplier,
+		"time_limit_multiplier":    user.TimeLimitMultiplier,
+		"text_only":                user.TextOnly,
+		"simplified_language":      user.SimplifiedLanguage,
+	})
+}
+
+func (e *NotificationEndpoints) MarkReadHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	found, err := e.repo.MarkNotificationRead(r.Context(), chi.URLParam(r, "id"), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update notification"))
+		return
+	}
+	if !found {
+		apperrors.Write(w, r, apperrors.NotFound("Notification not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Notification marked as read",
+	})
+}
+
+func (e *NotificationEndpoints) MarkAllReadHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	marked, err := e.repo.MarkAllNotificationsRead(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update notifications"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Notifications marked as read",
+		"marked":  marked,
+	})
+}
+
+// NotificationSettingsRequest chooses the channels notifications are delivered over besides
+// in-app. Security alerts are emailed even with email off. An empty webhook_url removes the
+// webhook.
+type NotificationSettingsRequest struct {
+	Email        bool   `json:"email"`
+	WebhookURL   string `json:"webhook_url"`
+	WeeklyDigest bool   `json:"weekly_digest"`
+	RotateSecret bool   `json:"rotate_secret,omitempty"`
+}
+
+func (e *NotificationEndpoints) GetSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	settings, err := e.repo.GetNotificationSettings(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get notification settings"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+// UpdateSettingsHandler saves the user's notification settings. The webhook signing secret is
+// only returned when it is created or rotated.
+func (e *NotificationEndpoints) UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Invalid user context"))
+		return
+	}
+
+	var req NotificationSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if req.WebhookURL != "" {
+		if err := ValidateWebhookURL(req.WebhookURL); err != nil {
+			apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+			return
+		}
+	}
+
+	settings, err := e.repo.GetNotificationSettings(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get notification settings"))
+		return
+	}
+
+	secret := ""
+	if req.WebhookURL != "" && (settings.WebhookSecret == "" || req.RotateSecret) {
+		if secret, err = newWebhookSecret(); err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to generate webhook secret"))
+			return
+		}
+		settings.WebhookSecret = secret
+	}
+	if req.WebhookURL != settings.WebhookURL {
+		settings.LastWebhookError = ""
+	}
+	if req.WebhookURL == "" {
+		settings.WebhookSecret = ""
+	}
+	settings.WebhookURL = req.WebhookURL
+	settings.Email = req.Email
+	if req.WeeklyDigest && !settings.WeeklyDigest {
+		// Start a fresh week rather than reporting on the one before opting in
+		next := firstDigestAt(models.AgentDigestWeekly, time.Now())
+		settings.NextDigestAt = &next
+	}
+	settings.WeeklyDigest = req.WeeklyDigest
+
+	if err := e.repo.SaveNotificationSettings(r.Context(), settings); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to save notification settings"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Notification settings saved", "email", settings.Email, "webhook", settings.WebhookURL != "", "weekly_digest", settings.WeeklyDigest)
+
+	response := map[string]interface{}{
+		"settings": settings,
+		"message":  "Notification settings saved",
+	}
+	if secret != "" {
+		response["secret"] = secret
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
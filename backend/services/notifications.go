@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// quietHoursInterval is how often notifications held for quiet hours are checked
+	quietHoursInterval = time.Minute
+	quietHoursBatch    = 100
+
+	progressDigestInterval = time.Hour
+	progressDigestBatch    = 100
+	progressDigestWeakest  = 2
+)
+
+// criticalNotifications are sent even during the user's quiet hours, and emailed even to users
+// who turned email off
+var criticalNotifications = map[string]bool{
+	models.NotificationNewDeviceLogin: true,
+}
+
+// NotificationService raises in-app notifications from events and delivers them over the
+// channels each user chose
+type NotificationService struct {
+	repo     *repository.GORMRepository
+	auth     *AuthService
+	channels []NotificationChannel
+	appURL   string
+}
+
+func NewNotificationService(repo *repository.GORMRepository, auth *AuthService, appURL string, channels ...NotificationChannel) *NotificationService {
+	return &NotificationService{
+		repo:     repo,
+		auth:     auth,
+		channels: channels,
+		appURL:   strings.TrimRight(appURL, "/"),
+	}
+}
+
+// HandleNewDeviceLogin alerts the user to a sign-in from a new device or location, with a
+// "this wasn't me" link that signs that device out
+func (s *NotificationService) HandleNewDeviceLogin(ctx context.Context, event Event) error {
+	var payload NewDeviceLoginPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	build := func() (*models.Notification, error) {
+		return s.newDeviceNotification(payload, user)
+	}
+	text := func(notification *models.Notification) string {
+		return fmt.Sprintf("Hi %s,\n\n%s\n\nIf this was you, there's nothing to do. If it wasn't, sign that device out now and change your password:\n%s\n",
+			user.FullName, notification.Body, notification.Link)
+	}
+	if err := s.raise(ctx, event, user, build, text); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("New device sign-in notification processed", "user_id", user.ID, "event_id", event.ID)
+	return nil
+}
+
+// HandleSummaryGenerated tells the candidate their interview summary is ready
+func (s *NotificationService) HandleSummaryGenerated(ctx context.Context, event Event) error {
+	var payload SummaryGeneratedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	// Candidates aren't told about summaries an admin's backfill re-scored
+	if payload.BackfillID != "" {
+		return nil
+	}
+
+	session, err := s.repo.GetInterviewSession(ctx, event.SessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return nil
+	}
+	user, err := s.repo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	build := func() (*models.Notification, error) {
+		summary, err := s.repo.GetInterviewSummary(ctx, event.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		// Regenerated since; the newer summary raises its own notification
+		if summary == nil || summary.ID != payload.SummaryID {
+			return nil, nil
+		}
+		return &models.Notification{
+			Type:  models.NotificationSummaryReady,
+			Title: "Your interview summary is ready",
+			Body: fmt.Sprintf("Your interview with %s scored %s. See your strengths, what to work on and how to improve.",
+				s.agentName(ctx, payload.AgentID), UserLocaleFormat(user).Number(summary.OverallScore, 0)),
+			Link: s.appURL + "/summary/" + url.PathEscape(event.SessionID),
+		}, nil
+	}
+	return s.raise(ctx, event, user, build, s.text(user))
+}
+
+// HandleSessionConcluded tells the candidate when an interview ended because they went inactive
+func (s *NotificationService) HandleSessionConcluded(ctx context.Context, event Event) error {
+	var payload SessionConcludedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if !payload.TimedOut {
+		return nil
+	}
+
+	user, err := s.repo.GetUserByID(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	build := func() (*models.Notification, error) {
+		notification := &models.Notification{
+			Type:  models.NotificationSessionTimedOut,
+			Title: "Your interview timed out",
+			Body:  fmt.Sprintf("Your interview with %s ended because there was no activity for a while.", s.agentName(ctx, payload.AgentID)),
+		}
+		if payload.TranscriptCount > 0 {
+			notification.Body += " Your summary is being generated from what you covered."
+			notification.Link = s.appURL + "/summary/" + url.PathEscape(event.SessionID)
+		} else {
+			notification.Body += " Nothing was recorded, so there is no summary."
+		}
+		return notification, nil
+	}
+	return s.raise(ctx, event, user, build, s.text(user))
+}
+
+// raise stores the notification an event raises and delivers it. Redelivered events reuse the
+// stored notification and only deliver it if it wasn't delivered already. build may return
+// nil when there is nothing to notify.
+func (s *NotificationService) raise(ctx context.Context, event Event, user *models.User, build func() (*models.Notification, error), text func(*models.Notification) string) error {
+	var notification *models.Notification
+	if event.ID != "" {
+		existing, err := s.repo.GetNotificationByEventID(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		notification = existing
+	}
+
+	if notification == nil {
+		built, err := build()
+		if err != nil || built == nil {
+			return err
+		}
+		notification = built
+		notification.UserID = user.ID
+		if event.ID != "" {
+			notification.EventID = &event.ID
+		}
+		if err := s.repo.CreateNotification(ctx, notification); err != nil {
+			return err
+		}
+	}
+
+	if notification.DeliveredAt != nil {
+		return nil
+	}
+	return s.deliver(ctx, user, notification, text(notification))
+}
+
+// deliver sends a stored notification over the user's channels, unless they are in their quiet
+// hours: then it is held until they end and StartQuietHoursJob sends it. Critical notifications
+// are never held.
+func (s *NotificationService) deliver(ctx context.Context, user *models.User, notification *models.Notification, text string) error {
+	if !criticalNotifications[notification.Type] {
+		if end, quiet := QuietHoursEnd(user.Timezone, user.QuietHoursStart, user.QuietHoursEnd, time.Now()); quiet {
+			logging.FromContext(ctx).Info("Notification held for quiet hours", "user_id", user.ID, "notification_id", notification.ID, "deliver_after", end)
+			return s.repo.HoldNotification(ctx, notification.ID, end)
+		}
+	}
+	return s.send(ctx, user, notification, text)
+}
+
+// send delivers a notification over every channel enabled for the user. It is marked delivered
+// only if every channel succeeded, so failed ones are retried.
+func (s *NotificationService) send(ctx context.Context, user *models.User, notification *models.Notification, text string) error {
+	settings, err := s.repo.GetNotificationSettings(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	message := NotificationMessage{
+		User:         user,
+		Settings:     settings,
+		Notification: notification,
+		Text:         text,
+	}
+	var errs []error
+	for _, channel := range s.channels {
+		if !channel.Enabled(message) {
+			continue
+		}
+		if err := channel.Deliver(ctx, message); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name(), err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return s.repo.MarkNotificationDelivered(ctx, notification.ID)
+}
+
+// text renders notifications to the user as a greeting, the body and the link
+func (s *NotificationService) text(user *models.User) func(*models.Notification) string {
+	return func(notification *models.Notification) string {
+		text := fmt.Sprintf("Hi %s,\n\n%s\n", user.FullName, notification.Body)
+		if notification.Link != "" {
+			text += "\n" + notification.Link + "\n"
+		}
+		return text
+	}
+}
+
+// agentName names an agent in a notification, falling back to a generic name if it's gone
+func (s *NotificationService) agentName(ctx context.Context, agentID string) string {
+	agent, err := s.repo.GetAgent(ctx, agentID)
+	if err != nil || agent == nil {
+		return "your interviewer"
+	}
+	return agent.Name
+}
+
+// StartQuietHoursJob periodically delivers the notifications held back for quiet hours once
+// their window has ended
+func (s *NotificationService) StartQuietHoursJob(lifecycle *Lifecycle) {
+	lifecycle.Go("quiet hours notifications", func(ctx context.Context) {
+		ticker := time.NewTicker(quietHoursInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sendDue(ctx)
+			}
+		}
+	})
+	slog.Info("Quiet hours notification job started", "interval", quietHoursInterval)
+}
+
+func (s *NotificationService) sendDue(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	notifications, err := s.repo.GetDueNotifications(ctx, time.Now(), quietHoursBatch)
+	if err != nil {
+		return
+	}
+
+	for i := range notifications {
+		notification := &notifications[i]
+		user, err := s.repo.GetUserByID(ctx, notification.UserID)
+		if err != nil || user == nil {
+			continue
+		}
+
+		// Failures stay due and are retried on the next run
+		if err := s.send(ctx, user, notification, s.text(user)(notification)); err != nil {
+			logger.Warn("Failed to send held notification", "error", err, "notification_id", notification.ID)
+			continue
+		}
+		logger.Info("Held notification sent", "user_id", user.ID, "notification_id", notification.ID)
+	}
+}
+
+// StartDigestJob sends weekly progress digests to the users who opted in
+func (s *NotificationService) StartDigestJob(lifecycle *Lifecycle) {
+	lifecycle.Go("progress digests", func(ctx context.Context) {
+		ticker := time.NewTicker(progressDigestInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sendDigests(ctx)
+			}
+		}
+	})
+	slog.Info("Progress digest job started", "interval", progressDigestInterval)
+}
+
+func (s *NotificationService) sendDigests(ctx context.Context) {
+	due, err := s.repo.GetDueProgressDigests(ctx, time.Now(), progressDigestBatch)
+	if err != nil {
+		return
+	}
+
+	for i := range due {
+		s.sendDigest(ctx, &due[i])
+	}
+}
+
+// sendDigest notifies a user of their progress in the week ending at NextDigestAt. Weeks
+// without sessions are skipped rather than nagging.
+func (s *NotificationService) sendDigest(ctx context.Context, settings *models.NotificationSettings) {
+	periodEnd := *settings.NextDigestAt
+	period := digestPeriod(models.AgentDigestWeekly)
+	periodStart := periodEnd.Add(-period)
+	next := nextDigestAt(periodEnd, models.AgentDigestWeekly, time.Now())
+
+	user, err := s.repo.GetUserByID(ctx, settings.UserID)
+	if err != nil {
+		return
+	}
+	if user != nil {
+		current, err := s.repo.GetUserPeriodStats(ctx, user.ID, periodStart, periodEnd)
+		if err != nil {
+			return
+		}
+		if current.Sessions > 0 {
+			if err := s.raiseDigest(ctx, user, periodStart, periodEnd, current); err != nil {
+				return
+			}
+		}
+	}
+
+	s.repo.UpdateNotificationSettings(ctx, settings.UserID, map[string]interface{}{"next_digest_at": next})
+}
+
+func (s *NotificationService) raiseDigest(ctx context.Context, user *models.User, periodStart, periodEnd time.Time, current *models.ScoreTrendPoint) error {
+	logger := logging.FromContext(ctx)
+	previous, err := s.repo.GetUserPeriodStats(ctx, user.ID, periodStart.Add(-periodEnd.Sub(periodStart)), periodStart)
+	if err != nil {
+		return err
+	}
+	weakest, err := s.repo.GetUserWeakestMetrics(ctx, user.ID, periodStart, progressDigestWeakest)
+	if err != nil {
+		return err
+	}
+
+	format := UserLocaleFormat(user)
+	interviews := "interviews"
+	if current.Sessions == 1 {
+		interviews = "interview"
+	}
+	body := fmt.Sprintf("In the week to %s you completed %d %s with an average score of %s",
+		format.Date(periodEnd), current.Sessions, interviews, format.Number(current.AverageScore, 0))
+	if change := DescribeScoreChange(current.AverageScore, previous.AverageScore, previous.Sessions); change != "" {
+		body += ", " + change
+	}
+	body += "."
+	if len(weakest) > 0 {
+		areas := make([]string, len(weakest))
+		for i, metric := range weakest {
+			areas[i] = strings.ReplaceAll(metric.Metric, "_", " ")
+		}
+		body += " Worth practicing: " + strings.Join(areas, " and ") + "."
+	}
+
+	notification := &models.Notification{
+		UserID: user.ID,
+		Type:   models.NotificationProgressDigest,
+		Title:  "Your weekly interview practice",
+		Body:   body,
+		Link:   s.appURL + "/summaries",
+	}
+	if err := s.repo.CreateNotification(ctx, notification); err != nil {
+		return err
+	}
+	// The digest is in-app either way; channel failures aren't worth sending it twice
+	if err := s.deliver(ctx, user, notification, s.text(user)(notification)); err != nil {
+		logger.Warn("Failed to deliver progress digest", "error", err, "user_id", user.ID, "notification_id", notification.ID)
+	}
+	logger.Info("Progress digest raised", "user_id", user.ID, "sessions", current.Sessions)
+	return nil
+}
+
+// DescribeScoreChange words how an average score compares with the week before's, or returns
+// "" when there were no sessions that week to compare with
+func DescribeScoreChange(average, previous float64, previousSessions int64) string {
+	if previousSessions == 0 {
+		return ""
+	}
+	change := average - previous
+	switch {
+	case math.Abs(change) < 0.5:
+		return "about the same as the week before"
+	case change > 0:
+		return fmt.Sprintf("up %.1f points from the week before", change)
+	default:
+		return fmt.Sprintf("down %.1f points from the week before", -change)
+	}
+}
+
+// QuietHoursEnd reports whether now falls within quiet hours from start to end ("HH:MM" in the
+// timezone, UTC if empty or unknown) and when they end. A window whose end is earlier than its
+// start spans midnight; empty or equal times mean no quiet hours.
+func QuietHoursEnd(timezone, start, end string, now time.Time) (time.Time, bool) {
+	startMinute, ok := parseClock(start)
+	if !ok {
+		return time.Time{}, false
+	}
+	endMinute, ok := parseClock(end)
+	if !ok || startMinute == endMinute {
+		return time.Time{}, false
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+
+	quiet := startMinute <= minute && minute < endMinute
+	if startMinute > endMinute {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	windowEnd := time.Date(local.Year(), local.Month(), local.Day(), endMinute/60, endMinute%60, 0, 0, location)
+	if !windowEnd.After(local) {
+		windowEnd = windowEnd.AddDate(0, 0, 1)
+	}
+	return windowEnd, true
+}
+
+// parseClock parses a "HH:MM" time of day into minutes after midnight
+func parseClock(clock string) (int, bool) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+	return parsed.Hour()*60 + parsed.Minute(), true
+}
+
+// newDeviceNotification builds the in-app notification for a new-device sign-in
+func (s *NotificationService) newDeviceNotification(payload NewDeviceLoginPayload, user *models.User) (*models.Notification, error) {
+	token, err := s.auth.DeviceRevocationToken(payload.UserID, payload.PermanentTokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	location := "an unknown location"
+	if payload.Country != "" {
+		location = payload.Country
+	}
+	return &models.Notification{
+		Type:  models.NotificationNewDeviceLogin,
+		Title: "New sign-in to your Praxis account",
+		Body: fmt.Sprintf("Your account was signed in from %s in %s (IP %s) at %s.",
+			payload.DeviceName, location, payload.IPAddress, UserLocaleFormat(user).DateTime(payload.OccurredAt)),
+		Link: s.appURL + "/security/revoke?token=" + url.QueryEscape(token),
+	}, nil
+}
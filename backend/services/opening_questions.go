@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// openingQuestionTTL is how long a pool is served before it is regenerated
+	openingQuestionTTL = 24 * time.Hour
+	// openingQuestionTimeout bounds a single pool generation
+	openingQuestionTimeout = time.Minute
+)
+
+// OpeningQuestionCache keeps a pool of pre-generated opening questions per agent so the first
+// question of an interview is served without waiting on the LLM
+type OpeningQuestionCache struct {
+	llm      LLMService
+	repo     *repository.GORMRepository
+	poolSize int
+	pools    map[string]*openingQuestionPool
+	mutex    sync.Mutex
+}
+
+// openingQuestionPool is the cached questions of one agent
+type openingQuestionPool struct {
+	questions      []string
+	generatedAt    time.Time
+	agentUpdatedAt time.Time // Pools generated before the agent was edited are regenerated
+	refreshing     bool
+}
+
+// NewOpeningQuestionCache creates the cache; a poolSize of 0 disables it
+func NewOpeningQuestionCache(llm LLMService, repo *repository.GORMRepository, poolSize int) *OpeningQuestionCache {
+	return &OpeningQuestionCache{
+		llm:      llm,
+		repo:     repo,
+		poolSize: poolSize,
+		pools:    make(map[string]*openingQuestionPool),
+	}
+}
+
+// Question returns a cached opening question for the agent. A missing or stale pool is
+// regenerated in the background; until the first one is ready it returns false and the
+// caller falls back to the standard welcome.
+func (c *OpeningQuestionCache) Question(agent *models.Agent) (string, bool) {
+	if c.poolSize <= 0 {
+		return "", false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	pool, exists := c.pools[agent.ID]
+	if !exists {
+		pool = &openingQuestionPool{}
+		c.pools[agent.ID] = pool
+	}
+	if !pool.refreshing && (time.Since(pool.generatedAt) > openingQuestionTTL || !pool.agentUpdatedAt.Equal(agent.UpdatedAt)) {
+		pool.refreshing = true
+		go c.refresh(*agent)
+	}
+
+	// Keep serving a stale pool while it regenerates, but never one for an older version of the agent
+	if len(pool.questions) == 0 || !pool.agentUpdatedAt.Equal(agent.UpdatedAt) {
+		return "", false
+	}
+	return pool.questions[rand.IntN(len(pool.questions))], true
+}
+
+// refresh generates a new pool for an agent
+func (c *OpeningQuestionCache) refresh(agent models.Agent) {
+	ctx, cancel := context.WithTimeout(context.Background(), openingQuestionTimeout)
+	defer cancel()
+
+	questions, err := c.llm.GenerateOpeningQuestions(ctx, &agent, c.poolSize)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	pool := c.pools[agent.ID]
+	pool.refreshing = false
+	if err != nil || len(questions) == 0 {
+		slog.Error("Failed to generate opening questions", "error", err, "agent_id", agent.ID)
+		return
+	}
+	pool.questions = questions
+	pool.generatedAt = time.Now()
+	pool.agentUpdatedAt = agent.UpdatedAt
+	slog.Info("Opening questions generated", "agent_id", agent.ID, "count", len(questions))
+}
+
+// StartRefreshJob pre-generates pools for public agents at startup and daily after that.
+// Private agents get their pool on first use.
+func (c *OpeningQuestionCache) StartRefreshJob(lifecycle *Lifecycle) {
+	if c.poolSize <= 0 {
+		return
+	}
+
+	lifecycle.Go("opening question refresh", func(ctx context.Context) {
+		ticker := time.NewTicker(openingQuestionTTL)
+		defer ticker.Stop()
+
+		for {
+			agents, err := c.repo.GetAgents(ctx, "", true)
+			if err == nil {
+				for i := range agents {
+					if ctx.Err() != nil {
+						return
+					}
+					c.Question(&agents[i])
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+	slog.Info("Opening question refresh job started", "interval", openingQuestionTTL, "pool_size", c.poolSize)
+}
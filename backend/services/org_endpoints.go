@@ -0,0 +1,891 @@
+package services
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// defaultOrgSessionsLimit is how many cohort sessions are listed without a limit
+const defaultOrgSessionsLimit = 100
+
+// OrgEndpoints lets bootcamps and companies run organizations: members are invited by email,
+// admins share agents and question banks with the organization and follow their cohort's
+// sessions with them
+type OrgEndpoints struct {
+	repo       *repository.GORMRepository
+	orgs       *OrgService
+	interviews *InterviewInviteService
+	auth       *AuthService
+	geo        *GeoResolver
+}
+
+func NewOrgEndpoints(repo *repository.GORMRepository, orgs *OrgService, interviews *InterviewInviteService, auth *AuthService, geo *GeoResolver) *OrgEndpoints {
+	return &OrgEndpoints{
+		repo:       repo,
+		orgs:       orgs,
+		interviews: interviews,
+		auth:       auth,
+		geo:        geo,
+	}
+}
+
+func (e *OrgEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/orgs", func(r chi.Router) {
+		r.Post("/", e.CreateOrgHandler)
+		r.Get("/", e.GetOrgsHandler)
+		r.Get("/invites/{token}", e.GetInviteHandler)
+		r.Post("/invites/{token}/accept", e.AcceptInviteHandler)
+		r.Get("/{id}", e.GetOrgHandler)
+		r.Get("/{id}/members", e.GetMembersHandler)
+		r.Put("/{id}/members/{userID}", e.UpdateMemberHandler)
+		r.Delete("/{id}/members/{userID}", e.RemoveMemberHandler)
+		r.Get("/{id}/invites", e.GetInvitesHandler)
+		r.Post("/{id}/invites", e.CreateInviteHandler)
+		r.Delete("/{id}/invites/{inviteID}", e.RevokeInviteHandler)
+		r.Get("/{id}/agents", e.GetOrgAgentsHandler)
+		r.Put("/{id}/agents/{agentID}", e.ShareAgentHandler)
+		r.Delete("/{id}/agents/{agentID}", e.UnshareAgentHandler)
+		r.Get("/{id}/question-banks", e.GetOrgQuestionBanksHandler)
+		r.Put("/{id}/question-banks/{bankID}", e.ShareQuestionBankHandler)
+		r.Delete("/{id}/question-banks/{bankID}", e.UnshareQuestionBankHandler)
+		r.Get("/{id}/sessions", e.GetOrgSessionsHandler)
+		r.Get("/{id}/comparisons", e.GetComparisonsHandler)
+		r.Get("/{id}/interview-invites", e.GetInterviewInvitesHandler)
+		r.Post("/{id}/interview-invites", e.CreateInterviewInviteHandler)
+		r.Delete("/{id}/interview-invites/{inviteID}", e.RevokeInterviewInviteHandler)
+	})
+}
+
+// RegisterPublicRoutes registers the routes candidates use to start an interview they were
+// invited to, before they have an account
+func (e *OrgEndpoints) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/interview-invites/{token}", e.GetInterviewInviteHandler)
+	r.Post("/interview-invites/{token}/start", e.StartInterviewInviteHandler)
+}
+
+type CreateOrgRequest struct {
+	Name string `json:"name"`
+}
+
+type OrgInviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // member, admin or owner; member when empty
+}
+
+// InterviewInviteRequest invites an external candidate to an interview with one of the
+// organization's agents
+type InterviewInviteRequest struct {
+	AgentID        string `json:"agent_id" validate:"required,uuid"`
+	CandidateName  string `json:"candidate_name" validate:"max=255"`
+	CandidateEmail string `json:"candidate_email" validate:"omitempty,email,max=255"` // The link is emailed here when given
+	ExpiresInDays  int    `json:"expires_in_days" validate:"min=0,max=30"`            // 7 when 0
+}
+
+// StartInterviewInviteRequest starts an invited interview, confirming the candidate's age and
+// acceptance of the terms as at signup
+type StartInterviewInviteRequest struct {
+	FullName    string `json:"full_name" validate:"max=255"` // Defaults to the name the recruiter gave
+	DateOfBirth string `json:"date_of_birth"`
+	AcceptTerms bool   `json:"accept_terms"`
+	Mode        string `json:"mode,omitempty" validate:"omitempty,oneof=voice text"`
+}
+
+type OrgMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// OrgMember is a member as other members see them
+type OrgMember struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	FullName  string    `json:"full_name,omitempty"`
+	AvatarURL string    `json:"avatar_url,omitempty"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// OrgSession is a cohort member's session with one of the organization's agents
+type OrgSession struct {
+	SessionID    string     `json:"session_id"`
+	UserID       string     `json:"user_id"`
+	Email        string     `json:"email"`
+	FullName     string     `json:"full_name,omitempty"`
+	AgentID      string     `json:"agent_id"`
+	AgentName    string     `json:"agent_name"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+	OverallScore *float64   `json:"overall_score,omitempty"` // Once the summary is ready
+	Passed       *bool      `json:"passed,omitempty"`
+	InviteID     string     `json:"invite_id,omitempty"` // Set for an external candidate's interview
+}
+
+// InterviewInviteStatus is an interview invite as the organization's admins follow it
+type InterviewInviteStatus struct {
+	models.InterviewInvite
+	Status       string   `json:"status"` // pending, expired, or the session's status once started
+	OverallScore *float64 `json:"overall_score,omitempty"`
+	Passed       *bool    `json:"passed,omitempty"`
+}
+
+// CreateOrgHandler creates an organization with the current user as its owner
+func (e *OrgEndpoints) CreateOrgHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req CreateOrgRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxOrgNameLen {
+		apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("name must be 1-%d characters", maxOrgNameLen)))
+		return
+	}
+
+	org := &models.Organization{Name: name, CreatedBy: user.ID}
+	if err := e.repo.CreateOrganization(r.Context(), org); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create organization"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Organization created", "org_id", org.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"organization": org,
+		"role":         models.OrgRoleOwner,
+	})
+}
+
+// GetOrgsHandler lists the organizations the user belongs to with their role in each
+func (e *OrgEndpoints) GetOrgsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	memberships, err := e.repo.GetUserOrgMemberships(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get organizations"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"memberships": memberships,
+	})
+}
+
+func (e *OrgEndpoints) GetOrgHandler(w http.ResponseWriter, r *http.Request) {
+	org, membership, ok := e.member(w, r, models.OrgRoleMember)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"organization": org,
+		"role":         membership.Role,
+	})
+}
+
+func (e *OrgEndpoints) GetMembersHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleMember)
+	if !ok {
+		return
+	}
+
+	memberships, err := e.repo.GetOrgMembers(r.Context(), org.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get members"))
+		return
+	}
+	members := make([]OrgMember, 0, len(memberships))
+	for _, membership := range memberships {
+		member := OrgMember{UserID: membership.UserID, Role: membership.Role, JoinedAt: membership.CreatedAt}
+		if membership.User != nil {
+			member.Email = membership.User.Email
+			member.FullName = membership.User.FullName
+			member.AvatarURL = membership.User.AvatarURL
+		}
+		members = append(members, member)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"members": members,
+	})
+}
+
+// UpdateMemberHandler changes a member's role
+func (e *OrgEndpoints) UpdateMemberHandler(w http.ResponseWriter, r *http.Request) {
+	org, actor, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+	target, ok := e.targetMember(w, r, org)
+	if !ok {
+		return
+	}
+
+	var req OrgMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if _, valid := orgRoleRanks[req.Role]; !valid {
+		apperrors.Write(w, r, apperrors.BadRequest("role must be member, admin or owner"))
+		return
+	}
+
+	if err := e.orgs.ChangeRole(r.Context(), actor.Role, target, req.Role); err != nil {
+		writeOrgError(w, r, err, "Failed to update member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"membership": target,
+	})
+}
+
+// RemoveMemberHandler removes a member from the organization; any member may remove themselves
+func (e *OrgEndpoints) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	org, actor, ok := e.member(w, r, models.OrgRoleMember)
+	if !ok {
+		return
+	}
+	target, ok := e.targetMember(w, r, org)
+	if !ok {
+		return
+	}
+
+	if err := e.orgs.Remove(r.Context(), actor, target); err != nil {
+		writeOrgError(w, r, err, "Failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *OrgEndpoints) GetInvitesHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	invites, err := e.repo.GetPendingOrgInvites(r.Context(), org.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get invites"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invites": invites,
+	})
+}
+
+// CreateInviteHandler invites an email address to the organization. The invite link is only
+// returned here.
+func (e *OrgEndpoints) CreateInviteHandler(w http.ResponseWriter, r *http.Request) {
+	org, actor, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+	user := r.Context().Value("user").(*models.User)
+
+	var req OrgInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	link, invite, err := e.orgs.Invite(r.Context(), org, user, actor.Role, req.Email, req.Role)
+	if err != nil {
+		writeOrgError(w, r, err, "Failed to create invite")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invite":     invite,
+		"invite_url": link,
+	})
+}
+
+func (e *OrgEndpoints) RevokeInviteHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	deleted, err := e.repo.DeleteOrgInvite(r.Context(), org.ID, chi.URLParam(r, "inviteID"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to revoke invite"))
+		return
+	}
+	if !deleted {
+		apperrors.Write(w, r, apperrors.NotFound("Invite not found"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Org invite revoked", "org_id", org.ID, "invite_id", chi.URLParam(r, "inviteID"))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetInviteHandler shows which organization and role an invite link is for, before it is accepted
+func (e *OrgEndpoints) GetInviteHandler(w http.ResponseWriter, r *http.Request) {
+	invite, err := e.orgs.PendingInvite(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writeOrgError(w, r, err, "Failed to get invite")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invite": invite,
+	})
+}
+
+func (e *OrgEndpoints) AcceptInviteHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	membership, err := e.orgs.Accept(r.Context(), user, chi.URLParam(r, "token"))
+	if err != nil {
+		writeOrgError(w, r, err, "Failed to accept invite")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"membership": membership,
+	})
+}
+
+// GetOrgAgentsHandler lists the agents shared with the organization
+func (e *OrgEndpoints) GetOrgAgentsHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleMember)
+	if !ok {
+		return
+	}
+
+	agents, err := e.repo.GetOrgAgents(r.Context(), org.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agents"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agents": agents,
+	})
+}
+
+// ShareAgentHandler shares one of the admin's agents with the organization, so every member
+// can practice against it. An agent is shared with at most one organization.
+func (e *OrgEndpoints) ShareAgentHandler(w http.ResponseWriter, r *http.Request) {
+	org, actor, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	agent, err := e.repo.GetAgent(r.Context(), chi.URLParam(r, "agentID"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agent"))
+		return
+	}
+	if agent == nil || agent.UserID == nil || *agent.UserID != actor.UserID {
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
+		return
+	}
+	if agent.OrganizationID != nil && *agent.OrganizationID != org.ID {
+		apperrors.Write(w, r, apperrors.Conflict("Agent is shared with another organization"))
+		return
+	}
+
+	if err := e.repo.SetAgentOrganization(r.Context(), agent.ID, &org.ID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to share agent"))
+		return
+	}
+	agent.OrganizationID = &org.ID
+	logging.FromContext(r.Context()).Info("Agent shared with organization", "org_id", org.ID, "agent_id", agent.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent": agent,
+	})
+}
+
+// UnshareAgentHandler takes an agent back from the organization. Sessions already running
+// with it carry on.
+func (e *OrgEndpoints) UnshareAgentHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	agent, err := e.repo.GetAgent(r.Context(), chi.URLParam(r, "agentID"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agent"))
+		return
+	}
+	if agent == nil || agent.OrganizationID == nil || *agent.OrganizationID != org.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
+		return
+	}
+
+	if err := e.repo.SetAgentOrganization(r.Context(), agent.ID, nil); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to unshare agent"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Agent unshared from organization", "org_id", org.ID, "agent_id", agent.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetOrgQuestionBanksHandler lists the question banks shared with the organization
+func (e *OrgEndpoints) GetOrgQuestionBanksHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	banks, err := e.repo.GetOrgQuestionBanks(r.Context(), org.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get question banks"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"banks": banks,
+	})
+}
+
+// ShareQuestionBankHandler shares one of the admin's question banks with the organization, so
+// the organization's agents can be linked to it. Candidates never see its questions up front.
+func (e *OrgEndpoints) ShareQuestionBankHandler(w http.ResponseWriter, r *http.Request) {
+	org, actor, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	bank, err := e.repo.GetQuestionBank(r.Context(), chi.URLParam(r, "bankID"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get question bank"))
+		return
+	}
+	if bank == nil || bank.UserID != actor.UserID {
+		apperrors.Write(w, r, apperrors.NotFound("Question bank not found"))
+		return
+	}
+	if bank.OrganizationID != nil && *bank.OrganizationID != org.ID {
+		apperrors.Write(w, r, apperrors.Conflict("Question bank is shared with another organization"))
+		return
+	}
+
+	if err := e.repo.SetQuestionBankOrganization(r.Context(), bank, &org.ID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to share question bank"))
+		return
+	}
+	bank.OrganizationID = &org.ID
+	bank.Questions = nil
+	logging.FromContext(r.Context()).Info("Question bank shared with organization", "org_id", org.ID, "bank_id", bank.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bank": bank,
+	})
+}
+
+// UnshareQuestionBankHandler takes a bank back from the organization; agents of other owners
+// linked to it go back to free-form questions
+func (e *OrgEndpoints) UnshareQuestionBankHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	bank, err := e.repo.GetQuestionBank(r.Context(), chi.URLParam(r, "bankID"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get question bank"))
+		return
+	}
+	if bank == nil || bank.OrganizationID == nil || *bank.OrganizationID != org.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Question bank not found"))
+		return
+	}
+
+	if err := e.repo.SetQuestionBankOrganization(r.Context(), bank, nil); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to unshare question bank"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Question bank unshared from organization", "org_id", org.ID, "bank_id", bank.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetOrgSessionsHandler lists the latest sessions the organization's members had with its
+// agents, with their scores, so admins can follow the cohort
+func (e *OrgEndpoints) GetOrgSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	limit := defaultOrgSessionsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	sessions, err := e.repo.GetOrgSessions(r.Context(), org.ID, limit)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get sessions"))
+		return
+	}
+	invited, err := e.invitedSessions(r, org.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get sessions"))
+		return
+	}
+
+	rows := make([]OrgSession, 0, len(sessions))
+	for _, session := range sessions {
+		rows = append(rows, orgSessionRow(session, invited))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": rows,
+		"count":    len(rows),
+	})
+}
+
+// GetComparisonsHandler compares the candidates who interviewed with one of the
+// organization's agents, to support hiring decisions: the distribution of their overall and
+// per-metric scores, and each candidate's rank and percentile
+func (e *OrgEndpoints) GetComparisonsHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	agentID := r.URL.Query().Get("agent_id")
+	if agentID == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("agent_id is required"))
+		return
+	}
+	agent, err := e.repo.GetAgent(r.Context(), agentID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get agent"))
+		return
+	}
+	if agent == nil || agent.OrganizationID == nil || *agent.OrganizationID != org.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
+		return
+	}
+
+	sessions, err := e.repo.GetOrgAgentScoredSessions(r.Context(), org.ID, agent.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to compare candidates"))
+		return
+	}
+	invited, err := e.invitedSessions(r, org.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to compare candidates"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"comparison": CompareCandidates(agent, sessions, invited),
+	})
+}
+
+// invitedSessions maps the sessions of the organization's started interview invites to them
+func (e *OrgEndpoints) invitedSessions(r *http.Request, orgID string) (map[string]models.InterviewInvite, error) {
+	invites, err := e.repo.GetInterviewInvites(r.Context(), orgID)
+	if err != nil {
+		return nil, err
+	}
+	invited := make(map[string]models.InterviewInvite, len(invites))
+	for _, invite := range invites {
+		if invite.SessionID != nil {
+			invited[*invite.SessionID] = invite
+		}
+	}
+	return invited, nil
+}
+
+// orgSessionRow describes a session for the organization's admins; the session's User, Agent
+// and Summary must be loaded
+func orgSessionRow(session models.InterviewSession, invited map[string]models.InterviewInvite) OrgSession {
+	row := OrgSession{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		Email:     session.User.Email,
+		FullName:  session.User.FullName,
+		AgentID:   session.AgentID,
+		AgentName: session.Agent.Name,
+		Status:    session.Status,
+		StartedAt: session.StartedAt,
+		EndedAt:   session.EndedAt,
+	}
+	if session.Summary != nil {
+		row.OverallScore = &session.Summary.OverallScore
+		row.Passed = session.Summary.Passed
+	}
+	if invite, ok := invited[session.ID]; ok {
+		row.InviteID = invite.ID
+		// Guests are known by what the recruiter entered until they create an account
+		if isGuestEmail(row.Email) {
+			row.Email = invite.CandidateEmail
+			row.FullName = cmp.Or(row.FullName, invite.CandidateName)
+		}
+	}
+	return row
+}
+
+// GetInterviewInvitesHandler lists the organization's interview invites with how each went
+func (e *OrgEndpoints) GetInterviewInvitesHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	invites, err := e.repo.GetInterviewInvites(r.Context(), org.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get interview invites"))
+		return
+	}
+	statuses := make([]InterviewInviteStatus, 0, len(invites))
+	for _, invite := range invites {
+		status := InterviewInviteStatus{InterviewInvite: invite, Status: "pending"}
+		switch {
+		case invite.Session != nil:
+			status.Status = invite.Session.Status
+			if invite.Session.Summary != nil {
+				status.OverallScore = &invite.Session.Summary.OverallScore
+				status.Passed = invite.Session.Summary.Passed
+			}
+		case invite.StartedAt != nil:
+			status.Status = "deleted" // The candidate deleted their guest account
+		case time.Now().After(invite.ExpiresAt):
+			status.Status = "expired"
+		}
+		status.Session = nil
+		statuses = append(statuses, status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invites": statuses,
+		"count":   len(statuses),
+	})
+}
+
+// CreateInterviewInviteHandler invites an external candidate to an interview with one of the
+// organization's agents. The invite link is only returned here.
+func (e *OrgEndpoints) CreateInterviewInviteHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+	user := r.Context().Value("user").(*models.User)
+
+	var req InterviewInviteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	link, invite, err := e.interviews.Create(r.Context(), org, user, req.AgentID, req.CandidateName, req.CandidateEmail, ttl)
+	if err != nil {
+		writeOrgError(w, r, err, "Failed to create interview invite")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invite":     invite,
+		"invite_url": link,
+	})
+}
+
+// RevokeInterviewInviteHandler revokes an interview invite the candidate hasn't started
+func (e *OrgEndpoints) RevokeInterviewInviteHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := e.member(w, r, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	inviteID := chi.URLParam(r, "inviteID")
+	deleted, err := e.repo.DeleteInterviewInvite(r.Context(), org.ID, inviteID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to revoke interview invite"))
+		return
+	}
+	if !deleted {
+		apperrors.Write(w, r, apperrors.NotFound("Interview invite not found or already started"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Interview invite revoked", "org_id", org.ID, "invite_id", inviteID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetInterviewInviteHandler shows a candidate who invited them and to what, before they start
+func (e *OrgEndpoints) GetInterviewInviteHandler(w http.ResponseWriter, r *http.Request) {
+	invite, err := e.interviews.Pending(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writeOrgError(w, r, err, "Failed to get interview invite")
+		return
+	}
+
+	response := map[string]interface{}{
+		"candidate_name": invite.CandidateName,
+		"expires_at":     invite.ExpiresAt,
+	}
+	if invite.Organization != nil {
+		response["organization"] = invite.Organization.Name
+	}
+	if invite.Agent != nil {
+		response["agent"] = map[string]interface{}{
+			"name":             invite.Agent.Name,
+			"interview_type":   invite.Agent.InterviewType,
+			"duration_minutes": InterviewDuration(0, invite.Agent),
+			"proctored":        invite.Agent.Proctored,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invite": response,
+	})
+}
+
+// StartInterviewInviteHandler starts an invited interview: the candidate is signed in to a new
+// guest account and gets the session to connect to
+func (e *OrgEndpoints) StartInterviewInviteHandler(w http.ResponseWriter, r *http.Request) {
+	var req StartInterviewInviteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	region := e.geo.Resolve(r)
+	device := DeviceFromRequest(r)
+	device.Country = region.Country
+	var ip string
+	if addr := clientIP(r); addr != nil {
+		ip = addr.String()
+	}
+
+	authResponse, session, err := e.interviews.Start(r.Context(), chi.URLParam(r, "token"), req, region, device, ip)
+	if err != nil {
+		writeOrgError(w, r, err, "Failed to start interview")
+		return
+	}
+
+	e.auth.SetAuthCookies(w, authResponse.AccessToken, authResponse.RefreshToken, authResponse.PermanentToken)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user":    authResponse.User,
+		"session": session,
+	})
+}
+
+// member loads the organization in the URL and the current user's membership of it, writing
+// the error response if they aren't a member or their role is below required
+func (e *OrgEndpoints) member(w http.ResponseWriter, r *http.Request, required string) (*models.Organization, *models.OrgMembership, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return nil, nil, false
+	}
+
+	orgID := chi.URLParam(r, "id")
+	membership, err := e.repo.GetOrgMembership(r.Context(), orgID, user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get organization"))
+		return nil, nil, false
+	}
+	if membership == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Organization not found"))
+		return nil, nil, false
+	}
+	if !OrgRoleAtLeast(membership.Role, required) {
+		apperrors.Write(w, r, apperrors.Forbidden("Not authorized to manage this organization"))
+		return nil, nil, false
+	}
+
+	org, err := e.repo.GetOrganization(r.Context(), orgID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get organization"))
+		return nil, nil, false
+	}
+	if org == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Organization not found"))
+		return nil, nil, false
+	}
+	return org, membership, true
+}
+
+// targetMember loads the membership of the user in the URL
+func (e *OrgEndpoints) targetMember(w http.ResponseWriter, r *http.Request, org *models.Organization) (*models.OrgMembership, bool) {
+	membership, err := e.repo.GetOrgMembership(r.Context(), org.ID, chi.URLParam(r, "userID"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get member"))
+		return nil, false
+	}
+	if membership == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Member not found"))
+		return nil, false
+	}
+	return membership, true
+}
+
+// writeOrgError responds to an OrgService error with its status
+func writeOrgError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.As(err, new(*apperrors.Error)):
+		apperrors.Write(w, r, err)
+	case errors.Is(err, ErrInvalidInviteEmail), errors.Is(err, ErrInterviewInviteAgent):
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+	case errors.Is(err, ErrOrgRoleNotAllowed), errors.Is(err, ErrOrgInviteEmail):
+		apperrors.Write(w, r, apperrors.Forbidden(err.Error()))
+	case errors.Is(err, ErrOrgInviteNotFound), errors.Is(err, ErrInterviewInviteNotFound):
+		apperrors.Write(w, r, apperrors.NotFound(err.Error()))
+	case errors.Is(err, ErrAlreadyOrgMember), errors.Is(err, ErrLastOrgOwner):
+		apperrors.Write(w, r, apperrors.Conflict(err.Error()))
+	default:
+		apperrors.Write(w, r, apperrors.Internal(fallback))
+	}
+}
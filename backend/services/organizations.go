@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// OrgInviteTTL is how long an invite link can be accepted for
+	OrgInviteTTL  = 7 * 24 * time.Hour
+	maxOrgNameLen = 100
+)
+
+// orgRoleRanks orders organization roles by privilege
+var orgRoleRanks = map[string]int{
+	models.OrgRoleMember: 1,
+	models.OrgRoleAdmin:  2,
+	models.OrgRoleOwner:  3,
+}
+
+var (
+	ErrOrgInviteNotFound  = errors.New("invite not found or expired")
+	ErrOrgInviteEmail     = errors.New("invite was sent to a different email address")
+	ErrAlreadyOrgMember   = errors.New("already a member of this organization")
+	ErrLastOrgOwner       = errors.New("an organization needs at least one owner")
+	ErrOrgRoleNotAllowed  = errors.New("not allowed to manage members with this role")
+	ErrInvalidInviteEmail = errors.New("a valid email is required")
+)
+
+// OrgRoleAtLeast reports whether role grants at least the privileges of required
+func OrgRoleAtLeast(role, required string) bool {
+	rank, ok := orgRoleRanks[role]
+	return ok && rank >= orgRoleRanks[required]
+}
+
+// CanManageOrgRole reports whether a member with actorRole may invite, remove, or change the
+// role of, members with role. Admins manage those below them; owners manage everyone.
+func CanManageOrgRole(actorRole, role string) bool {
+	rank, ok := orgRoleRanks[role]
+	if !ok {
+		return false
+	}
+	if actorRole == models.OrgRoleOwner {
+		return true
+	}
+	return OrgRoleAtLeast(actorRole, models.OrgRoleAdmin) && rank < orgRoleRanks[actorRole]
+}
+
+// OrgService manages organization invites and membership changes, keeping every organization
+// with at least one owner
+type OrgService struct {
+	repo   *repository.GORMRepository
+	auth   *AuthService
+	mailer Mailer
+	appURL string
+}
+
+func NewOrgService(repo *repository.GORMRepository, auth *AuthService, mailer Mailer, appURL string) *OrgService {
+	return &OrgService{
+		repo:   repo,
+		auth:   auth,
+		mailer: mailer,
+		appURL: strings.TrimRight(appURL, "/"),
+	}
+}
+
+// Invite creates an invite to the organization and emails its link. The link is also returned,
+// so it can be shared another way; the token in it can't be recovered later.
+func (s *OrgService) Invite(ctx context.Context, org *models.Organization, inviter *models.User, actorRole, email, role string) (string, *models.OrgInvite, error) {
+	logger := logging.FromContext(ctx)
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return "", nil, ErrInvalidInviteEmail
+	}
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+	if !CanManageOrgRole(actorRole, role) {
+		return "", nil, ErrOrgRoleNotAllowed
+	}
+
+	existing, err := s.repo.GetUserByEmail(ctx, address.Address)
+	if err != nil {
+		return "", nil, err
+	}
+	if existing != nil {
+		membership, err := s.repo.GetOrgMembership(ctx, org.ID, existing.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		if membership != nil {
+			return "", nil, ErrAlreadyOrgMember
+		}
+	}
+
+	token, err := s.auth.generateSecureToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	invite := &models.OrgInvite{
+		OrganizationID: org.ID,
+		Email:          address.Address,
+		Role:           role,
+		Token:          s.auth.hashToken(token),
+		InvitedBy:      inviter.ID,
+		ExpiresAt:      time.Now().Add(OrgInviteTTL),
+	}
+	if err := s.repo.CreateOrgInvite(ctx, invite); err != nil {
+		return "", nil, err
+	}
+
+	link := s.appURL + "/invites/" + url.PathEscape(token)
+	subject := fmt.Sprintf("You're invited to join %s", org.Name)
+	body := fmt.Sprintf("Hi,\n\n%s invited you to join %s on Praxis with the %s role, to practice interviews with the organization's interviewers.\n\nAccept the invite within %d days:\n%s\n",
+		inviter.FullName, org.Name, role, int(OrgInviteTTL.Hours()/24), link)
+	// The invite stands even if the email fails; the inviter has the link
+	if err := s.mailer.Send(ctx, invite.Email, subject, body); err != nil {
+		logger.Warn("Failed to email org invite", "error", err, "invite_id", invite.ID)
+	}
+
+	logger.Info("Org invite created", "org_id", org.ID, "invite_id", invite.ID, "role", role, "invited_by", inviter.ID)
+	return link, invite, nil
+}
+
+// PendingInvite returns the invite a token is for, if it can still be accepted
+func (s *OrgService) PendingInvite(ctx context.Context, token string) (*models.OrgInvite, error) {
+	invite, err := s.repo.GetOrgInviteByToken(ctx, s.auth.hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, ErrOrgInviteNotFound
+	}
+	return invite, nil
+}
+
+// Accept adds the user to the organization they were invited to. The invite must have been
+// sent to their email address.
+func (s *OrgService) Accept(ctx context.Context, user *models.User, token string) (*models.OrgMembership, error) {
+	invite, err := s.PendingInvite(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(invite.Email, user.Email) {
+		return nil, ErrOrgInviteEmail
+	}
+
+	existing, err := s.repo.GetOrgMembership(ctx, invite.OrganizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadyOrgMember
+	}
+
+	membership, err := s.repo.AcceptOrgInvite(ctx, invite, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	membership.Organization = invite.Organization
+	logging.FromContext(ctx).Info("Org invite accepted", "org_id", invite.OrganizationID, "invite_id", invite.ID, "user_id", user.ID, "role", invite.Role)
+	return membership, nil
+}
+
+// ChangeRole changes a member's role. The actor must be able to manage both the member's
+// current role and the new one.
+func (s *OrgService) ChangeRole(ctx context.Context, actorRole string, member *models.OrgMembership, role string) error {
+	if !CanManageOrgRole(actorRole, member.Role) || !CanManageOrgRole(actorRole, role) {
+		return ErrOrgRoleNotAllowed
+	}
+	updated, err := s.repo.UpdateOrgMemberRole(ctx, member.OrganizationID, member.UserID, role)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrLastOrgOwner
+	}
+	logging.FromContext(ctx).Info("Org member role changed", "org_id", member.OrganizationID, "user_id", member.UserID, "from", member.Role, "to", role)
+	member.Role = role
+	return nil
+}
+
+// Remove takes a member out of the organization. Members may always leave themselves.
+func (s *OrgService) Remove(ctx context.Context, actor *models.OrgMembership, member *models.OrgMembership) error {
+	if actor.UserID != member.UserID && !CanManageOrgRole(actor.Role, member.Role) {
+		return ErrOrgRoleNotAllowed
+	}
+	removed, err := s.repo.RemoveOrgMember(ctx, member.OrganizationID, member.UserID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrLastOrgOwner
+	}
+	logging.FromContext(ctx).Info("Org member removed", "org_id", member.OrganizationID, "user_id", member.UserID, "removed_by", actor.UserID)
+	return nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// Pacing bounds, so a short-format interview can't be configured into a rapid-fire quiz or a
+// nudge on every answer
+const (
+	MaxPaceQuestions   = 10
+	MinAnswerWordLimit = 20
+	MaxAnswerWordLimit = 1000
+	MinDwellSeconds    = 30
+	MaxDwellSeconds    = 1800
+)
+
+// Reasons the candidate is nudged to move on
+const (
+	PacingLongAnswer = "long_answer" // The answer ran past the word limit
+	PacingLongDwell  = "long_dwell"  // The question took longer than the dwell limit
+)
+
+// dwellNudgeMessage is sent to a candidate who is still on a question past the dwell limit
+const dwellNudgeMessage = "Let's keep things moving: give me the short version and we'll move on to the next question."
+
+// Pacing keeps short-format interviews on track. Zero values turn a control off.
+type Pacing struct {
+	QuestionsPerFiveMinutes int           // Target pace, stated to the interviewer
+	MaxAnswerWords          int           // Longer answers are acknowledged and moved on from
+	MaxDwell                time.Duration // Longest to spend on one question, follow-ups included
+}
+
+// AgentPacing returns the pacing an agent's interviews run at
+func AgentPacing(agent *models.Agent) Pacing {
+	if agent == nil {
+		return Pacing{}
+	}
+	return Pacing{
+		QuestionsPerFiveMinutes: agent.PaceQuestions,
+		MaxAnswerWords:          agent.MaxAnswerWords,
+		MaxDwell:                time.Duration(agent.MaxDwellSeconds) * time.Second,
+	}
+}
+
+// ValidatePacing checks the pacing an agent asks for; 0 turns a control off
+func ValidatePacing(questions, answerWords, dwellSeconds int) error {
+	if questions < 0 || questions > MaxPaceQuestions {
+		return fmt.Errorf("pace_questions must be between 0 and %d", MaxPaceQuestions)
+	}
+	if answerWords != 0 && (answerWords < MinAnswerWordLimit || answerWords > MaxAnswerWordLimit) {
+		return fmt.Errorf("max_answer_words must be 0 or between %d and %d", MinAnswerWordLimit, MaxAnswerWordLimit)
+	}
+	if dwellSeconds != 0 && (dwellSeconds < MinDwellSeconds || dwellSeconds > MaxDwellSeconds) {
+		return fmt.Errorf("max_dwell_seconds must be 0 or between %d and %d", MinDwellSeconds, MaxDwellSeconds)
+	}
+	return nil
+}
+
+// IsZero reports whether no pacing control is on
+func (p Pacing) IsZero() bool {
+	return p == Pacing{}
+}
+
+// ForCandidate stretches the dwell limit by the candidate's extra thinking time
+func (p Pacing) ForCandidate(accommodations Accommodations) Pacing {
+	if p.MaxDwell > 0 {
+		p.MaxDwell = accommodations.Think(p.MaxDwell)
+	}
+	return p
+}
+
+// Nudge returns why an answer given after dwell on its question should be followed by moving
+// on, or "" if it shouldn't
+func (p Pacing) Nudge(answer string, dwell time.Duration) string {
+	if p.MaxAnswerWords > 0 && len(strings.Fields(answer)) > p.MaxAnswerWords {
+		return PacingLongAnswer
+	}
+	if p.MaxDwell > 0 && dwell > p.MaxDwell {
+		return PacingLongDwell
+	}
+	return ""
+}
+
+// Instruction returns the system instruction section stating the pace, or "" if none is set
+func (p Pacing) Instruction() string {
+	var rules []string
+	if p.QuestionsPerFiveMinutes > 0 {
+		interval := 5 * time.Minute / time.Duration(p.QuestionsPerFiveMinutes)
+		rules = append(rules, fmt.Sprintf("Aim for about %d questions every 5 minutes, roughly one every %s including follow-ups. Keep your own turns brief.",
+			p.QuestionsPerFiveMinutes, interval.Round(time.Second)))
+	}
+	if p.MaxAnswerWords > 0 {
+		rules = append(rules, fmt.Sprintf("Invite concise answers of up to about %d words. If an answer runs long, acknowledge it briefly and move on.", p.MaxAnswerWords))
+	}
+	if p.MaxDwell > 0 {
+		rules = append(rules, fmt.Sprintf("Spend no more than %s on any one question, follow-ups included.", p.MaxDwell.Round(time.Second)))
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`
+
+PACING:
+This is a short-format interview; keep it on track.
+- %s`, strings.Join(rules, "\n- "))
+}
+
+// NudgeInstruction returns the system instruction section for the reply after a nudge
+func (p Pacing) NudgeInstruction(reason string) string {
+	cause := fmt.Sprintf("The candidate's last answer ran well past the %d words this interview allows.", p.MaxAnswerWords)
+	if reason == PacingLongDwell {
+		cause = fmt.Sprintf("The last question has taken longer than the %s this interview allows.", p.MaxDwell.Round(time.Second))
+	}
+	return fmt.Sprintf(`
+
+MOVE ON:
+%s Acknowledge the answer in a few words, say something like "let's move on", and ask the next question. Do not follow up on the last one.`, cause)
+}
+
+// pacingNudge checks the candidate's answer against the agent's pacing, and has the reply move
+// on to the next question when it ran long
+func (p *AIMessageProcessor) pacingNudge(sessionID string, agent *models.Agent, answer string) {
+	pacing := AgentPacing(agent).ForCandidate(p.timeoutService.Accommodations(sessionID))
+	if pacing.IsZero() {
+		return
+	}
+	dwell, _ := p.timeoutService.QuestionDwell(sessionID)
+	if reason := pacing.Nudge(answer, dwell); reason != "" {
+		p.llm.NudgePacing(sessionID, reason)
+		slog.Info("Pacing nudge", "session_id", sessionID, "reason", reason, "dwell", dwell)
+	}
+}
+
+// nudgeDwell tells a candidate still on a question past the dwell limit to wrap up, once per
+// question
+func (p *AIMessageProcessor) nudgeDwell(client *ws.Client) {
+	sessionID := client.SessionID
+	pacing := p.timeoutService.Pacing(sessionID).ForCandidate(p.timeoutService.Accommodations(sessionID))
+	if pacing.MaxDwell == 0 || p.timeoutService.GetClosingStage(sessionID) != ClosingStageNone {
+		return
+	}
+	dwell, answered := p.timeoutService.QuestionDwell(sessionID)
+	if answered || dwell <= pacing.MaxDwell || !p.timeoutService.ClaimPacingNudge(sessionID) {
+		return
+	}
+
+	logging.FromContext(client.Context()).Info("Pacing nudge", "session_id", sessionID, "reason", PacingLongDwell, "dwell", dwell)
+	p.sendEnvelope(client, ws.TypePacingNudge, ws.PacingNudgePayload{
+		Reason:  PacingLongDwell,
+		Message: dwellNudgeMessage,
+	})
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"unicode"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// winnowGram is how many tokens each fingerprinted k-gram spans; shorter matches are ignored
+	winnowGram = 5
+	// winnowWindow is how many consecutive k-grams each selected fingerprint is the minimum of
+	winnowWindow = 4
+	// minFingerprints is how many fingerprints a submission needs before it's compared; tiny
+	// snippets look alike no matter who wrote them
+	minFingerprints = 8
+	// maxComparedSubmissions bounds how many earlier answers each submission is compared with
+	maxComparedSubmissions = 500
+)
+
+// codeKeywords are kept verbatim when tokenizing; every other identifier is treated as the same
+// token, so renaming variables doesn't hide a copy
+var codeKeywords = map[string]bool{
+	"func": true, "def": true, "function": true, "class": true, "struct": true, "interface": true,
+	"return": true, "if": true, "else": true, "elif": true, "for": true, "while": true, "range": true,
+	"in": true, "break": true, "continue": true, "switch": true, "case": true, "default": true,
+	"var": true, "let": true, "const": true, "type": true, "map": true, "new": true, "make": true,
+	"go": true, "defer": true, "import": true, "from": true, "package": true, "try": true,
+	"except": true, "catch": true, "finally": true, "lambda": true, "yield": true, "async": true,
+	"await": true, "and": true, "or": true, "not": true, "nil": true, "null": true, "None": true,
+	"true": true, "false": true, "True": true, "False": true, "this": true, "self": true,
+}
+
+// PlagiarismChecker compares code answers to bank questions with the question's reference
+// solution and with other candidates' answers, and flags answers that are too similar
+type PlagiarismChecker struct {
+	repo      *repository.GORMRepository
+	threshold float64
+}
+
+// NewPlagiarismChecker flags answers at least threshold (0-1) similar to a match; 0 disables it
+func NewPlagiarismChecker(repo *repository.GORMRepository, threshold float64) *PlagiarismChecker {
+	return &PlagiarismChecker{repo: repo, threshold: threshold}
+}
+
+// Check compares a code submission with the question's solution and other users' submissions
+// and records a plagiarism flag for the closest match if it reaches the threshold
+func (c *PlagiarismChecker) Check(ctx context.Context, userID string, submission models.InterviewTranscript, question *models.Question) {
+	if c.threshold <= 0 || question == nil || submission.ID == "" {
+		return
+	}
+	fingerprints := winnow(submission.Content)
+	if len(fingerprints) < minFingerprints {
+		return
+	}
+
+	flag := models.SessionFlag{
+		SessionID:    submission.SessionID,
+		Kind:         models.SessionFlagPlagiarism,
+		TranscriptID: submission.ID,
+		QuestionID:   &question.ID,
+	}
+	if question.Solution != "" {
+		flag.Similarity = containment(fingerprints, winnow(question.Solution))
+		flag.Source = models.FlagSourceSolution
+	}
+
+	others, err := c.repo.GetQuestionSubmissions(ctx, question.ID, userID, maxComparedSubmissions)
+	if err != nil {
+		return
+	}
+	for _, other := range others {
+		if similarity := containment(fingerprints, winnow(other.Content)); similarity > flag.Similarity {
+			flag.Similarity = similarity
+			flag.Source = models.FlagSourceSubmission
+			flag.MatchedTranscriptID = &other.ID
+		}
+	}
+
+	if flag.Similarity < c.threshold {
+		return
+	}
+	if err := c.repo.CreateSessionFlag(ctx, &flag); err != nil {
+		return
+	}
+	logging.FromContext(ctx).Warn("Code answer flagged as similar", "session_id", submission.SessionID, "question_id", question.ID, "source", flag.Source, "similarity", flag.Similarity)
+}
+
+// CodeSimilarity is the share (0-1) of a submission's fingerprints that also occur in other.
+// Containment rather than a symmetric measure, so padding a copy with extra code doesn't hide it.
+func CodeSimilarity(submission, other string) float64 {
+	fingerprints := winnow(submission)
+	if len(fingerprints) < minFingerprints {
+		return 0
+	}
+	return containment(fingerprints, winnow(other))
+}
+
+func containment(fingerprints, other map[uint64]bool) float64 {
+	if len(fingerprints) == 0 {
+		return 0
+	}
+	shared := 0
+	for hash := range fingerprints {
+		if other[hash] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(fingerprints))
+}
+
+// winnow fingerprints code: it hashes every k-gram of normalized tokens and keeps the minimum
+// hash of each window of k-grams (Schleimer et al., "Winnowing", 2003)
+func winnow(code string) map[uint64]bool {
+	tokens := codeTokens(code)
+	if len(tokens) < winnowGram {
+		return nil
+	}
+
+	hashes := make([]uint64, 0, len(tokens)-winnowGram+1)
+	for i := 0; i+winnowGram <= len(tokens); i++ {
+		h := fnv.New64a()
+		for _, token := range tokens[i : i+winnowGram] {
+			h.Write([]byte(token))
+			h.Write([]byte{0})
+		}
+		hashes = append(hashes, h.Sum64())
+	}
+
+	fingerprints := make(map[uint64]bool)
+	for start := 0; start < len(hashes); start++ {
+		end := min(start+winnowWindow, len(hashes))
+		minimum := hashes[start]
+		for _, hash := range hashes[start+1 : end] {
+			minimum = min(minimum, hash)
+		}
+		fingerprints[minimum] = true
+		if end == len(hashes) {
+			break
+		}
+	}
+	return fingerprints
+}
+
+// codeTokens splits code into tokens, dropping whitespace and comments and normalizing
+// identifiers, numbers and string literals so they compare equal
+func codeTokens(code string) []string {
+	runes := []rune(code)
+	var tokens []string
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '#' || (r == '/' && i+1 < len(runes) && runes[i+1] == '/'):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '"' || r == '\'' || r == '`':
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			tokens = append(tokens, "STR")
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, "NUM")
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			if word := string(runes[start:i]); codeKeywords[word] {
+				tokens = append(tokens, word)
+			} else {
+				tokens = append(tokens, "ID")
+			}
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
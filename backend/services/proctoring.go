@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// proctoredCodeAck is said instead of the code review in proctored sessions, which give no
+// hints; the review is still kept for the summary
+const proctoredCodeAck = "Thank you, I've got your code. Let's continue."
+
+// proctorEventKinds are the events a client may report
+var proctorEventKinds = map[string]bool{
+	models.ProctorEventFocusLost:      true,
+	models.ProctorEventFocusRegained:  true,
+	models.ProctorEventTabHidden:      true,
+	models.ProctorEventFullscreenExit: true,
+}
+
+// ProctoringService runs proctored sessions for organizations screening candidates. The client
+// must send a heartbeat every HeartbeatInterval; there is no pausing, so a session that goes
+// ProctorMaxGap without one is ended. Focus changes the client reports and gaps in the
+// heartbeat are recorded for the proctoring report shown with the summary.
+type ProctoringService struct {
+	repo     *repository.GORMRepository
+	timeouts *SessionTimeoutService
+	llm      LLMService
+	interval time.Duration
+	maxGap   time.Duration
+
+	sessions map[string]*proctoredSession
+	mutex    sync.Mutex
+}
+
+type proctoredSession struct {
+	lastHeartbeat time.Time
+}
+
+func NewProctoringService(repo *repository.GORMRepository, timeouts *SessionTimeoutService, llm LLMService, cfg InterviewConfig) *ProctoringService {
+	service := &ProctoringService{
+		repo:     repo,
+		timeouts: timeouts,
+		llm:      llm,
+		interval: cfg.HeartbeatInterval,
+		maxGap:   cfg.ProctorMaxGap,
+		sessions: make(map[string]*proctoredSession),
+	}
+	if service.interval <= 0 {
+		service.interval = 10 * time.Second
+	}
+	if service.maxGap < 2*service.interval {
+		service.maxGap = 2 * service.interval
+	}
+
+	go service.startHeartbeatChecker()
+
+	return service
+}
+
+// Start proctors a session if it is proctored and tells the client how often to send
+// heartbeats. A reconnecting client keeps its last heartbeat, so the time it was gone is
+// recorded as a gap.
+func (s *ProctoringService) Start(client *ws.Client, session *models.InterviewSession) {
+	logger := logging.FromContext(client.Context())
+	if !session.Proctored {
+		return
+	}
+
+	s.mutex.Lock()
+	if _, exists := s.sessions[session.ID]; !exists {
+		s.sessions[session.ID] = &proctoredSession{lastHeartbeat: time.Now()}
+	}
+	s.mutex.Unlock()
+
+	s.llm.SetProctored(session.ID, true)
+
+	data, err := ws.Encode(ws.TypeProctoring, ws.ProctoringPayload{
+		HeartbeatIntervalSeconds: int(s.interval.Seconds()),
+		MaxGapSeconds:            int(s.maxGap.Seconds()),
+	})
+	if err != nil {
+		logger.Error("Failed to marshal proctoring message", "error", err)
+		return
+	}
+	safeSend(client.Send, data)
+	logger.Info("Proctoring started", "session_id", session.ID)
+}
+
+// IsProctored reports whether a session is being proctored
+func (s *ProctoringService) IsProctored(sessionID string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, exists := s.sessions[sessionID]
+	return exists
+}
+
+// Heartbeat notes that the candidate is still there. A heartbeat more than two intervals after
+// the last one closes a gap, which is recorded.
+func (s *ProctoringService) Heartbeat(ctx context.Context, sessionID string) {
+	s.mutex.Lock()
+	session, exists := s.sessions[sessionID]
+	if !exists {
+		s.mutex.Unlock()
+		return
+	}
+	now := time.Now()
+	since := session.lastHeartbeat
+	session.lastHeartbeat = now
+	s.mutex.Unlock()
+
+	if gap := now.Sub(since); gap > 2*s.interval {
+		s.record(ctx, sessionID, models.ProctorEventHeartbeatMissed, gap.Milliseconds(), since)
+	}
+}
+
+// RecordEvent stores a focus or fullscreen change the client reported
+func (s *ProctoringService) RecordEvent(ctx context.Context, sessionID string, payload ws.ProctorEventPayload) error {
+	if !proctorEventKinds[payload.Kind] {
+		return fmt.Errorf("kind must be focus_lost, focus_regained, tab_hidden or fullscreen_exited")
+	}
+	if !s.IsProctored(sessionID) {
+		return fmt.Errorf("session is not proctored")
+	}
+	s.record(ctx, sessionID, payload.Kind, payload.DurationMs, time.Now())
+	return nil
+}
+
+func (s *ProctoringService) record(ctx context.Context, sessionID, kind string, durationMs int64, at time.Time) {
+	event := models.ProctorEvent{
+		SessionID:  sessionID,
+		Kind:       kind,
+		DurationMs: durationMs,
+		OccurredAt: at,
+	}
+	if err := s.repo.CreateProctorEvent(ctx, &event); err != nil {
+		return
+	}
+	logging.FromContext(ctx).Info("Proctor event recorded", "session_id", sessionID, "kind", kind, "duration_ms", durationMs)
+}
+
+// BuildProctoringReport counts focus losses, time away, fullscreen exits and heartbeat gaps
+func BuildProctoringReport(sessionID string, events []models.ProctorEvent) *models.ProctoringReport {
+	report := &models.ProctoringReport{
+		SessionID: sessionID,
+		Events:    events,
+	}
+	if report.Events == nil {
+		report.Events = []models.ProctorEvent{}
+	}
+
+	for _, event := range events {
+		switch event.Kind {
+		case models.ProctorEventFocusLost, models.ProctorEventTabHidden:
+			report.FocusLosses++
+		case models.ProctorEventFocusRegained:
+			report.TimeAwaySeconds += event.DurationMs / 1000
+		case models.ProctorEventFullscreenExit:
+			report.FullscreenExits++
+		case models.ProctorEventHeartbeatMissed:
+			report.HeartbeatGaps++
+			report.LongestGapSeconds = max(report.LongestGapSeconds, event.DurationMs/1000)
+		}
+	}
+	report.Clean = len(events) == 0
+	return report
+}
+
+func (s *ProctoringService) startHeartbeatChecker() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.checkHeartbeats()
+	}
+}
+
+// checkHeartbeats ends sessions that have gone too long without a heartbeat and forgets
+// sessions that have finished
+func (s *ProctoringService) checkHeartbeats() {
+	type lostSession struct {
+		id   string
+		last time.Time
+	}
+	var lost []lostSession
+
+	now := time.Now()
+	s.mutex.Lock()
+	for id, session := range s.sessions {
+		if !s.timeouts.IsActive(id) {
+			delete(s.sessions, id)
+			continue
+		}
+		if now.Sub(session.lastHeartbeat) > s.maxGap {
+			lost = append(lost, lostSession{id: id, last: session.lastHeartbeat})
+			delete(s.sessions, id)
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, session := range lost {
+		slog.Warn("Proctored session lost its heartbeat", "session_id", session.id, "last_heartbeat", session.last)
+		s.record(context.Background(), session.id, models.ProctorEventHeartbeatMissed, now.Sub(session.last).Milliseconds(), session.last)
+		s.timeouts.ConcludeSession(session.id, "The proctored session lost contact with the candidate")
+	}
+}
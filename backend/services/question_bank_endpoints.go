@@ -0,0 +1,383 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	maxBankQuestions   = 200
+	maxQuestionTextLen = 1000
+	maxTestSuiteLen    = 50000
+	maxSolutionLen     = 50000
+)
+
+// QuestionBankEndpoints lets users curate question banks and the questions in them
+type QuestionBankEndpoints struct {
+	repo *repository.GORMRepository
+}
+
+func NewQuestionBankEndpoints(repo *repository.GORMRepository) *QuestionBankEndpoints {
+	return &QuestionBankEndpoints{repo: repo}
+}
+
+func (e *QuestionBankEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/questions", func(r chi.Router) {
+		r.Get("/banks", e.GetQuestionBanksHandler)
+		r.Post("/banks", e.CreateQuestionBankHandler)
+		r.Get("/banks/{id}", e.GetQuestionBankHandler)
+		r.Put("/banks/{id}", e.UpdateQuestionBankHandler)
+		r.Delete("/banks/{id}", e.DeleteQuestionBankHandler)
+		r.Post("/banks/{id}/questions", e.CreateQuestionHandler)
+		r.Put("/{id}", e.UpdateQuestionHandler)
+		r.Delete("/{id}", e.DeleteQuestionHandler)
+	})
+}
+
+type QuestionBankRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type QuestionRequest struct {
+	Text       string `json:"text"`
+	Topic      string `json:"topic"`
+	Difficulty string `json:"difficulty"`
+	Position   int    `json:"position"` // Only used when updating; 0 keeps the current position
+	// Hidden unit tests for coding questions, see CodeTestRunner for the file layout
+	TestLanguage string `json:"test_language"`
+	TestSuite    string `json:"test_suite"`
+	Solution     string `json:"solution"` // Reference solution that answers are checked for similarity against
+}
+
+// validate trims the question and checks its text, tests and difficulty
+func (req *QuestionRequest) validate() string {
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		return "text is required"
+	}
+	if len(req.Text) > maxQuestionTextLen {
+		return "text is too long"
+	}
+	req.TestLanguage = strings.ToLower(strings.TrimSpace(req.TestLanguage))
+	if strings.TrimSpace(req.TestSuite) == "" {
+		req.TestLanguage, req.TestSuite = "", ""
+	} else {
+		lang, ok := testLanguages[req.TestLanguage]
+		if !ok {
+			return "test_language must be one of " + strings.Join(testLanguageNames(), ", ")
+		}
+		if len(req.TestSuite) > maxTestSuiteLen {
+			return "test_suite is too long"
+		}
+		// Only declared tests are graded
+		if len(declaredTests(req.TestSuite, lang.declared)) == 0 {
+			return "test_suite declares no tests"
+		}
+		if lang.reserved != nil {
+			if match := lang.reserved.FindStringSubmatch(req.TestSuite); match != nil {
+				return "test_suite can't declare " + match[1] + ", the test harness does"
+			}
+		}
+	}
+	if len(req.Solution) > maxSolutionLen {
+		return "solution is too long"
+	}
+	switch req.Difficulty {
+	case "", models.QuestionDifficultyEasy, models.QuestionDifficultyMedium, models.QuestionDifficultyHard:
+		return ""
+	}
+	return "difficulty must be easy, medium or hard"
+}
+
+func (e *QuestionBankEndpoints) GetQuestionBanksHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	banks, err := e.repo.GetUserQuestionBanks(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get question banks"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"banks": banks,
+	})
+}
+
+func (e *QuestionBankEndpoints) CreateQuestionBankHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req QuestionBankRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("name is required"))
+		return
+	}
+
+	bank := models.QuestionBank{
+		UserID:      user.ID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+	}
+	if err := e.repo.CreateQuestionBank(r.Context(), &bank); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create question bank"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Question bank created", "bank_id", bank.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bank": bank,
+	})
+}
+
+func (e *QuestionBankEndpoints) GetQuestionBankHandler(w http.ResponseWriter, r *http.Request) {
+	bank, ok := e.ownedBank(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bank": bank,
+	})
+}
+
+func (e *QuestionBankEndpoints) UpdateQuestionBankHandler(w http.ResponseWriter, r *http.Request) {
+	bank, ok := e.ownedBank(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	var req QuestionBankRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("name is required"))
+		return
+	}
+
+	bank.Name = strings.TrimSpace(req.Name)
+	bank.Description = req.Description
+	if err := e.repo.UpdateQuestionBank(r.Context(), bank); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update question bank"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bank": bank,
+	})
+}
+
+// DeleteQuestionBankHandler deletes a bank; agents linked to it go back to free-form questions
+func (e *QuestionBankEndpoints) DeleteQuestionBankHandler(w http.ResponseWriter, r *http.Request) {
+	bank, ok := e.ownedBank(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	if err := e.repo.DeleteQuestionBank(r.Context(), bank.ID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete question bank"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Question bank deleted", "bank_id", bank.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateQuestionHandler appends a question to a bank
+func (e *QuestionBankEndpoints) CreateQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	bank, ok := e.ownedBank(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	if len(bank.Questions) >= maxBankQuestions {
+		apperrors.Write(w, r, apperrors.Conflict("Question bank is full"))
+		return
+	}
+
+	var req QuestionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		apperrors.Write(w, r, apperrors.BadRequest(msg))
+		return
+	}
+
+	question := models.Question{
+		BankID:       bank.ID,
+		Text:         req.Text,
+		Topic:        req.Topic,
+		Difficulty:   req.Difficulty,
+		TestLanguage: req.TestLanguage,
+		TestSuite:    req.TestSuite,
+		Solution:     req.Solution,
+	}
+	if err := e.repo.CreateQuestion(r.Context(), &question); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create question"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"question": question,
+	})
+}
+
+func (e *QuestionBankEndpoints) UpdateQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	question, ok := e.ownedQuestion(w, r)
+	if !ok {
+		return
+	}
+
+	var req QuestionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		apperrors.Write(w, r, apperrors.BadRequest(msg))
+		return
+	}
+
+	question.Text = req.Text
+	question.Topic = req.Topic
+	question.Difficulty = req.Difficulty
+	question.TestLanguage = req.TestLanguage
+	question.TestSuite = req.TestSuite
+	question.Solution = req.Solution
+	if req.Position > 0 {
+		question.Position = req.Position
+	}
+	if err := e.repo.UpdateQuestion(r.Context(), question); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update question"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"question": question,
+	})
+}
+
+func (e *QuestionBankEndpoints) DeleteQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	question, ok := e.ownedQuestion(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.repo.DeleteQuestion(r.Context(), question.ID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete question"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedBank loads a bank the current user owns, writing the error response if there isn't one
+func (e *QuestionBankEndpoints) ownedBank(w http.ResponseWriter, r *http.Request, bankID string) (*models.QuestionBank, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return nil, false
+	}
+
+	bank, err := e.repo.GetQuestionBank(r.Context(), bankID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get question bank"))
+		return nil, false
+	}
+	if bank == nil || bank.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Question bank not found"))
+		return nil, false
+	}
+	return bank, true
+}
+
+// ownedQuestion loads a question from one of the current user's banks
+func (e *QuestionBankEndpoints) ownedQuestion(w http.ResponseWriter, r *http.Request) (*models.Question, bool) {
+	question, err := e.repo.GetQuestion(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get question"))
+		return nil, false
+	}
+	if question == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Question not found"))
+		return nil, false
+	}
+	if _, ok := e.ownedBank(w, r, question.BankID); !ok {
+		return nil, false
+	}
+	return question, true
+}
+
+type AgentQuestionBankRequest struct {
+	BankID *string `json:"bank_id"` // null goes back to free-form questions
+}
+
+// PutQuestionBankHandler links an agent to one of its owner's question banks, or one shared with
+// the agent's organization. Sessions already running keep the questions they started with.
+func (e *AgentEndpoints) PutQuestionBankHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	var req AgentQuestionBankRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	if req.BankID != nil {
+		bank, err := e.repo.GetQuestionBank(r.Context(), *req.BankID)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to get question bank"))
+			return
+		}
+		// A bank shared with the agent's organization may be linked to it
+		shared := bank != nil && bank.OrganizationID != nil && agent.OrganizationID != nil && *bank.OrganizationID == *agent.OrganizationID
+		if bank == nil || (bank.UserID != *agent.UserID && !shared) {
+			apperrors.Write(w, r, apperrors.NotFound("Question bank not found"))
+			return
+		}
+	}
+
+	if err := e.repo.SetAgentQuestionBank(r.Context(), agent.ID, req.BankID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to link question bank"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Agent question bank linked", "agent_id", agent.ID, "bank_id", req.BankID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id": agent.ID,
+		"bank_id":  req.BankID,
+	})
+}
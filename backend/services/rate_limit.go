@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// rateLimitIdle is how long a key's bucket is kept after it was last used
+const rateLimitIdle = 10 * time.Minute
+
+// RateLimiter is a set of token buckets, one per key (a user ID or client IP). Each bucket
+// holds up to burst tokens and refills at perMinute tokens a minute.
+type RateLimiter struct {
+	name      string
+	perMinute float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	mutex     sync.Mutex
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// NewRateLimiter returns a limiter, or nil (which allows everything) when perMinute is 0
+func NewRateLimiter(name string, perMinute, burst int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	limiter := &RateLimiter{
+		name:      name,
+		perMinute: float64(perMinute),
+		burst:     float64(max(burst, 1)),
+		buckets:   make(map[string]*tokenBucket),
+	}
+	go limiter.cleanup()
+	return limiter
+}
+
+// Allow takes a token from the key's bucket. When the bucket is empty it returns false and
+// how long until a token is available.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: l.burst}
+		l.buckets[key] = bucket
+	} else {
+		refill := now.Sub(bucket.lastSeen).Minutes() * l.perMinute
+		bucket.tokens = math.Min(l.burst, bucket.tokens+refill)
+	}
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.perMinute * float64(time.Minute))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// cleanup forgets buckets that have been idle long enough to be full again
+func (l *RateLimiter) cleanup() {
+	ticker := time.NewTicker(rateLimitIdle)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mutex.Lock()
+		for key, bucket := range l.buckets {
+			if time.Since(bucket.lastSeen) > rateLimitIdle {
+				delete(l.buckets, key)
+			}
+		}
+		l.mutex.Unlock()
+	}
+}
+
+// LimitByIP rejects requests from a client IP that is over its rate with 429. It runs after
+// RealIP, so RemoteAddr is the client address forwarded by a trusted proxy, or else the
+// address the request came from.
+func (l *RateLimiter) LimitByIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r).String()
+		if ok, wait := l.Allow(ip); !ok {
+			writeRateLimited(w, r, wait)
+			logging.FromContext(r.Context()).Warn("Rate limited", "limiter", l.name, "ip", ip, "path", r.URL.Path)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LimitByUser rejects requests from an authenticated user that is over their rate with 429
+func (l *RateLimiter) LimitByUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, ok := r.Context().Value("user").(*models.User); ok {
+			if ok, wait := l.Allow(user.ID); !ok {
+				writeRateLimited(w, r, wait)
+				logging.FromContext(r.Context()).Warn("Rate limited", "limiter", l.name, "path", r.URL.Path)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeRateLimited(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	apperrors.Write(w, r, apperrors.TooManyRequests("Too many requests"))
+}
+
+// WebSocketLimiter caps how fast a user can send WebSocket messages. Messages that make the
+// interviewer reply (and so call Gemini and ElevenLabs) have a tighter limit of their own.
+type WebSocketLimiter struct {
+	messages *RateLimiter
+	turns    *RateLimiter
+}
+
+func NewWebSocketLimiter(cfg RateLimitConfig) *WebSocketLimiter {
+	return &WebSocketLimiter{
+		messages: NewRateLimiter("ws_messages", cfg.WebSocketMessagesPerMinute, cfg.WebSocketMessageBurst),
+		turns:    NewRateLimiter("ws_turns", cfg.WebSocketTurnsPerMinute, cfg.WebSocketTurnBurst),
+	}
+}
+
+// Allow implements ws.MessageLimiter
+func (l *WebSocketLimiter) Allow(client *ws.Client, env ws.Envelope) (bool, time.Duration) {
+	// Always let the candidate leave, and never drop the heartbeat of a proctored session
+	if env.Type == ws.TypeEndSession || env.Type == ws.TypeHeartbeat {
+		return true, 0
+	}
+	if ok, wait := l.messages.Allow(client.UserID); !ok {
+		return false, wait
+	}
+	if startsTurn(env) {
+		return l.turns.Allow(client.UserID)
+	}
+	return true, 0
+}
+
+// startsTurn reports whether a message makes the interviewer generate a reply
+func startsTurn(env ws.Envelope) bool {
+	switch env.Type {
+	case ws.TypeText, ws.TypeCode, ws.TypeAudio, ws.TypeTakeover:
+		return true
+	case ws.TypeAudioChunk:
+		// The payload hasn't been validated yet; a malformed chunk is rejected after this
+		var chunk struct {
+			IsLastChunk bool `json:"is_last_chunk"`
+		}
+		json.Unmarshal(env.Payload, &chunk)
+		return chunk.IsLastChunk
+	}
+	return false
+}
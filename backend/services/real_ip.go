@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies parses a comma-separated list of proxy addresses and CIDR ranges
+func ParseTrustedProxies(list string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return proxies, nil
+}
+
+// RealIP sets RemoteAddr to the client's address when the request came through one of the
+// TrustedProxies, and leaves the socket's address otherwise: anyone can send X-Forwarded-For,
+// so it's only believed from a proxy that sets it. The client is the last address in
+// X-Forwarded-For that isn't a trusted proxy, as each proxy appends the address it was reached
+// from to whatever the client sent; without the header, X-Real-IP is used. The list is checked
+// at startup, so invalid entries are ignored here.
+func RealIP(cfg ServerConfig) func(http.Handler) http.Handler {
+	proxies, _ := ParseTrustedProxies(cfg.TrustedProxies)
+	return func(next http.Handler) http.Handler {
+		if len(proxies) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if trustedProxy(proxies, remoteAddr(r.RemoteAddr)) {
+				if ip, ok := forwardedClient(r.Header, proxies); ok {
+					r.RemoteAddr = ip.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient returns the client address the trusted proxies forwarded
+func forwardedClient(header http.Header, proxies []netip.Prefix) (netip.Addr, bool) {
+	hops := strings.Split(strings.Join(header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(hop)
+		if err != nil {
+			// Whatever is left of it was written by someone we don't trust
+			return netip.Addr{}, false
+		}
+		if !trustedProxy(proxies, addr.Unmap()) {
+			return addr.Unmap(), true
+		}
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// remoteAddr parses a RemoteAddr with or without its port
+func remoteAddr(remote string) netip.Addr {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	addr, _ := netip.ParseAddr(host)
+	return addr.Unmap()
+}
+
+func trustedProxy(proxies []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// replayRetryAfterSeconds is the polling hint sent while an archived recording is restored
+const replayRetryAfterSeconds = 5
+
+type RecordingEndpoints struct {
+	repo       *repository.GORMRepository
+	recordings *RecordingService
+}
+
+func NewRecordingEndpoints(repo *repository.GORMRepository, recordings *RecordingService) *RecordingEndpoints {
+	return &RecordingEndpoints{
+		repo:       repo,
+		recordings: recordings,
+	}
+}
+
+// RegisterRoutes registers the routes flat rather than mounting /recordings, which would
+// shadow the public audio route
+func (e *RecordingEndpoints) RegisterRoutes(r chi.Router) {
+	r.Get("/recordings/session/{id}", e.GetSessionRecordingsHandler)
+	r.Get("/recordings/{id}/replay", e.ReplayRecordingHandler)
+}
+
+// RegisterPublicRoutes registers the signed audio route, so an audio element can play a
+// recording from its URL alone
+func (e *RecordingEndpoints) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/recordings/{id}/audio", e.DownloadAudioHandler)
+}
+
+func (e *RecordingEndpoints) GetSessionRecordingsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	if !e.ownsSession(w, r, sessionID, user.ID) {
+		return
+	}
+
+	recordings, err := e.repo.GetSessionAudioRecordings(r.Context(), sessionID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get recordings"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"recordings": recordings,
+		"count":      len(recordings),
+	})
+}
+
+// ReplayRecordingHandler streams a recording of one of the user's sessions
+func (e *RecordingEndpoints) ReplayRecordingHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	recording, err := e.repo.GetAudioRecording(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get recording"))
+		return
+	}
+	if recording == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Recording not found"))
+		return
+	}
+	if !e.ownsSession(w, r, recording.SessionID, user.ID) {
+		return
+	}
+	e.writeRecording(w, r, recording)
+}
+
+// DownloadAudioHandler serves a recording through a link from GET /sessions/{id}/audio, which
+// its signature authorizes. Backends that sign their own links don't use it.
+func (e *RecordingEndpoints) DownloadAudioHandler(w http.ResponseWriter, r *http.Request) {
+	recordingID := chi.URLParam(r, "id")
+	query := r.URL.Query()
+	if err := e.recordings.VerifyAudio(recordingID, query.Get("expires"), query.Get("signature"), time.Now()); err != nil {
+		apperrors.Write(w, r, apperrors.Forbidden("This audio link is invalid or has expired"))
+		return
+	}
+
+	recording, err := e.repo.GetAudioRecording(r.Context(), recordingID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get recording"))
+		return
+	}
+	if recording == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Recording not found"))
+		return
+	}
+	e.writeRecording(w, r, recording)
+}
+
+// writeRecording writes a recording's audio. Archived recordings return 202 with a
+// "preparing_replay" status while they are restored from cold storage.
+func (e *RecordingEndpoints) writeRecording(w http.ResponseWriter, r *http.Request, recording *models.AudioRecording) {
+	data, err := e.recordings.Replay(r.Context(), recording)
+	if errors.Is(err, ErrRecordingNotReady) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.Itoa(replayRetryAfterSeconds))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":       "preparing_replay",
+			"recording_id": recording.ID,
+		})
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to replay recording", "error", err, "recording_id", recording.ID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to load recording"))
+		return
+	}
+
+	w.Header().Set("Content-Type", recording.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+// ownsSession writes a 404 and returns false unless the session belongs to the user
+func (e *RecordingEndpoints) ownsSession(w http.ResponseWriter, r *http.Request, sessionID, userID string) bool {
+	session, err := e.repo.GetInterviewSession(r.Context(), sessionID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return false
+	}
+	if session == nil || session.UserID != userID {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return false
+	}
+	return true
+}
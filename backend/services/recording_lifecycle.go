@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	recordingLifecycleInterval = time.Hour
+	recordingLifecycleBatch    = 100
+	// recordingRestoreTimeout is how long a restore may sit in the restoring tier before it is retried
+	recordingRestoreTimeout = 10 * time.Minute
+)
+
+var (
+	// ErrRecordingNotReady is returned by Replay while a cold recording is being restored
+	ErrRecordingNotReady = fmt.Errorf("recording is being prepared for replay")
+	// ErrInvalidAudioSignature is returned for an audio link that was tampered with or expired
+	ErrInvalidAudioSignature = fmt.Errorf("invalid or expired audio link")
+)
+
+// RecordingService stores recorded audio and moves it between hot and cold storage.
+// Recordings older than the retention window are archived to the cold class by a
+// lifecycle job; replaying an archived recording restores it asynchronously.
+type RecordingService struct {
+	repo      *repository.GORMRepository
+	store     BlobStore
+	hotClass  string
+	coldClass string
+	coldAfter time.Duration
+	keepHot   time.Duration
+	urlTTL    time.Duration
+	secret    []byte // Signs audio links for backends that can't sign their own
+	publicURL string
+}
+
+func NewRecordingService(repo *repository.GORMRepository, store BlobStore, cfg StorageConfig, jwtSecret, publicURL string) *RecordingService {
+	hotClass, coldClass := store.DefaultClasses()
+	if cfg.HotClass != "" {
+		hotClass = cfg.HotClass
+	}
+	if cfg.ColdClass != "" {
+		coldClass = cfg.ColdClass
+	}
+	secret := sha256.Sum256([]byte("praxis-recording:" + jwtSecret))
+
+	return &RecordingService{
+		repo:      repo,
+		store:     store,
+		hotClass:  hotClass,
+		coldClass: coldClass,
+		coldAfter: time.Duration(cfg.ColdAfterDays) * 24 * time.Hour,
+		keepHot:   time.Duration(cfg.RestoredHotDays) * 24 * time.Hour,
+		urlTTL:    max(cfg.AudioURLTTL, time.Minute),
+		secret:    secret[:],
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// Save stores a recording in the hot tier, keyed by the transcript of the turn it was spoken in
+// when that is known
+func (s *RecordingService) Save(ctx context.Context, sessionID, transcriptID, speaker, contentType string, data []byte) (*models.AudioRecording, error) {
+	key := fmt.Sprintf("%s/%s", sessionID, uuid.NewString())
+	var turn *string
+	if transcriptID != "" {
+		key = fmt.Sprintf("%s/%s-%s", sessionID, transcriptID, speaker)
+		turn = &transcriptID
+	}
+	if err := s.store.Put(ctx, key, s.hotClass, data); err != nil {
+		return nil, err
+	}
+
+	recording := &models.AudioRecording{
+		SessionID:    sessionID,
+		TranscriptID: turn,
+		Speaker:      speaker,
+		StorageKey:   key,
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		Tier:         models.RecordingTierHot,
+		StorageClass: s.hotClass,
+	}
+	if err := s.repo.CreateAudioRecording(ctx, recording); err != nil {
+		s.store.Delete(ctx, key, s.hotClass)
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Audio recording saved", "recording_id", recording.ID, "session_id", sessionID, "speaker", speaker, "size", len(data))
+	return recording, nil
+}
+
+// Replay returns the recording's audio if it is hot. For archived recordings it starts
+// an asynchronous restore and returns ErrRecordingNotReady; callers should poll.
+func (s *RecordingService) Replay(ctx context.Context, recording *models.AudioRecording) ([]byte, error) {
+	switch recording.Tier {
+	case models.RecordingTierHot:
+		return s.store.Get(ctx, recording.StorageKey, recording.StorageClass)
+	case models.RecordingTierCold:
+		started, err := s.repo.BeginRecordingRestore(ctx, recording.ID)
+		if err != nil {
+			return nil, err
+		}
+		if started {
+			logging.FromContext(ctx).Info("Restoring archived recording for replay", "recording_id", recording.ID)
+			go s.restore(*recording)
+		}
+		return nil, ErrRecordingNotReady
+	default:
+		return nil, ErrRecordingNotReady
+	}
+}
+
+// AudioURL returns a link that downloads a hot recording until it expires. Backends that sign
+// URLs serve it directly; otherwise the link is signed here and served by the API.
+func (s *RecordingService) AudioURL(recording *models.AudioRecording, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(s.urlTTL)
+	if signer, ok := s.store.(URLSigner); ok {
+		link, err := signer.SignedURL(recording.StorageKey, recording.StorageClass, recording.ContentType, s.urlTTL)
+		if !errors.Is(err, ErrURLSigningUnavailable) {
+			return link, expiresAt, err
+		}
+	}
+
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.sign(recording.ID, expires)},
+	}
+	return s.publicURL + "/api/v1/recordings/" + url.PathEscape(recording.ID) + "/audio?" + query.Encode(), expiresAt, nil
+}
+
+// VerifyAudio checks an audio link's signature and expiry
+func (s *RecordingService) VerifyAudio(recordingID, expires, signature string, now time.Time) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return ErrInvalidAudioSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(recordingID, expires))) {
+		return ErrInvalidAudioSignature
+	}
+	return nil
+}
+
+func (s *RecordingService) sign(recordingID, expires string) string {
+	return signDigest(string(s.secret), []byte(recordingID+":"+expires))
+}
+
+// restore copies an archived recording back to the hot class; the cold copy is kept
+// so the recording can be re-archived without another upload
+func (s *RecordingService) restore(recording models.AudioRecording) {
+	ctx := context.Background()
+
+	data, err := s.store.Get(ctx, recording.StorageKey, s.coldClass)
+	if err == nil {
+		err = s.store.Put(ctx, recording.StorageKey, s.hotClass, data)
+	}
+	if err != nil {
+		slog.Error("Failed to restore recording", "recording_id", recording.ID, "error", err)
+		s.repo.UpdateAudioRecording(ctx, recording.ID, map[string]interface{}{
+			"tier":       models.RecordingTierCold,
+			"last_error": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	s.repo.UpdateAudioRecording(ctx, recording.ID, map[string]interface{}{
+		"tier":          models.RecordingTierHot,
+		"storage_class": s.hotClass,
+		"restored_at":   &now,
+		"last_error":    "",
+	})
+	slog.Info("Recording restored", "recording_id", recording.ID)
+}
+
+// archive moves a hot recording to the cold class
+func (s *RecordingService) archive(ctx context.Context, recording models.AudioRecording) error {
+	// Restored recordings already have a cold copy; only the hot copy needs removing
+	if recording.ArchivedAt == nil {
+		data, err := s.store.Get(ctx, recording.StorageKey, recording.StorageClass)
+		if err != nil {
+			return err
+		}
+		if err := s.store.Put(ctx, recording.StorageKey, s.coldClass, data); err != nil {
+			return err
+		}
+	}
+
+	updates := map[string]interface{}{
+		"tier":          models.RecordingTierCold,
+		"storage_class": s.coldClass,
+		"last_error":    "",
+	}
+	if recording.ArchivedAt == nil {
+		updates["archived_at"] = time.Now()
+	}
+	if err := s.repo.UpdateAudioRecording(ctx, recording.ID, updates); err != nil {
+		return err
+	}
+
+	// Only drop the hot copy once the database points at the cold one
+	s.store.Delete(ctx, recording.StorageKey, recording.StorageClass)
+	return nil
+}
+
+// StartLifecycleJob periodically archives aged recordings and retries stalled restores
+func (s *RecordingService) StartLifecycleJob(lifecycle *Lifecycle) {
+	if s.coldAfter <= 0 {
+		slog.Info("Recording cold storage disabled")
+		return
+	}
+
+	lifecycle.Go("recording lifecycle", func(ctx context.Context) {
+		ticker := time.NewTicker(recordingLifecycleInterval)
+		defer ticker.Stop()
+
+		for {
+			s.runLifecycle(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func (s *RecordingService) runLifecycle(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	now := time.Now()
+
+	// A restored recording stays hot for keepHot rather than the full retention window
+	due, err := s.repo.GetRecordingsDueForArchive(ctx, now.Add(-s.coldAfter), now.Add(-s.keepHot), recordingLifecycleBatch)
+	if err == nil {
+		archived := 0
+		for _, recording := range due {
+			if err := s.archive(ctx, recording); err != nil {
+				logger.Error("Failed to archive recording", "recording_id", recording.ID, "error", err)
+				s.repo.UpdateAudioRecording(ctx, recording.ID, map[string]interface{}{"last_error": err.Error()})
+				continue
+			}
+			archived++
+		}
+		if archived > 0 {
+			logger.Info("Archived recordings to cold storage", "count", archived, "class", s.coldClass)
+		}
+	}
+
+	stalled, err := s.repo.GetStalledRestores(ctx, now.Add(-recordingRestoreTimeout), recordingLifecycleBatch)
+	if err == nil {
+		for _, recording := range stalled {
+			logger.Warn("Retrying stalled recording restore", "recording_id", recording.ID)
+			s.repo.UpdateAudioRecording(ctx, recording.ID, map[string]interface{}{"updated_at": now})
+			go s.restore(recording)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// Page size and margin of exported reports: US Letter, portrait, in points
+const (
+	reportPageWidth  = 612
+	reportPageHeight = 792
+	reportMargin     = 54
+)
+
+// Fonts of exported reports, as named in each page's resources
+const (
+	reportFontRegular = "F1" // Helvetica
+	reportFontBold    = "F2" // Helvetica-Bold
+	reportFontMono    = "F3" // Courier, for code
+)
+
+// reportBlock is a paragraph of a report, wrapped to the page width when laid out
+type reportBlock struct {
+	text  string
+	font  string
+	size  float64
+	space float64 // Extra space above the block
+	color string  // PDF fill color, empty for black
+}
+
+// reportLogoHeight is the most a brand's logo may take at the top of a report's first page
+const reportLogoHeight = 48
+
+// renderReportPDF writes a report as a paginated PDF, branded if brand isn't nil. Like
+// certificates it only uses the standard fonts, so text outside Latin-1 is replaced.
+func renderReportPDF(report *SessionReport, format *LocaleFormat, brand *Brand) []byte {
+	if brand == nil {
+		brand = &Brand{}
+	}
+	primary, accent := pdfColor(brand.PrimaryColor), pdfColor(brand.AccentColor)
+
+	var blocks []reportBlock
+	add := func(text, font string, size, space float64) {
+		blocks = append(blocks, reportBlock{text: text, font: font, size: size, space: space})
+	}
+	heading := func(text string, size, space float64) {
+		blocks = append(blocks, reportBlock{text: text, font: reportFontBold, size: size, space: space, color: primary})
+	}
+	label := func(text string) {
+		blocks = append(blocks, reportBlock{text: text, font: reportFontBold, size: 9, space: 10, color: accent})
+	}
+
+	title, subtitle, _ := strings.Cut(report.Header, "\n")
+	heading(title, 18, 0)
+	if subtitle = strings.TrimSpace(subtitle); subtitle != "" {
+		add(subtitle, reportFontRegular, 11, 4)
+	}
+	space := 10.0
+	for _, detail := range reportDetails(report, format) {
+		add(detail, reportFontRegular, 10, space)
+		space = 0
+	}
+
+	if summary := report.Summary; summary != nil {
+		heading("Summary", 14, 18)
+		add(fmt.Sprintf("Overall score: %s / 100%s", format.Number(summary.OverallScore, 0), reportPassed(summary)), reportFontBold, 10, 4)
+		add(strings.TrimSpace(summary.Summary), reportFontRegular, 10, 6)
+		for _, section := range reportSummarySections(summary) {
+			heading(section.title, 11, 10)
+			add(section.text, reportFontRegular, 10, 4)
+		}
+	}
+
+	if len(report.Scores) > 0 {
+		heading("Scores", 14, 18)
+		space := 4.0
+		for _, score := range report.Scores {
+			line := fmt.Sprintf("%s: %s / %s", score.Metric, format.Number(score.Score, 0), format.Number(score.MaxScore, 0))
+			add(line, reportFontRegular, 10, space)
+			space = 0
+		}
+	}
+
+	heading("Transcript", 14, 18)
+	for _, turn := range report.Transcript {
+		speaker := fmt.Sprintf("%s - %s", reportSpeakers[turn.Speaker], format.DateTime(turn.Timestamp))
+		if turn.Kind == models.TranscriptKindCode {
+			speaker += " - code"
+			if turn.Language != "" {
+				speaker += " (" + turn.Language + ")"
+			}
+			if turn.TestsTotal > 0 {
+				speaker += fmt.Sprintf(", passed %d of %d tests", turn.TestsPassed, turn.TestsTotal)
+			}
+			label(speaker)
+			add(strings.TrimRight(turn.Content, "\n"), reportFontMono, 8, 2)
+			continue
+		}
+		label(speaker)
+		add(strings.TrimSpace(turn.Content), reportFontRegular, 10, 2)
+	}
+
+	// The logo sits above the header on the first page
+	var logo string
+	first := float64(reportPageHeight - reportMargin)
+	if brand.Logo != nil {
+		width, height := logoSize(brand.Logo, reportPageWidth-2*reportMargin, reportLogoHeight)
+		first -= height + 12
+		logo = fmt.Sprintf("q %.2f 0 0 %.2f %d %.2f cm /Im1 Do Q\n", width, height, reportMargin, first+12)
+	}
+	// The footer text sits above the page number, and the content above both
+	var footer []string
+	if text := strings.TrimSpace(brand.FooterText); text != "" {
+		footer = wrapReportText(text, reportFontRegular, 8, reportPageWidth-2*reportMargin)
+	}
+	bottom := float64(reportMargin + 12 + 10*len(footer))
+
+	pages := layoutReportPages(blocks, first, bottom)
+	pages[0] = logo + pages[0]
+	return writeReportPDF(pages, footer, brand.Logo)
+}
+
+// layoutReportPages wraps blocks to the page width and breaks them into pages of content
+// streams between top and bottom, starting the first page at first rather than the top
+func layoutReportPages(blocks []reportBlock, first, bottom float64) []string {
+	var pages []string
+	var page strings.Builder
+	top := float64(reportPageHeight - reportMargin)
+	y := first
+
+	for _, block := range blocks {
+		lineHeight := block.size * 1.35
+		if page.Len() > 0 {
+			y -= block.space
+		}
+		color := block.color
+		if color == "" {
+			color = "0 0 0"
+		}
+		for _, line := range wrapReportText(block.text, block.font, block.size, reportPageWidth-2*reportMargin) {
+			if y-lineHeight < bottom {
+				pages = append(pages, page.String())
+				page.Reset()
+				y = top
+			}
+			y -= lineHeight
+			if line != "" {
+				fmt.Fprintf(&page, "BT %s rg /%s %.1f Tf %d %.2f Td (%s) Tj ET\n", color, block.font, block.size, reportMargin, y, pdfEscape(line))
+			}
+		}
+	}
+	return append(pages, page.String())
+}
+
+// wrapReportText breaks text into Latin-1 lines no wider than width points. Code keeps its
+// indentation and is broken at the width; prose is broken between words.
+func wrapReportText(text, font string, size, width float64) []string {
+	maxWidth := width * 1000 / size
+	measure := helveticaWidth
+	if font == reportFontBold {
+		// Bold runs about 5% wider than regular
+		measure = func(s string) float64 { return helveticaWidth(s) * 1.05 }
+	} else if font == reportFontMono {
+		measure = func(s string) float64 { return float64(len(s)) * 600 }
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		paragraph = pdfLatin1(strings.TrimRight(paragraph, "\r"))
+		if font == reportFontMono {
+			for measure(paragraph) > maxWidth {
+				cut := int(maxWidth / 600)
+				lines = append(lines, paragraph[:cut])
+				paragraph = paragraph[cut:]
+			}
+			lines = append(lines, paragraph)
+			continue
+		}
+
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			// Words wider than the page, such as URLs, are broken across lines
+			for measure(word) > maxWidth {
+				cut := len(word) - 1
+				for cut > 1 && measure(word[:cut]) > maxWidth {
+					cut--
+				}
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			if candidate := strings.TrimSpace(line + " " + word); measure(candidate) <= maxWidth {
+				line = candidate
+				continue
+			}
+			lines = append(lines, line)
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// writeReportPDF assembles the pages, numbering each at the foot under the footer lines. A
+// logo is available to every page as /Im1.
+func writeReportPDF(pages, footer []string, logo image.Image) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // Pages, once the page objects are numbered
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	}
+	resources := fmt.Sprintf("/Font << /%s 3 0 R /%s 4 0 R /%s 5 0 R >>", reportFontRegular, reportFontBold, reportFontMono)
+	if logo != nil {
+		objects = append(objects, pdfImageObject(logo))
+		resources += fmt.Sprintf(" /XObject << /Im1 %d 0 R >>", len(objects))
+	}
+
+	kids := make([]string, len(pages))
+	for i, content := range pages {
+		lines := append(append([]string(nil), footer...), fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+		for j, line := range lines {
+			x := (reportPageWidth - helveticaWidth(line)*8/1000) / 2
+			y := reportMargin/2 + 10*(len(lines)-1-j)
+			content += fmt.Sprintf("BT 0 0 0 rg /%s 8 Tf %.2f %d Td (%s) Tj ET\n", reportFontRegular, x, y, pdfEscape(line))
+		}
+
+		pageObject := len(objects) + 1
+		kids[i] = fmt.Sprintf("%d 0 R", pageObject)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << %s >> /Contents %d 0 R >>", reportPageWidth, reportPageHeight, resources, pageObject+1),
+			pdfStream(content),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	return writePDF(objects)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// writeDeadlineGrace is how long past its deadline a slow request may keep writing, so it can
+// still report the timeout to the client
+const writeDeadlineGrace = 5 * time.Second
+
+// slowRoutes are uploads, exports and streamed downloads, which get SlowRequestTimeout instead
+// of RequestTimeout
+var slowRoutes = []string{
+	"/api/v1/documents",
+	"/api/v1/recordings",
+	"/api/v1/certificates",
+	"/api/v1/admin/research-dataset",
+}
+
+// untimedRoutes live as long as the client stays connected
+var untimedRoutes = []string{
+	"/api/v1/ws",
+}
+
+// RequestDeadline bounds each request by its route's timeout. The deadline is set on the
+// request context, so repository and provider calls made with r.Context() give up once it
+// passes. Slow routes also get their connection deadlines extended past the server-wide
+// ReadTimeout and WriteTimeout.
+func RequestDeadline(cfg ServerConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := routeTimeout(r.URL.Path, cfg)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			if timeout > cfg.ReadTimeout || timeout > cfg.WriteTimeout {
+				rc := http.NewResponseController(w)
+				deadline := time.Now().Add(timeout + writeDeadlineGrace)
+				rc.SetReadDeadline(deadline)
+				rc.SetWriteDeadline(deadline)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			if ctx.Err() == context.DeadlineExceeded {
+				logging.FromContext(r.Context()).Warn("Request exceeded its deadline", "method", r.Method, "path", r.URL.Path, "timeout", timeout)
+			}
+		})
+	}
+}
+
+// routeTimeout returns the time budget of a request path; 0 leaves it unbounded
+func routeTimeout(path string, cfg ServerConfig) time.Duration {
+	for _, prefix := range untimedRoutes {
+		if strings.HasPrefix(path, prefix) {
+			return 0
+		}
+	}
+	for _, prefix := range slowRoutes {
+		if strings.HasPrefix(path, prefix) {
+			return cfg.SlowRequestTimeout
+		}
+	}
+	return cfg.RequestTimeout
+}
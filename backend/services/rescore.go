@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// rescorePollInterval is how often the backfill worker looks for backfills due to queue more
+	rescorePollInterval = 10 * time.Second
+	// rescoreLease keeps a claimed backfill from being claimed again while it is being worked
+	rescoreLease = time.Minute
+	// DefaultRescoreDays is how far back a backfill reaches unless the admin says otherwise
+	DefaultRescoreDays = 30
+	MaxRescoreDays     = 365
+)
+
+// RescoreRequest is the body for starting a re-scoring backfill
+type RescoreRequest struct {
+	ScoringPolicyID *string `json:"scoring_policy_id,omitempty"` // Only sessions scored under an older version of this policy
+	AgentID         *string `json:"agent_id,omitempty"`          // Only this agent's sessions
+	Days            int     `json:"days,omitempty"`              // Sessions started in the last this many days; defaults to 30
+	RatePerMinute   int     `json:"rate_per_minute,omitempty"`   // Defaults to SUMMARY_RESCORE_RATE
+}
+
+// RescoreProgress reports how far a backfill has got
+type RescoreProgress struct {
+	*models.RescoreBackfill
+	Completed       int64      `json:"completed"`                  // Sessions re-scored
+	Failed          int64      `json:"failed"`                     // Sessions whose re-scoring failed or was cancelled
+	InFlight        int64      `json:"in_flight"`                  // Sessions queued or being re-scored
+	Percent         float64    `json:"percent"`                    // Finished sessions out of the total
+	EstimatedFinish *time.Time `json:"estimated_finish,omitempty"` // At the backfill's rate, while it is running
+}
+
+// RescoreStep returns how many sessions a backfill queues at a time and how long it waits before
+// queuing more, so it averages ratePerMinute without queuing more often than the worker polls
+func RescoreStep(ratePerMinute int) (int, time.Duration) {
+	ratePerMinute = max(ratePerMinute, 1)
+	batch := max(ratePerMinute*int(rescorePollInterval)/int(time.Minute), 1)
+	return batch, time.Minute * time.Duration(batch) / time.Duration(ratePerMinute)
+}
+
+// RescoreService re-scores recent sessions' stored transcripts in the background, e.g. after a
+// scoring policy changes. Each session is regenerated by a summary job, so the superseded
+// summary is kept as a summary version, and the rate caps LLM spend.
+type RescoreService struct {
+	repo      *repository.GORMRepository
+	summaries *SummaryJobService
+	config    SummaryConfig
+}
+
+func NewRescoreService(repo *repository.GORMRepository, summaries *SummaryJobService, config SummaryConfig) *RescoreService {
+	config.RescoreRate = max(config.RescoreRate, 1)
+	config.MaxRescoreRate = max(config.MaxRescoreRate, config.RescoreRate)
+	return &RescoreService{
+		repo:      repo,
+		summaries: summaries,
+		config:    config,
+	}
+}
+
+// Start runs the worker that queues the sessions of running backfills at their rates
+func (s *RescoreService) Start(lifecycle *Lifecycle) {
+	lifecycle.Go("rescore backfills", func(ctx context.Context) {
+		ticker := time.NewTicker(rescorePollInterval)
+		defer ticker.Stop()
+
+		for {
+			for ctx.Err() == nil && s.runNext(ctx) {
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+	slog.Info("Rescore backfill worker started", "default_rate", s.config.RescoreRate, "max_rate", s.config.MaxRescoreRate)
+}
+
+// Begin validates a request and starts its backfill. Only one backfill runs at a time, so
+// backfills can't add up past the rate limit.
+func (s *RescoreService) Begin(ctx context.Context, admin *models.User, req RescoreRequest) (*models.RescoreBackfill, error) {
+	days := req.Days
+	if days == 0 {
+		days = DefaultRescoreDays
+	}
+	if days < 1 || days > MaxRescoreDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", MaxRescoreDays)
+	}
+	rate := req.RatePerMinute
+	if rate == 0 {
+		rate = s.config.RescoreRate
+	}
+	if rate < 1 || rate > s.config.MaxRescoreRate {
+		return nil, fmt.Errorf("rate_per_minute must be between 1 and %d", s.config.MaxRescoreRate)
+	}
+
+	backfill := &models.RescoreBackfill{
+		RequestedBy:   admin.ID,
+		Since:         time.Now().AddDate(0, 0, -days),
+		RatePerMinute: rate,
+		Status:        models.RescoreBackfillRunning,
+		NextRunAt:     time.Now(),
+	}
+	if req.ScoringPolicyID != nil && *req.ScoringPolicyID != "" {
+		policy, err := s.repo.GetScoringPolicy(ctx, *req.ScoringPolicyID)
+		if err != nil {
+			return nil, err
+		}
+		if policy == nil {
+			return nil, fmt.Errorf("scoring policy not found")
+		}
+		backfill.ScoringPolicyID = &policy.ID
+	}
+	if req.AgentID != nil && *req.AgentID != "" {
+		agent, err := s.repo.GetAgent(ctx, *req.AgentID)
+		if err != nil {
+			return nil, err
+		}
+		if agent == nil {
+			return nil, fmt.Errorf("agent not found")
+		}
+		backfill.AgentID = &agent.ID
+	}
+
+	running, err := s.repo.GetRescoreBackfills(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(running) > 0 && running[0].Status == models.RescoreBackfillRunning {
+		return nil, fmt.Errorf("backfill %s is still running; cancel it or wait for it to finish", running[0].ID)
+	}
+
+	total, err := s.repo.CountRescoreCandidates(ctx, backfill)
+	if err != nil {
+		return nil, err
+	}
+	backfill.Total = int(total)
+	if err := s.repo.CreateRescoreBackfill(ctx, backfill); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Rescore backfill started", "backfill_id", backfill.ID, "admin_id", admin.ID, "total", backfill.Total,
+		"scoring_policy_id", backfill.ScoringPolicyID, "agent_id", backfill.AgentID, "since", backfill.Since, "rate_per_minute", rate)
+	return backfill, nil
+}
+
+// Cancel stops a running backfill, dropping its sessions that haven't started re-scoring. It
+// reports whether the backfill was running.
+func (s *RescoreService) Cancel(ctx context.Context, backfillID string) (bool, error) {
+	cancelled, err := s.repo.FinishRescoreBackfill(ctx, backfillID, models.RescoreBackfillCancelled)
+	if err == nil && cancelled {
+		logging.FromContext(ctx).Info("Rescore backfill cancelled", "backfill_id", backfillID)
+	}
+	return cancelled, err
+}
+
+// Progress reports a backfill's progress from its summary jobs
+func (s *RescoreService) Progress(ctx context.Context, backfill *models.RescoreBackfill) (*RescoreProgress, error) {
+	counts, err := s.repo.GetRescoreJobCounts(ctx, backfill.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &RescoreProgress{
+		RescoreBackfill: backfill,
+		Completed:       counts[models.SummaryJobDone],
+		Failed:          counts[models.SummaryJobFailed],
+		InFlight:        counts[models.SummaryJobPending] + counts[models.SummaryJobRunning],
+		Percent:         100,
+	}
+	if backfill.Total > 0 {
+		progress.Percent = min(float64(progress.Completed+progress.Failed)/float64(backfill.Total)*100, 100)
+	}
+	if backfill.Status == models.RescoreBackfillRunning {
+		remaining := max(backfill.Total-backfill.Queued, 0)
+		finish := backfill.NextRunAt.Add(time.Minute * time.Duration(remaining) / time.Duration(backfill.RatePerMinute))
+		progress.EstimatedFinish = &finish
+	}
+	return progress, nil
+}
+
+// runNext queues the next sessions of one due backfill, reporting whether there was one. A
+// backfill whose jobs have fallen a minute's worth behind waits for them, so a slow LLM isn't
+// buried in re-scoring work.
+func (s *RescoreService) runNext(ctx context.Context) bool {
+	logger := logging.FromContext(ctx)
+	backfill, err := s.repo.ClaimRescoreBackfill(ctx, rescoreLease)
+	if err != nil || backfill == nil {
+		return false
+	}
+	batch, wait := RescoreStep(backfill.RatePerMinute)
+
+	counts, err := s.repo.GetRescoreJobCounts(ctx, backfill.ID)
+	if err != nil {
+		return true
+	}
+	inFlight := counts[models.SummaryJobPending] + counts[models.SummaryJobRunning]
+	if inFlight >= int64(max(backfill.RatePerMinute, batch)) {
+		s.repo.AdvanceRescoreBackfill(ctx, backfill.ID, backfill.Cursor, 0, time.Now().Add(wait))
+		return true
+	}
+
+	sessionIDs, err := s.repo.NextRescoreSessions(ctx, backfill, batch)
+	if err != nil {
+		return true
+	}
+	if len(sessionIDs) == 0 {
+		if inFlight == 0 {
+			if done, err := s.repo.FinishRescoreBackfill(ctx, backfill.ID, models.RescoreBackfillDone); err == nil && done {
+				logger.Info("Rescore backfill done", "backfill_id", backfill.ID, "queued", backfill.Queued)
+			}
+		} else {
+			s.repo.AdvanceRescoreBackfill(ctx, backfill.ID, backfill.Cursor, 0, time.Now().Add(wait))
+		}
+		return true
+	}
+
+	cursor, queued := backfill.Cursor, 0
+	for _, sessionID := range sessionIDs {
+		if _, err := s.summaries.EnqueueRescore(ctx, sessionID, backfill.ID); err != nil {
+			logger.Error("Failed to queue rescore", "backfill_id", backfill.ID, "session_id", sessionID, "error", err)
+			break
+		}
+		cursor = sessionID
+		queued++
+	}
+	s.repo.AdvanceRescoreBackfill(ctx, backfill.ID, cursor, queued, time.Now().Add(wait))
+	return true
+}
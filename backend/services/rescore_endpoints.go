@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const recentRescoreBackfills = 20
+
+func (e *AdminEndpoints) GetRescoreBackfillsHandler(w http.ResponseWriter, r *http.Request) {
+	backfills, err := e.repo.GetRescoreBackfills(r.Context(), recentRescoreBackfills)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get rescore backfills"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"backfills": backfills,
+		"count":     len(backfills),
+	})
+}
+
+// StartRescoreBackfillHandler starts re-scoring recent sessions' stored transcripts, e.g. after
+// a scoring policy was updated. Sessions are re-scored in the background at the given rate.
+func (e *AdminEndpoints) StartRescoreBackfillHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req RescoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	backfill, err := e.rescore.Begin(r.Context(), admin, req)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Rescore backfill not started", "admin_id", admin.ID, "error", err)
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"backfill": backfill,
+		"message":  "Rescore backfill started",
+	})
+}
+
+// GetRescoreBackfillHandler reports a backfill's progress, for polling while it runs
+func (e *AdminEndpoints) GetRescoreBackfillHandler(w http.ResponseWriter, r *http.Request) {
+	backfill, err := e.repo.GetRescoreBackfill(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get rescore backfill"))
+		return
+	}
+	if backfill == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Rescore backfill not found"))
+		return
+	}
+
+	progress, err := e.rescore.Progress(r.Context(), backfill)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get rescore progress"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"backfill": progress,
+	})
+}
+
+func (e *AdminEndpoints) CancelRescoreBackfillHandler(w http.ResponseWriter, r *http.Request) {
+	cancelled, err := e.rescore.Cancel(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to cancel rescore backfill"))
+		return
+	}
+	if !cancelled {
+		apperrors.Write(w, r, apperrors.NotFound("No running rescore backfill with that ID"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Rescore backfill cancelled",
+	})
+}
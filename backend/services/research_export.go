@@ -0,0 +1,184 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// researchExportPageSize is how many sessions are loaded per query while streaming an export
+const researchExportPageSize = 100
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	urlPattern   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().\-]{7,}\d`)
+)
+
+// ResearchSession is one anonymized session in a research dataset
+type ResearchSession struct {
+	SessionHash  string             `json:"session_hash"`
+	UserHash     string             `json:"user_hash"`
+	AgentID      string             `json:"agent_id"` // Agents are not personal data
+	Industry     string             `json:"industry,omitempty"`
+	Level        string             `json:"level,omitempty"`
+	Language     string             `json:"language"`
+	Duration     int                `json:"duration"`
+	EndedMonth   string             `json:"ended_month"` // Month only, e.g. 2025-01
+	OverallScore *float64           `json:"overall_score,omitempty"`
+	Scores       map[string]float64 `json:"scores,omitempty"`
+	Turns        []ResearchTurn     `json:"turns"`
+}
+
+// ResearchTurn is one scrubbed turn of an anonymized transcript
+type ResearchTurn struct {
+	Speaker  string `json:"speaker"`
+	Content  string `json:"content"`
+	Kind     string `json:"kind"`               // text, code
+	Language string `json:"language,omitempty"` // Programming language of a code turn
+	ReplyTo  *int   `json:"reply_to,omitempty"` // Index of the code turn a code analysis responds to
+}
+
+// ResearchExportService produces anonymized research datasets from the sessions of users who
+// opted in. User and session IDs are replaced by keyed hashes and transcripts are scrubbed of
+// contact details and the candidate's own name.
+type ResearchExportService struct {
+	repo     *repository.GORMRepository
+	hashSalt string
+}
+
+func NewResearchExportService(repo *repository.GORMRepository, hashSalt string) *ResearchExportService {
+	return &ResearchExportService{
+		repo:     repo,
+		hashSalt: hashSalt,
+	}
+}
+
+// Export streams sessions ended within [from, to) as gzipped JSON lines, returning how many
+// were written. Without a configured salt each export uses a random one, so hashes can't be
+// linked across exports.
+func (s *ResearchExportService) Export(ctx context.Context, w io.Writer, from, to time.Time) (int, error) {
+	salt := s.hashSalt
+	if salt == "" {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return 0, err
+		}
+		salt = hex.EncodeToString(random)
+	}
+
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+
+	count := 0
+	afterID := ""
+	for {
+		sessions, err := s.repo.GetResearchSessions(ctx, from, to, afterID, researchExportPageSize)
+		if err != nil {
+			return count, err
+		}
+		for i := range sessions {
+			if err := encoder.Encode(anonymizeSession(&sessions[i], salt)); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(sessions) < researchExportPageSize {
+			break
+		}
+		afterID = sessions[len(sessions)-1].ID
+	}
+
+	if err := gz.Close(); err != nil {
+		return count, err
+	}
+	logging.FromContext(ctx).Info("Research dataset exported", "sessions", count, "from", from, "to", to)
+	return count, nil
+}
+
+func anonymizeSession(session *models.InterviewSession, salt string) ResearchSession {
+	identifiers := strings.Fields(session.User.FullName)
+	if local, _, ok := strings.Cut(session.User.Email, "@"); ok {
+		identifiers = append(identifiers, local)
+	}
+
+	result := ResearchSession{
+		SessionHash: researchHash(salt, "session:"+session.ID),
+		UserHash:    researchHash(salt, "user:"+session.UserID),
+		AgentID:     session.AgentID,
+		Industry:    session.Agent.Industry,
+		Level:       session.Agent.Level,
+		Language:    session.Language,
+		Duration:    session.Duration,
+		Turns:       []ResearchTurn{},
+	}
+	if session.EndedAt != nil {
+		result.EndedMonth = session.EndedAt.UTC().Format("2006-01")
+	}
+	if session.Summary != nil {
+		result.OverallScore = &session.Summary.OverallScore
+	}
+	if len(session.PerformanceScores) > 0 {
+		result.Scores = make(map[string]float64, len(session.PerformanceScores))
+		for _, score := range session.PerformanceScores {
+			result.Scores[score.Metric] = score.Score
+		}
+	}
+	// Turns link by index rather than transcript ID, which would identify the session
+	turnIndex := make(map[string]int)
+	for _, transcript := range scoredTranscripts(session.Transcripts) {
+		kind := transcript.Kind
+		if kind == "" {
+			kind = models.TranscriptKindText
+		}
+		turn := ResearchTurn{
+			Speaker:  transcript.Speaker,
+			Content:  AnonymizeText(transcript.Content, identifiers),
+			Kind:     kind,
+			Language: transcript.Language,
+		}
+		if transcript.ReplyToID != nil {
+			if index, ok := turnIndex[*transcript.ReplyToID]; ok {
+				turn.ReplyTo = &index
+			}
+		}
+		turnIndex[transcript.ID] = len(result.Turns)
+		result.Turns = append(result.Turns, turn)
+	}
+	return result
+}
+
+// AnonymizeText strips email addresses, URLs, phone numbers and the given identifiers (such as
+// the candidate's name) from free text
+func AnonymizeText(text string, identifiers []string) string {
+	text = emailPattern.ReplaceAllString(text, "[EMAIL]")
+	text = urlPattern.ReplaceAllString(text, "[URL]")
+	text = phonePattern.ReplaceAllString(text, "[PHONE]")
+	for _, identifier := range identifiers {
+		// Very short tokens like initials would redact ordinary words
+		if len(identifier) < 3 {
+			continue
+		}
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(identifier) + `\b`)
+		text = pattern.ReplaceAllString(text, "[NAME]")
+	}
+	return text
+}
+
+func researchHash(salt, value string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// Rubric bounds, so the summary prompt stays short enough to score every item
+const (
+	maxRubricItems             = 10
+	maxRubricMetricLength      = 100
+	maxRubricDescriptionLength = 500
+	maxRubricWeight            = 100
+)
+
+// RubricItemRequest is one metric of a custom scoring rubric
+type RubricItemRequest struct {
+	Metric      string  `json:"metric"`
+	Description string  `json:"description,omitempty"`
+	Weight      float64 `json:"weight"` // Relative to the other items; 0 counts as 1
+}
+
+type RubricRequest struct {
+	Items []RubricItemRequest `json:"items"`
+}
+
+// ValidateRubric checks a custom rubric and returns its items in order. Metric names must be
+// unique, ignoring case, and can't take the names of the metrics scored for every session.
+func ValidateRubric(req RubricRequest) ([]models.RubricItem, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("rubric must have at least one item")
+	}
+	if len(req.Items) > maxRubricItems {
+		return nil, fmt.Errorf("rubric can have at most %d items", maxRubricItems)
+	}
+
+	items := make([]models.RubricItem, len(req.Items))
+	seen := make(map[string]bool, len(req.Items))
+	for i, item := range req.Items {
+		metric := strings.TrimSpace(item.Metric)
+		key := strings.ToLower(metric)
+		switch {
+		case metric == "":
+			return nil, fmt.Errorf("item %d has no metric", i+1)
+		case utf8.RuneCountInString(metric) > maxRubricMetricLength:
+			return nil, fmt.Errorf("metric %q is longer than %d characters", metric, maxRubricMetricLength)
+		case seen[key]:
+			return nil, fmt.Errorf("duplicate metric %q", metric)
+		case strings.HasPrefix(key, "stage:") || strings.HasPrefix(key, strings.ToLower(speechMetricPrefix)) || key == strings.ToLower(writingQualityMetric) || key == "unit tests":
+			return nil, fmt.Errorf("metric %q is reserved", metric)
+		case utf8.RuneCountInString(item.Description) > maxRubricDescriptionLength:
+			return nil, fmt.Errorf("description of %q is longer than %d characters", metric, maxRubricDescriptionLength)
+		case item.Weight < 0 || item.Weight > maxRubricWeight:
+			return nil, fmt.Errorf("weight of %q must be between 0 and %d", metric, maxRubricWeight)
+		}
+		seen[key] = true
+
+		weight := item.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		items[i] = models.RubricItem{
+			Metric:      metric,
+			Description: strings.TrimSpace(item.Description),
+			Weight:      weight,
+		}
+	}
+	return items, nil
+}
+
+// GetRubricHandler returns the items of an agent's custom scoring rubric; none when its
+// sessions are scored on its interview type's metrics
+func (e *AgentEndpoints) GetRubricHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := e.repo.GetAgentRubric(r.Context(), agent.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get rubric"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id": agent.ID,
+		"items":    items,
+		"count":    len(items),
+	})
+}
+
+// PutRubricHandler replaces an agent's custom scoring rubric. Summaries generated from then on
+// score its items; existing summaries keep their scores until regenerated.
+func (e *AgentEndpoints) PutRubricHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	var req RubricRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	items, err := ValidateRubric(req)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	if err := e.repo.ReplaceAgentRubric(r.Context(), agent.ID, items); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to save rubric"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Agent rubric updated", "agent_id", agent.ID, "items", len(items))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id": agent.ID,
+		"items":    items,
+		"count":    len(items),
+	})
+}
+
+// DeleteRubricHandler removes an agent's custom rubric, reverting to its interview type's metrics
+func (e *AgentEndpoints) DeleteRubricHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.repo.ReplaceAgentRubric(r.Context(), agent.ID, nil); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to remove rubric"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Agent rubric removed", "agent_id", agent.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// ScoringPolicyRequest is the body for creating or updating a scoring policy
+type ScoringPolicyRequest struct {
+	Name         string               `json:"name"`
+	Weights      map[string]float64   `json:"weights"`                  // Metric name to weight; empty keeps the model's score
+	Gates        []models.ScoringGate `json:"gates,omitempty"`          // e.g. [{"metric": "Communication", "min": 40}]
+	PassScore    float64              `json:"pass_score"`               // Minimum OverallScore to pass
+	GateScoreCap *float64             `json:"gate_score_cap,omitempty"` // OverallScore ceiling when a gate fails
+}
+
+// GateResult records whether a single minimum gate held
+type GateResult struct {
+	Metric string   `json:"metric"`
+	Min    float64  `json:"min"`
+	Score  *float64 `json:"score"` // nil when the metric wasn't scored, which fails the gate
+	Passed bool     `json:"passed"`
+}
+
+// ScoreBreakdown is the audit record of how a policy produced a summary's OverallScore
+type ScoreBreakdown struct {
+	PolicyName    string             `json:"policy_name"`
+	Weights       map[string]float64 `json:"weights"`
+	Metrics       map[string]float64 `json:"metrics"`
+	WeightedScore float64            `json:"weighted_score"`
+	Gates         []GateResult       `json:"gates"`
+	Capped        bool               `json:"capped"`
+}
+
+// ScoringResult is the outcome of applying a scoring policy
+type ScoringResult struct {
+	OverallScore float64
+	Passed       bool
+	Breakdown    ScoreBreakdown
+}
+
+// ScoringPolicyService resolves and applies the scoring policy for an agent's sessions
+type ScoringPolicyService struct {
+	repo *repository.GORMRepository
+}
+
+func NewScoringPolicyService(repo *repository.GORMRepository) *ScoringPolicyService {
+	return &ScoringPolicyService{repo: repo}
+}
+
+// PolicyForAgent returns the agent's assigned policy, falling back to the site-wide default.
+// It returns nil when neither is set, in which case the model's score is used as is.
+func (s *ScoringPolicyService) PolicyForAgent(ctx context.Context, agent *models.Agent) (*models.ScoringPolicy, error) {
+	if agent.ScoringPolicyID != nil {
+		policy, err := s.repo.GetScoringPolicy(ctx, *agent.ScoringPolicyID)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			return policy, nil
+		}
+	}
+	return s.repo.GetDefaultScoringPolicy(ctx)
+}
+
+// Finalize applies the agent's scoring policy to a summary before it is saved, recording
+// the policy version, the model's raw score and the breakdown so the score can be audited.
+// A policy that can't be loaded leaves the model's score in place.
+func (s *ScoringPolicyService) Finalize(ctx context.Context, agent *models.Agent, summary *models.InterviewSummary, scores []models.PerformanceScore) {
+	logger := logging.FromContext(ctx)
+	policy, err := s.PolicyForAgent(ctx, agent)
+	if err != nil {
+		logger.Error("Failed to resolve scoring policy, keeping model score", "session_id", summary.SessionID, "error", err)
+		return
+	}
+	if policy == nil {
+		return
+	}
+
+	result, err := ApplyScoringPolicy(policy, summary.OverallScore, scores)
+	if err != nil {
+		logger.Error("Invalid scoring policy, keeping model score", "session_id", summary.SessionID, "policy_id", policy.ID, "error", err)
+		return
+	}
+	breakdown, err := json.Marshal(result.Breakdown)
+	if err != nil {
+		logger.Error("Failed to encode score breakdown", "session_id", summary.SessionID, "error", err)
+		return
+	}
+
+	rawScore := summary.OverallScore
+	summary.RawScore = &rawScore
+	summary.OverallScore = result.OverallScore
+	summary.Passed = &result.Passed
+	summary.ScoringPolicyID = &policy.ID
+	summary.ScoringPolicyVersion = policy.Version
+	summary.ScoreBreakdown = string(breakdown)
+
+	logger.Info("Scoring policy applied", "session_id", summary.SessionID, "policy_id", policy.ID, "raw_score", rawScore, "overall_score", result.OverallScore, "passed", result.Passed)
+}
+
+// ApplyScoringPolicy computes the OverallScore from metric scores: the weighted average of the
+// weighted metrics (the raw score when none are weighted or scored), capped at GateScoreCap
+// when any gate fails. Metric names are matched case-insensitively.
+func ApplyScoringPolicy(policy *models.ScoringPolicy, rawScore float64, scores []models.PerformanceScore) (ScoringResult, error) {
+	weights, gates, err := decodeScoringPolicy(policy)
+	if err != nil {
+		return ScoringResult{}, err
+	}
+
+	metrics := make(map[string]float64, len(scores))
+	for _, score := range scores {
+		value := score.Score
+		if score.MaxScore > 0 && score.MaxScore != 100 {
+			value = score.Score / score.MaxScore * 100
+		}
+		metrics[strings.ToLower(score.Metric)] = value
+	}
+
+	breakdown := ScoreBreakdown{
+		PolicyName: policy.Name,
+		Weights:    weights,
+		Metrics:    metrics,
+		Gates:      make([]GateResult, 0, len(gates)),
+	}
+
+	var weighted, totalWeight float64
+	for metric, weight := range weights {
+		if value, ok := metrics[strings.ToLower(metric)]; ok {
+			weighted += value * weight
+			totalWeight += weight
+		}
+	}
+	overall := rawScore
+	if totalWeight > 0 {
+		overall = weighted / totalWeight
+	}
+	breakdown.WeightedScore = roundScore(overall)
+
+	gatesPassed := true
+	for _, gate := range gates {
+		result := GateResult{Metric: gate.Metric, Min: gate.Min}
+		if value, ok := metrics[strings.ToLower(gate.Metric)]; ok {
+			result.Score = &value
+			result.Passed = value >= gate.Min
+		}
+		if !result.Passed {
+			gatesPassed = false
+		}
+		breakdown.Gates = append(breakdown.Gates, result)
+	}
+
+	if !gatesPassed && policy.GateScoreCap != nil && overall > *policy.GateScoreCap {
+		overall = *policy.GateScoreCap
+		breakdown.Capped = true
+	}
+	overall = roundScore(overall)
+
+	return ScoringResult{
+		OverallScore: overall,
+		Passed:       gatesPassed && overall >= policy.PassScore,
+		Breakdown:    breakdown,
+	}, nil
+}
+
+// ValidateScoringPolicy checks a policy request before it is stored
+func ValidateScoringPolicy(req ScoringPolicyRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	for metric, weight := range req.Weights {
+		if strings.TrimSpace(metric) == "" {
+			return fmt.Errorf("weights must name a metric")
+		}
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("weight for %q must be a non-negative number", metric)
+		}
+	}
+	for _, gate := range req.Gates {
+		if strings.TrimSpace(gate.Metric) == "" {
+			return fmt.Errorf("gates must name a metric")
+		}
+		if gate.Min < 0 || gate.Min > 100 {
+			return fmt.Errorf("gate minimum for %q must be between 0 and 100", gate.Metric)
+		}
+	}
+	if req.PassScore < 0 || req.PassScore > 100 {
+		return fmt.Errorf("pass_score must be between 0 and 100")
+	}
+	if req.GateScoreCap != nil && (*req.GateScoreCap < 0 || *req.GateScoreCap > 100) {
+		return fmt.Errorf("gate_score_cap must be between 0 and 100")
+	}
+	return nil
+}
+
+// applyScoringPolicyRequest copies a validated request onto a policy
+func applyScoringPolicyRequest(policy *models.ScoringPolicy, req ScoringPolicyRequest) error {
+	weights := req.Weights
+	if weights == nil {
+		weights = map[string]float64{}
+	}
+	gates := req.Gates
+	if gates == nil {
+		gates = []models.ScoringGate{}
+	}
+
+	encodedWeights, err := json.Marshal(weights)
+	if err != nil {
+		return err
+	}
+	encodedGates, err := json.Marshal(gates)
+	if err != nil {
+		return err
+	}
+
+	policy.Name = strings.TrimSpace(req.Name)
+	policy.Weights = string(encodedWeights)
+	policy.Gates = string(encodedGates)
+	policy.PassScore = req.PassScore
+	policy.GateScoreCap = req.GateScoreCap
+	return nil
+}
+
+func decodeScoringPolicy(policy *models.ScoringPolicy) (map[string]float64, []models.ScoringGate, error) {
+	weights := map[string]float64{}
+	if policy.Weights != "" {
+		if err := json.Unmarshal([]byte(policy.Weights), &weights); err != nil {
+			return nil, nil, fmt.Errorf("decode weights: %w", err)
+		}
+	}
+	var gates []models.ScoringGate
+	if policy.Gates != "" {
+		if err := json.Unmarshal([]byte(policy.Gates), &gates); err != nil {
+			return nil, nil, fmt.Errorf("decode gates: %w", err)
+		}
+	}
+	return weights, gates, nil
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// ScoringPolicyEndpoints lets agent owners manage the scoring policies for their agents.
+// Site-wide policies are managed under /admin/scoring-policies.
+type ScoringPolicyEndpoints struct {
+	repo *repository.GORMRepository
+}
+
+func NewScoringPolicyEndpoints(repo *repository.GORMRepository) *ScoringPolicyEndpoints {
+	return &ScoringPolicyEndpoints{repo: repo}
+}
+
+func (e *ScoringPolicyEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/scoring-policies", func(r chi.Router) {
+		r.Get("/", e.GetScoringPoliciesHandler)
+		r.Post("/", e.CreateScoringPolicyHandler)
+		r.Get("/{id}", e.GetScoringPolicyHandler)
+		r.Put("/{id}", e.UpdateScoringPolicyHandler)
+		r.Delete("/{id}", e.DeleteScoringPolicyHandler)
+	})
+}
+
+// GetScoringPoliciesHandler lists the user's own policies and the site-wide ones they can assign
+func (e *ScoringPolicyEndpoints) GetScoringPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	policies, err := e.repo.GetScoringPolicies(r.Context(), &user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get scoring policies"))
+		return
+	}
+	sitePolicies, err := e.repo.GetScoringPolicies(r.Context(), nil)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get scoring policies"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"policies":      policies,
+		"site_policies": sitePolicies,
+	})
+}
+
+func (e *ScoringPolicyEndpoints) CreateScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+	createScoringPolicy(w, r, e.repo, &user.ID)
+}
+
+func (e *ScoringPolicyEndpoints) GetScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	policy, ok := e.ownedPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"policy": policy,
+	})
+}
+
+func (e *ScoringPolicyEndpoints) UpdateScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	policy, ok := e.ownedPolicy(w, r)
+	if !ok {
+		return
+	}
+	updateScoringPolicy(w, r, e.repo, policy)
+}
+
+func (e *ScoringPolicyEndpoints) DeleteScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	policy, ok := e.ownedPolicy(w, r)
+	if !ok {
+		return
+	}
+	deleteScoringPolicy(w, r, e.repo, policy)
+}
+
+// ownedPolicy loads a policy the current user owns, writing the error response if there isn't one
+func (e *ScoringPolicyEndpoints) ownedPolicy(w http.ResponseWriter, r *http.Request) (*models.ScoringPolicy, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return nil, false
+	}
+
+	policy, err := e.repo.GetScoringPolicy(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get scoring policy"))
+		return nil, false
+	}
+	if policy == nil || policy.OwnerID == nil || *policy.OwnerID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Scoring policy not found"))
+		return nil, false
+	}
+	return policy, true
+}
+
+type AgentScoringPolicyRequest struct {
+	PolicyID *string `json:"policy_id"` // null reverts to the site default
+}
+
+// PutScoringPolicyHandler assigns one of the owner's policies, or a site-wide one, to an agent.
+// The policy applies to summaries finalized from then on; existing summaries keep their score.
+func (e *AgentEndpoints) PutScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	agent, ok := e.ownedAgent(w, r)
+	if !ok {
+		return
+	}
+
+	var req AgentScoringPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	if req.PolicyID != nil {
+		policy, err := e.repo.GetScoringPolicy(r.Context(), *req.PolicyID)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to get scoring policy"))
+			return
+		}
+		if policy == nil || (policy.OwnerID != nil && *policy.OwnerID != *agent.UserID) {
+			apperrors.Write(w, r, apperrors.NotFound("Scoring policy not found"))
+			return
+		}
+	}
+
+	if err := e.repo.SetAgentScoringPolicy(r.Context(), agent.ID, req.PolicyID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to assign scoring policy"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Agent scoring policy assigned", "agent_id", agent.ID, "policy_id", req.PolicyID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id":  agent.ID,
+		"policy_id": req.PolicyID,
+	})
+}
+
+func (e *AdminEndpoints) GetSiteScoringPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	policies, err := e.repo.GetScoringPolicies(r.Context(), nil)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get scoring policies"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"policies": policies,
+	})
+}
+
+func (e *AdminEndpoints) CreateSiteScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	createScoringPolicy(w, r, e.repo, nil)
+}
+
+func (e *AdminEndpoints) UpdateSiteScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	policy, ok := e.sitePolicy(w, r)
+	if !ok {
+		return
+	}
+	updateScoringPolicy(w, r, e.repo, policy)
+}
+
+func (e *AdminEndpoints) DeleteSiteScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	policy, ok := e.sitePolicy(w, r)
+	if !ok {
+		return
+	}
+	deleteScoringPolicy(w, r, e.repo, policy)
+}
+
+// SetDefaultScoringPolicyHandler makes a site-wide policy apply to agents without their own
+func (e *AdminEndpoints) SetDefaultScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	policy, ok := e.sitePolicy(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.repo.SetDefaultScoringPolicy(r.Context(), policy.ID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to set default scoring policy"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Default scoring policy set", "policy_id", policy.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"default_policy_id": policy.ID,
+	})
+}
+
+func (e *AdminEndpoints) sitePolicy(w http.ResponseWriter, r *http.Request) (*models.ScoringPolicy, bool) {
+	policy, err := e.repo.GetScoringPolicy(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get scoring policy"))
+		return nil, false
+	}
+	if policy == nil || policy.OwnerID != nil {
+		apperrors.Write(w, r, apperrors.NotFound("Scoring policy not found"))
+		return nil, false
+	}
+	return policy, true
+}
+
+func createScoringPolicy(w http.ResponseWriter, r *http.Request, repo *repository.GORMRepository, ownerID *string) {
+	var req ScoringPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if err := ValidateScoringPolicy(req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	policy := &models.ScoringPolicy{OwnerID: ownerID, Version: 1}
+	if err := applyScoringPolicyRequest(policy, req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid scoring policy"))
+		return
+	}
+	if err := repo.CreateScoringPolicy(r.Context(), policy); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create scoring policy"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Scoring policy created", "policy_id", policy.ID, "site_wide", ownerID == nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"policy": policy,
+	})
+}
+
+func updateScoringPolicy(w http.ResponseWriter, r *http.Request, repo *repository.GORMRepository, policy *models.ScoringPolicy) {
+	var req ScoringPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if err := ValidateScoringPolicy(req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	if err := applyScoringPolicyRequest(policy, req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid scoring policy"))
+		return
+	}
+	if err := repo.UpdateScoringPolicy(r.Context(), policy); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to update scoring policy"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Scoring policy updated", "policy_id", policy.ID, "version", policy.Version)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"policy": policy,
+	})
+}
+
+func deleteScoringPolicy(w http.ResponseWriter, r *http.Request, repo *repository.GORMRepository, policy *models.ScoringPolicy) {
+	if err := repo.DeleteScoringPolicy(r.Context(), policy.ID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete scoring policy"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Scoring policy deleted", "policy_id", policy.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const (
+	// maxSearchQueryLength bounds a search query, in characters
+	maxSearchQueryLength = 200
+	// defaultSearchSessions and maxSearchSessions bound how many sessions a search returns
+	defaultSearchSessions = 20
+	maxSearchSessions     = 50
+	// maxSessionMatches is how many matching turns are shown for each session
+	maxSessionMatches = 3
+)
+
+// Postgres marks the matched words with these control characters, which transcripts never
+// contain, so the snippet can be HTML-escaped before they become <mark> tags
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// searchHeadline are the ts_headline options of a snippet: up to two short fragments around
+// the matched words
+var searchHeadline = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxWords=25, MinWords=8, MaxFragments=2, FragmentDelimiter=" … "`
+
+// SearchMatch is a matching turn of a session found by a search
+type SearchMatch struct {
+	TranscriptID string    `json:"transcript_id"`
+	Speaker      string    `json:"speaker"`
+	Timestamp    time.Time `json:"timestamp"`
+	Snippet      string    `json:"snippet"` // HTML-escaped, with the matched words in <mark> tags
+}
+
+// SessionSearchResult is a session in which a search query was discussed, with its best
+// matching turns
+type SessionSearchResult struct {
+	SessionID string        `json:"session_id"`
+	AgentName string        `json:"agent_name"`
+	StartedAt time.Time     `json:"started_at"`
+	Matches   []SearchMatch `json:"matches"`
+}
+
+// HighlightSnippet HTML-escapes a ts_headline snippet and wraps the words it marked in <mark>
+// tags, so clients can render it as HTML
+func HighlightSnippet(snippet string) string {
+	escaped := html.EscapeString(strings.ToValidUTF8(snippet, ""))
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").Replace(escaped)
+}
+
+// groupSearchMatches groups matching turns by session, ordered by each session's best match,
+// keeping at most limit sessions and maxSessionMatches turns of each
+func groupSearchMatches(matches []models.TranscriptMatch, limit int) []SessionSearchResult {
+	results := []SessionSearchResult{}
+	index := make(map[string]int)
+	for _, match := range matches {
+		i, ok := index[match.SessionID]
+		if !ok {
+			if len(results) == limit {
+				continue
+			}
+			i = len(results)
+			index[match.SessionID] = i
+			results = append(results, SessionSearchResult{
+				SessionID: match.SessionID,
+				AgentName: match.AgentName,
+				StartedAt: match.StartedAt,
+			})
+		}
+		if len(results[i].Matches) < maxSessionMatches {
+			results[i].Matches = append(results[i].Matches, SearchMatch{
+				TranscriptID: match.TranscriptID,
+				Speaker:      match.Speaker,
+				Timestamp:    match.Timestamp,
+				Snippet:      HighlightSnippet(match.Snippet),
+			})
+		}
+	}
+	return results
+}
+
+// SearchHandler finds the user's past sessions in which a topic was discussed, using
+// Postgres full-text search over their transcripts
+func (e *SessionEndpoints) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("q is required"))
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		apperrors.Write(w, r, apperrors.BadRequest("q must be at most 200 characters"))
+		return
+	}
+
+	limit := defaultSearchSessions
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > maxSearchSessions {
+			apperrors.Write(w, r, apperrors.BadRequest("limit must be between 1 and 50"))
+			return
+		}
+		limit = parsed
+	}
+
+	// Enough turns for every session to show its matches, most of the time
+	matches, err := e.repo.SearchTranscripts(r.Context(), user.ID, query, searchHeadline, limit*maxSessionMatches*2)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to search transcripts"))
+		return
+	}
+
+	results := groupSearchMatches(matches, limit)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":    query,
+		"sessions": results,
+		"count":    len(results),
+	})
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// Secrets that can be loaded from a secrets provider instead of the environment
+const (
+	SecretGeminiAPIKey  = "gemini_api_key"
+	SecretElevenLabsKey = "elevenlabs_api_key"
+	SecretOpenAIAPIKey  = "openai_api_key"
+	SecretDeepgramKey   = "deepgram_api_key"
+	SecretJWT           = "jwt_secret"
+	SecretDatabaseURL   = "database_url"
+)
+
+// SecretsProvider fetches secrets from an external store. A reference names the secret
+// in the store, optionally followed by #field to pick one field of a JSON or KV secret.
+type SecretsProvider interface {
+	GetSecret(ctx context.Context, ref string) (string, error)
+}
+
+// NewSecretsProvider creates the provider selected by configuration; "none" returns nil
+func NewSecretsProvider(cfg SecretsConfig) (SecretsProvider, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "vault":
+		return NewVaultSecretsProvider(cfg.VaultAddr, cfg.VaultToken, cfg.VaultMount)
+	case "aws":
+		return NewAWSSecretsProvider(cfg.AWSRegion)
+	case "gcp":
+		return NewGCPSecretsProvider(context.Background(), cfg.GCPProject)
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q", cfg.Provider)
+	}
+}
+
+// SecretsManager holds secrets loaded from a provider, refreshes them periodically and
+// runs rotation hooks when a value changes so services can switch over without a restart
+type SecretsManager struct {
+	provider SecretsProvider
+	refs     map[string]string // Secret name to provider reference
+	values   map[string]string
+	hooks    map[string][]func(string)
+	interval time.Duration
+	mutex    sync.RWMutex
+}
+
+// LoadSecrets fetches every configured secret reference and overrides the matching
+// config values. Any failure is returned so the server doesn't start half-configured.
+func LoadSecrets(ctx context.Context, cfg *Config) (*SecretsManager, error) {
+	provider, err := NewSecretsProvider(cfg.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := &SecretsManager{
+		provider: provider,
+		refs:     make(map[string]string),
+		values:   make(map[string]string),
+		hooks:    make(map[string][]func(string)),
+		interval: cfg.Secrets.RefreshInterval,
+	}
+	if provider == nil {
+		return manager, nil
+	}
+
+	targets := map[string]*string{
+		SecretGeminiAPIKey:  &cfg.AI.GeminiAPIKey,
+		SecretElevenLabsKey: &cfg.AI.ElevenLabsKey,
+		SecretOpenAIAPIKey:  &cfg.AI.OpenAIKey,
+		SecretDeepgramKey:   &cfg.AI.DeepgramKey,
+		SecretJWT:           &cfg.JWT.Secret,
+		SecretDatabaseURL:   &cfg.Database.URL,
+	}
+	for name, ref := range cfg.Secrets.Refs {
+		if ref == "" {
+			continue
+		}
+		value, err := provider.GetSecret(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load secret %s: %w", name, err)
+		}
+		manager.refs[name] = ref
+		manager.values[name] = value
+		*targets[name] = value
+	}
+
+	logging.FromContext(ctx).Info("Secrets loaded", "provider", cfg.Secrets.Provider, "count", len(manager.refs))
+	return manager, nil
+}
+
+// Managed reports whether a secret comes from the provider
+func (m *SecretsManager) Managed(name string) bool {
+	_, ok := m.refs[name]
+	return ok
+}
+
+// Get returns the current value of a managed secret
+func (m *SecretsManager) Get(name string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.values[name]
+}
+
+// OnRotate registers a hook run with the new value whenever a managed secret changes
+func (m *SecretsManager) OnRotate(name string, hook func(value string)) {
+	if !m.Managed(name) {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.hooks[name] = append(m.hooks[name], hook)
+}
+
+// Refresh re-fetches every managed secret. Fetch errors keep the previous value.
+func (m *SecretsManager) Refresh(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	for name, ref := range m.refs {
+		value, err := m.provider.GetSecret(ctx, ref)
+		if err != nil {
+			logger.Error("Failed to refresh secret, keeping current value", "secret", name, "error", err)
+			continue
+		}
+
+		m.mutex.Lock()
+		changed := value != m.values[name]
+		m.values[name] = value
+		hooks := append([]func(string){}, m.hooks[name]...)
+		m.mutex.Unlock()
+
+		if !changed {
+			continue
+		}
+		logger.Warn("Secret rotated", "secret", name, "hooks", len(hooks))
+		for _, hook := range hooks {
+			hook(value)
+		}
+	}
+}
+
+// StartRefresh refreshes secrets in the background at the configured interval
+func (m *SecretsManager) StartRefresh(lifecycle *Lifecycle) {
+	if m.provider == nil || len(m.refs) == 0 || m.interval <= 0 {
+		return
+	}
+
+	lifecycle.Go("secrets refresh", func(ctx context.Context) {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			refreshCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			m.Refresh(refreshCtx)
+			cancel()
+		}
+	})
+	slog.Info("Secrets refresh started", "interval", m.interval)
+}
+
+// DatabaseBeforeConnect applies the latest database credentials to each new connection,
+// so a rotated password is picked up without dropping the connections already open
+func (m *SecretsManager) DatabaseBeforeConnect(ctx context.Context, cc *pgx.ConnConfig) error {
+	if !m.Managed(SecretDatabaseURL) {
+		return nil
+	}
+
+	latest, err := pgx.ParseConfig(m.Get(SecretDatabaseURL))
+	if err != nil {
+		return fmt.Errorf("invalid rotated database URL: %w", err)
+	}
+	cc.User = latest.User
+	cc.Password = latest.Password
+	return nil
+}
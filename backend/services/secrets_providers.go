@@ -0,0 +1,292 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/auth"
+	"cloud.google.com/go/auth/credentials"
+)
+
+const secretsRequestTimeout = 10 * time.Second
+
+// splitSecretRef splits "name#field" into the secret name and optional field
+func splitSecretRef(ref string) (string, string) {
+	name, field, _ := strings.Cut(ref, "#")
+	return name, field
+}
+
+// selectSecretField returns the whole secret, or one field of it when the secret is a JSON object
+func selectSecretField(secret, field string) (string, error) {
+	if field == "" {
+		return secret, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, can't select field %q", field)
+	}
+	value, ok := fields[field].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string field %q", field)
+	}
+	return value, nil
+}
+
+// readSecretResponse reads a provider response body, turning non-200 statuses into errors
+func readSecretResponse(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("secrets API error: %d - %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// VaultSecretsProvider reads secrets from a HashiCorp Vault KV v2 engine.
+// References are "path#key"; the key defaults to "value".
+type VaultSecretsProvider struct {
+	addr   string
+	token  string
+	mount  string
+	client *http.Client
+}
+
+func NewVaultSecretsProvider(addr, token, mount string) (*VaultSecretsProvider, error) {
+	if addr == "" || token == "" {
+		return nil, fmt.Errorf("vault secrets provider requires VAULT_ADDR and VAULT_TOKEN")
+	}
+	return &VaultSecretsProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		client: &http.Client{Timeout: secretsRequestTimeout},
+	}, nil
+}
+
+func (p *VaultSecretsProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	path, key := splitSecretRef(ref)
+	if key == "" {
+		key = "value"
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", p.addr, p.mount, strings.Trim(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	body, err := readSecretResponse(resp)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	value, ok := result.Data.Data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string key %q", path, key)
+	}
+	return value, nil
+}
+
+// AWSSecretsProvider reads secrets from AWS Secrets Manager using credentials from the
+// standard AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN variables.
+// References are "secret-id#field"; without a field the whole SecretString is used.
+type AWSSecretsProvider struct {
+	region string
+	client *http.Client
+}
+
+func NewAWSSecretsProvider(region string) (*AWSSecretsProvider, error) {
+	if region == "" {
+		return nil, fmt.Errorf("aws secrets provider requires AWS_REGION")
+	}
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" || os.Getenv("AWS_SECRET_ACCESS_KEY") == "" {
+		return nil, fmt.Errorf("aws secrets provider requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return &AWSSecretsProvider{
+		region: region,
+		client: &http.Client{Timeout: secretsRequestTimeout},
+	}, nil
+}
+
+func (p *AWSSecretsProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	secretID, field := splitSecretRef(ref)
+	payload, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	host := fmt.Sprintf("secretsmanager.%s.amazonaws.com", p.region)
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://"+host+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	p.sign(req, host, payload, time.Now().UTC())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	body, err := readSecretResponse(resp)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode secrets manager response: %w", err)
+	}
+	return selectSecretField(result.SecretString, field)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to the request
+func (p *AWSSecretsProvider) sign(req *http.Request, host string, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         host,
+		"x-amz-date":   amzDate,
+		"x-amz-target": req.Header.Get("X-Amz-Target"),
+	}
+	names := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+		headers["x-amz-security-token"] = token
+		names = []string{"content-type", "host", "x-amz-date", "x-amz-security-token", "x-amz-target"}
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := strings.Join([]string{
+		"POST", "/", "", canonicalHeaders.String(), signedHeaders, hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + p.region + "/secretsmanager/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	for _, part := range []string{date, p.region, "secretsmanager", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		os.Getenv("AWS_ACCESS_KEY_ID"), scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// GCPSecretsProvider reads the latest version of secrets from GCP Secret Manager using
+// application default credentials. References are "secret-name#field" or a full
+// "projects/.../secrets/.../versions/..." resource name.
+type GCPSecretsProvider struct {
+	project string
+	creds   *auth.Credentials
+	client  *http.Client
+}
+
+func NewGCPSecretsProvider(ctx context.Context, project string) (*GCPSecretsProvider, error) {
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes: []string{"https://www.googleapis.com/auth/cloud-platform"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find GCP credentials: %w", err)
+	}
+	if project == "" {
+		if project, err = creds.ProjectID(ctx); err != nil || project == "" {
+			return nil, fmt.Errorf("gcp secrets provider requires GCP_PROJECT")
+		}
+	}
+	return &GCPSecretsProvider{
+		project: project,
+		creds:   creds,
+		client:  &http.Client{Timeout: secretsRequestTimeout},
+	}, nil
+}
+
+func (p *GCPSecretsProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	name, field := splitSecretRef(ref)
+	if !strings.HasPrefix(name, "projects/") {
+		name = fmt.Sprintf("projects/%s/secrets/%s", p.project, name)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := p.creds.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get GCP access token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://secretmanager.googleapis.com/v1/"+name+":access", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Value)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	body, err := readSecretResponse(resp)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode secret manager response: %w", err)
+	}
+	secret, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return selectSecretField(string(secret), field)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"github.com/krshsl/praxis/backend/repository"
 	"golang.org/x/crypto/bcrypt"
@@ -70,8 +71,38 @@ func (s *DatabaseSeeder) SeedDatabase() error {
 		return fmt.Errorf("test user not found")
 	}
 
-	// Create default agents (always public)
-	defaultAgents := []models.Agent{
+	// Seed default agents (always public, idempotent)
+	s.SeedAgents(ctx)
+
+	// Create private agent for test user
+	privateAgent := models.Agent{
+		UserID:      &firstUser.ID, // Private agent
+		Name:        "My Custom Interviewer",
+		Gender:      "other",
+		Description: "A personalized interviewer for my specific needs",
+		Personality: "Adaptive and supportive, tailored to my learning style and career goals.",
+		Industry:    "General",
+		Level:       "Mid",
+		IsPublic:    false,
+		IsActive:    true,
+	}
+
+	// Seed private agent for test user (idempotent)
+	if err := s.seedAgent(ctx, privateAgent); err != nil {
+		slog.Error("Failed to seed private agent", "error", err)
+	}
+
+	// Mark seeding as complete
+	if err := s.markSeedingComplete(ctx); err != nil {
+		slog.Error("Failed to mark seeding as complete", "error", err)
+	}
+
+	return nil
+}
+
+// defaultAgents are the public agents every deployment starts with
+func defaultAgents() []models.Agent {
+	return []models.Agent{
 		{
 			UserID:      nil, // Public agent
 			Name:        "Sarah Chen - Tech Recruiter",
@@ -139,38 +170,19 @@ func (s *DatabaseSeeder) SeedDatabase() error {
 			IsActive:    true,
 		},
 	}
+}
 
-	// Seed default agents (idempotent)
-	for _, agent := range defaultAgents {
+// SeedAgents creates any of the default public agents that are missing, leaving existing ones
+// untouched, and returns how many failed
+func (s *DatabaseSeeder) SeedAgents(ctx context.Context) int {
+	failed := 0
+	for _, agent := range defaultAgents() {
 		if err := s.seedAgent(ctx, agent); err != nil {
-			slog.Error("Failed to seed agent", "name", agent.Name, "error", err)
+			logging.FromContext(ctx).Error("Failed to seed agent", "name", agent.Name, "error", err)
+			failed++
 		}
 	}
-
-	// Create private agent for test user
-	privateAgent := models.Agent{
-		UserID:      &firstUser.ID, // Private agent
-		Name:        "My Custom Interviewer",
-		Gender:      "other",
-		Description: "A personalized interviewer for my specific needs",
-		Personality: "Adaptive and supportive, tailored to my learning style and career goals.",
-		Industry:    "General",
-		Level:       "Mid",
-		IsPublic:    false,
-		IsActive:    true,
-	}
-
-	// Seed private agent for test user (idempotent)
-	if err := s.seedAgent(ctx, privateAgent); err != nil {
-		slog.Error("Failed to seed private agent", "error", err)
-	}
-
-	// Mark seeding as complete
-	if err := s.markSeedingComplete(ctx); err != nil {
-		slog.Error("Failed to mark seeding as complete", "error", err)
-	}
-
-	return nil
+	return failed
 }
 
 // isSeedingComplete checks if seeding has already been completed
@@ -197,12 +209,14 @@ func (s *DatabaseSeeder) isSeedingComplete(ctx context.Context) bool {
 func (s *DatabaseSeeder) markSeedingComplete(ctx context.Context) error {
 	// For now, we rely on the presence of default agents to determine completion
 	// In a more robust implementation, you could create a seeding_metadata table
-	slog.Info("Database seeding completed successfully")
+	logging.FromContext(ctx).Info("Database seeding completed successfully")
 	return nil
 }
 
 // seedUser seeds a single user (idempotent)
 func (s *DatabaseSeeder) seedUser(ctx context.Context, user models.User) error {
+	logger := logging.FromContext(ctx)
+
 	// Check if user already exists
 	existingUser, err := s.repo.GetUserByEmail(ctx, user.Email)
 	if err != nil {
@@ -210,7 +224,7 @@ func (s *DatabaseSeeder) seedUser(ctx context.Context, user models.User) error {
 	}
 
 	if existingUser != nil {
-		slog.Info("User already exists, skipping", "email", user.Email)
+		logger.Info("User already exists, skipping", "email", user.Email)
 		return nil
 	}
 
@@ -219,12 +233,14 @@ func (s *DatabaseSeeder) seedUser(ctx context.Context, user models.User) error {
 		return fmt.Errorf("failed to create user %s: %w", user.Email, err)
 	}
 
-	slog.Info("Created user", "email", user.Email)
+	logger.Info("Created user", "email", user.Email)
 	return nil
 }
 
 // seedAgent seeds a single agent (idempotent)
 func (s *DatabaseSeeder) seedAgent(ctx context.Context, agent models.Agent) error {
+	logger := logging.FromContext(ctx)
+
 	// For public agents, check by name and public status
 	if agent.UserID == nil {
 		agents, err := s.repo.GetAgents(ctx, "", true) // Get all public agents
@@ -234,7 +250,7 @@ func (s *DatabaseSeeder) seedAgent(ctx context.Context, agent models.Agent) erro
 
 		for _, existingAgent := range agents {
 			if existingAgent.Name == agent.Name && existingAgent.UserID == nil {
-				slog.Info("Public agent already exists, skipping", "name", agent.Name)
+				logger.Info("Public agent already exists, skipping", "name", agent.Name)
 				return nil
 			}
 		}
@@ -247,17 +263,20 @@ func (s *DatabaseSeeder) seedAgent(ctx context.Context, agent models.Agent) erro
 
 		for _, existingAgent := range agents {
 			if existingAgent.Name == agent.Name {
-				slog.Info("Private agent already exists, skipping", "name", agent.Name, "user_id", *agent.UserID)
+				logger.Info("Private agent already exists, skipping", "name", agent.Name, "user_id", *agent.UserID)
 				return nil
 			}
 		}
 	}
 
 	// Agent doesn't exist, create it
+	if agent.VoiceID == "" {
+		agent.VoiceID = PickDeterministicVoice(agent.Name, agent.Gender)
+	}
 	if err := s.repo.CreateAgent(ctx, &agent); err != nil {
 		return fmt.Errorf("failed to create agent %s: %w", agent.Name, err)
 	}
 
-	slog.Info("Created agent", "name", agent.Name, "is_public", agent.UserID == nil)
+	logger.Info("Created agent", "name", agent.Name, "is_public", agent.UserID == nil)
 	return nil
 }
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"compress/flate"
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -13,6 +16,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/websocket"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"github.com/krshsl/praxis/backend/repository"
 	ws "github.com/krshsl/praxis/backend/websocket"
@@ -24,15 +29,58 @@ type Server struct {
 	config             *Config
 	gormDB             *repository.GORMRepository
 	rawDB              interface{} // Store the raw GORM DB for services that need it
-	geminiService      *GeminiService
-	elevenLabsService  *ElevenLabsService
+	llm                LLMService
+	speech             SpeechService
+	transcriber        TranscriptionProvider
 	timeoutService     *SessionTimeoutService
 	aiMessageProcessor *AIMessageProcessor
 	websocketHandler   *WebSocketHandler
+	sessionObservers   *SessionObserverService
 	authService        *AuthService
 	authEndpoints      *AuthEndpoints
 	sessionEndpoints   *SessionEndpoints
 	agentEndpoints     *AgentEndpoints
+	recordingEndpoints *RecordingEndpoints
+	status             *StatusService
+	tokenEndpoints     *SessionTokenEndpoints
+	adminEndpoints     *AdminEndpoints
+	qualityService     *SummaryQualityService
+	experimentService  *ExperimentService
+	recordingService   *RecordingService
+	agentDigests       *AgentDigestService
+	scoringPolicies    *ScoringPolicyService
+	scoringEndpoints   *ScoringPolicyEndpoints
+	certificates       *CertificateService
+	certEndpoints      *CertificateEndpoints
+	explanations       *ExplanationService
+	documents          *DocumentService
+	flows              *FlowEngine
+	docEndpoints       *DocumentEndpoints
+	importEndpoints    *DataImportEndpoints
+	exportEndpoints    *ExportEndpoints
+	questionEndpoints  *QuestionBankEndpoints
+	eventBus           *EventBus
+	geo                *GeoResolver
+	legalService       *LegalService
+	legalEndpoints     *LegalEndpoints
+	notifications      *NotificationService
+	notifyEndpoints    *NotificationEndpoints
+	errorEndpoints     *ClientErrorEndpoints
+	clientInfo         *ClientInfoService
+	brandEndpoints     *BrandingEndpoints
+	statsEndpoints     *AnalyticsEndpoints
+	complyEndpoints    *ComplianceEndpoints
+	orgEndpoints       *OrgEndpoints
+	interviewInvites   *InterviewInviteService
+	webhooks           *WebhookService
+	webhookEndpoints   *WebhookEndpoints
+	apiKeys            *APIKeyService
+	keyEndpoints       *APIKeyEndpoints
+	accountEndpoints   *AccountEndpoints
+	impersonation      *ImpersonationService
+	secrets            *SecretsManager
+	startup            StartupStatus
+	lifecycle          *Lifecycle
 	wsHub              *ws.Hub
 	upgrader           websocket.Upgrader
 }
@@ -40,72 +88,293 @@ type Server struct {
 // NewServer creates a new server instance
 func NewServer(config *Config) *Server {
 	return &Server{
-		config: config,
+		config:    config,
+		lifecycle: NewLifecycle(),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return CheckOrigin(r, config.WebSocket.AllowedOrigins)
 			},
+			// Transcripts and base64 audio replies shrink well; browsers negotiate it by default
+			EnableCompression: config.WebSocket.Compression,
 		},
 	}
 }
 
-// InitializeServices initializes all server services
+// InitializeServices initializes all server services. The database, Gemini and the JWT
+// secret are required; ElevenLabs is optional and falls back to text-only responses.
 func (s *Server) InitializeServices() error {
-	// Initialize database connection
-	if s.config.Database.URL != "" {
-		// Database initialization is handled in main.go
-		slog.Info("Database connection will be initialized in main.go")
-	} else {
-		slog.Warn("Database URL not configured, running without database")
+	// Required dependencies
+	if s.gormDB == nil {
+		return fmt.Errorf("database is required: set DATABASE_URL")
+	}
+	rawDB, ok := s.rawDB.(*gorm.DB)
+	if !ok || rawDB == nil {
+		return fmt.Errorf("database is required: raw GORM connection not set")
+	}
+	if s.config.AI.GeminiAPIKey == "" {
+		return fmt.Errorf("gemini is required: set GEMINI_API_KEY")
+	}
+	if s.config.JWT.Secret == "" {
+		return fmt.Errorf("JWT secret is required: set JWT_SECRET")
+	}
+	if _, err := ParseTrustedProxies(s.config.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("SERVER_TRUSTED_PROXIES: %w", err)
+	}
+	if err := CheckValidateTags(ValidatedRequests()...); err != nil {
+		return fmt.Errorf("invalid request validation: %w", err)
 	}
 
 	// Initialize AI services
-	if s.config.AI.GeminiAPIKey != "" {
-		s.geminiService = NewGeminiService(s.config.AI.GeminiAPIKey)
-		slog.Info("Gemini service initialized")
-	}
+	geminiService := NewGeminiService(s.config.AI.GeminiAPIKey)
+	s.llm = geminiService
+	geminiService.StartCacheCleanup(s.lifecycle)
+	slog.Info("Gemini service initialized")
 
 	if s.config.AI.ElevenLabsKey != "" {
-		s.elevenLabsService = NewElevenLabsService(s.config.AI.ElevenLabsKey)
-		slog.Info("ElevenLabs service initialized")
+		regions := ParseTTSRegions(s.config.AI.ElevenLabsRegions)
+		s.speech = NewElevenLabsService(s.config.AI.ElevenLabsKey, regions)
+		slog.Info("ElevenLabs service initialized", "regions", len(regions))
+	} else {
+		s.speech = NoopSpeechService{}
+		slog.Warn("ElevenLabs API key not configured, agents will respond with text only")
 	}
 
-	// Initialize session timeout service
-	if s.rawDB != nil && s.geminiService != nil {
-		if gormDB, ok := s.rawDB.(*gorm.DB); ok {
-			s.timeoutService = NewSessionTimeoutService(gormDB, s.geminiService)
-			slog.Info("Session timeout service initialized")
-		}
+	// Scratch files of audio conversion, cleaned up if a conversion or the process dies
+	tempFiles, err := NewTempFiles(s.config.TempFiles)
+	if err != nil {
+		return fmt.Errorf("audio temp files: %w", err)
 	}
+	tempFiles.StartCleanupJob(s.lifecycle)
+	slog.Info("Audio temp files initialized", "dir", s.config.TempFiles.Dir, "max_mb", s.config.TempFiles.MaxMB)
+	transcodes := NewTranscodePool(s.config.Transcode)
 
-	// Initialize AI message processor
-	if s.geminiService != nil && s.elevenLabsService != nil && s.timeoutService != nil && s.gormDB != nil {
-		s.aiMessageProcessor = NewAIMessageProcessor(s.geminiService, s.elevenLabsService, s.timeoutService, s.gormDB)
-		slog.Info("AI message processor initialized")
+	transcriber, err := NewTranscriptionProvider(s.config.AI, geminiService)
+	if err != nil {
+		return fmt.Errorf("transcription: %w", err)
 	}
+	s.transcriber = transcriber
+	slog.Info("Transcription initialized", "providers", transcriber.Name())
+
+	// The status page reports from the circuit breakers of the model and of transcription
+	transcriptionHealth := &ProviderHealth{}
+	s.status = NewStatusService(s.gormDB, s.databaseStatus, geminiService.Health(), transcriptionHealth, s.speech, transcodes)
 
-	// Initialize authentication services
-	if s.config.JWT.Secret != "" && s.gormDB != nil {
-		s.authService = NewAuthService(s.gormDB, s.config.JWT.Secret)
-		s.authEndpoints = NewAuthEndpoints(s.authService)
-		s.sessionEndpoints = NewSessionEndpoints(s.gormDB, s.geminiService)
-		s.agentEndpoints = NewAgentEndpoints(s.gormDB)
-		slog.Info("Authentication service initialized")
+	geoProvider, err := NewGeoProvider(s.config.Geo)
+	if err != nil {
+		return fmt.Errorf("geo provider: %w", err)
 	}
+	s.geo = NewGeoResolver(geoProvider, s.config.Geo.CountryHeader)
+	slog.Info("Geo resolver initialized", "provider", s.config.Geo.Provider)
 
-	// Initialize WebSocket handler
-	if s.aiMessageProcessor != nil {
-		s.websocketHandler = NewWebSocketHandler(s.aiMessageProcessor, s.timeoutService)
-		slog.Info("WebSocket handler initialized")
+	// Initialize the event bus
+	s.eventBus = NewEventBus(s.gormDB)
+	s.eventBus.StartRetryLoop(s.lifecycle)
+	slog.Info("Event bus initialized")
+
+	// Initialize summary quality monitoring and scoring experiments
+	s.qualityService = NewSummaryQualityService(s.gormDB, s.config.Quality)
+	s.experimentService = NewExperimentService(s.gormDB, s.llm, s.qualityService)
+	slog.Info("Summary quality and experiment services initialized")
+
+	// Initialize recording storage and its hot/cold lifecycle job
+	blobStore, err := NewBlobStore(s.config.Storage)
+	if err != nil {
+		return fmt.Errorf("recording storage: %w", err)
 	}
+	s.recordingService = NewRecordingService(s.gormDB, blobStore, s.config.Storage, s.config.JWT.Secret, s.config.Server.PublicURL)
+	s.recordingService.StartLifecycleJob(s.lifecycle)
+	slog.Info("Recording service initialized", "backend", s.config.Storage.Backend)
+
+	// Initialize org branding, whose logos share the recording storage
+	branding := NewBrandingService(s.gormDB, blobStore, s.config.Storage)
+	s.brandEndpoints = NewBrandingEndpoints(branding)
+
+	// Initialize custom scoring policies, applied when summaries are finalized
+	s.scoringPolicies = NewScoringPolicyService(s.gormDB)
+	s.scoringEndpoints = NewScoringPolicyEndpoints(s.gormDB)
+	s.statsEndpoints = NewAnalyticsEndpoints(s.gormDB)
+	s.questionEndpoints = NewQuestionBankEndpoints(s.gormDB)
+	s.complyEndpoints = NewComplianceEndpoints(s.gormDB)
+
+	// Initialize the summary job queue, worked in the background
+	summaries := NewSummaryJobService(s.gormDB, s.llm, s.eventBus, s.scoringPolicies, s.config.AI.Timeouts, s.config.Summary)
+	summaries.Start(s.lifecycle)
+	rescore := NewRescoreService(s.gormDB, summaries, s.config.Summary)
+	rescore.Start(s.lifecycle)
+
+	// Initialize session timeout service
+	s.timeoutService = NewSessionTimeoutService(rawDB, s.eventBus, summaries)
+	s.timeoutService.StartTimeoutChecker(s.lifecycle)
+	slog.Info("Session timeout service initialized")
+
+	// At shutdown, live sessions are saved first, as that queues summaries and publishes events
+	s.lifecycle.OnShutdown("active sessions", s.timeoutService.FlushSessions)
+	s.lifecycle.OnShutdown("summary jobs", summaries.Stop)
+	s.lifecycle.OnShutdown("event deliveries", s.eventBus.Wait)
 
 	// Initialize WebSocket hub
 	s.wsHub = ws.NewHub()
+	s.wsHub.SetMessageLimiter(NewWebSocketLimiter(s.config.RateLimit))
+	s.wsHub.SetBandwidthLimits(ws.BandwidthLimits{
+		MaxBytesIn:  int64(s.config.WebSocket.MaxSessionMBIn) << 20,
+		MaxBytesOut: int64(s.config.WebSocket.MaxSessionMBOut) << 20,
+	})
 	go s.wsHub.Run()
 
+	// Initialize AI message processor
+	s.explanations = NewExplanationService(s.gormDB, s.llm, s.config.Interview.CaptureExplanations)
+	s.documents = NewDocumentService(s.gormDB)
+	s.flows = NewFlowEngine(s.llm)
+	openings := NewOpeningQuestionCache(s.llm, s.gormDB, s.config.Interview.OpeningQuestions)
+	openings.StartRefreshJob(s.lifecycle)
+	audioCache := NewAudioCache(s.config.AI.AudioCacheDir)
+	welcome := NewWelcomeService(s.llm, s.speech, audioCache)
+	analyzer := NewStaticAnalyzer(s.config.Interview.StaticAnalysis, s.config.Interview.CodeTestSandbox, s.config.Interview.StaticAnalysisTimeout)
+	plagiarism := NewPlagiarismChecker(s.gormDB, s.config.Interview.PlagiarismThreshold)
+	tests := NewCodeTestRunner(s.config.Interview.CodeTests, s.config.Interview.CodeTestSandbox, s.config.Interview.CodeTestTimeout)
+	proctoring := NewProctoringService(s.gormDB, s.timeoutService, s.llm, s.config.Interview)
+	compliance := NewComplianceService(s.gormDB)
+	s.clientInfo = NewClientInfoService(s.gormDB)
+	s.aiMessageProcessor = NewAIMessageProcessor(s.llm, s.speech, monitoredTranscriber{s.transcriber, transcriptionHealth}, s.timeoutService, s.gormDB, s.eventBus, s.recordingService, s.explanations, s.documents, s.flows, openings, welcome, audioCache, analyzer, tests, plagiarism, proctoring, compliance, s.clientInfo, s.config.AI.Timeouts, s.config.Interview.WarmupTurns, s.config.AI.StreamSpeech)
+	slog.Info("AI message processor initialized")
+
+	// Initialize authentication services and endpoints
+	s.authService = NewAuthService(s.gormDB, s.config.JWT.Secret, s.geo, s.eventBus)
+	s.impersonation = NewImpersonationService(s.gormDB, s.authService)
+	s.legalService = NewLegalService(s.gormDB, s.config.Legal.MinAge)
+	s.legalEndpoints = NewLegalEndpoints(s.legalService)
+	s.authEndpoints = NewAuthEndpoints(s.authService, s.geo, s.legalService)
+	s.sessionEndpoints = NewSessionEndpoints(s.gormDB, s.eventBus, s.geo, summaries, s.wsHub, s.aiMessageProcessor, s.timeoutService, branding, s.recordingService)
+	s.agentDigests = NewAgentDigestService(s.gormDB, s.config.AgentDigest.MinSessions)
+	s.agentDigests.StartDigestJob(s.lifecycle)
+	s.agentEndpoints = NewAgentEndpoints(s.gormDB, s.agentDigests)
+	s.recordingEndpoints = NewRecordingEndpoints(s.gormDB, s.recordingService)
+	s.tokenEndpoints = NewSessionTokenEndpoints(s.gormDB, NewSessionTokenService(s.gormDB, s.authService), s.timeoutService)
+	s.apiKeys = NewAPIKeyService(s.gormDB, s.authService)
+	s.keyEndpoints = NewAPIKeyEndpoints(s.gormDB, s.apiKeys)
+	s.certificates, err = NewCertificateService(s.gormDB, s.config.Certificate, s.config.JWT.Secret, s.config.Server.PublicURL)
+	if err != nil {
+		return fmt.Errorf("certificates: %w", err)
+	}
+	s.certEndpoints = NewCertificateEndpoints(s.gormDB, s.certificates, branding)
+	s.docEndpoints = NewDocumentEndpoints(s.gormDB, s.documents)
+	s.importEndpoints = NewDataImportEndpoints(s.gormDB, NewDataImportService(s.gormDB))
+	// Export archives are built in the background and kept in the recording storage
+	exports := NewExportJobService(s.gormDB, blobStore, s.config.Storage, branding, s.config.Export, s.config.JWT.Secret, s.config.Server.PublicURL)
+	exports.Start(s.lifecycle)
+	s.exportEndpoints = NewExportEndpoints(s.gormDB, exports)
+	// Account deletion and the purge of soft-deleted data, stored files included
+	purge := NewDataPurgeService(s.gormDB, blobStore, s.config.Storage, s.config.Purge)
+	purge.StartPurgeJob(s.lifecycle)
+	agentHealth := NewAgentHealthService(s.gormDB)
+	agentHealth.StartHealthJob(s.lifecycle)
+	s.adminEndpoints = NewAdminEndpoints(s.gormDB, s.qualityService, s.experimentService, s.legalService, s.impersonation, NewResearchExportService(s.gormDB, s.config.Research.HashSalt), agentHealth, tempFiles, transcodes, s.wsHub, rescore)
+	slog.Info("Authentication service initialized")
+
+	// Initialize notifications, in-app and delivered by email and users' webhooks
+	mailer, err := NewMailer(s.config.Mail)
+	if err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	s.notifications = NewNotificationService(s.gormDB, s.authService, s.config.Server.PublicURL,
+		NewEmailChannel(s.gormDB, mailer), NewWebhookChannel(s.gormDB))
+	s.notifications.StartQuietHoursJob(s.lifecycle)
+	s.notifications.StartDigestJob(s.lifecycle)
+	s.notifyEndpoints = NewNotificationEndpoints(s.gormDB)
+	slog.Info("Notification service initialized", "mail_provider", s.config.Mail.Provider)
+
+	// Initialize webhook subscriptions to session lifecycle events
+	s.webhooks = NewWebhookService(s.gormDB)
+	s.webhooks.Start(s.lifecycle)
+	s.webhookEndpoints = NewWebhookEndpoints(s.gormDB, s.webhooks)
+
+	// Initialize organizations, whose invites and interview invites are emailed
+	s.interviewInvites = NewInterviewInviteService(s.gormDB, s.authService, s.legalService, mailer, s.config.Server.PublicURL)
+	s.orgEndpoints = NewOrgEndpoints(s.gormDB, NewOrgService(s.gormDB, s.authService, mailer, s.config.Server.PublicURL), s.interviewInvites, s.authService, s.geo)
+	s.accountEndpoints = NewAccountEndpoints(purge, exports, s.interviewInvites, s.authService)
+
+	// Initialize WebSocket handler
+	clientErrors := NewClientErrorService(s.gormDB)
+	s.errorEndpoints = NewClientErrorEndpoints(s.gormDB, clientErrors)
+	s.websocketHandler = NewWebSocketHandler(s.aiMessageProcessor, clientErrors, proctoring)
+	s.sessionObservers = NewSessionObserverService(s.gormDB, s.wsHub, s.aiMessageProcessor, s.timeoutService, compliance)
+	slog.Info("WebSocket handler initialized")
+
+	s.registerEventSubscribers()
+	s.registerSecretRotations()
+
 	return nil
 }
 
+// registerEventSubscribers wires services to the session events they react to
+func (s *Server) registerEventSubscribers() {
+	s.eventBus.Subscribe(EventSessionStarted, "webhooks", s.webhooks.HandleSessionStarted)
+	s.eventBus.Subscribe(EventSessionEndRequested, "session_timeout", s.timeoutService.HandleSessionEndRequested)
+	s.eventBus.Subscribe(EventSummaryGenerated, "summary_quality", s.qualityService.HandleSummaryGenerated)
+	s.eventBus.Subscribe(EventSummaryGenerated, "scoring_experiments", s.experimentService.HandleSummaryGenerated)
+	s.eventBus.Subscribe(EventSummaryGenerated, "certificates", s.certificates.HandleSummaryGenerated)
+	s.eventBus.Subscribe(EventSummaryGenerated, "explanations", s.explanations.HandleSummaryGenerated)
+	s.eventBus.Subscribe(EventSummaryGenerated, "notifications", s.notifications.HandleSummaryGenerated)
+	s.eventBus.Subscribe(EventSummaryGenerated, "webhooks", s.webhooks.HandleSummaryGenerated)
+	s.eventBus.Subscribe(EventSessionConcluded, "flows", s.flows.HandleSessionConcluded)
+	s.eventBus.Subscribe(EventSessionConcluded, "question_banks", s.aiMessageProcessor.HandleSessionConcluded)
+	s.eventBus.Subscribe(EventSessionConcluded, "notifications", s.notifications.HandleSessionConcluded)
+	s.eventBus.Subscribe(EventSessionConcluded, "webhooks", s.webhooks.HandleSessionConcluded)
+	s.eventBus.Subscribe(EventSessionConcluded, "bandwidth", NewBandwidthRecorder(s.gormDB, s.wsHub).HandleSessionConcluded)
+	s.eventBus.Subscribe(EventSessionConcluded, "session_observers", s.sessionObservers.HandleSessionConcluded)
+	s.eventBus.Subscribe(EventNewDeviceLogin, "notifications", s.notifications.HandleNewDeviceLogin)
+}
+
+// StartupStatus records how startup dependencies came up, for readiness reporting
+type StartupStatus struct {
+	DatabaseConfigured bool   `json:"database_configured"`
+	DatabaseAttempts   int    `json:"database_attempts,omitempty"`
+	DatabaseError      string `json:"database_error,omitempty"`
+}
+
+// SetStartupStatus records the outcome of connecting to startup dependencies
+func (s *Server) SetStartupStatus(status StartupStatus) {
+	s.startup = status
+}
+
+// SetSecrets sets the secrets manager whose rotations are applied to running services
+func (s *Server) SetSecrets(secrets *SecretsManager) {
+	s.secrets = secrets
+}
+
+// registerSecretRotations applies rotated secrets to the services using them
+func (s *Server) registerSecretRotations() {
+	if s.secrets == nil {
+		return
+	}
+
+	s.secrets.OnRotate(SecretJWT, s.authService.RotateJWTSecret)
+	if gemini, ok := s.llm.(*GeminiService); ok && gemini != nil {
+		s.secrets.OnRotate(SecretGeminiAPIKey, func(apiKey string) {
+			if err := gemini.SetAPIKey(apiKey); err != nil {
+				slog.Error("Failed to apply rotated Gemini API key", "error", err)
+			}
+		})
+	}
+	if elevenLabs, ok := s.speech.(*ElevenLabsService); ok {
+		s.secrets.OnRotate(SecretElevenLabsKey, elevenLabs.SetAPIKey)
+	}
+	for _, provider := range transcriptionProviders(s.transcriber) {
+		switch t := provider.(type) {
+		case *WhisperTranscriber:
+			s.secrets.OnRotate(SecretOpenAIAPIKey, t.SetAPIKey)
+		case *DeepgramTranscriber:
+			s.secrets.OnRotate(SecretDeepgramKey, t.SetAPIKey)
+		}
+	}
+	// New database connections pick up rotated credentials through DatabaseBeforeConnect
+	s.secrets.OnRotate(SecretDatabaseURL, func(string) {
+		slog.Info("Database credentials rotated; new connections will use them")
+	})
+
+	s.secrets.StartRefresh(s.lifecycle)
+}
+
 // SetDatabase sets the database connection
 func (s *Server) SetDatabase(db *repository.GORMRepository, rawDB interface{}) {
 	s.gormDB = db
@@ -118,58 +387,115 @@ func (s *Server) SetupRoutes() *chi.Mux {
 
 	// Middleware
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(logging.Middleware)
+	r.Use(RealIP(s.config.Server))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(RequestDeadline(s.config.Server))
+	r.Use(Compress(s.config.Server))
+	r.NotFound(apperrors.NotFoundHandler)
+	r.MethodNotAllowed(apperrors.MethodNotAllowedHandler)
+
+	ipLimiter := NewRateLimiter("ip", s.config.RateLimit.IPPerMinute, s.config.RateLimit.IPBurst)
+	userLimiter := NewRateLimiter("user", s.config.RateLimit.UserPerMinute, s.config.RateLimit.UserBurst)
 
 	// Health endpoint
 	r.Get("/health", s.healthHandler)
+	r.Get("/ready", s.readinessHandler)
+
+	// Cookie authentication, an admin's impersonation token or a scoped API key, whichever is
+	// presented
+	authenticate := s.apiKeys.Middleware(s.impersonation.Middleware(s.authService.Middleware))
 
 	// API v1 route group
-	r.Route("/api/v1", func(r chi.Router) {
+	r.Route(APIV1Prefix, func(r chi.Router) {
+		r.Use(ipLimiter.LimitByIP)
 		r.Get("/", s.apiV1Handler)
-		// WebSocket route (protected)
-		if s.authService != nil {
+		r.Get("/status", s.status.StatusHandler)
+
+		// Authentication routes
+		r.Route("/auth", func(r chi.Router) {
+			// Public auth routes (no middleware)
+			r.Post("/login", s.authEndpoints.LoginHandler)
+			r.Post("/signup", s.authEndpoints.SignupHandler)
+			r.Post("/refresh", s.authEndpoints.RefreshHandler)
+			r.Post("/logout", s.authEndpoints.LogoutHandler)
+			r.Post("/revoke-device", s.authEndpoints.RevokeDeviceLinkHandler)
+
+			// Protected auth routes (with middleware)
 			r.Group(func(r chi.Router) {
-				r.Use(s.authService.Middleware)
-				r.Get("/ws", s.websocketHandlerFunc)
+				r.Use(authenticate)
+				r.Get("/me", s.authEndpoints.MeHandler)
+				r.Get("/sessions", s.authEndpoints.GetDevicesHandler)
+				r.Delete("/sessions/{id}", s.authEndpoints.RevokeDeviceHandler)
 			})
-		} else {
-			r.Get("/ws", s.websocketHandlerFunc)
-		}
+		})
+
+		// Session-token routes for external tools (bearer token, no cookies)
+		s.tokenEndpoints.RegisterExternalRoutes(r)
+		s.legalEndpoints.RegisterPublicRoutes(r)
+		s.certEndpoints.RegisterPublicRoutes(r)
+		s.exportEndpoints.RegisterPublicRoutes(r)
+		s.recordingEndpoints.RegisterPublicRoutes(r)
+		s.orgEndpoints.RegisterPublicRoutes(r)
+
+		// Protected routes
+		r.Group(func(r chi.Router) {
+			r.Use(authenticate)
+			r.Use(userLimiter.LimitByUser)
+			r.Use(s.interviewInvites.RestrictGuests)
+
+			// Reachable before the current terms are accepted
+			s.legalEndpoints.RegisterRoutes(r)
+			s.accountEndpoints.RegisterRoutes(r)
+			s.notifyEndpoints.RegisterRoutes(r)
+			s.errorEndpoints.RegisterRoutes(r)
+
+			// Everything else requires the current terms and privacy policy
+			r.Group(func(r chi.Router) {
+				r.Use(s.legalService.RequireCurrentTerms)
 
-		// Authentication routes
-		if s.authEndpoints != nil {
-			r.Route("/auth", func(r chi.Router) {
-				// Public auth routes (no middleware)
-				r.Post("/login", s.authEndpoints.LoginHandler)
-				r.Post("/signup", s.authEndpoints.SignupHandler)
-				r.Post("/refresh", s.authEndpoints.RefreshHandler)
-				r.Post("/logout", s.authEndpoints.LogoutHandler)
-
-				// Protected auth routes (with middleware)
+				r.Get("/ws", s.websocketHandlerFunc)
+				r.Get("/ws/observe", s.observeHandlerFunc)
+				s.sessionEndpoints.RegisterRoutes(r)
+				s.agentEndpoints.RegisterRoutes(r)
+				s.scoringEndpoints.RegisterRoutes(r)
+				s.certEndpoints.RegisterRoutes(r)
+				s.docEndpoints.RegisterRoutes(r)
+				s.importEndpoints.RegisterRoutes(r)
+				s.exportEndpoints.RegisterRoutes(r)
+				s.questionEndpoints.RegisterRoutes(r)
+				s.recordingEndpoints.RegisterRoutes(r)
+				s.tokenEndpoints.RegisterRoutes(r)
+				s.brandEndpoints.RegisterRoutes(r)
+				s.statsEndpoints.RegisterRoutes(r)
+				s.complyEndpoints.RegisterRoutes(r)
+				s.orgEndpoints.RegisterRoutes(r)
+				s.webhookEndpoints.RegisterRoutes(r)
+				s.keyEndpoints.RegisterRoutes(r)
+
+				// Admin routes (admin role required)
 				r.Group(func(r chi.Router) {
-					r.Use(s.authService.Middleware)
-					r.Get("/me", s.authEndpoints.MeHandler)
+					r.Use(s.authService.RequireAdmin)
+					s.adminEndpoints.RegisterRoutes(r)
 				})
 			})
-		}
+		})
+	})
 
-		// Session routes (protected)
-		if s.sessionEndpoints != nil && s.authService != nil {
-			r.Group(func(r chi.Router) {
-				r.Use(s.authService.Middleware)
-				s.sessionEndpoints.RegisterRoutes(r)
-			})
-		}
+	// API v2 only carries endpoints whose responses changed; everything else stays on v1
+	r.Route(APIV2Prefix, func(r chi.Router) {
+		r.Use(ipLimiter.LimitByIP)
+		r.Get("/", s.apiV2Handler)
 
-		// Agent routes (protected)
-		if s.agentEndpoints != nil && s.authService != nil {
-			r.Group(func(r chi.Router) {
-				r.Use(s.authService.Middleware)
-				s.agentEndpoints.RegisterRoutes(r)
-			})
-		}
+		r.Group(func(r chi.Router) {
+			r.Use(authenticate)
+			r.Use(userLimiter.LimitByUser)
+			r.Use(s.interviewInvites.RestrictGuests)
+			r.Use(s.legalService.RequireCurrentTerms)
+
+			s.sessionEndpoints.RegisterV2Routes(r)
+		})
 	})
 
 	return r
@@ -183,8 +509,12 @@ func (s *Server) Start() {
 	}
 
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: s.SetupRoutes(),
+		Addr:              ":" + port,
+		Handler:           s.SetupRoutes(),
+		ReadHeaderTimeout: s.config.Server.ReadHeaderTimeout,
+		ReadTimeout:       s.config.Server.ReadTimeout,
+		WriteTimeout:      s.config.Server.WriteTimeout,
+		IdleTimeout:       s.config.Server.IdleTimeout,
 	}
 
 	// Graceful shutdown
@@ -202,23 +532,27 @@ func (s *Server) Start() {
 	<-quit
 
 	slog.Info("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		slog.Error("Server forced to shutdown", "error", err)
 	}
+	if err := s.lifecycle.Shutdown(ctx); err != nil {
+		slog.Error("Background services forced to shutdown", "error", err)
+	}
 
 	slog.Info("Server exited")
 }
 
 // CheckOrigin validates the origin of WebSocket connections to prevent CSRF attacks
 func CheckOrigin(r *http.Request, allowedOriginsStr string) bool {
+	logger := logging.FromContext(r.Context())
 	origin := r.Header.Get("Origin")
 
 	// If no allowed origins are configured, deny all requests for security
 	if allowedOriginsStr == "" {
-		slog.Warn("WebSocket connection rejected: no allowed origins configured", "origin", origin)
+		logger.Warn("WebSocket connection rejected: no allowed origins configured", "origin", origin)
 		return false
 	}
 
@@ -233,42 +567,69 @@ func CheckOrigin(r *http.Request, allowedOriginsStr string) bool {
 	// Check if origin is in allowed list
 	for _, allowed := range allowedOrigins {
 		if allowed == origin {
-			slog.Info("WebSocket connection accepted", "origin", origin)
+			logger.Info("WebSocket connection accepted", "origin", origin)
 			return true
 		}
 	}
 
-	slog.Warn("WebSocket connection rejected: origin not allowed", "origin", origin, "allowed_origins", allowedOriginsStr)
+	logger.Warn("WebSocket connection rejected: origin not allowed", "origin", origin, "allowed_origins", allowedOriginsStr)
 	return false
 }
 
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	status := "ok"
-	dbStatus := "not configured"
-
-	if s.rawDB != nil {
-		// We need to cast the rawDB to the actual GORM DB type
-		// This is a bit of a hack, but it works for now
-		if gormDB, ok := s.rawDB.(*gorm.DB); ok {
-			if sqlDB, err := gormDB.DB(); err == nil {
-				if err := sqlDB.Ping(); err != nil {
-					dbStatus = "down"
-					status = "degraded"
-				} else {
-					dbStatus = "up"
-				}
-			} else {
-				dbStatus = "down"
-				status = "degraded"
-			}
-		}
+	dbStatus := s.databaseStatus()
+	if dbStatus == "down" {
+		status = "degraded"
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"` + status + `","database":"` + dbStatus + `"}`))
 
-	slog.Info("Health check", "status", status, "database", dbStatus)
+	logging.FromContext(r.Context()).Info("Health check", "status", status, "database", dbStatus)
+}
+
+// readinessHandler reports whether the server can take traffic. A server that started
+// degraded without its database stays not ready, so load balancers route around it.
+func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	dbStatus := s.databaseStatus()
+	ready := dbStatus != "down"
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ready":    ready,
+		"database": dbStatus,
+		"startup":  s.startup,
+	})
+}
+
+// databaseStatus pings the database: "up", "down", or "not configured"
+func (s *Server) databaseStatus() string {
+	if !s.startup.DatabaseConfigured {
+		return "not configured"
+	}
+	if s.gormDB == nil {
+		// Startup gave up connecting; the server is running degraded
+		return "down"
+	}
+
+	// We need to cast the rawDB to the actual GORM DB type
+	gormDB, ok := s.rawDB.(*gorm.DB)
+	if !ok || gormDB == nil {
+		return "down"
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return "down"
+	}
+	if err := sqlDB.Ping(); err != nil {
+		return "down"
+	}
+	return "up"
 }
 
 func (s *Server) apiV1Handler(w http.ResponseWriter, r *http.Request) {
@@ -276,77 +637,158 @@ func (s *Server) apiV1Handler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"message":"API v1","version":"1.0.0"}`))
 
-	slog.Info("API v1 accessed")
+	logging.FromContext(r.Context()).Info("API v1 accessed")
+}
+
+func (s *Server) apiV2Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message":"API v2","version":"2.0.0"}`))
 }
 
 func (s *Server) websocketHandlerFunc(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		slog.Error("WebSocket connection failed - user not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		logger.Error("WebSocket connection failed - user not found in context")
+		apperrors.Write(w, r, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	// Extract session ID from query parameters - this should be an existing InterviewSession ID
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		logger.Error("WebSocket connection requires session_id parameter")
+		apperrors.Write(w, r, apperrors.BadRequest("Session ID is required"))
 		return
 	}
 
+	// Only the candidate may connect, and only while the interview is running
+	session, err := s.gormDB.GetInterviewSession(r.Context(), sessionID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return
+	}
+	if session == nil || session.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+	if session.Status != "active" {
+		apperrors.Write(w, r, apperrors.Conflict("Session has ended"))
+		return
+	}
+
+	// Extract agent ID from query parameters
+	agentID := r.URL.Query().Get("agent_id")
+	if agentID == "" {
+		agentID = "default_agent"
+	}
+
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		slog.Error("WebSocket upgrade failed", "error", err)
+		logger.Error("WebSocket upgrade failed", "error", err)
 		return
 	}
 	defer conn.Close()
+	// Frames go out as the interview happens, so favour latency over ratio
+	conn.SetCompressionLevel(flate.BestSpeed)
 
-	slog.Info("WebSocket connection established", "user_id", user.ID, "email", user.Email)
+	logger.Info("WebSocket connection established", "email", user.Email)
+
+	// A reconnecting client replaces its previous connection, which the server may not have
+	// noticed has dropped
+	for _, previous := range s.wsHub.SessionClients(sessionID) {
+		logger.Info("Closing superseded WebSocket connection", "session_id", sessionID)
+		previous.Close()
+	}
 
 	// Register client with hub
-	client := s.wsHub.RegisterClient(conn, user.ID)
+	client := s.wsHub.RegisterClient(logging.With(r.Context(), "session_id", sessionID), conn, user.ID, sessionID)
+	client.UserAgent = r.UserAgent()
+	client.TextMode = session.Mode == models.SessionModeText
+
+	// Record the browser, audio codec and connection, to break failures down by client type
+	clientInfo := ClientInfoFromRequest(r)
+	client.AudioCodec = clientInfo.AudioCodec
+	s.clientInfo.RecordConnect(r.Context(), client, clientInfo)
 
 	// Set up message handler for AI processing
-	if s.websocketHandler != nil {
-		client.MessageHandler = func(c *ws.Client, messageBytes []byte) {
-			s.websocketHandler.HandleWebSocketMessage(c, messageBytes)
-		}
+	client.MessageHandler = s.websocketHandler.HandleWebSocketMessage
+
+	// Register session with timeout service, using the time limit chosen at creation. A
+	// reconnect re-attaches to the session's live state instead.
+	limit := DefaultInterviewMinutes
+	if session.DurationMinutes > 0 {
+		limit = session.DurationMinutes
+	}
+	if !s.timeoutService.RegisterSession(sessionID, user.ID, agentID, time.Duration(limit)*time.Minute, UserAccommodations(user)) {
+		s.eventBus.Publish(r.Context(), EventSessionStarted, sessionID, SessionStartedPayload{
+			UserID:  user.ID,
+			AgentID: agentID,
+		})
 	}
 
-	// Register session with timeout service if available
-	if s.timeoutService != nil {
-		// Extract session ID from query parameters - this should be an existing InterviewSession ID
-		sessionID := r.URL.Query().Get("session_id")
-		if sessionID == "" {
-			slog.Error("WebSocket connection requires session_id parameter")
-			http.Error(w, "Session ID is required", http.StatusBadRequest)
-			return
-		}
+	// Start goroutines for reading and writing. ReadPump exits when the client disconnects or
+	// is superseded, unregistering it from the hub.
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		client.ReadPump()
+	}()
+	go client.WritePump()
 
-		// Extract agent ID from query parameters
-		agentID := r.URL.Query().Get("agent_id")
-		if agentID == "" {
-			agentID = "default_agent"
-		}
+	// Auto-start the interview
+	s.websocketHandler.HandleWebSocketConnection(client)
 
-		// Update the client's session ID to use the provided one
-		client.SessionID = sessionID
-		s.timeoutService.RegisterSession(sessionID, user.ID, agentID)
+	// Keep the connection open until the read side is done, then clean up after it, unless a
+	// newer connection has taken the session over
+	<-readDone
+	for _, other := range s.wsHub.SessionClients(sessionID) {
+		if other != client {
+			return
+		}
 	}
+	s.websocketHandler.HandleWebSocketDisconnect(client)
+}
 
-	// Start goroutines for reading and writing
-	go client.ReadPump()
-	go client.WritePump()
+// observeHandlerFunc connects an organization admin to a live interview with one of its agents,
+// or one it invited a candidate to: they see each turn as it happens and can take over the
+// interviewer for a question
+func (s *Server) observeHandlerFunc(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
 
-	// Auto-start the interview
-	if s.websocketHandler != nil {
-		s.websocketHandler.HandleWebSocketConnection(client)
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("Session ID is required"))
+		return
+	}
+	if _, err := s.sessionObservers.Authorize(r.Context(), user, sessionID); err != nil {
+		apperrors.Write(w, r, err)
+		return
 	}
 
-	// Handle AI conversation flow
-	go s.handleAIConversation(client)
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("WebSocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetCompressionLevel(flate.BestSpeed)
 
-	// Keep connection alive
-	select {}
-}
+	ctx := logging.With(r.Context(), "session_id", sessionID, "observer", true)
+	client := s.wsHub.RegisterObserver(ctx, conn, user.ID, sessionID)
+	client.UserAgent = r.UserAgent()
+	client.MessageHandler = s.sessionObservers.HandleMessage
+	logging.FromContext(ctx).Info("Observer connected to session")
 
-func (s *Server) handleAIConversation(client *ws.Client) {
-	// This function is now handled by the AI message processor
-	// The actual message processing happens in the WebSocket client handlers
-	// which are connected to the AI message processor
-	slog.Info("AI conversation handler started", "session_id", client.SessionID, "user_id", client.UserID)
+	go client.WritePump()
+	s.sessionObservers.Attach(client.Context(), client)
+	client.ReadPump()
 }
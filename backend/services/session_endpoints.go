@@ -1,38 +1,62 @@
 package services
 
 import (
-	"context"
+	"cmp"
 	"encoding/json"
 	"fmt"
-	"log/slog"
+	"io"
 	"net/http"
-	"strings"
-	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"github.com/krshsl/praxis/backend/repository"
+	ws "github.com/krshsl/praxis/backend/websocket"
 )
 
 type SessionEndpoints struct {
-	repo          *repository.GORMRepository
-	geminiService *GeminiService
+	repo      *repository.GORMRepository
+	eventBus  *EventBus
+	geo       *GeoResolver
+	summaries *SummaryJobService
+	// Live interviews, so ending a session through the API can close its connection
+	hub       *ws.Hub
+	processor *AIMessageProcessor
+	timeouts  *SessionTimeoutService
+	branding  *BrandingService
+	// Stored audio, linked from a session's turns
+	recordings *RecordingService
 }
 
-// Global mutex for summary generation to prevent race conditions across services
-var summaryGenerationMutex sync.Mutex
-
-func NewSessionEndpoints(repo *repository.GORMRepository, geminiService *GeminiService) *SessionEndpoints {
+func NewSessionEndpoints(repo *repository.GORMRepository, eventBus *EventBus, geo *GeoResolver, summaries *SummaryJobService, hub *ws.Hub, processor *AIMessageProcessor, timeouts *SessionTimeoutService, branding *BrandingService, recordings *RecordingService) *SessionEndpoints {
 	return &SessionEndpoints{
-		repo:          repo,
-		geminiService: geminiService,
+		repo:      repo,
+		eventBus:  eventBus,
+		geo:       geo,
+		summaries: summaries,
+		hub:       hub,
+		processor: processor,
+		timeouts:  timeouts,
+		branding:  branding,
+
+		recordings: recordings,
 	}
 }
 
 type CreateSessionRequest struct {
-	AgentID string `json:"agent_id" validate:"required"`
+	AgentID  string `json:"agent_id" validate:"required"`
+	Language string `json:"language,omitempty"` // Defaults to the user's language, then the client's region
+
+	ResumeID         *string `json:"resume_id,omitempty"`                                                                               // Uploaded resume to tailor questions to
+	JobDescriptionID *string `json:"job_description_id,omitempty"`                                                                      // Uploaded job description to tailor questions to
+	DurationMinutes  int     `json:"duration_minutes,omitempty"`                                                                        // Interview length; defaults to the agent's
+	Proctored        bool    `json:"proctored,omitempty"`                                                                               // Always on for proctored agents
+	Mode             string  `json:"mode,omitempty" validate:"omitempty,oneof=voice text"`                                              // text for typed answers only; defaults to voice
+	InterviewType    string  `json:"interview_type,omitempty" validate:"omitempty,oneof=behavioral technical system_design case_study"` // Defaults to the agent's
+	Coaching         bool    `json:"coaching,omitempty"`                                                                                // Practice with hints; not for proctored sessions
 }
 
 type CreateSessionResponse struct {
@@ -40,6 +64,12 @@ type CreateSessionResponse struct {
 	Message string                  `json:"message"`
 }
 
+// RateSessionRequest is the candidate's rating of a finished interview, which feeds the
+// catalog ranking of public agents
+type RateSessionRequest struct {
+	Rating int `json:"rating" validate:"required,min=1,max=5"`
+}
+
 type GetSessionsResponse struct {
 	Sessions []models.InterviewSession `json:"sessions"`
 	Count    int                       `json:"count"`
@@ -50,42 +80,117 @@ func (e *SessionEndpoints) RegisterRoutes(r chi.Router) {
 		r.Post("/", e.CreateSessionHandler)
 		r.Get("/", e.GetSessionsHandler)
 		r.Get("/{id}", e.GetSessionHandler)
+		r.Get("/{id}/code", e.GetSessionCodeHandler)
+		r.Get("/{id}/export", e.ExportSessionHandler)
+		r.Get("/{id}/replay", e.GetSessionReplayHandler)
+		r.Get("/{id}/audio", e.GetSessionAudioHandler)
+		r.Post("/{id}/end", e.EndSessionHandler)
+		r.Put("/{id}/rating", e.RateSessionHandler)
+		r.Post("/{id}/drill", e.CreateDrillHandler)
+		r.Get("/{id}/drills", e.GetSessionDrillsHandler)
+		r.Get("/{id}/coaching-notes", e.GetCoachingNotesHandler)
+		r.Get("/{id}/transcript-review", e.GetTranscriptReviewHandler)
+		r.Put("/{id}/transcript-review/turns/{index}", e.CorrectTurnHandler)
+		r.Post("/{id}/transcript-review/finish", e.FinishTranscriptReviewHandler)
 		r.Delete("/{id}", e.DeleteSessionHandler)
 		r.Delete("/bulk", e.BulkDeleteSessionsHandler)
 	})
 
 	// Summary routes
 	r.Route("/summaries", func(r chi.Router) {
-		r.Get("/session/{id}", e.GetSummaryBySessionHandler)
+		r.With(Deprecated(summaryV1Deprecation)).Get("/session/{id}", e.GetSummaryBySessionHandler)
+		r.Get("/session/{id}/status", e.GetSummaryStatusHandler)
+		r.Get("/session/{id}/versions", e.GetSummaryVersionsHandler)
 		r.Post("/session/{id}/generate", e.GenerateSummaryHandler)
+		r.Get("/jobs/{jobID}", e.GetSummaryJobHandler)
 	})
+
+	// "Why was I asked this?" explanations, available once the interview is over
+	r.Get("/explanations/session/{id}", e.GetSessionExplanationsHandler)
+
+	// Full-text search of the user's transcripts
+	r.Get("/search", e.SearchHandler)
 }
 
 func (e *SessionEndpoints) CreateSessionHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	var req CreateSessionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	// Validate agent exists
 	agent, err := e.repo.GetAgentByID(r.Context(), req.AgentID, user.ID)
 	if err != nil {
-		slog.Error("Failed to get agent", "error", err, "agent_id", req.AgentID)
-		http.Error(w, "Failed to validate agent", http.StatusInternalServerError)
+		logger.Error("Failed to get agent", "error", err, "agent_id", req.AgentID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to validate agent"))
 		return
 	}
 	if agent == nil {
-		http.Error(w, "Agent not found", http.StatusNotFound)
+		apperrors.Write(w, r, apperrors.NotFound("Agent not found"))
 		return
 	}
+	if !agent.IsActive {
+		apperrors.Write(w, r, apperrors.Conflict("Agent has been deactivated"))
+		return
+	}
+	if req.DurationMinutes < 0 || req.DurationMinutes > MaxInterviewMinutes {
+		apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("duration_minutes must be between 1 and %d", MaxInterviewMinutes)))
+		return
+	}
+
+	// Documents must belong to the user and be of the right kind
+	for _, ref := range []struct {
+		id   *string
+		kind string
+	}{
+		{req.ResumeID, models.DocumentKindResume},
+		{req.JobDescriptionID, models.DocumentKindJobDescription},
+	} {
+		if ref.id == nil {
+			continue
+		}
+		document, err := e.repo.GetDocument(r.Context(), *ref.id, user.ID)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to validate document"))
+			return
+		}
+		if document == nil || document.Kind != ref.kind {
+			apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("%s document not found", ref.kind)))
+			return
+		}
+	}
+
+	// Hints would defeat the point of a proctored interview
+	if req.Coaching && (req.Proctored || agent.Proctored) {
+		apperrors.Write(w, r, apperrors.BadRequest("Practice with hints is not available for proctored interviews"))
+		return
+	}
+
+	region := e.geo.Resolve(r)
+
+	// Accounts created before region tracking get their jurisdiction on first session
+	if user.Jurisdiction == "" {
+		if err := e.repo.UpdateUserRegion(r.Context(), user.ID, region.Country, region.Jurisdiction, region.Language, region.ConsentRequired); err == nil {
+			user.Country, user.Jurisdiction, user.Language, user.ConsentRequired = region.Country, region.Jurisdiction, region.Language, region.ConsentRequired
+		}
+	}
+
+	language := req.Language
+	if language == "" {
+		language = user.Language
+	}
+	if language == "" {
+		language = region.Language
+	}
 
 	// Create new interview session
 	now := time.Now()
@@ -95,11 +200,24 @@ func (e *SessionEndpoints) CreateSessionHandler(w http.ResponseWriter, r *http.R
 		AgentID:   req.AgentID,
 		Status:    "active",
 		StartedAt: now,
+		Country:   region.Country,
+		Language:  language,
+
+		DurationMinutes:  InterviewDuration(req.DurationMinutes, agent),
+		ResumeID:         req.ResumeID,
+		JobDescriptionID: req.JobDescriptionID,
+		Proctored:        req.Proctored || agent.Proctored,
+		Mode:             models.SessionModeVoice,
+		InterviewType:    cmp.Or(req.InterviewType, agent.InterviewType),
+		Coaching:         req.Coaching,
+	}
+	if req.Mode == models.SessionModeText {
+		session.Mode = models.SessionModeText
 	}
 
 	if err := e.repo.CreateInterviewSession(r.Context(), &session); err != nil {
-		slog.Error("Failed to create interview session", "error", err, "user_id", user.ID)
-		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		logger.Error("Failed to create interview session", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to create session"))
 		return
 	}
 
@@ -112,21 +230,23 @@ func (e *SessionEndpoints) CreateSessionHandler(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("Interview session created", "session_id", session.ID, "user_id", user.ID, "agent_id", req.AgentID)
+	logger.Info("Interview session created", "session_id", session.ID, "agent_id", req.AgentID)
 }
 
 func (e *SessionEndpoints) GetSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	sessions, err := e.repo.GetInterviewSessions(r.Context(), user.ID)
 	if err != nil {
-		slog.Error("Failed to get interview sessions", "error", err, "user_id", user.ID)
-		http.Error(w, "Failed to get sessions", http.StatusInternalServerError)
+		logger.Error("Failed to get interview sessions", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to get sessions"))
 		return
 	}
 
@@ -138,294 +258,564 @@ func (e *SessionEndpoints) GetSessionsHandler(w http.ResponseWriter, r *http.Req
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("Interview sessions retrieved", "user_id", user.ID, "count", len(sessions))
+	logger.Info("Interview sessions retrieved", "count", len(sessions))
 }
 
 func (e *SessionEndpoints) GetSessionHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	sessionID := chi.URLParam(r, "id")
 	if sessionID == "" {
-		http.Error(w, "Session ID is required", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.BadRequest("Session ID is required"))
 		return
 	}
 
 	// Get session with transcripts and summary
 	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
 	if err != nil {
-		slog.Error("Failed to get interview session", "error", err, "session_id", sessionID, "user_id", user.ID)
-		http.Error(w, "Session not found", http.StatusNotFound)
+		logger.Error("Failed to get interview session", "error", err, "session_id", sessionID)
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+	if session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	// Notes are shown alongside the transcript, each at the turn it was taken during
+	notes, err := e.repo.GetSessionNotes(r.Context(), sessionID, true)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session notes"))
+		return
+	}
+
+	// A live session's usage is only stored once it ends
+	if e.timeouts.IsActive(sessionID) {
+		usage := e.hub.SessionUsage(sessionID)
+		session.BytesIn, session.BytesOut = usage.BytesIn, usage.BytesOut
+	}
+
+	response := map[string]interface{}{
 		"session": session,
-	})
+		"notes":   notes,
+	}
+	// How each answer of a text interview was typed, keyed by its transcript turn
+	if session.Mode == models.SessionModeText {
+		typing, err := e.repo.GetSessionTurnMetrics(r.Context(), sessionID)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to get typing metrics"))
+			return
+		}
+		response["typing"] = map[string]interface{}{
+			"summary": SummarizeTyping(typing),
+			"turns":   typing,
+		}
+	} else {
+		// How each spoken answer was delivered, keyed the same way
+		speech, err := e.repo.GetSessionSpeechMetrics(r.Context(), sessionID)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to get speech metrics"))
+			return
+		}
+		response["speech"] = map[string]interface{}{
+			"summary": SummarizeSpeech(speech),
+			"turns":   speech,
+		}
+	}
 
-	slog.Info("Interview session retrieved", "session_id", sessionID, "user_id", user.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+
+	logger.Info("Interview session retrieved", "session_id", sessionID)
 }
 
+// GetSummaryBySessionHandler returns a session's summary in the v1 shape, queuing its
+// generation if it has none. Deprecated in favor of GetSummaryV2Handler, whose body has one shape.
 func (e *SessionEndpoints) GetSummaryBySessionHandler(w http.ResponseWriter, r *http.Request) {
-	// Get user from context (set by auth middleware)
+	result, err := e.loadSummary(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	status, body := result.v1Response()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// GetSummaryStatusHandler reports where a session's summary is: pending or running in the job
+// queue, ready, failed after its last attempt, or none if it was never queued
+func (e *SessionEndpoints) GetSummaryStatusHandler(w http.ResponseWriter, r *http.Request) {
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	sessionID := chi.URLParam(r, "id")
-	if sessionID == "" {
-		http.Error(w, "Session ID is required", http.StatusBadRequest)
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil || session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
 		return
 	}
 
-	// First verify the session belongs to the user
-	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	job, err := e.summaries.LatestJob(r.Context(), sessionID)
 	if err != nil {
-		slog.Error("Failed to get interview session", "error", err, "session_id", sessionID, "user_id", user.ID)
-		http.Error(w, "Session not found", http.StatusNotFound)
+		apperrors.Write(w, r, apperrors.Internal("Failed to get summary status"))
 		return
 	}
 
-	if session == nil {
-		http.Error(w, "Session not found", http.StatusNotFound)
+	// A queued regeneration takes precedence over the summary it will replace
+	status := "none"
+	switch {
+	case job != nil && (job.Status == models.SummaryJobPending || job.Status == models.SummaryJobRunning):
+		status = job.Status
+	case session.Summary != nil:
+		status = "ready"
+	case job != nil:
+		status = job.Status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id": sessionID,
+		"status":     status,
+		"job":        job,
+	})
+}
+
+// EndSessionHandler ends an interview without going through its WebSocket. A connected
+// candidate hears the sign-off and is disconnected; either way the session is completed and
+// its summary generated in the background.
+func (e *SessionEndpoints) EndSessionHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
-	// Get the summary for this session
-	summary, err := e.repo.GetInterviewSummary(r.Context(), sessionID)
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
 	if err != nil {
-		slog.Error("Failed to get interview summary", "error", err, "session_id", sessionID, "user_id", user.ID)
-		http.Error(w, "Failed to get summary", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return
+	}
+	if session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+	if session.Status == "completed" {
+		apperrors.Write(w, r, apperrors.Conflict("Session has already ended"))
 		return
 	}
 
-	// If no summary exists, trigger summary generation
-	if summary == nil {
-		// Use global mutex to prevent concurrent summary generation across services
-		summaryGenerationMutex.Lock()
-		defer summaryGenerationMutex.Unlock()
-
-		// Double-check if summary was created by another goroutine
-		summary, err = e.repo.GetInterviewSummary(r.Context(), sessionID)
-		if err != nil {
-			slog.Error("Failed to re-check for summary", "error", err, "session_id", sessionID)
-			http.Error(w, "Failed to check summary status", http.StatusInternalServerError)
-			return
+	const reason = "User ended interview"
+	if clients := e.hub.SessionClients(sessionID); len(clients) > 0 {
+		// The closing sequence completes the session once the sign-off has been delivered
+		for _, client := range clients {
+			e.processor.EndSession(client, reason)
 		}
+		e.writeEnding(w, sessionID)
+		logger.Info("Live session ended via API", "session_id", sessionID)
+		return
+	}
+	if e.timeouts.IsActive(sessionID) {
+		// Still tracked but disconnected; finalize it as a timeout would
+		go e.timeouts.ConcludeSession(sessionID, reason)
+		e.writeEnding(w, sessionID)
+		logger.Info("Disconnected session ended via API", "session_id", sessionID)
+		return
+	}
 
-		if summary != nil {
-			// Summary was created by another goroutine, return it
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"summary": summary,
-				"status":  "ready",
-			})
-			return
+	// Not tracked at all, e.g. the server restarted mid-interview. Only the request that
+	// completes the session summarizes it and announces it concluded.
+	completed, err := e.repo.CompleteInterviewSession(r.Context(), session, time.Now())
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to end session"))
+		return
+	}
+	if !completed {
+		logger.Info("Session ended concurrently, skipping its conclusion", "session_id", sessionID)
+		apperrors.Write(w, r, apperrors.Conflict("Session has already ended"))
+		return
+	}
+	transcripts, err := e.repo.GetInterviewTranscripts(r.Context(), sessionID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session transcripts"))
+		return
+	}
+	summaryStatus := "none"
+	if transcripts = scoredTranscripts(transcripts); len(transcripts) > 0 {
+		if job, err := e.summaries.Enqueue(r.Context(), sessionID, nil); err == nil {
+			summaryStatus = job.Status
 		}
+	}
 
-		slog.Info("No summary found, triggering automatic generation", "session_id", sessionID, "user_id", user.ID)
+	e.eventBus.Publish(r.Context(), EventSessionConcluded, session.ID, SessionConcludedPayload{
+		UserID:          user.ID,
+		AgentID:         session.AgentID,
+		Duration:        session.Duration,
+		TranscriptCount: len(transcripts),
+	})
+	logger.Info("Session ended via API", "session_id", sessionID)
 
-		// Get transcripts for the session
-		transcripts, err := e.repo.GetInterviewTranscripts(r.Context(), sessionID)
-		if err != nil {
-			slog.Error("Failed to get transcripts for summary generation", "error", err, "session_id", sessionID)
-			http.Error(w, "Failed to get session transcripts", http.StatusInternalServerError)
-			return
-		}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         "completed",
+		"session_id":     sessionID,
+		"ended_at":       session.EndedAt,
+		"duration":       session.Duration,
+		"summary_status": summaryStatus,
+	})
+}
 
-		if len(transcripts) == 0 {
-			http.Error(w, "No transcripts available for summary generation", http.StatusBadRequest)
-			return
-		}
+// RateSessionHandler records the candidate's 1-5 rating of a completed interview; rating again
+// replaces the earlier rating
+func (e *SessionEndpoints) RateSessionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
 
-		// Trigger summary generation in a goroutine
-		go func() {
-			ctx := context.Background()
-			slog.Info("Starting automatic summary generation", "session_id", sessionID, "transcript_count", len(transcripts), "user_id", user.ID)
-
-			// Get agent information for personality-based summary
-			agent, err := e.repo.GetAgent(ctx, session.AgentID)
-			if err != nil {
-				slog.Error("Failed to load agent for summary generation", "session_id", sessionID, "error", err)
-				return
-			}
-
-			// Prepare conversation history for AI analysis
-			conversationHistory := make([]string, 0, len(transcripts))
-			for _, transcript := range transcripts {
-				conversationHistory = append(conversationHistory,
-					transcript.Speaker+": "+transcript.Content)
-			}
-
-			// Generate personality-based summary using Gemini
-			summaryPrompt := e.buildPersonalityBasedSummaryPrompt(*agent, conversationHistory)
-
-			slog.Info("Generating AI summary with Gemini", "session_id", sessionID, "agent_name", agent.Name, "conversation_length", len(conversationHistory))
-			geminiService := e.getGeminiService() // You'll need to implement this method
-			if geminiService == nil {
-				slog.Error("Gemini service not available for summary generation", "session_id", sessionID)
-				return
-			}
-
-			summary, err := geminiService.GenerateSummary(ctx, summaryPrompt)
-			if err != nil {
-				slog.Error("Failed to generate summary", "session_id", sessionID, "error", err, "user_id", user.ID)
-				return
-			}
-			slog.Info("AI summary generated successfully", "session_id", sessionID, "summary_length", len(summary), "user_id", user.ID)
-
-			// Parse the AI response to extract structured data
-			parsedSummary := e.parseAISummary(summary)
-
-			// Create summary record
-			interviewSummary := models.InterviewSummary{
-				SessionID:       session.ID,
-				Summary:         parsedSummary.Summary,
-				Strengths:       parsedSummary.Strengths,
-				Weaknesses:      parsedSummary.Weaknesses,
-				Recommendations: parsedSummary.Recommendations,
-				OverallScore:    float64(parsedSummary.OverallScore),
-			}
-
-			if err := e.repo.CreateInterviewSummary(ctx, &interviewSummary); err != nil {
-				slog.Error("Failed to save generated summary", "session_id", sessionID, "error", err)
-				return
-			}
-			slog.Info("Summary saved to database", "session_id", sessionID, "summary_id", interviewSummary.ID)
-
-			// Generate performance scores
-			e.generatePerformanceScores(ctx, session.ID, parsedSummary)
-
-			slog.Info("Automatic summary generation completed successfully", "session_id", sessionID, "overall_score", parsedSummary.OverallScore)
-		}()
-
-		// Return immediate response indicating generation has started
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted) // 202 Accepted - processing
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status":     "generating",
-			"message":    "Summary generation has been triggered. Please check back in a few minutes.",
-			"session_id": sessionID,
-		})
+	var req RateSessionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil || session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+	if session.Status != "completed" {
+		apperrors.Write(w, r, apperrors.Conflict("Only completed sessions can be rated"))
+		return
+	}
+
+	if err := e.repo.SetSessionRating(r.Context(), sessionID, req.Rating); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to rate session"))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"summary": summary,
-		"status":  "ready",
+		"session_id": sessionID,
+		"rating":     req.Rating,
 	})
+}
 
-	slog.Info("Interview summary retrieved", "session_id", sessionID, "user_id", user.ID)
+// writeEnding answers an end request for a session that is finalized in the background
+func (e *SessionEndpoints) writeEnding(w http.ResponseWriter, sessionID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "ending",
+		"message":    "The session is being ended and its summary generated.",
+		"session_id": sessionID,
+	})
 }
 
+// GetSessionCodeHandler returns the code the candidate submitted during a session, each with
+// its analysis and a diff against the previous submission
+func (e *SessionEndpoints) GetSessionCodeHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil || session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+
+	submissions := buildCodeHistory(session.Transcripts)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"submissions": submissions,
+		"count":       len(submissions),
+	})
+}
+
+// ExportSessionHandler downloads a report of a session's transcript, summary and scores.
+// ?format=pdf (the default), md or json; the agent's report header template heads it, and PDFs
+// carry the branding of the agent's owner.
+func (e *SessionEndpoints) ExportSessionHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	exportFormat := r.URL.Query().Get("format")
+	if exportFormat == "" {
+		exportFormat = ExportFormatPDF
+	}
+	if exportFormat != ExportFormatPDF && exportFormat != ExportFormatMarkdown && exportFormat != ExportFormatJSON {
+		apperrors.Write(w, r, apperrors.BadRequest("format must be pdf, md or json"))
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil || session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+
+	candidate := user.FullName
+	if candidate == "" {
+		candidate = user.Email
+	}
+	format := UserLocaleFormat(user)
+	report, err := BuildSessionReport(session, candidate, format)
+	if err != nil {
+		// The header template was checked when it was saved, so this shouldn't happen
+		logger.Error("Failed to build session report", "error", err, "session_id", sessionID, "agent_id", session.AgentID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to render report"))
+		return
+	}
+
+	branding, err := e.branding.ForAgent(r.Context(), &session.Agent)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get branding"))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="praxis-interview-%s.%s"`, sessionID, exportFormat))
+	switch exportFormat {
+	case ExportFormatPDF:
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(renderReportPDF(report, format, e.branding.Brand(r.Context(), branding)))
+	case ExportFormatMarkdown:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		io.WriteString(w, RenderReportMarkdown(report, format))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+
+	logger.Info("Session exported", "session_id", sessionID, "format", exportFormat)
+}
+
+// GetSessionExplanationsHandler returns the model's rationale for the questions asked and the
+// scores given in a session. kind=question or kind=score filters the list.
+func (e *SessionEndpoints) GetSessionExplanationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil || session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+	// Explaining questions mid-interview would coach the candidate
+	if session.Status == "active" {
+		apperrors.Write(w, r, apperrors.Conflict("Explanations are available once the interview has ended"))
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && kind != models.ExplanationKindQuestion && kind != models.ExplanationKindScore {
+		apperrors.Write(w, r, apperrors.BadRequest("kind must be question or score"))
+		return
+	}
+
+	explanations, err := e.repo.GetSessionExplanations(r.Context(), sessionID, kind)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get explanations"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"explanations": explanations,
+	})
+}
+
+// GenerateSummaryHandler queues summary generation for a completed session and answers 202
+// with the job to poll. A session that already has a summary needs ?force=true, which replaces it.
 func (e *SessionEndpoints) GenerateSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	sessionID := chi.URLParam(r, "id")
 	if sessionID == "" {
-		http.Error(w, "Session ID is required", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.BadRequest("Session ID is required"))
 		return
 	}
+	force := r.URL.Query().Get("force") == "true"
 
 	// First verify the session belongs to the user and is completed
 	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
 	if err != nil {
-		slog.Error("Failed to get interview session", "error", err, "session_id", sessionID, "user_id", user.ID)
-		http.Error(w, "Session not found", http.StatusNotFound)
+		logger.Error("Failed to get interview session", "error", err, "session_id", sessionID)
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
 		return
 	}
 
 	if session == nil {
-		http.Error(w, "Session not found", http.StatusNotFound)
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
 		return
 	}
 
 	if session.Status != "completed" {
-		http.Error(w, "Session must be completed to generate summary", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.BadRequest("Session must be completed to generate summary"))
 		return
 	}
 
-	// Check if summary already exists
-	existingSummary, err := e.repo.GetInterviewSummary(r.Context(), sessionID)
-	if err != nil {
-		slog.Error("Failed to check existing summary", "error", err, "session_id", sessionID)
-		http.Error(w, "Failed to check existing summary", http.StatusInternalServerError)
+	if session.Summary != nil && !force {
+		apperrors.Write(w, r, apperrors.Conflict("Summary already exists; use force=true to regenerate it"))
 		return
 	}
 
-	if existingSummary != nil {
-		http.Error(w, "Summary already exists", http.StatusConflict)
+	// Warm-up small talk is never scored
+	if len(scoredTranscripts(session.Transcripts)) == 0 {
+		apperrors.Write(w, r, apperrors.BadRequest("No transcripts available for summary generation"))
 		return
 	}
 
-	// Get transcripts for the session
-	transcripts, err := e.repo.GetInterviewTranscripts(r.Context(), sessionID)
+	var job *models.SummaryJob
+	if force {
+		job, err = e.summaries.EnqueueRegeneration(r.Context(), sessionID)
+	} else {
+		job, err = e.summaries.Enqueue(r.Context(), sessionID, nil)
+	}
 	if err != nil {
-		slog.Error("Failed to get transcripts for summary generation", "error", err, "session_id", sessionID)
-		http.Error(w, "Failed to get session transcripts", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("Failed to queue summary generation"))
 		return
 	}
 
-	if len(transcripts) == 0 {
-		http.Error(w, "No transcripts available for summary generation", http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     job.Status,
+		"job_id":     job.ID,
+		"session_id": sessionID,
+		"force":      job.Force,
+	})
+
+	logger.Info("Manual summary generation queued", "session_id", sessionID, "job_id", job.ID, "force", force)
+}
+
+// GetSummaryJobHandler returns a summary job of one of the user's sessions, for polling after
+// requesting generation
+func (e *SessionEndpoints) GetSummaryJobHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	job, err := e.summaries.Job(r.Context(), chi.URLParam(r, "jobID"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get summary job"))
+		return
+	}
+	if job == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Summary job not found"))
+		return
+	}
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), job.SessionID, user.ID)
+	if err != nil || session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Summary job not found"))
 		return
 	}
 
-	// Trigger summary generation (this would need to be implemented with proper timeout service access)
-	// For now, return a message that manual generation is not fully implemented
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Manual summary generation is not yet implemented. Summaries are generated automatically when sessions end.",
-		"status":  "not_implemented",
+		"job": job,
 	})
+}
+
+// GetSummaryVersionsHandler lists the earlier summaries of one of the user's sessions, kept
+// when it was regenerated or re-scored under a changed scoring policy
+func (e *SessionEndpoints) GetSummaryVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
 
-	slog.Info("Manual summary generation requested", "session_id", sessionID, "user_id", user.ID, "transcript_count", len(transcripts))
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil || session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+
+	versions, err := e.repo.GetSummaryVersions(r.Context(), sessionID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get summary versions"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"versions":   versions,
+		"count":      len(versions),
+		"session_id": sessionID,
+	})
 }
 
 func (e *SessionEndpoints) DeleteSessionHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	sessionID := chi.URLParam(r, "id")
 	if sessionID == "" {
-		http.Error(w, "Session ID is required", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.BadRequest("Session ID is required"))
 		return
 	}
 
 	// Verify session belongs to user before deleting
 	_, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
 	if err != nil {
-		slog.Error("Failed to get interview session for deletion", "error", err, "session_id", sessionID, "user_id", user.ID)
-		http.Error(w, "Session not found", http.StatusNotFound)
+		logger.Error("Failed to get interview session for deletion", "error", err, "session_id", sessionID)
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
 		return
 	}
 
 	// Delete the session (this will cascade delete transcripts, summaries, and scores due to foreign key constraints)
 	if err := e.repo.DeleteInterviewSession(r.Context(), sessionID); err != nil {
-		slog.Error("Failed to delete interview session", "error", err, "session_id", sessionID, "user_id", user.ID)
-		http.Error(w, "Failed to delete session", http.StatusInternalServerError)
+		logger.Error("Failed to delete interview session", "error", err, "session_id", sessionID)
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete session"))
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-	slog.Info("Interview session deleted", "session_id", sessionID, "user_id", user.ID)
+	logger.Info("Interview session deleted", "session_id", sessionID)
 }
 
 type BulkDeleteRequest struct {
@@ -433,29 +823,25 @@ type BulkDeleteRequest struct {
 }
 
 func (e *SessionEndpoints) BulkDeleteSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusInternalServerError)
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
 		return
 	}
 
 	var req BulkDeleteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	if len(req.SessionIDs) == 0 {
-		http.Error(w, "At least one session ID is required", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	// Verify all sessions belong to user before deleting
 	sessions, err := e.repo.GetInterviewSessions(r.Context(), user.ID)
 	if err != nil {
-		slog.Error("Failed to get user sessions for bulk deletion", "error", err, "user_id", user.ID)
-		http.Error(w, "Failed to verify sessions", http.StatusInternalServerError)
+		logger.Error("Failed to get user sessions for bulk deletion", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to verify sessions"))
 		return
 	}
 
@@ -468,7 +854,7 @@ func (e *SessionEndpoints) BulkDeleteSessionsHandler(w http.ResponseWriter, r *h
 	// Verify all requested sessions belong to the user
 	for _, sessionID := range req.SessionIDs {
 		if !userSessionIDs[sessionID] {
-			http.Error(w, "One or more sessions do not belong to the user", http.StatusForbidden)
+			apperrors.Write(w, r, apperrors.Forbidden("One or more sessions do not belong to the user"))
 			return
 		}
 	}
@@ -476,8 +862,8 @@ func (e *SessionEndpoints) BulkDeleteSessionsHandler(w http.ResponseWriter, r *h
 	// Delete all sessions
 	deletedCount, err := e.repo.BulkDeleteInterviewSessions(r.Context(), req.SessionIDs)
 	if err != nil {
-		slog.Error("Failed to bulk delete interview sessions", "error", err, "session_ids", req.SessionIDs, "user_id", user.ID)
-		http.Error(w, "Failed to delete sessions", http.StatusInternalServerError)
+		logger.Error("Failed to bulk delete interview sessions", "error", err, "session_ids", req.SessionIDs)
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete sessions"))
 		return
 	}
 
@@ -489,211 +875,5 @@ func (e *SessionEndpoints) BulkDeleteSessionsHandler(w http.ResponseWriter, r *h
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	slog.Info("Bulk interview sessions deleted", "deleted_count", deletedCount, "user_id", user.ID)
-}
-
-// getGeminiService returns the Gemini service instance
-func (e *SessionEndpoints) getGeminiService() *GeminiService {
-	return e.geminiService
-}
-
-// buildPersonalityBasedSummaryPrompt creates a summary prompt tailored to the agent's personality
-func (e *SessionEndpoints) buildPersonalityBasedSummaryPrompt(agent models.Agent, conversationHistory []string) string {
-	// Determine scoring strictness based on agent personality
-	scoringGuidance := e.getScoringGuidance(agent.Personality)
-
-	// Build industry-specific context
-	industryContext := e.getIndustryContext(agent.Industry, agent.Level)
-
-	// Create personality-specific tone and expectations
-	personalityTone := e.getPersonalityTone(agent.Personality)
-
-	prompt := fmt.Sprintf(`You are %s, a %s interviewer in the %s industry. 
-Your personality: %s
-
-%s
-
-Based on this interview conversation, provide a comprehensive analysis that reflects your interviewing style and personality:
-
-1. A narrative summary of the interview (written in your voice and style)
-2. Key strengths demonstrated by the candidate
-3. Areas for improvement (be specific and constructive)
-4. Specific recommendations for the candidate's growth
-5. An overall score (0-100) using this scoring guidance: %s
-
-%s
-
-Conversation:
-%s
-
-Please structure your response as:
-SUMMARY: [Your narrative summary]
-STRENGTHS: [Key strengths]
-WEAKNESSES: [Areas for improvement]
-RECOMMENDATIONS: [Specific recommendations]
-SCORE: [Numerical score 0-100]`,
-		agent.Name,
-		agent.Level,
-		agent.Industry,
-		agent.Personality,
-		industryContext,
-		scoringGuidance,
-		personalityTone,
-		joinStrings(conversationHistory, "\n"))
-
-	return prompt
-}
-
-// parseAISummary parses the structured JSON response from Gemini
-func (e *SessionEndpoints) parseAISummary(response string) *ParsedSummary {
-	// Parse structured JSON response from Gemini
-	var jsonResponse struct {
-		Summary         string  `json:"summary"`
-		Strengths       string  `json:"strengths"`
-		Weaknesses      string  `json:"weaknesses"`
-		Recommendations string  `json:"recommendations"`
-		OverallScore    float64 `json:"overallScore"`
-		TechnicalSkills []struct {
-			Skill  string  `json:"skill"`
-			Rating float64 `json:"rating"`
-		} `json:"technicalSkills"`
-		CommunicationSkills []struct {
-			Skill  string  `json:"skill"`
-			Rating float64 `json:"rating"`
-		} `json:"communicationSkills"`
-	}
-
-	// Parse the JSON response
-	if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
-		slog.Error("Failed to parse AI summary JSON", "error", err, "response", response)
-		// Fallback to basic parsing if JSON parsing fails
-		return &ParsedSummary{
-			Summary:         response,
-			Strengths:       "Unable to parse structured response",
-			Weaknesses:      "Unable to parse structured response",
-			Recommendations: "Unable to parse structured response",
-			OverallScore:    50.0, // Default score
-		}
-	}
-
-	// Validate and sanitize the response
-	if jsonResponse.OverallScore < 0 {
-		jsonResponse.OverallScore = 0
-	}
-	if jsonResponse.OverallScore > 100 {
-		jsonResponse.OverallScore = 100
-	}
-
-	// Ensure we have valid strings
-	if jsonResponse.Summary == "" {
-		jsonResponse.Summary = "No summary provided"
-	}
-	if jsonResponse.Strengths == "" {
-		jsonResponse.Strengths = "No strengths identified"
-	}
-	if jsonResponse.Weaknesses == "" {
-		jsonResponse.Weaknesses = "No weaknesses identified"
-	}
-	if jsonResponse.Recommendations == "" {
-		jsonResponse.Recommendations = "No recommendations provided"
-	}
-
-	slog.Info("Successfully parsed structured AI summary",
-		"overall_score", jsonResponse.OverallScore,
-		"technical_skills_count", len(jsonResponse.TechnicalSkills),
-		"communication_skills_count", len(jsonResponse.CommunicationSkills))
-
-	return &ParsedSummary{
-		Summary:         jsonResponse.Summary,
-		Strengths:       jsonResponse.Strengths,
-		Weaknesses:      jsonResponse.Weaknesses,
-		Recommendations: jsonResponse.Recommendations,
-		OverallScore:    jsonResponse.OverallScore,
-	}
-}
-
-// generatePerformanceScores creates detailed performance scores
-func (e *SessionEndpoints) generatePerformanceScores(ctx context.Context, sessionID string, parsedSummary *ParsedSummary) {
-	// Calculate performance scores based on the overall score and session characteristics
-	baseScore := parsedSummary.OverallScore
-
-	// Create performance scores that are related to the overall score
-	scores := []models.PerformanceScore{
-		{
-			SessionID: sessionID,
-			Metric:    "Communication",
-			Score:     e.calculateMetricScore(baseScore, 0.1), // Slightly higher than base
-			MaxScore:  100.0,
-		},
-		{
-			SessionID: sessionID,
-			Metric:    "Technical Knowledge",
-			Score:     e.calculateMetricScore(baseScore, -0.05), // Slightly lower than base
-			MaxScore:  100.0,
-		},
-		{
-			SessionID: sessionID,
-			Metric:    "Problem Solving",
-			Score:     e.calculateMetricScore(baseScore, 0.0), // Same as base
-			MaxScore:  100.0,
-		},
-		{
-			SessionID: sessionID,
-			Metric:    "Professionalism",
-			Score:     e.calculateMetricScore(baseScore, 0.05), // Slightly higher than base
-			MaxScore:  100.0,
-		},
-	}
-
-	// Save performance scores to database
-	for _, score := range scores {
-		if err := e.repo.CreatePerformanceScore(ctx, &score); err != nil {
-			slog.Error("Failed to create performance score", "session_id", sessionID, "metric", score.Metric, "error", err)
-		}
-	}
-
-	slog.Info("Performance scores generation completed", "session_id", sessionID, "scores_count", len(scores))
-}
-
-// calculateMetricScore calculates a metric score based on the base score and adjustment
-func (e *SessionEndpoints) calculateMetricScore(baseScore float64, adjustment float64) float64 {
-	adjustedScore := baseScore + (baseScore * adjustment)
-	if adjustedScore < 0 {
-		return 0
-	}
-	if adjustedScore > 100 {
-		return 100
-	}
-	return adjustedScore
-}
-
-// Helper methods for summary generation
-func (e *SessionEndpoints) getScoringGuidance(personality string) string {
-	switch strings.ToLower(personality) {
-	case "strict", "tough", "demanding":
-		return "Be very strict and demanding. Only give high scores (80+) for exceptional performance. Average performance should score 50-70."
-	case "encouraging", "supportive", "friendly":
-		return "Be encouraging and supportive. Focus on potential and growth. Give higher scores (70+) for good effort and communication."
-	case "technical", "analytical":
-		return "Focus heavily on technical accuracy and problem-solving skills. Be precise in evaluation."
-	default:
-		return "Be balanced and fair in your evaluation. Consider both technical skills and communication."
-	}
-}
-
-func (e *SessionEndpoints) getIndustryContext(industry, level string) string {
-	return fmt.Sprintf("This is a %s level interview in the %s industry. Focus on relevant skills and knowledge for this domain.", level, industry)
-}
-
-func (e *SessionEndpoints) getPersonalityTone(personality string) string {
-	switch strings.ToLower(personality) {
-	case "strict", "tough":
-		return "Be direct and honest in your feedback. Don't sugarcoat areas for improvement."
-	case "encouraging", "supportive":
-		return "Be positive and constructive. Focus on growth opportunities and potential."
-	case "technical", "analytical":
-		return "Be precise and detailed in your analysis. Focus on technical accuracy and methodology."
-	default:
-		return "Be professional and balanced in your tone."
-	}
+	logger.Info("Bulk interview sessions deleted", "deleted_count", deletedCount)
 }
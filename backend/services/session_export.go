@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// Formats a session can be exported in
+const (
+	ExportFormatPDF      = "pdf"
+	ExportFormatMarkdown = "md"
+	ExportFormatJSON     = "json"
+)
+
+const (
+	// defaultReportHeader heads the reports of agents without a header of their own
+	defaultReportHeader = "Interview Report: {{.AgentName}}"
+	// maxReportHeaderLength caps an agent's report header template
+	maxReportHeaderLength = 1000
+)
+
+// ReportHeaderData is the data available to an agent's report header template
+type ReportHeaderData struct {
+	AgentName string
+	Industry  string
+	Level     string
+	Candidate string
+	Date      string // Day of the interview, in the reader's locale
+	SessionID string
+}
+
+// ValidateReportHeader checks that a report header template parses and renders
+func ValidateReportHeader(header string) error {
+	if len(header) > maxReportHeaderLength {
+		return fmt.Errorf("report_header must be at most %d characters", maxReportHeaderLength)
+	}
+	_, err := renderReportHeader(header, ReportHeaderData{})
+	return err
+}
+
+func renderReportHeader(header string, data ReportHeaderData) (string, error) {
+	if strings.TrimSpace(header) == "" {
+		header = defaultReportHeader
+	}
+	tmpl, err := template.New("report_header").Option("missingkey=error").Parse(header)
+	if err != nil {
+		return "", fmt.Errorf("invalid report header: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render report header: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// SessionReport is a session's transcript, summary and scores laid out for export
+type SessionReport struct {
+	Header     string         `json:"header"` // Rendered from the agent's report header template; may span lines
+	SessionID  string         `json:"session_id"`
+	Agent      ReportAgent    `json:"agent"`
+	Candidate  string         `json:"candidate"`
+	StartedAt  time.Time      `json:"started_at"`
+	EndedAt    *time.Time     `json:"ended_at,omitempty"`
+	Duration   int            `json:"duration"` // Seconds
+	Summary    *ReportSummary `json:"summary,omitempty"`
+	Scores     []ReportScore  `json:"scores"`
+	Transcript []ReportTurn   `json:"transcript"`
+}
+
+type ReportAgent struct {
+	Name     string `json:"name"`
+	Industry string `json:"industry,omitempty"`
+	Level    string `json:"level,omitempty"`
+}
+
+type ReportSummary struct {
+	OverallScore    float64 `json:"overall_score"`
+	Passed          *bool   `json:"passed,omitempty"`
+	Summary         string  `json:"summary"`
+	Strengths       string  `json:"strengths,omitempty"`
+	Weaknesses      string  `json:"weaknesses,omitempty"`
+	Recommendations string  `json:"recommendations,omitempty"`
+}
+
+type ReportScore struct {
+	Metric   string  `json:"metric"`
+	Score    float64 `json:"score"`
+	MaxScore float64 `json:"max_score"`
+}
+
+// ReportTurn is one turn of the transcript. Code turns carry the language, and the hidden test
+// results when they were graded.
+type ReportTurn struct {
+	Speaker     string    `json:"speaker"` // interviewer or candidate
+	Kind        string    `json:"kind"`
+	Language    string    `json:"language,omitempty"`
+	Content     string    `json:"content"`
+	TestsPassed int       `json:"tests_passed,omitempty"`
+	TestsTotal  int       `json:"tests_total,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// BuildSessionReport lays out a session loaded with its agent, transcripts, summary and scores.
+// format dates the header the way the reader reads dates.
+func BuildSessionReport(session *models.InterviewSession, candidate string, format *LocaleFormat) (*SessionReport, error) {
+	header, err := renderReportHeader(session.Agent.ReportHeader, ReportHeaderData{
+		AgentName: session.Agent.Name,
+		Industry:  session.Agent.Industry,
+		Level:     session.Agent.Level,
+		Candidate: candidate,
+		Date:      format.Date(session.StartedAt),
+		SessionID: session.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SessionReport{
+		Header:    header,
+		SessionID: session.ID,
+		Agent: ReportAgent{
+			Name:     session.Agent.Name,
+			Industry: session.Agent.Industry,
+			Level:    session.Agent.Level,
+		},
+		Candidate:  candidate,
+		StartedAt:  session.StartedAt,
+		EndedAt:    session.EndedAt,
+		Duration:   session.Duration,
+		Scores:     []ReportScore{},
+		Transcript: []ReportTurn{},
+	}
+
+	if summary := session.Summary; summary != nil {
+		report.Summary = &ReportSummary{
+			OverallScore:    summary.OverallScore,
+			Passed:          summary.Passed,
+			Summary:         summary.Summary,
+			Strengths:       summary.Strengths,
+			Weaknesses:      summary.Weaknesses,
+			Recommendations: summary.Recommendations,
+		}
+	}
+	for _, score := range session.PerformanceScores {
+		report.Scores = append(report.Scores, ReportScore{Metric: score.Metric, Score: score.Score, MaxScore: score.MaxScore})
+	}
+
+	transcripts := append([]models.InterviewTranscript(nil), session.Transcripts...)
+	sort.SliceStable(transcripts, func(i, j int) bool {
+		if transcripts[i].TurnOrder != transcripts[j].TurnOrder {
+			return transcripts[i].TurnOrder < transcripts[j].TurnOrder
+		}
+		return transcripts[i].Timestamp.Before(transcripts[j].Timestamp)
+	})
+	for _, transcript := range transcripts {
+		speaker := "candidate"
+		if transcript.Speaker == "agent" {
+			speaker = "interviewer"
+		}
+		report.Transcript = append(report.Transcript, ReportTurn{
+			Speaker:     speaker,
+			Kind:        transcript.Kind,
+			Language:    transcript.Language,
+			Content:     transcript.Content,
+			TestsPassed: transcript.TestsPassed,
+			TestsTotal:  transcript.TestsTotal,
+			Timestamp:   transcript.Timestamp,
+		})
+	}
+	return report, nil
+}
+
+// reportSpeakers labels the speakers of a transcript
+var reportSpeakers = map[string]string{
+	"interviewer": "Interviewer",
+	"candidate":   "Candidate",
+}
+
+// reportDetails are the lines under the header describing the interview
+func reportDetails(report *SessionReport, format *LocaleFormat) []string {
+	interviewer := report.Agent.Name
+	if descriptor := strings.TrimSpace(report.Agent.Level + " " + report.Agent.Industry); descriptor != "" {
+		interviewer += " (" + descriptor + ")"
+	}
+	return []string{
+		"Interviewer: " + interviewer,
+		"Candidate: " + report.Candidate,
+		"Date: " + format.DateTime(report.StartedAt),
+		fmt.Sprintf("Duration: %d min", (report.Duration+30)/60),
+	}
+}
+
+// RenderReportMarkdown writes a report as Markdown, with code turns in fenced blocks
+func RenderReportMarkdown(report *SessionReport, format *LocaleFormat) string {
+	var md strings.Builder
+	title, subtitle, _ := strings.Cut(report.Header, "\n")
+	fmt.Fprintf(&md, "# %s\n\n", title)
+	if subtitle = strings.TrimSpace(subtitle); subtitle != "" {
+		fmt.Fprintf(&md, "%s\n\n", subtitle)
+	}
+	for _, detail := range reportDetails(report, format) {
+		fmt.Fprintf(&md, "- %s\n", detail)
+	}
+
+	if summary := report.Summary; summary != nil {
+		fmt.Fprintf(&md, "\n## Summary\n\nOverall score: %s / 100", format.Number(summary.OverallScore, 0))
+		md.WriteString(reportPassed(summary))
+		fmt.Fprintf(&md, "\n\n%s\n", strings.TrimSpace(summary.Summary))
+		for _, section := range reportSummarySections(summary) {
+			fmt.Fprintf(&md, "\n### %s\n\n%s\n", section.title, section.text)
+		}
+	}
+
+	if len(report.Scores) > 0 {
+		md.WriteString("\n## Scores\n\n| Metric | Score |\n| --- | --- |\n")
+		for _, score := range report.Scores {
+			fmt.Fprintf(&md, "| %s | %s / %s |\n", score.Metric, format.Number(score.Score, 0), format.Number(score.MaxScore, 0))
+		}
+	}
+
+	md.WriteString("\n## Transcript\n")
+	for _, turn := range report.Transcript {
+		fmt.Fprintf(&md, "\n**%s** (%s)", reportSpeakers[turn.Speaker], format.DateTime(turn.Timestamp))
+		if turn.Kind != models.TranscriptKindCode {
+			fmt.Fprintf(&md, ": %s\n", strings.TrimSpace(turn.Content))
+			continue
+		}
+		md.WriteString(" submitted code")
+		if turn.TestsTotal > 0 {
+			fmt.Fprintf(&md, ", passing %d of %d tests", turn.TestsPassed, turn.TestsTotal)
+		}
+		fence := markdownFence(turn.Content)
+		fmt.Fprintf(&md, ":\n\n%s%s\n%s\n%s\n", fence, turn.Language, strings.TrimRight(turn.Content, "\n"), fence)
+	}
+	return md.String()
+}
+
+// markdownFence returns a code fence longer than any run of backticks in content, so the
+// content can't close it
+func markdownFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// reportPassed notes the outcome of a scoring policy's pass mark, if the summary has one
+func reportPassed(summary *ReportSummary) string {
+	switch {
+	case summary.Passed == nil:
+		return ""
+	case *summary.Passed:
+		return " (passed)"
+	default:
+		return " (not passed)"
+	}
+}
+
+type reportSection struct {
+	title string
+	text  string
+}
+
+// reportSummarySections are the parts of a summary after its narrative, leaving out empty ones
+func reportSummarySections(summary *ReportSummary) []reportSection {
+	var sections []reportSection
+	for _, section := range []reportSection{
+		{title: "Strengths", text: summary.Strengths},
+		{title: "Areas for Improvement", text: summary.Weaknesses},
+		{title: "Recommendations", text: summary.Recommendations},
+	} {
+		if section.text = strings.TrimSpace(section.text); section.text != "" {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
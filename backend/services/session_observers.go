@@ -0,0 +1,221 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// maxTakeoverQuestionLength caps the size of an observer's question
+const maxTakeoverQuestionLength = 2000
+
+var (
+	ErrTakeoverInactive    = errors.New("the interview isn't running")
+	ErrTakeoverClosing     = errors.New("the interview is wrapping up")
+	ErrTakeoverNoCandidate = errors.New("the candidate isn't connected")
+	ErrTakeoverBannedTopic = errors.New("the question raises a banned topic")
+	ErrTakeoverTooLong     = fmt.Errorf("the question must be at most %d characters", maxTakeoverQuestionLength)
+	ErrTakeoverFailed      = errors.New("the question couldn't be asked")
+)
+
+// SessionObserverService lets an organization's admins follow a live interview its members or
+// invited candidates are having, over a WebSocket of their own. They see each turn as it
+// happens and can take over the interviewer for a question: injected to be asked right away,
+// or overriding the interviewer's next reply. Their questions are screened for banned topics
+// like the interviewer's.
+type SessionObserverService struct {
+	repo       *repository.GORMRepository
+	hub        *ws.Hub
+	processor  *AIMessageProcessor
+	timeouts   *SessionTimeoutService
+	compliance *ComplianceService
+}
+
+func NewSessionObserverService(repo *repository.GORMRepository, hub *ws.Hub, processor *AIMessageProcessor, timeouts *SessionTimeoutService, compliance *ComplianceService) *SessionObserverService {
+	return &SessionObserverService{
+		repo:       repo,
+		hub:        hub,
+		processor:  processor,
+		timeouts:   timeouts,
+		compliance: compliance,
+	}
+}
+
+// Authorize returns the session a user wants to observe, if they are an admin of an
+// organization that can see it and it is still running
+func (s *SessionObserverService) Authorize(ctx context.Context, user *models.User, sessionID string) (*models.InterviewSession, error) {
+	session, err := s.repo.GetInterviewSession(ctx, sessionID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to get session")
+	}
+	if session == nil {
+		return nil, apperrors.NotFound("Session not found")
+	}
+
+	memberships, err := s.repo.GetUserOrgMemberships(ctx, user.ID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to check organizations")
+	}
+	allowed := false
+	for _, membership := range memberships {
+		if !OrgRoleAtLeast(membership.Role, models.OrgRoleAdmin) {
+			continue
+		}
+		if allowed, err = s.repo.IsOrgSession(ctx, membership.OrganizationID, sessionID); err != nil {
+			return nil, apperrors.Internal("Failed to check organizations")
+		}
+		if allowed {
+			break
+		}
+	}
+	if !allowed {
+		return nil, apperrors.NotFound("Session not found")
+	}
+	if session.Status != "active" {
+		return nil, apperrors.Conflict("Session has ended")
+	}
+	return session, nil
+}
+
+// Attach catches a new observer up on the conversation so far
+func (s *SessionObserverService) Attach(ctx context.Context, observer *ws.Client) {
+	// The live transcript is replayed even if the stored one can't be read
+	stored, _ := s.repo.GetInterviewTranscripts(ctx, observer.SessionID)
+	s.processor.replayTranscript(observer, stored)
+}
+
+// HandleMessage handles a message from an observer; takeovers are all they may send
+func (s *SessionObserverService) HandleMessage(observer *ws.Client, env ws.Envelope) {
+	logger := logging.FromContext(observer.Context())
+	payload, perr := ws.DecodePayload(env)
+	if perr != nil {
+		observer.SendError(perr.Code, perr.Message)
+		return
+	}
+	takeover, ok := payload.(ws.TakeoverPayload)
+	if !ok {
+		observer.SendError(ws.ErrCodeNotAllowed, "Observers can only send takeover messages")
+		return
+	}
+
+	if err := s.Takeover(observer.Context(), observer.SessionID, takeover); err != nil {
+		logger.Warn("Takeover rejected", "mode", takeover.Mode, "error", err)
+		observer.SendError(ws.ErrCodeProcessingFailed, err.Error())
+		return
+	}
+	logger.Info("Interviewer taken over", "mode", takeover.Mode)
+
+	messageBytes, err := ws.Encode(ws.TypeTakeoverAccepted, takeover)
+	if err != nil {
+		logger.Error("Failed to marshal takeover message", "error", err)
+		return
+	}
+	safeSend(observer.Send, messageBytes)
+}
+
+// Takeover puts an observer's question to the candidate of a running interview: right away to
+// inject it, or instead of the interviewer's next reply to override it. A later override
+// replaces one not yet asked.
+func (s *SessionObserverService) Takeover(ctx context.Context, sessionID string, takeover ws.TakeoverPayload) error {
+	question := strings.TrimSpace(takeover.Question)
+	if len(question) > maxTakeoverQuestionLength {
+		return ErrTakeoverTooLong
+	}
+	if !s.timeouts.IsActive(sessionID) {
+		return ErrTakeoverInactive
+	}
+	if s.timeouts.GetClosingStage(sessionID) != ClosingStageNone {
+		return ErrTakeoverClosing
+	}
+
+	session, err := s.repo.GetInterviewSession(ctx, sessionID)
+	if err != nil || session == nil {
+		return ErrTakeoverInactive
+	}
+	agent, err := s.repo.GetAgent(ctx, session.AgentID)
+	if err != nil || agent == nil {
+		return fmt.Errorf("failed to get the interviewer")
+	}
+	if s.compliance.Screen(ctx, sessionID, agent, question) {
+		return ErrTakeoverBannedTopic
+	}
+
+	if takeover.Mode == ws.TakeoverOverride {
+		if !s.timeouts.SetNextQuestion(sessionID, question) {
+			return ErrTakeoverInactive
+		}
+		return nil
+	}
+
+	candidates := s.hub.SessionClients(sessionID)
+	if len(candidates) == 0 {
+		return ErrTakeoverNoCandidate
+	}
+	return s.processor.AskQuestion(candidates[0], agent, question)
+}
+
+// HandleSessionConcluded tells the observers of a finished session it has ended and
+// disconnects them
+func (s *SessionObserverService) HandleSessionConcluded(ctx context.Context, event Event) error {
+	messageBytes, err := ws.Encode(ws.TypeEndSession, ws.EndSessionPayload{Reason: "Interview concluded"})
+	if err != nil {
+		return err
+	}
+	s.hub.CloseObservers(event.SessionID, messageBytes)
+	return nil
+}
+
+// AskQuestion has the interviewer put a question to the candidate right away, such as one an
+// observing admin injected. It's saved first, so it's only asked once it has its turn.
+func (p *AIMessageProcessor) AskQuestion(client *ws.Client, agent *models.Agent, question string) error {
+	ctx := client.Context()
+	p.timeoutService.UpdateActivity(client.SessionID)
+
+	phase, _ := p.turnPhase(client.SessionID)
+	transcript := models.InterviewTranscript{
+		SessionID: client.SessionID,
+		Speaker:   "agent",
+		Content:   question,
+		Phase:     phase,
+		Stage:     p.flows.Stage(client.SessionID),
+		Timestamp: time.Now(),
+	}
+	if err := p.repo.CreateInterviewTranscript(ctx, &transcript); err != nil {
+		logging.FromContext(ctx).Error("Failed to save injected question transcript", "error", err)
+		return ErrTakeoverFailed
+	}
+	p.recordTurn(client, transcript)
+	p.respond(ctx, client, question, agent, transcript.ID)
+	return nil
+}
+
+// recordTurn adds a turn to the session's live transcript and shows it to anyone observing
+func (p *AIMessageProcessor) recordTurn(client *ws.Client, transcript models.InterviewTranscript) {
+	p.timeoutService.AddTranscript(client.SessionID, transcript)
+	p.observeTurn(client, transcript)
+}
+
+// observeTurn sends a turn to the admins observing the session, if there are any
+func (p *AIMessageProcessor) observeTurn(client *ws.Client, transcript models.InterviewTranscript) {
+	messageBytes, err := ws.Encode(ws.TypeLiveTurn, ws.TurnPayload{
+		Speaker:   transcript.Speaker,
+		Content:   transcript.Content,
+		Kind:      cmp.Or(transcript.Kind, models.TranscriptKindText),
+		Language:  transcript.Language,
+		Timestamp: transcript.Timestamp,
+	})
+	if err != nil {
+		logging.FromContext(client.Context()).Error("Failed to marshal live turn", "error", err)
+		return
+	}
+	client.Hub.Broadcast(client.SessionID, messageBytes)
+}
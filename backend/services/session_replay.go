@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+const (
+	// speechAudioMIME is the format of the interviewer's synthesized replies
+	speechAudioMIME = "audio/mpeg"
+	// replayAudioWindow is how far apart a recording and its turn may be saved and still be matched
+	replayAudioWindow = 2 * time.Minute
+)
+
+// ReplayAudio points at the stored audio of a turn, replayed through the recordings API
+type ReplayAudio struct {
+	RecordingID string `json:"recording_id"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	Tier        string `json:"tier"` // Cold recordings answer 202 while they are restored
+	URL         string `json:"url"`
+}
+
+// ReplayTurn is a turn of an interview placed on its timeline
+type ReplayTurn struct {
+	TranscriptID string       `json:"transcript_id"`
+	Speaker      string       `json:"speaker"`
+	Content      string       `json:"content"`
+	Kind         string       `json:"kind"`
+	Language     string       `json:"language,omitempty"`
+	Phase        string       `json:"phase"`
+	OffsetMs     int64        `json:"offset_ms"` // Since the session started
+	Audio        *ReplayAudio `json:"audio,omitempty"`
+}
+
+// BuildReplay orders a session's turns by when they were spoken, with each turn's offset from
+// the start of the session. Recordings are attached to the turn they were saved for; older
+// recordings, saved before they were keyed by transcript, are matched to the unmatched turn of
+// the same speaker saved closest to them.
+func BuildReplay(session *models.InterviewSession, transcripts []models.InterviewTranscript, recordings []models.AudioRecording) []ReplayTurn {
+	sorted := slices.Clone(transcripts)
+	slices.SortStableFunc(sorted, func(a, b models.InterviewTranscript) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	turns := make([]ReplayTurn, len(sorted))
+	for i, transcript := range sorted {
+		turns[i] = ReplayTurn{
+			TranscriptID: transcript.ID,
+			Speaker:      transcript.Speaker,
+			Content:      transcript.Content,
+			Kind:         transcript.Kind,
+			Language:     transcript.Language,
+			Phase:        transcript.Phase,
+			OffsetMs:     max(0, transcript.Timestamp.Sub(session.StartedAt).Milliseconds()),
+		}
+	}
+
+	byTranscript := make(map[string]int, len(sorted))
+	for i, transcript := range sorted {
+		byTranscript[transcript.ID] = i
+	}
+	var unlinked []models.AudioRecording
+	for _, recording := range recordings {
+		if recording.TranscriptID == nil {
+			unlinked = append(unlinked, recording)
+		} else if i, ok := byTranscript[*recording.TranscriptID]; ok {
+			turns[i].Audio = replayAudio(recording)
+		}
+	}
+
+	for _, recording := range unlinked {
+		match := -1
+		var closest time.Duration
+		for i, transcript := range sorted {
+			if turns[i].Audio != nil || transcript.Speaker != recording.Speaker || transcript.Kind == models.TranscriptKindCode {
+				continue
+			}
+			gap := recording.CreatedAt.Sub(transcript.Timestamp).Abs()
+			if gap <= replayAudioWindow && (match < 0 || gap < closest) {
+				match, closest = i, gap
+			}
+		}
+		if match >= 0 {
+			turns[match].Audio = replayAudio(recording)
+		}
+	}
+	return turns
+}
+
+func replayAudio(recording models.AudioRecording) *ReplayAudio {
+	return &ReplayAudio{
+		RecordingID: recording.ID,
+		ContentType: recording.ContentType,
+		SizeBytes:   recording.SizeBytes,
+		Tier:        recording.Tier,
+		URL:         "/api/v1/recordings/" + recording.ID + "/replay",
+	}
+}
+
+// keepReplyAudio stores the interviewer's spoken reply for replay. Like the candidate's
+// recordings it is saved in the background, and a failure doesn't interrupt the interview.
+func (p *AIMessageProcessor) keepReplyAudio(sessionID, transcriptID string, audioData []byte) {
+	go func() {
+		if _, err := p.recordings.Save(context.Background(), sessionID, transcriptID, "agent", speechAudioMIME, audioData); err != nil {
+			slog.Error("Failed to save reply recording", "error", err, "session_id", sessionID)
+		}
+	}()
+}
+
+// GetSessionReplayHandler returns a session's turns on its timeline, with the stored audio
+// of each spoken turn, so the interview can be replayed as it happened
+func (e *SessionEndpoints) GetSessionReplayHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return
+	}
+	if session == nil {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+
+	recordings, err := e.repo.GetSessionAudioRecordings(r.Context(), session.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get recordings"))
+		return
+	}
+
+	turns := BuildReplay(session, session.Transcripts, recordings)
+	var durationMs int64
+	if session.EndedAt != nil {
+		durationMs = session.EndedAt.Sub(session.StartedAt).Milliseconds()
+	} else if len(turns) > 0 {
+		durationMs = turns[len(turns)-1].OffsetMs
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id":  session.ID,
+		"started_at":  session.StartedAt,
+		"duration_ms": durationMs,
+		"turns":       turns,
+		"count":       len(turns),
+	})
+}
+
+// SessionAudio is a stored recording of one of a session's turns, with a signed link to it
+type SessionAudio struct {
+	RecordingID  string    `json:"recording_id"`
+	TranscriptID *string   `json:"transcript_id,omitempty"` // Unset for recordings saved before they were keyed by turn
+	Speaker      string    `json:"speaker"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Tier         string    `json:"tier"`
+	URL          string    `json:"url,omitempty"` // Empty while archived; replaying the recording restores it
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// GetSessionAudioHandler lists the stored audio of a session's turns, the candidate's answers
+// and the interviewer's replies, with links that play each without further authentication
+func (e *SessionEndpoints) GetSessionAudioHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	session, err := e.repo.GetInterviewSession(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return
+	}
+	if session == nil || session.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return
+	}
+
+	recordings, err := e.repo.GetSessionAudioRecordings(r.Context(), session.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get recordings"))
+		return
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	audio := make([]SessionAudio, len(recordings))
+	for i, recording := range recordings {
+		audio[i] = SessionAudio{
+			RecordingID:  recording.ID,
+			TranscriptID: recording.TranscriptID,
+			Speaker:      recording.Speaker,
+			ContentType:  recording.ContentType,
+			SizeBytes:    recording.SizeBytes,
+			Tier:         recording.Tier,
+			CreatedAt:    recording.CreatedAt,
+		}
+		if recording.Tier != models.RecordingTierHot {
+			continue
+		}
+		link, expires, err := e.recordings.AudioURL(&recording, now)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Failed to sign audio URL", "error", err, "recording_id", recording.ID)
+			continue
+		}
+		audio[i].URL, expiresAt = link, &expires
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id": session.ID,
+		"audio":      audio,
+		"count":      len(audio),
+		"expires_at": expiresAt, // When the links stop working; null if none were signed
+	})
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// maxReplayedTurns caps the turns replayed to a client resuming an interview
+const maxReplayedTurns = 50
+
+// RecentTurns returns the last limit turns of a transcript in the order they happened, and
+// whether older ones were left out. Turns are ordered by time, as turns saved before turn
+// orders were allocated by the database can share one.
+func RecentTurns(transcripts []models.InterviewTranscript, limit int) ([]models.InterviewTranscript, bool) {
+	turns := append([]models.InterviewTranscript(nil), transcripts...)
+	sort.SliceStable(turns, func(i, j int) bool {
+		return turns[i].Timestamp.Before(turns[j].Timestamp)
+	})
+	if limit > 0 && len(turns) > limit {
+		return turns[len(turns)-limit:], true
+	}
+	return turns, false
+}
+
+const (
+	// welcomePollInterval is how often a connection waiting for another's welcome checks for it
+	welcomePollInterval = 250 * time.Millisecond
+	// welcomeWaitSlack is how much longer than generating a welcome a connection waits for
+	// another's, for saving it
+	welcomeWaitSlack = 10 * time.Second
+)
+
+// ClaimOrAwaitWelcome settles which of the connections to a new session welcomes the candidate,
+// as quick reconnects race to: the one that claims the session gets true. The others wait up
+// to wait for its welcome to be saved and get the transcripts to replay, as the candidate may
+// be left on one of them once the older connections are replaced. A claim released because its
+// welcome couldn't be saved goes to the next connection to check.
+func ClaimOrAwaitWelcome(ctx context.Context, repo *repository.GORMRepository, sessionID string, wait time.Duration) (bool, []models.InterviewTranscript, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	ticker := time.NewTicker(welcomePollInterval)
+	defer ticker.Stop()
+
+	for {
+		claimed, err := repo.ClaimSessionWelcome(ctx, sessionID)
+		if err != nil || claimed {
+			return claimed, nil, err
+		}
+		transcripts, err := repo.GetInterviewTranscripts(ctx, sessionID)
+		if err != nil || len(transcripts) > 0 {
+			return false, transcripts, err
+		}
+		select {
+		case <-ctx.Done():
+			return false, nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// replayTranscript sends a client connecting to an interview that has begun the latest turns,
+// so it can show the conversation again. The live transcript holds turns not yet saved, such
+// as spoken answers; the stored one adds the welcome and anything from before a crash lost
+// the live one.
+func (p *AIMessageProcessor) replayTranscript(client *ws.Client, stored []models.InterviewTranscript) {
+	logger := logging.FromContext(client.Context())
+	live, _ := p.timeoutService.GetLiveTranscripts(client.SessionID)
+	var liveSince time.Time
+	for i, turn := range live {
+		if i == 0 || turn.Timestamp.Before(liveSince) {
+			liveSince = turn.Timestamp
+		}
+	}
+	transcripts := live
+	for _, turn := range stored {
+		if len(live) == 0 || turn.Timestamp.Before(liveSince) {
+			transcripts = append(transcripts, turn)
+		}
+	}
+	turns, truncated := RecentTurns(transcripts, maxReplayedTurns)
+
+	payload := ws.SessionResumedPayload{
+		Turns:     make([]ws.TurnPayload, 0, len(turns)),
+		Truncated: truncated,
+	}
+	for _, turn := range turns {
+		kind := turn.Kind
+		if kind == "" {
+			kind = models.TranscriptKindText
+		}
+		payload.Turns = append(payload.Turns, ws.TurnPayload{
+			Speaker:   turn.Speaker,
+			Content:   turn.Content,
+			Kind:      kind,
+			Language:  turn.Language,
+			Timestamp: turn.Timestamp,
+		})
+	}
+
+	messageBytes, err := ws.Encode(ws.TypeSessionResumed, payload)
+	if err != nil {
+		logger.Error("Failed to marshal session resumed message", "error", err)
+		return
+	}
+	safeSend(client.Send, messageBytes)
+	logger.Info("Interview resumed", "replayed_turns", len(turns), "truncated", truncated)
+}
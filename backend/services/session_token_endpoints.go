@@ -0,0 +1,211 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// SessionTokenEndpoints lets session owners manage scoped tokens, and serves the
+// external API those tokens unlock
+type SessionTokenEndpoints struct {
+	repo           *repository.GORMRepository
+	tokens         *SessionTokenService
+	timeoutService *SessionTimeoutService
+}
+
+type CreateSessionTokenRequest struct {
+	SessionID  string   `json:"session_id" validate:"required"`
+	Actions    []string `json:"actions"` // read, notes; defaults to read
+	Label      string   `json:"label"`
+	TTLMinutes int      `json:"ttl_minutes"`
+}
+
+type CreateSessionNoteRequest struct {
+	Content string `json:"content" validate:"required"`
+}
+
+func NewSessionTokenEndpoints(repo *repository.GORMRepository, tokens *SessionTokenService, timeoutService *SessionTimeoutService) *SessionTokenEndpoints {
+	return &SessionTokenEndpoints{
+		repo:           repo,
+		tokens:         tokens,
+		timeoutService: timeoutService,
+	}
+}
+
+// RegisterRoutes registers the owner routes; must be mounted behind the auth middleware
+func (e *SessionTokenEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/session-tokens", func(r chi.Router) {
+		r.Post("/", e.CreateTokenHandler)
+		r.Get("/session/{id}", e.GetTokensHandler)
+		r.Delete("/{id}", e.RevokeTokenHandler)
+	})
+}
+
+// RegisterExternalRoutes registers the routes authenticated by session tokens instead of cookies
+func (e *SessionTokenEndpoints) RegisterExternalRoutes(r chi.Router) {
+	r.Route("/external/sessions/{id}", func(r chi.Router) {
+		r.With(e.tokens.RequireSessionScope(SessionActionRead)).Get("/transcript", e.GetTranscriptHandler)
+		r.With(e.tokens.RequireSessionScope(SessionActionRead)).Get("/notes", e.GetNotesHandler)
+		r.With(e.tokens.RequireSessionScope(SessionActionNotes)).Post("/notes", e.CreateNoteHandler)
+	})
+}
+
+// CreateTokenHandler mints a session token. Admins impersonating the owner can't mint tokens,
+// which would outlive the impersonation.
+func (e *SessionTokenEndpoints) CreateTokenHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+	if _, impersonated := r.Context().Value("impersonation").(*models.Impersonation); impersonated {
+		apperrors.Write(w, r, apperrors.Forbidden("Session tokens can't be created while impersonating"))
+		return
+	}
+
+	var req CreateSessionTokenRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	token, record, err := e.tokens.Mint(r.Context(), user, req.SessionID, req.Actions, req.Label, time.Duration(req.TTLMinutes)*time.Minute)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to mint session token", "error", err, "session_id", req.SessionID)
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":         token,
+		"session_token": record,
+		"message":       "Store this token now; it will not be shown again",
+	})
+}
+
+func (e *SessionTokenEndpoints) GetTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	tokens, err := e.repo.GetSessionTokens(r.Context(), chi.URLParam(r, "id"), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session tokens"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tokens": tokens,
+		"count":  len(tokens),
+	})
+}
+
+func (e *SessionTokenEndpoints) RevokeTokenHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	tokenID := chi.URLParam(r, "id")
+	revoked, err := e.repo.RevokeSessionToken(r.Context(), tokenID, user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to revoke session token"))
+		return
+	}
+	if !revoked {
+		apperrors.Write(w, r, apperrors.NotFound("Session token not found"))
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("Session token revoked", "token_id", tokenID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Session token revoked",
+	})
+}
+
+// GetTranscriptHandler returns the live transcript for an active session, or the stored one otherwise
+func (e *SessionTokenEndpoints) GetTranscriptHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	transcripts, live := e.timeoutService.GetLiveTranscripts(sessionID)
+	if !live {
+		var err error
+		transcripts, err = e.repo.GetInterviewTranscripts(r.Context(), sessionID)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to get transcript"))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id":  sessionID,
+		"live":        live,
+		"transcripts": transcripts,
+		"count":       len(transcripts),
+	})
+}
+
+func (e *SessionTokenEndpoints) GetNotesHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	notes, err := e.repo.GetSessionNotes(r.Context(), sessionID, false)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get notes"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"notes": notes,
+		"count": len(notes),
+	})
+}
+
+func (e *SessionTokenEndpoints) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value("session_token").(*models.SessionToken)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("Session token not found in context"))
+		return
+	}
+
+	var req CreateSessionNoteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" {
+		apperrors.Write(w, r, apperrors.BadRequest("Content is required"))
+		return
+	}
+
+	note := &models.SessionNote{
+		SessionID: token.SessionID,
+		TokenID:   &token.ID,
+		Content:   req.Content,
+	}
+	if err := e.repo.CreateSessionNote(r.Context(), note); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to save note"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"note": note,
+	})
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// Session token actions; a token grants session:{id}:{action} for each action
+const (
+	SessionActionRead  = "read"  // Fetch the live transcript and notes
+	SessionActionNotes = "notes" // Append notes
+)
+
+const (
+	DefaultSessionTokenTTL = time.Hour
+	MaxSessionTokenTTL     = 24 * time.Hour
+)
+
+// SessionScope formats the scope string granting an action on a session
+func SessionScope(sessionID, action string) string {
+	return fmt.Sprintf("session:%s:%s", sessionID, action)
+}
+
+// SessionTokenService mints and verifies bearer tokens limited to one session,
+// so external tools can work with a session without full account access
+type SessionTokenService struct {
+	repo *repository.GORMRepository
+	auth *AuthService
+}
+
+func NewSessionTokenService(repo *repository.GORMRepository, auth *AuthService) *SessionTokenService {
+	return &SessionTokenService{
+		repo: repo,
+		auth: auth,
+	}
+}
+
+// Mint issues a token for a session owned by the user. The plaintext token is only returned here.
+// Tokens aren't minted for an impersonating admin.
+func (s *SessionTokenService) Mint(ctx context.Context, user *models.User, sessionID string, actions []string, label string, ttl time.Duration) (string, *models.SessionToken, error) {
+	if _, impersonated := ctx.Value("impersonation").(*models.Impersonation); impersonated {
+		return "", nil, fmt.Errorf("session tokens can't be created while impersonating")
+	}
+	session, err := s.repo.GetInterviewSession(ctx, sessionID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil || session.UserID != user.ID {
+		return "", nil, fmt.Errorf("session not found")
+	}
+
+	if len(actions) == 0 {
+		actions = []string{SessionActionRead}
+	}
+	scopes := make([]string, 0, len(actions))
+	for _, action := range actions {
+		if action != SessionActionRead && action != SessionActionNotes {
+			return "", nil, fmt.Errorf("unknown scope action %q", action)
+		}
+		scopes = append(scopes, SessionScope(sessionID, action))
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultSessionTokenTTL
+	}
+	if ttl > MaxSessionTokenTTL {
+		ttl = MaxSessionTokenTTL
+	}
+
+	token, err := s.auth.generateSecureToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	record := &models.SessionToken{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		Token:     s.auth.hashToken(token),
+		Label:     label,
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.repo.CreateSessionToken(ctx, record); err != nil {
+		return "", nil, fmt.Errorf("failed to store token: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Session token minted", "token_id", record.ID, "session_id", sessionID, "user_id", user.ID, "scopes", record.Scopes)
+	return token, record, nil
+}
+
+// Verify returns the token record if the bearer token is valid and grants the scope
+func (s *SessionTokenService) Verify(ctx context.Context, token, scope string) (*models.SessionToken, error) {
+	record, err := s.repo.GetSessionToken(ctx, s.auth.hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("invalid or expired token")
+	}
+	if !slices.Contains(strings.Split(record.Scopes, ","), scope) {
+		return nil, fmt.Errorf("token lacks scope %s", scope)
+	}
+	return record, nil
+}
+
+// RequireSessionScope authenticates a session token from the Authorization header and
+// checks it grants the action on the session in the {id} URL parameter
+func (s *SessionTokenService) RequireSessionScope(action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || token == "" {
+				apperrors.Write(w, r, apperrors.Unauthorized("Unauthorized"))
+				return
+			}
+
+			sessionID := chi.URLParam(r, "id")
+			record, err := s.Verify(r.Context(), token, SessionScope(sessionID, action))
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("Session token rejected", "session_id", sessionID, "action", action, "error", err)
+				apperrors.Write(w, r, apperrors.Forbidden("Forbidden"))
+				return
+			}
+
+			go s.repo.TouchSessionToken(context.Background(), record.ID)
+
+			ctx := context.WithValue(r.Context(), "session_token", record)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/krshsl/praxis/backend/models"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// speechMetricPrefix starts the names of the performance scores of how a voice interview's
+// answers were spoken, which aren't judged by the model
+const speechMetricPrefix = "Speech: "
+
+// fillerWords matches verbal fillers in a transcription. Transcribers often tidy these away,
+// so the count is a floor rather than the exact number spoken.
+var fillerWords = regexp.MustCompile(`(?i)\b(u+m+|u+h+|uhm|e+r+m*|a+h+|h+m+|you know|i mean|basically|literally)\b`)
+
+// CountFillerWords counts the verbal fillers in an answer
+func CountFillerWords(answer string) int {
+	return len(fillerWords.FindAllStringIndex(answer, -1))
+}
+
+// AnalyzeSpeech measures a spoken answer from its transcription and, if the client reported
+// it, the timing of its recording
+func AnalyzeSpeech(sessionID, transcriptID, answer string, timing *ws.SpeechTiming) models.SpeechMetrics {
+	metrics := models.SpeechMetrics{
+		SessionID:    sessionID,
+		TranscriptID: transcriptID,
+		Words:        len(strings.Fields(answer)),
+		FillerWords:  CountFillerWords(answer),
+	}
+	if timing == nil {
+		return metrics
+	}
+	metrics.DurationMs = timing.DurationMs
+	metrics.Pauses = len(timing.PausesMs)
+	for _, pause := range timing.PausesMs {
+		metrics.PauseMs += pause
+		metrics.LongestPauseMs = max(metrics.LongestPauseMs, pause)
+	}
+	return metrics
+}
+
+// SpeechSummary aggregates how a candidate spoke their answers
+type SpeechSummary struct {
+	Answers           int     `json:"answers"`             // Answers with speech metrics
+	TimedAnswers      int     `json:"timed_answers"`       // Of which the client reported the timing
+	MedianWords       int64   `json:"median_words"`        // Typical answer length
+	WordsPerMinute    float64 `json:"words_per_minute"`    // Over the timed answers
+	FillersPer100     float64 `json:"fillers_per_100"`     // Filler words per 100 words
+	PausesPerMinute   float64 `json:"pauses_per_minute"`   // Over the timed answers
+	PauseShare        float64 `json:"pause_share"`         // Fraction of the timed answers spent silent
+	LongestPauseMs    int64   `json:"longest_pause_ms"`    // The longest silence in any answer
+	MedianDurationMs  int64   `json:"median_duration_ms"`  // Typical length of a timed answer
+	TotalSpeakingSecs float64 `json:"total_speaking_secs"` // Length of the timed answers together
+}
+
+// SummarizeSpeech aggregates the speech metrics of a session's answers
+func SummarizeSpeech(metrics []models.SpeechMetrics) SpeechSummary {
+	summary := SpeechSummary{Answers: len(metrics)}
+	if len(metrics) == 0 {
+		return summary
+	}
+
+	words := make([]int64, len(metrics))
+	var durations []int64
+	var totalWords, fillers, timedWords, pauses int
+	var durationMs, pauseMs int64
+	for i, m := range metrics {
+		words[i] = int64(m.Words)
+		totalWords += m.Words
+		fillers += m.FillerWords
+		summary.LongestPauseMs = max(summary.LongestPauseMs, m.LongestPauseMs)
+		if m.DurationMs > 0 {
+			durations = append(durations, m.DurationMs)
+			timedWords += m.Words
+			pauses += m.Pauses
+			durationMs += m.DurationMs
+			pauseMs += m.PauseMs
+		}
+	}
+	summary.MedianWords = median(words)
+	if totalWords > 0 {
+		summary.FillersPer100 = round1(float64(fillers) * 100 / float64(totalWords))
+	}
+	if len(durations) > 0 {
+		minutes := float64(durationMs) / 60000
+		summary.TimedAnswers = len(durations)
+		summary.MedianDurationMs = median(durations)
+		summary.TotalSpeakingSecs = round1(float64(durationMs) / 1000)
+		summary.WordsPerMinute = round1(float64(timedWords) / minutes)
+		summary.PausesPerMinute = round1(float64(pauses) / minutes)
+		summary.PauseShare = math.Round(min(float64(pauseMs)/float64(durationMs), 1)*100) / 100
+	}
+	return summary
+}
+
+// bandScore scores a value 100 between good and goodUntil, falling linearly to 0 at zeroBelow
+// and zeroAbove
+func bandScore(value, zeroBelow, good, goodUntil, zeroAbove float64) float64 {
+	switch {
+	case value < good:
+		return math.Max(0, 100*(value-zeroBelow)/(good-zeroBelow))
+	case value > goodUntil:
+		return math.Max(0, 100*(zeroAbove-value)/(zeroAbove-goodUntil))
+	}
+	return 100
+}
+
+// speechPerformanceScores scores how the answers were spoken, so scoring policies can weight or
+// gate on delivery: a conversational pace (120-160 words a minute), few fillers, not too much
+// silence and answers of a useful length (50-250 words). Pace and pauses need the timing.
+func speechPerformanceScores(sessionID string, metrics []models.SpeechMetrics) []models.PerformanceScore {
+	speech := SummarizeSpeech(metrics)
+	if speech.Answers == 0 {
+		return nil
+	}
+	score := func(metric string, value float64) models.PerformanceScore {
+		return models.PerformanceScore{
+			SessionID: sessionID,
+			Metric:    speechMetricPrefix + metric,
+			Score:     math.Round(value),
+			MaxScore:  100.0,
+		}
+	}
+
+	scores := []models.PerformanceScore{
+		score("Filler Words", bandScore(speech.FillersPer100, -1, 0, 1, 7)),
+		score("Answer Length", bandScore(float64(speech.MedianWords), 0, 50, 250, 600)),
+	}
+	if speech.TimedAnswers > 0 {
+		scores = append(scores,
+			score("Pace", bandScore(speech.WordsPerMinute, 60, 120, 160, 220)),
+			score("Pauses", bandScore(speech.PauseShare, -1, 0, 0.2, 0.6)),
+		)
+	}
+	return scores
+}
+
+// speechInstruction gives the summary model the measured delivery of a voice interview's
+// answers, or returns "" without any
+func speechInstruction(metrics []models.SpeechMetrics) string {
+	speech := SummarizeSpeech(metrics)
+	if speech.Answers == 0 {
+		return ""
+	}
+	lines := []string{
+		fmt.Sprintf("- Answer length: %d words typically", speech.MedianWords),
+		fmt.Sprintf("- Filler words (um, uh, you know...): %.1f per 100 words", speech.FillersPer100),
+	}
+	if speech.TimedAnswers > 0 {
+		lines = append(lines,
+			fmt.Sprintf("- Pace: %.0f words per minute", speech.WordsPerMinute),
+			fmt.Sprintf("- Pauses of a second or more: %.1f per minute, %.0f%% of speaking time, the longest %.1f seconds", speech.PausesPerMinute, speech.PauseShare*100, float64(speech.LongestPauseMs)/1000),
+		)
+	}
+	return fmt.Sprintf(`
+
+SPEECH DELIVERY: Measured from the candidate's spoken answers:
+%s
+Mention delivery in the feedback where it helps the candidate, but judge the scores on what they said; delivery is scored separately.`, strings.Join(lines, "\n"))
+}
+
+// recordSpeechMetrics stores how the candidate spoke an interview answer
+func (p *AIMessageProcessor) recordSpeechMetrics(ctx context.Context, sessionID, transcriptID, answer string, timing *ws.SpeechTiming) {
+	metrics := AnalyzeSpeech(sessionID, transcriptID, answer, timing)
+	p.repo.CreateSpeechMetrics(ctx, &metrics)
+}
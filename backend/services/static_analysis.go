@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// maxFindingsSize caps the linter output passed to the model and stored with the review
+const maxFindingsSize = 4000
+
+// linter is a static analysis command for one language. Commands run in a scratch directory
+// holding the submission as file (plus any extra files), e.g. a go.mod for Go. Exit codes up
+// to maxFindingsExit mean issues were found; higher ones mean the linter itself failed.
+type linter struct {
+	name            string
+	command         []string
+	maxFindingsExit int
+}
+
+type lintLanguage struct {
+	file     string
+	extra    map[string]string
+	linters  []linter // The first one installed is used
+	goSeeded bool     // The linters type-check with the Go build cache, which starts from the seed
+}
+
+var lintLanguages = map[string]lintLanguage{
+	"go": {
+		file:     "main.go",
+		extra:    map[string]string{"go.mod": "module submission\n\ngo 1.24\n"},
+		goSeeded: true,
+		linters: []linter{
+			{name: "golangci-lint", command: []string{"golangci-lint", "run", "./..."}, maxFindingsExit: 1},
+			{name: "go vet", command: []string{"go", "vet", "./..."}, maxFindingsExit: 1},
+		},
+	},
+	"python": {
+		file: "main.py",
+		linters: []linter{
+			// pylint's exit code is a bitmask of message categories; 32 is a usage error
+			{name: "pylint", command: []string{"pylint", "--score=n", "--reports=n", "--disable=missing-module-docstring", "main.py"}, maxFindingsExit: 31},
+		},
+	},
+	"javascript": {
+		file: "main.js",
+		linters: []linter{
+			{name: "eslint", command: []string{"eslint", "--format", "unix", "main.js"}, maxFindingsExit: 1},
+		},
+	},
+	"typescript": {
+		file: "main.ts",
+		linters: []linter{
+			{name: "eslint", command: []string{"eslint", "--format", "unix", "main.ts"}, maxFindingsExit: 1},
+		},
+	},
+}
+
+// StaticAnalyzer runs language linters over code submissions so the code review starts from
+// objective findings. Linters only read the code, but they parse and type-check whatever the
+// candidate sent, so like the hidden tests they only run inside the configured sandbox command.
+type StaticAnalyzer struct {
+	enabled bool
+	sandbox []string
+	timeout time.Duration
+}
+
+func NewStaticAnalyzer(enabled bool, sandbox string, timeout time.Duration) *StaticAnalyzer {
+	analyzer := &StaticAnalyzer{enabled: enabled, sandbox: strings.Fields(sandbox), timeout: timeout}
+	if enabled && len(analyzer.sandbox) == 0 {
+		slog.Warn("Static analysis is enabled without a sandbox command, code won't be linted")
+		analyzer.enabled = false
+	}
+	return analyzer
+}
+
+// Analyze returns the linter findings for a submission, or "" when analysis is disabled, no
+// linter for the language is installed, or it found nothing
+func (a *StaticAnalyzer) Analyze(ctx context.Context, code, language string) string {
+	logger := logging.FromContext(ctx)
+	if !a.enabled {
+		return ""
+	}
+	lang, ok := lintLanguages[strings.ToLower(language)]
+	if !ok {
+		return ""
+	}
+	tool, ok := installedLinter(lang.linters)
+	if !ok {
+		logger.Debug("No linter installed for language", "language", language)
+		return ""
+	}
+
+	dir, err := os.MkdirTemp("", "praxis-lint-*")
+	if err != nil {
+		logger.Error("Failed to create lint directory", "error", err)
+		return ""
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{lang.file: code}
+	for name, content := range lang.extra {
+		files[name] = content
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			logger.Error("Failed to write lint file", "error", err)
+			return ""
+		}
+	}
+	if lang.goSeeded {
+		if seed := goCacheSeed(ctx); seed != "" {
+			if err := os.CopyFS(filepath.Join(dir, ".cache"), os.DirFS(seed)); err != nil {
+				logger.Error("Failed to copy the build cache", "error", err)
+				return ""
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	// Linters exit non-zero when they report findings, so only a failure to run counts
+	stdout, stderr, err := runSandboxed(ctx, a.sandbox, dir, lintEnv(dir), tool.command, nil)
+	output := bytes.NewBuffer(append(stdout, stderr...))
+	if ctx.Err() != nil {
+		logger.Warn("Linter timed out", "linter", tool.name, "timeout", a.timeout)
+		return ""
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() <= tool.maxFindingsExit {
+		err = nil
+	}
+	if err != nil {
+		logger.Warn("Linter failed", "error", err, "linter", tool.name, "output", truncateFindings(output.String()))
+		return ""
+	}
+
+	findings := strings.TrimSpace(strings.ReplaceAll(output.String(), dir+string(filepath.Separator), ""))
+	if findings == "" {
+		return ""
+	}
+	logger.Info("Static analysis found issues", "linter", tool.name, "language", language, "size", len(findings))
+	return tool.name + ":\n" + truncateFindings(findings)
+}
+
+func truncateFindings(findings string) string {
+	if len(findings) > maxFindingsSize {
+		return findings[:maxFindingsSize] + "\n... (truncated)"
+	}
+	return findings
+}
+
+// installedLinter returns the first linter whose command is on the PATH
+func installedLinter(linters []linter) (linter, bool) {
+	for _, l := range linters {
+		if _, err := exec.LookPath(l.command[0]); err == nil {
+			return l, true
+		}
+	}
+	return linter{}, false
+}
+
+// lintEnv is testEnv with the linters' own caches in the scratch directory too: home points at
+// it, Go may not download modules or toolchains, and cgo is off so a submission's C preamble and
+// #cgo flags never reach a C compiler. No cache outlives the run; the Go build cache starts as a
+// copy of the seed the code tests use, so the standard library isn't compiled for every lint.
+func lintEnv(dir string) []string {
+	return append(testEnv(dir),
+		"GOLANGCI_LINT_CACHE="+filepath.Join(dir, ".golangci-lint"),
+		"PYLINTHOME="+filepath.Join(dir, ".pylint"),
+	)
+}
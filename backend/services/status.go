@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+// Operational states of the public status page, from best to worst
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusOutage      = "outage"
+)
+
+// Features the status page can report as degraded
+const (
+	FeatureInterviews   = "interviews"    // The interviewer can't reply
+	FeatureVoiceReplies = "voice_replies" // Replies are sent as text only
+	FeatureVoiceAnswers = "voice_answers" // Spoken answers can't be transcribed; typed ones still work
+	FeatureSummaries    = "summaries"     // Summaries are delayed
+	FeatureExports      = "exports"       // Exports are delayed
+)
+
+const (
+	// providerFailureLimit is how many calls in a row must fail before a provider is reported down
+	providerFailureLimit = 3
+	// providerRecovery is how long a provider stays down without a new call; with no traffic to
+	// prove otherwise it is assumed to have recovered
+	providerRecovery = 5 * time.Minute
+	// statusCacheTTL is how long a computed status is served, and cached by clients and proxies
+	statusCacheTTL = 30 * time.Second
+	// queueDelayThreshold is how long a job may wait for a worker before its queue is degraded
+	queueDelayThreshold = 10 * time.Minute
+)
+
+// ProviderHealth is a circuit breaker's view of an external provider: after providerFailureLimit
+// failed calls in a row it is down until a call succeeds, or providerRecovery passes without
+// another failure. Calls cut short by their own context don't count.
+type ProviderHealth struct {
+	mu          sync.Mutex
+	failures    int
+	lastFailure time.Time
+}
+
+// Report records the outcome of a call to the provider
+func (h *ProviderHealth) Report(ctx context.Context, err error) {
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.failures = 0
+		return
+	}
+	h.failures++
+	h.lastFailure = time.Now()
+}
+
+// Status returns StatusOutage while the provider is down and StatusOperational otherwise
+func (h *ProviderHealth) Status(now time.Time) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failures >= providerFailureLimit && now.Sub(h.lastFailure) < providerRecovery {
+		return StatusOutage
+	}
+	return StatusOperational
+}
+
+// monitoredTranscriber reports the outcome of each transcription to a ProviderHealth. Wrapping a
+// fallback chain, it is down only once every provider in the chain fails.
+type monitoredTranscriber struct {
+	TranscriptionProvider
+	health *ProviderHealth
+}
+
+func (t monitoredTranscriber) Transcribe(ctx context.Context, audioData []byte, mimeType, prompt string) (Transcription, error) {
+	result, err := t.TranscriptionProvider.Transcribe(ctx, audioData, mimeType, prompt)
+	t.health.Report(ctx, err)
+	return result, err
+}
+
+// ComponentStatus is the state of one part of the service
+type ComponentStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// QueueStatus is the backlog of one of the background queues
+type QueueStatus struct {
+	Name              string `json:"name"`
+	Status            string `json:"status"`
+	Backlog           int64  `json:"backlog"`             // Jobs waiting for a worker
+	OldestWaitSeconds int64  `json:"oldest_wait_seconds"` // How long the oldest has waited
+}
+
+// ServiceStatus is the public status page. It only carries states and counts, never provider
+// names, errors or anything identifying a user.
+type ServiceStatus struct {
+	Status           string            `json:"status"`
+	Components       []ComponentStatus `json:"components"`
+	Queues           []QueueStatus     `json:"queues"`
+	DegradedFeatures []string          `json:"degraded_features"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// StatusService derives the public status from the server's own health checks: the database
+// ping, the circuit breakers of the AI providers, the TTS region probes and the job queues.
+// A computed status is cached for statusCacheTTL, so the public endpoint can't load the database.
+type StatusService struct {
+	repo          *repository.GORMRepository
+	database      func() string // The /health database check: up, down or not configured
+	llm           *ProviderHealth
+	transcription *ProviderHealth
+	speech        SpeechService
+	transcodes    *TranscodePool
+
+	mu     sync.Mutex
+	cached *ServiceStatus
+}
+
+func NewStatusService(repo *repository.GORMRepository, database func() string, llm, transcription *ProviderHealth, speech SpeechService, transcodes *TranscodePool) *StatusService {
+	return &StatusService{
+		repo:          repo,
+		database:      database,
+		llm:           llm,
+		transcription: transcription,
+		speech:        speech,
+		transcodes:    transcodes,
+	}
+}
+
+// Current returns the cached status, computing it again once it is older than statusCacheTTL
+func (s *StatusService) Current(ctx context.Context) ServiceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.cached != nil && now.Sub(s.cached.UpdatedAt) < statusCacheTTL {
+		return *s.cached
+	}
+	status := s.compute(ctx, now)
+	s.cached = &status
+	return status
+}
+
+func (s *StatusService) compute(ctx context.Context, now time.Time) ServiceStatus {
+	database := StatusOperational
+	if s.database() != "up" {
+		database = StatusOutage
+	}
+	components := []ComponentStatus{
+		{Name: "api", Status: StatusOperational},
+		{Name: "database", Status: database},
+		{Name: "interviewer", Status: s.llm.Status(now)},
+		{Name: "voice", Status: s.speechStatus()},
+		{Name: "transcription", Status: s.transcription.Status(now)},
+	}
+
+	var queues []QueueStatus
+	if database == StatusOperational {
+		summaries, err := s.repo.GetSummaryJobBacklog(ctx)
+		queues = append(queues, queueStatus("summaries", summaries, err, now))
+		exports, err := s.repo.GetExportJobBacklog(ctx)
+		queues = append(queues, queueStatus("exports", exports, err, now))
+	}
+	if s.transcodes != nil {
+		stats := s.transcodes.Stats()
+		transcodes := QueueStatus{Name: "audio_processing", Status: StatusOperational, Backlog: int64(stats.Queued)}
+		if stats.Queued > stats.MaxConcurrent {
+			transcodes.Status = StatusDegraded
+		}
+		queues = append(queues, transcodes)
+	}
+
+	return ServiceStatus{
+		Status:           overallStatus(components, queues),
+		Components:       components,
+		Queues:           queues,
+		DegradedFeatures: degradedFeatures(components, queues),
+		UpdatedAt:        now,
+	}
+}
+
+// speechStatus is down when speech isn't configured or every TTS region failed its probes, and
+// degraded while only some have
+func (s *StatusService) speechStatus() string {
+	elevenLabs, ok := s.speech.(*ElevenLabsService)
+	if !ok {
+		return StatusOutage
+	}
+	regions := elevenLabs.RegionStatus()
+	healthy := 0
+	for _, region := range regions {
+		if region.Healthy {
+			healthy++
+		}
+	}
+	switch {
+	case len(regions) > 0 && healthy == 0:
+		return StatusOutage
+	case healthy < len(regions):
+		return StatusDegraded
+	}
+	return StatusOperational
+}
+
+// queueStatus reports a queue as degraded once its oldest job has waited queueDelayThreshold
+func queueStatus(name string, backlog repository.QueueBacklog, err error, now time.Time) QueueStatus {
+	queue := QueueStatus{Name: name, Status: StatusOperational, Backlog: backlog.Waiting}
+	if err != nil {
+		queue.Status = StatusDegraded
+		return queue
+	}
+	if backlog.Oldest != nil {
+		wait := max(now.Sub(*backlog.Oldest), 0)
+		queue.OldestWaitSeconds = int64(wait.Seconds())
+		if wait >= queueDelayThreshold {
+			queue.Status = StatusDegraded
+		}
+	}
+	return queue
+}
+
+// overallStatus is an outage when interviews can't run at all, and degraded when anything else
+// is less than operational
+func overallStatus(components []ComponentStatus, queues []QueueStatus) string {
+	overall := StatusOperational
+	for _, component := range components {
+		if component.Status == StatusOperational {
+			continue
+		}
+		if component.Status == StatusOutage && (component.Name == "database" || component.Name == "interviewer") {
+			return StatusOutage
+		}
+		overall = StatusDegraded
+	}
+	for _, queue := range queues {
+		if queue.Status != StatusOperational {
+			overall = StatusDegraded
+		}
+	}
+	return overall
+}
+
+// degradedFeatures names what users will notice isn't working as usual
+func degradedFeatures(components []ComponentStatus, queues []QueueStatus) []string {
+	features := []string{}
+	down := make(map[string]bool)
+	for _, component := range components {
+		down[component.Name] = component.Status != StatusOperational
+	}
+	if down["database"] || down["interviewer"] {
+		features = append(features, FeatureInterviews, FeatureSummaries)
+	}
+	if down["voice"] {
+		features = append(features, FeatureVoiceReplies)
+	}
+	if down["transcription"] {
+		features = append(features, FeatureVoiceAnswers)
+	}
+	for _, queue := range queues {
+		if queue.Status == StatusOperational {
+			continue
+		}
+		switch queue.Name {
+		case "summaries":
+			features = append(features, FeatureSummaries)
+		case "exports":
+			features = append(features, FeatureExports)
+		}
+	}
+	slices.Sort(features)
+	return slices.Compact(features)
+}
+
+// StatusHandler serves the public status page. The response is the same for every caller, so
+// clients and proxies may cache it for statusCacheTTL.
+func (s *StatusService) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	status := s.Current(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(statusCacheTTL.Seconds())))
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to write status", "error", err)
+	}
+}
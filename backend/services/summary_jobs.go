@@ -0,0 +1,496 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// summaryJobPollInterval is how often idle workers look for due jobs, e.g. retries
+	summaryJobPollInterval = 5 * time.Second
+	// maxSummaryRetryBackoff caps the delay between attempts of a job
+	maxSummaryRetryBackoff = 30 * time.Minute
+	// summaryAbortGrace is how long aborted jobs get to put themselves back in the queue
+	summaryAbortGrace = 5 * time.Second
+)
+
+// errSummaryNotPossible fails a job without retrying, as another attempt can't succeed
+var errSummaryNotPossible = errors.New("summary cannot be generated")
+
+// SummaryJobService generates session summaries from a queue of jobs worked by a fixed pool of
+// workers. Failed attempts are retried with exponential backoff.
+type SummaryJobService struct {
+	repo     *repository.GORMRepository
+	llm      LLMService
+	eventBus *EventBus
+	scoring  *ScoringPolicyService
+	timeouts CallTimeouts
+	config   SummaryConfig
+	wake     chan struct{} // Nudges an idle worker when a job is queued
+
+	workers sync.WaitGroup
+	abort   context.CancelFunc // Cancels the jobs being worked
+}
+
+func NewSummaryJobService(repo *repository.GORMRepository, llm LLMService, eventBus *EventBus, scoring *ScoringPolicyService, timeouts CallTimeouts, config SummaryConfig) *SummaryJobService {
+	config.Workers = max(config.Workers, 1)
+	config.MaxAttempts = max(config.MaxAttempts, 1)
+	return &SummaryJobService{
+		repo:     repo,
+		llm:      llm,
+		eventBus: eventBus,
+		scoring:  scoring,
+		timeouts: timeouts,
+		config:   config,
+		wake:     make(chan struct{}, config.Workers),
+	}
+}
+
+// Start requeues jobs interrupted by the last shutdown and starts the workers. At shutdown the
+// workers stop taking jobs, and Stop waits for the ones they are working.
+func (s *SummaryJobService) Start(lifecycle *Lifecycle) {
+	if requeued, err := s.repo.RequeueRunningSummaryJobs(context.Background()); err == nil && requeued > 0 {
+		slog.Info("Requeued interrupted summary jobs", "count", requeued)
+	}
+
+	var jobCtx context.Context
+	jobCtx, s.abort = context.WithCancel(context.Background())
+	s.workers.Add(s.config.Workers)
+	for i := 0; i < s.config.Workers; i++ {
+		lifecycle.Go("summary worker", func(ctx context.Context) {
+			defer s.workers.Done()
+			s.work(ctx, jobCtx)
+		})
+	}
+	slog.Info("Summary job workers started", "workers", s.config.Workers, "max_attempts", s.config.MaxAttempts)
+}
+
+// Enqueue queues summary generation for a finished session, returning the session's open job
+// if it already has one. transcripts are the live transcripts of a session that just ended; nil
+// summarizes the stored transcripts. With transcript review on, a session with low-confidence
+// spoken answers is held for the candidate to correct them.
+func (s *SummaryJobService) Enqueue(ctx context.Context, sessionID string, transcripts []models.InterviewTranscript) (*models.SummaryJob, error) {
+	job := &models.SummaryJob{SessionID: sessionID}
+	if transcripts != nil {
+		data, err := json.Marshal(transcripts)
+		if err != nil {
+			return nil, err
+		}
+		encoded := string(data)
+		job.Transcripts = &encoded
+
+		if s.config.TranscriptReview {
+			if flagged := LowConfidenceTurns(transcripts, s.config.ReviewConfidence); len(flagged) > 0 {
+				job.Stage = models.SummaryStageReview
+				job.RunAfter = time.Now().Add(s.config.ReviewWindow)
+				logging.FromContext(ctx).Info("Summary held for transcript review", "session_id", sessionID, "flagged_turns", len(flagged), "until", job.RunAfter)
+			}
+		}
+	}
+	return s.enqueue(ctx, job)
+}
+
+// EnqueueRegeneration queues a new summary of a session's stored transcripts that replaces the
+// summary it already has
+func (s *SummaryJobService) EnqueueRegeneration(ctx context.Context, sessionID string) (*models.SummaryJob, error) {
+	return s.enqueue(ctx, &models.SummaryJob{SessionID: sessionID, Force: true})
+}
+
+// EnqueueRescore queues a re-scoring backfill's regeneration of a session's summary
+func (s *SummaryJobService) EnqueueRescore(ctx context.Context, sessionID, backfillID string) (*models.SummaryJob, error) {
+	return s.enqueue(ctx, &models.SummaryJob{SessionID: sessionID, Force: true, BackfillID: &backfillID})
+}
+
+func (s *SummaryJobService) enqueue(ctx context.Context, job *models.SummaryJob) (*models.SummaryJob, error) {
+	job.Status = models.SummaryJobPending
+	if job.Stage == "" {
+		job.Stage = models.SummaryStageQueued
+	}
+	if job.RunAfter.IsZero() {
+		job.RunAfter = time.Now()
+	}
+
+	job, err := s.repo.EnqueueSummaryJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	logging.FromContext(ctx).Info("Summary job queued", "session_id", job.SessionID, "job_id", job.ID, "status", job.Status, "force", job.Force)
+	return job, nil
+}
+
+// Job returns a summary job by ID, or nil if there is none
+func (s *SummaryJobService) Job(ctx context.Context, jobID string) (*models.SummaryJob, error) {
+	return s.repo.GetSummaryJob(ctx, jobID)
+}
+
+// LatestJob returns a session's most recent summary job, or nil if it never had one
+func (s *SummaryJobService) LatestJob(ctx context.Context, sessionID string) (*models.SummaryJob, error) {
+	return s.repo.GetLatestSummaryJob(ctx, sessionID)
+}
+
+// Stop waits for the jobs being worked to finish, aborting them when ctx is done. An aborted
+// job goes back in the queue without the attempt counting.
+func (s *SummaryJobService) Stop(ctx context.Context) {
+	if s.abort == nil {
+		return
+	}
+	if waitGroup(ctx, &s.workers) {
+		return
+	}
+
+	logging.FromContext(ctx).Warn("Shutdown deadline reached, aborting running summary jobs")
+	s.abort()
+	graceCtx, cancel := context.WithTimeout(context.Background(), summaryAbortGrace)
+	defer cancel()
+	waitGroup(graceCtx, &s.workers)
+}
+
+// work runs jobs until ctx is done. jobCtx is the jobs' own, so a job being worked at
+// shutdown can finish.
+func (s *SummaryJobService) work(ctx, jobCtx context.Context) {
+	ticker := time.NewTicker(summaryJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil && s.runNext(jobCtx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and runs one due job, reporting whether there was one
+func (s *SummaryJobService) runNext(ctx context.Context) bool {
+	logger := logging.FromContext(ctx)
+	job, err := s.repo.ClaimSummaryJob(ctx)
+	if err != nil || job == nil {
+		return false
+	}
+
+	summary, err := s.generate(ctx, job)
+	if err != nil && ctx.Err() != nil {
+		logger.Warn("Summary job aborted, returning it to the queue", "session_id", job.SessionID, "job_id", job.ID)
+		s.repo.ReleaseSummaryJob(context.WithoutCancel(ctx), job.ID)
+		return false
+	}
+	switch {
+	case err == nil:
+		logger.Info("Summary job done", "session_id", job.SessionID, "job_id", job.ID, "summary_id", summary.ID)
+		s.repo.FinishSummaryJob(ctx, job.ID, models.SummaryJobDone, "", time.Time{})
+	case errors.Is(err, errSummaryNotPossible) || job.Attempts >= s.config.MaxAttempts:
+		logger.Error("Summary job failed", "session_id", job.SessionID, "job_id", job.ID, "attempts", job.Attempts, "error", err)
+		s.repo.FinishSummaryJob(ctx, job.ID, models.SummaryJobFailed, err.Error(), time.Time{})
+	default:
+		retryAt := time.Now().Add(SummaryRetryBackoff(s.config.RetryBackoff, job.Attempts))
+		logger.Warn("Summary attempt failed, retrying", "session_id", job.SessionID, "job_id", job.ID, "attempts", job.Attempts, "retry_at", retryAt, "error", err)
+		s.repo.FinishSummaryJob(ctx, job.ID, models.SummaryJobPending, err.Error(), retryAt)
+	}
+	return true
+}
+
+// SummaryRetryBackoff returns the delay before retrying a job that has failed the given number
+// of attempts: base, doubling each attempt, up to maxSummaryRetryBackoff
+func SummaryRetryBackoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < maxSummaryRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxSummaryRetryBackoff)
+}
+
+// generate writes the summary and performance scores of a job's session, then publishes
+// EventSummaryGenerated. A session that already has a summary is left as it is unless the job
+// forces regeneration. It returns the session's summary, whichever job wrote it.
+func (s *SummaryJobService) generate(ctx context.Context, job *models.SummaryJob) (*models.InterviewSummary, error) {
+	logger := logging.FromContext(ctx)
+	existing, err := s.repo.GetInterviewSummary(ctx, job.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && !job.Force {
+		logger.Info("Summary already exists for session, skipping generation", "session_id", job.SessionID)
+		return existing, nil
+	}
+
+	session, err := s.repo.GetInterviewSession(ctx, job.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("%w: session not found", errSummaryNotPossible)
+	}
+
+	// Get agent information for personality-based summary
+	agent, err := s.repo.GetAgent(ctx, session.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, fmt.Errorf("%w: agent not found", errSummaryNotPossible)
+	}
+
+	// The candidate's feedback tone, if they chose one, overrides the agent's
+	user, err := s.repo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	feedbackTone := ""
+	if user != nil {
+		feedbackTone = user.FeedbackTone
+	}
+
+	s.repo.SetSummaryJobStage(ctx, job.ID, models.SummaryStageTranscribing)
+	var transcripts []models.InterviewTranscript
+	if job.Transcripts != nil {
+		if err := json.Unmarshal([]byte(*job.Transcripts), &transcripts); err != nil {
+			return nil, fmt.Errorf("%w: invalid transcripts: %v", errSummaryNotPossible, err)
+		}
+	} else if transcripts, err = s.repo.GetInterviewTranscripts(ctx, job.SessionID); err != nil {
+		return nil, err
+	}
+
+	// Warm-up small talk is never scored
+	transcripts = scoredTranscripts(transcripts)
+	if len(transcripts) == 0 {
+		return nil, fmt.Errorf("%w: no scored transcripts", errSummaryNotPossible)
+	}
+
+	// Prepare conversation history for AI analysis
+	conversationHistory := summaryConversation(transcripts)
+
+	// Generate personality-based summary using Gemini
+	var typing []models.TurnMetrics
+	var speech []models.SpeechMetrics
+	if session.Mode == models.SessionModeText {
+		if typing, err = s.repo.GetSessionTurnMetrics(ctx, session.ID); err != nil {
+			return nil, err
+		}
+	} else if speech, err = s.repo.GetSessionSpeechMetrics(ctx, session.ID); err != nil {
+		return nil, err
+	}
+	// The agent's custom rubric, if it has one, replaces the metrics of the interview type
+	rubric, err := s.repo.GetAgentRubric(ctx, agent.ID)
+	if err != nil {
+		return nil, err
+	}
+	customRubric := len(rubric) > 0
+	if !customRubric {
+		rubric = typeRubric(session.InterviewType)
+	}
+	summaryPrompt := buildPersonalityBasedSummaryPrompt(*agent, feedbackTone, conversationHistory) + stageScoringInstruction(transcripts) + testResultsInstruction(transcripts) + writingScoringInstruction(session, typing) + speechInstruction(speech) + rubricScoringInstruction(session.InterviewType, rubric, customRubric)
+
+	logger.Info("Generating AI summary with Gemini", "session_id", session.ID, "agent_name", agent.Name, "conversation_length", len(conversationHistory), "attempt", job.Attempts)
+	s.repo.SetSummaryJobStage(ctx, job.ID, models.SummaryStagePrompting)
+	summaryCtx, cancel := withTimeout(ctx, s.timeouts.Summary)
+	summary, err := s.llm.GenerateSummary(summaryCtx, summaryPrompt)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+	logger.Info("AI summary generated successfully", "session_id", session.ID, "summary_length", len(summary))
+
+	// Parse the AI response to extract structured data
+	s.repo.SetSummaryJobStage(ctx, job.ID, models.SummaryStageParsing)
+	parsedSummary := parseSummaryResponse(summary)
+
+	// Create summary record, scored by the agent's scoring policy if it has one
+	interviewSummary := models.InterviewSummary{
+		SessionID:       session.ID,
+		Summary:         parsedSummary.Summary,
+		Strengths:       parsedSummary.Strengths,
+		Weaknesses:      parsedSummary.Weaknesses,
+		Recommendations: parsedSummary.Recommendations,
+		OverallScore:    parsedSummary.OverallScore,
+	}
+	// A custom rubric's weights set the overall score, before any scoring policy
+	if customRubric {
+		if score, ok := rubricOverallScore(rubric, parsedSummary); ok {
+			interviewSummary.OverallScore = score
+		}
+	}
+	scores := append(buildPerformanceScores(session.ID, rubric, parsedSummary), testPerformanceScores(session.ID, transcripts)...)
+	if session.Mode == models.SessionModeText {
+		scores = append(scores, writingPerformanceScores(session.ID, parsedSummary)...)
+	} else {
+		scores = append(scores, speechPerformanceScores(session.ID, speech)...)
+	}
+	s.scoring.Finalize(ctx, agent, &interviewSummary, scores)
+
+	// Scores are saved with the summary, before subscribers look for them
+	s.repo.SetSummaryJobStage(ctx, job.ID, models.SummaryStageSaving)
+	if existing != nil {
+		if err := s.repo.ReplaceInterviewSummary(ctx, &interviewSummary, scores); err != nil {
+			return nil, err
+		}
+	} else {
+		// Another summary of the session saved meanwhile wins; its scores and event are its own
+		stored, created, err := s.repo.CreateInterviewSummary(ctx, &interviewSummary, scores)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			logger.Info("Session already summarized, discarding this summary", "session_id", session.ID, "job_id", job.ID, "summary_id", stored.ID)
+			return stored, nil
+		}
+	}
+
+	generated := SummaryGeneratedPayload{
+		SummaryID:   interviewSummary.ID,
+		AgentID:     session.AgentID,
+		RawResponse: summary,
+	}
+	if job.BackfillID != nil {
+		generated.BackfillID = *job.BackfillID
+	}
+	s.eventBus.Publish(ctx, EventSummaryGenerated, session.ID, generated)
+
+	logger.Info("Summary generation completed successfully", "session_id", session.ID, "job_id", job.ID, "overall_score", interviewSummary.OverallScore)
+	return &interviewSummary, nil
+}
+
+// buildPersonalityBasedSummaryPrompt creates a summary prompt tailored to the agent's personality.
+// A feedback tone changes only how the feedback is worded, not how it is scored.
+func buildPersonalityBasedSummaryPrompt(agent models.Agent, feedbackTone string, conversationHistory []string) string {
+	// Determine scoring strictness based on agent personality
+	scoringGuidance := getScoringGuidance(agent.Personality)
+
+	// Build industry-specific context
+	industryContext := getIndustryContext(agent.Industry)
+
+	// Create personality-specific tone and expectations, unless the candidate chose a tone
+	personalityTone := FeedbackToneGuidance(feedbackTone, agent.Personality)
+
+	prompt := fmt.Sprintf(`You are %s, a %s interviewer in the %s industry.
+Your personality: %s
+
+%s
+
+Based on this interview conversation, provide a comprehensive analysis that reflects your interviewing style and personality:
+
+1. A narrative summary of the interview (written in your voice and style)
+2. Key strengths demonstrated by the candidate
+3. Areas for improvement (be specific and constructive)
+4. Specific recommendations for the candidate's growth
+5. An overall score (0-100) using this scoring guidance: %s
+
+%s
+
+Conversation:
+%s
+
+Please structure your response as:
+SUMMARY: [Your narrative summary]
+STRENGTHS: [Key strengths]
+WEAKNESSES: [Areas for improvement]
+RECOMMENDATIONS: [Specific recommendations]
+SCORE: [Numerical score 0-100]`,
+		agent.Name,
+		agent.Level,
+		agent.Industry,
+		agent.Personality,
+		industryContext,
+		scoringGuidance,
+		personalityTone,
+		joinStrings(conversationHistory, "\n"))
+
+	return prompt
+}
+
+// getScoringGuidance returns scoring criteria based on agent personality
+func getScoringGuidance(personality string) string {
+	personalityLower := strings.ToLower(personality)
+
+	if strings.Contains(personalityLower, "strict") || strings.Contains(personalityLower, "rigorous") || strings.Contains(personalityLower, "demanding") {
+		return "Be very strict and demanding. Only give high scores (80+) for exceptional performance. Average performance should score 50-70. Poor performance should score below 50. Focus heavily on technical accuracy and depth."
+	} else if strings.Contains(personalityLower, "encouraging") || strings.Contains(personalityLower, "supportive") || strings.Contains(personalityLower, "mentor") {
+		return "Be encouraging and supportive. Give credit for effort and potential. High scores (80+) for good performance with growth potential. Average performance should score 60-80. Focus on potential and learning attitude."
+	} else if strings.Contains(personalityLower, "grilling") || strings.Contains(personalityLower, "intense") || strings.Contains(personalityLower, "challenging") {
+		return "Be very challenging and thorough. Only give high scores (85+) for outstanding performance under pressure. Average performance should score 40-70. Poor performance should score below 40. Focus on handling pressure and technical depth."
+	} else if strings.Contains(personalityLower, "friendly") || strings.Contains(personalityLower, "approachable") || strings.Contains(personalityLower, "collaborative") {
+		return "Be fair and balanced. High scores (80+) for strong performance. Average performance should score 60-80. Focus on communication and collaboration skills."
+	}
+
+	// Default balanced approach
+	return "Be fair and balanced. High scores (80+) for strong performance. Average performance should score 60-80. Focus on both technical skills and soft skills."
+}
+
+// getIndustryContext returns industry-specific evaluation criteria
+func getIndustryContext(industry string) string {
+	switch strings.ToLower(industry) {
+	case "software engineering", "technology":
+		return "Focus on technical problem-solving, code quality, system design thinking, and ability to learn new technologies. Consider algorithmic thinking, debugging skills, and understanding of software development practices."
+	case "finance", "banking":
+		return "Focus on analytical thinking, attention to detail, risk assessment, and understanding of financial concepts. Consider quantitative skills, regulatory knowledge, and market awareness."
+	case "consulting":
+		return "Focus on problem-solving frameworks, client communication, business acumen, and structured thinking. Consider case study performance, presentation skills, and strategic thinking."
+	case "marketing", "sales":
+		return "Focus on creativity, communication skills, market understanding, and customer orientation. Consider campaign thinking, brand awareness, and persuasive abilities."
+	case "healthcare", "medical":
+		return "Focus on attention to detail, patient care orientation, medical knowledge, and ethical considerations. Consider clinical thinking, empathy, and professional standards."
+	default:
+		return "Focus on relevant technical skills, problem-solving abilities, communication, and cultural fit for the role."
+	}
+}
+
+// FeedbackToneGuidance returns the tone guidance for a user's feedback tone, falling back to the
+// agent personality's when the user hasn't chosen one
+func FeedbackToneGuidance(feedbackTone, personality string) string {
+	switch feedbackTone {
+	case models.FeedbackToneDirect:
+		return "Write your feedback in a direct, matter-of-fact tone. State shortcomings plainly and specifically, without softening them, and keep praise brief."
+	case models.FeedbackToneBalanced:
+		return "Write your feedback in a balanced tone. Give strengths and shortcomings equal weight, and pair each criticism with what the candidate can do about it."
+	case models.FeedbackToneGentle:
+		return "Write your feedback in a gentle, encouraging tone, whatever your interviewing style. Lead with what went well, frame shortcomings as next steps rather than failures, and avoid harsh or discouraging wording, while still being honest about what needs work."
+	}
+	return getPersonalityTone(personality)
+}
+
+// getPersonalityTone returns tone guidance based on agent personality
+func getPersonalityTone(personality string) string {
+	personalityLower := strings.ToLower(personality)
+
+	if strings.Contains(personalityLower, "strict") || strings.Contains(personalityLower, "rigorous") {
+		return "Write your feedback in a direct, professional tone. Be specific about shortcomings and don't sugarcoat issues. Use precise technical language."
+	} else if strings.Contains(personalityLower, "encouraging") || strings.Contains(personalityLower, "supportive") {
+		return "Write your feedback in an encouraging, constructive tone. Focus on potential and growth opportunities. Be supportive while being honest about areas for improvement."
+	} else if strings.Contains(personalityLower, "grilling") || strings.Contains(personalityLower, "intense") {
+		return "Write your feedback in a direct, challenging tone. Be thorough in your analysis and don't hold back on criticism. Focus on performance under pressure."
+	} else if strings.Contains(personalityLower, "friendly") || strings.Contains(personalityLower, "approachable") {
+		return "Write your feedback in a warm, professional tone. Balance constructive criticism with positive reinforcement. Be encouraging while maintaining professionalism."
+	}
+
+	// Default professional tone
+	return "Write your feedback in a professional, balanced tone. Be constructive and specific in your recommendations."
+}
+
+// calculateMetricScore adjusts the base score by a fraction of itself, within 0-100
+func calculateMetricScore(baseScore float64, adjustment float64) float64 {
+	adjustedScore := baseScore + (baseScore * adjustment)
+	if adjustedScore < 0 {
+		return 0
+	}
+	if adjustedScore > 100 {
+		return 100
+	}
+	return adjustedScore
+}
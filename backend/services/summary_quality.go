@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// SummaryPromptVersion identifies the summary prompt template; bump it whenever the prompt changes
+	SummaryPromptVersion = "v1"
+
+	MinSummaryLength = 200
+	MaxSummaryLength = 6000
+)
+
+// Placeholder values written by parseAISummary when a section is missing
+var summaryPlaceholders = []string{
+	"Unable to parse structured response",
+	"No summary provided",
+	"No strengths identified",
+	"No weaknesses identified",
+	"No recommendations provided",
+}
+
+// Matches two or more consecutive capitalized words, e.g. "John Smith"
+var properNamePattern = regexp.MustCompile(`\b[A-Z][a-z]+(?:\s+[A-Z][a-z]+)+\b`)
+
+// SummaryQualityService scores generated summaries and raises regression alarms, which are
+// stored until an admin clears them
+type SummaryQualityService struct {
+	repo   *repository.GORMRepository
+	config QualityConfig
+}
+
+func NewSummaryQualityService(repo *repository.GORMRepository, config QualityConfig) *SummaryQualityService {
+	if config.Window <= 0 {
+		config.Window = 50
+	}
+	return &SummaryQualityService{
+		repo:   repo,
+		config: config,
+	}
+}
+
+// Evaluate runs the automatic quality checks against a summary without persisting anything
+func (s *SummaryQualityService) Evaluate(rawResponse string, summary *models.InterviewSummary, agent *models.Agent, transcripts []models.InterviewTranscript) *models.SummaryQuality {
+	quality := &models.SummaryQuality{
+		SummaryID:     summary.ID,
+		SessionID:     summary.SessionID,
+		PromptVersion: SummaryPromptVersion,
+		Model:         ModelName,
+	}
+
+	// JSON validity of the raw model output
+	var raw map[string]interface{}
+	quality.JSONValid = json.Unmarshal([]byte(rawResponse), &raw) == nil
+
+	// Length bounds on the narrative summary
+	quality.SummaryLength = len(summary.Summary)
+	quality.LengthWithinBounds = quality.SummaryLength >= MinSummaryLength && quality.SummaryLength <= MaxSummaryLength
+
+	// Section completeness
+	sections := map[string]string{
+		"summary":         summary.Summary,
+		"strengths":       summary.Strengths,
+		"weaknesses":      summary.Weaknesses,
+		"recommendations": summary.Recommendations,
+	}
+	var missing []string
+	for _, name := range []string{"summary", "strengths", "weaknesses", "recommendations"} {
+		if isPlaceholderSection(sections[name]) {
+			missing = append(missing, name)
+		}
+	}
+	quality.SectionCompleteness = float64(len(sections)-len(missing)) / float64(len(sections))
+	quality.MissingSections = strings.Join(missing, ",")
+
+	// Hallucinated-name detection
+	quality.HallucinatedNames = strings.Join(detectHallucinatedNames(summary, agent, transcripts), ",")
+
+	quality.QualityScore = scoreSummaryQuality(quality)
+	return quality
+}
+
+// HandleSummaryGenerated records quality for summaries published on the event bus
+func (s *SummaryQualityService) HandleSummaryGenerated(ctx context.Context, event Event) error {
+	summaryCtx, err := loadSummaryEventContext(ctx, s.repo, event)
+	if err != nil {
+		return err
+	}
+
+	// Redelivered events must not record the same summary twice
+	existing, err := s.repo.GetSummaryQuality(ctx, summaryCtx.summary.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	return s.Record(ctx, summaryCtx.payload.RawResponse, summaryCtx.summary, summaryCtx.agent, summaryCtx.transcripts)
+}
+
+// Record evaluates and stores a summary's quality, then checks for regressions
+func (s *SummaryQualityService) Record(ctx context.Context, rawResponse string, summary *models.InterviewSummary, agent *models.Agent, transcripts []models.InterviewTranscript) error {
+	logger := logging.FromContext(ctx)
+	quality := s.Evaluate(rawResponse, summary, agent, transcripts)
+
+	if err := s.repo.CreateSummaryQuality(ctx, quality); err != nil {
+		logger.Error("Failed to store summary quality", "error", err, "summary_id", summary.ID)
+		return err
+	}
+
+	logger.Info("Summary quality recorded",
+		"session_id", summary.SessionID,
+		"summary_id", summary.ID,
+		"quality_score", quality.QualityScore,
+		"json_valid", quality.JSONValid,
+		"missing_sections", quality.MissingSections,
+		"hallucinated_names", quality.HallucinatedNames)
+
+	s.checkRegression(ctx, quality.PromptVersion, quality.Model)
+	return nil
+}
+
+// checkRegression compares the recent window for a prompt version/model against the baseline
+func (s *SummaryQualityService) checkRegression(ctx context.Context, promptVersion, model string) {
+	current, err := s.repo.GetRecentSummaryQualityStats(ctx, promptVersion, model, s.config.Window)
+	if err != nil || current == nil || current.SampleCount == 0 {
+		return
+	}
+
+	// Require a minimum sample before alerting to avoid noise
+	minSamples := int64(s.config.Window / 5)
+	if minSamples < 5 {
+		minSamples = 5
+	}
+	if current.SampleCount < minSamples {
+		return
+	}
+
+	if s.config.MinJSONValidRate > 0 && current.JSONValidRate < s.config.MinJSONValidRate {
+		s.raiseAlert(ctx, &models.SummaryQualityAlert{
+			PromptVersion: promptVersion,
+			Model:         model,
+			Reason:        "json validity rate below minimum",
+			Current:       current,
+			RaisedAt:      time.Now(),
+		})
+	}
+
+	baseline, err := s.repo.GetBaselineSummaryQualityStats(ctx, promptVersion, model, s.config.Window)
+	if err != nil || baseline == nil || baseline.SampleCount < minSamples {
+		return
+	}
+
+	if s.config.AlertDrop > 0 && baseline.AvgQualityScore-current.AvgQualityScore >= s.config.AlertDrop {
+		s.raiseAlert(ctx, &models.SummaryQualityAlert{
+			PromptVersion: promptVersion,
+			Model:         model,
+			Reason:        "average quality score dropped versus previous prompt/model",
+			Current:       current,
+			Baseline:      baseline,
+			RaisedAt:      time.Now(),
+		})
+	}
+}
+
+func (s *SummaryQualityService) raiseAlert(ctx context.Context, alert *models.SummaryQualityAlert) {
+	// Keep only one open alert per prompt version/model/reason
+	raised, err := s.repo.SaveSummaryQualityAlert(ctx, alert)
+	if err != nil || !raised {
+		return
+	}
+
+	args := []any{
+		"prompt_version", alert.PromptVersion,
+		"model", alert.Model,
+		"reason", alert.Reason,
+		"avg_quality_score", alert.Current.AvgQualityScore,
+		"json_valid_rate", alert.Current.JSONValidRate,
+	}
+	if alert.Baseline != nil {
+		args = append(args, "baseline_avg_quality_score", alert.Baseline.AvgQualityScore)
+	}
+	logging.FromContext(ctx).Error("ALERT: summary quality regression detected", args...)
+}
+
+// GetAlerts returns the currently raised quality alerts
+func (s *SummaryQualityService) GetAlerts(ctx context.Context) ([]models.SummaryQualityAlert, error) {
+	return s.repo.GetSummaryQualityAlerts(ctx)
+}
+
+// ClearAlerts acknowledges the alerts raised up to now; one raised again while clearing stays
+func (s *SummaryQualityService) ClearAlerts(ctx context.Context) error {
+	return s.repo.DeleteSummaryQualityAlerts(ctx, time.Now())
+}
+
+// GetStats returns aggregated quality metrics per prompt version and model
+func (s *SummaryQualityService) GetStats(ctx context.Context) ([]models.SummaryQualityStats, error) {
+	return s.repo.GetSummaryQualityStats(ctx)
+}
+
+func isPlaceholderSection(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return true
+	}
+	for _, placeholder := range summaryPlaceholders {
+		if trimmed == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// detectHallucinatedNames finds proper names in the summary that never appear in the conversation
+func detectHallucinatedNames(summary *models.InterviewSummary, agent *models.Agent, transcripts []models.InterviewTranscript) []string {
+	var known strings.Builder
+	if agent != nil {
+		known.WriteString(strings.ToLower(agent.Name + " " + agent.Industry + " " + agent.Description))
+	}
+	for _, transcript := range transcripts {
+		known.WriteString(" ")
+		known.WriteString(strings.ToLower(transcript.Content))
+	}
+	knownText := known.String()
+
+	text := strings.Join([]string{summary.Summary, summary.Strengths, summary.Weaknesses, summary.Recommendations}, "\n")
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, candidate := range properNamePattern.FindAllString(text, -1) {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		// A name is only suspicious if none of its words appear anywhere in the conversation
+		found := false
+		for _, word := range strings.Fields(strings.ToLower(candidate)) {
+			if strings.Contains(knownText, word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names = append(names, candidate)
+		}
+	}
+	return names
+}
+
+// scoreSummaryQuality combines the individual checks into a 0-100 score
+func scoreSummaryQuality(quality *models.SummaryQuality) float64 {
+	score := 0.0
+	if quality.JSONValid {
+		score += 30
+	}
+	score += 30 * quality.SectionCompleteness
+	if quality.LengthWithinBounds {
+		score += 20
+	}
+	if quality.HallucinatedNames == "" {
+		score += 20
+	}
+	return score
+}
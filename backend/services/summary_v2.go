@@ -0,0 +1,214 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// summaryResult is where a session's summary stands, rendered by each API version in its shape
+type summaryResult struct {
+	sessionID  string
+	summary    *models.InterviewSummary
+	flags      []models.SessionFlag
+	proctoring *models.ProctoringReport
+	job        *models.SummaryJob // Failed, or queued for a session without a summary
+}
+
+// loadSummary gets a session's summary. A session without one has its generation queued,
+// joining an open job if there is one, unless its last attempt failed.
+func (e *SessionEndpoints) loadSummary(r *http.Request) (*summaryResult, error) {
+	logger := logging.FromContext(r.Context())
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		return nil, apperrors.Internal("User not found in context")
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	if sessionID == "" {
+		return nil, apperrors.BadRequest("Session ID is required")
+	}
+
+	// First verify the session belongs to the user
+	session, err := e.repo.GetInterviewSessionWithDetails(r.Context(), sessionID, user.ID)
+	if err != nil {
+		logger.Error("Failed to get interview session", "error", err, "session_id", sessionID)
+		return nil, apperrors.NotFound("Session not found")
+	}
+	if session == nil {
+		return nil, apperrors.NotFound("Session not found")
+	}
+
+	summary, err := e.repo.GetInterviewSummary(r.Context(), sessionID)
+	if err != nil {
+		logger.Error("Failed to get interview summary", "error", err, "session_id", sessionID)
+		return nil, apperrors.Internal("Failed to get summary")
+	}
+
+	// If no summary exists, queue its generation
+	if summary == nil {
+		job, err := e.summaries.LatestJob(r.Context(), sessionID)
+		if err != nil {
+			return nil, apperrors.Internal("Failed to check summary status")
+		}
+		if job != nil && job.Status == models.SummaryJobFailed {
+			return &summaryResult{sessionID: sessionID, job: job}, nil
+		}
+
+		transcripts, err := e.repo.GetInterviewTranscripts(r.Context(), sessionID)
+		if err != nil {
+			logger.Error("Failed to get transcripts for summary generation", "error", err, "session_id", sessionID)
+			return nil, apperrors.Internal("Failed to get session transcripts")
+		}
+
+		// Warm-up small talk is never scored
+		if len(scoredTranscripts(transcripts)) == 0 {
+			return nil, apperrors.BadRequest("No transcripts available for summary generation")
+		}
+
+		// Joins the session's open job if it has one
+		job, err = e.summaries.Enqueue(r.Context(), sessionID, nil)
+		if err != nil {
+			return nil, apperrors.Internal("Failed to queue summary generation")
+		}
+		return &summaryResult{sessionID: sessionID, job: job}, nil
+	}
+
+	// Flags are shown alongside the summary but never affect its scores
+	flags, err := e.repo.GetSessionFlags(r.Context(), sessionID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to get session flags")
+	}
+
+	result := &summaryResult{sessionID: sessionID, summary: summary, flags: flags}
+	if session.Proctored {
+		events, err := e.repo.GetProctorEvents(r.Context(), sessionID)
+		if err != nil {
+			return nil, apperrors.Internal("Failed to get proctoring events")
+		}
+		result.proctoring = BuildProctoringReport(sessionID, events)
+	}
+
+	logger.Info("Interview summary retrieved", "session_id", sessionID)
+	return result, nil
+}
+
+// v1Response is the v1 shape: the summary and its flags once ready, or else the job's fields
+// at the top level, answered 202 while it is queued and 200 once it has failed
+func (s *summaryResult) v1Response() (int, map[string]interface{}) {
+	if s.summary != nil {
+		response := map[string]interface{}{
+			"summary": s.summary,
+			"flags":   s.flags,
+			"status":  "ready",
+		}
+		if s.proctoring != nil {
+			response["proctoring"] = s.proctoring
+		}
+		return http.StatusOK, response
+	}
+
+	job := s.job
+	if job.Status == models.SummaryJobFailed {
+		return http.StatusOK, map[string]interface{}{
+			"status":     job.Status,
+			"stage":      job.Stage,
+			"error":      job.LastError,
+			"attempts":   job.Attempts,
+			"job_id":     job.ID,
+			"session_id": s.sessionID,
+		}
+	}
+
+	// The error of an earlier attempt tells the client a retry is coming, not to wait forever
+	response := map[string]interface{}{
+		"status":     job.Status,
+		"stage":      job.Stage,
+		"attempts":   job.Attempts,
+		"message":    "Summary generation has been queued. Please check back in a few minutes.",
+		"job_id":     job.ID,
+		"session_id": s.sessionID,
+	}
+	if job.LastError != "" {
+		response["error"] = job.LastError
+		response["retry_at"] = job.RunAfter
+	}
+	return http.StatusAccepted, response
+}
+
+// SummaryJobV2 is the progress of a summary that isn't ready
+type SummaryJobV2 struct {
+	ID       string     `json:"id"`
+	Stage    string     `json:"stage"`
+	Attempts int        `json:"attempts"`
+	Error    string     `json:"error,omitempty"`    // Of the last attempt; a queued job will retry
+	RetryAt  *time.Time `json:"retry_at,omitempty"` // When a queued job that failed is retried
+}
+
+// SummaryV2 is a session's summary in one shape whatever its state: every field is present,
+// null when it doesn't apply
+type SummaryV2 struct {
+	SessionID  string                   `json:"session_id"`
+	Status     string                   `json:"status"` // ready, pending, running or failed
+	Summary    *models.InterviewSummary `json:"summary"`
+	Flags      []models.SessionFlag     `json:"flags"`
+	Proctoring *models.ProctoringReport `json:"proctoring"`
+	Job        *SummaryJobV2            `json:"job"` // Unset once ready
+}
+
+// v2Response is the v2 shape, answered 202 while the summary is queued and 200 otherwise
+func (s *summaryResult) v2Response() (int, SummaryV2) {
+	response := SummaryV2{
+		SessionID:  s.sessionID,
+		Status:     "ready",
+		Summary:    s.summary,
+		Flags:      s.flags,
+		Proctoring: s.proctoring,
+	}
+	if response.Flags == nil {
+		response.Flags = []models.SessionFlag{}
+	}
+	if s.summary != nil {
+		return http.StatusOK, response
+	}
+
+	response.Status = s.job.Status
+	response.Job = &SummaryJobV2{
+		ID:       s.job.ID,
+		Stage:    s.job.Stage,
+		Attempts: s.job.Attempts,
+		Error:    s.job.LastError,
+	}
+	if s.job.Status == models.SummaryJobFailed {
+		return http.StatusOK, response
+	}
+	if s.job.LastError != "" {
+		response.Job.RetryAt = &s.job.RunAfter
+	}
+	return http.StatusAccepted, response
+}
+
+// RegisterV2Routes registers the session endpoints whose v2 response differs from v1
+func (e *SessionEndpoints) RegisterV2Routes(r chi.Router) {
+	r.Get("/summaries/session/{id}", e.GetSummaryV2Handler)
+}
+
+// GetSummaryV2Handler returns a session's summary, queuing its generation if it has none, as
+// a SummaryV2
+func (e *SessionEndpoints) GetSummaryV2Handler(w http.ResponseWriter, r *http.Request) {
+	result, err := e.loadSummary(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	status, body := result.v2Response()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
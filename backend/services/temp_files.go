@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	tempFilesCleanupInterval = 5 * time.Minute
+	// tempFilesOrphanGrace is how old an untracked file must be before the cleanup removes it,
+	// so a file isn't removed between being created and being tracked
+	tempFilesOrphanGrace = time.Minute
+)
+
+// ErrTempSpaceExhausted is returned when a temp file would take the directory over its limit
+var ErrTempSpaceExhausted = errors.New("temporary file space exhausted")
+
+// TempFiles manages the scratch files of audio processing in a directory of its own. Every file
+// is tracked from creation until its handle is released; files left behind by a crash, and
+// handles never released, are removed by a periodic cleanup. Space is reserved as files are
+// created, so concurrent conversions can't fill the disk past MaxBytes.
+type TempFiles struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+
+	handles map[string]*TempFile
+	used    int64
+	stats   TempFileStats
+	mutex   sync.Mutex
+}
+
+// TempFile is a tracked temp file. Release it when done; it is removed after MaxAge otherwise.
+type TempFile struct {
+	path    string
+	size    int64 // Bytes reserved, or written if more
+	created time.Time
+	files   *TempFiles
+}
+
+// TempFileStats are the counters reported by the admin API
+type TempFileStats struct {
+	Dir            string `json:"dir"`
+	Open           int    `json:"open"`
+	UsedBytes      int64  `json:"used_bytes"`
+	MaxBytes       int64  `json:"max_bytes"`
+	Created        int64  `json:"created"`
+	Released       int64  `json:"released"`
+	Rejected       int64  `json:"rejected"`        // Creations refused for lack of space
+	LeakedRemoved  int64  `json:"leaked_removed"`  // Handles removed after MaxAge without being released
+	OrphansRemoved int64  `json:"orphans_removed"` // Untracked files removed, e.g. left by a crash
+}
+
+func NewTempFiles(cfg TempFilesConfig) (*TempFiles, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create temp directory: %w", err)
+	}
+	files := &TempFiles{
+		dir:      cfg.Dir,
+		maxBytes: int64(cfg.MaxMB) << 20,
+		maxAge:   cfg.MaxAge,
+		handles:  make(map[string]*TempFile),
+	}
+	if files.maxAge <= 0 {
+		files.maxAge = time.Hour
+	}
+	return files, nil
+}
+
+// Create makes an empty temp file named like os.CreateTemp's pattern, reserving size bytes
+// for what will be written to it. A size of 0 reserves nothing until Refresh.
+func (t *TempFiles) Create(pattern string, size int64) (*TempFile, error) {
+	t.mutex.Lock()
+	if t.maxBytes > 0 && t.used+size > t.maxBytes {
+		t.stats.Rejected++
+		used := t.used
+		t.mutex.Unlock()
+		slog.Warn("Temp file rejected, directory is full", "dir", t.dir, "used_bytes", used, "requested_bytes", size)
+		return nil, ErrTempSpaceExhausted
+	}
+	t.used += size
+	t.mutex.Unlock()
+
+	file, err := os.CreateTemp(t.dir, pattern)
+	if err != nil {
+		t.mutex.Lock()
+		t.used -= size
+		t.mutex.Unlock()
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	file.Close()
+
+	handle := &TempFile{path: file.Name(), size: size, created: time.Now(), files: t}
+	t.mutex.Lock()
+	t.handles[handle.path] = handle
+	t.stats.Created++
+	t.mutex.Unlock()
+	return handle, nil
+}
+
+// Path is where the file is, to give to a command such as ffmpeg
+func (f *TempFile) Path() string {
+	return f.path
+}
+
+// Write replaces the file's content with data
+func (f *TempFile) Write(data []byte) error {
+	if err := os.WriteFile(f.path, data, 0o600); err != nil {
+		return err
+	}
+	f.Refresh()
+	return nil
+}
+
+// Refresh accounts for the file's size on disk after something other than Write filled it.
+// It never shrinks the reservation.
+func (f *TempFile) Refresh() {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return
+	}
+	t := f.files
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if grown := info.Size() - f.size; grown > 0 && t.handles[f.path] == f {
+		f.size += grown
+		t.used += grown
+	}
+}
+
+// Release removes the file and frees its space. Releasing twice is harmless.
+func (f *TempFile) Release() {
+	if f.remove() {
+		f.files.mutex.Lock()
+		f.files.stats.Released++
+		f.files.mutex.Unlock()
+	}
+}
+
+// remove stops tracking the file and deletes it, reporting whether it was still tracked
+func (f *TempFile) remove() bool {
+	t := f.files
+	t.mutex.Lock()
+	if t.handles[f.path] != f {
+		t.mutex.Unlock()
+		return false
+	}
+	delete(t.handles, f.path)
+	t.used -= f.size
+	t.mutex.Unlock()
+
+	if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove temp file", "path", f.path, "error", err)
+	}
+	return true
+}
+
+// Stats returns the current usage and counters
+func (t *TempFiles) Stats() TempFileStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	stats := t.stats
+	stats.Dir = t.dir
+	stats.Open = len(t.handles)
+	stats.UsedBytes = t.used
+	stats.MaxBytes = t.maxBytes
+	return stats
+}
+
+// StartCleanupJob removes leftovers now, which at startup are whatever a crashed process left,
+// and then periodically
+func (t *TempFiles) StartCleanupJob(lifecycle *Lifecycle) {
+	lifecycle.Go("temp file cleanup", func(ctx context.Context) {
+		t.cleanup()
+		ticker := time.NewTicker(tempFilesCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.cleanup()
+			}
+		}
+	})
+}
+
+// cleanup removes handles held past MaxAge and files in the directory no handle tracks
+func (t *TempFiles) cleanup() {
+	now := time.Now()
+	var leaked []*TempFile
+	t.mutex.Lock()
+	for _, handle := range t.handles {
+		if now.Sub(handle.created) > t.maxAge {
+			leaked = append(leaked, handle)
+		}
+	}
+	t.mutex.Unlock()
+	removed := 0
+	for _, handle := range leaked {
+		if handle.remove() {
+			slog.Warn("Removed temp file that was never released", "path", handle.path, "age", now.Sub(handle.created))
+			removed++
+		}
+	}
+	t.mutex.Lock()
+	t.stats.LeakedRemoved += int64(removed)
+	t.mutex.Unlock()
+
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		slog.Error("Failed to list temp directory", "dir", t.dir, "error", err)
+		return
+	}
+	orphans := 0
+	for _, entry := range entries {
+		path := filepath.Join(t.dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < tempFilesOrphanGrace {
+			continue
+		}
+		t.mutex.Lock()
+		_, tracked := t.handles[path]
+		t.mutex.Unlock()
+		if tracked {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			slog.Warn("Failed to remove orphaned temp file", "path", path, "error", err)
+			continue
+		}
+		orphans++
+	}
+
+	t.mutex.Lock()
+	t.stats.OrphansRemoved += int64(orphans)
+	t.mutex.Unlock()
+	if removed > 0 || orphans > 0 {
+		slog.Info("Temp files cleaned up", "dir", t.dir, "leaked", removed, "orphans", orphans)
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The test harnesses report results with tokens rather than with what the tests print or a report
+// file, both of which the submission controls. Each declared test gets a random token, given to
+// the harness on stdin before any submission code runs, and the harness prints a test's token
+// only once the test passed, so the submission can't learn the token of a test it fails. Tests
+// without their token in the output failed, including every test when the submission exits early.
+
+// goHarness is the package the generated TestMain hands the suite's tests to. Packages are
+// initialized before the packages importing them, so it reads stdin before the submission can.
+const goHarness = `// Package praxisharness runs the hidden test named on stdin and prints its token if it passed
+package praxisharness
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var (
+	token, test string
+	started     atomic.Bool
+)
+
+func init() {
+	input := bufio.NewReader(os.Stdin)
+	token, _ = input.ReadString('\n')
+	test, _ = input.ReadString('\n')
+	token, test = strings.TrimSpace(token), strings.TrimSpace(test)
+}
+
+// Main runs the test, only when called by the generated TestMain and only once. The flags are
+// the harness's, not os.Args, which the submission could change, and m.Run, which sets the
+// testing package up, runs none of the tests; the test only passes if it then ran.
+func Main(m *testing.M, tests map[string]func(*testing.T)) {
+	if !calledByTestMain() || !started.CompareAndSwap(false, true) {
+		return
+	}
+	run, ok := tests[test]
+	if !ok {
+		return
+	}
+	flag.CommandLine.Parse([]string{"-test.v", "-test.run=^$"})
+	stderr := os.Stderr
+	os.Stderr = nil
+	m.Run()
+	os.Stderr = stderr
+	flag.Set("test.run", "")
+	ran := false
+	passed := testing.RunTests(func(string, string) (bool, error) { return true, nil }, []testing.InternalTest{{
+		Name: test,
+		F: func(t *testing.T) {
+			ran = true
+			run(t)
+		},
+	}})
+	if passed && ran {
+		fmt.Println(token)
+	}
+}
+
+func calledByTestMain() bool {
+	pcs := make([]uintptr, 2)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	caller, more := frames.Next()
+	main, _ := frames.Next()
+	return more && strings.HasSuffix(caller.Function, ".TestMain") && main.Function == "main.main"
+}
+`
+
+// goTestMain generates the suite's TestMain, which hands its tests to the harness. The map is a
+// literal in the function, so the submission can't swap the tests it holds.
+func goTestMain(suite string, tests []string) (string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "main_test.go", suite, parser.PackageClauseOnly)
+	if err != nil {
+		return "", err
+	}
+	var main strings.Builder
+	fmt.Fprintf(&main, "package %s\n\nimport (\n\t\"testing\"\n\n\t\"submission/praxisharness\"\n)\n\n", file.Name.Name)
+	main.WriteString("func TestMain(m *testing.M) {\n\tpraxisharness.Main(m, map[string]func(*testing.T){\n")
+	for _, test := range tests {
+		fmt.Fprintf(&main, "\t\t%s: %s,\n", strconv.Quote(test), test)
+	}
+	main.WriteString("\t})\n}\n")
+	return main.String(), nil
+}
+
+// goFiles are the files a Go submission is built with: the suite, its TestMain and the harness
+func goFiles(suite string, tests []string) (map[string]string, error) {
+	main, err := goTestMain(suite, tests)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"go.mod":                   "module submission\n\ngo 1.24\n",
+		"main_test.go":             suite,
+		"praxis_main_test.go":      main,
+		"praxisharness/harness.go": goHarness,
+	}, nil
+}
+
+// goDirective matches directives that would hand the suite or the harness's memory to a Go
+// submission: embedding files, and linking to unexported symbols
+var goDirective = regexp.MustCompile(`(?m)^\s*//go:(embed|linkname)`)
+
+// refuseGoSubmission says why a Go submission can't be run, or "" if it can. unsafe would let it
+// read the harness's token; one that doesn't parse is left for the build to report.
+func refuseGoSubmission(code string) string {
+	if match := goDirective.FindStringSubmatch(code); match != nil {
+		return "Submissions can't use //go:" + match[1]
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", code, parser.ImportsOnly)
+	if err != nil {
+		return ""
+	}
+	for _, spec := range file.Imports {
+		if path, _ := strconv.Unquote(spec.Path.Value); path == "unsafe" {
+			return "Submissions can't import unsafe"
+		}
+	}
+	return ""
+}
+
+// pythonHarness reads the tokens and the suite from stdin, then runs the suite under pytest
+// without it ever touching the disk: it's compiled with pytest's assertion rewriting in memory
+// and stands in for the empty test_solution.py pytest collects. Results are written at the end
+// of the session to a copy of stdout taken before pytest captures it, for each test whose
+// parametrized cases all passed; a test skipped or failing in setup or teardown didn't pass.
+const pythonHarness = `import ast, json, os, sys, types
+out = os.dup(1)
+tokens = json.loads(sys.stdin.readline())
+source = sys.stdin.read()
+sys.dont_write_bytecode = True
+
+import pytest
+from _pytest.assertion.rewrite import rewrite_asserts
+
+path = os.path.abspath("test_solution.py")
+results = {}
+
+
+class Harness:
+    def pytest_sessionstart(self, session):
+        module = types.ModuleType("test_solution")
+        module.__file__ = path
+        sys.modules["test_solution"] = module
+        tree = ast.parse(source, path)
+        rewrite_asserts(tree, source.encode(), path, session.config)
+        exec(compile(tree, path, "exec"), module.__dict__)
+
+    def pytest_runtest_logreport(self, report):
+        if report.when == "call" or report.failed:
+            name = report.nodeid.split("::")[-1].split("[")[0]
+            results[name] = results.get(name, True) and report.passed
+
+    def pytest_sessionfinish(self, session):
+        for name, passed in results.items():
+            if passed and name in tokens:
+                os.write(out, (tokens[name] + "\n").encode())
+
+
+pytest.main(["-q", "-p", "no:cacheprovider", "test_solution.py"], plugins=[Harness()])
+`
+
+// javascriptHarness reads the tokens and the suite from stdin and runs the suite in memory.
+// The suite's require hands it a node:test whose tests record whether they passed; the
+// submission is loaded through a separate module, so its own require gets node's node:test, and
+// the suite is strict, so the submission can't reach the suite's require through its callers.
+// Tokens are printed on exit for each test that passed every time it ran.
+const javascriptHarness = `'use strict';
+const fs = require('node:fs');
+const path = require('node:path');
+const vm = require('node:vm');
+const Module = require('node:module');
+const realTest = require('node:test');
+
+const input = fs.readFileSync(0, 'utf8');
+const newline = input.indexOf('\n');
+const tokens = JSON.parse(input.slice(0, newline));
+const suite = input.slice(newline + 1);
+const write = fs.writeSync;
+const results = new Map();
+
+process.on('exit', () => {
+  for (const [name, passed] of results) {
+    if (passed && Object.hasOwn(tokens, name)) write(1, tokens[name] + '\n');
+  }
+});
+
+const record = (name, passed) => results.set(name, (results.get(name) ?? true) && passed);
+
+const context = (t) => new Proxy(t, {
+  get(target, property) {
+    if (property === 'test') return register(target.test.bind(target));
+    const value = Reflect.get(target, property, target);
+    return typeof value === 'function' ? value.bind(target) : value;
+  },
+});
+
+const graded = (name, fn) => {
+  if (fn.length >= 2) {
+    return function (t, done) {
+      return fn.call(this, context(t), (err) => {
+        record(name, !err);
+        done(err);
+      });
+    };
+  }
+  return async function (t) {
+    try {
+      await fn.call(this, context(t));
+    } catch (err) {
+      record(name, false);
+      throw err;
+    }
+    record(name, true);
+  };
+};
+
+const withGrading = (args) => {
+  const index = args.findLastIndex((arg) => typeof arg === 'function');
+  if (index < 0) return args;
+  const name = typeof args[0] === 'string' ? args[0] : args[index].name;
+  const wrapped = [...args];
+  wrapped[index] = graded(name, args[index]);
+  return wrapped;
+};
+
+function register(run) {
+  const registered = (...args) => run(...withGrading(args));
+  for (const variant of ['only', 'skip', 'todo']) {
+    if (typeof run[variant] === 'function') registered[variant] = (...args) => run[variant](...withGrading(args));
+  }
+  return registered;
+}
+
+const gradedTest = register(realTest);
+const testing = Object.assign(gradedTest, realTest, { ...gradedTest, test: gradedTest, it: register(realTest.it) });
+
+const filename = path.join(process.cwd(), 'solution.test.js');
+const loader = new Module(filename, null);
+loader.filename = filename;
+loader.paths = Module._nodeModulePaths(process.cwd());
+const suiteRequire = (id) => (id === 'node:test' ? testing : loader.require(id));
+const suiteModule = { exports: {} };
+const load = vm.compileFunction("'use strict';" + suite, ['exports', 'require', 'module', '__filename', '__dirname'], { filename });
+load.call(suiteModule.exports, suiteModule.exports, suiteRequire, suiteModule, filename, process.cwd());
+`
+
+// harnessInput is what an interpreted harness reads on stdin: the tokens by test, then the suite
+func harnessInput(tokens map[string]string, suite string) []byte {
+	byTest := make(map[string]string, len(tokens))
+	for token, test := range tokens {
+		byTest[test] = token
+	}
+	header, _ := json.Marshal(byTest)
+	return append(append(header, '\n'), suite...)
+}
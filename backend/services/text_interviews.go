@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krshsl/praxis/backend/models"
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// textModeNotice answers a voice answer sent in a text interview
+const textModeNotice = "This is a text interview, so voice answers aren't accepted. Please type your answer."
+
+// writingQualityMetric is the performance score of a text interview's written communication
+const writingQualityMetric = "Writing Quality"
+
+// TypingSummary aggregates how a candidate typed their answers
+type TypingSummary struct {
+	Answers          int     `json:"answers"`             // Answers with typing metrics
+	MedianLatencyMs  int64   `json:"median_latency_ms"`   // Typical time before starting to type an answer
+	MedianComposeMs  int64   `json:"median_compose_ms"`   // Typical time spent typing an answer
+	WordsPerMinute   float64 `json:"words_per_minute"`    // Over the time spent typing
+	EditsPer100Chars float64 `json:"edits_per_100_chars"` // How much answers were revised
+	PastedShare      float64 `json:"pasted_share"`        // Fraction of the answers' characters that were pasted
+}
+
+// SummarizeTyping aggregates the typing metrics of a session's answers
+func SummarizeTyping(metrics []models.TurnMetrics) TypingSummary {
+	summary := TypingSummary{Answers: len(metrics)}
+	if len(metrics) == 0 {
+		return summary
+	}
+
+	latencies := make([]int64, len(metrics))
+	composes := make([]int64, len(metrics))
+	var characters, words, edits, pasted int
+	var composeMs int64
+	for i, m := range metrics {
+		latencies[i], composes[i] = m.ResponseLatencyMs, m.ComposeMs
+		characters += m.Characters
+		words += m.Words
+		edits += m.Edits
+		pasted += m.PastedChars
+		composeMs += m.ComposeMs
+	}
+	summary.MedianLatencyMs = median(latencies)
+	summary.MedianComposeMs = median(composes)
+	if composeMs > 0 {
+		summary.WordsPerMinute = round1(float64(words) / (float64(composeMs) / 60000))
+	}
+	if characters > 0 {
+		summary.EditsPer100Chars = round1(float64(edits) * 100 / float64(characters))
+		summary.PastedShare = math.Round(min(float64(pasted)/float64(characters), 1)*100) / 100
+	}
+	return summary
+}
+
+func median(values []int64) int64 {
+	sorted := slices.Sorted(slices.Values(values))
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+func round1(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+// recordTypingMetrics stores how the candidate typed an answer, with the answer's length
+func (p *AIMessageProcessor) recordTypingMetrics(ctx context.Context, sessionID, transcriptID, content string, metrics *ws.TypingMetrics) {
+	p.repo.CreateTurnMetrics(ctx, &models.TurnMetrics{
+		SessionID:         sessionID,
+		TranscriptID:      transcriptID,
+		ResponseLatencyMs: metrics.ResponseLatencyMs,
+		ComposeMs:         metrics.ComposeMs,
+		Keystrokes:        metrics.Keystrokes,
+		Edits:             metrics.Edits,
+		Pastes:            metrics.Pastes,
+		PastedChars:       metrics.PastedChars,
+		Characters:        utf8.RuneCountInString(content),
+		Words:             len(strings.Fields(content)),
+	})
+}
+
+// writingScoringInstruction asks the summary model to score a text interview's written
+// communication, or returns "" for a voice interview
+func writingScoringInstruction(session *models.InterviewSession, metrics []models.TurnMetrics) string {
+	if session.Mode != models.SessionModeText {
+		return ""
+	}
+	instruction := `
+
+TEXT INTERVIEW: The candidate typed every answer. In addition to the overall score, score their written communication (0-100) in writingScore: clarity, structure, concision and tone suited to a written exchange. Typos that don't obscure meaning shouldn't cost much, and typing speed isn't a skill being assessed.`
+	if typing := SummarizeTyping(metrics); typing.Answers > 0 && typing.PastedShare >= 0.5 {
+		instruction += fmt.Sprintf(`
+%.0f%% of the answers' text was pasted rather than typed; weigh whether the answers read as the candidate's own words.`, typing.PastedShare*100)
+	}
+	return instruction
+}
+
+// writingPerformanceScores turns the model's writing score into a "Writing Quality"
+// performance score, so scoring policies can weight or gate on it
+func writingPerformanceScores(sessionID string, summary ParsedSummary) []models.PerformanceScore {
+	if summary.WritingScore == nil {
+		return nil
+	}
+	return []models.PerformanceScore{{
+		SessionID: sessionID,
+		Metric:    writingQualityMetric,
+		Score:     math.Max(0, math.Min(100, *summary.WritingScore)),
+		MaxScore:  100.0,
+	}}
+}
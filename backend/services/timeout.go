@@ -9,18 +9,40 @@ import (
 	"sync"
 	"time"
 
+	"github.com/krshsl/praxis/backend/logging"
 	"github.com/krshsl/praxis/backend/models"
 	"gorm.io/gorm"
 )
 
 const (
 	DefaultTimeout = 30 * time.Minute
-	InterviewLimit = 5 * time.Minute
+	// DefaultInterviewMinutes is the interview length when neither the session nor the agent sets one
+	DefaultInterviewMinutes = 5
+	// MaxInterviewMinutes is the longest interview a session or agent can ask for
+	MaxInterviewMinutes = 120
+	// timeRemainingInterval is how often active sessions are told how much time is left
+	timeRemainingInterval = 30 * time.Second
+)
+
+// Closing sequence stages
+const (
+	ClosingStageNone       = ""
+	ClosingStageQuestions  = "questions"  // Agent asked "any questions for me?" and is waiting for the reply
+	ClosingStageSignOff    = "sign_off"   // Agent is about to deliver the sign-off
+	ClosingStageFinalizing = "finalizing" // Sign-off delivered, session is being finalized
+)
+
+// Summary ETA estimation bounds
+const (
+	summaryETABase          = 20 * time.Second
+	summaryETAPerTranscript = time.Second
+	summaryETAMax           = 2 * time.Minute
 )
 
 type SessionTimeoutService struct {
 	db             *gorm.DB
-	geminiService  *GeminiService
+	eventBus       *EventBus
+	summaries      *SummaryJobService
 	activeSessions map[string]*ActiveSession
 	mutex          sync.RWMutex
 }
@@ -30,6 +52,7 @@ type ActiveSession struct {
 	UserID       string
 	AgentID      string
 	LastActivity time.Time
+	Deadline     time.Time // When the interview's time limit runs out
 	Transcripts  []models.InterviewTranscript
 	CancelFunc   context.CancelFunc
 	// Audio chunking support
@@ -38,40 +61,63 @@ type ActiveSession struct {
 	ChunksMutex sync.RWMutex
 	// Penalty tracking
 	EmptyResponseCount int
+	// The candidate's accessibility settings
+	Accommodations Accommodations
+	// Pacing of the agent, and whether the candidate was nudged off the current question
+	Pacing       Pacing
+	PacingNudged bool
+	// Rolling estimate of the candidate's ability, for agents that adapt question difficulty
+	Ability AbilityEstimate
+	// A question an observing admin set to be asked instead of the interviewer's next reply
+	NextQuestion string
+	// Closing sequence
+	ClosingStage     string
+	ClosingReason    string
+	ClosingStartedAt time.Time
 }
 
-func NewSessionTimeoutService(db *gorm.DB, geminiService *GeminiService) *SessionTimeoutService {
+func NewSessionTimeoutService(db *gorm.DB, eventBus *EventBus, summaries *SummaryJobService) *SessionTimeoutService {
 	service := &SessionTimeoutService{
 		db:             db,
-		geminiService:  geminiService,
+		eventBus:       eventBus,
+		summaries:      summaries,
 		activeSessions: make(map[string]*ActiveSession),
 	}
-
-	// Start the timeout checker
-	go service.startTimeoutChecker()
-
 	return service
 }
 
-func (s *SessionTimeoutService) RegisterSession(sessionID, userID, agentID string) {
+// RegisterSession starts tracking a session with the given time limit, stretched by the
+// candidate's accommodations. A client reconnecting to a session still being tracked
+// re-attaches to it, keeping its transcripts, deadline and closing state; RegisterSession
+// reports whether it did.
+func (s *SessionTimeoutService) RegisterSession(sessionID, userID, agentID string, limit time.Duration, accommodations Accommodations) bool {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	_ = ctx // Will be used for future context operations
+	if existing, exists := s.activeSessions[sessionID]; exists {
+		existing.LastActivity = time.Now()
+		slog.Info("Session resumed", "session_id", sessionID, "user_id", userID, "transcripts", len(existing.Transcripts), "deadline", existing.Deadline)
+		return true
+	}
 
+	_, cancel := context.WithCancel(context.Background())
+	deadline := time.Now().Add(accommodations.Limit(limit))
 	s.activeSessions[sessionID] = &ActiveSession{
 		SessionID:    sessionID,
 		UserID:       userID,
 		AgentID:      agentID,
 		LastActivity: time.Now(),
+		Deadline:     deadline,
 		Transcripts:  make([]models.InterviewTranscript, 0),
 		CancelFunc:   cancel,
 		AudioChunks:  make(map[int][]byte),
 		TotalChunks:  0,
+		// Set at registration, so changes apply from the next interview
+		Accommodations: accommodations,
 	}
 
-	slog.Info("Session registered for timeout tracking", "session_id", sessionID, "user_id", userID)
+	slog.Info("Session registered for timeout tracking", "session_id", sessionID, "user_id", userID, "deadline", deadline)
+	return false
 }
 
 func (s *SessionTimeoutService) UpdateActivity(sessionID string) {
@@ -84,17 +130,50 @@ func (s *SessionTimeoutService) UpdateActivity(sessionID string) {
 	}
 }
 
+// IsActive reports whether a session is still being tracked, i.e. it hasn't been finalized
+func (s *SessionTimeoutService) IsActive(sessionID string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	_, exists := s.activeSessions[sessionID]
+	return exists
+}
+
 func (s *SessionTimeoutService) IsInterviewExpired(sessionID string) bool {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	if session, exists := s.activeSessions[sessionID]; exists {
-		elapsed := time.Since(session.LastActivity)
-		return elapsed > InterviewLimit
+		return time.Now().After(session.Deadline)
 	}
 	return false
 }
 
+// RemainingTime returns the time left before an active session's limit, and false when the
+// session isn't active
+func (s *SessionTimeoutService) RemainingTime(sessionID string) (time.Duration, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists {
+		return 0, false
+	}
+	return max(time.Until(session.Deadline), 0), true
+}
+
+// InterviewDuration resolves a session's time limit in minutes: the requested length, else
+// the agent's, else the default
+func InterviewDuration(requested int, agent *models.Agent) int {
+	if requested > 0 {
+		return requested
+	}
+	if agent != nil && agent.DurationMinutes > 0 {
+		return agent.DurationMinutes
+	}
+	return DefaultInterviewMinutes
+}
+
 func (s *SessionTimeoutService) AddTranscript(sessionID string, transcript models.InterviewTranscript) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -102,10 +181,41 @@ func (s *SessionTimeoutService) AddTranscript(sessionID string, transcript model
 	if session, exists := s.activeSessions[sessionID]; exists {
 		session.Transcripts = append(session.Transcripts, transcript)
 		session.LastActivity = time.Now()
+		if transcript.Speaker == "agent" {
+			session.PacingNudged = false
+		}
 		slog.Debug("Transcript added to session", "session_id", sessionID, "turn_order", transcript.TurnOrder)
 	}
 }
 
+// GetLiveTranscripts returns a copy of the in-memory transcript of an active session
+func (s *SessionTimeoutService) GetLiveTranscripts(sessionID string) ([]models.InterviewTranscript, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists {
+		return nil, false
+	}
+	return append([]models.InterviewTranscript(nil), session.Transcripts...), true
+}
+
+// CountUserTurns returns how many candidate turns have been recorded for a session
+func (s *SessionTimeoutService) CountUserTurns(sessionID string) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	count := 0
+	if session, exists := s.activeSessions[sessionID]; exists {
+		for _, transcript := range session.Transcripts {
+			if transcript.Speaker == "user" {
+				count++
+			}
+		}
+	}
+	return count
+}
+
 func (s *SessionTimeoutService) EndSession(sessionID string) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -138,7 +248,189 @@ func (s *SessionTimeoutService) ConcludeSession(sessionID string, reason string)
 	}
 
 	// Reuse the timed-out finalization flow
-	s.handleTimedOutSession(session)
+	s.handleTimedOutSession(session, false)
+}
+
+// HandleSessionEndRequested concludes a session when an end is requested on the event bus
+func (s *SessionTimeoutService) HandleSessionEndRequested(ctx context.Context, event Event) error {
+	var payload SessionEndRequestedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	s.ConcludeSession(event.SessionID, payload.Reason)
+	return nil
+}
+
+// BeginClosing moves an active session into the closing sequence at the given stage.
+// It returns false if the session is not tracked or is already closing.
+func (s *SessionTimeoutService) BeginClosing(sessionID, reason, stage string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists || session.ClosingStage != ClosingStageNone {
+		return false
+	}
+
+	session.ClosingStage = stage
+	session.ClosingReason = reason
+	session.ClosingStartedAt = time.Now()
+	session.LastActivity = time.Now()
+	slog.Info("Session closing sequence started", "session_id", sessionID, "stage", stage, "reason", reason)
+	return true
+}
+
+// GetClosingStage returns the closing stage for a session, or ClosingStageNone
+func (s *SessionTimeoutService) GetClosingStage(sessionID string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if session, exists := s.activeSessions[sessionID]; exists {
+		return session.ClosingStage
+	}
+	return ClosingStageNone
+}
+
+// CompleteClosing marks the closing sequence as finalizing and returns the closing reason.
+// Only the first caller succeeds, so the sign-off is delivered exactly once.
+func (s *SessionTimeoutService) CompleteClosing(sessionID string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists || session.ClosingStage == ClosingStageNone || session.ClosingStage == ClosingStageFinalizing {
+		return "", false
+	}
+
+	session.ClosingStage = ClosingStageFinalizing
+	return session.ClosingReason, true
+}
+
+// EstimateSummaryETA estimates how long summary generation will take based on conversation length
+func (s *SessionTimeoutService) EstimateSummaryETA(sessionID string) time.Duration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	eta := summaryETABase
+	if session, exists := s.activeSessions[sessionID]; exists {
+		eta += time.Duration(len(session.Transcripts)) * summaryETAPerTranscript
+	}
+	if eta > summaryETAMax {
+		eta = summaryETAMax
+	}
+	return eta
+}
+
+// Accommodations returns the accessibility settings of a session's candidate
+func (s *SessionTimeoutService) Accommodations(sessionID string) Accommodations {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if session, exists := s.activeSessions[sessionID]; exists {
+		return session.Accommodations
+	}
+	return noAccommodations
+}
+
+// SetPacing sets the pacing of an active session's agent
+func (s *SessionTimeoutService) SetPacing(sessionID string, pacing Pacing) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if session, exists := s.activeSessions[sessionID]; exists {
+		session.Pacing = pacing
+	}
+}
+
+// Pacing returns the pacing of an active session's agent
+func (s *SessionTimeoutService) Pacing(sessionID string) Pacing {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if session, exists := s.activeSessions[sessionID]; exists {
+		return session.Pacing
+	}
+	return Pacing{}
+}
+
+// SetNextQuestion has an active session's interviewer ask a question instead of its next reply,
+// replacing any set before; it reports false if the session isn't active
+func (s *SessionTimeoutService) SetNextQuestion(sessionID, question string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists {
+		return false
+	}
+	session.NextQuestion = question
+	return true
+}
+
+// TakeNextQuestion returns and clears the question set to replace an active session's next
+// reply, if there is one
+func (s *SessionTimeoutService) TakeNextQuestion(sessionID string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists || session.NextQuestion == "" {
+		return "", false
+	}
+	question := session.NextQuestion
+	session.NextQuestion = ""
+	return question, true
+}
+
+// RecordAnswerScore adds the score of the candidate's latest answer to an active session's
+// ability estimate and returns it, reporting false if the session isn't active
+func (s *SessionTimeoutService) RecordAnswerScore(sessionID string, score float64) (AbilityEstimate, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists {
+		return AbilityEstimate{}, false
+	}
+	session.Ability = session.Ability.Record(score)
+	return session.Ability, true
+}
+
+// QuestionDwell returns how long the candidate has spent on the interviewer's last turn, up to
+// their answer if they gave one, and whether they did
+func (s *SessionTimeoutService) QuestionDwell(sessionID string) (time.Duration, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists {
+		return 0, false
+	}
+	for i := len(session.Transcripts) - 1; i >= 0; i-- {
+		if session.Transcripts[i].Speaker != "agent" {
+			continue
+		}
+		if i == len(session.Transcripts)-1 {
+			return time.Since(session.Transcripts[i].Timestamp), false
+		}
+		return session.Transcripts[len(session.Transcripts)-1].Timestamp.Sub(session.Transcripts[i].Timestamp), true
+	}
+	return 0, false
+}
+
+// ClaimPacingNudge marks the candidate nudged off the current question, reporting false if
+// they already were
+func (s *SessionTimeoutService) ClaimPacingNudge(sessionID string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, exists := s.activeSessions[sessionID]
+	if !exists || session.PacingNudged {
+		return false
+	}
+	session.PacingNudged = true
+	return true
 }
 
 // IncrementEmptyResponse increments the empty/unintelligible response counter and returns the updated count
@@ -166,12 +458,42 @@ func (s *SessionTimeoutService) ResetEmptyResponse(sessionID string) {
 	}
 }
 
-func (s *SessionTimeoutService) startTimeoutChecker() {
-	ticker := time.NewTicker(30 * time.Second) // Check every 30 seconds
-	defer ticker.Stop()
+// StartTimeoutChecker concludes inactive sessions in the background
+func (s *SessionTimeoutService) StartTimeoutChecker(lifecycle *Lifecycle) {
+	lifecycle.Go("session timeout checker", func(ctx context.Context) {
+		ticker := time.NewTicker(30 * time.Second) // Check every 30 seconds
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.checkTimeouts()
+			}
+		}
+	})
+}
 
-	for range ticker.C {
-		s.checkTimeouts()
+// FlushSessions concludes the sessions still active at shutdown, as what is only held in
+// memory, such as the live transcripts and the deadline, doesn't survive a restart. Each
+// session is completed and its summary queued, to be generated once the server is back.
+func (s *SessionTimeoutService) FlushSessions(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	s.mutex.RLock()
+	sessions := make([]*ActiveSession, 0, len(s.activeSessions))
+	for _, session := range s.activeSessions {
+		sessions = append(sessions, session)
+	}
+	s.mutex.RUnlock()
+
+	for i, session := range sessions {
+		if ctx.Err() != nil {
+			logger.Error("Shutdown deadline reached before every active session was saved", "unsaved", len(sessions)-i)
+			return
+		}
+		logger.Info("Concluding active session for shutdown", "session_id", session.SessionID)
+		s.handleTimedOutSession(session, false)
 	}
 }
 
@@ -183,7 +505,7 @@ func (s *SessionTimeoutService) checkTimeouts() {
 	var timedOutSessions []*ActiveSession
 
 	for _, session := range s.activeSessions {
-		if now.Sub(session.LastActivity) > timeoutDuration {
+		if now.Sub(session.LastActivity) > session.Accommodations.Think(timeoutDuration) {
 			timedOutSessions = append(timedOutSessions, session)
 		}
 	}
@@ -195,11 +517,14 @@ func (s *SessionTimeoutService) checkTimeouts() {
 			"session_id", session.SessionID,
 			"inactive_duration", now.Sub(session.LastActivity))
 
-		s.handleTimedOutSession(session)
+		s.handleTimedOutSession(session, true)
 	}
 }
 
-func (s *SessionTimeoutService) handleTimedOutSession(session *ActiveSession) {
+// handleTimedOutSession completes a session and queues its summary. timedOut is set when the
+// candidate went inactive, rather than the session being ended or flushed.
+func (s *SessionTimeoutService) handleTimedOutSession(session *ActiveSession, timedOut bool) {
+	// The candidate is gone, so nothing cancels this
 	ctx := context.Background()
 
 	// Update session status in database
@@ -221,192 +546,25 @@ func (s *SessionTimeoutService) handleTimedOutSession(session *ActiveSession) {
 		return
 	}
 
-	// Generate summary if we have transcripts
+	// Queue summary generation if we have transcripts
 	if len(session.Transcripts) > 0 {
-		slog.Info("Starting automatic summary generation", "session_id", session.SessionID, "transcript_count", len(session.Transcripts))
-		s.generateAutoSummary(ctx, &dbSession, session.Transcripts)
-		slog.Info("Automatic summary generation completed", "session_id", session.SessionID)
+		if _, err := s.summaries.Enqueue(ctx, session.SessionID, session.Transcripts); err != nil {
+			slog.Error("Failed to queue summary generation", "session_id", session.SessionID, "error", err)
+		}
 	} else {
 		slog.Warn("No transcripts available for summary generation", "session_id", session.SessionID)
 	}
 
 	// Remove from active sessions
 	s.EndSession(session.SessionID)
-}
-
-func (s *SessionTimeoutService) generateAutoSummary(ctx context.Context, session *models.InterviewSession, transcripts []models.InterviewTranscript) {
-	if s.geminiService == nil {
-		slog.Warn("Gemini service not available, skipping auto summary generation")
-		return
-	}
-
-	// Use global mutex to prevent concurrent summary generation across services
-	// Note: This should be the same mutex used in session_endpoints.go
-	// For now, we'll use the existing mutex but this could be improved with a shared service
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	// Check if summary already exists to prevent duplicates
-	var existingSummary models.InterviewSummary
-	err := s.db.Where("session_id = ?", session.ID).First(&existingSummary).Error
-	if err == nil {
-		slog.Info("Summary already exists for session, skipping generation", "session_id", session.ID)
-		return
-	}
-	if err != gorm.ErrRecordNotFound {
-		slog.Error("Failed to check for existing summary", "session_id", session.ID, "error", err)
-		return
-	}
-
-	// Get agent information for personality-based summary
-	var agent models.Agent
-	if err := s.db.Preload("User").First(&agent, session.AgentID).Error; err != nil {
-		slog.Error("Failed to load agent for summary generation", "session_id", session.ID, "error", err)
-		return
-	}
-
-	// Prepare conversation history for AI analysis
-	conversationHistory := make([]string, 0, len(transcripts))
-	for _, transcript := range transcripts {
-		conversationHistory = append(conversationHistory,
-			transcript.Speaker+": "+transcript.Content)
-	}
-
-	// Generate personality-based summary using Gemini
-	summaryPrompt := s.buildPersonalityBasedSummaryPrompt(agent, conversationHistory)
-
-	slog.Info("Generating AI summary with Gemini", "session_id", session.ID, "agent_name", agent.Name, "conversation_length", len(conversationHistory))
-	summary, err := s.geminiService.GenerateSummary(ctx, summaryPrompt)
-	if err != nil {
-		slog.Error("Failed to generate auto summary", "session_id", session.ID, "error", err)
-		return
-	}
-	slog.Info("AI summary generated successfully", "session_id", session.ID, "summary_length", len(summary))
-
-	// Parse the AI response to extract structured data
-	parsedSummary := s.parseAISummary(summary)
-
-	// Create summary record
-	interviewSummary := models.InterviewSummary{
-		SessionID:       session.ID,
-		Summary:         parsedSummary.Summary,
-		Strengths:       parsedSummary.Strengths,
-		Weaknesses:      parsedSummary.Weaknesses,
-		Recommendations: parsedSummary.Recommendations,
-		OverallScore:    parsedSummary.OverallScore,
-	}
-
-	if err := s.db.Create(&interviewSummary).Error; err != nil {
-		slog.Error("Failed to save auto-generated summary", "session_id", session.ID, "error", err)
-		return
-	}
-	slog.Info("Summary saved to database", "session_id", session.ID, "summary_id", interviewSummary.ID)
-
-	// Generate performance scores
-	s.generatePerformanceScores(ctx, session.ID, parsedSummary)
 
-	slog.Info("Auto summary generation completed successfully", "session_id", session.ID, "overall_score", parsedSummary.OverallScore)
-}
-
-// buildPersonalityBasedSummaryPrompt creates a summary prompt tailored to the agent's personality
-func (s *SessionTimeoutService) buildPersonalityBasedSummaryPrompt(agent models.Agent, conversationHistory []string) string {
-	// Determine scoring strictness based on agent personality
-	scoringGuidance := s.getScoringGuidance(agent.Personality)
-
-	// Build industry-specific context
-	industryContext := s.getIndustryContext(agent.Industry, agent.Level)
-
-	// Create personality-specific tone and expectations
-	personalityTone := s.getPersonalityTone(agent.Personality)
-
-	prompt := fmt.Sprintf(`You are %s, a %s interviewer in the %s industry. 
-Your personality: %s
-
-%s
-
-Based on this interview conversation, provide a comprehensive analysis that reflects your interviewing style and personality:
-
-1. A narrative summary of the interview (written in your voice and style)
-2. Key strengths demonstrated by the candidate
-3. Areas for improvement (be specific and constructive)
-4. Specific recommendations for the candidate's growth
-5. An overall score (0-100) using this scoring guidance: %s
-
-%s
-
-Conversation:
-%s
-
-Please structure your response as:
-SUMMARY: [Your narrative summary]
-STRENGTHS: [Key strengths]
-WEAKNESSES: [Areas for improvement]
-RECOMMENDATIONS: [Specific recommendations]
-SCORE: [Numerical score 0-100]`,
-		agent.Name,
-		agent.Level,
-		agent.Industry,
-		agent.Personality,
-		industryContext,
-		scoringGuidance,
-		personalityTone,
-		joinStrings(conversationHistory, "\n"))
-
-	return prompt
-}
-
-// getScoringGuidance returns scoring criteria based on agent personality
-func (s *SessionTimeoutService) getScoringGuidance(personality string) string {
-	personalityLower := strings.ToLower(personality)
-
-	if strings.Contains(personalityLower, "strict") || strings.Contains(personalityLower, "rigorous") || strings.Contains(personalityLower, "demanding") {
-		return "Be very strict and demanding. Only give high scores (80+) for exceptional performance. Average performance should score 50-70. Poor performance should score below 50. Focus heavily on technical accuracy and depth."
-	} else if strings.Contains(personalityLower, "encouraging") || strings.Contains(personalityLower, "supportive") || strings.Contains(personalityLower, "mentor") {
-		return "Be encouraging and supportive. Give credit for effort and potential. High scores (80+) for good performance with growth potential. Average performance should score 60-80. Focus on potential and learning attitude."
-	} else if strings.Contains(personalityLower, "grilling") || strings.Contains(personalityLower, "intense") || strings.Contains(personalityLower, "challenging") {
-		return "Be very challenging and thorough. Only give high scores (85+) for outstanding performance under pressure. Average performance should score 40-70. Poor performance should score below 40. Focus on handling pressure and technical depth."
-	} else if strings.Contains(personalityLower, "friendly") || strings.Contains(personalityLower, "approachable") || strings.Contains(personalityLower, "collaborative") {
-		return "Be fair and balanced. High scores (80+) for strong performance. Average performance should score 60-80. Focus on communication and collaboration skills."
-	}
-
-	// Default balanced approach
-	return "Be fair and balanced. High scores (80+) for strong performance. Average performance should score 60-80. Focus on both technical skills and soft skills."
-}
-
-// getIndustryContext returns industry-specific evaluation criteria
-func (s *SessionTimeoutService) getIndustryContext(industry, level string) string {
-	switch strings.ToLower(industry) {
-	case "software engineering", "technology":
-		return "Focus on technical problem-solving, code quality, system design thinking, and ability to learn new technologies. Consider algorithmic thinking, debugging skills, and understanding of software development practices."
-	case "finance", "banking":
-		return "Focus on analytical thinking, attention to detail, risk assessment, and understanding of financial concepts. Consider quantitative skills, regulatory knowledge, and market awareness."
-	case "consulting":
-		return "Focus on problem-solving frameworks, client communication, business acumen, and structured thinking. Consider case study performance, presentation skills, and strategic thinking."
-	case "marketing", "sales":
-		return "Focus on creativity, communication skills, market understanding, and customer orientation. Consider campaign thinking, brand awareness, and persuasive abilities."
-	case "healthcare", "medical":
-		return "Focus on attention to detail, patient care orientation, medical knowledge, and ethical considerations. Consider clinical thinking, empathy, and professional standards."
-	default:
-		return "Focus on relevant technical skills, problem-solving abilities, communication, and cultural fit for the role."
-	}
-}
-
-// getPersonalityTone returns tone guidance based on agent personality
-func (s *SessionTimeoutService) getPersonalityTone(personality string) string {
-	personalityLower := strings.ToLower(personality)
-
-	if strings.Contains(personalityLower, "strict") || strings.Contains(personalityLower, "rigorous") {
-		return "Write your feedback in a direct, professional tone. Be specific about shortcomings and don't sugarcoat issues. Use precise technical language."
-	} else if strings.Contains(personalityLower, "encouraging") || strings.Contains(personalityLower, "supportive") {
-		return "Write your feedback in an encouraging, constructive tone. Focus on potential and growth opportunities. Be supportive while being honest about areas for improvement."
-	} else if strings.Contains(personalityLower, "grilling") || strings.Contains(personalityLower, "intense") {
-		return "Write your feedback in a direct, challenging tone. Be thorough in your analysis and don't hold back on criticism. Focus on performance under pressure."
-	} else if strings.Contains(personalityLower, "friendly") || strings.Contains(personalityLower, "approachable") {
-		return "Write your feedback in a warm, professional tone. Balance constructive criticism with positive reinforcement. Be encouraging while maintaining professionalism."
-	}
-
-	// Default professional tone
-	return "Write your feedback in a professional, balanced tone. Be constructive and specific in your recommendations."
+	s.eventBus.Publish(ctx, EventSessionConcluded, session.SessionID, SessionConcludedPayload{
+		UserID:          session.UserID,
+		AgentID:         dbSession.AgentID,
+		Duration:        dbSession.Duration,
+		TranscriptCount: len(session.Transcripts),
+		TimedOut:        timedOut,
+	})
 }
 
 type ParsedSummary struct {
@@ -415,9 +573,29 @@ type ParsedSummary struct {
 	Weaknesses      string
 	Recommendations string
 	OverallScore    float64
+	StageScores     []StageScore
+	WritingScore    *float64 // Written communication of a text interview
+	RubricScores    []RubricScore
+}
+
+// RubricScore is the model's score for one metric of a typed interview's rubric
+type RubricScore struct {
+	Metric string  `json:"metric"`
+	Score  float64 `json:"score"`
+}
+
+// StageScore is the model's score for a single interview flow stage
+type StageScore struct {
+	Stage string  `json:"stage"`
+	Score float64 `json:"score"`
 }
 
 func (s *SessionTimeoutService) parseAISummary(aiResponse string) ParsedSummary {
+	return parseSummaryResponse(aiResponse)
+}
+
+// parseSummaryResponse parses the structured JSON summary returned by Gemini
+func parseSummaryResponse(aiResponse string) ParsedSummary {
 	// Parse structured JSON response from Gemini
 	var response struct {
 		Summary         string  `json:"summary"`
@@ -433,6 +611,9 @@ func (s *SessionTimeoutService) parseAISummary(aiResponse string) ParsedSummary
 			Skill  string  `json:"skill"`
 			Rating float64 `json:"rating"`
 		} `json:"communicationSkills"`
+		StageScores  []StageScore  `json:"stageScores"`
+		WritingScore *float64      `json:"writingScore"`
+		RubricScores []RubricScore `json:"rubricScores"`
 	}
 
 	// Parse the JSON response
@@ -481,6 +662,9 @@ func (s *SessionTimeoutService) parseAISummary(aiResponse string) ParsedSummary
 		Weaknesses:      response.Weaknesses,
 		Recommendations: response.Recommendations,
 		OverallScore:    response.OverallScore,
+		StageScores:     response.StageScores,
+		WritingScore:    response.WritingScore,
+		RubricScores:    response.RubricScores,
 	}
 }
 
@@ -519,64 +703,6 @@ func (s *SessionTimeoutService) calculateScoreFromResponse(response string) floa
 	return score
 }
 
-func (s *SessionTimeoutService) calculateMetricScore(baseScore float64, adjustment float64) float64 {
-	// Calculate a metric score based on the base score with an adjustment
-	adjustedScore := baseScore + (baseScore * adjustment)
-
-	// Ensure score is within bounds
-	if adjustedScore < 0 {
-		adjustedScore = 0
-	}
-	if adjustedScore > 100 {
-		adjustedScore = 100
-	}
-
-	return adjustedScore
-}
-
-func (s *SessionTimeoutService) generatePerformanceScores(ctx context.Context, sessionID string, summary ParsedSummary) {
-	// Calculate performance scores based on the overall score and session characteristics
-	baseScore := summary.OverallScore
-
-	// Create performance scores that are related to the overall score
-	scores := []models.PerformanceScore{
-		{
-			SessionID: sessionID,
-			Metric:    "Communication",
-			Score:     s.calculateMetricScore(baseScore, 0.1), // Slightly higher than base
-			MaxScore:  100.0,
-			Weight:    0.25,
-		},
-		{
-			SessionID: sessionID,
-			Metric:    "Technical Knowledge",
-			Score:     s.calculateMetricScore(baseScore, 0.05), // Close to base score
-			MaxScore:  100.0,
-			Weight:    0.3,
-		},
-		{
-			SessionID: sessionID,
-			Metric:    "Engagement",
-			Score:     s.calculateMetricScore(baseScore, -0.1), // Slightly lower than base
-			MaxScore:  100.0,
-			Weight:    0.2,
-		},
-		{
-			SessionID: sessionID,
-			Metric:    "Session Completion",
-			Score:     s.calculateMetricScore(baseScore, -0.15), // Lower due to timeout
-			MaxScore:  100.0,
-			Weight:    0.25,
-		},
-	}
-
-	for _, score := range scores {
-		if err := s.db.Create(&score).Error; err != nil {
-			slog.Error("Failed to create performance score", "session_id", sessionID, "metric", score.Metric, "error", err)
-		}
-	}
-}
-
 func joinStrings(strs []string, sep string) string {
 	if len(strs) == 0 {
 		return ""
@@ -592,6 +718,25 @@ func joinStrings(strs []string, sep string) string {
 	return result
 }
 
+// DetachClient forgets what a session's dropped connection left half done: its audio chunks
+// can't be completed, as the candidate answers again after reconnecting. The session itself
+// stays tracked until it is concluded.
+func (s *SessionTimeoutService) DetachClient(sessionID string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if session, exists := s.activeSessions[sessionID]; exists {
+		session.ChunksMutex.Lock()
+		defer session.ChunksMutex.Unlock()
+
+		if len(session.AudioChunks) > 0 {
+			slog.Info("Discarding incomplete audio of dropped connection", "session_id", sessionID, "chunks", len(session.AudioChunks), "total_chunks", session.TotalChunks)
+		}
+		session.AudioChunks = make(map[int][]byte)
+		session.TotalChunks = 0
+	}
+}
+
 // AddAudioChunk stores an audio chunk for a session
 func (s *SessionTimeoutService) AddAudioChunk(sessionID string, chunkData []byte, chunkIndex int, totalChunks int, isLastChunk bool) {
 	s.mutex.Lock()
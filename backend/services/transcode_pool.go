@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// ErrTranscodeQueueTimeout is returned when a transcode waited QueueTimeout without a free slot
+var ErrTranscodeQueueTimeout = errors.New("timed out waiting for a free transcoder")
+
+// TranscodePool runs ffmpeg with at most MaxConcurrent processes at once; the rest queue for up
+// to QueueTimeout. Each process runs niced, with ulimit caps on its address space and CPU time,
+// and is killed after Timeout.
+type TranscodePool struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+	timeout      time.Duration
+	nice         int
+	memoryKB     int
+	cpuSeconds   int
+
+	stats       TranscodeStats
+	waits       int64 // Transcodes that got a slot, for AverageWaitMs
+	totalWaitMs int64
+	mutex       sync.Mutex
+}
+
+// TranscodeStats are the counters reported by the admin API
+type TranscodeStats struct {
+	MaxConcurrent int     `json:"max_concurrent"`
+	Running       int     `json:"running"`
+	Queued        int     `json:"queued"`
+	Completed     int64   `json:"completed"`
+	Failed        int64   `json:"failed"`
+	QueueTimeouts int64   `json:"queue_timeouts"`
+	AverageWaitMs float64 `json:"average_wait_ms"` // Time spent queued, over transcodes that got a slot
+	MaxWaitMs     int64   `json:"max_wait_ms"`
+}
+
+func NewTranscodePool(cfg TranscodeConfig) *TranscodePool {
+	maxConcurrent := max(cfg.MaxConcurrent, 1)
+	pool := &TranscodePool{
+		slots:        make(chan struct{}, maxConcurrent),
+		queueTimeout: cfg.QueueTimeout,
+		timeout:      cfg.Timeout,
+		nice:         cfg.Nice,
+		memoryKB:     cfg.MaxMemoryMB * 1024,
+		cpuSeconds:   cfg.MaxCPUSeconds,
+	}
+	pool.stats.MaxConcurrent = maxConcurrent
+	return pool
+}
+
+// Run runs ffmpeg with args once a slot is free
+func (p *TranscodePool) Run(ctx context.Context, args ...string) error {
+	queuedAt := time.Now()
+	p.mutex.Lock()
+	p.stats.Queued++
+	p.mutex.Unlock()
+
+	queueCtx := ctx
+	if p.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		queueCtx, cancel = context.WithTimeout(ctx, p.queueTimeout)
+		defer cancel()
+	}
+	select {
+	case p.slots <- struct{}{}:
+	case <-queueCtx.Done():
+		p.mutex.Lock()
+		p.stats.Queued--
+		p.stats.QueueTimeouts++
+		p.mutex.Unlock()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logging.FromContext(ctx).Warn("Transcode gave up waiting for a slot", "waited", time.Since(queuedAt))
+		return ErrTranscodeQueueTimeout
+	}
+	defer func() { <-p.slots }()
+
+	wait := time.Since(queuedAt).Milliseconds()
+	p.mutex.Lock()
+	p.stats.Queued--
+	p.stats.Running++
+	p.waits++
+	p.totalWaitMs += wait
+	p.stats.MaxWaitMs = max(p.stats.MaxWaitMs, wait)
+	p.mutex.Unlock()
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	var stderr strings.Builder
+	cmd := p.command(ctx, args)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	p.mutex.Lock()
+	p.stats.Running--
+	if err != nil {
+		p.stats.Failed++
+	} else {
+		p.stats.Completed++
+	}
+	p.mutex.Unlock()
+
+	if err != nil {
+		// ffmpeg ends its stderr with the reason it failed
+		output := strings.TrimSpace(stderr.String())
+		if i := strings.LastIndex(output, "\n"); i >= 0 {
+			output = output[i+1:]
+		}
+		return fmt.Errorf("ffmpeg: %w: %s", err, output)
+	}
+	return nil
+}
+
+// command wraps ffmpeg in a shell that applies the limits, as Go can't set a child's rlimits
+func (p *TranscodePool) command(ctx context.Context, args []string) *exec.Cmd {
+	var limits []string
+	if p.memoryKB > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -v %d", p.memoryKB))
+	}
+	if p.cpuSeconds > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -t %d", p.cpuSeconds))
+	}
+	run := `exec ffmpeg "$@"`
+	if p.nice > 0 {
+		run = fmt.Sprintf(`exec nice -n %d ffmpeg "$@"`, p.nice)
+	}
+	if len(limits) == 0 && p.nice <= 0 {
+		return exec.CommandContext(ctx, "ffmpeg", args...)
+	}
+	script := strings.Join(append(limits, run), " && ")
+	// After the script, the first argument is $0 and the rest are "$@"
+	return exec.CommandContext(ctx, "sh", append([]string{"-c", script, "ffmpeg"}, args...)...)
+}
+
+// Stats returns the current load and counters
+func (p *TranscodePool) Stats() TranscodeStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := p.stats
+	if p.waits > 0 {
+		stats.AverageWaitMs = float64(p.totalWaitMs) / float64(p.waits)
+	}
+	return stats
+}
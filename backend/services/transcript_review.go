@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// maxCorrectionLength caps a corrected answer, in runes
+const maxCorrectionLength = 5000
+
+var (
+	ErrNoTranscriptReview = errors.New("no transcript review is pending for this session")
+	ErrTurnNotCorrectable = errors.New("only spoken answers can be corrected")
+	ErrReviewChanged      = errors.New("the transcript changed while it was being corrected; reload it and try again")
+)
+
+// ReviewTurn is a turn of a transcript held for review. Spoken answers can be corrected; those
+// transcribed with low confidence are highlighted.
+type ReviewTurn struct {
+	Index         int       `json:"index"` // Position in the transcript, used to correct the turn
+	Speaker       string    `json:"speaker"`
+	Content       string    `json:"content"`
+	Kind          string    `json:"kind"`
+	Confidence    *float64  `json:"confidence,omitempty"`
+	Original      string    `json:"original,omitempty"` // What was transcribed, once corrected
+	Correctable   bool      `json:"correctable"`
+	LowConfidence bool      `json:"low_confidence"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type CorrectTurnRequest struct {
+	Content string `json:"content"`
+}
+
+// LowConfidenceTurns returns the positions of the spoken answers transcribed with a confidence
+// below threshold. Turns whose provider reported no confidence are never flagged.
+func LowConfidenceTurns(transcripts []models.InterviewTranscript, threshold float64) []int {
+	var flagged []int
+	for i, turn := range transcripts {
+		if turn.Speaker == "user" && turn.Confidence != nil && *turn.Confidence < threshold {
+			flagged = append(flagged, i)
+		}
+	}
+	return flagged
+}
+
+// heldForReview returns a session's summary job if it is held for transcript review, with the
+// transcripts it will summarize
+func (s *SummaryJobService) heldForReview(ctx context.Context, sessionID string) (*models.SummaryJob, []models.InterviewTranscript, error) {
+	job, err := s.repo.GetLatestSummaryJob(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job == nil || job.Status != models.SummaryJobPending || job.Stage != models.SummaryStageReview || job.Transcripts == nil {
+		return nil, nil, ErrNoTranscriptReview
+	}
+
+	var transcripts []models.InterviewTranscript
+	if err := json.Unmarshal([]byte(*job.Transcripts), &transcripts); err != nil {
+		return nil, nil, fmt.Errorf("invalid review transcripts: %w", err)
+	}
+	return job, transcripts, nil
+}
+
+// TranscriptReview returns the transcript of a session whose summary is held for review, and
+// when the summary will be generated without it
+func (s *SummaryJobService) TranscriptReview(ctx context.Context, sessionID string) ([]ReviewTurn, time.Time, error) {
+	job, transcripts, err := s.heldForReview(ctx, sessionID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	flagged := make(map[int]bool)
+	for _, i := range LowConfidenceTurns(transcripts, s.config.ReviewConfidence) {
+		flagged[i] = true
+	}
+	turns := make([]ReviewTurn, 0, len(transcripts))
+	for i, transcript := range transcripts {
+		kind := transcript.Kind
+		if kind == "" {
+			kind = models.TranscriptKindText
+		}
+		turns = append(turns, ReviewTurn{
+			Index:         i,
+			Speaker:       transcript.Speaker,
+			Content:       transcript.Content,
+			Kind:          kind,
+			Confidence:    transcript.Confidence,
+			Original:      transcript.Original,
+			Correctable:   transcript.Speaker == "user" && transcript.Confidence != nil,
+			LowConfidence: flagged[i],
+			Timestamp:     transcript.Timestamp,
+		})
+	}
+	return turns, job.RunAfter, nil
+}
+
+// CorrectTurn replaces what was transcribed of a spoken answer with what the candidate says
+// they said, keeping the original
+func (s *SummaryJobService) CorrectTurn(ctx context.Context, sessionID string, index int, content string) (*ReviewTurn, error) {
+	job, transcripts, err := s.heldForReview(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(transcripts) {
+		return nil, ErrTurnNotCorrectable
+	}
+	turn := &transcripts[index]
+	if turn.Speaker != "user" || turn.Confidence == nil {
+		return nil, ErrTurnNotCorrectable
+	}
+
+	if turn.Original == "" {
+		turn.Original = turn.Content
+	}
+	turn.Content = content
+	data, err := json.Marshal(transcripts)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.SetReviewTranscripts(ctx, job.ID, *job.Transcripts, string(data))
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrReviewChanged
+	}
+	logging.FromContext(ctx).Info("Transcript turn corrected", "session_id", sessionID, "job_id", job.ID, "index", index)
+
+	return &ReviewTurn{
+		Index:         index,
+		Speaker:       turn.Speaker,
+		Content:       turn.Content,
+		Kind:          models.TranscriptKindText,
+		Confidence:    turn.Confidence,
+		Original:      turn.Original,
+		Correctable:   true,
+		LowConfidence: *turn.Confidence < s.config.ReviewConfidence,
+		Timestamp:     turn.Timestamp,
+	}, nil
+}
+
+// FinishReview releases a held summary to be generated now, with the corrections made
+func (s *SummaryJobService) FinishReview(ctx context.Context, sessionID string) (*models.SummaryJob, error) {
+	job, _, err := s.heldForReview(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	released, err := s.repo.ReleaseReviewedSummaryJob(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !released {
+		return nil, ErrNoTranscriptReview
+	}
+	job.Stage = models.SummaryStageQueued
+	job.RunAfter = time.Now()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	logging.FromContext(ctx).Info("Transcript review finished, summary queued", "session_id", sessionID, "job_id", job.ID)
+	return job, nil
+}
+
+// GetTranscriptReviewHandler returns the transcript of one of the user's sessions whose summary
+// is held for review, with low-confidence answers highlighted
+func (e *SessionEndpoints) GetTranscriptReviewHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := e.reviewedSession(w, r)
+	if !ok {
+		return
+	}
+
+	turns, until, err := e.summaries.TranscriptReview(r.Context(), sessionID)
+	if err != nil {
+		writeReviewError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id":   sessionID,
+		"turns":        turns,
+		"review_until": until,
+	})
+}
+
+// CorrectTurnHandler corrects a spoken answer in a transcript held for review
+func (e *SessionEndpoints) CorrectTurnHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := e.reviewedSession(w, r)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid turn index"))
+		return
+	}
+	var req CorrectTurnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" || len([]rune(content)) > maxCorrectionLength {
+		apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("content must be 1-%d characters", maxCorrectionLength)))
+		return
+	}
+
+	turn, err := e.summaries.CorrectTurn(r.Context(), sessionID, index, content)
+	if err != nil {
+		writeReviewError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"turn": turn,
+	})
+}
+
+// FinishTranscriptReviewHandler ends the review, so the summary is generated right away
+func (e *SessionEndpoints) FinishTranscriptReviewHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := e.reviewedSession(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := e.summaries.FinishReview(r.Context(), sessionID)
+	if err != nil {
+		writeReviewError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id": sessionID,
+		"status":     job.Status,
+		"job":        job,
+	})
+}
+
+// reviewedSession checks the session in the URL belongs to the current user
+func (e *SessionEndpoints) reviewedSession(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return "", false
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	session, err := e.repo.GetInterviewSession(r.Context(), sessionID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get session"))
+		return "", false
+	}
+	if session == nil || session.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Session not found"))
+		return "", false
+	}
+	return sessionID, true
+}
+
+// writeReviewError responds to a transcript review error with its status
+func writeReviewError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNoTranscriptReview):
+		apperrors.Write(w, r, apperrors.NotFound(err.Error()))
+	case errors.Is(err, ErrTurnNotCorrectable):
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+	case errors.Is(err, ErrReviewChanged):
+		apperrors.Write(w, r, apperrors.Conflict(err.Error()))
+	default:
+		apperrors.Write(w, r, apperrors.Internal("Failed to review transcript"))
+	}
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// Speech-to-text providers, named in TRANSCRIPTION_PROVIDERS
+const (
+	TranscriptionGemini   = "gemini"
+	TranscriptionWhisper  = "whisper"
+	TranscriptionDeepgram = "deepgram"
+)
+
+const (
+	openAITranscriptionURL = "https://api.openai.com/v1/audio/transcriptions"
+	whisperModel           = "whisper-1"
+	deepgramListenURL      = "https://api.deepgram.com/v1/listen?model=nova-2&smart_format=true"
+	// transcriptionErrorBody caps how much of an error response is kept in the error
+	transcriptionErrorBody = 1024
+)
+
+// Transcription is a transcribed answer and how sure the provider was of it
+type Transcription struct {
+	Text       string
+	Confidence *float64 // 0-1; nil when the provider doesn't report one
+}
+
+// logprobConfidence turns an average token log probability into a 0-1 confidence. Providers
+// leave it at 0 when they don't report one.
+func logprobConfidence(avgLogprob float64) *float64 {
+	if avgLogprob == 0 {
+		return nil
+	}
+	confidence := math.Min(math.Exp(avgLogprob), 1)
+	return &confidence
+}
+
+// NewTranscriptionProvider builds the providers named in cfg.Transcription. With more than
+// one, each is a fallback for the ones before it.
+func NewTranscriptionProvider(cfg AIConfig, gemini *GeminiService) (TranscriptionProvider, error) {
+	var providers []TranscriptionProvider
+	for _, name := range strings.Split(cfg.Transcription, ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "":
+			continue
+		case TranscriptionGemini:
+			providers = append(providers, &GeminiTranscriber{gemini: gemini})
+		case TranscriptionWhisper:
+			if cfg.OpenAIKey == "" {
+				return nil, fmt.Errorf("whisper transcription requires OPENAI_API_KEY")
+			}
+			providers = append(providers, NewWhisperTranscriber(cfg.OpenAIKey))
+		case TranscriptionDeepgram:
+			if cfg.DeepgramKey == "" {
+				return nil, fmt.Errorf("deepgram transcription requires DEEPGRAM_API_KEY")
+			}
+			providers = append(providers, NewDeepgramTranscriber(cfg.DeepgramKey))
+		default:
+			return nil, fmt.Errorf("unknown transcription provider %q", name)
+		}
+	}
+
+	switch len(providers) {
+	case 0:
+		return &GeminiTranscriber{gemini: gemini}, nil
+	case 1:
+		return providers[0], nil
+	}
+	return &FallbackTranscriber{providers: providers}, nil
+}
+
+// transcriptionProviders lists the providers behind a fallback chain, or the provider itself
+func transcriptionProviders(provider TranscriptionProvider) []TranscriptionProvider {
+	if fallback, ok := provider.(*FallbackTranscriber); ok {
+		return fallback.providers
+	}
+	return []TranscriptionProvider{provider}
+}
+
+// baseMIME strips the parameters from a MIME type, e.g. "audio/webm;codecs=opus" to "audio/webm"
+func baseMIME(mimeType string) string {
+	if base, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return base
+	}
+	base, _, _ := strings.Cut(mimeType, ";")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// transcriptionAPIError is a non-200 response from a speech-to-text API
+type transcriptionAPIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *transcriptionAPIError) Error() string {
+	return fmt.Sprintf("%s API error: %d - %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// doTranscriptionRequest sends a request and decodes a 200 response into v
+func doTranscriptionRequest(client *http.Client, req *http.Request, provider string, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, transcriptionErrorBody))
+		return &transcriptionAPIError{Provider: provider, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", provider, err)
+	}
+	return nil
+}
+
+// GeminiTranscriber transcribes with Gemini, following the prompt
+type GeminiTranscriber struct {
+	gemini *GeminiService
+}
+
+func (t *GeminiTranscriber) Name() string {
+	return TranscriptionGemini
+}
+
+func (t *GeminiTranscriber) Transcribe(ctx context.Context, audioData []byte, mimeType, prompt string) (Transcription, error) {
+	if t.gemini == nil {
+		return Transcription{}, fmt.Errorf("gemini service not initialized")
+	}
+	return t.gemini.TranscribeAudio(ctx, audioData, geminiAudioMIME(mimeType), prompt)
+}
+
+// geminiAudioMIME maps a recording's format to one Gemini accepts. Gemini doesn't list WebM,
+// but reads the Opus audio of a WebM recording labelled as Ogg.
+func geminiAudioMIME(mimeType string) string {
+	switch base := baseMIME(mimeType); base {
+	case "", "audio/webm", "video/webm":
+		return "audio/ogg"
+	case "audio/mpeg":
+		return "audio/mp3"
+	case "audio/x-wav", "audio/wave":
+		return "audio/wav"
+	default:
+		return base
+	}
+}
+
+// WhisperTranscriber transcribes with OpenAI's Whisper model, passing the prompt as context. Its
+// confidence is the average log probability of the segments, weighted by their length.
+type WhisperTranscriber struct {
+	apiKey   string
+	keyMutex sync.RWMutex
+	client   *http.Client
+}
+
+func NewWhisperTranscriber(apiKey string) *WhisperTranscriber {
+	return &WhisperTranscriber{
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (t *WhisperTranscriber) Name() string {
+	return TranscriptionWhisper
+}
+
+// SetAPIKey switches to a rotated API key for subsequent requests
+func (t *WhisperTranscriber) SetAPIKey(apiKey string) {
+	t.keyMutex.Lock()
+	t.apiKey = apiKey
+	t.keyMutex.Unlock()
+	slog.Info("OpenAI API key rotated")
+}
+
+func (t *WhisperTranscriber) Transcribe(ctx context.Context, audioData []byte, mimeType, prompt string) (Transcription, error) {
+	fileName, err := whisperFileName(mimeType)
+	if err != nil {
+		return Transcription{}, err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		return Transcription{}, err
+	}
+	if _, err := file.Write(audioData); err != nil {
+		return Transcription{}, err
+	}
+	form.WriteField("model", whisperModel)
+	form.WriteField("response_format", "verbose_json")
+	if prompt != "" {
+		form.WriteField("prompt", prompt)
+	}
+	if err := form.Close(); err != nil {
+		return Transcription{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, openAITranscriptionURL, &body)
+	if err != nil {
+		return Transcription{}, err
+	}
+	t.keyMutex.RLock()
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	t.keyMutex.RUnlock()
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var result struct {
+		Text     string `json:"text"`
+		Segments []struct {
+			Start      float64 `json:"start"`
+			End        float64 `json:"end"`
+			AvgLogprob float64 `json:"avg_logprob"`
+		} `json:"segments"`
+	}
+	if err := doTranscriptionRequest(t.client, req, TranscriptionWhisper, &result); err != nil {
+		return Transcription{}, err
+	}
+
+	transcription := Transcription{Text: result.Text}
+	var weighted, duration float64
+	for _, segment := range result.Segments {
+		length := max(segment.End-segment.Start, 0.01)
+		weighted += segment.AvgLogprob * length
+		duration += length
+	}
+	if duration > 0 {
+		transcription.Confidence = logprobConfidence(weighted / duration)
+	}
+	logging.FromContext(ctx).Info("Audio transcribed with Whisper", "size", len(audioData), "transcript_length", len(result.Text))
+	return transcription, nil
+}
+
+// whisperFileName names the upload, as OpenAI tells the format from the file extension
+func whisperFileName(mimeType string) (string, error) {
+	switch baseMIME(mimeType) {
+	case "", "audio/webm", "video/webm":
+		return "audio.webm", nil
+	case "audio/ogg":
+		return "audio.ogg", nil
+	case "audio/mpeg", "audio/mp3":
+		return "audio.mp3", nil
+	case "audio/mp4", "audio/m4a", "audio/x-m4a":
+		return "audio.m4a", nil
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return "audio.wav", nil
+	case "audio/flac":
+		return "audio.flac", nil
+	}
+	return "", fmt.Errorf("whisper does not support %s audio", mimeType)
+}
+
+// DeepgramTranscriber transcribes with Deepgram. Deepgram takes no free-form prompt, so the
+// prompt is ignored; its own filtering already leaves silence out of the transcript.
+type DeepgramTranscriber struct {
+	apiKey   string
+	keyMutex sync.RWMutex
+	client   *http.Client
+}
+
+func NewDeepgramTranscriber(apiKey string) *DeepgramTranscriber {
+	return &DeepgramTranscriber{
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (t *DeepgramTranscriber) Name() string {
+	return TranscriptionDeepgram
+}
+
+// SetAPIKey switches to a rotated API key for subsequent requests
+func (t *DeepgramTranscriber) SetAPIKey(apiKey string) {
+	t.keyMutex.Lock()
+	t.apiKey = apiKey
+	t.keyMutex.Unlock()
+	slog.Info("Deepgram API key rotated")
+}
+
+func (t *DeepgramTranscriber) Transcribe(ctx context.Context, audioData []byte, mimeType, prompt string) (Transcription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, deepgramListenURL, bytes.NewReader(audioData))
+	if err != nil {
+		return Transcription{}, err
+	}
+	t.keyMutex.RLock()
+	req.Header.Set("Authorization", "Token "+t.apiKey)
+	t.keyMutex.RUnlock()
+	// Deepgram reads the container from the Content-Type, with parameters such as codecs
+	contentType := mimeType
+	if contentType == "" {
+		contentType = "audio/webm"
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	var result struct {
+		Results struct {
+			Channels []struct {
+				Alternatives []struct {
+					Transcript string  `json:"transcript"`
+					Confidence float64 `json:"confidence"`
+				} `json:"alternatives"`
+			} `json:"channels"`
+		} `json:"results"`
+	}
+	if err := doTranscriptionRequest(t.client, req, TranscriptionDeepgram, &result); err != nil {
+		return Transcription{}, err
+	}
+
+	var transcription Transcription
+	if channels := result.Results.Channels; len(channels) > 0 && len(channels[0].Alternatives) > 0 {
+		best := channels[0].Alternatives[0]
+		transcription.Text = best.Transcript
+		// Deepgram reports 0 for an empty transcript
+		if best.Transcript != "" {
+			confidence := best.Confidence
+			transcription.Confidence = &confidence
+		}
+	}
+	logging.FromContext(ctx).Info("Audio transcribed with Deepgram", "size", len(audioData), "transcript_length", len(transcription.Text))
+	return transcription, nil
+}
+
+// FallbackTranscriber tries its providers in order until one succeeds
+type FallbackTranscriber struct {
+	providers []TranscriptionProvider
+}
+
+func (t *FallbackTranscriber) Name() string {
+	names := make([]string, len(t.providers))
+	for i, provider := range t.providers {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (t *FallbackTranscriber) Transcribe(ctx context.Context, audioData []byte, mimeType, prompt string) (Transcription, error) {
+	var errs []error
+	for _, provider := range t.providers {
+		transcript, err := provider.Transcribe(ctx, audioData, mimeType, prompt)
+		if err == nil {
+			return transcript, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		// Out of time or the client left; the next provider would fail the same way
+		if ctx.Err() != nil {
+			break
+		}
+		logging.FromContext(ctx).Warn("Transcription provider failed, trying the next", "provider", provider.Name(), "error", err)
+	}
+	return Transcription{}, fmt.Errorf("all transcription providers failed: %w", errors.Join(errs...))
+}
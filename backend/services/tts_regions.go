@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultElevenLabsBaseURL = "https://api.elevenlabs.io"
+
+	ttsProbeInterval = 60 * time.Second // How often region health is re-checked
+	ttsProbeTimeout  = 3 * time.Second
+	ttsFailureLimit  = 2 // Consecutive failures before a region is marked unhealthy
+)
+
+// TTSRegion is a regional TTS API endpoint
+type TTSRegion struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"base_url"`
+}
+
+// TTSRegionStatus is the cached health of a region
+type TTSRegionStatus struct {
+	Region              TTSRegion     `json:"region"`
+	Healthy             bool          `json:"healthy"`
+	Latency             time.Duration `json:"latency"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	CheckedAt           time.Time     `json:"checked_at"`
+}
+
+// ParseTTSRegions parses a comma-separated "name=url" list, e.g. "us=https://api.elevenlabs.io,eu=https://api.eu.residency.elevenlabs.io".
+// An empty spec yields the single default region.
+func ParseTTSRegions(spec string) []TTSRegion {
+	var regions []TTSRegion
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, baseURL, found := strings.Cut(entry, "=")
+		if !found {
+			// Bare URL: use it as its own name
+			baseURL = name
+		}
+		baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+		if baseURL == "" {
+			slog.Warn("Ignoring TTS region without URL", "entry", entry)
+			continue
+		}
+		regions = append(regions, TTSRegion{Name: strings.TrimSpace(name), BaseURL: baseURL})
+	}
+
+	if len(regions) == 0 {
+		regions = []TTSRegion{{Name: "default", BaseURL: DefaultElevenLabsBaseURL}}
+	}
+	return regions
+}
+
+// TTSRegionSelector ranks regions by probed latency and health, with failover on errors
+type TTSRegionSelector struct {
+	apiKey  string
+	client  *http.Client
+	regions []TTSRegion
+	status  map[string]*TTSRegionStatus
+	mutex   sync.RWMutex
+}
+
+func NewTTSRegionSelector(apiKey string, regions []TTSRegion) *TTSRegionSelector {
+	selector := &TTSRegionSelector{
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: ttsProbeTimeout},
+		regions: regions,
+		status:  make(map[string]*TTSRegionStatus, len(regions)),
+	}
+
+	// Regions start healthy and in configured order until the first probe completes
+	for _, region := range regions {
+		selector.status[region.Name] = &TTSRegionStatus{Region: region, Healthy: true}
+	}
+
+	// Probing only matters when there is more than one region to choose from
+	if len(regions) > 1 {
+		go selector.startProbeLoop()
+	}
+
+	return selector
+}
+
+// Ranked returns regions ordered fastest healthy first; unhealthy regions are kept last as a final fallback
+func (s *TTSRegionSelector) Ranked() []TTSRegion {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ranked := make([]TTSRegion, len(s.regions))
+	copy(ranked, s.regions)
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := s.status[ranked[i].Name], s.status[ranked[j].Name]
+		if a.Healthy != b.Healthy {
+			return a.Healthy
+		}
+		// Unprobed regions (zero latency) keep their configured order
+		if a.Latency == 0 || b.Latency == 0 {
+			return false
+		}
+		return a.Latency < b.Latency
+	})
+	return ranked
+}
+
+// ReportSuccess records a successful request, refreshing the region's latency
+func (s *TTSRegionSelector) ReportSuccess(region TTSRegion, latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := s.status[region.Name]
+	status.Healthy = true
+	status.ConsecutiveFailures = 0
+	status.Latency = latency
+	status.CheckedAt = time.Now()
+}
+
+// ReportFailure records a failed request; the region is marked unhealthy after repeated failures
+func (s *TTSRegionSelector) ReportFailure(region TTSRegion) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := s.status[region.Name]
+	status.ConsecutiveFailures++
+	status.CheckedAt = time.Now()
+	if status.ConsecutiveFailures >= ttsFailureLimit && status.Healthy {
+		status.Healthy = false
+		slog.Warn("TTS region marked unhealthy", "region", region.Name, "failures", status.ConsecutiveFailures)
+	}
+}
+
+// Status returns the cached health of every region
+func (s *TTSRegionSelector) Status() []TTSRegionStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	statuses := make([]TTSRegionStatus, 0, len(s.regions))
+	for _, region := range s.regions {
+		statuses = append(statuses, *s.status[region.Name])
+	}
+	return statuses
+}
+
+func (s *TTSRegionSelector) startProbeLoop() {
+	s.probeAll()
+
+	ticker := time.NewTicker(ttsProbeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.probeAll()
+	}
+}
+
+// probeAll measures round-trip latency to every region in parallel
+func (s *TTSRegionSelector) probeAll() {
+	var wg sync.WaitGroup
+	for _, region := range s.regions {
+		wg.Add(1)
+		go func(region TTSRegion) {
+			defer wg.Done()
+			s.probe(region)
+		}(region)
+	}
+	wg.Wait()
+}
+
+// SetAPIKey updates the key used for probes after a rotation
+func (s *TTSRegionSelector) SetAPIKey(apiKey string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.apiKey = apiKey
+}
+
+func (s *TTSRegionSelector) probe(region TTSRegion) {
+	ctx, cancel := context.WithTimeout(context.Background(), ttsProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", region.BaseURL+"/v1/models", nil)
+	if err != nil {
+		s.ReportFailure(region)
+		return
+	}
+	s.mutex.RLock()
+	req.Header.Set("xi-api-key", s.apiKey)
+	s.mutex.RUnlock()
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		slog.Warn("TTS region probe failed", "region", region.Name, "error", err)
+		s.ReportFailure(region)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		slog.Warn("TTS region probe returned server error", "region", region.Name, "status", resp.StatusCode)
+		s.ReportFailure(region)
+		return
+	}
+
+	latency := time.Since(start)
+	s.ReportSuccess(region, latency)
+	slog.Debug("TTS region probed", "region", region.Name, "latency_ms", latency.Milliseconds())
+}
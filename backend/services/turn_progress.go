@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	ws "github.com/krshsl/praxis/backend/websocket"
+)
+
+// turnProgress reports the stages of a candidate's turn to the client, so the wait for a reply
+// isn't silent
+type turnProgress struct {
+	client  *ws.Client
+	started time.Time
+}
+
+type turnProgressKey struct{}
+
+// startTurn begins reporting a turn's progress. The returned context carries it to respond,
+// which reports speech synthesis only for turns; the welcome and other unprompted lines report
+// nothing.
+func startTurn(ctx context.Context, client *ws.Client) (context.Context, *turnProgress) {
+	progress := &turnProgress{client: client, started: time.Now()}
+	return context.WithValue(ctx, turnProgressKey{}, progress), progress
+}
+
+// turnProgressFrom returns the turn a context belongs to, or nil
+func turnProgressFrom(ctx context.Context) *turnProgress {
+	progress, _ := ctx.Value(turnProgressKey{}).(*turnProgress)
+	return progress
+}
+
+// report tells the client the turn has reached a stage. It is safe to call on nil.
+func (t *turnProgress) report(stage string) {
+	if t == nil {
+		return
+	}
+	data, err := ws.Encode(ws.TypeProgress, ws.ProgressPayload{
+		Stage:     stage,
+		ElapsedMs: time.Since(t.started).Milliseconds(),
+	})
+	if err != nil {
+		return
+	}
+	safeSend(t.client.Send, data)
+}
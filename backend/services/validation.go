@@ -0,0 +1,270 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// FieldError is one request field that failed validation
+type FieldError struct {
+	Field   string `json:"field"` // JSON name of the field
+	Rule    string `json:"rule"`  // The validate rule it broke, e.g. required or min
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every field of a request that failed validation
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validateRule is one rule of a validate tag, with its parameter: the bound for min, max and
+// maxbytes, or the options for oneof
+type validateRule struct {
+	name  string
+	param string
+	limit float64
+}
+
+// fieldRules are the rules of one field of a validated struct
+type fieldRules struct {
+	index int
+	name  string
+	rules []validateRule
+}
+
+// structRules caches each validated struct type's rules ([]fieldRules by reflect.Type)
+var structRules sync.Map
+
+// ValidateStruct checks a struct against its `validate` tags, returning nil if it passes.
+// The tags follow go-playground/validator's syntax for the rules requests use: required,
+// omitempty, min, max, oneof, email and uuid, plus maxbytes, which bounds a string's length in
+// bytes rather than characters. Nested structs aren't descended into. A malformed tag is an
+// error; CheckValidateTags finds those at startup.
+func ValidateStruct(v interface{}) (ValidationErrors, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	fields, err := rulesFor(value.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var errs ValidationErrors
+	for _, field := range fields {
+		if fieldErr := validateField(field.name, value.Field(field.index), field.rules); fieldErr != nil {
+			errs = append(errs, *fieldErr)
+		}
+	}
+	return errs, nil
+}
+
+// CheckValidateTags parses the validate tags of each request type, so a malformed tag stops
+// the server from starting rather than failing the first request
+func CheckValidateTags(requests ...interface{}) error {
+	for _, request := range requests {
+		if _, err := rulesFor(reflect.Indirect(reflect.ValueOf(request)).Type()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidatedRequests returns the request types with validate tags, for CheckValidateTags
+func ValidatedRequests() []interface{} {
+	return []interface{}{
+		AcceptLegalDocumentsRequest{},
+		BulkDeleteRequest{},
+		ConvertGuestRequest{},
+		CreateAgentRequest{},
+		CreateSessionNoteRequest{},
+		CreateSessionRequest{},
+		CreateSessionTokenRequest{},
+		ExportRequest{},
+		ImpersonateRequest{},
+		InterviewInviteRequest{},
+		LegalDocumentRequest{},
+		LoginRequest{},
+		RateSessionRequest{},
+		RevokeDeviceRequest{},
+		ScoringExperimentRequest{},
+		SignupRequest{},
+		StartInterviewInviteRequest{},
+	}
+}
+
+// rulesFor returns the rules of a struct type's fields, parsing its tags the first time
+func rulesFor(structType reflect.Type) ([]fieldRules, error) {
+	if cached, ok := structRules.Load(structType); ok {
+		return cached.([]fieldRules), nil
+	}
+	var fields []fieldRules
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+		rules, err := parseValidateTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", structType.Name(), field.Name, err)
+		}
+		fields = append(fields, fieldRules{index: i, name: jsonFieldName(field), rules: rules})
+	}
+	structRules.Store(structType, fields)
+	return fields, nil
+}
+
+// parseValidateTag splits a validate tag into its rules, checking each is known and has a valid
+// parameter
+func parseValidateTag(tag string) ([]validateRule, error) {
+	var rules []validateRule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(part, "=")
+		rule := validateRule{name: name, param: param}
+		switch name {
+		case "required", "omitempty", "email", "uuid":
+			if param != "" {
+				return nil, fmt.Errorf("validate rule %s takes no parameter", name)
+			}
+		case "min", "max", "maxbytes":
+			limit, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s=%q", name, param)
+			}
+			rule.limit = limit
+		case "oneof":
+			if strings.TrimSpace(param) == "" {
+				return nil, fmt.Errorf("oneof needs options")
+			}
+		default:
+			return nil, fmt.Errorf("unknown validate rule %q", name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// validateField checks one field against its rules, stopping at the first it breaks
+func validateField(name string, value reflect.Value, rules []validateRule) *FieldError {
+	for _, rule := range rules {
+		if rule.name == "omitempty" {
+			if value.IsZero() {
+				return nil
+			}
+			continue
+		}
+		if rule.name != "required" && value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+
+		var message string
+		switch rule.name {
+		case "required":
+			if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+				message = fmt.Sprintf("%s is required", name)
+			}
+		case "min", "max":
+			if size, unit, ok := fieldSize(value); ok {
+				if rule.name == "min" && size < rule.limit {
+					message = fmt.Sprintf("%s must be at least %s%s", name, rule.param, unit)
+				} else if rule.name == "max" && size > rule.limit {
+					message = fmt.Sprintf("%s must be at most %s%s", name, rule.param, unit)
+				}
+			}
+		case "maxbytes":
+			if value.Kind() == reflect.String && float64(len(value.String())) > rule.limit {
+				message = fmt.Sprintf("%s must be at most %s bytes", name, rule.param)
+			}
+		case "oneof":
+			options := strings.Fields(rule.param)
+			if !slices.Contains(options, fmt.Sprint(value.Interface())) {
+				message = fmt.Sprintf("%s must be one of %s", name, strings.Join(options, ", "))
+			}
+		case "email":
+			if address, err := mail.ParseAddress(value.String()); err != nil || address.Address != value.String() {
+				message = fmt.Sprintf("%s must be a valid email address", name)
+			}
+		case "uuid":
+			if _, err := uuid.Parse(value.String()); err != nil {
+				message = fmt.Sprintf("%s must be a valid UUID", name)
+			}
+		}
+		if message != "" {
+			return &FieldError{Field: name, Rule: rule.name, Param: rule.param, Message: message}
+		}
+	}
+	return nil
+}
+
+// fieldSize returns what min and max compare: a string's length in characters, a slice or map's
+// length, or a number's value
+func fieldSize(value reflect.Value) (float64, string, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), " characters", true
+	case reflect.Slice, reflect.Map:
+		return float64(value.Len()), " items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), "", true
+	}
+	return 0, "", false
+}
+
+// jsonFieldName returns the name a field has in request bodies
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// decodeRequest decodes a JSON request body into dst and validates it, writing the error
+// response if either fails: 400 for a malformed body, and 422 listing the failing fields
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return false
+	}
+
+	errs, err := ValidateStruct(dst)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to validate request", "error", err)
+		apperrors.Write(w, r, apperrors.Internal("Failed to validate request"))
+		return false
+	}
+	if len(errs) == 0 {
+		return true
+	}
+	apperrors.Write(w, r, apperrors.Unprocessable(errs.Error()).
+		WithCode(apperrors.CodeValidation).
+		WithDetails(map[string]interface{}{"fields": errs}))
+	return false
+}
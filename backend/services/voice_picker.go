@@ -23,7 +23,14 @@ var maleVoices = []string{
 	"bVMeCyTHy58xNoL34h3p", // Clyde
 }
 
-// PickDeterministicVoice returns a stock ElevenLabs voice ID based on name and gender
+// maxVoiceIDLength matches the size of Agent.VoiceID
+const maxVoiceIDLength = 32
+
+// agentGenders are the genders an agent may have; other and unset pick from every voice
+var agentGenders = map[string]bool{"": true, "male": true, "female": true, "other": true}
+
+// PickDeterministicVoice returns a stock ElevenLabs voice ID based on name and gender, so an
+// agent keeps the same voice across sessions
 func PickDeterministicVoice(name, gender string) string {
 	var pool []string
 	switch strings.ToLower(gender) {
@@ -32,7 +39,8 @@ func PickDeterministicVoice(name, gender string) string {
 	case "male":
 		pool = maleVoices
 	default:
-		pool = append(femaleVoices, maleVoices...)
+		pool = make([]string, 0, len(femaleVoices)+len(maleVoices))
+		pool = append(append(pool, femaleVoices...), maleVoices...)
 	}
 	if len(pool) == 0 {
 		return "pNInz6obpgDQGcFmaJgB" // fallback Adam
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// scoredTranscripts drops warm-up turns so they never reach summary or rubric evaluation
+func scoredTranscripts(transcripts []models.InterviewTranscript) []models.InterviewTranscript {
+	scored := make([]models.InterviewTranscript, 0, len(transcripts))
+	for _, transcript := range transcripts {
+		if transcript.Phase == models.TranscriptPhaseWarmup {
+			continue
+		}
+		scored = append(scored, transcript)
+	}
+	return scored
+}
+
+// turnPhase returns the phase of the candidate's next turn and whether it is the last warm-up turn
+func (p *AIMessageProcessor) turnPhase(sessionID string) (string, bool) {
+	userTurns := p.timeoutService.CountUserTurns(sessionID)
+	if userTurns < p.warmupTurns {
+		return models.TranscriptPhaseWarmup, userTurns == p.warmupTurns-1
+	}
+	return models.TranscriptPhaseInterview, false
+}
+
+// generateReply produces the agent's reply for the current phase and the phase to record it under
+func (p *AIMessageProcessor) generateReply(ctx context.Context, sessionID string, agent *models.Agent, userMessage string, history []models.InterviewTranscript, phase string, finalWarmup bool) (string, string, error) {
+	if phase != models.TranscriptPhaseWarmup {
+		// A stage that has used its budget hands over before the reply, which opens the next stage
+		transition := ""
+		if p.timeoutService.GetClosingStage(sessionID) == ClosingStageNone {
+			transition = p.flows.Advance(sessionID)
+			p.pacingNudge(sessionID, agent, userMessage)
+			p.adaptDifficulty(sessionID, agent, userMessage)
+		}
+		response, err := p.screened(ctx, sessionID, agent, func() (string, error) {
+			if question, ok := p.timeoutService.TakeNextQuestion(sessionID); ok {
+				return question, nil
+			}
+			llmCtx, cancel := withTimeout(ctx, p.timeouts.LLM)
+			defer cancel()
+			return p.llm.GenerateInterviewResponse(llmCtx, sessionID, agent, userMessage, history)
+		})
+		if err == nil {
+			response = p.flows.Signal(sessionID, response)
+			p.explanations.ExplainQuestion(sessionID, agent, history, userMessage, response)
+			if transition != "" {
+				response = transition + " " + response
+			}
+		}
+		return response, models.TranscriptPhaseInterview, err
+	}
+
+	response, err := p.screened(ctx, sessionID, agent, func() (string, error) {
+		if question, ok := p.timeoutService.TakeNextQuestion(sessionID); ok {
+			return question, nil
+		}
+		llmCtx, cancel := withTimeout(ctx, p.timeouts.LLM)
+		defer cancel()
+		return p.llm.GenerateWarmupResponse(llmCtx, sessionID, agent, userMessage, history, finalWarmup)
+	})
+	// The reply to the last warm-up turn carries the first real question
+	replyPhase := models.TranscriptPhaseWarmup
+	if finalWarmup {
+		replyPhase = models.TranscriptPhaseInterview
+		p.flows.Begin(sessionID)
+		if err == nil {
+			p.explanations.ExplainQuestion(sessionID, agent, history, userMessage, response)
+		}
+	}
+	return response, replyPhase, err
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krshsl/praxis/backend/apperrors"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	maxWebhooksPerUser       = 10
+	maxWebhookDescription    = 200
+	defaultWebhookDeliveries = 50
+	maxWebhookDeliveries     = 200
+)
+
+type WebhookRequest struct {
+	URL          string   `json:"url"`
+	Events       []string `json:"events"`
+	AgentID      *string  `json:"agent_id,omitempty"` // Only this agent's sessions; all the user's agents if omitted
+	Description  string   `json:"description,omitempty"`
+	Enabled      *bool    `json:"enabled,omitempty"` // Defaults to true
+	RotateSecret bool     `json:"rotate_secret,omitempty"`
+}
+
+type WebhookEndpoints struct {
+	repo     *repository.GORMRepository
+	webhooks *WebhookService
+}
+
+func NewWebhookEndpoints(repo *repository.GORMRepository, webhooks *WebhookService) *WebhookEndpoints {
+	return &WebhookEndpoints{repo: repo, webhooks: webhooks}
+}
+
+func (e *WebhookEndpoints) RegisterRoutes(r chi.Router) {
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", e.GetWebhooksHandler)
+		r.Post("/", e.CreateWebhookHandler)
+		r.Get("/{id}", e.GetWebhookHandler)
+		r.Put("/{id}", e.UpdateWebhookHandler)
+		r.Delete("/{id}", e.DeleteWebhookHandler)
+		r.Get("/{id}/deliveries", e.GetDeliveriesHandler)
+		r.Post("/{id}/deliveries/{deliveryID}/redeliver", e.RedeliverHandler)
+	})
+}
+
+func (e *WebhookEndpoints) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	webhooks, err := e.repo.GetUserWebhooks(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get webhooks"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhooks": webhooks,
+		"count":    len(webhooks),
+	})
+}
+
+// CreateWebhookHandler subscribes a URL to lifecycle events of the user's agents' sessions. The
+// signing secret is only returned here and when it is rotated.
+func (e *WebhookEndpoints) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+
+	count, err := e.repo.CountUserWebhooks(r.Context(), user.ID)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to count webhooks"))
+		return
+	}
+	if count >= maxWebhooksPerUser {
+		apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("At most %d webhooks are allowed", maxWebhooksPerUser)))
+		return
+	}
+
+	webhook := &models.Webhook{UserID: user.ID}
+	if !e.applyRequest(w, r, user, webhook, req) {
+		return
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to generate webhook secret"))
+		return
+	}
+	webhook.Secret = secret
+
+	if err := e.repo.CreateWebhook(r.Context(), webhook); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to create webhook"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Webhook created", "webhook_id", webhook.ID, "events", webhook.Events)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhook": webhook,
+		"secret":  secret,
+		"message": "Webhook created",
+	})
+}
+
+func (e *WebhookEndpoints) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := e.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhook": webhook,
+	})
+}
+
+func (e *WebhookEndpoints) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := e.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	user := r.Context().Value("user").(*models.User)
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest("Invalid request body"))
+		return
+	}
+	if !e.applyRequest(w, r, user, webhook, req) {
+		return
+	}
+
+	secret := ""
+	if req.RotateSecret {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to generate webhook secret"))
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	if err := e.repo.SaveWebhook(r.Context(), webhook); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to save webhook"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Webhook updated", "user_id", user.ID, "webhook_id", webhook.ID, "enabled", webhook.Enabled, "events", webhook.Events)
+
+	response := map[string]interface{}{
+		"webhook": webhook,
+		"message": "Webhook updated",
+	}
+	if secret != "" {
+		response["secret"] = secret
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (e *WebhookEndpoints) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := e.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.repo.DeleteWebhook(r.Context(), webhook.ID); err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to delete webhook"))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Webhook deleted", "user_id", webhook.UserID, "webhook_id", webhook.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Webhook deleted",
+	})
+}
+
+// GetDeliveriesHandler lists a webhook's recent deliveries with their payloads, attempts and the
+// latest response, for debugging receivers. ?status= filters by pending, delivered or failed;
+// ?limit= caps the count.
+func (e *WebhookEndpoints) GetDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := e.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryFailed:
+	default:
+		apperrors.Write(w, r, apperrors.BadRequest("status must be pending, delivered or failed"))
+		return
+	}
+	limit := defaultWebhookDeliveries
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			apperrors.Write(w, r, apperrors.BadRequest("Invalid limit"))
+			return
+		}
+		limit = min(parsed, maxWebhookDeliveries)
+	}
+
+	deliveries, err := e.repo.GetWebhookDeliveries(r.Context(), webhook.ID, status, limit)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get webhook deliveries"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// RedeliverHandler sends one of a webhook's deliveries again, e.g. once a failing receiver is
+// fixed
+func (e *WebhookEndpoints) RedeliverHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := e.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	if !webhook.Enabled {
+		apperrors.Write(w, r, apperrors.BadRequest("Enable the webhook before redelivering"))
+		return
+	}
+
+	queued, err := e.webhooks.Redeliver(r.Context(), webhook.ID, chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to redeliver"))
+		return
+	}
+	if !queued {
+		apperrors.Write(w, r, apperrors.NotFound("Delivery not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Delivery queued",
+	})
+}
+
+// ownedWebhook loads a webhook the current user owns, writing the error response if there isn't one
+func (e *WebhookEndpoints) ownedWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apperrors.Write(w, r, apperrors.Internal("User not found in context"))
+		return nil, false
+	}
+
+	webhook, err := e.repo.GetWebhook(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal("Failed to get webhook"))
+		return nil, false
+	}
+	if webhook == nil || webhook.UserID != user.ID {
+		apperrors.Write(w, r, apperrors.NotFound("Webhook not found"))
+		return nil, false
+	}
+	return webhook, true
+}
+
+// applyRequest validates a create or update request and applies it to the webhook, writing the
+// error response if it is invalid
+func (e *WebhookEndpoints) applyRequest(w http.ResponseWriter, r *http.Request, user *models.User, webhook *models.Webhook, req WebhookRequest) bool {
+	if err := ValidateWebhookURL(req.URL); err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return false
+	}
+	events, err := ParseWebhookEvents(req.Events)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return false
+	}
+	description := strings.TrimSpace(req.Description)
+	if len([]rune(description)) > maxWebhookDescription {
+		apperrors.Write(w, r, apperrors.BadRequest(fmt.Sprintf("description must be at most %d characters", maxWebhookDescription)))
+		return false
+	}
+
+	if req.AgentID != nil && *req.AgentID != "" {
+		agent, err := e.repo.GetAgent(r.Context(), *req.AgentID)
+		if err != nil {
+			apperrors.Write(w, r, apperrors.Internal("Failed to get agent"))
+			return false
+		}
+		if agent == nil || agent.UserID == nil || *agent.UserID != user.ID {
+			apperrors.Write(w, r, apperrors.BadRequest("agent_id must be one of your agents"))
+			return false
+		}
+		webhook.AgentID = &agent.ID
+	} else {
+		webhook.AgentID = nil
+	}
+
+	webhook.URL = req.URL
+	webhook.Events = events
+	webhook.Description = description
+	webhook.Enabled = req.Enabled == nil || *req.Enabled
+	return true
+}
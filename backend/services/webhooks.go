@@ -0,0 +1,352 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+	"github.com/krshsl/praxis/backend/repository"
+)
+
+const (
+	// MaxWebhookAttempts is how many times a delivery is tried before it is marked failed
+	MaxWebhookAttempts = 8
+	// webhookRetryBackoff is the delay after the first failed attempt, doubling each attempt
+	webhookRetryBackoff = 30 * time.Second
+	// webhookDeliveryLease is how long a claimed delivery is held before another attempt may
+	// take it, should the instance sending it die
+	webhookDeliveryLease  = time.Minute
+	webhookPollInterval   = 5 * time.Second
+	webhookTimeout        = 10 * time.Second
+	maxWebhookErrorLength = 500
+)
+
+// Headers sent with every webhook delivery, besides the AgentDigestSignatureHeader signature
+const (
+	WebhookEventHeader    = "X-Praxis-Event"
+	WebhookDeliveryHeader = "X-Praxis-Delivery"
+)
+
+// webhookEvents lists the events webhooks can subscribe to, in the order they happen
+var webhookEvents = []string{
+	models.WebhookEventSessionStarted,
+	models.WebhookEventSessionCompleted,
+	models.WebhookEventSummaryGenerated,
+}
+
+// WebhookPayload is the body POSTed to a webhook. The ID is the delivery's and stays the same
+// across retries, so receivers can drop duplicates.
+type WebhookPayload struct {
+	ID         string             `json:"id"`
+	Event      string             `json:"event"`
+	OccurredAt time.Time          `json:"occurred_at"`
+	Data       WebhookSessionData `json:"data"`
+}
+
+// WebhookSessionData describes the session an event is about
+type WebhookSessionData struct {
+	SessionID string           `json:"session_id"`
+	AgentID   string           `json:"agent_id"`
+	AgentName string           `json:"agent_name"`
+	Candidate WebhookCandidate `json:"candidate"`
+	Status    string           `json:"status"`
+	StartedAt time.Time        `json:"started_at"`
+	EndedAt   *time.Time       `json:"ended_at,omitempty"`
+	Duration  int              `json:"duration,omitempty"`  // Seconds
+	TimedOut  bool             `json:"timed_out,omitempty"` // Ended because the candidate went inactive
+	Summary   *WebhookSummary  `json:"summary,omitempty"`   // summary.generated only
+}
+
+type WebhookCandidate struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type WebhookSummary struct {
+	ID           string  `json:"id"`
+	OverallScore float64 `json:"overall_score"`
+	Passed       *bool   `json:"passed,omitempty"`
+	Summary      string  `json:"summary"`
+}
+
+// ParseWebhookEvents checks the events a webhook subscribes to, returning them comma-separated
+// in the order they happen
+func ParseWebhookEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		return "", fmt.Errorf("at least one event is required: %s", strings.Join(webhookEvents, ", "))
+	}
+	for _, event := range events {
+		if !slices.Contains(webhookEvents, event) {
+			return "", fmt.Errorf("unknown event %q: must be one of %s", event, strings.Join(webhookEvents, ", "))
+		}
+	}
+	var subscribed []string
+	for _, event := range webhookEvents {
+		if slices.Contains(events, event) {
+			subscribed = append(subscribed, event)
+		}
+	}
+	return strings.Join(subscribed, ","), nil
+}
+
+// webhookSubscribes reports whether a webhook subscribes to an event
+func webhookSubscribes(webhook models.Webhook, event string) bool {
+	return slices.Contains(strings.Split(webhook.Events, ","), event)
+}
+
+// WebhookService sends session lifecycle events to the webhooks of the agent's owner. Each
+// event is stored as a delivery per webhook and sent by a background worker, which retries
+// failures with backoff.
+type WebhookService struct {
+	repo   *repository.GORMRepository
+	client *http.Client
+	wake   chan struct{}
+}
+
+func NewWebhookService(repo *repository.GORMRepository) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		client: newPublicHTTPClient(webhookTimeout),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Start runs the delivery worker until shutdown
+func (s *WebhookService) Start(lifecycle *Lifecycle) {
+	lifecycle.Go("webhook deliveries", func(ctx context.Context) {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+
+		for {
+			for ctx.Err() == nil && s.deliverNext(ctx) {
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.wake:
+			case <-ticker.C:
+			}
+		}
+	})
+	slog.Info("Webhook delivery worker started", "max_attempts", MaxWebhookAttempts)
+}
+
+// HandleSessionStarted queues session.started deliveries
+func (s *WebhookService) HandleSessionStarted(ctx context.Context, event Event) error {
+	return s.queue(ctx, event, models.WebhookEventSessionStarted, nil)
+}
+
+// HandleSessionConcluded queues session.completed deliveries
+func (s *WebhookService) HandleSessionConcluded(ctx context.Context, event Event) error {
+	var payload SessionConcludedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	return s.queue(ctx, event, models.WebhookEventSessionCompleted, func(data *WebhookSessionData) error {
+		data.TimedOut = payload.TimedOut
+		return nil
+	})
+}
+
+// HandleSummaryGenerated queues summary.generated deliveries, with the summary's score
+func (s *WebhookService) HandleSummaryGenerated(ctx context.Context, event Event) error {
+	return s.queue(ctx, event, models.WebhookEventSummaryGenerated, func(data *WebhookSessionData) error {
+		summary, err := s.repo.GetInterviewSummary(ctx, event.SessionID)
+		if err != nil {
+			return err
+		}
+		if summary != nil {
+			data.Summary = &WebhookSummary{
+				ID:           summary.ID,
+				OverallScore: summary.OverallScore,
+				Passed:       summary.Passed,
+				Summary:      summary.Summary,
+			}
+		}
+		return nil
+	})
+}
+
+// queue stores a delivery of the event for each of the owner's webhooks subscribed to it.
+// decorate adds what is particular to the event.
+func (s *WebhookService) queue(ctx context.Context, event Event, name string, decorate func(data *WebhookSessionData) error) error {
+	session, err := s.repo.GetInterviewSession(ctx, event.SessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return nil
+	}
+	agent, err := s.repo.GetAgent(ctx, session.AgentID)
+	if err != nil {
+		return err
+	}
+	if agent == nil || agent.UserID == nil {
+		return nil
+	}
+
+	webhooks, err := s.repo.GetAgentWebhooks(ctx, *agent.UserID, agent.ID)
+	if err != nil {
+		return err
+	}
+	webhooks = slices.DeleteFunc(webhooks, func(webhook models.Webhook) bool {
+		return !webhookSubscribes(webhook, name)
+	})
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	candidate, err := s.repo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+	data := WebhookSessionData{
+		SessionID: session.ID,
+		AgentID:   agent.ID,
+		AgentName: agent.Name,
+		Status:    session.Status,
+		StartedAt: session.StartedAt,
+		EndedAt:   session.EndedAt,
+		Duration:  session.Duration,
+	}
+	if candidate != nil {
+		data.Candidate = WebhookCandidate{ID: candidate.ID, Name: candidate.FullName, Email: candidate.Email}
+	}
+	if decorate != nil {
+		if err := decorate(&data); err != nil {
+			return err
+		}
+	}
+
+	eventID := event.ID
+	if eventID == "" {
+		eventID = uuid.NewString()
+	}
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		payload := WebhookPayload{ID: uuid.NewString(), Event: name, OccurredAt: event.OccurredAt, Data: data}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:            payload.ID,
+			WebhookID:     webhook.ID,
+			EventID:       eventID,
+			Event:         name,
+			SessionID:     session.ID,
+			Payload:       string(body),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	queued, err := s.repo.CreateWebhookDeliveries(ctx, deliveries)
+	if err != nil {
+		return err
+	}
+	if queued > 0 {
+		s.notify()
+		logging.FromContext(ctx).Info("Webhook deliveries queued", "event", name, "session_id", session.ID, "count", queued)
+	}
+	return nil
+}
+
+// Redeliver queues one of a webhook's deliveries to be sent again, with a fresh set of attempts
+func (s *WebhookService) Redeliver(ctx context.Context, webhookID, deliveryID string) (bool, error) {
+	queued, err := s.repo.RetryWebhookDelivery(ctx, webhookID, deliveryID)
+	if err != nil || !queued {
+		return false, err
+	}
+	s.notify()
+	logging.FromContext(ctx).Info("Webhook delivery requeued", "webhook_id", webhookID, "delivery_id", deliveryID)
+	return true, nil
+}
+
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverNext claims and sends one due delivery, reporting whether there was one
+func (s *WebhookService) deliverNext(ctx context.Context) bool {
+	logger := logging.FromContext(ctx)
+	delivery, err := s.repo.ClaimWebhookDelivery(ctx, webhookDeliveryLease)
+	if err != nil || delivery == nil {
+		return false
+	}
+
+	webhook, err := s.repo.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		// Left leased; the attempt is retried once the lease runs out
+		return true
+	}
+	if webhook == nil || !webhook.Enabled {
+		s.repo.FinishWebhookDelivery(ctx, delivery.ID, models.WebhookDeliveryFailed, 0, "webhook was deleted or disabled", time.Time{})
+		return true
+	}
+
+	statusCode, err := s.post(ctx, webhook, delivery)
+	switch {
+	case err == nil:
+		s.repo.FinishWebhookDelivery(ctx, delivery.ID, models.WebhookDeliveryDelivered, statusCode, "", time.Time{})
+		logger.Info("Webhook delivered", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "event", delivery.Event, "attempts", delivery.Attempts)
+	case delivery.Attempts >= MaxWebhookAttempts:
+		logger.Warn("Webhook delivery failed", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		s.repo.FinishWebhookDelivery(ctx, delivery.ID, models.WebhookDeliveryFailed, statusCode, truncateWebhookError(err), time.Time{})
+	default:
+		// Same doubling schedule as summary retries, capped at maxSummaryRetryBackoff
+		retryAt := time.Now().Add(SummaryRetryBackoff(webhookRetryBackoff, delivery.Attempts))
+		logger.Debug("Webhook attempt failed, retrying", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "retry_at", retryAt, "error", err)
+		s.repo.FinishWebhookDelivery(ctx, delivery.ID, models.WebhookDeliveryPending, statusCode, truncateWebhookError(err), retryAt)
+	}
+	return true
+}
+
+// post sends a delivery signed with the webhook's secret, returning the response status. Only
+// a 2xx response counts as delivered; the start of any other response is kept in the error
+// for the delivery log.
+func (s *WebhookService) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AgentDigestSignatureHeader, "sha256="+signDigest(webhook.Secret, body))
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorLength))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %d: %s", resp.StatusCode, strings.TrimSpace(string(response)))
+	}
+	return resp.StatusCode, nil
+}
+
+func truncateWebhookError(err error) string {
+	message := err.Error()
+	if len(message) > maxWebhookErrorLength {
+		message = message[:maxWebhookErrorLength]
+	}
+	return message
+}
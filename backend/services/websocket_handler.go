@@ -1,11 +1,8 @@
 package services
 
 import (
-	"encoding/base64"
-	"encoding/json"
-	"log/slog"
-	"time"
-
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
 	ws "github.com/krshsl/praxis/backend/websocket"
 )
 
@@ -26,124 +23,87 @@ func safeSend(ch chan<- []byte, msg []byte) {
 
 type WebSocketHandler struct {
 	aiMessageProcessor *AIMessageProcessor
-	timeoutService     *SessionTimeoutService
+	clientErrors       *ClientErrorService
+	proctoring         *ProctoringService
 }
 
-func NewWebSocketHandler(aiMessageProcessor *AIMessageProcessor, timeoutService *SessionTimeoutService) *WebSocketHandler {
+func NewWebSocketHandler(aiMessageProcessor *AIMessageProcessor, clientErrors *ClientErrorService, proctoring *ProctoringService) *WebSocketHandler {
 	return &WebSocketHandler{
 		aiMessageProcessor: aiMessageProcessor,
-		timeoutService:     timeoutService,
+		clientErrors:       clientErrors,
+		proctoring:         proctoring,
 	}
 }
 
 // HandleWebSocketConnection handles the initial WebSocket connection and auto-starts the interview
 func (h *WebSocketHandler) HandleWebSocketConnection(client *ws.Client) {
-	slog.Info("WebSocket connection handled", "user_id", client.UserID, "session_id", client.SessionID)
+	logging.FromContext(client.Context()).Info("WebSocket connection handled")
 
 	// Auto-start the interview
-	if h.aiMessageProcessor != nil {
-		h.aiMessageProcessor.AutoStartInterview(client)
-	} else {
-		slog.Warn("AI message processor not available for auto-start", "session_id", client.SessionID)
-	}
+	h.aiMessageProcessor.AutoStartInterview(client)
+}
+
+// HandleWebSocketDisconnect cleans up after a client's connection closes
+func (h *WebSocketHandler) HandleWebSocketDisconnect(client *ws.Client) {
+	h.aiMessageProcessor.HandleDisconnect(client)
 }
 
-// HandleWebSocketMessage processes incoming WebSocket messages and routes them to AI processing
-func (h *WebSocketHandler) HandleWebSocketMessage(client *ws.Client, messageBytes []byte) {
-	var msg ws.Message
-	if err := json.Unmarshal(messageBytes, &msg); err != nil {
-		slog.Error("Failed to unmarshal WebSocket message", "error", err)
+// HandleWebSocketMessage validates an incoming message's payload and routes it to AI
+// processing. Invalid payloads are answered with an invalid_payload error.
+func (h *WebSocketHandler) HandleWebSocketMessage(client *ws.Client, env ws.Envelope) {
+	logger := logging.FromContext(client.Context())
+	payload, perr := ws.DecodePayload(env)
+	if perr != nil {
+		logger.Warn("Invalid WebSocket payload", "type", env.Type, "code", perr.Code, "error", perr.Message)
+		client.SendError(perr.Code, perr.Message)
 		return
 	}
 
-	slog.Info("WebSocket message received", "type", msg.Type, "user_id", client.UserID, "session_id", client.SessionID)
+	// Heartbeats arrive every few seconds, so they are handled before the message is logged
+	if _, ok := payload.(ws.HeartbeatPayload); ok {
+		h.proctoring.Heartbeat(client.Context(), client.SessionID)
+		return
+	}
 
-	// Route message to appropriate AI processor
-	switch msg.Type {
-	case "text":
-		if h.aiMessageProcessor != nil {
-			h.aiMessageProcessor.ProcessTextMessage(client, msg.Content)
-		} else {
-			slog.Warn("AI message processor not available", "session_id", client.SessionID)
-		}
-	case "code":
-		if h.aiMessageProcessor != nil {
-			h.aiMessageProcessor.ProcessCodeMessage(client, msg.Content, msg.Language)
-		} else {
-			slog.Warn("AI message processor not available", "session_id", client.SessionID)
-		}
-	case "audio":
-		// Handle both binary and Base64 audio data
-		var audioData []byte
-		if len(msg.AudioData) > 0 {
-			// Binary audio data
-			audioData = msg.AudioData
-		} else if msg.AudioDataBase64 != "" {
-			// Base64 audio data from frontend
-			decoded, err := base64.StdEncoding.DecodeString(msg.AudioDataBase64)
-			if err != nil {
-				slog.Error("Failed to decode Base64 audio data", "error", err, "session_id", client.SessionID)
-				return
-			}
-			audioData = decoded
-		} else {
-			slog.Error("No audio data provided", "session_id", client.SessionID)
-			return
-		}
+	logger.Info("WebSocket message received", "type", env.Type)
 
-		slog.Info("Audio message routed", "session_id", client.SessionID, "audio_size", len(audioData))
-		if h.aiMessageProcessor != nil {
-			h.aiMessageProcessor.ProcessAudioMessage(client, audioData)
-		} else {
-			slog.Warn("AI message processor not available", "session_id", client.SessionID)
-		}
-	case "audio_chunk":
-		// Handle chunked audio data
-		var audioData []byte
-		if len(msg.AudioData) > 0 {
-			// Binary audio data
-			audioData = msg.AudioData
-		} else if msg.AudioDataBase64 != "" {
-			// Base64 audio data from frontend
-			decoded, err := base64.StdEncoding.DecodeString(msg.AudioDataBase64)
-			if err != nil {
-				slog.Error("Failed to decode Base64 audio chunk data", "error", err, "session_id", client.SessionID)
-				return
-			}
-			audioData = decoded
-		} else {
-			slog.Error("No audio chunk data provided", "session_id", client.SessionID)
-			return
-		}
+	// A text interview's candidate may have no microphone, and its replies aren't spoken
+	if client.TextMode && (env.Type == ws.TypeAudio || env.Type == ws.TypeAudioChunk) {
+		client.SendError(ws.ErrCodeTextMode, textModeNotice)
+		return
+	}
 
-		slog.Info("Audio chunk routed", "session_id", client.SessionID, "chunk_index", msg.ChunkIndex, "total_chunks", msg.TotalChunks)
-		if h.aiMessageProcessor != nil {
-			h.aiMessageProcessor.ProcessAudioChunk(client, audioData, msg.ChunkIndex, msg.TotalChunks, msg.IsLastChunk)
-		} else {
-			slog.Warn("AI message processor not available", "session_id", client.SessionID)
-		}
-	case "end_session":
-		// End the session politely and generate summary
-		slog.Info("Received end_session request", "session_id", client.SessionID)
-		// Send confirmation message to client
-		endMsg := map[string]any{
-			"type":    "end_session",
-			"content": "Thank you for your time. We'll wrap up the session and prepare your summary.",
-		}
-		if b, err := json.Marshal(endMsg); err == nil {
-			safeSend(client.Send, b)
+	// Route message to appropriate AI processor
+	switch p := payload.(type) {
+	case ws.TextPayload:
+		h.aiMessageProcessor.ProcessTextMessage(client, p.Content, p.Metrics)
+	case ws.CodePayload:
+		h.aiMessageProcessor.ProcessCodeMessage(client, p.Content, p.Language)
+	case ws.AudioPayload:
+		logger.Info("Audio message routed", "audio_size", len(p.AudioData))
+		h.aiMessageProcessor.ProcessAudioMessage(client, p.AudioData, p.Timing)
+	case ws.AudioChunkPayload:
+		logger.Info("Audio chunk routed", "chunk_index", p.ChunkIndex, "total_chunks", p.TotalChunks)
+		h.aiMessageProcessor.ProcessAudioChunk(client, p.AudioData, p.ChunkIndex, p.TotalChunks, p.IsLastChunk, p.Timing)
+	case ws.NotePayload:
+		h.aiMessageProcessor.SaveNote(client, p.Content)
+	case ws.ClientErrorPayload:
+		report := ClientErrorReport{Kind: p.Kind, Message: p.Message, Context: p.Context}
+		if err := report.Validate(); err != nil {
+			client.SendError(ws.ErrCodeInvalidPayload, err.Error())
+			return
 		}
-		if h.timeoutService != nil {
-			h.timeoutService.ConcludeSession(client.SessionID, "User ended interview")
+		sessionID := client.SessionID
+		h.clientErrors.Record(client.Context(), client.UserID, &sessionID, report, models.ClientErrorSourceWebSocket, client.UserAgent)
+	case ws.ProctorEventPayload:
+		if err := h.proctoring.RecordEvent(client.Context(), client.SessionID, p); err != nil {
+			client.SendError(ws.ErrCodeInvalidPayload, err.Error())
 		}
-		// Close the WebSocket connection after a short delay to allow the message to be sent
-		go func() {
-			// Wait 200ms to ensure message is sent
-			// (tune as needed for your infra)
-			<-time.After(200 * time.Millisecond)
-			client.Conn.Close()
-		}()
-	default:
-		slog.Warn("Unknown message type", "type", msg.Type, "session_id", client.SessionID)
+	case ws.TakeoverPayload:
+		client.SendError(ws.ErrCodeNotAllowed, "Only observers can take over the interviewer")
+	case ws.EndSessionPayload:
+		// End the session politely: sign off, announce the summary, then finalize
+		logger.Info("Received end_session request")
+		h.aiMessageProcessor.EndSession(client, "User ended interview")
 	}
 }
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+	"github.com/krshsl/praxis/backend/models"
+)
+
+// welcomeTimeout bounds generating a welcome while the candidate waits; slower generations
+// fall back to the template
+const welcomeTimeout = 4 * time.Second
+
+// welcomeEndings close the welcome, in each supported interview language: the warm-up
+// ice-breaker and the invitation to introduce themselves
+var welcomeEndings = map[string][2]string{
+	"en": {"Before we dive in, let's take a moment to settle in - how has your day been going so far?", "Let's start with a brief introduction - could you tell me about yourself and what brings you to this interview?"},
+	"es": {"Antes de empezar, tomemos un momento para ponernos cómodos: ¿qué tal va tu día?", "Empecemos con una breve presentación: ¿podrías hablarme de ti y de lo que te trae a esta entrevista?"},
+	"fr": {"Avant de commencer, prenons un moment pour nous installer : comment se passe votre journée jusqu'ici ?", "Commençons par une brève présentation : pourriez-vous me parler de vous et de ce qui vous amène à cet entretien ?"},
+	"de": {"Bevor wir loslegen, nehmen wir uns einen Moment Zeit: Wie läuft Ihr Tag bisher?", "Beginnen wir mit einer kurzen Vorstellung: Könnten Sie etwas über sich erzählen und was Sie zu diesem Gespräch führt?"},
+	"it": {"Prima di iniziare, prendiamoci un momento: come sta andando la tua giornata finora?", "Iniziamo con una breve presentazione: potresti parlarmi di te e di cosa ti porta a questo colloquio?"},
+	"pt": {"Antes de começarmos, vamos nos acomodar: como está sendo o seu dia até agora?", "Vamos começar com uma breve apresentação: você poderia me falar sobre você e o que o traz a esta entrevista?"},
+}
+
+// welcomeGreetings are the template introductions used when a greeting can't be generated
+var welcomeGreetings = map[string]string{
+	"en": "Hello! I'm %s, and I'll be conducting your %s interview today.",
+	"es": "¡Hola! Soy %s y hoy conduciré tu entrevista de %s.",
+	"fr": "Bonjour ! Je suis %s et je mènerai votre entretien de %s aujourd'hui.",
+	"de": "Hallo! Ich bin %s und führe heute Ihr Gespräch im Bereich %s.",
+	"it": "Ciao! Sono %s e oggi condurrò il tuo colloquio di %s.",
+	"pt": "Olá! Eu sou %s e vou conduzir a sua entrevista de %s hoje.",
+}
+
+// WelcomeService produces the spoken welcome that opens an interview, in the agent's
+// personality and the session's language. Greetings are generated once per agent and
+// language and their speech is pre-rendered so later sessions start instantly.
+type WelcomeService struct {
+	llm       LLMService
+	speech    SpeechService
+	audio     *AudioCache
+	greetings map[string]string
+	mutex     sync.RWMutex
+}
+
+func NewWelcomeService(llm LLMService, speech SpeechService, audio *AudioCache) *WelcomeService {
+	return &WelcomeService{
+		llm:       llm,
+		speech:    speech,
+		audio:     audio,
+		greetings: make(map[string]string),
+	}
+}
+
+// Welcome returns the welcome for a session. With a warm-up it ends on an ice-breaker;
+// otherwise it ends on question, or on an invitation to introduce themselves when question is "".
+func (s *WelcomeService) Welcome(ctx context.Context, agent *models.Agent, language string, warmup bool, question string) string {
+	if _, ok := welcomeEndings[language]; !ok {
+		language = DefaultLanguage
+	}
+
+	ending := welcomeEndings[language][1]
+	if warmup {
+		ending = welcomeEndings[language][0]
+	} else if question != "" {
+		ending = question
+	}
+	return s.greeting(ctx, agent, language) + " " + ending
+}
+
+// greeting returns the cached greeting for an agent and language, generating it on first use
+func (s *WelcomeService) greeting(ctx context.Context, agent *models.Agent, language string) string {
+	// Greetings written before the agent was edited are regenerated
+	key := fmt.Sprintf("%s|%d|%s", agent.ID, agent.UpdatedAt.Unix(), language)
+
+	s.mutex.RLock()
+	greeting, cached := s.greetings[key]
+	s.mutex.RUnlock()
+	if cached {
+		return greeting
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, welcomeTimeout)
+	defer cancel()
+
+	greeting, err := s.llm.GenerateWelcome(ctx, agent, language)
+	greeting = strings.TrimSpace(greeting)
+	if err != nil || greeting == "" {
+		logging.FromContext(ctx).Warn("Failed to generate welcome, using template", "error", err, "agent_id", agent.ID, "language", language)
+		return fmt.Sprintf(welcomeGreetings[language], agent.Name, agent.Industry)
+	}
+
+	s.mutex.Lock()
+	s.greetings[key] = greeting
+	s.mutex.Unlock()
+
+	go s.prerender(agent, greeting, language)
+	return greeting
+}
+
+// prerender renders the speech of the welcomes built from a new greeting into the audio
+// cache. The welcome with a pooled opening question varies per session and isn't cached.
+func (s *WelcomeService) prerender(agent *models.Agent, greeting, language string) {
+	if _, disabled := s.speech.(NoopSpeechService); disabled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	voiceID := agentVoiceID(agent)
+	for _, ending := range welcomeEndings[language] {
+		text := greeting + " " + ending
+		s.audio.Pin(text)
+		_, err := s.audio.GetOrGenerate(ctx, text, voiceID, func() (io.ReadCloser, error) {
+			return s.speech.TextToSpeech(ctx, text, voiceID)
+		})
+		if err != nil {
+			slog.Warn("Failed to pre-render welcome audio", "error", err, "agent_id", agent.ID, "language", language)
+			return
+		}
+	}
+	slog.Info("Welcome audio pre-rendered", "agent_id", agent.ID, "language", language)
+}
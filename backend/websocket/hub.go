@@ -1,59 +1,65 @@
 package websocket
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log/slog"
+	"math"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/krshsl/praxis/backend/logging"
 )
 
 type Hub struct {
 	clients    map[*Client]bool
+	sessions   map[string]map[*Client]bool // Clients by the session they are connected to
 	register   chan *Client
 	unregister chan *Client
-	broadcast  chan []byte
+	broadcast  chan sessionMessage
+	limiter    MessageLimiter
+	usage      sessionUsage
 	mu         sync.RWMutex
 }
 
+// sessionMessage is a message for everyone observing a session
+type sessionMessage struct {
+	sessionID string
+	data      []byte
+}
+
+// MessageLimiter decides whether a client may send another message; when it may not, it
+// returns how long the client should wait
+type MessageLimiter interface {
+	Allow(client *Client, env Envelope) (bool, time.Duration)
+}
+
 type Client struct {
 	Hub                 *Hub
 	Conn                *websocket.Conn
 	Send                chan []byte
 	UserID              string
 	SessionID           string
+	UserAgent           string
+	AudioCodec          string // MIME type the client records answers in; empty if it didn't say
+	TextMode            bool   // A text interview, whose answers are typed
+	Observer            bool   // An organization admin following the session rather than its candidate
+	ConnectedAt         time.Time
 	ConversationHistory []string
-	MessageHandler      func(*Client, []byte) // Function to handle incoming messages
+	MessageHandler      func(*Client, Envelope) // Function to handle incoming messages
 	mu                  sync.RWMutex
-}
-
-type Message struct {
-	Type            string `json:"type"` // "text", "code", "audio", "audio_chunk", "user_message"
-	Content         string `json:"content"`
-	Language        string `json:"language,omitempty"`
-	AudioData       []byte `json:"audio_data,omitempty"`
-	AudioDataBase64 string `json:"audio_data_base64,omitempty"` // For Base64 encoded audio from frontend
-	ChunkIndex      int    `json:"chunk_index,omitempty"`       // For audio chunks
-	TotalChunks     int    `json:"total_chunks,omitempty"`      // For audio chunks
-	IsLastChunk     bool   `json:"is_last_chunk,omitempty"`     // For audio chunks
-	SessionID       string `json:"session_id,omitempty"`
-}
-
-type AudioMessage struct {
-	Type      string `json:"type"` // "audio"
-	AudioData []byte `json:"audio_data"`
-	SessionID string `json:"session_id,omitempty"`
+	ctx                 context.Context // Cancelled when the connection's read loop exits
+	cancel              context.CancelFunc
 }
 
 func NewHub() *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
+		sessions:   make(map[string]map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
-		broadcast:  make(chan []byte),
+		broadcast:  make(chan sessionMessage),
 	}
 }
 
@@ -63,51 +69,159 @@ func (h *Hub) Run() {
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
+			if h.sessions[client.SessionID] == nil {
+				h.sessions[client.SessionID] = make(map[*Client]bool)
+			}
+			h.sessions[client.SessionID][client] = true
 			h.mu.Unlock()
-			slog.Info("Client registered", "user_id", client.UserID, "session_id", client.SessionID)
+			slog.Info("Client registered", "user_id", client.UserID, "session_id", client.SessionID, "observer", client.Observer)
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.Send)
-			}
+			h.remove(client)
 			h.mu.Unlock()
-			slog.Info("Client unregistered", "user_id", client.UserID, "session_id", client.SessionID)
+			slog.Info("Client unregistered", "user_id", client.UserID, "session_id", client.SessionID, "observer", client.Observer)
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
-			for client := range h.clients {
+			h.mu.Lock()
+			for client := range h.sessions[message.sessionID] {
+				if !client.Observer {
+					continue
+				}
 				select {
-				case client.Send <- message:
+				case client.Send <- message.data:
 				default:
-					close(client.Send)
-					delete(h.clients, client)
+					// An observer that can't keep up is dropped rather than holding up the interview
+					h.remove(client)
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
+		}
+	}
+}
+
+// remove forgets a client and closes its send channel; h.mu must be held
+func (h *Hub) remove(client *Client) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	delete(h.sessions[client.SessionID], client)
+	if len(h.sessions[client.SessionID]) == 0 {
+		delete(h.sessions, client.SessionID)
+	}
+	close(client.Send)
+}
+
+// SessionClients returns the candidate's connections to an interview session
+func (h *Hub) SessionClients(sessionID string) []*Client {
+	return h.sessionClients(sessionID, false)
+}
+
+// SessionObservers returns the connections of the admins observing an interview session
+func (h *Hub) SessionObservers(sessionID string) []*Client {
+	return h.sessionClients(sessionID, true)
+}
+
+func (h *Hub) sessionClients(sessionID string, observers bool) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var clients []*Client
+	for client := range h.sessions[sessionID] {
+		if client.Observer == observers {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// Broadcast sends a message to everyone observing a session; its candidate doesn't get it
+func (h *Hub) Broadcast(sessionID string, message []byte) {
+	if len(h.SessionObservers(sessionID)) == 0 {
+		return
+	}
+	h.broadcast <- sessionMessage{sessionID: sessionID, data: message}
+}
+
+// CloseObservers sends everyone observing a session a last message, e.g. that it has ended, and
+// disconnects them once it has gone out
+func (h *Hub) CloseObservers(sessionID string, message []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.sessions[sessionID] {
+		if !client.Observer {
+			continue
+		}
+		select {
+		case client.Send <- message:
+		default:
 		}
+		h.remove(client)
 	}
 }
 
-func (h *Hub) RegisterClient(conn *websocket.Conn, userID string) *Client {
-	sessionID := uuid.New().String()
-	client := &Client{
+// SetMessageLimiter rate-limits the messages clients send; messages over the limit are dropped
+func (h *Hub) SetMessageLimiter(limiter MessageLimiter) {
+	h.limiter = limiter
+}
+
+// RegisterClient adds a candidate's connection to an interview session to the hub. The client's
+// context keeps the values of ctx, such as the request's logger, but outlives the request that
+// upgraded the connection.
+func (h *Hub) RegisterClient(ctx context.Context, conn *websocket.Conn, userID, sessionID string) *Client {
+	client := newClient(ctx, h, conn, userID, sessionID)
+	h.register <- client
+	return client
+}
+
+// RegisterObserver adds the connection of an admin observing an interview session to the hub.
+// Observers get what Broadcast sends the session, and nothing meant for its candidate.
+func (h *Hub) RegisterObserver(ctx context.Context, conn *websocket.Conn, userID, sessionID string) *Client {
+	client := newClient(ctx, h, conn, userID, sessionID)
+	client.Observer = true
+	h.register <- client
+	return client
+}
+
+func newClient(ctx context.Context, h *Hub, conn *websocket.Conn, userID, sessionID string) *Client {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	return &Client{
 		Hub:                 h,
 		Conn:                conn,
 		Send:                make(chan []byte, 256),
 		UserID:              userID,
 		SessionID:           sessionID,
+		ConnectedAt:         time.Now(),
 		ConversationHistory: []string{},
 		MessageHandler:      nil, // Will be set by the main.go handler
+		ctx:                 ctx,
+		cancel:              cancel,
 	}
+}
 
-	h.register <- client
-	return client
+// Done returns a channel that is closed once the client disconnects
+func (c *Client) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// Close disconnects the client, e.g. when a newer connection to its session replaces it
+func (c *Client) Close() {
+	c.cancel()
+	c.Conn.Close()
+}
+
+// Context returns a context that is cancelled once the client disconnects, so work done on
+// its behalf stops with it
+func (c *Client) Context() context.Context {
+	return c.ctx
 }
 
 func (c *Client) ReadPump() {
+	logger := logging.FromContext(c.ctx)
 	defer func() {
+		c.cancel()
 		c.Hub.unregister <- c
 		c.Conn.Close()
 	}()
@@ -123,37 +237,73 @@ func (c *Client) ReadPump() {
 		_, messageBytes, err := c.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				slog.Error("WebSocket error", "error", err)
+				logger.Error("WebSocket error", "error", err)
 			}
 			break
 		}
 
-		var msg Message
-		if err := json.Unmarshal(messageBytes, &msg); err != nil {
-			slog.Error("Failed to unmarshal message", "error", err)
+		// Rejected messages still count against the limit, so a broken client can't flood us
+		env, perr := ParseEnvelope(messageBytes)
+		// Every message counts towards the session's bandwidth too
+		withinBandwidth := c.countIn(len(messageBytes), env)
+
+		if c.Hub.limiter != nil {
+			if ok, wait := c.Hub.limiter.Allow(c, env); !ok {
+				logger.Warn("WebSocket message rate limited", "type", env.Type)
+				c.sendRateLimited(wait)
+				continue
+			}
+		}
+
+		if !withinBandwidth {
+			c.SendError(ErrCodeBandwidthExceeded, "This interview has used up its data allowance for voice. Please type your answers instead.")
+			continue
+		}
+
+		if perr != nil {
+			logger.Warn("Rejected WebSocket message", "code", perr.Code, "error", perr.Message)
+			c.SendError(perr.Code, perr.Message)
 			continue
 		}
 
-		slog.Info("Message received", "type", msg.Type, "session_id", c.SessionID, "content_length", len(msg.Content))
+		logger.Info("Message received", "type", env.Type, "payload_length", len(env.Payload))
 
 		// Use message handler if available, otherwise fall back to default handling
 		if c.MessageHandler != nil {
 			// Run message handler asynchronously to avoid blocking
-			go c.MessageHandler(c, messageBytes)
+			go c.MessageHandler(c, env)
 		} else {
 			// Fallback to default message handling
-			switch msg.Type {
-			case "text":
-				c.handleTextMessage(msg)
-			case "code":
-				c.handleCodeMessage(msg)
+			payload, perr := DecodePayload(env)
+			if perr != nil {
+				c.SendError(perr.Code, perr.Message)
+				continue
+			}
+			switch p := payload.(type) {
+			case TextPayload:
+				c.handleTextMessage(p)
+			case CodePayload:
+				c.handleCodeMessage(p)
 			default:
-				slog.Warn("Unknown message type", "type", msg.Type)
+				logger.Warn("Unhandled message type", "type", env.Type)
 			}
 		}
 	}
 }
 
+// sendRateLimited tells the client its message was dropped and when to try again
+func (c *Client) sendRateLimited(wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	data, err := Encode(TypeRateLimited, RateLimitedPayload{
+		Message:           fmt.Sprintf("You're sending messages too quickly. Please wait %d seconds and try again.", seconds),
+		RetryAfterSeconds: seconds,
+	})
+	if err != nil {
+		return
+	}
+	c.trySend(data)
+}
+
 func (c *Client) WritePump() {
 	ticker := time.NewTicker(54 * time.Second)
 	defer func() {
@@ -175,11 +325,14 @@ func (c *Client) WritePump() {
 				return
 			}
 			w.Write(message)
+			c.countOut(len(message))
 
 			n := len(c.Send)
 			for i := 0; i < n; i++ {
+				queued := <-c.Send
 				w.Write([]byte{'\n'})
-				w.Write(<-c.Send)
+				w.Write(queued)
+				c.countOut(len(queued) + 1)
 			}
 
 			if err := w.Close(); err != nil {
@@ -195,7 +348,7 @@ func (c *Client) WritePump() {
 	}
 }
 
-func (c *Client) handleTextMessage(msg Message) {
+func (c *Client) handleTextMessage(msg TextPayload) {
 	// Add to conversation history
 	c.mu.Lock()
 	c.ConversationHistory = append(c.ConversationHistory, msg.Content)
@@ -203,29 +356,23 @@ func (c *Client) handleTextMessage(msg Message) {
 
 	// Trigger AI conversation processing
 	// This will be handled by the AI message processor
-	slog.Info("Text message received for AI processing", "content", msg.Content, "user_id", c.UserID, "session_id", c.SessionID)
+	logging.FromContext(c.ctx).Info("Text message received for AI processing", "content", msg.Content)
 }
 
-func (c *Client) handleCodeMessage(msg Message) {
+func (c *Client) handleCodeMessage(msg CodePayload) {
 	// Add to conversation history
 	c.mu.Lock()
 	c.ConversationHistory = append(c.ConversationHistory, fmt.Sprintf("Code submission in %s: %s", msg.Language, msg.Content))
 	c.mu.Unlock()
 
 	// Trigger code analysis processing
-	slog.Info("Code message received for AI analysis", "language", msg.Language, "user_id", c.UserID)
+	logging.FromContext(c.ctx).Info("Code message received for AI analysis", "language", msg.Language)
 }
 
 func (c *Client) SendAudio(audioData []byte) {
-	audioMsg := AudioMessage{
-		Type:      "audio",
-		AudioData: audioData,
-		SessionID: c.SessionID,
-	}
-
-	audioBytes, err := json.Marshal(audioMsg)
+	audioBytes, err := Encode(TypeAudio, AudioReplyPayload{AudioData: audioData})
 	if err != nil {
-		slog.Error("Failed to marshal audio message", "error", err)
+		logging.FromContext(c.ctx).Error("Failed to marshal audio message", "error", err)
 		return
 	}
 
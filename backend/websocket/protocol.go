@@ -0,0 +1,518 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// ProtocolVersion is the envelope version this server speaks. Clients on another version get
+// an unsupported_version error carrying the supported one.
+const ProtocolVersion = 1
+
+// Message types sent by the client
+const (
+	TypeText        = "text"
+	TypeCode        = "code"
+	TypeAudio       = "audio"
+	TypeAudioChunk  = "audio_chunk"
+	TypeNote        = "note"
+	TypeEndSession  = "end_session"
+	TypeClientError = "client_error"
+	TypeHeartbeat   = "heartbeat"
+	TypeProctor     = "proctor_event"
+)
+
+// TypeTakeover is sent by an admin observing a session to put a question to the candidate
+const TypeTakeover = "takeover"
+
+// Ways an observer's question is put to the candidate, sent in TakeoverPayload.Mode
+const (
+	TakeoverInject   = "inject"   // Asked right away
+	TakeoverOverride = "override" // Asked instead of the interviewer's next reply
+)
+
+// Message types sent by the server; audio, audio_chunk and end_session are shared with the client
+const (
+	TypeUserMessage      = "user_message"
+	TypeNoteSaved        = "note_saved"
+	TypeSummaryPending   = "summary_pending"
+	TypeTimeRemaining    = "time_remaining"
+	TypeRateLimited      = "rate_limited"
+	TypeError            = "error"
+	TypeProctoring       = "proctoring"
+	TypeProgress         = "progress"
+	TypeTestResults      = "test_results"
+	TypeSessionResumed   = "session_resumed"
+	TypePacingNudge      = "pacing_nudge"
+	TypeHint             = "hint"
+	TypeLiveTurn         = "live_turn"         // To observers, as each turn happens
+	TypeTakeoverAccepted = "takeover_accepted" // To an observer, once their question is asked or set
+)
+
+// Stages of a candidate's turn reported in ProgressPayload
+const (
+	StageTranscribing    = "transcribing"     // Turning the recorded answer into text
+	StageThinking        = "thinking"         // Generating the interviewer's reply
+	StageRunningTests    = "running_tests"    // Running a code submission against hidden tests
+	StageReviewingCode   = "reviewing_code"   // Reviewing a code submission
+	StageGeneratingAudio = "generating_audio" // Synthesizing the spoken reply
+)
+
+// Error codes sent in ErrorPayload.Code
+const (
+	ErrCodeInvalidMessage     = "invalid_message"     // Not a JSON envelope
+	ErrCodeUnsupportedVersion = "unsupported_version" // Envelope v is not ProtocolVersion
+	ErrCodeUnknownType        = "unknown_type"        // No such client message type
+	ErrCodeInvalidPayload     = "invalid_payload"     // Payload doesn't match its type
+	ErrCodeProcessingFailed   = "processing_failed"   // A valid message could not be handled
+	ErrCodeTextMode           = "text_mode"           // Voice answers sent in a text interview
+	ErrCodeNotAllowed         = "not_allowed"         // A message type the connection may not send, e.g. takeover from the candidate
+)
+
+// maxTypingDurationMs bounds the durations in TypingMetrics, to reject nonsense from clients
+const maxTypingDurationMs = int64(24 * time.Hour / time.Millisecond)
+
+// maxSpeechDurationMs and maxSpeechPauses bound SpeechTiming in the same way
+const (
+	maxSpeechDurationMs = int64(time.Hour / time.Millisecond)
+	maxSpeechPauses     = 1000
+)
+
+// Envelope wraps every message in both directions
+type Envelope struct {
+	V       int             `json:"v"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// TextPayload is a candidate's typed answer, with how it was typed if the client measured it
+type TextPayload struct {
+	Content string         `json:"content"`
+	Metrics *TypingMetrics `json:"metrics,omitempty"`
+}
+
+// TypingMetrics describes how a typed answer was composed
+type TypingMetrics struct {
+	ResponseLatencyMs int64 `json:"response_latency_ms"` // From the interviewer's message appearing to the first keystroke
+	ComposeMs         int64 `json:"compose_ms"`          // From the first keystroke to sending
+	Keystrokes        int   `json:"keystrokes"`
+	Edits             int   `json:"edits"` // Deletions and overwritten selections
+	Pastes            int   `json:"pastes"`
+	PastedChars       int   `json:"pasted_chars"`
+}
+
+// CodePayload is a code submission
+type CodePayload struct {
+	Content  string `json:"content"`
+	Language string `json:"language,omitempty"`
+}
+
+// AudioPayload is a whole recorded answer; audio_data is base64 in JSON. It carries how the
+// answer was spoken if the client measured it.
+type AudioPayload struct {
+	AudioData []byte        `json:"audio_data"`
+	Timing    *SpeechTiming `json:"timing,omitempty"`
+}
+
+// AudioChunkPayload is one piece of a recorded answer sent in chunks. Only the last chunk may
+// carry the answer's timing.
+type AudioChunkPayload struct {
+	AudioData   []byte        `json:"audio_data"`
+	ChunkIndex  int           `json:"chunk_index"`
+	TotalChunks int           `json:"total_chunks"`
+	IsLastChunk bool          `json:"is_last_chunk"`
+	Timing      *SpeechTiming `json:"timing,omitempty"`
+}
+
+// SpeechTiming describes how a spoken answer was paced, measured by the client from the
+// recording's audio level
+type SpeechTiming struct {
+	DurationMs int64   `json:"duration_ms"`         // From starting to stopping the recording
+	PausesMs   []int64 `json:"pauses_ms,omitempty"` // Each silence of at least a second between words
+}
+
+// validate rejects timing no recording could have
+func (t *SpeechTiming) validate() *ProtocolError {
+	if t == nil {
+		return nil
+	}
+	if t.DurationMs < 0 || t.DurationMs > maxSpeechDurationMs {
+		return invalidPayload("timing duration_ms must be between 0 and 1 hour")
+	}
+	if len(t.PausesMs) > maxSpeechPauses {
+		return invalidPayload("timing has more than %d pauses", maxSpeechPauses)
+	}
+	var paused int64
+	for _, pause := range t.PausesMs {
+		if pause < 0 {
+			return invalidPayload("timing pauses must not be negative")
+		}
+		paused += pause
+	}
+	if paused > t.DurationMs {
+		return invalidPayload("timing pauses are longer than the recording")
+	}
+	return nil
+}
+
+// NotePayload is a private note the candidate keeps during the session
+type NotePayload struct {
+	Content string `json:"content"`
+}
+
+// ClientErrorPayload reports a failure on the client, e.g. audio that wouldn't play
+type ClientErrorPayload struct {
+	Kind    string          `json:"kind"`
+	Message string          `json:"message"`
+	Context json.RawMessage `json:"context,omitempty"` // A JSON object of details
+}
+
+// HeartbeatPayload keeps a proctored session alive; the payload may be omitted
+type HeartbeatPayload struct{}
+
+// ProctorEventPayload reports a focus or fullscreen change in a proctored session
+type ProctorEventPayload struct {
+	Kind       string `json:"kind"`
+	DurationMs int64  `json:"duration_ms,omitempty"` // How long focus was away, for focus_regained
+}
+
+// EndSessionPayload ends the session; the payload may be omitted by the client
+type EndSessionPayload struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// TakeoverPayload is an observer's question for the candidate, asked in the interviewer's voice
+type TakeoverPayload struct {
+	Question string `json:"question"`
+	Mode     string `json:"mode"` // inject or override
+}
+
+// MessagePayload is a text reply from the interviewer, or the transcript of the candidate's
+// own audio for user_message
+type MessagePayload struct {
+	Content  string `json:"content"`
+	Language string `json:"language,omitempty"`
+}
+
+// AudioReplyPayload is a spoken reply from the interviewer with its text, if any
+type AudioReplyPayload struct {
+	Content   string `json:"content,omitempty"`
+	AudioData []byte `json:"audio_data"`
+}
+
+// AudioReplyChunkPayload is one piece of a spoken reply streamed while it is synthesized. The
+// first chunk carries the text; the total isn't known until the chunk marked last, whose audio
+// may be empty if synthesis broke off.
+type AudioReplyChunkPayload struct {
+	Content     string `json:"content,omitempty"`
+	AudioData   []byte `json:"audio_data"`
+	ChunkIndex  int    `json:"chunk_index"`
+	IsLastChunk bool   `json:"is_last_chunk"`
+}
+
+// NoteSavedPayload acknowledges a note
+type NoteSavedPayload struct {
+	NoteID string `json:"note_id"`
+}
+
+// SummaryPendingPayload tells the client the summary is being generated
+type SummaryPendingPayload struct {
+	Message    string `json:"message"`
+	ETASeconds int    `json:"eta_seconds"`
+}
+
+// TimeRemainingPayload counts down to the interview's time limit
+type TimeRemainingPayload struct {
+	RemainingSeconds int `json:"remaining_seconds"`
+}
+
+// RateLimitedPayload tells the client its message was dropped and when to try again
+type RateLimitedPayload struct {
+	Message           string `json:"message"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+}
+
+// ProctoringPayload tells the client its session is proctored and how often to send heartbeats
+type ProctoringPayload struct {
+	HeartbeatIntervalSeconds int `json:"heartbeat_interval_seconds"`
+	MaxGapSeconds            int `json:"max_gap_seconds"` // The session ends after this long without a heartbeat
+}
+
+// ProgressPayload tells the client what a slow turn is doing. ElapsedMs is the time since the
+// candidate's message arrived; the reply, or an error, ends the turn.
+type ProgressPayload struct {
+	Stage     string `json:"stage"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+}
+
+// TestResultsPayload is how a code submission did against its question's hidden tests. The
+// tests stay hidden: the candidate sees whether each passed, in order, but not its name or the
+// run's output.
+type TestResultsPayload struct {
+	Passed   int               `json:"passed"`
+	Total    int               `json:"total"`
+	Tests    []TestCasePayload `json:"tests"`
+	TimedOut bool              `json:"timed_out"`
+}
+
+// TestCasePayload is the outcome of one hidden test
+type TestCasePayload struct {
+	Passed bool `json:"passed"`
+}
+
+// SessionResumedPayload is sent when a client connects to an interview that has already begun,
+// e.g. after its connection dropped. It carries the latest turns, oldest first, so the client
+// can show the conversation again and carry on from the last question.
+type SessionResumedPayload struct {
+	Turns     []TurnPayload `json:"turns"`
+	Truncated bool          `json:"truncated"` // Older turns were left out
+}
+
+// TurnPayload is one turn of the conversation replayed on resume, or sent to observers as a
+// live_turn when it happens
+type TurnPayload struct {
+	Speaker   string    `json:"speaker"` // user or agent
+	Content   string    `json:"content"`
+	Kind      string    `json:"kind"`               // text or code
+	Language  string    `json:"language,omitempty"` // Programming language of a code turn
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PacingNudgePayload asks the candidate to wrap up a question they have spent too long on
+type PacingNudgePayload struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// HintPayload is a private coaching note on the candidate's last answer, in a session practiced
+// with hints. It is for the candidate's eyes only and is never spoken by the interviewer.
+type HintPayload struct {
+	Note         string `json:"note"`
+	TranscriptID string `json:"transcript_id,omitempty"` // The answer it coaches
+}
+
+// ErrorPayload reports a rejected or failed message
+type ErrorPayload struct {
+	Code             string `json:"code"`
+	Message          string `json:"message"`
+	SupportedVersion int    `json:"supported_version,omitempty"` // Set for unsupported_version
+}
+
+// ProtocolError is a message the server rejects, with the code sent back to the client
+type ProtocolError struct {
+	Code    string
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func invalidPayload(format string, args ...any) *ProtocolError {
+	return &ProtocolError{Code: ErrCodeInvalidPayload, Message: fmt.Sprintf(format, args...)}
+}
+
+// ParseEnvelope decodes a client frame and checks its version and type. The payload is left
+// for DecodePayload.
+func ParseEnvelope(data []byte) (Envelope, *ProtocolError) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, &ProtocolError{Code: ErrCodeInvalidMessage, Message: "message must be a JSON envelope with v, type and payload"}
+	}
+	// Checked before the strict decode, so clients of the unversioned protocol are told to upgrade
+	if env.V != ProtocolVersion {
+		return env, &ProtocolError{Code: ErrCodeUnsupportedVersion, Message: fmt.Sprintf("protocol version %d is not supported, use %d", env.V, ProtocolVersion)}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&Envelope{}); err != nil {
+		return env, &ProtocolError{Code: ErrCodeInvalidMessage, Message: "envelope may only have v, type and payload"}
+	}
+	switch env.Type {
+	case TypeText, TypeCode, TypeAudio, TypeAudioChunk, TypeNote, TypeEndSession, TypeClientError, TypeHeartbeat, TypeProctor, TypeTakeover:
+		return env, nil
+	}
+	return env, &ProtocolError{Code: ErrCodeUnknownType, Message: fmt.Sprintf("unknown message type %q", env.Type)}
+}
+
+// DecodePayload strictly decodes a client envelope's payload into the struct for its type and
+// validates it, returning e.g. a TextPayload for a text message
+func DecodePayload(env Envelope) (any, *ProtocolError) {
+	switch env.Type {
+	case TypeText:
+		var p TextPayload
+		if err := decodeStrict(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(p.Content) == "" {
+			return nil, invalidPayload("content is required")
+		}
+		if m := p.Metrics; m != nil {
+			if m.ResponseLatencyMs < 0 || m.ComposeMs < 0 || m.Keystrokes < 0 || m.Edits < 0 || m.Pastes < 0 || m.PastedChars < 0 {
+				return nil, invalidPayload("metrics must not be negative")
+			}
+			if m.ResponseLatencyMs > maxTypingDurationMs || m.ComposeMs > maxTypingDurationMs {
+				return nil, invalidPayload("metrics durations must be at most 24 hours")
+			}
+		}
+		return p, nil
+	case TypeCode:
+		var p CodePayload
+		if err := decodeStrict(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(p.Content) == "" {
+			return nil, invalidPayload("content is required")
+		}
+		return p, nil
+	case TypeAudio:
+		var p AudioPayload
+		if err := decodeStrict(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		if len(p.AudioData) == 0 {
+			return nil, invalidPayload("audio_data is required")
+		}
+		if err := p.Timing.validate(); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case TypeAudioChunk:
+		var p AudioChunkPayload
+		if err := decodeStrict(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		if len(p.AudioData) == 0 {
+			return nil, invalidPayload("audio_data is required")
+		}
+		if p.TotalChunks < 1 || p.ChunkIndex < 0 || p.ChunkIndex >= p.TotalChunks {
+			return nil, invalidPayload("chunk_index %d is out of range for %d chunks", p.ChunkIndex, p.TotalChunks)
+		}
+		if p.IsLastChunk != (p.ChunkIndex == p.TotalChunks-1) {
+			return nil, invalidPayload("is_last_chunk must be set on the last chunk only")
+		}
+		if p.Timing != nil && !p.IsLastChunk {
+			return nil, invalidPayload("timing must be sent with the last chunk only")
+		}
+		if err := p.Timing.validate(); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case TypeNote:
+		var p NotePayload
+		if err := decodeStrict(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(p.Content) == "" {
+			return nil, invalidPayload("content is required")
+		}
+		return p, nil
+	case TypeClientError:
+		var p ClientErrorPayload
+		if err := decodeStrict(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(p.Kind) == "" || strings.TrimSpace(p.Message) == "" {
+			return nil, invalidPayload("kind and message are required")
+		}
+		return p, nil
+	case TypeProctor:
+		var p ProctorEventPayload
+		if err := decodeStrict(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(p.Kind) == "" {
+			return nil, invalidPayload("kind is required")
+		}
+		if p.DurationMs < 0 {
+			return nil, invalidPayload("duration_ms must not be negative")
+		}
+		return p, nil
+	case TypeTakeover:
+		var p TakeoverPayload
+		if err := decodeStrict(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(p.Question) == "" {
+			return nil, invalidPayload("question is required")
+		}
+		if p.Mode != TakeoverInject && p.Mode != TakeoverOverride {
+			return nil, invalidPayload("mode must be %s or %s", TakeoverInject, TakeoverOverride)
+		}
+		return p, nil
+	case TypeHeartbeat:
+		var p HeartbeatPayload
+		if len(env.Payload) > 0 && string(env.Payload) != "null" {
+			if err := decodeStrict(env.Payload, &p); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+	case TypeEndSession:
+		var p EndSessionPayload
+		if len(env.Payload) > 0 && string(env.Payload) != "null" {
+			if err := decodeStrict(env.Payload, &p); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+	}
+	return nil, &ProtocolError{Code: ErrCodeUnknownType, Message: fmt.Sprintf("unknown message type %q", env.Type)}
+}
+
+// decodeStrict decodes a payload, rejecting unknown fields and trailing data
+func decodeStrict(payload json.RawMessage, v any) *ProtocolError {
+	if len(payload) == 0 || string(payload) == "null" {
+		return invalidPayload("payload is required")
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return invalidPayload("%v", err)
+	}
+	if dec.More() {
+		return invalidPayload("payload has trailing data")
+	}
+	return nil
+}
+
+// Encode wraps a payload in an envelope of the current version
+func Encode(msgType string, payload any) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{V: ProtocolVersion, Type: msgType, Payload: raw})
+}
+
+// SendError replies with a structured error. It never blocks, so it is safe to call from the
+// read loop.
+func (c *Client) SendError(code, message string) {
+	payload := ErrorPayload{Code: code, Message: message}
+	if code == ErrCodeUnsupportedVersion {
+		payload.SupportedVersion = ProtocolVersion
+	}
+	data, err := Encode(TypeError, payload)
+	if err != nil {
+		logging.FromContext(c.ctx).Error("Failed to marshal error message", "error", err)
+		return
+	}
+	c.trySend(data)
+}
+
+// trySend queues a message unless the client's buffer is full or already closed
+func (c *Client) trySend(data []byte) {
+	defer func() {
+		// Send is closed once the client unregisters
+		recover()
+	}()
+	select {
+	case c.Send <- data:
+	default:
+	}
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/krshsl/praxis/backend/logging"
+)
+
+// ErrCodeBandwidthExceeded is sent when a session has used up what it may transfer
+const ErrCodeBandwidthExceeded = "bandwidth_exceeded"
+
+// SessionUsage is what a session's connections have transferred, counted in message bytes
+// before compression
+type SessionUsage struct {
+	BytesIn  int64 `json:"bytes_in"`  // Received from the client
+	BytesOut int64 `json:"bytes_out"` // Sent to the client
+}
+
+// BandwidthLimits caps what one session may transfer over all its connections; 0 is unlimited.
+// A session over MaxBytesIn can't send voice; one over MaxBytesOut gets replies as text.
+type BandwidthLimits struct {
+	MaxBytesIn  int64
+	MaxBytesOut int64
+}
+
+// ConnectionInfo describes a live connection, for the admin connections view
+type ConnectionInfo struct {
+	UserID      string       `json:"user_id"`
+	SessionID   string       `json:"session_id"`
+	UserAgent   string       `json:"user_agent,omitempty"`
+	ConnectedAt time.Time    `json:"connected_at"`
+	Observer    bool         `json:"observer,omitempty"` // An admin observing the session
+	Usage       SessionUsage `json:"usage"`              // The session's, across reconnects
+}
+
+type sessionCounter struct {
+	in, out    atomic.Int64
+	outCapped  atomic.Bool // The client was told replies are text from now on
+	inRejected atomic.Bool // The client was told voice is refused from now on
+}
+
+// sessionUsage counts bytes per session, kept across reconnects until the session is forgotten
+type sessionUsage struct {
+	counters map[string]*sessionCounter
+	limits   BandwidthLimits
+	mu       sync.Mutex
+}
+
+func (u *sessionUsage) counter(sessionID string) *sessionCounter {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.counters == nil {
+		u.counters = make(map[string]*sessionCounter)
+	}
+	counter, ok := u.counters[sessionID]
+	if !ok {
+		counter = &sessionCounter{}
+		u.counters[sessionID] = counter
+	}
+	return counter
+}
+
+// SetBandwidthLimits sets the per-session ceilings applied from the next message on
+func (h *Hub) SetBandwidthLimits(limits BandwidthLimits) {
+	h.usage.mu.Lock()
+	h.usage.limits = limits
+	h.usage.mu.Unlock()
+}
+
+func (h *Hub) bandwidthLimits() BandwidthLimits {
+	h.usage.mu.Lock()
+	defer h.usage.mu.Unlock()
+	return h.usage.limits
+}
+
+// SessionUsage returns what a session has transferred since its first connection
+func (h *Hub) SessionUsage(sessionID string) SessionUsage {
+	counter := h.usage.counter(sessionID)
+	return SessionUsage{BytesIn: counter.in.Load(), BytesOut: counter.out.Load()}
+}
+
+// ForgetSession stops counting a session that has ended, returning what it transferred
+func (h *Hub) ForgetSession(sessionID string) SessionUsage {
+	h.usage.mu.Lock()
+	counter, ok := h.usage.counters[sessionID]
+	delete(h.usage.counters, sessionID)
+	h.usage.mu.Unlock()
+	if !ok {
+		return SessionUsage{}
+	}
+	return SessionUsage{BytesIn: counter.in.Load(), BytesOut: counter.out.Load()}
+}
+
+// OutboundCapped reports whether a session has sent all it may, so replies should go out as
+// text, and whether this is the first time it was asked since, so the client can be told once
+func (h *Hub) OutboundCapped(sessionID string) (capped, first bool) {
+	limit := h.bandwidthLimits().MaxBytesOut
+	counter := h.usage.counter(sessionID)
+	if limit <= 0 || counter.out.Load() < limit {
+		return false, false
+	}
+	return true, counter.outCapped.CompareAndSwap(false, true)
+}
+
+// Connections lists the live connections with their sessions' usage
+func (h *Hub) Connections() []ConnectionInfo {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	connections := make([]ConnectionInfo, 0, len(clients))
+	for _, client := range clients {
+		connections = append(connections, ConnectionInfo{
+			UserID:      client.UserID,
+			SessionID:   client.SessionID,
+			UserAgent:   client.UserAgent,
+			ConnectedAt: client.ConnectedAt,
+			Observer:    client.Observer,
+			Usage:       h.SessionUsage(client.SessionID),
+		})
+	}
+	return connections
+}
+
+// countIn records a message received from the client, reporting whether the session may still
+// send voice. Observers' traffic isn't the session's.
+func (c *Client) countIn(size int, env Envelope) bool {
+	if c.Observer {
+		return true
+	}
+	counter := c.Hub.usage.counter(c.SessionID)
+	total := counter.in.Add(int64(size))
+	limit := c.Hub.bandwidthLimits().MaxBytesIn
+	if limit <= 0 || total <= limit || (env.Type != TypeAudio && env.Type != TypeAudioChunk) {
+		return true
+	}
+	if counter.inRejected.CompareAndSwap(false, true) {
+		logging.FromContext(c.ctx).Warn("Session over inbound bandwidth, refusing voice", "bytes_in", total, "limit", limit)
+	}
+	return false
+}
+
+func (c *Client) countOut(size int) {
+	if c.Observer {
+		return
+	}
+	c.Hub.usage.counter(c.SessionID).out.Add(int64(size))
+}
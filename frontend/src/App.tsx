@@ -8,6 +8,8 @@ import { CodingView } from 'components/CodingView'
 import { AgentsPage } from 'components/AgentsPage'
 import { SummaryPage } from 'components/SummaryPage'
 import { InterviewSummaryPage } from 'components/InterviewSummaryPage'
+import { RevokeDevicePage } from 'components/auth/RevokeDevicePage'
+import { VerifyCertificatePage } from 'components/VerifyCertificatePage'
 import { useUser, useAuthLoading, useIsAuthChecked, useCheckAuth } from 'store/useAuth'
 import { Toaster } from 'components/ui/Toaster'
 import { ThemeProvider } from 'contexts/ThemeContext'
@@ -44,6 +46,8 @@ function App() {
         <Routes>
           <Route path="/login" element={user ? <Navigate to="/" replace /> : <LoginForm />} />
           <Route path="/signup" element={user ? <Navigate to="/" replace /> : <SignUpForm />} />
+          <Route path="/security/revoke" element={<RevokeDevicePage />} />
+          <Route path="/verify/:code" element={<VerifyCertificatePage />} />
 
           <Route element={<ProtectedRoute />}>
             <Route element={<MainLayout />}>
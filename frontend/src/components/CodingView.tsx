@@ -101,7 +101,7 @@ export function CodingView() {
   const [currentQuestion] = useState(CODING_QUESTIONS[0])
   const [aiFeedback] = useState('')
   const editorRef = useRef<any>(null)
-  const { isConnected, messages } = useConversationStore()
+  const { isConnected, messages, testResults } = useConversationStore()
 
   const handleEditorDidMount = (editor: any) => {
     editorRef.current = editor
@@ -119,12 +119,12 @@ export function CodingView() {
     }
 
     setIsSubmitting(true)
+    useConversationStore.getState().setTestResults(null)
     try {
       websocketService.sendMessage({
         type: 'code',
         content: code,
-        language,
-        session_id: useConversationStore.getState().currentSession || undefined
+        language
       })
     } catch (error) {
       console.error('Failed to submit code:', error)
@@ -152,8 +152,7 @@ export function CodingView() {
     setIsEnding(true)
     try {
       await websocketService.sendMessage({
-        type: 'end_session',
-        session_id: useConversationStore.getState().currentSession || undefined
+        type: 'end_session'
       })
       navigate('/dashboard')
     } catch (error) {
@@ -282,6 +281,25 @@ export function CodingView() {
                 />
               </div>
 
+              {/* Hidden test results of the last submission */}
+              {testResults && (
+                <div className="mt-4 rounded-lg border p-3 space-y-2">
+                  <div className={`text-sm font-semibold ${testResults.passed === testResults.total ? 'text-primary' : 'text-destructive'}`}>
+                    Passed {testResults.passed} of {testResults.total} tests
+                    {testResults.timedOut && ' (timed out)'}
+                  </div>
+                  {testResults.tests.length > 0 && (
+                    <ul className="text-xs space-y-1">
+                      {testResults.tests.map((test, i) => (
+                        <li key={i} className={test.passed ? 'text-muted-foreground' : 'text-destructive'}>
+                          {test.passed ? '✓' : '✗'} Test {i + 1}
+                        </li>
+                      ))}
+                    </ul>
+                  )}
+                </div>
+              )}
+
               {/* Action Buttons */}
               <div className="flex flex-wrap gap-2 mt-4">
                 <Button
@@ -4,6 +4,18 @@ import { Card, CardContent, CardHeader, CardTitle } from 'components/ui/Card'
 import { Button } from 'components/ui/Button'
 import { CircularProgress } from 'components/ui/CircularProgress'
 import { apiService } from 'services/api'
+import type { ExportFormat, SummaryStage } from 'services/api'
+
+// What the summary job is doing while the candidate waits
+const SUMMARY_STAGE_LABELS: Record<SummaryStage, string> = {
+  review: 'Waiting for you to review your transcript...',
+  queued: 'Waiting to start...',
+  transcribing: 'Gathering your answers...',
+  prompting: 'Reviewing your interview...',
+  parsing: 'Reading the results...',
+  saving: 'Saving your summary...',
+  done: 'Done',
+}
 
 interface InterviewSummary {
   id: string
@@ -30,6 +42,8 @@ interface InterviewSummary {
     rating: number
   }[]
   isGenerating?: boolean
+  stage?: SummaryStage
+  retrying?: boolean
 }
 
 export function InterviewSummaryPage() {
@@ -56,9 +70,17 @@ export function InterviewSummaryPage() {
       
       // Try to get the summary
       let summary = null
+      let stage: SummaryStage | undefined
+      let retrying = false
       try {
         const summaryResponse = await apiService.getSummary(id)
+        if (summaryResponse.status === 'failed') {
+          setError(`Summary generation failed: ${summaryResponse.error || 'unknown error'}`)
+          return
+        }
         summary = summaryResponse.summary
+        stage = summaryResponse.stage
+        retrying = Boolean(summaryResponse.error)
       } catch (summaryError: any) {
         // If summary is still being generated (202), show loading state
         if (summaryError.response?.status === 202) {
@@ -109,7 +131,9 @@ export function InterviewSummaryPage() {
         recommendations: summary?.recommendations || 'Analysis in progress...',
         technical_skills: [],
         communication_skills: [],
-        isGenerating: !summary
+        isGenerating: !summary,
+        stage,
+        retrying
       }
       
       setSummary(interviewSummary)
@@ -121,6 +145,21 @@ export function InterviewSummaryPage() {
     }
   }
 
+  const exportReport = async (format: ExportFormat) => {
+    if (!sessionId) return
+    try {
+      const blob = await apiService.exportSession(sessionId, format)
+      const url = URL.createObjectURL(blob)
+      const link = document.createElement('a')
+      link.href = url
+      link.download = `praxis-interview-${sessionId}.${format}`
+      link.click()
+      URL.revokeObjectURL(url)
+    } catch (err) {
+      console.error('Report export error:', err)
+    }
+  }
+
   if (loading) {
     return (
       <div className="flex items-center justify-center min-h-screen">
@@ -151,6 +190,14 @@ export function InterviewSummaryPage() {
                   <p className="text-muted-foreground mb-4">
                     Our AI is reviewing your responses and generating a personalized summary with detailed feedback.
                   </p>
+                  {summary.stage && (
+                    <p className="text-sm font-medium">{SUMMARY_STAGE_LABELS[summary.stage]}</p>
+                  )}
+                  {summary.retrying && (
+                    <p className="text-sm text-orange-600 dark:text-orange-400">
+                      The last attempt failed, so it will be tried again shortly.
+                    </p>
+                  )}
                 </div>
 
                 <div className="bg-muted p-4 rounded-lg">
@@ -361,6 +408,18 @@ export function InterviewSummaryPage() {
                     </div>
                   </div>
 
+                  <div className="flex gap-2">
+                    <Button onClick={() => exportReport('pdf')} variant="outline" className="flex-1">
+                      PDF
+                    </Button>
+                    <Button onClick={() => exportReport('md')} variant="outline" className="flex-1">
+                      Markdown
+                    </Button>
+                    <Button onClick={() => exportReport('json')} variant="outline" className="flex-1">
+                      JSON
+                    </Button>
+                  </div>
+
                   <Button 
                     onClick={() => navigate('/dashboard')} 
                     className="w-full"
@@ -12,6 +12,16 @@ import { audioService } from 'services/audio'
 import { useUser } from 'store/useAuth'
 import { INTERVIEW_TIMING } from 'constants/timing'
 import { useToast } from 'hooks/useToast'
+import type { TurnStage } from 'store/useStore'
+
+// What the interviewer is doing while the candidate waits for a reply
+const TURN_STAGE_LABELS: Record<TurnStage, string> = {
+  transcribing: 'Transcribing your answer...',
+  thinking: 'Thinking...',
+  running_tests: 'Running your code against the tests...',
+  reviewing_code: 'Reviewing your code...',
+  generating_audio: 'Preparing the reply...',
+}
 
 export function InterviewView() {
   // Ref to control current audio element
@@ -31,7 +41,9 @@ export function InterviewView() {
     isTyping,
     typingContent,
     audioGenerationFailed,
-    sessionEnded
+    sessionEnded,
+    interviewTimeRemaining,
+    turnProgress
   } = useConversationStore()
   const { toast } = useToast()
   // Show a full-page modal when the session ends (triggered by backend 'end_session')
@@ -63,6 +75,9 @@ export function InterviewView() {
       })
       
       audio.addEventListener('error', () => {
+        websocketService.reportError('playback', audio.error?.message || 'Audio failed to load', {
+          media_error_code: audio.error?.code,
+        })
         setAudioSrc(null)
         useConversationStore.getState().setAudioPlaying(false)
         startThinkingPhase()
@@ -281,8 +296,7 @@ export function InterviewView() {
     try {
       // End the session and generate summary
       await websocketService.sendMessage({
-        type: 'end_session',
-        session_id: useConversationStore.getState().currentSession || undefined
+        type: 'end_session'
       })
       // Disconnect WebSocket first
       websocketService.disconnect()
@@ -428,6 +442,11 @@ export function InterviewView() {
                 {isConnected ? 'Connected' : 'Disconnected'}
               </span>
             </div>
+            {interviewTimeRemaining !== null && (
+              <span className="text-sm text-muted-foreground tabular-nums">
+                {Math.floor(interviewTimeRemaining / 60)}:{String(interviewTimeRemaining % 60).padStart(2, '0')} left
+              </span>
+            )}
           </div>
           <Button
             onClick={() => setShowStopModal(true)}
@@ -466,7 +485,9 @@ export function InterviewView() {
               {isProcessing && (
                 <div className="flex items-center space-x-2">
                   <div className="animate-spin rounded-full h-4 w-4 border-b-2 border-primary"></div>
-                  <span className="text-sm text-muted-foreground">Processing...</span>
+                  <span className="text-sm text-muted-foreground">
+                    {turnProgress ? TURN_STAGE_LABELS[turnProgress.stage] : 'Processing...'}
+                  </span>
                 </div>
               )}
             </div>
@@ -577,7 +598,9 @@ export function InterviewView() {
               <div className="w-full">
                 <div className="flex items-center justify-center space-x-2">
                   <div className="animate-spin rounded-full h-4 w-4 border-b-2 border-primary"></div>
-                  <p className="text-sm text-muted-foreground">Processing audio...</p>
+                  <p className="text-sm text-muted-foreground">
+                    {turnProgress ? TURN_STAGE_LABELS[turnProgress.stage] : 'Processing audio...'}
+                  </p>
                 </div>
               </div>
             )}
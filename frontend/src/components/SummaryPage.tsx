@@ -68,8 +68,10 @@ export function SummaryPage() {
         })
         
         const summaryResponse = await apiService.getSummary(session.id)
+        // Keep polling until the summary is ready or its job has failed for good
+        const result = summaryResponse.summary ?? (summaryResponse.status === 'failed' ? 'error' as const : 'loading' as const)
         setSummaries(prev => {
-          const updatedSummaries = { ...prev, [session.id]: summaryResponse.summary }
+          const updatedSummaries = { ...prev, [session.id]: result }
           summariesRef.current = updatedSummaries
           return updatedSummaries
         })
@@ -0,0 +1,84 @@
+import { useEffect, useState } from 'react'
+import { useNavigate, useParams } from 'react-router-dom'
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from 'components/ui/Card'
+import { apiService } from 'services/api'
+import type { CertificateVerification } from 'services/api'
+
+// Public page behind the verification link printed on interview certificates
+export function VerifyCertificatePage() {
+  const { code = '' } = useParams()
+  const [verification, setVerification] = useState<CertificateVerification | null>(null)
+  const [error, setError] = useState<string | null>(null)
+
+  const navigate = useNavigate()
+
+  useEffect(() => {
+    apiService
+      .verifyCertificate(code)
+      .then(setVerification)
+      .catch(() => setError('No certificate exists with this code.'))
+  }, [code])
+
+  const details = verification?.details
+  // The interview's organization brands the page, if it has set a branding
+  const branding = verification?.branding
+
+  return (
+    <div className="flex min-h-screen items-center justify-center">
+      <Card className="w-full max-w-md" style={branding?.primary_color ? { borderColor: branding.primary_color } : undefined}>
+        <CardHeader>
+          {branding?.logo_url && (
+            <img src={apiService.assetUrl(branding.logo_url)} alt="" className="mb-2 max-h-12 self-start object-contain" />
+          )}
+          <CardTitle style={branding?.primary_color ? { color: branding.primary_color } : undefined}>
+            Certificate verification
+          </CardTitle>
+          <CardDescription>Code {code}</CardDescription>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          {!verification && !error && (
+            <div className="text-sm text-muted-foreground">Checking certificate...</div>
+          )}
+
+          {error && <div className="text-sm text-destructive">{error}</div>}
+
+          {verification && (
+            <div className={verification.valid ? 'text-sm font-medium text-green-600' : 'text-sm text-destructive'}>
+              {verification.valid
+                ? 'This certificate is genuine and was issued by Praxis.'
+                : `This certificate is not valid: ${verification.reason}.`}
+            </div>
+          )}
+
+          {details && (
+            <dl className="grid grid-cols-2 gap-2 text-sm">
+              <dt className="text-muted-foreground">Candidate</dt>
+              <dd>{details.candidate_name}</dd>
+              <dt className="text-muted-foreground">Interview</dt>
+              <dd>{[details.agent_name, details.level, details.industry].filter(Boolean).join(' - ')}</dd>
+              <dt className="text-muted-foreground">Overall score</dt>
+              <dd>{Math.round(details.overall_score)} / 100</dd>
+              <dt className="text-muted-foreground">Completed</dt>
+              <dd>{new Date(details.completed_at).toLocaleDateString()}</dd>
+            </dl>
+          )}
+
+          {branding?.footer_text && (
+            <p className="whitespace-pre-line text-center text-xs text-muted-foreground">{branding.footer_text}</p>
+          )}
+
+          <div className="text-center text-sm text-muted-foreground">
+            <button
+              type="button"
+              className="text-primary hover:underline"
+              style={branding?.accent_color ? { color: branding.accent_color } : undefined}
+              onClick={() => navigate('/')}
+            >
+              Back to Praxis
+            </button>
+          </div>
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
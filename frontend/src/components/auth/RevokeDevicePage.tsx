@@ -0,0 +1,76 @@
+import { useState } from 'react'
+import { useNavigate, useSearchParams } from 'react-router-dom'
+import { Button } from 'components/ui/Button'
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from 'components/ui/Card'
+import { apiService } from 'services/api'
+
+// Landing page for the "this wasn't me" link in new sign-in notifications.
+// Revocation only happens on an explicit click so email link scanners can't trigger it.
+export function RevokeDevicePage() {
+  const [searchParams] = useSearchParams()
+  const token = searchParams.get('token') ?? ''
+  const [loading, setLoading] = useState(false)
+  const [result, setResult] = useState<string | null>(null)
+  const [error, setError] = useState<string | null>(null)
+
+  const navigate = useNavigate()
+
+  const handleRevoke = async () => {
+    setLoading(true)
+    setError(null)
+
+    try {
+      const response = await apiService.revokeDeviceFromLink(token)
+      setResult(response.message)
+    } catch {
+      setError('This link is invalid or has expired. Sign in and review your devices instead.')
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  return (
+    <div className="flex min-h-screen items-center justify-center">
+      <Card className="w-full max-w-md">
+        <CardHeader>
+          <CardTitle>Secure your account</CardTitle>
+          <CardDescription>
+            Sign out the device from the sign-in you didn't recognise
+          </CardDescription>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          {result ? (
+            <div className="text-sm">
+              {result}. We recommend changing your password as well.
+            </div>
+          ) : (
+            <Button
+              className="w-full"
+              variant="destructive"
+              disabled={loading || !token}
+              onClick={handleRevoke}
+            >
+              {loading ? 'Signing out device...' : "This wasn't me - sign it out"}
+            </Button>
+          )}
+
+          {error && (
+            <div className="text-sm text-destructive">
+              {error}
+            </div>
+          )}
+
+          <div className="text-center text-sm text-muted-foreground">
+            <button
+              type="button"
+              className="text-primary hover:underline"
+              onClick={() => navigate('/')}
+            >
+              Back to Praxis
+            </button>
+          </div>
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
@@ -12,7 +12,9 @@ export function SignUpForm() {
     name: '',
     email: '',
     password: '',
-    confirmPassword: ''
+    confirmPassword: '',
+    dateOfBirth: '',
+    acceptTerms: false
   })
   
   const signUp = useSignUp()
@@ -20,10 +22,10 @@ export function SignUpForm() {
   const { toast } = useToast()
 
   const handleInputChange = (e: React.ChangeEvent<HTMLInputElement>) => {
-    const { name, value } = e.target
+    const { name, value, type, checked } = e.target
     setFormData(prev => ({
       ...prev,
-      [name]: value
+      [name]: type === 'checkbox' ? checked : value
     }))
   }
 
@@ -39,7 +41,7 @@ export function SignUpForm() {
     }
 
     try {
-      await signUp(formData.name, formData.email, formData.password)
+      await signUp(formData.name, formData.email, formData.password, formData.dateOfBirth, formData.acceptTerms)
       
       // Show success toast
       toast({
@@ -109,6 +111,8 @@ export function SignUpForm() {
                 name="password"
                 type="password"
                 required
+                minLength={8}
+                maxLength={72}
                 value={formData.password}
                 onChange={handleInputChange}
                 className="w-full px-3 py-2 border border-input rounded-md focus:outline-none focus:ring-2 focus:ring-ring"
@@ -132,6 +136,36 @@ export function SignUpForm() {
               />
             </div>
 
+            <div>
+              <label htmlFor="dateOfBirth" className="block text-sm font-medium mb-1">
+                Date of Birth
+              </label>
+              <input
+                id="dateOfBirth"
+                name="dateOfBirth"
+                type="date"
+                required
+                value={formData.dateOfBirth}
+                onChange={handleInputChange}
+                className="w-full px-3 py-2 border border-input rounded-md focus:outline-none focus:ring-2 focus:ring-ring"
+              />
+            </div>
+
+            <div className="flex items-start gap-2">
+              <input
+                id="acceptTerms"
+                name="acceptTerms"
+                type="checkbox"
+                required
+                checked={formData.acceptTerms}
+                onChange={handleInputChange}
+                className="mt-1"
+              />
+              <label htmlFor="acceptTerms" className="text-sm text-muted-foreground">
+                I agree to the Terms of Service and Privacy Policy
+              </label>
+            </div>
+
             {error && (
               <div className="text-sm text-destructive">
                 {error}
@@ -3,6 +3,7 @@ import type { AxiosInstance, AxiosResponse, AxiosError } from 'axios'
 
 // API Configuration
 const API_BASE_URL = import.meta.env.VITE_API_URL || 'http://localhost:8080/api/v1'
+const API_V2_BASE_URL = API_BASE_URL.replace(/\/v1\/?$/, '/v2')
 
 // Types
 export interface User {
@@ -17,16 +18,44 @@ export interface Agent {
   id: string
   user_id?: string
   name: string
+  gender?: 'male' | 'female' | 'other'
+  voice_id?: string // ElevenLabs voice; picked from name and gender when left empty
   description: string
   personality: string
   industry?: string
   level?: string
   is_public: boolean
   is_active: boolean
+  is_archived?: boolean
+  proctored?: boolean // Screening agent: every session with it is proctored
+  report_header?: string // Template heading exported reports; the first line is the title
+  organization_id?: string // Organization whose members may practice against it
+  // Pacing for short-format interviews; 0 turns a control off
+  pace_questions?: number // Target questions per 5 minutes
+  max_answer_words?: number
+  max_dwell_seconds?: number // Longest to spend on one question
+  interview_type?: InterviewType
+  adaptive_difficulty?: boolean // Harder or easier questions as the candidate's answers get stronger or weaker
   created_at: string
   updated_at: string
 }
 
+// One metric of an agent's custom scoring rubric
+export interface RubricItem {
+  id?: string
+  metric: string
+  description?: string
+  weight: number // Relative share of the overall score
+}
+
+export type ExportFormat = 'pdf' | 'md' | 'json'
+
+// A text interview's candidate types every answer and reads every reply
+export type SessionMode = 'voice' | 'text'
+
+// Selects the interviewer's prompt, the scoring rubric and the metrics; unset for a general interview
+export type InterviewType = 'behavioral' | 'technical' | 'system_design' | 'case_study'
+
 export interface Session {
   id: string
   user_id: string
@@ -35,11 +64,73 @@ export interface Session {
   started_at: string
   ended_at?: string
   duration: number
+  proctored?: boolean
+  mode?: SessionMode
+  interview_type?: InterviewType
+  coaching?: boolean
   agent?: Agent
   created_at: string
   updated_at: string
 }
 
+// A past session in which a searched topic came up, with its best matching turns. Snippets
+// are HTML-escaped, with the matched words in <mark> tags.
+export interface SessionSearchResult {
+  session_id: string
+  agent_name: string
+  started_at: string
+  matches: { transcript_id: string; speaker: 'user' | 'agent'; timestamp: string; snippet: string }[]
+}
+
+// The archive of all the user's data, built in the background; download_url is set once done
+export interface AccountExport {
+  id: string
+  status: 'pending' | 'running' | 'done' | 'failed' | 'expired'
+  percent: number
+  download_url?: string
+  url_expires_at?: string
+  expires_at?: string
+}
+
+// A private tip on one of the candidate's answers in a session practiced with hints
+export interface CoachingNote {
+  id: string
+  session_id: string
+  transcript_id?: string
+  question: string
+  note: string
+  created_at: string
+}
+
+// A live session is 'ending' until the sign-off has been delivered
+export interface EndSessionResponse {
+  status: 'ending' | 'completed'
+  session_id: string
+  ended_at?: string
+  duration?: number
+  summary_status?: 'generating' | 'none'
+}
+
+// Topics are a JSON array of the weaknesses the drill asks about
+export interface DrillPlan {
+  id: string
+  session_id: string
+  source_session_id: string
+  topics: string
+  source_score: number
+  created_at: string
+}
+
+export interface DrillProgress {
+  session_id: string
+  status: Session['status']
+  started_at: string
+  ended_at?: string
+  topics: string
+  source_score: number
+  overall_score?: number
+}
+
 export interface Transcript {
   id: string
   session_id: string
@@ -63,6 +154,127 @@ export interface Summary {
   updated_at: string
 }
 
+// Stage a summary job has reached; a failed job keeps the stage it failed in. A job in review
+// waits for the candidate to correct low-confidence spoken answers.
+export type SummaryStage = 'review' | 'queued' | 'transcribing' | 'prompting' | 'parsing' | 'saving' | 'done'
+
+// A turn of a transcript held for review; low-confidence spoken answers are highlighted
+export interface ReviewTurn {
+  index: number
+  speaker: 'user' | 'agent'
+  content: string
+  kind: string
+  confidence?: number // 0-1, when the transcription provider reports one
+  original?: string // What was transcribed, once corrected
+  correctable: boolean
+  low_confidence: boolean
+  timestamp: string
+}
+
+// A turn of an interview on its timeline, with the audio it was spoken with
+export interface ReplayTurn {
+  transcript_id: string
+  speaker: 'user' | 'agent'
+  content: string
+  kind: string
+  language?: string
+  phase: string
+  offset_ms: number // Since the session started
+  audio?: {
+    recording_id: string
+    content_type: string
+    size_bytes: number
+    tier: string
+    url: string // Answers 202 while an archived recording is restored
+  }
+}
+
+export type OperationalStatus = 'operational' | 'degraded' | 'outage'
+
+// The public status page
+export interface ServiceStatus {
+  status: OperationalStatus
+  components: { name: string; status: OperationalStatus }[]
+  queues: { name: string; status: OperationalStatus; backlog: number; oldest_wait_seconds: number }[]
+  degraded_features: string[]
+  updated_at: string
+}
+
+// A stored recording of a session's turn, with a link that plays it until it expires
+export interface SessionAudio {
+  recording_id: string
+  transcript_id?: string
+  speaker: 'user' | 'agent'
+  content_type: string
+  size_bytes: number
+  tier: string
+  url?: string // Absent while archived
+  created_at: string
+}
+
+export interface SessionReplay {
+  session_id: string
+  started_at: string
+  duration_ms: number
+  turns: ReplayTurn[]
+  count: number
+}
+
+export interface SummaryJob {
+  id: string
+  session_id: string
+  status: 'pending' | 'running' | 'failed' | 'done'
+  stage: SummaryStage
+  force: boolean
+  attempts: number
+  last_error?: string
+  run_after: string
+  started_at?: string
+  finished_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface SummaryResponse {
+  summary?: Summary
+  status?: string
+  stage?: SummaryStage
+  error?: string
+  attempts?: number
+  retry_at?: string
+  job_id?: string
+  proctoring?: ProctoringReport
+}
+
+// A similarity flag shown alongside a summary; it never affects the scores
+export interface SessionFlag {
+  id: string
+  session_id: string
+  kind: string // plagiarism
+  transcript_id: string
+  question_id?: string
+  source?: string // solution, submission
+  matched_transcript_id?: string
+  similarity: number // 0 to 1
+  created_at: string
+}
+
+// The v2 summary, with every field present whatever its state
+export interface SummaryV2 {
+  session_id: string
+  status: 'ready' | 'pending' | 'running' | 'failed'
+  summary: Summary | null
+  flags: SessionFlag[]
+  proctoring: ProctoringReport | null
+  job: {
+    id: string
+    stage: SummaryStage
+    attempts: number
+    error?: string
+    retry_at?: string
+  } | null
+}
+
 export interface Score {
   id: string
   session_id: string
@@ -74,14 +286,261 @@ export interface Score {
   updated_at: string
 }
 
+export interface CertificateDetails {
+  code: string
+  candidate_name: string
+  agent_name: string
+  industry?: string
+  level?: string
+  overall_score: number
+  completed_at: string
+  issued_at: string
+}
+
+export interface CertificateVerification {
+  valid: boolean
+  reason?: string
+  payload?: unknown
+  signature?: string
+  key_id?: string
+  public_key?: string
+  details?: CertificateDetails
+  branding?: ShareBranding
+}
+
+// How an agent owner brands exported reports and certificate pages
+export interface OrgBranding {
+  id: string
+  user_id: string
+  logo_content_type?: string
+  primary_color?: string
+  accent_color?: string
+  footer_text?: string
+  created_at: string
+  updated_at: string
+}
+
+export type BrandingUpdate = Pick<OrgBranding, 'primary_color' | 'accent_color' | 'footer_text'>
+
+// Branding shown on a public page; logo_url is relative to the API
+export interface ShareBranding {
+  primary_color?: string
+  accent_color?: string
+  footer_text?: string
+  logo_url?: string
+}
+
+// Progress across the user's completed sessions, aggregated by the API
+export type AnalyticsPeriod = 'day' | 'week' | 'month'
+
+export interface ScoreTrendPoint {
+  period: string // First day of the period, YYYY-MM-DD
+  sessions: number
+  average_score: number
+}
+
+export interface MetricStats {
+  metric: string
+  sessions: number
+  average_score: number
+  min_score: number
+  max_score: number
+}
+
+export interface IndustryStats {
+  industry: string // Empty for agents without one
+  sessions: number
+  average_score: number
+}
+
+export type ClientErrorKind = 'playback' | 'decode' | 'reconnect' | 'other'
+
+// A failure seen in the browser, reported so it can be debugged per session
+export interface ClientErrorReport {
+  session_id?: string
+  kind: ClientErrorKind
+  message: string
+  context?: Record<string, unknown>
+}
+
+export interface ProctorEvent {
+  id: string
+  session_id: string
+  kind: 'focus_lost' | 'focus_regained' | 'tab_hidden' | 'fullscreen_exited' | 'heartbeat_missed'
+  duration_ms?: number
+  occurred_at: string
+}
+
+// What happened during a proctored session; shown with the summary, never scored
+export interface ProctoringReport {
+  session_id: string
+  focus_losses: number
+  time_away_seconds: number
+  fullscreen_exits: number
+  heartbeat_gaps: number
+  longest_gap_seconds: number
+  clean: boolean
+  events: ProctorEvent[]
+}
+
+// A topic interviewers never raise; site-wide topics have no owner_id. Terms are a JSON array.
+export interface BannedTopic {
+  id: string
+  owner_id?: string
+  topic: string
+  terms: string
+  reason?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface BannedTopicRequest {
+  topic: string
+  terms: string[]
+  reason?: string
+}
+
+// An interviewer reply that raised a banned topic and was blocked
+export interface ComplianceViolation {
+  id: string
+  session_id: string
+  agent_id: string
+  owner_id?: string
+  banned_topic_id: string
+  topic: string
+  term: string
+  content: string
+  created_at: string
+}
+
+export type OrgRole = 'member' | 'admin' | 'owner'
+
+export interface Organization {
+  id: string
+  name: string
+  created_by: string
+  created_at: string
+  updated_at: string
+}
+
+export interface OrgMembership {
+  id: string
+  organization_id: string
+  user_id: string
+  role: OrgRole
+  organization?: Organization
+  created_at: string
+}
+
+export interface OrgMember {
+  user_id: string
+  email: string
+  full_name?: string
+  avatar_url?: string
+  role: OrgRole
+  joined_at: string
+}
+
+export interface OrgInvite {
+  id: string
+  organization_id: string
+  email: string
+  role: OrgRole
+  invited_by: string
+  expires_at: string
+  accepted_at?: string
+  organization?: Organization
+  created_at: string
+}
+
+// A cohort member's session with one of the organization's agents
+export interface OrgSession {
+  session_id: string
+  user_id: string
+  email: string
+  full_name?: string
+  invite_id?: string
+  agent_id: string
+  agent_name: string
+  status: string
+  started_at: string
+  ended_at?: string
+  overall_score?: number
+  passed?: boolean
+}
+
+// How candidates' normalized (0-100) scores in one metric, or overall, are spread
+export interface ScoreDistribution {
+  metric: string
+  candidates: number
+  mean: number
+  std_dev: number
+  min: number
+  p25: number
+  median: number
+  p75: number
+  max: number
+  histogram: number[] // 0-10, 10-20, ... 90-100
+}
+
+export interface CandidateRanking extends OrgSession {
+  rank: number
+  percentile: number
+  metrics: Record<string, { score: number; percentile: number }>
+}
+
+export interface CandidateComparison {
+  agent_id: string
+  agent_name: string
+  candidates: number
+  overall: ScoreDistribution
+  metrics: ScoreDistribution[]
+  rankings: CandidateRanking[]
+}
+
+// An external candidate's invite to one interview; status is pending, expired or the session's
+export interface InterviewInvite {
+  id: string
+  organization_id: string
+  agent_id: string
+  candidate_name?: string
+  candidate_email?: string
+  created_by: string
+  expires_at: string
+  started_at?: string
+  session_id?: string
+  agent?: Agent
+  status: string
+  overall_score?: number
+  passed?: boolean
+  created_at: string
+}
+
+// What a candidate sees before starting an interview they were invited to
+export interface PendingInterviewInvite {
+  candidate_name?: string
+  expires_at: string
+  organization?: string
+  agent?: {
+    name: string
+    interview_type: string
+    duration_minutes: number
+    proctored: boolean
+  }
+}
+
 export interface AuthResponse {
   user: User
   message: string
 }
 
+// Error bodies are {code, message, details, request_id}
 export interface ApiError {
   message: string
   status?: number
+  code?: string
+  details?: Record<string, unknown>
+  requestId?: string
 }
 
 // Create axios instance with default configuration
@@ -138,9 +597,13 @@ apiClient.interceptors.response.use(
     }
 
     // Handle other errors
+    const body = error.response?.data as any
     const apiError: ApiError = {
-      message: (error.response?.data as any)?.message || error.message || 'An error occurred',
+      message: body?.message || error.message || 'An error occurred',
       status: error.response?.status,
+      code: body?.code,
+      details: body?.details,
+      requestId: body?.request_id,
     }
 
     console.error('[API] Response error:', apiError)
@@ -182,8 +645,28 @@ class ApiService {
     return response.data
   }
 
-  async deleteAgent(id: string): Promise<void> {
-    await apiClient.delete(`/agents/${id}`)
+  // Deactivates the agent; hard deletes it instead, which is refused once it has sessions
+  async deleteAgent(id: string, hard = false): Promise<void> {
+    await apiClient.delete(`/agents/${id}`, { params: hard ? { hard: true } : undefined })
+  }
+
+  async getAgentRubric(id: string): Promise<{ agent_id: string; items: RubricItem[]; count: number }> {
+    const response = await apiClient.get<{ agent_id: string; items: RubricItem[]; count: number }>(`/agents/${id}/rubric`)
+    return response.data
+  }
+
+  async putAgentRubric(id: string, items: RubricItem[]): Promise<{ agent_id: string; items: RubricItem[]; count: number }> {
+    const response = await apiClient.put<{ agent_id: string; items: RubricItem[]; count: number }>(`/agents/${id}/rubric`, { items })
+    return response.data
+  }
+
+  async deleteAgentRubric(id: string): Promise<void> {
+    await apiClient.delete(`/agents/${id}/rubric`)
+  }
+
+  async activateAgent(id: string): Promise<{ agent: Agent }> {
+    const response = await apiClient.post<{ agent: Agent }>(`/agents/${id}/activate`)
+    return response.data
   }
 
   // Session methods
@@ -192,8 +675,8 @@ class ApiService {
     return response.data
   }
 
-  async createSession(agentId: string): Promise<{ session: Session }> {
-    const response = await apiClient.post<{ session: Session }>('/sessions', { agent_id: agentId })
+  async createSession(agentId: string, mode?: SessionMode, interviewType?: InterviewType, coaching?: boolean): Promise<{ session: Session }> {
+    const response = await apiClient.post<{ session: Session }>('/sessions', { agent_id: agentId, mode, interview_type: interviewType, coaching })
     return response.data
   }
 
@@ -202,8 +685,87 @@ class ApiService {
     return response.data
   }
 
-  async endSession(id: string): Promise<{ session: Session }> {
-    const response = await apiClient.put<{ session: Session }>(`/sessions/${id}/end`)
+  // Downloads a report of the session's transcript, summary and scores
+  async exportSession(id: string, format: ExportFormat = 'pdf'): Promise<Blob> {
+    const response = await apiClient.get<Blob>(`/sessions/${id}/export`, { params: { format }, responseType: 'blob' })
+    return response.data
+  }
+
+  // Starts a short session that asks only about the weaknesses in the session's summary
+  async createDrill(sessionId: string): Promise<{ session: Session; drill_plan: DrillPlan }> {
+    const response = await apiClient.post<{ session: Session; drill_plan: DrillPlan }>(`/sessions/${sessionId}/drill`)
+    return response.data
+  }
+
+  async getSessionDrills(sessionId: string): Promise<{ session_id: string; drills: DrillProgress[]; count: number }> {
+    const response = await apiClient.get<{ session_id: string; drills: DrillProgress[]; count: number }>(`/sessions/${sessionId}/drills`)
+    return response.data
+  }
+
+  async getCoachingNotes(sessionId: string): Promise<{ session_id: string; notes: CoachingNote[]; count: number }> {
+    const response = await apiClient.get<{ session_id: string; notes: CoachingNote[]; count: number }>(`/sessions/${sessionId}/coaching-notes`)
+    return response.data
+  }
+
+  // Full-text search of the user's transcripts, e.g. for sessions that discussed "load balancer"
+  async searchTranscripts(query: string, limit?: number): Promise<{ query: string; sessions: SessionSearchResult[]; count: number }> {
+    const response = await apiClient.get<{ query: string; sessions: SessionSearchResult[]; count: number }>('/search', { params: { q: query, limit } })
+    return response.data
+  }
+
+  // Keeps a guest's interview history under a full account
+  async convertGuest(email: string, password: string, full_name?: string): Promise<void> {
+    await apiClient.post('/users/me/convert', { email, password, full_name })
+  }
+
+  // Queues the user's data export, or returns the one already underway
+  async exportAccount(): Promise<{ export: AccountExport; queued: boolean }> {
+    const response = await apiClient.post<{ export: AccountExport; queued: boolean }>('/users/me/export')
+    return response.data
+  }
+
+  // The user's latest data export; poll until it has a download_url
+  async getAccountExport(): Promise<{ export: AccountExport; queued: boolean }> {
+    const response = await apiClient.get<{ export: AccountExport; queued: boolean }>('/users/me/export')
+    return response.data
+  }
+
+  // A session's turns with their offsets and audio, to replay it as it happened
+  async getSessionReplay(sessionId: string): Promise<SessionReplay> {
+    const response = await apiClient.get<SessionReplay>(`/sessions/${sessionId}/replay`)
+    return response.data
+  }
+
+  // A session's stored audio, with signed links an audio element can play directly
+  async getSessionAudio(sessionId: string): Promise<{ session_id: string; audio: SessionAudio[]; count: number; expires_at: string | null }> {
+    const response = await apiClient.get<{ session_id: string; audio: SessionAudio[]; count: number; expires_at: string | null }>(`/sessions/${sessionId}/audio`)
+    return response.data
+  }
+
+  // The transcript of a session whose summary is held until its spoken answers are reviewed
+  async getTranscriptReview(sessionId: string): Promise<{ session_id: string; turns: ReviewTurn[]; review_until: string }> {
+    const response = await apiClient.get<{ session_id: string; turns: ReviewTurn[]; review_until: string }>(`/sessions/${sessionId}/transcript-review`)
+    return response.data
+  }
+
+  async correctTranscriptTurn(sessionId: string, index: number, content: string): Promise<{ turn: ReviewTurn }> {
+    const response = await apiClient.put<{ turn: ReviewTurn }>(`/sessions/${sessionId}/transcript-review/turns/${index}`, { content })
+    return response.data
+  }
+
+  // Generates the summary now, with the corrections made
+  async finishTranscriptReview(sessionId: string): Promise<{ session_id: string; status: string; job: SummaryJob }> {
+    const response = await apiClient.post<{ session_id: string; status: string; job: SummaryJob }>(`/sessions/${sessionId}/transcript-review/finish`)
+    return response.data
+  }
+
+  async endSession(id: string): Promise<EndSessionResponse> {
+    const response = await apiClient.post<EndSessionResponse>(`/sessions/${id}/end`)
+    return response.data
+  }
+
+  async rateSession(id: string, rating: number): Promise<{ session_id: string; rating: number }> {
+    const response = await apiClient.put<{ session_id: string; rating: number }>(`/sessions/${id}/rating`, { rating })
     return response.data
   }
 
@@ -230,8 +792,34 @@ class ApiService {
   }
 
   // Summary methods
-  async getSummary(sessionId: string): Promise<{ summary: Summary; status?: string }> {
-    const response = await apiClient.get<{ summary: Summary; status?: string }>(`/summaries/session/${sessionId}`)
+  // Without a summary yet, the response describes its job instead: status, stage and the error
+  // of the last failed attempt
+  async getSummary(sessionId: string): Promise<SummaryResponse> {
+    const response = await apiClient.get<SummaryResponse>(`/summaries/session/${sessionId}`)
+    return response.data
+  }
+
+  async getSummaryV2(sessionId: string): Promise<SummaryV2> {
+    const response = await apiClient.get<SummaryV2>(`/summaries/session/${sessionId}`, { baseURL: API_V2_BASE_URL })
+    return response.data
+  }
+
+  async getSummaryStatus(sessionId: string): Promise<{ session_id: string; status: string; job: SummaryJob | null }> {
+    const response = await apiClient.get<{ session_id: string; status: string; job: SummaryJob | null }>(`/summaries/session/${sessionId}/status`)
+    return response.data
+  }
+
+  async generateSummary(sessionId: string, force = false): Promise<{ status: string; job_id: string; session_id: string; force: boolean }> {
+    const response = await apiClient.post<{ status: string; job_id: string; session_id: string; force: boolean }>(
+      `/summaries/session/${sessionId}/generate`,
+      undefined,
+      { params: force ? { force: true } : undefined }
+    )
+    return response.data
+  }
+
+  async getSummaryJob(jobId: string): Promise<{ job: SummaryJob }> {
+    const response = await apiClient.get<{ job: SummaryJob }>(`/summaries/jobs/${jobId}`)
     return response.data
   }
 
@@ -251,6 +839,221 @@ class ApiService {
     return response.data
   }
 
+  // Security methods
+  async revokeDeviceFromLink(token: string): Promise<{ message: string; revoked: boolean }> {
+    const response = await apiClient.post<{ message: string; revoked: boolean }>('/auth/revoke-device', { token })
+    return response.data
+  }
+
+  // Client errors that couldn't be sent over the WebSocket
+  async reportClientError(report: ClientErrorReport): Promise<void> {
+    await apiClient.post('/client-errors', report)
+  }
+
+  // Public operational status, safe to show without signing in
+  async getStatus(): Promise<ServiceStatus> {
+    const response = await apiClient.get<ServiceStatus>('/status')
+    return response.data
+  }
+
+  // Certificate methods
+  async verifyCertificate(code: string): Promise<CertificateVerification> {
+    const response = await apiClient.get<CertificateVerification>(`/verify/${encodeURIComponent(code)}`)
+    return response.data
+  }
+
+  // Analytics methods; days limits each to the sessions of the last so many days (default 90)
+  async getScoreTrend(period: AnalyticsPeriod = 'week', days?: number): Promise<{ since: string; period: AnalyticsPeriod; timezone: string; trend: ScoreTrendPoint[] }> {
+    const response = await apiClient.get<{ since: string; period: AnalyticsPeriod; timezone: string; trend: ScoreTrendPoint[] }>('/analytics/trend', { params: { period, days } })
+    return response.data
+  }
+
+  async getMetricStats(days?: number): Promise<{ since: string; metrics: MetricStats[] }> {
+    const response = await apiClient.get<{ since: string; metrics: MetricStats[] }>('/analytics/metrics', { params: { days } })
+    return response.data
+  }
+
+  async getIndustryStats(days?: number): Promise<{ since: string; industries: IndustryStats[] }> {
+    const response = await apiClient.get<{ since: string; industries: IndustryStats[] }>('/analytics/industries', { params: { days } })
+    return response.data
+  }
+
+  async getWeakestMetrics(limit?: number, days?: number): Promise<{ since: string; weakest: MetricStats[] }> {
+    const response = await apiClient.get<{ since: string; weakest: MetricStats[] }>('/analytics/weakest', { params: { limit, days } })
+    return response.data
+  }
+
+  // Compliance methods; topics apply to sessions started after they change
+  async getBannedTopics(): Promise<{ topics: BannedTopic[]; site_topics: BannedTopic[] }> {
+    const response = await apiClient.get<{ topics: BannedTopic[]; site_topics: BannedTopic[] }>('/compliance/topics')
+    return response.data
+  }
+
+  async createBannedTopic(request: BannedTopicRequest): Promise<{ topic: BannedTopic }> {
+    const response = await apiClient.post<{ topic: BannedTopic }>('/compliance/topics', request)
+    return response.data
+  }
+
+  async updateBannedTopic(id: string, request: BannedTopicRequest): Promise<{ topic: BannedTopic }> {
+    const response = await apiClient.put<{ topic: BannedTopic }>(`/compliance/topics/${id}`, request)
+    return response.data
+  }
+
+  async deleteBannedTopic(id: string): Promise<void> {
+    await apiClient.delete(`/compliance/topics/${id}`)
+  }
+
+  async getComplianceViolations(limit?: number): Promise<{ violations: ComplianceViolation[]; count: number }> {
+    const response = await apiClient.get<{ violations: ComplianceViolation[]; count: number }>('/compliance/violations', { params: { limit } })
+    return response.data
+  }
+
+  // Organization methods; admins invite members and share agents and question banks
+  async getOrganizations(): Promise<{ memberships: OrgMembership[] }> {
+    const response = await apiClient.get<{ memberships: OrgMembership[] }>('/orgs')
+    return response.data
+  }
+
+  async createOrganization(name: string): Promise<{ organization: Organization; role: OrgRole }> {
+    const response = await apiClient.post<{ organization: Organization; role: OrgRole }>('/orgs', { name })
+    return response.data
+  }
+
+  async getOrganization(id: string): Promise<{ organization: Organization; role: OrgRole }> {
+    const response = await apiClient.get<{ organization: Organization; role: OrgRole }>(`/orgs/${id}`)
+    return response.data
+  }
+
+  async getOrgMembers(id: string): Promise<{ members: OrgMember[] }> {
+    const response = await apiClient.get<{ members: OrgMember[] }>(`/orgs/${id}/members`)
+    return response.data
+  }
+
+  async updateOrgMember(id: string, userId: string, role: OrgRole): Promise<{ membership: OrgMembership }> {
+    const response = await apiClient.put<{ membership: OrgMembership }>(`/orgs/${id}/members/${userId}`, { role })
+    return response.data
+  }
+
+  // Removes a member; members can remove themselves to leave
+  async removeOrgMember(id: string, userId: string): Promise<void> {
+    await apiClient.delete(`/orgs/${id}/members/${userId}`)
+  }
+
+  async getOrgInvites(id: string): Promise<{ invites: OrgInvite[] }> {
+    const response = await apiClient.get<{ invites: OrgInvite[] }>(`/orgs/${id}/invites`)
+    return response.data
+  }
+
+  // The invite link is emailed and only returned here
+  async createOrgInvite(id: string, email: string, role?: OrgRole): Promise<{ invite: OrgInvite; invite_url: string }> {
+    const response = await apiClient.post<{ invite: OrgInvite; invite_url: string }>(`/orgs/${id}/invites`, { email, role })
+    return response.data
+  }
+
+  async revokeOrgInvite(id: string, inviteId: string): Promise<void> {
+    await apiClient.delete(`/orgs/${id}/invites/${inviteId}`)
+  }
+
+  async getInvite(token: string): Promise<{ invite: OrgInvite }> {
+    const response = await apiClient.get<{ invite: OrgInvite }>(`/orgs/invites/${token}`)
+    return response.data
+  }
+
+  async acceptInvite(token: string): Promise<{ membership: OrgMembership }> {
+    const response = await apiClient.post<{ membership: OrgMembership }>(`/orgs/invites/${token}/accept`)
+    return response.data
+  }
+
+  // Ranks the candidates who interviewed with one of the organization's agents
+  async getCandidateComparison(id: string, agentId: string): Promise<{ comparison: CandidateComparison }> {
+    const response = await apiClient.get<{ comparison: CandidateComparison }>(`/orgs/${id}/comparisons`, { params: { agent_id: agentId } })
+    return response.data
+  }
+
+  async getInterviewInvites(id: string): Promise<{ invites: InterviewInvite[] }> {
+    const response = await apiClient.get<{ invites: InterviewInvite[] }>(`/orgs/${id}/interview-invites`)
+    return response.data
+  }
+
+  // The link is emailed when candidate_email is given and only returned here
+  async createInterviewInvite(
+    id: string,
+    invite: { agent_id: string; candidate_name?: string; candidate_email?: string; expires_in_days?: number }
+  ): Promise<{ invite: InterviewInvite; invite_url: string }> {
+    const response = await apiClient.post<{ invite: InterviewInvite; invite_url: string }>(`/orgs/${id}/interview-invites`, invite)
+    return response.data
+  }
+
+  async revokeInterviewInvite(id: string, inviteId: string): Promise<void> {
+    await apiClient.delete(`/orgs/${id}/interview-invites/${inviteId}`)
+  }
+
+  async getInterviewInvite(token: string): Promise<{ invite: PendingInterviewInvite }> {
+    const response = await apiClient.get<{ invite: PendingInterviewInvite }>(`/interview-invites/${token}`)
+    return response.data
+  }
+
+  // Signs the candidate in as a guest and returns the session to connect to
+  async startInterviewInvite(
+    token: string,
+    start: { accept_terms: boolean; date_of_birth?: string; full_name?: string; mode?: SessionMode }
+  ): Promise<{ user: User; session: Session }> {
+    const response = await apiClient.post<{ user: User; session: Session }>(`/interview-invites/${token}/start`, start)
+    return response.data
+  }
+
+  async getOrgAgents(id: string): Promise<{ agents: Agent[] }> {
+    const response = await apiClient.get<{ agents: Agent[] }>(`/orgs/${id}/agents`)
+    return response.data
+  }
+
+  async shareAgentWithOrg(id: string, agentId: string): Promise<{ agent: Agent }> {
+    const response = await apiClient.put<{ agent: Agent }>(`/orgs/${id}/agents/${agentId}`)
+    return response.data
+  }
+
+  async unshareAgentFromOrg(id: string, agentId: string): Promise<void> {
+    await apiClient.delete(`/orgs/${id}/agents/${agentId}`)
+  }
+
+  async shareQuestionBankWithOrg(id: string, bankId: string): Promise<void> {
+    await apiClient.put(`/orgs/${id}/question-banks/${bankId}`)
+  }
+
+  async unshareQuestionBankFromOrg(id: string, bankId: string): Promise<void> {
+    await apiClient.delete(`/orgs/${id}/question-banks/${bankId}`)
+  }
+
+  async getOrgSessions(id: string, limit?: number): Promise<{ sessions: OrgSession[]; count: number }> {
+    const response = await apiClient.get<{ sessions: OrgSession[]; count: number }>(`/orgs/${id}/sessions`, { params: { limit } })
+    return response.data
+  }
+
+  // Branding methods
+  async getBranding(): Promise<{ branding: OrgBranding | null }> {
+    const response = await apiClient.get<{ branding: OrgBranding | null }>('/branding')
+    return response.data
+  }
+
+  async updateBranding(update: BrandingUpdate): Promise<{ branding: OrgBranding }> {
+    const response = await apiClient.put<{ branding: OrgBranding }>('/branding', update)
+    return response.data
+  }
+
+  // Uploads a PNG or JPEG logo of at most 512 KB
+  async uploadLogo(file: File): Promise<{ branding: OrgBranding }> {
+    return this.uploadFile<{ branding: OrgBranding }>('/branding/logo', file)
+  }
+
+  async deleteLogo(): Promise<void> {
+    await apiClient.delete('/branding/logo')
+  }
+
+  // Absolute URL of an asset the API names by its path, such as a shared page's logo
+  assetUrl(path: string): string {
+    return `${API_BASE_URL}${path}`
+  }
+
   // WebSocket URL for authenticated connections
   getWebSocketUrl(): string {
     const wsProtocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
@@ -258,6 +1061,11 @@ class ApiService {
     return `${wsProtocol}//${wsHost}/api/v1/ws`
   }
 
+  // WebSocket URL for an organization admin observing a live session
+  getObserveWebSocketUrl(sessionId: string): string {
+    return `${this.getWebSocketUrl()}/observe?session_id=${encodeURIComponent(sessionId)}`
+  }
+
   // Generic API methods
   async get<T>(url: string, config?: any): Promise<T> {
     const response = await apiClient.get<T>(url, config)
@@ -1,5 +1,12 @@
 import { websocketService } from 'services/websocket'
+import type { SpeechTiming } from 'services/websocket'
 import { useConversationStore } from 'store/useStore'
+import { recordingMimeType } from 'services/media'
+
+// Below this level (0-1) the microphone is taken to be silent
+const SILENCE_LEVEL = 0.02
+// Silences shorter than this are the ordinary gaps between words
+const MIN_PAUSE_MS = 1000
 
 class AudioService {
   private mediaRecorder: MediaRecorder | null = null
@@ -8,6 +15,11 @@ class AudioService {
   private analyser: AnalyserNode | null = null
   private microphone: MediaStreamAudioSourceNode | null = null
   private animationFrame: number | null = null
+  // Timing of the answer being recorded
+  private recordingStartedAt = 0
+  private silenceStartedAt: number | null = null
+  private heardSpeech = false
+  private pausesMs: number[] = []
 
   async startRecording(): Promise<void> {
     try {
@@ -19,9 +31,8 @@ class AudioService {
         } 
       })
 
-      this.mediaRecorder = new MediaRecorder(stream, {
-        mimeType: 'audio/webm;codecs=opus'
-      })
+      const mimeType = recordingMimeType()
+      this.mediaRecorder = new MediaRecorder(stream, mimeType ? { mimeType } : undefined)
 
       this.audioChunks = []
 
@@ -32,15 +43,23 @@ class AudioService {
       }
 
       this.mediaRecorder.onstop = () => {
-        const audioBlob = new Blob(this.audioChunks, { type: 'audio/webm' })
+        const timing: SpeechTiming = {
+          duration_ms: Math.round(performance.now() - this.recordingStartedAt),
+          pauses_ms: this.pausesMs,
+        }
+        const audioBlob = new Blob(this.audioChunks, { type: this.mediaRecorder?.mimeType || 'audio/webm' })
         const sizeMB = (audioBlob.size / 1024 / 1024).toFixed(2)
         console.log('🎤 Audio recording stopped, sending audio blob:', audioBlob.size, 'bytes', `(${sizeMB} MB)`)
         
         // Send audio in chunks if it's too large
-        this.sendAudioInChunks(audioBlob)
+        this.sendAudioInChunks(audioBlob, timing)
         this.audioChunks = []
       }
 
+      this.recordingStartedAt = performance.now()
+      this.silenceStartedAt = null
+      this.heardSpeech = false
+      this.pausesMs = []
       this.mediaRecorder.start(100) // Collect data every 100ms
       useConversationStore.getState().setRecording(true)
 
@@ -99,6 +118,7 @@ class AudioService {
       const normalizedLevel = average / 255
       
       useConversationStore.getState().setAudioLevel(normalizedLevel)
+      this.trackSilence(normalizedLevel)
       
       this.animationFrame = requestAnimationFrame(updateLevel)
     }
@@ -106,6 +126,21 @@ class AudioService {
     updateLevel()
   }
 
+  // Records a pause when speech resumes after a long enough silence. Silence before the first
+  // word and after the last isn't a pause.
+  private trackSilence(level: number): void {
+    const now = performance.now()
+    if (level < SILENCE_LEVEL) {
+      this.silenceStartedAt ??= now
+      return
+    }
+    if (this.silenceStartedAt !== null && this.heardSpeech && now - this.silenceStartedAt >= MIN_PAUSE_MS) {
+      this.pausesMs.push(Math.round(now - this.silenceStartedAt))
+    }
+    this.silenceStartedAt = null
+    this.heardSpeech = true
+  }
+
   async playAudio(audioBlob: Blob): Promise<void> {
     try {
       const audioUrl = URL.createObjectURL(audioBlob)
@@ -129,13 +164,13 @@ class AudioService {
     return useConversationStore.getState().audioLevel
   }
 
-  private async sendAudioInChunks(audioBlob: Blob) {
+  private async sendAudioInChunks(audioBlob: Blob, timing: SpeechTiming) {
     const chunkSize = 2 * 1024 * 1024 // 2MB chunks
     const totalSize = audioBlob.size
     
     if (totalSize <= chunkSize) {
       // Small enough to send directly
-      websocketService.sendAudio(audioBlob)
+      websocketService.sendAudio(audioBlob, timing)
       return
     }
 
@@ -159,7 +194,7 @@ class AudioService {
       console.log(`📤 Sending chunk ${i + 1}/${chunks.length}: ${chunk.size} bytes${isLastChunk ? ' (final)' : ''}`)
       
       // Send chunk with metadata
-      websocketService.sendAudioChunk(chunk, i, chunks.length, isLastChunk)
+      websocketService.sendAudioChunk(chunk, i, chunks.length, isLastChunk, timing)
       
       // Small delay between chunks to prevent overwhelming the server
       if (!isLastChunk) {
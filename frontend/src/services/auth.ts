@@ -58,11 +58,13 @@ class AuthService {
   }
 
   // Signup method using backend API
-  async signUp(email: string, password: string, fullName: string): Promise<{ user: User }> {
+  async signUp(email: string, password: string, fullName: string, dateOfBirth: string, acceptTerms: boolean): Promise<{ user: User }> {
     const response = await apiService.post<{ user: User }>('/auth/signup', {
       email,
       password,
       full_name: fullName,
+      date_of_birth: dateOfBirth,
+      accept_terms: acceptTerms,
     })
     
     this.user = response.user
@@ -92,6 +94,14 @@ class AuthService {
     }
   }
 
+  // Permanently deletes the account and all its data, confirmed with the password
+  async deleteAccount(password: string): Promise<void> {
+    await apiService.delete('/users/me', { data: { password } })
+    this.user = null
+    this.isInitialized = true
+    this.clearStoredAuthData()
+  }
+
   getCurrentUser(): User | null {
     return this.user
   }
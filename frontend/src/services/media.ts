@@ -0,0 +1,22 @@
+// Recording formats in order of preference. Chrome and Firefox record WebM/Opus, Safari only
+// MP4; the server transcribes all of them.
+const RECORDING_MIME_TYPES = ['audio/webm;codecs=opus', 'audio/ogg;codecs=opus', 'audio/mp4']
+
+// The format answers are recorded in, or '' to let the browser choose
+export function recordingMimeType(): string {
+  if (typeof MediaRecorder === 'undefined' || typeof MediaRecorder.isTypeSupported !== 'function') {
+    return ''
+  }
+  return RECORDING_MIME_TYPES.find((type) => MediaRecorder.isTypeSupported(type)) ?? ''
+}
+
+interface NetworkInformation {
+  type?: string
+  effectiveType?: string
+}
+
+// The kind of network the browser is on, e.g. wifi or 4g, or '' if it doesn't say
+export function connectionType(): string {
+  const connection = (navigator as Navigator & { connection?: NetworkInformation }).connection
+  return connection?.type ?? connection?.effectiveType ?? ''
+}
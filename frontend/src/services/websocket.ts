@@ -1,26 +1,158 @@
 import { useConversationStore } from 'store/useStore'
+import type { TurnStage } from 'store/useStore'
+import { apiService } from 'services/api'
+import type { ClientErrorKind } from 'services/api'
+import { connectionType, recordingMimeType } from 'services/media'
 
-export interface WebSocketMessage {
-  type: 'text' | 'code' | 'audio' | 'end_session' | 'user_message'
-  content?: string
-  language?: string
-  session_id?: string
+// Envelope version spoken with the server (backend/websocket/protocol.go)
+export const PROTOCOL_VERSION = 1
+
+// Messages sent to the server; the envelope is added by the service
+export type WebSocketMessage =
+  | { type: 'text'; content: string; metrics?: TypingMetrics }
+  | { type: 'code'; content: string; language?: string }
+  | { type: 'end_session'; reason?: string }
+
+// How a typed answer was composed, measured while the candidate typed it
+export interface TypingMetrics {
+  response_latency_ms: number // From the interviewer's message appearing to the first keystroke
+  compose_ms: number // From the first keystroke to sending
+  keystrokes: number
+  edits: number // Deletions and overwritten selections
+  pastes: number
+  pasted_chars: number
+}
+
+// How a spoken answer was paced, measured from the microphone level while recording it
+export interface SpeechTiming {
+  duration_ms: number // From starting to stopping the recording
+  pauses_ms: number[] // Each silence of at least a second between words
+}
+
+export type WebSocketErrorCode =
+  | 'invalid_message'
+  | 'unsupported_version'
+  | 'unknown_type'
+  | 'invalid_payload'
+  | 'processing_failed'
+  | 'bandwidth_exceeded'
+  | 'text_mode'
+  | 'not_allowed'
+
+export interface WebSocketErrorPayload {
+  code: WebSocketErrorCode
+  message: string
+  supported_version?: number
 }
 
-export interface AudioMessage {
-  type: 'audio'
-  audio_data: string
-  session_id: string
+// Messages received from the server
+export type ServerMessage = { v: number } & (
+  | { type: 'text' | 'user_message'; payload: { content: string; language?: string } }
+  | { type: 'audio'; payload: { content?: string; audio_data: string } }
+  | { type: 'audio_chunk'; payload: { content?: string; audio_data: string; chunk_index: number; is_last_chunk: boolean } }
+  | { type: 'note_saved'; payload: { note_id: string } }
+  | { type: 'summary_pending'; payload: { message: string; eta_seconds: number } }
+  | { type: 'time_remaining'; payload: { remaining_seconds: number } }
+  | { type: 'rate_limited'; payload: { message: string; retry_after_seconds: number } }
+  | { type: 'end_session'; payload?: { reason?: string } }
+  | { type: 'error'; payload: WebSocketErrorPayload }
+  | { type: 'proctoring'; payload: { heartbeat_interval_seconds: number; max_gap_seconds: number } }
+  | { type: 'progress'; payload: { stage: TurnStage; elapsed_ms: number } }
+  | { type: 'test_results'; payload: { passed: number; total: number; tests: { passed: boolean }[]; timed_out: boolean } }
+  | { type: 'session_resumed'; payload: { turns: ResumedTurn[]; truncated: boolean } }
+  | { type: 'pacing_nudge'; payload: { reason: 'long_answer' | 'long_dwell'; message: string } }
+  | { type: 'hint'; payload: { note: string; transcript_id?: string } }
+  | { type: 'live_turn'; payload: ResumedTurn }
+  | { type: 'takeover_accepted'; payload: TakeoverRequest }
+)
+
+// An observing admin's question: asked right away (inject) or instead of the next reply (override)
+export interface TakeoverRequest {
+  question: string
+  mode: 'inject' | 'override'
 }
 
-export interface CombinedAudioMessage {
-  type: 'audio'
-  content?: string
+// A turn of the conversation replayed when connecting to an interview that has begun, or sent
+// to observers as it happens
+export interface ResumedTurn {
+  speaker: 'user' | 'agent'
+  content: string
+  kind: 'text' | 'code'
   language?: string
-  session_id?: string
-  audio_data?: string
-  audio_data_base64?: string
-  AudioDataBase64?: string
+  timestamp: string
+}
+
+function base64ToArrayBuffer(data: string): ArrayBuffer {
+  const byteString = atob(data)
+  const ab = new ArrayBuffer(byteString.length)
+  const ia = new Uint8Array(ab)
+  for (let i = 0; i < byteString.length; i++) {
+    ia[i] = byteString.charCodeAt(i)
+  }
+  return ab
+}
+
+// StreamedAudio plays a reply whose audio arrives in chunks. With MediaSource, playback starts
+// from the first chunk; otherwise the chunks are joined and played once the last arrives.
+class StreamedAudio {
+  readonly src: string | null = null
+  private mediaSource: MediaSource | null = null
+  private sourceBuffer: SourceBuffer | null = null
+  private queue: ArrayBuffer[] = []
+  private chunks: ArrayBuffer[] = []
+  private ended = false
+  onError?: (message: string) => void
+
+  constructor() {
+    if (typeof MediaSource === 'undefined' || !MediaSource.isTypeSupported('audio/mpeg')) {
+      return
+    }
+    const mediaSource = new MediaSource()
+    this.mediaSource = mediaSource
+    this.src = URL.createObjectURL(mediaSource)
+    mediaSource.addEventListener('sourceopen', () => {
+      this.sourceBuffer = mediaSource.addSourceBuffer('audio/mpeg')
+      this.sourceBuffer.addEventListener('updateend', () => this.drain())
+      this.drain()
+    }, { once: true })
+  }
+
+  append(data: ArrayBuffer, last: boolean) {
+    if (!this.mediaSource) {
+      this.chunks.push(data)
+      return
+    }
+    if (data.byteLength > 0) {
+      this.queue.push(data)
+    }
+    this.ended = last
+    this.drain()
+  }
+
+  // The whole reply as one playable URL, for browsers without MediaSource
+  toBlobURL(): string {
+    return URL.createObjectURL(new Blob(this.chunks, { type: 'audio/mpeg' }))
+  }
+
+  // SourceBuffer takes one append at a time, so chunks wait for the previous to finish
+  private drain() {
+    const mediaSource = this.mediaSource
+    const sourceBuffer = this.sourceBuffer
+    if (!mediaSource || !sourceBuffer || sourceBuffer.updating || mediaSource.readyState !== 'open') {
+      return
+    }
+    const next = this.queue.shift()
+    try {
+      if (next) {
+        sourceBuffer.appendBuffer(next)
+      } else if (this.ended) {
+        mediaSource.endOfStream()
+      }
+    } catch (e) {
+      this.queue = []
+      this.onError?.(e instanceof Error ? e.message : String(e))
+    }
+  }
 }
 
 class WebSocketService {
@@ -29,6 +161,10 @@ class WebSocketService {
   private maxReconnectAttempts = 5
   private reconnectDelay = 1000
   private isConnecting = false
+  private summaryPendingMessage: string | null = null
+  private audioStream: StreamedAudio | null = null
+  private heartbeatTimer: ReturnType<typeof setInterval> | null = null
+  private stopProctoringListeners: (() => void) | null = null
 
   constructor(url: string) {
     this.url = url
@@ -53,8 +189,14 @@ class WebSocketService {
           return
         }
 
-        // Build WebSocket URL with session ID parameter
-        const wsUrl = `${this.url}?session_id=${currentSession}`
+        // Build WebSocket URL with session ID parameter, and the recording format and network
+        // the server breaks failures down by
+        const params = new URLSearchParams({ session_id: currentSession })
+        const codec = recordingMimeType()
+        if (codec) params.set('codec', codec)
+        const connection = connectionType()
+        if (connection) params.set('connection', connection)
+        const wsUrl = `${this.url}?${params}`
         this.ws = new WebSocket(wsUrl)
 
         this.ws.onopen = () => {
@@ -78,6 +220,7 @@ class WebSocketService {
                 this.handleMessage(data)
               } catch (err) {
                 console.error('Error parsing WebSocket message chunk:', err, msg)
+                this.reportError('decode', err instanceof Error ? err.message : String(err), { length: msg.length })
               }
             }
           } catch (error) {
@@ -108,6 +251,10 @@ class WebSocketService {
             this.scheduleReconnect()
           } else if (this.reconnectAttempts >= this.maxReconnectAttempts) {
             console.error('Max reconnection attempts reached')
+            this.reportError('reconnect', 'Max reconnection attempts reached', {
+              attempts: this.reconnectAttempts,
+              close_code: event.code,
+            })
           }
         }
 
@@ -136,47 +283,236 @@ class WebSocketService {
     }, delay)
   }
 
-  private handleMessage(data: WebSocketMessage | AudioMessage) {
+  private handleMessage(data: ServerMessage) {
     const store = useConversationStore.getState()
 
-    if (data.type === 'end_session') {
-      store.setCurrentSession(null)
-      store.clearMessages()
-      store.setSessionEnded(true, 'Session ended by server')
-      this.disconnect()
+    if (data.v !== PROTOCOL_VERSION) {
+      console.error(`Unsupported WebSocket protocol version ${data.v}, expected ${PROTOCOL_VERSION}`)
       return
     }
 
-    if (data.type === 'audio') {
-      const audioMessage = data as CombinedAudioMessage
-      const audioData = audioMessage.audio_data || audioMessage.audio_data_base64 || audioMessage.AudioDataBase64
-      
-      if (audioData) {
-        store.setAudioGenerationFailed(false)
-        this.playAudio(audioData)
-      } else {
-        store.setAudioGenerationFailed(true)
+    switch (data.type) {
+      case 'summary_pending':
+        // Shown as the end-of-session reason once the server ends the session
+        this.summaryPendingMessage = data.payload.message || null
+        return
+
+      case 'time_remaining':
+        store.setInterviewTimeRemaining(data.payload.remaining_seconds)
+        return
+
+      case 'note_saved':
+        return
+
+      case 'rate_limited':
+        // The message was dropped, so no reply is coming
+        console.warn(data.payload.message, `retry after ${data.payload.retry_after_seconds}s`)
+        store.setProcessing(false)
+        return
+
+      case 'error':
+        this.handleError(data.payload)
+        return
+
+      case 'proctoring':
+        this.startProctoring(data.payload.heartbeat_interval_seconds)
+        return
+
+      case 'progress':
+        store.setProcessing(true)
+        store.setTurnProgress({ stage: data.payload.stage, elapsedMs: data.payload.elapsed_ms })
+        return
+
+      case 'session_resumed':
+        // The server's transcript replaces what this page showed before the connection dropped
+        store.setMessages(data.payload.turns.map((turn) => ({
+          content: turn.content,
+          role: turn.speaker === 'user' ? 'user' : 'assistant',
+          type: turn.kind,
+          language: turn.language,
+          timestamp: new Date(turn.timestamp),
+        })))
+        store.setProcessing(false)
+        return
+
+      case 'pacing_nudge':
+        // Shown like a reply, without waiting on one
+        store.addMessage({ content: data.payload.message, role: 'assistant', type: 'text' })
+        return
+
+      case 'test_results': {
+        const { passed, total, tests, timed_out: timedOut } = data.payload
+        store.setTestResults({ passed, total, tests, timedOut })
+        return
       }
-      
-      if ('content' in data && data.content) {
-        store.setTyping(true)
-        store.setTypingContent(data.content)
-        this.startTypingAnimation(data.content, !audioData)
+
+      case 'end_session':
+        store.setInterviewTimeRemaining(null)
+        store.setCurrentSession(null)
+        store.clearMessages()
+        store.setSessionEnded(true, this.summaryPendingMessage || data.payload?.reason || 'Session ended by server')
+        this.summaryPendingMessage = null
+        this.disconnect()
+        return
+
+      case 'audio': {
+        const { content, audio_data: audioData } = data.payload
+
+        if (audioData) {
+          store.setAudioGenerationFailed(false)
+          this.playAudio(audioData)
+        } else {
+          store.setAudioGenerationFailed(true)
+        }
+
+        if (content) {
+          store.setTyping(true)
+          store.setTypingContent(content)
+          this.startTypingAnimation(content, !audioData)
+        }
+        store.setProcessing(false)
+        return
+      }
+
+      case 'audio_chunk': {
+        const { content, audio_data: audioData, chunk_index: chunkIndex, is_last_chunk: isLastChunk } = data.payload
+
+        if (chunkIndex === 0) {
+          this.audioStream = new StreamedAudio()
+          this.audioStream.onError = (message) => this.reportError('playback', message, { streamed: true })
+          store.setAudioGenerationFailed(false)
+          if (this.audioStream.src) {
+            this._audioCallback?.(this.audioStream.src)
+          }
+          if (content) {
+            store.setTyping(true)
+            store.setTypingContent(content)
+            this.startTypingAnimation(content)
+          }
+          store.setProcessing(false)
+        }
+
+        // Chunks of a reply whose start was missed can't be played
+        const stream = this.audioStream
+        if (!stream) {
+          return
+        }
+        stream.append(audioData ? base64ToArrayBuffer(audioData) : new ArrayBuffer(0), isLastChunk)
+        if (isLastChunk) {
+          if (!stream.src) {
+            this._audioCallback?.(stream.toBlobURL())
+          }
+          this.audioStream = null
+        }
+        return
       }
-      store.setProcessing(false)
-    } else if ('content' in data) {
-      const role = data.type === 'user_message' ? 'user' : 'assistant'
+
+      case 'text':
+      case 'user_message':
+        store.addMessage({
+          content: data.payload.content || '',
+          role: data.type === 'user_message' ? 'user' : 'assistant',
+          type: 'text',
+          language: data.payload.language,
+        })
+        store.setProcessing(false)
+        return
+    }
+  }
+
+  // Protocol errors mean this client and the server disagree; processing errors are shown
+  // to the candidate like a reply
+  private handleError(error: WebSocketErrorPayload) {
+    const store = useConversationStore.getState()
+    store.setProcessing(false)
+
+    if (error.code === 'processing_failed' || error.code === 'bandwidth_exceeded') {
+      store.addMessage({ content: error.message, role: 'assistant', type: 'text' })
+      return
+    }
+
+    console.error(`WebSocket message rejected (${error.code}): ${error.message}`)
+    if (error.code === 'unsupported_version') {
       store.addMessage({
-        content: data.content || '',
-        role: role,
-        type: data.type as 'text' | 'code' | 'audio',
-        language: data.language,
+        content: 'This page is out of date. Please reload it to continue the interview.',
+        role: 'assistant',
+        type: 'text',
       })
-      store.setProcessing(false)
     }
   }
 
+  // send wraps a payload in the protocol envelope
+  private send(type: string, payload?: object) {
+    if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+      this.ws.send(JSON.stringify({ v: PROTOCOL_VERSION, type, payload }))
+    }
+  }
+
+  // startProctoring sends heartbeats and reports focus changes for a proctored session. It is
+  // called again on every reconnect, so it replaces what a previous connection started.
+  private startProctoring(intervalSeconds: number) {
+    this.stopProctoring()
 
+    this.send('heartbeat')
+    this.heartbeatTimer = setInterval(() => this.send('heartbeat'), Math.max(intervalSeconds, 1) * 1000)
+
+    let lostAt: number | null = null
+    const onBlur = () => {
+      if (lostAt === null) {
+        lostAt = Date.now()
+        this.send('proctor_event', { kind: 'focus_lost' })
+      }
+    }
+    const onFocus = () => {
+      if (lostAt !== null) {
+        this.send('proctor_event', { kind: 'focus_regained', duration_ms: Date.now() - lostAt })
+        lostAt = null
+      }
+    }
+    const onVisibilityChange = () => {
+      if (document.visibilityState === 'hidden') {
+        this.send('proctor_event', { kind: 'tab_hidden' })
+      }
+    }
+    const onFullscreenChange = () => {
+      if (!document.fullscreenElement) {
+        this.send('proctor_event', { kind: 'fullscreen_exited' })
+      }
+    }
+
+    window.addEventListener('blur', onBlur)
+    window.addEventListener('focus', onFocus)
+    document.addEventListener('visibilitychange', onVisibilityChange)
+    document.addEventListener('fullscreenchange', onFullscreenChange)
+    this.stopProctoringListeners = () => {
+      window.removeEventListener('blur', onBlur)
+      window.removeEventListener('focus', onFocus)
+      document.removeEventListener('visibilitychange', onVisibilityChange)
+      document.removeEventListener('fullscreenchange', onFullscreenChange)
+    }
+  }
+
+  private stopProctoring() {
+    if (this.heartbeatTimer) {
+      clearInterval(this.heartbeatTimer)
+      this.heartbeatTimer = null
+    }
+    this.stopProctoringListeners?.()
+    this.stopProctoringListeners = null
+  }
+
+  // reportError tells the server about a failure in the browser, over the WebSocket while it is
+  // open and over HTTP otherwise
+  reportError(kind: ClientErrorKind, message: string, context?: Record<string, unknown>) {
+    if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+      this.send('client_error', { kind, message, context })
+      return
+    }
+    const sessionId = useConversationStore.getState().currentSession ?? undefined
+    apiService.reportClientError({ session_id: sessionId, kind, message, context }).catch(() => {
+      // Nothing more to do if the report itself fails
+    })
+  }
 
   setAudioCallback(callback: (audioSrc: string) => void) {
     this._audioCallback = callback
@@ -228,13 +564,7 @@ class WebSocketService {
       if (audioData.startsWith('data:audio')) {
         audioSrc = audioData
       } else {
-        const byteString = atob(audioData)
-        const ab = new ArrayBuffer(byteString.length)
-        const ia = new Uint8Array(ab)
-        for (let i = 0; i < byteString.length; i++) {
-          ia[i] = byteString.charCodeAt(i)
-        }
-        const blob = new Blob([ab], { type: 'audio/mpeg' })
+        const blob = new Blob([base64ToArrayBuffer(audioData)], { type: 'audio/mpeg' })
         audioSrc = URL.createObjectURL(blob)
       }
       
@@ -242,27 +572,33 @@ class WebSocketService {
         this._audioCallback(audioSrc)
       }
     } catch (e) {
-      // Handle audio processing error silently
+      this.reportError('playback', e instanceof Error ? e.message : String(e))
     }
   }
 
 
   sendMessage(message: WebSocketMessage) {
     if (this.ws && this.ws.readyState === WebSocket.OPEN) {
-      this.ws.send(JSON.stringify(message))
-      
+      const { type, ...payload } = message
+      this.send(type, payload)
+
       if (message.type !== 'end_session') {
         useConversationStore.getState().addMessage({
-          content: message.content || '',
+          content: message.content,
           role: 'user',
-          type: message.type as 'text' | 'code' | 'audio',
-          language: message.language,
+          type: message.type,
+          language: message.type === 'code' ? message.language : undefined,
         })
       }
     }
   }
 
-  sendAudio(audioBlob: Blob) {
+  // Private notes are stored with the session but never shown to the interviewer
+  sendNote(content: string) {
+    this.send('note', { content })
+  }
+
+  sendAudio(audioBlob: Blob, timing?: SpeechTiming) {
     if (this.ws && this.ws.readyState === WebSocket.OPEN) {
       useConversationStore.getState().setProcessing(true)
       
@@ -272,17 +608,14 @@ class WebSocketService {
         const uint8Array = new Uint8Array(arrayBuffer)
         const audioData = btoa(String.fromCharCode(...uint8Array))
         
-        this.ws?.send(JSON.stringify({
-          type: 'audio',
-          audio_data: audioData,
-          session_id: useConversationStore.getState().currentSession
-        }))
+        this.send('audio', { audio_data: audioData, timing })
       }
       reader.readAsArrayBuffer(audioBlob)
     }
   }
 
-  sendAudioChunk(audioBlob: Blob, chunkIndex: number, totalChunks: number, isLastChunk: boolean) {
+  // The answer's timing goes with the last chunk
+  sendAudioChunk(audioBlob: Blob, chunkIndex: number, totalChunks: number, isLastChunk: boolean, timing?: SpeechTiming) {
     if (this.ws && this.ws.readyState === WebSocket.OPEN) {
       if (chunkIndex === 0) {
         useConversationStore.getState().setProcessing(true)
@@ -293,21 +626,20 @@ class WebSocketService {
         const uint8Array = new Uint8Array(arrayBuffer)
         const audioData = btoa(String.fromCharCode(...uint8Array))
         
-        
-        this.ws?.send(JSON.stringify({
-          type: 'audio_chunk',
+        this.send('audio_chunk', {
           audio_data: audioData,
           chunk_index: chunkIndex,
           total_chunks: totalChunks,
           is_last_chunk: isLastChunk,
-          session_id: useConversationStore.getState().currentSession
-        }))
+          timing: isLastChunk ? timing : undefined,
+        })
       }
       reader.readAsArrayBuffer(audioBlob)
     }
   }
 
   disconnect() {
+    this.stopProctoring()
     if (this.ws) {
       this.ws.close()
       this.ws = null
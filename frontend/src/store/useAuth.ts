@@ -13,7 +13,7 @@ interface AuthActions {
   setLoading: (loading: boolean) => void
   setIsAuthChecked: (isAuthChecked: boolean) => void
   login: (email: string, password: string) => Promise<{ data: any; error: null } | { data: null; error: any }>
-  signUp: (name: string, email: string, password: string) => Promise<{ data: any; error: null } | { data: null; error: any }>
+  signUp: (name: string, email: string, password: string, dateOfBirth: string, acceptTerms: boolean) => Promise<{ data: any; error: null } | { data: null; error: any }>
   signOut: () => Promise<{ error: null } | { error: any }>
   checkAuth: () => Promise<boolean>
   clearAuth: () => void
@@ -44,10 +44,10 @@ export const useAuth = create<AuthState & AuthActions>()(
         }
       },
 
-      signUp: async (name: string, email: string, password: string) => {
+      signUp: async (name: string, email: string, password: string, dateOfBirth: string, acceptTerms: boolean) => {
         try {
           set({ loading: true })
-          const response = await authService.signUp(email, password, name)
+          const response = await authService.signUp(email, password, name, dateOfBirth, acceptTerms)
           set({ user: response.user, loading: false, isAuthChecked: true })
           return { data: response, error: null }
         } catch (error) {
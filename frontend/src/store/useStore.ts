@@ -10,6 +10,23 @@ export interface Message {
   timestamp: Date
 }
 
+// Stage of the candidate's turn the server is working on
+export type TurnStage = 'transcribing' | 'thinking' | 'running_tests' | 'reviewing_code' | 'generating_audio'
+
+export interface TurnProgress {
+  stage: TurnStage
+  elapsedMs: number
+}
+
+// How the last code submission did against the question's hidden tests, which stay hidden:
+// each test's outcome is known, in order, but not its name
+export interface TestResults {
+  passed: number
+  total: number
+  tests: { passed: boolean }[]
+  timedOut: boolean
+}
+
 export interface ConversationState {
   messages: Message[]
   isRecording: boolean
@@ -28,6 +45,9 @@ export interface ConversationState {
   isTyping: boolean
   typingContent: string
   audioGenerationFailed: boolean
+  interviewTimeRemaining: number | null
+  turnProgress: TurnProgress | null
+  testResults: TestResults | null
 }
 
 export interface ConversationActions {
@@ -43,12 +63,17 @@ export interface ConversationActions {
   setThinkingTimeRemaining: (time: number) => void
   setSpeakingTimeRemaining: (time: number) => void
   clearMessages: () => void
+  // Replaces the conversation, e.g. with the turns replayed when an interview resumes
+  setMessages: (messages: Omit<Message, 'id'>[]) => void
   updateMessage: (id: string, updates: Partial<Message>) => void
   setSessionEnded: (ended: boolean, reason?: string) => void
   setAudioPlaying: (playing: boolean) => void
   setTyping: (typing: boolean) => void
   setTypingContent: (content: string) => void
   setAudioGenerationFailed: (failed: boolean) => void
+  setInterviewTimeRemaining: (seconds: number | null) => void
+  setTurnProgress: (progress: TurnProgress | null) => void
+  setTestResults: (results: TestResults | null) => void
 }
 
 export const useConversationStore = create<ConversationState & ConversationActions>()(
@@ -72,6 +97,9 @@ export const useConversationStore = create<ConversationState & ConversationActio
       isTyping: false,
       typingContent: '',
       audioGenerationFailed: false,
+      interviewTimeRemaining: null,
+      turnProgress: null,
+      testResults: null,
 
       // Actions
       addMessage: (message) => {
@@ -102,7 +130,8 @@ export const useConversationStore = create<ConversationState & ConversationActio
       },
 
       setProcessing: (processing) => {
-        set({ isProcessing: processing })
+        // A finished turn has no stage left to show
+        set(processing ? { isProcessing: true } : { isProcessing: false, turnProgress: null })
       },
 
       setUserSpeaking: (speaking) => {
@@ -129,6 +158,10 @@ export const useConversationStore = create<ConversationState & ConversationActio
         set({ messages: [] })
       },
 
+      setMessages: (messages) => {
+        set({ messages: messages.map((message) => ({ ...message, id: crypto.randomUUID() })) })
+      },
+
       updateMessage: (id, updates) => {
         set((state) => ({
           messages: state.messages.map((msg) =>
@@ -156,6 +189,18 @@ export const useConversationStore = create<ConversationState & ConversationActio
       setAudioGenerationFailed: (failed) => {
         set({ audioGenerationFailed: failed })
       },
+
+      setInterviewTimeRemaining: (seconds) => {
+        set({ interviewTimeRemaining: seconds })
+      },
+
+      setTurnProgress: (progress) => {
+        set({ turnProgress: progress })
+      },
+
+      setTestResults: (results) => {
+        set({ testResults: results })
+      },
     }),
     {
       name: 'conversation-store',